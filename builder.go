@@ -0,0 +1,401 @@
+package datara
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/schema"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Schema adalah dokumen Schema JSON, format yang sama dengan snapshot
+// migrations/schema.json. Schema program bisa mencetaknya ke stdout sebagai
+// pengganti SQL.
+type Schema = state.SchemaState
+
+// SchemaBuilder menyusun Schema tanpa reflection maupun struct tag:
+//
+//	users := datara.NewTable("users").
+//		Column("id", datara.BigInt().AutoIncrement().PrimaryKey()).
+//		Column("email", datara.Varchar(255).NotNull().Unique())
+//	posts := datara.NewTable("posts").
+//		Column("id", datara.BigInt().AutoIncrement().PrimaryKey()).
+//		Column("user_id", datara.BigInt().NotNull()).
+//		ForeignKey(datara.ForeignKey("user_id").References("users", "id").OnDelete("CASCADE"))
+//	err := datara.NewSchema().Table(users).Table(posts).WriteJSON(os.Stdout)
+//
+// Semua builder hanya mengumpulkan data; kesalahan seperti kolom ganda atau
+// primary key ke kolom yang tidak ada dilaporkan sekaligus oleh Build.
+type SchemaBuilder struct {
+	tables []*TableBuilder
+}
+
+// NewSchema membuat SchemaBuilder kosong
+func NewSchema() *SchemaBuilder {
+	return &SchemaBuilder{}
+}
+
+// Table menambahkan tabel ke schema sesuai urutan pemanggilan
+func (b *SchemaBuilder) Table(table *TableBuilder) *SchemaBuilder {
+	b.tables = append(b.tables, table)
+	return b
+}
+
+// Build menyusun Schema dan memvalidasinya dengan aturan yang sama seperti
+// dokumen Schema JSON dari schema program. Error bertipe ContractErrors.
+func (b *SchemaBuilder) Build() (*Schema, error) {
+	result := state.NewSchemaState()
+	result.Version = schema.ContractVersion
+
+	var errs schema.ContractErrors
+	for i, tb := range b.tables {
+		table, tableErrs := tb.build()
+		errs = append(errs, tableErrs...)
+		if _, exists := result.Tables[table.Name]; exists {
+			errs = append(errs, schema.ContractError{Path: "tables." + table.Name, Message: "duplicate table"})
+			continue
+		}
+		table.Position = i + 1
+		result.Tables[table.Name] = table
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	if err := schema.ValidateContract(data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WriteJSON menjalankan Build lalu menulis dokumen Schema JSON ke w, mis.
+// os.Stdout dari schema program
+func (b *SchemaBuilder) WriteJSON(w io.Writer) error {
+	result, err := b.Build()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// TableBuilder menyusun satu tabel untuk SchemaBuilder
+type TableBuilder struct {
+	name        string
+	columns     []namedColumn
+	primaryKey  []string
+	indexes     []state.Index
+	foreignKeys []*ForeignKeyBuilder
+	options     map[string]string
+	// descriptions adalah deskripsi index per nama dari DescribeIndex
+	descriptions map[string]string
+}
+
+type namedColumn struct {
+	name   string
+	column *ColumnBuilder
+}
+
+// NewTable membuat TableBuilder untuk tabel name
+func NewTable(name string) *TableBuilder {
+	return &TableBuilder{name: name}
+}
+
+// Column menambahkan kolom sesuai urutan pemanggilan
+func (t *TableBuilder) Column(name string, column *ColumnBuilder) *TableBuilder {
+	t.columns = append(t.columns, namedColumn{name: name, column: column})
+	return t
+}
+
+// PrimaryKey menetapkan primary key komposit; untuk satu kolom cukup
+// ColumnBuilder.PrimaryKey
+func (t *TableBuilder) PrimaryKey(columns ...string) *TableBuilder {
+	t.primaryKey = append(t.primaryKey, columns...)
+	return t
+}
+
+// Index menambahkan index bernama name pada columns
+func (t *TableBuilder) Index(name string, columns ...string) *TableBuilder {
+	t.indexes = append(t.indexes, state.Index{Name: name, Columns: columns})
+	return t
+}
+
+// UniqueIndex menambahkan unique index bernama name pada columns
+func (t *TableBuilder) UniqueIndex(name string, columns ...string) *TableBuilder {
+	t.indexes = append(t.indexes, state.Index{Name: name, Columns: columns, Unique: true})
+	return t
+}
+
+// DescribeIndex menetapkan deskripsi index bernama name, mis. pola query yang
+// dilayaninya. Deskripsi disimpan di snapshot dan ditulis sebagai komentar SQL.
+func (t *TableBuilder) DescribeIndex(name, description string) *TableBuilder {
+	if t.descriptions == nil {
+		t.descriptions = make(map[string]string)
+	}
+	t.descriptions[name] = description
+	return t
+}
+
+// SpatialIndex menambahkan SPATIAL index (MySQL) atau index GiST (Postgres)
+// bernama name pada kolom spatial
+func (t *TableBuilder) SpatialIndex(name string, columns ...string) *TableBuilder {
+	t.indexes = append(t.indexes, state.Index{Name: name, Columns: columns, Spatial: true})
+	return t
+}
+
+// ForeignKey menambahkan foreign key dari ForeignKey(...).References(...)
+func (t *TableBuilder) ForeignKey(fk *ForeignKeyBuilder) *TableBuilder {
+	t.foreignKeys = append(t.foreignKeys, fk)
+	return t
+}
+
+// Option menetapkan opsi tabel MySQL yang menimpa default config, mis.
+// Option("engine", "MyISAM") atau Option("row_format", "COMPRESSED")
+func (t *TableBuilder) Option(key, value string) *TableBuilder {
+	if t.options == nil {
+		t.options = make(map[string]string)
+	}
+	t.options[key] = value
+	return t
+}
+
+// build mengubah builder menjadi state.Table beserta kesalahan yang hanya
+// bisa dideteksi sebelum tabel menjadi map, mis. kolom atau index ganda
+func (t *TableBuilder) build() (state.Table, schema.ContractErrors) {
+	path := "tables." + t.name
+	var errs schema.ContractErrors
+	if t.name == "" {
+		errs = append(errs, schema.ContractError{Path: "tables", Message: "table name is empty"})
+	}
+
+	table := state.Table{
+		Name:        t.name,
+		Columns:     make(map[string]state.Column, len(t.columns)),
+		Indexes:     make(map[string]state.Index),
+		Constraints: make([]state.Constraint, 0),
+		PrimaryKey:  append([]string(nil), t.primaryKey...),
+		Options:     state.NormalizeTableOptions(t.options),
+	}
+	indexes := append([]state.Index(nil), t.indexes...)
+	for i, nc := range t.columns {
+		columnPath := path + ".columns." + nc.name
+		if _, exists := table.Columns[nc.name]; exists {
+			errs = append(errs, schema.ContractError{Path: columnPath, Message: "duplicate column"})
+			continue
+		}
+		if nc.column.sqlType == "" {
+			errs = append(errs, schema.ContractError{Path: columnPath, Message: "column type is empty"})
+		}
+		col := nc.column.column
+		col.Name, col.Type, col.Position = nc.name, nc.column.sqlType, i+1
+		table.Columns[nc.name] = col
+
+		if nc.column.primaryKey {
+			table.PrimaryKey = append(table.PrimaryKey, nc.name)
+		}
+		if nc.column.unique {
+			indexes = append(indexes, state.Index{Name: fmt.Sprintf("idx_%s_%s", t.name, nc.name), Columns: []string{nc.name}, Unique: true})
+		}
+		if nc.column.index {
+			indexes = append(indexes, state.Index{Name: fmt.Sprintf("idx_%s_%s", t.name, nc.name), Columns: []string{nc.name}})
+		}
+	}
+	for _, idx := range indexes {
+		if _, exists := table.Indexes[idx.Name]; exists {
+			errs = append(errs, schema.ContractError{Path: path + ".indexes." + idx.Name, Message: "duplicate index"})
+			continue
+		}
+		table.Indexes[idx.Name] = idx
+	}
+	names := make([]string, 0, len(t.descriptions))
+	for name := range t.descriptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		idx, exists := table.Indexes[name]
+		if !exists {
+			errs = append(errs, schema.ContractError{Path: path + ".indexes." + name, Message: "description for unknown index"})
+			continue
+		}
+		idx.Description = t.descriptions[name]
+		table.Indexes[name] = idx
+	}
+	for _, fk := range t.foreignKeys {
+		table.ForeignKeys = append(table.ForeignKeys, fk.fk)
+	}
+	for key := range t.options {
+		if !state.IsTableOptionKey(key) {
+			errs = append(errs, schema.ContractError{Path: path + ".options." + key, Message: "unknown table option"})
+		}
+	}
+	return table, errs
+}
+
+// ColumnBuilder menyusun satu kolom; buat dengan BigInt, Varchar, Type, dst.
+// Kolom nullable kecuali NotNull atau PrimaryKey dipanggil.
+type ColumnBuilder struct {
+	sqlType    string
+	column     state.Column
+	primaryKey bool
+	unique     bool
+	index      bool
+}
+
+// Type membuat kolom dengan tipe SQL apa adanya, mis. Type("timestamp with time zone")
+func Type(sqlType string) *ColumnBuilder {
+	return &ColumnBuilder{sqlType: sqlType, column: state.Column{Nullable: true}}
+}
+
+// Tipe kolom yang umum dipakai
+func BigInt() *ColumnBuilder            { return Type("bigint") }
+func Int() *ColumnBuilder               { return Type("int") }
+func SmallInt() *ColumnBuilder          { return Type("smallint") }
+func Bool() *ColumnBuilder              { return Type("boolean") }
+func Text() *ColumnBuilder              { return Type("text") }
+func JSON() *ColumnBuilder              { return Type("json") }
+func Date() *ColumnBuilder              { return Type("date") }
+func Timestamp() *ColumnBuilder         { return Type("timestamp") }
+func Varchar(length int) *ColumnBuilder { return Type(fmt.Sprintf("varchar(%d)", length)) }
+func Char(length int) *ColumnBuilder    { return Type(fmt.Sprintf("char(%d)", length)) }
+func Decimal(precision, scale int) *ColumnBuilder {
+	return Type(fmt.Sprintf("decimal(%d,%d)", precision, scale))
+}
+
+// Tipe spatial; SRID ditetapkan dengan ColumnBuilder.SRID
+func Geometry() *ColumnBuilder   { return Type("geometry") }
+func Point() *ColumnBuilder      { return Type("point") }
+func LineString() *ColumnBuilder { return Type("linestring") }
+func Polygon() *ColumnBuilder    { return Type("polygon") }
+
+// SRID menetapkan spatial reference system kolom spatial, mis. 4326
+func (c *ColumnBuilder) SRID(srid int) *ColumnBuilder {
+	c.column.SRID = srid
+	return c
+}
+
+// NotNull menandai kolom NOT NULL
+func (c *ColumnBuilder) NotNull() *ColumnBuilder {
+	c.column.Nullable = false
+	return c
+}
+
+// Default menetapkan DEFAULT sebagai ekspresi SQL, mis. "0", "'active'" atau
+// "CURRENT_TIMESTAMP"
+func (c *ColumnBuilder) Default(expr string) *ColumnBuilder {
+	c.column.DefaultValue = state.ParseDefault(expr)
+	return c
+}
+
+// AutoIncrement menandai kolom auto increment (serial/identity di Postgres)
+func (c *ColumnBuilder) AutoIncrement() *ColumnBuilder {
+	c.column.AutoIncrement = true
+	return c
+}
+
+// PrimaryKey menjadikan kolom primary key; kolomnya otomatis NOT NULL
+func (c *ColumnBuilder) PrimaryKey() *ColumnBuilder {
+	c.primaryKey = true
+	c.column.Nullable = false
+	return c
+}
+
+// Unique menambahkan unique index idx_<tabel>_<kolom>
+func (c *ColumnBuilder) Unique() *ColumnBuilder {
+	c.unique = true
+	return c
+}
+
+// Index menambahkan index idx_<tabel>_<kolom>
+func (c *ColumnBuilder) Index() *ColumnBuilder {
+	c.index = true
+	return c
+}
+
+// Comment menetapkan komentar kolom
+func (c *ColumnBuilder) Comment(comment string) *ColumnBuilder {
+	if c.column.Tags == nil {
+		c.column.Tags = make(map[string]string)
+	}
+	c.column.Tags["comment"] = comment
+	return c
+}
+
+// Group memasukkan kolom ke kelompok name, ditulis sebagai komentar "-- name"
+// di CREATE TABLE jika naming.column_groups aktif
+func (c *ColumnBuilder) Group(name string) *ColumnBuilder {
+	if c.column.Tags == nil {
+		c.column.Tags = make(map[string]string)
+	}
+	c.column.Tags["group"] = name
+	return c
+}
+
+// Mask menetapkan cara menganonimkan kolom untuk datara mask-sql: "email",
+// "hash" atau "null"
+func (c *ColumnBuilder) Mask(rule string) *ColumnBuilder {
+	c.column.Mask = rule
+	return c
+}
+
+// Seed menetapkan ekspresi SQL untuk kolom di datara seed, mis.
+// "'user{n}@example.com'"; {n} diganti nomor baris
+func (c *ColumnBuilder) Seed(expr string) *ColumnBuilder {
+	if c.column.Tags == nil {
+		c.column.Tags = make(map[string]string)
+	}
+	c.column.Tags["seed"] = expr
+	return c
+}
+
+// Backfill menetapkan ekspresi SQL untuk mengisi baris lama saat kolom NOT
+// NULL baru ditambahkan dengan -expand-not-null, mis. "'active'"
+func (c *ColumnBuilder) Backfill(expr string) *ColumnBuilder {
+	if c.column.Tags == nil {
+		c.column.Tags = make(map[string]string)
+	}
+	c.column.Tags["backfill"] = expr
+	return c
+}
+
+// ForeignKeyBuilder menyusun foreign key untuk TableBuilder.ForeignKey
+type ForeignKeyBuilder struct {
+	fk state.ForeignKey
+}
+
+// ForeignKey memulai foreign key dari columns; lanjutkan dengan References
+func ForeignKey(columns ...string) *ForeignKeyBuilder {
+	return &ForeignKeyBuilder{fk: state.ForeignKey{Columns: columns}}
+}
+
+// References menetapkan tabel dan kolom yang direferensikan
+func (f *ForeignKeyBuilder) References(table string, columns ...string) *ForeignKeyBuilder {
+	f.fk.RefTable, f.fk.RefColumns = table, columns
+	return f
+}
+
+// Name menimpa nama default fk_<tabel>_<kolom>
+func (f *ForeignKeyBuilder) Name(name string) *ForeignKeyBuilder {
+	f.fk.Name = name
+	return f
+}
+
+// OnDelete menetapkan aksi ON DELETE, mis. "CASCADE" atau "SET NULL"
+func (f *ForeignKeyBuilder) OnDelete(action string) *ForeignKeyBuilder {
+	f.fk.OnDelete = strings.ToUpper(action)
+	return f
+}
+
+// OnUpdate menetapkan aksi ON UPDATE
+func (f *ForeignKeyBuilder) OnUpdate(action string) *ForeignKeyBuilder {
+	f.fk.OnUpdate = strings.ToUpper(action)
+	return f
+}
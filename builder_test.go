@@ -0,0 +1,183 @@
+package datara
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/schema"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// blogSchema adalah schema users dan posts dari contoh di SchemaBuilder
+func blogSchema() *SchemaBuilder {
+	users := NewTable("users").
+		Column("id", BigInt().AutoIncrement().PrimaryKey()).
+		Column("email", Varchar(255).NotNull().Unique())
+	posts := NewTable("posts").
+		Column("id", BigInt().AutoIncrement().PrimaryKey()).
+		Column("user_id", BigInt().NotNull().Index()).
+		Column("title", Varchar(200).Default("''")).
+		ForeignKey(ForeignKey("user_id").References("users", "id").OnDelete("cascade"))
+	return NewSchema().Table(users).Table(posts)
+}
+
+func TestBuilderBuild(t *testing.T) {
+	built, err := blogSchema().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if built.Version != schema.ContractVersion {
+		t.Errorf("Version = %q, want %q", built.Version, schema.ContractVersion)
+	}
+
+	posts := built.Tables["posts"]
+	if posts.Position != 2 || built.Tables["users"].Position != 1 {
+		t.Errorf("table positions = users %d, posts %d; want declaration order", built.Tables["users"].Position, posts.Position)
+	}
+	if !reflect.DeepEqual(posts.PrimaryKey, []string{"id"}) {
+		t.Errorf("PrimaryKey = %v, want [id]", posts.PrimaryKey)
+	}
+	if id := posts.Columns["id"]; id.Nullable || !id.AutoIncrement || id.Position != 1 {
+		t.Errorf("id = %+v, want NOT NULL auto increment at position 1", id)
+	}
+	if title := posts.Columns["title"]; !title.Nullable || !title.DefaultValue.Equal(&state.DefaultValue{Kind: state.DefaultString}) {
+		t.Errorf("title = %+v, want a nullable column with default ''", title)
+	}
+	if idx, ok := built.Tables["users"].Indexes["idx_users_email"]; !ok || !idx.Unique {
+		t.Errorf("users indexes = %v, want unique idx_users_email", built.Tables["users"].Indexes)
+	}
+	want := []state.ForeignKey{{Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE"}}
+	if !reflect.DeepEqual(posts.ForeignKeys, want) {
+		t.Errorf("ForeignKeys = %+v, want %+v", posts.ForeignKeys, want)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *SchemaBuilder
+		want   []string
+	}{
+		{
+			name: "duplicate column",
+			schema: NewSchema().Table(NewTable("users").
+				Column("id", BigInt().PrimaryKey()).
+				Column("id", Int())),
+			want: []string{"tables.users.columns.id: duplicate column"},
+		},
+		{
+			name: "duplicate table",
+			schema: NewSchema().
+				Table(NewTable("users").Column("id", BigInt())).
+				Table(NewTable("users").Column("id", BigInt())),
+			want: []string{"tables.users: duplicate table"},
+		},
+		{
+			name:   "empty names",
+			schema: NewSchema().Table(NewTable("").Column("id", Type(""))),
+			want:   []string{"tables: table name is empty", "tables..columns.id: column type is empty"},
+		},
+		{
+			name: "duplicate index",
+			schema: NewSchema().Table(NewTable("users").
+				Column("email", Varchar(255).Unique()).
+				Index("idx_users_email", "email")),
+			want: []string{"tables.users.indexes.idx_users_email: duplicate index"},
+		},
+		{
+			name: "every table reported",
+			schema: NewSchema().
+				Table(NewTable("users").Column("id", BigInt()).Option("engin", "InnoDB")).
+				Table(NewTable("posts").Column("id", BigInt()).DescribeIndex("idx_missing", "lookup")),
+			want: []string{
+				"tables.users.options.engin: unknown table option",
+				"tables.posts.indexes.idx_missing: description for unknown index",
+			},
+		},
+		{
+			name:   "missing primary key column",
+			schema: NewSchema().Table(NewTable("users").Column("id", BigInt()).PrimaryKey("uuid")),
+			want:   []string{`tables.users.primary_key[0]: unknown column "uuid"`},
+		},
+		{
+			name: "missing index column",
+			schema: NewSchema().Table(NewTable("users").
+				Column("id", BigInt()).
+				Index("idx_users_name", "name")),
+			want: []string{`tables.users.indexes.idx_users_name.columns[0]: unknown column "name"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			built, err := tt.schema.Build()
+			var errs schema.ContractErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Build() = %v, %v; want ContractErrors", built, err)
+			}
+			var got []string
+			for _, e := range errs {
+				got = append(got, e.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Build() errors = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilderForeignKeyErrors(t *testing.T) {
+	_, err := NewSchema().Table(NewTable("posts").
+		Column("id", BigInt().PrimaryKey()).
+		Column("user_id", BigInt()).
+		ForeignKey(ForeignKey("author_id").References("users", "id").OnDelete("explode"))).Build()
+	if err == nil {
+		t.Fatal("Build() succeeded, want foreign key errors")
+	}
+	for _, want := range []string{`"author_id"`, `"users"`, "EXPLODE"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Build() = %v, want it to mention %s", err, want)
+		}
+	}
+}
+
+// TestBuilderJSONRoundTrip memastikan output WriteJSON diterima schema
+// program contract dan menghasilkan migration yang sama tiap kali
+func TestBuilderJSONRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := blogSchema().WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, _, err := schema.DecodeContract(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := diff.NewGenerator(&diff.Config{Dialect: diff.DialectPostgres})
+	statements, err := g.GenerateStatements(state.NewSchemaState(), decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql := strings.Join(statements, "\n")
+	for _, want := range []string{
+		`CREATE TABLE "users"`,
+		`CREATE UNIQUE INDEX "idx_users_email" ON "users" ("email")`,
+		`FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE`,
+		`"title" varchar(200) DEFAULT ''`,
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("migration does not contain %s:\n%s", want, sql)
+		}
+	}
+
+	again, _, err := schema.DecodeContract(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statements, err := g.GenerateStatements(decoded, again); err != nil || len(statements) != 0 {
+		t.Errorf("decoding the same document twice produced %v, %v", statements, err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/schema"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// addMigration mendaftarkan migration dari tool lain atau tulisan tangan:
+// file divalidasi, disalin ke direktori migration, lalu datara.sum,
+// datara.snapshots dan snapshot schema diperbarui. Snapshot dimajukan dengan
+// menjalankan ulang bagian up file di atas snapshot terakhir; jika ada
+// statement yang tidak dipahami, snapshot dibiarkan kecuali refresh diset.
+func addMigration(ctx context.Context, path string, keepName, move, refresh bool) error {
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read migration: %w", err)
+	}
+
+	dir := config.Migration.Dir
+	name := filepath.Base(path)
+	if keepName {
+		if err := checkKeptName(dir, config.filePattern, name); err != nil {
+			return err
+		}
+	}
+
+	executor := newExecutor(config)
+	addition, err := executor.PrepareAddition(name, string(content))
+	if err != nil {
+		return err
+	}
+	if refresh {
+		if err := executor.RefreshAddition(ctx, addition); err != nil {
+			return err
+		}
+	}
+
+	// ApplyAddition menyimpan snapshot sebelum file migration ditulis; jika
+	// langkah berikutnya gagal, snapshot dan bookkeeping dikembalikan
+	backup, err := backupGeneration(config, executor)
+	if err != nil {
+		return err
+	}
+	var filename string
+	err = func() error {
+		migration, err := executor.ApplyAddition(addition)
+		if err != nil {
+			return err
+		}
+		if keepName {
+			filename = filepath.Join(dir, name)
+			if err := os.WriteFile(filename, []byte(migration), 0644); err != nil {
+				return fmt.Errorf("failed to write migration file: %w", err)
+			}
+			infof("Added migration file: %s\n", filename)
+		} else if filename, err = writeMigrationFile(config, migration, addedMigrationName(name)); err != nil {
+			return err
+		}
+		if embeddedBookkeeping(config) {
+			return nil
+		}
+		if err := schema.RecordSnapshots(dir, filename, addition.Before, addition.After); err != nil {
+			return err
+		}
+		return syncSum(config)
+	}()
+	if err != nil {
+		var files []string
+		if filename != "" {
+			files = append(files, filename)
+		}
+		if restoreErr := backup.restore(files...); restoreErr != nil {
+			return fmt.Errorf("%w; reverting the migration also failed: %v", err, restoreErr)
+		}
+		return err
+	}
+
+	if move {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+
+	for _, skipped := range addition.Skipped {
+		problem := "not interpreted"
+		if skipped.Problem != "" {
+			problem = skipped.Problem
+		}
+		fmt.Printf("%s: %s\n  %s\n", skipped.Location, problem, strings.Join(strings.Fields(skipped.Statement), " "))
+	}
+	if addition.Refreshed() {
+		infof("Schema snapshot refreshed from the schema program\n")
+		return nil
+	}
+	if !addition.Advanced() {
+		var warnings state.Warnings
+		warnings.Add("snapshot-not-advanced", "", "", "snapshot not advanced: %d statement(s) could not be interpreted, so the next generate may repeat "+
+			"its changes; add it with -refresh-snapshot to take the snapshot from the schema program", len(addition.Skipped))
+		return reportWarnings(warnings)
+	}
+	return nil
+}
+
+// checkKeptName memastikan file dengan -keep-name cocok dengan pola migration
+// dan berjalan setelah migration yang sudah ada, agar replay mengikuti urutan
+// yang sama dengan runner
+func checkKeptName(dir string, pattern *regexp.Regexp, name string) error {
+	if filepath.Ext(name) != ".sql" || migrationVersionPattern.FindString(name) == "" {
+		return fmt.Errorf("%s needs a numeric version prefix and a .sql extension to keep its name", name)
+	}
+	if !pattern.MatchString(name) {
+		return fmt.Errorf("%s does not match migration.file_pattern; drop -keep-name to give it a new timestamp", name)
+	}
+	if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+		return fmt.Errorf("%s already exists in %s", name, dir)
+	}
+	existing, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return err
+	}
+	for _, file := range existing {
+		if base := filepath.Base(file); base > name && migrationVersionPattern.MatchString(base) {
+			return fmt.Errorf("%s would run before the existing migration %s; drop -keep-name to give it a new timestamp", name, base)
+		}
+	}
+	return nil
+}
+
+// migrationVersionPattern menangkap versi di awal nama file migration dan
+// nameSeparatorPattern karakter yang tidak boleh ada di nama migration
+var (
+	migrationVersionPattern = regexp.MustCompile(`^[0-9]+`)
+	nameSeparatorPattern    = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// addedMigrationName menurunkan nama migration dari nama file sumber tanpa
+// versi dan ekstensinya, mis. "20240101_add_users.up.sql" menjadi "add_users"
+func addedMigrationName(file string) string {
+	name := strings.TrimSuffix(strings.TrimSuffix(file, ".sql"), ".up")
+	name = migrationVersionPattern.ReplaceAllString(name, "")
+	name = strings.ToLower(nameSeparatorPattern.ReplaceAllString(name, "_"))
+	return strings.Trim(name, "_")
+}
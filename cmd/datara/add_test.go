@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/schema"
+)
+
+func TestAddMigrationRestoresSnapshotOnFailure(t *testing.T) {
+	resetFlags()
+	defer resetFlags()
+	config := testProject(t)
+	dir := filepath.Dir(config)
+	source := filepath.Join(dir, "add_users.sql")
+	if err := os.WriteFile(source, []byte("-- migrate:up\nCREATE TABLE users (id INT NOT NULL);\n\n-- migrate:down\nDROP TABLE users;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// -timestamp yang tidak valid membuat penulisan file migration gagal
+	// setelah snapshot disimpan
+	err := Run([]string{"add", "-quiet", "-config", config, "-timestamp", "not-a-timestamp", source})
+	if err == nil {
+		t.Fatal("add with an invalid -timestamp succeeded")
+	}
+
+	configPath = config
+	cfg, err := readConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := newExecutor(cfg).Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := snapshot.Tables["users"]; ok {
+		t.Error("the schema snapshot kept the table of the migration that failed to be added")
+	}
+	migrations, err := filepath.Glob(filepath.Join(cfg.Migration.Dir, "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) > 0 {
+		t.Errorf("add left migration files behind: %v", migrations)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Migration.Dir, schema.SumFile)); !os.IsNotExist(err) {
+		t.Errorf("add left %s behind", schema.SumFile)
+	}
+
+	// Tanpa kegagalan, snapshot dimajukan
+	if err := Run([]string{"add", "-quiet", "-config", config, "-timestamp", "20300101000000", source}); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot, err = newExecutor(cfg).Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := snapshot.Tables["users"]; !ok {
+		t.Error("add did not advance the schema snapshot")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/microsoft/go-mssqldb"
+
+	"github.com/akmalulginan/datara/internal/applier"
+)
+
+// applyMigrations menjalankan migration yang belum tercatat di database dsn
+// (default $DATABASE_URL), atau membatalkan down migration terakhir jika
+// down > 0. datara.sum diverifikasi lebih dulu.
+func applyMigrations(ctx context.Context, dsn string, down int) error {
+	if dsn == "" {
+		dsn = os.Getenv("DATABASE_URL")
+	}
+	if dsn == "" {
+		return &usageError{fmt.Errorf("missing database: pass -dsn or set DATABASE_URL")}
+	}
+	if down < 0 {
+		return &usageError{fmt.Errorf("-down must be positive, got %d", down)}
+	}
+
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	dir := config.Migration.Dir
+	if err := verifyMigrations(config); err != nil {
+		return err
+	}
+
+	dialect := diffConfig(config).Dialect
+	driver, err := applier.Driver(dialect)
+	if err != nil {
+		return &configError{err}
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	a := applier.New(db, dialect, dir)
+	a.SetBatchSeparator(batchSeparator(config))
+	a.SetFilePattern(config.filePattern)
+	if down > 0 {
+		done, err := a.Down(ctx, down)
+		for _, name := range done {
+			infof("Rolled back %s\n", name)
+		}
+		if err == nil && len(done) == 0 {
+			infof("No applied migrations to roll back\n")
+		}
+		return err
+	}
+
+	done, err := a.Up(ctx)
+	for _, name := range done {
+		infof("Applied %s\n", name)
+	}
+	if err == nil && len(done) == 0 {
+		infof("Database is up to date\n")
+	}
+	return err
+}
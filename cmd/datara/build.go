@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// buildSchemaProgram meng-compile schema program "go run <file|package>" di
+// datara.hcl menjadi binary out, sehingga image runtime cukup berisi datara
+// dan binary tersebut. go build dijalankan dari direktori program agar
+// go.mod modul program yang dipakai; flag di antara "run" dan path program
+// (mis. -tags) ikut diteruskan.
+func buildSchemaProgram(ctx context.Context, out string) error {
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	program := config.Schema.Program
+	if len(program) < 3 || filepath.Base(program[0]) != "go" || program[1] != "run" {
+		return &configError{fmt.Errorf("schema.program %q is not a go run command; only go run programs can be built", strings.Join(program, " "))}
+	}
+
+	target := program[len(program)-1]
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(config.dir, target)
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return fmt.Errorf("failed to resolve schema program: %w", err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return fmt.Errorf("failed to read schema program: %w", err)
+	}
+	dir, pkg := target, "."
+	if !info.IsDir() {
+		dir, pkg = filepath.Dir(target), "./"+filepath.Base(target)
+	}
+
+	if out == "" {
+		return &usageError{errors.New("building schema program: -out is empty")}
+	}
+	if !allowOutsideRoot {
+		root, err := realPath(filepath.Dir(configPath))
+		if err != nil {
+			return err
+		}
+		if err := checkInsideRoot("-out", root, config.dir, out); err != nil {
+			return &configError{err}
+		}
+	}
+	binary := out
+	if !filepath.IsAbs(binary) {
+		binary = filepath.Join(config.dir, binary)
+	}
+	if binary, err = filepath.Abs(binary); err != nil {
+		return fmt.Errorf("failed to resolve -out: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	args := append([]string{"build", "-o", binary}, program[2:len(program)-1]...)
+	args = append(args, pkg)
+	cmd := exec.CommandContext(ctx, program[0], args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("go is not installed; run build-schema-program where the Go toolchain is available, e.g. a Docker build stage: %w", err)
+		}
+		return fmt.Errorf("go build failed: %w", err)
+	}
+
+	rel := out
+	if !filepath.IsAbs(rel) && !strings.HasPrefix(rel, ".") {
+		rel = "./" + filepath.ToSlash(rel)
+	}
+	infof("Built %s; set schema.program = [%q] in datara.hcl to run it without go\n", binary, rel)
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+func TestBuildSchemaProgram(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	path := testProject(t)
+	dir := filepath.Dir(path)
+	program := filepath.Join(dir, "register")
+	if err := os.MkdirAll(program, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"go.mod": "module register\n\ngo 1.21\n",
+		"main.go": "package main\n\nimport \"fmt\"\n\nfunc main() {\n" +
+			"\tfmt.Println(\"CREATE TABLE users (id BIGINT PRIMARY KEY, email VARCHAR(100) NOT NULL);\")\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(program, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// setProgram mengganti schema.program di datara.hcl
+	setProgram := func(from, to string) {
+		t.Helper()
+		config, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config = []byte(strings.Replace(string(config), "program = "+from, "program = "+to, 1))
+		if err := os.WriteFile(path, config, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setProgram(`["true"]`, `["go", "run", "./register"]`)
+
+	// 1. Program di-compile sekali ke -out, relatif terhadap datara.hcl
+	if _, err := runStdout(t, "build-schema-program", "-quiet", "-out", "bin/register", "-config", path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bin", "register")); err != nil {
+		t.Fatalf("binary not built: %v", err)
+	}
+
+	// 2. Binary dijalankan tanpa go run dan output-nya di-parse ke snapshot
+	setProgram(`["go", "run", "./register"]`, `["./bin/register"]`)
+	if _, err := runStdout(t, "generate", "-quiet", "-config", path); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := state.LoadFromFile(filepath.Join(dir, "migrations", "schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, ok := snapshot.Tables["users"]
+	if !ok || len(snapshot.Tables) != 1 {
+		t.Fatalf("snapshot tables = %v, want only users", snapshot.Tables)
+	}
+	for name, want := range map[string]string{"id": "BIGINT", "email": "VARCHAR(100)"} {
+		if col := users.Columns[name]; col.Type != want || col.Nullable {
+			t.Errorf("users.%s = %s nullable=%v, want %s NOT NULL", name, col.Type, col.Nullable, want)
+		}
+	}
+}
@@ -0,0 +1,568 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// options menyimpan flag khusus subcommand. Flag yang dipakai bersama
+// (config, quiet, strict, ...) tetap berupa variabel package dan didefinisikan
+// sekali di commonFlags atau sharedFlags.
+type options struct {
+	name       string
+	empty      bool
+	github     bool
+	chdir      bool
+	prune      bool
+	deep       bool
+	fix        bool
+	verifyDown bool
+	dialect    string
+	// since dan until dipakai oleh generate untuk migration gabungan
+	since string
+	until string
+	// tables dipakai oleh generate untuk membatasi migration pada tabel tertentu
+	tables stringList
+	// syncOrder dan twoPhase dipakai oleh generate
+	syncOrder bool
+	twoPhase  bool
+	// selects dipakai oleh mask-sql
+	selects bool
+	// rows, seed dan stdout dipakai oleh seed
+	rows   int
+	seed   int64
+	stdout bool
+	// out dipakai oleh build-schema-program
+	out string
+	// from, runner dan force dipakai oleh import
+	from   string
+	runner string
+	force  bool
+	// dsn dan down dipakai oleh apply
+	dsn  string
+	down int
+	// addr dan allowRefresh dipakai oleh serve
+	addr         string
+	allowRefresh bool
+	// targetDump dan dumpSchemas dipakai oleh repair
+	targetDump  string
+	dumpSchemas stringList
+	// keepName, move dan refreshSnapshot dipakai oleh add
+	keepName        bool
+	move            bool
+	refreshSnapshot bool
+}
+
+// stringList adalah flag yang boleh diulang, mis. -table users -table orders
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// command adalah satu subcommand CLI beserta flag dan help-nya
+type command struct {
+	name    string
+	aliases []string
+	summary string
+	// action dipakai di pesan error, mis. "Error generating diff: ..."
+	action string
+	// shared adalah nama flag dari sharedFlags yang diterima command ini,
+	// usage mengganti teks help-nya untuk command ini
+	shared []string
+	usage  map[string]string
+	flags  func(fs *flag.FlagSet, o *options)
+	run    func(ctx context.Context, o *options, args []string) error
+}
+
+// commands adalah daftar subcommand; "diff", "status" dan "verify" adalah
+// alias agar -cmd lama dan nama yang umum tetap bekerja
+var commands = []*command{
+	{
+		name:    "generate",
+		aliases: []string{"diff"},
+		summary: "Generate a migration from the schema program (default)",
+		action:  "generating diff",
+		shared:  append([]string{"timestamp", "table"}, planFlagNames...),
+		usage: map[string]string{
+			"table": "Only include changes to this table (repeatable, glob patterns allowed); other changes stay pending",
+		},
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.since, "since", "", "Print one consolidated migration from this migration version to -until on stdout, without writing files")
+			fs.StringVar(&o.until, "until", "", "Last migration version for -since (default: the latest migration)")
+			fs.BoolVar(&o.syncOrder, "sync-order", false, "Also reorder existing columns to match the schema program (MySQL only)")
+			fs.BoolVar(&o.twoPhase, "two-phase", false, "Add new foreign keys and checks as NOT VALID and write VALIDATE CONSTRAINT to a second migration (Postgres)")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			if o.since != "" || o.until != "" {
+				return printRangeMigration(o.since, o.until)
+			}
+			return withDebugBundle("generate", func() error {
+				return generateDiff(ctx, o.tables, o.syncOrder, o.twoPhase)
+			})
+		},
+	},
+	{
+		name:    "check",
+		aliases: []string{"status"},
+		summary: "Fail if the schema program has changes without a migration (CI)",
+		action:  "checking schema",
+		shared:  planFlagNames,
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.BoolVar(&o.github, "github", false, "Print failures as GitHub Actions annotations")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return withDebugBundle("check", func() error {
+				return checkSchema(ctx, o.github)
+			})
+		},
+	},
+	{
+		name:    "new",
+		summary: "Create an empty migration for manual changes: datara new [-empty] <name>",
+		action:  "creating migration",
+		shared:  []string{"timestamp"},
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.name, "name", "", "Name of the manual migration")
+			fs.BoolVar(&o.empty, "empty", false, "Create the manual migration without opening $EDITOR")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			if o.name == "" && len(args) > 0 {
+				o.name = args[0]
+			}
+			return newMigration(o.name, o.empty)
+		},
+	},
+	{
+		name:    "add",
+		summary: "Register a migration written by another tool or by hand: datara add [-keep-name] <file.sql>",
+		action:  "adding migration",
+		shared:  []string{"timestamp"},
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.BoolVar(&o.keepName, "keep-name", false, "Keep the file name instead of giving it a timestamped name")
+			fs.BoolVar(&o.move, "move", false, "Remove the source file after it is registered")
+			fs.BoolVar(&o.refreshSnapshot, "refresh-snapshot", false, "Take the new schema snapshot from the schema program instead of replaying the file")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			if len(args) != 1 {
+				return &usageError{errors.New("add needs exactly one migration file")}
+			}
+			return addMigration(ctx, args[0], o.keepName, o.move, o.refreshSnapshot)
+		},
+	},
+	{
+		name:    "hash",
+		aliases: []string{"verify"},
+		summary: "Verify migration checksums in datara.sum",
+		action:  "hashing migrations",
+		shared:  []string{"strict"},
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.BoolVar(&o.prune, "prune", false, "Sync datara.sum with the migration files on disk, or seal manual migrations in embedded mode")
+			fs.BoolVar(&o.deep, "deep", false, "Also replay migrations and check each step against datara.snapshots")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return hashMigrations(o.prune, o.deep)
+		},
+	},
+	{
+		name:    "embed",
+		summary: "Move datara.sum and the schema snapshot into migration trailers (migration.bookkeeping = \"embedded\")",
+		action:  "embedding bookkeeping",
+		flags:   func(fs *flag.FlagSet, o *options) {},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return embedBookkeeping()
+		},
+	},
+	{
+		name:    "doctor",
+		summary: "Replay migrations and report statements that would fail",
+		action:  "checking migrations",
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.BoolVar(&o.fix, "fix", false, "Remove exact-duplicate statements from later migrations")
+			fs.BoolVar(&o.verifyDown, "verify-down", false, "Check that each down section reverts its up section")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			if o.verifyDown {
+				return verifyDownMigrations()
+			}
+			return doctor(o.fix)
+		},
+	},
+	{
+		name:    "migrate-state",
+		summary: "Rewrite the schema snapshot in the current state format",
+		action:  "migrating state",
+		flags:   func(fs *flag.FlagSet, o *options) {},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return migrateState()
+		},
+	},
+	{
+		name:    "contract",
+		summary: "Validate a Schema JSON document (contract validate <file>) or print its JSON Schema (contract schema)",
+		action:  "checking contract",
+		flags:   func(fs *flag.FlagSet, o *options) {},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return contract(args)
+		},
+	},
+	{
+		name:    "indexes",
+		summary: "List every index in the schema snapshot with its columns, uniqueness, method and description",
+		action:  "listing indexes",
+		shared:  []string{"table"},
+		usage:   map[string]string{"table": "Only list the indexes of this table (repeatable; default: all tables)"},
+		flags:   func(fs *flag.FlagSet, o *options) {},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return listIndexes(o.tables)
+		},
+	},
+	{
+		name:    "mask-sql",
+		summary: "Print UPDATE statements that anonymize columns tagged mask=email|hash|null",
+		action:  "generating mask SQL",
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.BoolVar(&o.selects, "select", false, "Print one SELECT per table for dump tooling instead of UPDATE statements")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return maskSQL(o.selects)
+		},
+	},
+	{
+		name:    "seed",
+		summary: "Write INSERT statements with fake rows for the snapshot tables to migrations/seed",
+		action:  "generating seed data",
+		shared:  []string{"table"},
+		usage:   map[string]string{"table": "Seed this table and the tables it references (repeatable; default: all tables)"},
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.IntVar(&o.rows, "rows", 10, "Number of rows per table")
+			fs.Int64Var(&o.seed, "seed", 1, "Random seed; the same seed and schema produce the same SQL")
+			fs.BoolVar(&o.stdout, "stdout", false, "Print the statements instead of writing a file")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return seedData(o.tables, o.rows, o.seed, o.stdout)
+		},
+	},
+	{
+		name:    "build-schema-program",
+		summary: "Compile the go run schema program into a binary so go is not needed at runtime",
+		action:  "building schema program",
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.out, "out", "bin/register", "Path of the compiled binary, relative to datara.hcl")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return buildSchemaProgram(ctx, o.out)
+		},
+	},
+	{
+		name:    "serve",
+		summary: "Serve the schema snapshot and pending changes as read-only JSON over HTTP",
+		action:  "serving schema",
+		shared:  []string{"no-cache", "include-sensitive"},
+		usage:   map[string]string{"include-sensitive": "Show defaults and comments of sensitive columns in /schema, /tables and /diff"},
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.addr, "addr", ":8787", "Address to listen on")
+			fs.BoolVar(&o.allowRefresh, "allow-refresh", false, "Allow POST /refresh to re-run the schema program")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return serve(ctx, o.addr, o.allowRefresh)
+		},
+	},
+	{
+		name:    "import",
+		summary: "Build the schema snapshot from existing dbmate or golang-migrate migrations",
+		action:  "importing migrations",
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.from, "from", "", "Directory with the existing migrations")
+			fs.StringVar(&o.runner, "runner", "dbmate", "Migration tool that wrote them (dbmate or golang-migrate)")
+			fs.BoolVar(&o.force, "force", false, "Replace a schema snapshot that already has tables")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return importMigrations(o.from, o.runner, o.force)
+		},
+	},
+	{
+		name:    "repair",
+		summary: "Print the statements that make a database schema dump match the schema program (repair -target-dump dump.sql)",
+		action:  "generating repair",
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.targetDump, "target-dump", "", "Schema-only SQL dump of the drifted database (pg_dump --schema-only, mysqldump --no-data)")
+			fs.Var(&o.dumpSchemas, "dump-schema", "Database schema of the dump that datara manages (repeatable; default: public on postgres, dbo on mssql)")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return repairFromDump(ctx, o.targetDump, o.dumpSchemas)
+		},
+	},
+	{
+		name:    "apply",
+		summary: "Apply pending migrations to a database (apply -dsn <url>), or roll back with -down N",
+		action:  "applying migrations",
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.dsn, "dsn", "", "Database connection string (default: $DATABASE_URL)")
+			fs.IntVar(&o.down, "down", 0, "Roll back the last N applied migrations using their down sections")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return applyMigrations(ctx, o.dsn, o.down)
+		},
+	},
+	{
+		name:    "init",
+		summary: "Create datara.hcl and the migrations directory",
+		action:  "initializing project",
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.dialect, "dialect", "postgres", "Database dialect (postgres, mysql, mssql or cockroach)")
+		},
+		run: func(ctx context.Context, o *options, args []string) error {
+			return initProject(o.dialect)
+		},
+	},
+}
+
+// findCommand mencari subcommand berdasarkan nama atau alias
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+		for _, alias := range c.aliases {
+			if alias == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// Run menjalankan datara dengan argumen CLI (tanpa nama program), sehingga
+// test bisa memanggilnya tanpa mengubah os.Args
+func Run(args []string) error {
+	return RunContext(context.Background(), args)
+}
+
+// RunContext sama dengan Run, tetapi program schema dihentikan ketika ctx
+// dibatalkan. Argumen pertama yang bukan flag adalah subcommand; tanpa
+// subcommand, flag lama (-cmd diff, ...) dipakai dan default-nya generate.
+func RunContext(ctx context.Context, args []string) error {
+	resetFlags()
+
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if args[0] == "help" {
+			return help(args[1:])
+		}
+		c := findCommand(args[0])
+		if c == nil {
+			return &usageError{fmt.Errorf("running datara: unknown command %q; available commands: %s", args[0], commandNames())}
+		}
+		return runCommand(ctx, c, args[1:])
+	}
+	return runLegacy(ctx, args)
+}
+
+// runCommand mem-parse flag subcommand lalu menjalankannya
+func runCommand(ctx context.Context, c *command, args []string) error {
+	var o options
+	fs := flag.NewFlagSet("datara "+c.name, flag.ContinueOnError)
+	commonFlags(fs, &o)
+	for _, name := range c.shared {
+		sharedFlags[name](fs, &o)
+	}
+	for name, usage := range c.usage {
+		fs.Lookup(name).Usage = usage
+	}
+	c.flags(fs, &o)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: datara %s [flags]\n\n%s\n", c.name, c.summary)
+		if len(c.aliases) > 0 {
+			fmt.Fprintf(out, "\nAliases: %s\n", strings.Join(c.aliases, ", "))
+		}
+		fmt.Fprintf(out, "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return &usageError{fmt.Errorf("%s: %w", c.action, err)}
+	}
+	return execute(ctx, c, &o, fs.Args())
+}
+
+// runLegacy menjalankan bentuk lama "datara -cmd <command> [flags]" dengan
+// semua flag di satu FlagSet. Flag bersama didaftarkan sekali dari
+// sharedFlags, dan flag khusus command tidak pernah memakai nama yang sama.
+func runLegacy(ctx context.Context, args []string) error {
+	var o options
+	var name string
+	fs := flag.NewFlagSet("datara", flag.ContinueOnError)
+	fs.StringVar(&name, "cmd", "generate", "Command to execute ("+commandNames()+")")
+	commonFlags(fs, &o)
+	for _, define := range sharedFlags {
+		define(fs, &o)
+	}
+	for _, c := range commands {
+		c.flags(fs, &o)
+	}
+	fs.Usage = func() {
+		printUsage(fs.Output())
+		fmt.Fprintf(fs.Output(), "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return &usageError{fmt.Errorf("running datara: %w", err)}
+	}
+
+	c := findCommand(name)
+	if c == nil {
+		return &usageError{fmt.Errorf("running datara: unknown command %q; available commands: %s", name, commandNames())}
+	}
+	return execute(ctx, c, &o, fs.Args())
+}
+
+// execute menerapkan flag bersama (quiet, chdir) lalu menjalankan command
+func execute(ctx context.Context, c *command, o *options, args []string) error {
+	if quiet {
+		log.SetOutput(io.Discard)
+	} else if progress = newProgress(); progress != nil {
+		log.SetOutput(progress)
+	}
+	if o.chdir {
+		if err := os.Chdir(filepath.Dir(configPath)); err != nil {
+			return fmt.Errorf("changing directory: %w", err)
+		}
+		configPath = filepath.Base(configPath)
+	}
+	if err := c.run(ctx, o, args); err != nil {
+		return fmt.Errorf("%s: %w", c.action, err)
+	}
+	return nil
+}
+
+// commonFlags mendaftarkan flag yang berlaku untuk semua subcommand
+func commonFlags(fs *flag.FlagSet, o *options) {
+	fs.StringVar(&configPath, "config", configPath, "Path to the config file")
+	fs.BoolVar(&o.chdir, "chdir", false, "Change to the config file's directory before running")
+	fs.BoolVar(&quiet, "quiet", false, "Only print errors and command results")
+	fs.Bool("json-errors", false, "Print failures as a single JSON object {code, class, message, details} on stderr")
+	fs.BoolVar(&cwdRelativePaths, "cwd-relative-paths", false, "Resolve relative paths in the config against the working directory (previous behavior)")
+	fs.BoolVar(&allowOutsideRoot, "allow-outside-root", false, "Allow migration.dir and schema.program paths outside the config file's directory")
+	fs.StringVar(&schemaOverride, "schema", "", "Schema program file, overriding the last argument of schema.program")
+	fs.StringVar(&outputOverride, "output", "", "Migration directory, overriding migration.dir")
+	fs.StringVar(&formatOverride, "format", "", "Migration format, overriding migration.format")
+}
+
+// planFlagNames adalah flag bersama untuk command yang menjalankan schema program
+var planFlagNames = []string{
+	"plan-json", "no-cache", "include-sensitive", "markdown", "full", "strict-row-size", "strict-tags",
+	"warnings-as-errors", "allow-empty-schema", "allow-not-null-without-default", "expand-not-null", "debug-bundle",
+}
+
+// sharedFlags mendefinisikan flag yang dipakai lebih dari satu command, satu
+// kali per flag. Command memilihnya dengan nama lewat command.shared.
+var sharedFlags = map[string]func(fs *flag.FlagSet, o *options){
+	"timestamp": func(fs *flag.FlagSet, o *options) {
+		fs.StringVar(&timestamp, "timestamp", "", "Pin the migration timestamp (in migration.timestamp_format); defaults to $SOURCE_DATE_EPOCH or the current time")
+	},
+	"table": func(fs *flag.FlagSet, o *options) {
+		fs.Var(&o.tables, "table", "Only include this table (repeatable)")
+	},
+	"strict": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&strictSum, "strict", false, "Fail instead of pruning datara.sum entries for deleted migrations")
+	},
+	"plan-json": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&planJSON, "plan-json", false, "Print the pending changes as a versioned JSON document instead of writing a migration")
+	},
+	"no-cache": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&noCache, "no-cache", false, "Always run the schema program instead of reusing its cached output")
+	},
+	"include-sensitive": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&includeSensitive, "include-sensitive", false, "Show defaults and comments of sensitive columns in command output")
+	},
+	"markdown": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&reportMarkdown, "markdown", false, "Print the change summary as a Markdown table")
+	},
+	"full": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&reportAll, "full", false, "List every changed object in the change summary instead of truncating long lists")
+	},
+	"strict-row-size": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&strictRowSize, "strict-row-size", false, "Fail instead of warning when a table exceeds the row size or column limit")
+	},
+	"strict-tags": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&strictTags, "strict-tags", false, "Treat unknown db tag keys as errors")
+	},
+	"warnings-as-errors": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&warningsAsErrors, "warnings-as-errors", false, "Fail when the plan produces any warning")
+	},
+	"allow-empty-schema": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&allowEmptySchema, "allow-empty-schema", false, "Accept empty schema program output as a schema without tables (drops every table)")
+	},
+	"allow-not-null-without-default": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&allowNotNull, "allow-not-null-without-default", false, "Only warn about new NOT NULL columns without a default on existing tables")
+	},
+	"expand-not-null": func(fs *flag.FlagSet, o *options) {
+		fs.BoolVar(&expandNotNull, "expand-not-null", false, "Add new NOT NULL columns without a default as nullable, backfill, then SET NOT NULL")
+	},
+	"debug-bundle": func(fs *flag.FlagSet, o *options) {
+		fs.StringVar(&debugBundle, "debug-bundle", "", "Write every transformation step (program output, parsed schema, snapshot, plan, SQL) to this zip file; sensitive values are redacted")
+	},
+}
+
+// resetFlags mengembalikan flag bersama ke default agar Run bisa dipanggil
+// berulang kali dalam satu proses
+func resetFlags() {
+	configPath = "datara.hcl"
+	quiet, cwdRelativePaths, strictSum, strictRowSize, strictTags, allowOutsideRoot = false, false, false, false, false, false
+	timestamp, includeSensitive, planJSON, noCache = "", false, false, false
+	schemaOverride, outputOverride, formatOverride = "", "", ""
+	reportMarkdown, reportAll, warningsAsErrors, allowEmptySchema = false, false, false, false
+	allowNotNull, expandNotNull = false, false
+	debugBundle, trace = "", nil
+	progress = nil
+	log.SetOutput(os.Stderr)
+}
+
+// help mencetak daftar subcommand atau help satu subcommand
+func help(args []string) error {
+	if len(args) == 0 {
+		printUsage(os.Stdout)
+		return nil
+	}
+	c := findCommand(args[0])
+	if c == nil {
+		return &usageError{fmt.Errorf("running datara: unknown command %q; available commands: %s", args[0], commandNames())}
+	}
+	return runCommand(context.Background(), c, []string{"-h"})
+}
+
+// printUsage mencetak ringkasan semua subcommand
+func printUsage(out io.Writer) {
+	fmt.Fprintf(out, "Usage: datara <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-13s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(out, "\nRun 'datara help <command>' for the flags of a command. Without a command, datara runs generate.\n")
+}
+
+// commandNames mengembalikan nama semua subcommand, dipisah koma
+func commandNames() string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestFindCommandAliases(t *testing.T) {
+	tests := map[string]string{
+		"generate": "generate",
+		"diff":     "generate",
+		"check":    "check",
+		"status":   "check",
+		"hash":     "hash",
+		"verify":   "hash",
+		"init":     "init",
+	}
+	for name, want := range tests {
+		c := findCommand(name)
+		if c == nil || c.name != want {
+			t.Errorf("findCommand(%q) = %v, want %s", name, c, want)
+		}
+	}
+	if c := findCommand("nope"); c != nil {
+		t.Errorf("findCommand(nope) = %s, want nil", c.name)
+	}
+}
+
+func TestRunHelp(t *testing.T) {
+	stdout := os.Stdout
+	devnull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devnull.Close()
+	os.Stdout = devnull
+	defer func() { os.Stdout = stdout }()
+
+	args := append([]string(nil), os.Args...)
+	for _, argv := range [][]string{{"help"}, {"help", "generate"}, {"help", "verify"}, {"generate", "-h"}, {"-h"}} {
+		if err := Run(argv); err != nil {
+			t.Errorf("Run(%q) = %v, want help without an error", argv, err)
+		}
+	}
+	if err := Run([]string{"help", "nope"}); exitCode(err) != exitUsage {
+		t.Errorf("Run(help nope) = %v, want a usage error", err)
+	}
+	if !reflect.DeepEqual(os.Args, args) {
+		t.Errorf("Run changed os.Args to %q", os.Args)
+	}
+	resetFlags()
+}
+
+func TestOverrideFlags(t *testing.T) {
+	resetFlags()
+	defer resetFlags()
+	path := testProject(t)
+	output := t.TempDir()
+
+	var o options
+	fs := flag.NewFlagSet("datara generate", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	commonFlags(fs, &o)
+	err := fs.Parse([]string{"-config", path, "-schema", "models/register.go", "-output", output, "-format", "golang-migrate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := readConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Schema.Program[len(config.Schema.Program)-1]; got != "models/register.go" {
+		t.Errorf("schema program = %q, want the -schema override", got)
+	}
+	if config.Migration.Dir != output {
+		t.Errorf("migration dir = %q, want the -output override %q", config.Migration.Dir, output)
+	}
+	if config.Migration.Format != "golang-migrate" {
+		t.Errorf("migration format = %q, want the -format override", config.Migration.Format)
+	}
+
+	// Tanpa override, nilai datara.hcl dipakai dengan path relatif terhadap config
+	resetFlags()
+	configPath = path
+	config, err = readConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(filepath.Dir(path), "migrations"); config.Migration.Dir != want {
+		t.Errorf("migration dir = %q, want %q", config.Migration.Dir, want)
+	}
+}
+
+func TestBareRunIsGenerate(t *testing.T) {
+	defer resetFlags()
+	tests := []struct {
+		name string
+		args func(config string) []string
+	}{
+		{"subcommand", func(config string) []string { return []string{"generate", "-quiet", "-config", config} }},
+		{"alias", func(config string) []string { return []string{"diff", "-quiet", "-config", config} }},
+		{"bare", func(config string) []string { return []string{"-quiet", "-config", config} }},
+		{"legacy -cmd", func(config string) []string { return []string{"-cmd", "generate", "-quiet", "-config", config} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Schema program yang gagal membuktikan generate benar-benar berjalan
+			config := withProgram(t, testProject(t), "exit 3\n")
+			if err := Run(tt.args(config)); exitCode(err) != exitSchemaProgram {
+				t.Errorf("Run() = %v, want generate to run the schema program", err)
+			}
+		})
+	}
+}
+
+func TestSharedFlags(t *testing.T) {
+	for _, c := range commands {
+		for _, name := range c.shared {
+			if sharedFlags[name] == nil {
+				t.Errorf("%s: unknown shared flag %q", c.name, name)
+			}
+		}
+		for name := range c.usage {
+			if !slices.Contains(c.shared, name) {
+				t.Errorf("%s: usage override for -%s, which is not one of its shared flags", c.name, name)
+			}
+		}
+	}
+
+	// -h menghentikan parsing setelah flag sebelumnya diisi, sehingga nilai flag
+	// bersama bisa diperiksa lewat subcommand maupun -cmd lama tanpa menjalankan apa pun
+	stderr := os.Stderr
+	devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devnull.Close()
+	os.Stderr = devnull
+	defer func() { os.Stderr = stderr }()
+	defer resetFlags()
+
+	for _, args := range [][]string{
+		{"check", "-no-cache", "-h"},
+		{"serve", "-no-cache", "-h"},
+		{"-cmd", "check", "-no-cache", "-table", "users", "-strict", "-h"},
+	} {
+		if err := Run(args); err != nil {
+			t.Errorf("Run(%q) = %v", args, err)
+		}
+		if !noCache {
+			t.Errorf("Run(%q) did not set -no-cache", args)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/schema"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// debugBundleVersion adalah versi format manifest.json di debug bundle
+const debugBundleVersion = 1
+
+// trace mengumpulkan artefak untuk -debug-bundle; nil jika flag tidak diset
+var trace *debugTrace
+
+// debugArtifacts mendeskripsikan setiap file di debug bundle, sesuai urutan
+// transformasi
+var debugArtifacts = map[string]string{
+	diff.TraceRawOutput:          "stdout of the schema program, before sanitizing",
+	diff.TraceSanitizedOutput:    "schema program output after terminal artifacts and noise are removed",
+	diff.TraceParsedSchema:       "Schema JSON parsed from the sanitized output",
+	diff.TraceSnapshot:           "schema snapshot loaded from the migrations directory",
+	diff.TraceNormalizedSnapshot: "snapshot after tags and dialect normalization, as compared by the diff",
+	diff.TraceNormalizedSchema:   "parsed schema after tags and dialect normalization, as compared by the diff",
+	diff.TracePlan:               "structured plan, as printed by -plan-json",
+	diff.TraceMigration:          "final migration SQL",
+}
+
+// debugTrace mengimplementasikan diff.Tracer: state disimpan sebagai JSON yang
+// sudah disamarkan saat dilaporkan, teks disamarkan saat bundle ditulis karena
+// kolom sensitifnya baru diketahui setelah schema di-parse
+type debugTrace struct {
+	command   string
+	dialect   string
+	redactor  *diff.Generator
+	sensitive map[string]bool
+	names     []string
+	files     map[string][]byte
+	text      map[string]bool
+}
+
+// newDebugTrace membuat debugTrace untuk command; pola kolom sensitif default
+// dipakai sampai newExecutor memasang config
+func newDebugTrace(command string) *debugTrace {
+	return &debugTrace{
+		command:   command,
+		redactor:  diff.NewGenerator(&diff.Config{}),
+		sensitive: make(map[string]bool),
+		files:     make(map[string][]byte),
+		text:      make(map[string]bool),
+	}
+}
+
+// withDebugBundle menjalankan run dan, jika -debug-bundle diset, menulis
+// artefak yang terkumpul ke zip, juga ketika run gagal
+func withDebugBundle(command string, run func() error) error {
+	if debugBundle == "" {
+		return run()
+	}
+	trace = newDebugTrace(command)
+	defer func() { trace = nil }()
+	err := run()
+	if writeErr := trace.write(debugBundle, err); writeErr != nil {
+		if err == nil {
+			return writeErr
+		}
+		log.Printf("Failed to write debug bundle: %v", writeErr)
+		return err
+	}
+	infof("Wrote debug bundle %s\n", debugBundle)
+	return err
+}
+
+// configure memakai config untuk pola kolom sensitif dan dialect
+func (t *debugTrace) configure(config *Config) {
+	t.redactor = diff.NewGenerator(diffConfig(config))
+	t.dialect = config.Migration.Dialect
+}
+
+// Trace mengimplementasikan diff.Tracer. Artefak yang dilaporkan ulang
+// menimpa isi sebelumnya tanpa mengubah urutan.
+func (t *debugTrace) Trace(name string, artifact interface{}) {
+	switch value := artifact.(type) {
+	case string:
+		t.store(name, []byte(value), true)
+	case *state.SchemaState:
+		for column := range t.redactor.SensitiveColumns(value) {
+			t.sensitive[column] = true
+		}
+		if !includeSensitive {
+			value = t.redactor.RedactState(value)
+		}
+		t.storeJSON(name, value)
+	default:
+		t.storeJSON(name, value)
+	}
+}
+
+// recordPlan melaporkan plan terstruktur dan SQL up-nya; nil-safe agar bisa
+// dipanggil tanpa memeriksa -debug-bundle
+func (t *debugTrace) recordPlan(config *Config, plan *schema.Plan) {
+	if t == nil {
+		return
+	}
+	doc, err := planDocument(config, plan)
+	if err != nil {
+		log.Printf("Debug bundle: failed to build plan: %v", err)
+		return
+	}
+	t.Trace(diff.TracePlan, doc)
+	if plan != nil && len(plan.Up) > 0 {
+		t.Trace(diff.TraceMigration, strings.Join(plan.Up, "\n")+"\n")
+	}
+}
+
+// recordMigration melaporkan isi file migration yang ditulis generate
+func (t *debugTrace) recordMigration(filename string) {
+	if t == nil {
+		return
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		log.Printf("Debug bundle: failed to read migration: %v", err)
+		return
+	}
+	t.Trace(diff.TraceMigration, string(content))
+}
+
+func (t *debugTrace) storeJSON(name string, value interface{}) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		log.Printf("Debug bundle: failed to encode %s: %v", name, err)
+		return
+	}
+	t.store(name, append(data, '\n'), false)
+}
+
+func (t *debugTrace) store(name string, data []byte, text bool) {
+	if _, ok := t.files[name]; !ok {
+		t.names = append(t.names, name)
+	}
+	t.files[name] = data
+	t.text[name] = text
+}
+
+// debugManifest adalah isi manifest.json
+type debugManifest struct {
+	Version  int                 `json:"version"`
+	Command  string              `json:"command"`
+	Dialect  string              `json:"dialect,omitempty"`
+	Created  string              `json:"created"`
+	Redacted bool                `json:"redacted"`
+	Result   string              `json:"result"`
+	Error    string              `json:"error,omitempty"`
+	Files    []debugManifestFile `json:"files"`
+}
+
+type debugManifestFile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Size        int    `json:"size"`
+}
+
+// write menulis manifest.json dan setiap artefak ke zip di path. runErr
+// dicatat di manifest agar bundle dari run yang gagal tetap bisa dibaca.
+func (t *debugTrace) write(path string, runErr error) error {
+	manifest := debugManifest{
+		Version:  debugBundleVersion,
+		Command:  t.command,
+		Dialect:  t.dialect,
+		Created:  time.Now().UTC().Format(time.RFC3339),
+		Redacted: !includeSensitive,
+		Result:   "ok",
+		Files:    []debugManifestFile{},
+	}
+	if runErr != nil {
+		manifest.Result, manifest.Error = "error", runErr.Error()
+	}
+	contents := make(map[string][]byte, len(t.names))
+	for _, name := range t.names {
+		data := t.files[name]
+		if t.text[name] && !includeSensitive {
+			data = []byte(redactText(string(data), t.sensitive))
+		}
+		contents[name] = data
+		manifest.Files = append(manifest.Files, debugManifestFile{Name: name, Description: debugArtifacts[name], Size: len(data)})
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create debug bundle: %w", err)
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+	entries, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeZipEntry(archive, "manifest.json", append(entries, '\n')); err != nil {
+		return err
+	}
+	for _, name := range t.names {
+		if err := writeZipEntry(archive, name, contents[name]); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write debug bundle: %w", err)
+	}
+	return file.Close()
+}
+
+func writeZipEntry(archive *zip.Writer, name string, data []byte) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write debug bundle: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write debug bundle: %w", err)
+	}
+	return nil
+}
+
+// redactedValuePattern menangkap nilai DEFAULT, redactedCommentPattern
+// komentar COMMENT '...' dan COMMENT ON ... IS '...', pada baris yang menyebut
+// kolom sensitif
+var (
+	redactedValuePattern   = regexp.MustCompile(`(?i)\b(DEFAULT)(\s+)('(?:[^']|'')*'|[^\s,;)]+)`)
+	redactedCommentPattern = regexp.MustCompile(`(?i)\b(COMMENT|IS)(\s+)('(?:[^']|'')*')`)
+)
+
+// redactText menyamarkan default dan komentar kolom sensitif pada output
+// program atau SQL. Output berupa Schema JSON disamarkan per kolom dan ditulis
+// ulang dengan indentasi.
+func redactText(text string, sensitive map[string]bool) string {
+	if len(sensitive) == 0 {
+		return text
+	}
+	if trimmed := strings.TrimSpace(text); strings.HasPrefix(trimmed, "{") {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(trimmed), &doc); err == nil {
+			if out, err := json.MarshalIndent(redactJSON(doc, sensitive), "", "  "); err == nil {
+				return string(out) + "\n"
+			}
+		}
+	}
+	names := make([]string, 0, len(sensitive))
+	for name := range sensitive {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	sort.Strings(names)
+	column := regexp.MustCompile("(?i)(^|[^\\w])[`\"\\[]?(" + strings.Join(names, "|") + ")[`\"\\]]?($|[^\\w])")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if !column.MatchString(line) {
+			continue
+		}
+		line = redactedValuePattern.ReplaceAllString(line, "${1}${2}'"+state.Redacted+"'")
+		lines[i] = redactedCommentPattern.ReplaceAllString(line, "${1}${2}'"+state.Redacted+"'")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// redactJSON menyamarkan default_value dan tag default/comment/seed pada objek
+// kolom sensitif di dokumen Schema JSON
+func redactJSON(value interface{}, sensitive map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if name, ok := v["name"].(string); ok && sensitive[strings.ToLower(name)] {
+			if _, ok := v["default_value"]; ok {
+				v["default_value"] = state.Redacted
+			}
+			if tags, ok := v["tags"].(map[string]interface{}); ok {
+				for _, key := range []string{"default", "comment", "seed"} {
+					if _, ok := tags[key]; ok {
+						tags[key] = state.Redacted
+					}
+				}
+			}
+		}
+		for key, child := range v {
+			v[key] = redactJSON(child, sensitive)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactJSON(child, sensitive)
+		}
+	}
+	return value
+}
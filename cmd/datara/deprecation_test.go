@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara"
+	"github.com/akmalulginan/datara/internal/schema"
+)
+
+// usersSchema menulis schema.json berisi tabel users; legacy menentukan
+// kolom legacy: "" (tidak ada), "column" atau "deprecated"
+func usersSchema(t *testing.T, path, legacy string) {
+	t.Helper()
+	users := datara.NewTable("users").Column("id", datara.BigInt().PrimaryKey())
+	if legacy != "" {
+		users.Column("legacy", datara.Varchar(50))
+	}
+	built, err := datara.NewSchema().Table(users).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy == "deprecated" {
+		column := built.Tables["users"].Columns["legacy"]
+		column.Deprecated = true
+		built.Tables["users"].Columns["legacy"] = column
+	}
+	data, err := json.Marshal(built)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "schema.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runStdout menjalankan Run dan mengembalikan output stdout-nya
+func runStdout(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	defer resetFlags()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	runErr := Run(args)
+	os.Stdout = stdout
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), runErr
+}
+
+// latestMigration mengembalikan isi file migration terbaru
+func latestMigration(t *testing.T, path string) string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(path), "migrations", "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations written: %v", err)
+	}
+	sort.Strings(files)
+	content, err := os.ReadFile(files[len(files)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestDeprecationLifecycle(t *testing.T) {
+	path := withProgram(t, testProject(t), "cat schema.json\n")
+	journal := filepath.Join(filepath.Dir(path), "migrations", schema.DeprecationFile)
+
+	// 1. Kolom legacy ditambahkan
+	usersSchema(t, path, "column")
+	if _, err := runStdout(t, "generate", "-quiet", "-config", path); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	// 2. Kolom ditandai deprecated: tetap ada, komentarnya berubah, dan
+	// tercatat di journal
+	usersSchema(t, path, "deprecated")
+	if _, err := runStdout(t, "generate", "-quiet", "-config", path); err != nil {
+		t.Fatalf("deprecate: %v", err)
+	}
+	migration := latestMigration(t, path)
+	if !strings.Contains(migration, `COMMENT ON COLUMN "users"."legacy" IS 'DEPRECATED'`) || strings.Contains(migration, "DROP COLUMN") {
+		t.Errorf("deprecation migration:\n%s\nwant only the DEPRECATED comment", migration)
+	}
+	entries, err := os.ReadFile(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields := strings.Fields(string(entries)); len(fields) != 3 || fields[1] != "users.legacy" {
+		t.Errorf("%s = %q, want one entry for users.legacy", schema.DeprecationFile, entries)
+	}
+	out, err := runStdout(t, "status", "-config", path)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !strings.Contains(out, "users.legacy  deprecated 0 day(s) ago in ") {
+		t.Errorf("status output:\n%s\nwant users.legacy listed as deprecated 0 days ago", out)
+	}
+
+	// 3. Kolom dihapus: DROP COLUMN tanpa peringatan undeprecated-drop
+	usersSchema(t, path, "")
+	if _, err := runStdout(t, "generate", "-quiet", "-warnings-as-errors", "-config", path); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	if migration := latestMigration(t, path); !strings.Contains(migration, `ALTER TABLE "users" DROP COLUMN "legacy"`) {
+		t.Errorf("drop migration:\n%s\nwant DROP COLUMN legacy", migration)
+	}
+}
+
+func TestUndeprecatedDropWarns(t *testing.T) {
+	path := withProgram(t, testProject(t), "cat schema.json\n")
+	usersSchema(t, path, "column")
+	if _, err := runStdout(t, "generate", "-quiet", "-config", path); err != nil {
+		t.Fatal(err)
+	}
+
+	usersSchema(t, path, "")
+	_, err := runStdout(t, "generate", "-quiet", "-warnings-as-errors", "-config", path)
+	if err == nil || exitCode(err) != exitValidation {
+		t.Fatalf("Run() = %v, want the undeprecated-drop warning to fail with exit code %d", err, exitValidation)
+	}
+}
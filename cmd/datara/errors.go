@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/applier"
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/schema"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Exit code untuk setiap kelas error
+const (
+	exitGeneric          = 1
+	exitPendingChanges   = 2
+	exitChecksumMismatch = 3
+	exitValidation       = 4
+	exitUsage            = 5
+	exitConfig           = 6
+	exitSchemaProgram    = 7
+	exitContract         = 8
+	exitFormatVersion    = 9
+)
+
+// usageError menandai flag atau command yang tidak valid
+type usageError struct{ err error }
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+// configError menandai datara.hcl yang tidak bisa dibaca atau tidak valid
+type configError struct{ err error }
+
+func (e *configError) Error() string { return e.err.Error() }
+func (e *configError) Unwrap() error { return e.err }
+
+// warningsError dikembalikan jika -warnings-as-errors diset dan plan
+// menghasilkan peringatan
+type warningsError struct{ warnings state.Warnings }
+
+func (e *warningsError) Error() string {
+	return fmt.Sprintf("%d warning(s) treated as errors", len(e.warnings))
+}
+
+// summaryError menampilkan ringkasan, tetapi tetap membungkus error aslinya
+// agar kelasnya dikenali (mis. pelanggaran contract yang sudah dicetak per baris)
+type summaryError struct {
+	summary string
+	err     error
+}
+
+func (e *summaryError) Error() string { return e.summary }
+func (e *summaryError) Unwrap() error { return e.err }
+
+// errorEnvelope adalah error CLI untuk -json-errors
+type errorEnvelope struct {
+	Code    int                    `json:"code"`
+	Class   string                 `json:"class"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// classifyError memetakan error ke exit code, nama kelas dan detail
+// terstruktur. Kelas error baru cukup ditambahkan di sini agar ikut exit code
+// dan envelope JSON.
+func classifyError(err error) errorEnvelope {
+	var checksumErr *schema.ChecksumMismatchError
+	var appliedErr *applier.HashMismatchError
+	var divergenceErr *schema.SnapshotDivergenceError
+	var validationErr *diff.ValidationError
+	var duplicateErr *schema.DuplicateTableError
+	var programErr *schema.SchemaProgramError
+	var emptyErr *schema.EmptySchemaError
+	var goErr *schema.GoNotFoundError
+	var contractErrs schema.ContractErrors
+	var versionErr *state.FormatVersionError
+	var usageErr *usageError
+	var configErr *configError
+	var warningsErr *warningsError
+	var hookErr *hookError
+
+	e := errorEnvelope{Code: exitGeneric, Class: "error", Message: err.Error()}
+	switch {
+	case errors.Is(err, errPendingChanges):
+		e.Code, e.Class = exitPendingChanges, "pending_changes"
+	case errors.As(err, &checksumErr):
+		e.Code, e.Class = exitChecksumMismatch, "checksum_mismatch"
+		e.Details = map[string]interface{}{"file": checksumErr.File, "want": checksumErr.Want, "got": checksumErr.Got}
+	case errors.As(err, &appliedErr):
+		e.Code, e.Class = exitChecksumMismatch, "checksum_mismatch"
+		e.Details = map[string]interface{}{"file": appliedErr.File, "want": appliedErr.Recorded, "got": appliedErr.Got}
+	case errors.As(err, &divergenceErr):
+		e.Code, e.Class = exitChecksumMismatch, "snapshot_divergence"
+		e.Details = map[string]interface{}{"file": divergenceErr.File, "want": divergenceErr.Want, "got": divergenceErr.Got}
+	case errors.As(err, &validationErr):
+		e.Code, e.Class = exitValidation, "validation"
+		e.Details = map[string]interface{}{"table": validationErr.Table, "column": validationErr.Column, "rule": validationErr.Rule}
+	case errors.As(err, &duplicateErr):
+		e.Code, e.Class = exitValidation, "duplicate_table"
+		e.Details = map[string]interface{}{"table": duplicateErr.Table, "first": duplicateErr.First, "second": duplicateErr.Second}
+	case errors.As(err, &warningsErr):
+		e.Code, e.Class = exitValidation, "warnings"
+		e.Details = map[string]interface{}{"warnings": warningsErr.warnings}
+	case errors.As(err, &usageErr):
+		e.Code, e.Class = exitUsage, "usage"
+	case errors.As(err, &configErr):
+		e.Code, e.Class = exitConfig, "config"
+	case errors.As(err, &programErr):
+		e.Code, e.Class = exitSchemaProgram, "schema_program"
+		e.Details = map[string]interface{}{"exit_code": programErr.ExitCode, "stderr": strings.TrimSpace(programErr.Stderr)}
+	case errors.As(err, &emptyErr):
+		e.Code, e.Class = exitSchemaProgram, "empty_schema"
+		e.Details = map[string]interface{}{"stderr": strings.TrimSpace(emptyErr.Stderr)}
+	case errors.As(err, &goErr):
+		e.Code, e.Class = exitSchemaProgram, "go_not_found"
+		e.Details = map[string]interface{}{"program": goErr.Program}
+	case errors.As(err, &hookErr):
+		e.Class = "hook"
+		e.Details = map[string]interface{}{"stage": hookErr.Stage, "command": hookErr.Command}
+	case errors.As(err, &contractErrs):
+		e.Code, e.Class = exitContract, "contract"
+		violations := make([]map[string]string, len(contractErrs))
+		for i, violation := range contractErrs {
+			violations[i] = map[string]string{"path": violation.Path, "message": violation.Message}
+		}
+		e.Details = map[string]interface{}{"violations": violations}
+	case errors.As(err, &versionErr):
+		e.Code, e.Class = exitFormatVersion, "format_version"
+		e.Details = map[string]interface{}{"path": versionErr.Path, "version": versionErr.Version}
+	}
+	return e
+}
+
+// exitCode memetakan error ke exit code CLI
+func exitCode(err error) int {
+	return classifyError(err).Code
+}
+
+// reportError adalah satu-satunya tempat error CLI ditampilkan di stderr:
+// sebagai teks, atau sebagai satu objek JSON dengan -json-errors, sehingga
+// stdout hanya berisi hasil perintah. Exit code-nya dikembalikan.
+func reportError(err error, asJSON bool) int {
+	e := classifyError(err)
+	if !asJSON {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return e.Code
+	}
+	encoder := json.NewEncoder(os.Stderr)
+	encoder.SetEscapeHTML(false)
+	if encoder.Encode(e) != nil {
+		e.Details = nil
+		encoder.Encode(e)
+	}
+	return e.Code
+}
+
+// jsonErrorsRequested mencari -json-errors di argumen. Argumen dibaca langsung
+// karena error bisa terjadi sebelum flag selesai di-parse (mis. command tidak dikenal).
+func jsonErrorsRequested(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		switch strings.TrimLeft(arg, "-") {
+		case "json-errors", "json-errors=true", "json-errors=1":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/applier"
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/schema"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err   error
+		code  int
+		class string
+	}{
+		{errors.New("boom"), exitGeneric, "error"},
+		{errPendingChanges, exitPendingChanges, "pending_changes"},
+		{&schema.ChecksumMismatchError{File: "1.sql", Want: "h1:a", Got: "h1:b"}, exitChecksumMismatch, "checksum_mismatch"},
+		{&applier.HashMismatchError{File: "1.sql", Recorded: "a", Got: "b"}, exitChecksumMismatch, "checksum_mismatch"},
+		{&schema.SnapshotDivergenceError{File: "1.sql"}, exitChecksumMismatch, "snapshot_divergence"},
+		{&diff.ValidationError{Table: "users", Column: "id", Rule: "fk-type"}, exitValidation, "validation"},
+		{&schema.DuplicateTableError{Table: "users"}, exitValidation, "duplicate_table"},
+		{&warningsError{}, exitValidation, "warnings"},
+		{&usageError{errors.New("bad flag")}, exitUsage, "usage"},
+		{&configError{errors.New("bad hcl")}, exitConfig, "config"},
+		{&schema.SchemaProgramError{ExitCode: 2}, exitSchemaProgram, "schema_program"},
+		{&schema.EmptySchemaError{}, exitSchemaProgram, "empty_schema"},
+		{&schema.GoNotFoundError{Program: []string{"go", "run", "."}}, exitSchemaProgram, "go_not_found"},
+		{schema.ContractErrors{{Path: "tables", Message: "empty"}}, exitContract, "contract"},
+		{&state.FormatVersionError{Path: "schema.json", Version: "9"}, exitFormatVersion, "format_version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			// Error selalu dibungkus oleh command sebelum sampai ke CLI
+			wrapped := fmt.Errorf("generating diff: %w", tt.err)
+			got := classifyError(wrapped)
+			if got.Code != tt.code || got.Class != tt.class {
+				t.Errorf("classifyError(%T) = %d %s, want %d %s", tt.err, got.Code, got.Class, tt.code, tt.class)
+			}
+			if got.Message != wrapped.Error() {
+				t.Errorf("message = %q, want %q", got.Message, wrapped.Error())
+			}
+		})
+	}
+}
+
+// captureOutput menjalankan fn dan mengembalikan apa yang ditulis ke stdout dan stderr
+func captureOutput(t *testing.T, fn func()) (stdout, stderr string) {
+	t.Helper()
+	read := func(target **os.File) func() string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		original := *target
+		*target = w
+		return func() string {
+			*target = original
+			w.Close()
+			out, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(out)
+		}
+	}
+	doneStdout, doneStderr := read(&os.Stdout), read(&os.Stderr)
+	fn()
+	return doneStdout(), doneStderr()
+}
+
+func TestReportError(t *testing.T) {
+	err := fmt.Errorf("reading config: %w", &configError{errors.New("bad hcl")})
+	tests := []struct {
+		name   string
+		asJSON bool
+		want   string
+	}{
+		{"text", false, "Error reading config: bad hcl\n"},
+		{"json", true, `{"code":6,"class":"config","message":"reading config: bad hcl"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var code int
+			stdout, stderr := captureOutput(t, func() { code = reportError(err, tt.asJSON) })
+			if code != exitConfig {
+				t.Errorf("exit code = %d, want %d", code, exitConfig)
+			}
+			// stdout dicadangkan untuk hasil perintah
+			if stdout != "" {
+				t.Errorf("stdout = %q, want empty", stdout)
+			}
+			if stderr != tt.want {
+				t.Errorf("stderr = %q, want %q", stderr, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/schema"
+)
+
+// Tahap hook di blok hooks datara.hcl
+const (
+	hookPreGenerate  = "pre_generate"
+	hookPostGenerate = "post_generate"
+)
+
+// hookData adalah nilai placeholder {{.File}}, {{.Dir}} dan {{.Tables}} di
+// argumen hook, juga diteruskan sebagai DATARA_FILE, DATARA_DIR dan
+// DATARA_TABLES_CHANGED
+type hookData struct {
+	// File adalah path migration yang ditulis; kosong untuk pre_generate
+	File string
+	// Dir adalah direktori migration
+	Dir string
+	// Tables adalah nama tabel yang berubah, dipisah koma
+	Tables string
+}
+
+// hookError dikembalikan jika hook keluar dengan error
+type hookError struct {
+	Stage   string
+	Command []string
+	err     error
+}
+
+func (e *hookError) Error() string {
+	return fmt.Sprintf("%s hook %q failed: %v", e.Stage, strings.Join(e.Command, " "), e.err)
+}
+
+func (e *hookError) Unwrap() error { return e.err }
+
+// newHookData membuat hookData untuk plan. Nama tabel diambil dari daftar
+// perubahan sehingga sama dengan ringkasan yang dicetak generate.
+func newHookData(config *Config, plan *schema.Plan) (hookData, error) {
+	changes, err := diff.NewGenerator(diffConfig(config)).Changes(plan.Current, plan.Desired)
+	if err != nil {
+		return hookData{}, err
+	}
+	seen := make(map[string]bool)
+	var tables []string
+	for _, change := range changes {
+		if !seen[change.Table] {
+			seen[change.Table] = true
+			tables = append(tables, change.Table)
+		}
+	}
+	sort.Strings(tables)
+	// Hook dijalankan dari direktori config, jadi path dibuat absolut
+	dir, err := filepath.Abs(config.Migration.Dir)
+	if err != nil {
+		return hookData{}, err
+	}
+	return hookData{Dir: dir, Tables: strings.Join(tables, ",")}, nil
+}
+
+// runHook menjalankan command hook dari direktori config. Placeholder di
+// setiap argumen di-render dengan data, dan output hook diteruskan baris per
+// baris ke logger.
+func runHook(ctx context.Context, config *Config, stage string, command []string, data hookData) error {
+	if len(command) == 0 {
+		return nil
+	}
+	args := make([]string, len(command))
+	for i, arg := range command {
+		tmpl, err := template.New(stage).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return &configError{fmt.Errorf("invalid hooks.%s argument %q: %w", stage, arg, err)}
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return &configError{fmt.Errorf("invalid hooks.%s argument %q: %w", stage, arg, err)}
+		}
+		args[i] = b.String()
+	}
+
+	log.Printf("Running %s hook: %s", stage, strings.Join(args, " "))
+	output := &hookLogWriter{prefix: stage}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = config.dir
+	cmd.Env = append(os.Environ(),
+		"DATARA_FILE="+data.File,
+		"DATARA_DIR="+data.Dir,
+		"DATARA_TABLES_CHANGED="+data.Tables,
+	)
+	cmd.Stdout, cmd.Stderr = output, output
+	err := cmd.Run()
+	output.flush()
+	if err != nil {
+		return &hookError{Stage: stage, Command: args, err: err}
+	}
+	return nil
+}
+
+// postGenerate menjalankan hook post_generate untuk migration data.File,
+// migration pertama dari files. Hook boleh mengubah file (mis. formatter),
+// jadi checksum files dicatat ulang di datara.sum. Jika hook gagal, semua
+// files dan bookkeeping-nya dikembalikan dari backup; tanpa backup
+// (best_effort) kegagalan hanya dicatat.
+func postGenerate(ctx context.Context, config *Config, data hookData, backup *generationBackup, files []string) error {
+	err := runHook(ctx, config, hookPostGenerate, config.Hooks.PostGenerate, data)
+	if err != nil && backup != nil {
+		if restoreErr := backup.restore(files...); restoreErr != nil {
+			return fmt.Errorf("%w; reverting the migration also failed: %v", err, restoreErr)
+		}
+		infof("Reverted migration %s\n", strings.Join(files, ", "))
+		return err
+	}
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = filepath.Base(file)
+	}
+	return schema.RehashSum(config.Migration.Dir, names...)
+}
+
+// hookLogWriter meneruskan output hook ke logger per baris
+type hookLogWriter struct {
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (w *hookLogWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// Baris belum lengkap, simpan untuk Write berikutnya
+			w.buf.WriteString(line)
+			return len(p), nil
+		}
+		log.Printf("%s: %s", w.prefix, strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// flush menulis sisa output yang tidak diakhiri newline
+func (w *hookLogWriter) flush() {
+	if w.buf.Len() > 0 {
+		log.Printf("%s: %s", w.prefix, w.buf.String())
+		w.buf.Reset()
+	}
+}
+
+// generationBackup menyimpan isi file snapshot dan bookkeeping sebelum
+// generate menulisnya, sehingga migration bisa dibatalkan seluruhnya jika
+// penulisan batch atau hook post_generate gagal
+type generationBackup struct {
+	// files memetakan path ke isi sebelumnya; nil berarti file belum ada
+	files map[string][]byte
+}
+
+// backupGeneration mencadangkan snapshot executor serta datara.sum,
+// datara.snapshots dan datara.deprecations di direktori migration
+func backupGeneration(config *Config, executor *schema.Executor) (*generationBackup, error) {
+	paths := append(executor.StateFiles(),
+		filepath.Join(config.Migration.Dir, schema.SumFile),
+		filepath.Join(config.Migration.Dir, schema.JournalFile),
+		filepath.Join(config.Migration.Dir, schema.DeprecationFile),
+	)
+	backup := &generationBackup{files: make(map[string][]byte, len(paths))}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			backup.files[path] = nil
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+		backup.files[path] = content
+	}
+	return backup, nil
+}
+
+// restore menghapus migration filenames lalu mengembalikan file yang
+// dicadangkan ke isi sebelum generate
+func (b *generationBackup) restore(filenames ...string) error {
+	for _, filename := range filenames {
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", filename, err)
+		}
+	}
+	for path, content := range b.files {
+		if content == nil {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/schema"
+)
+
+// withHooks menulis hook.sh, yang mencatat argumen, environment dan jumlah
+// migration ke hooks.log lalu keluar dengan exit code $HOOK_EXIT_<tahap>,
+// dan menambahkan blok hooks ke datara.hcl
+func withHooks(t *testing.T, path, block string) string {
+	t.Helper()
+	dir := filepath.Dir(path)
+	script := `stage=$1; shift
+echo "$stage args=$* file=$DATARA_FILE dir=$DATARA_DIR tables=$DATARA_TABLES_CHANGED migrations=$(ls migrations/*.sql 2>/dev/null | wc -l | tr -d ' ')" >> hooks.log
+if [ "$stage" = post ] && [ -n "$DATARA_FILE" ]; then echo "-- formatted" >> "$DATARA_FILE"; fi
+eval "exit \${HOOK_EXIT_$stage:-0}"
+`
+	if err := os.WriteFile(filepath.Join(dir, "hook.sh"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = append(config, block...)
+	if err := os.WriteFile(path, config, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// hooksLog mengembalikan baris hooks.log
+func hooksLog(t *testing.T, path string) []string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(filepath.Dir(path), "hooks.log"))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(content)), "\n")
+}
+
+const testHooks = `hooks {
+  pre_generate  = ["/bin/sh", "hook.sh", "pre", "{{.Tables}}"]
+  post_generate = ["/bin/sh", "hook.sh", "post", "{{.File}}"]
+}
+`
+
+func TestHooksOrderAndEnvironment(t *testing.T) {
+	path := withHooks(t, testProject(t), testHooks)
+	usersSchema(t, withProgram(t, path, "cat schema.json\n"), "")
+	if _, err := runStdout(t, "generate", "-quiet", "-config", path); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := filepath.Abs(filepath.Join(filepath.Dir(path), "migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if len(files) != 1 {
+		t.Fatalf("migrations = %v, want one", files)
+	}
+	// pre_generate berjalan sebelum migration ditulis, post_generate sesudahnya
+	want := []string{
+		"pre args=users file= dir=" + dir + " tables=users migrations=0",
+		"post args=" + files[0] + " file=" + files[0] + " dir=" + dir + " tables=users migrations=1",
+	}
+	if got := hooksLog(t, path); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("hooks.log =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Edit oleh post_generate dicatat ulang di datara.sum
+	if content, _ := os.ReadFile(files[0]); !strings.HasSuffix(string(content), "-- formatted\n") {
+		t.Errorf("post_generate edit missing from migration:\n%s", content)
+	}
+	if err := schema.VerifySum(dir, nil); err != nil {
+		t.Errorf("VerifySum after post_generate = %v", err)
+	}
+}
+
+func TestHookFailure(t *testing.T) {
+	tests := []struct {
+		name  string
+		stage string
+		block string
+		// wantMigration bernilai true jika migration tetap ditulis
+		wantMigration bool
+	}{
+		{name: "pre_generate stops generate", stage: "pre", block: testHooks},
+		{name: "post_generate reverts the migration", stage: "post", block: testHooks},
+		{name: "best_effort keeps the migration", stage: "post", wantMigration: true,
+			block: strings.Replace(testHooks, "hooks {\n", "hooks {\n  best_effort = true\n", 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOOK_EXIT_"+tt.stage, "3")
+			path := withHooks(t, testProject(t), tt.block)
+			usersSchema(t, withProgram(t, path, "cat schema.json\n"), "")
+			_, err := runStdout(t, "generate", "-quiet", "-config", path)
+
+			dir := filepath.Join(filepath.Dir(path), "migrations")
+			files, _ := filepath.Glob(filepath.Join(dir, "*.sql"))
+			if tt.wantMigration {
+				if err != nil || len(files) != 1 {
+					t.Fatalf("generate = %v with migrations %v, want one migration and no error", err, files)
+				}
+				if err := schema.VerifySum(dir, nil); err != nil {
+					t.Errorf("VerifySum = %v", err)
+				}
+				return
+			}
+
+			var hookErr *hookError
+			if !errors.As(err, &hookErr) || hookErr.Stage != map[string]string{"pre": hookPreGenerate, "post": hookPostGenerate}[tt.stage] {
+				t.Fatalf("generate = %v, want a %s hook error", err, tt.stage)
+			}
+			if envelope := classifyError(err); envelope.Class != "hook" || envelope.Code != exitGeneric {
+				t.Errorf("classifyError = %s/%d, want hook/%d", envelope.Class, envelope.Code, exitGeneric)
+			}
+			if len(files) != 0 {
+				t.Errorf("migrations left after a failed hook: %v", files)
+			}
+			for _, name := range []string{schema.SumFile, "schema.json", "schema_hash"} {
+				if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+					t.Errorf("%s left after a failed hook", name)
+				}
+			}
+		})
+	}
+}
+
+func TestHookInvalidPlaceholder(t *testing.T) {
+	path := withHooks(t, testProject(t), "hooks {\n  pre_generate = [\"echo\", \"{{.Table}}\"]\n}\n")
+	usersSchema(t, withProgram(t, path, "cat schema.json\n"), "")
+	if _, err := runStdout(t, "generate", "-quiet", "-config", path); exitCode(err) != exitConfig {
+		t.Errorf("generate = %v, want exit code %d", err, exitConfig)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// listIndexes mencetak setiap index di snapshot schema, satu baris per index
+// terurut berdasarkan tabel lalu nama index, dengan kolom dipisah tab agar
+// mudah di-grep: tabel, index, kolom, unique, metode dan deskripsi
+func listIndexes(tables []string) error {
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	snapshot, err := newExecutor(config).Snapshot()
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	selected := make(map[string]bool, len(tables))
+	for _, name := range tables {
+		if _, ok := snapshot.Tables[name]; !ok {
+			return &usageError{fmt.Errorf("unknown table %q", name)}
+		}
+		selected[name] = true
+	}
+
+	dialect := diffConfig(config).Dialect
+	var lines []string
+	for _, table := range snapshot.Tables {
+		if len(selected) > 0 && !selected[table.Name] {
+			continue
+		}
+		for _, idx := range table.Indexes {
+			unique := "non-unique"
+			if idx.Unique {
+				unique = "unique"
+			}
+			description := strings.Join(strings.Fields(idx.Description), " ")
+			if description == "" {
+				description = "-"
+			}
+			lines = append(lines, strings.Join([]string{table.Name, idx.Name, indexColumnList(idx), unique,
+				diff.IndexMethod(dialect, idx), description}, "\t"))
+		}
+	}
+	if len(lines) == 0 {
+		infof("No indexes in the schema snapshot\n")
+		return nil
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// indexColumnList merender kolom index dipisah koma, dengan prefix length dan
+// kolom INCLUDE, mis. "email(191),name include(bio)"
+func indexColumnList(idx state.Index) string {
+	columns := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		columns[i] = col
+		if length := idx.Lengths[col]; length > 0 {
+			columns[i] += "(" + strconv.Itoa(length) + ")"
+		}
+	}
+	list := strings.Join(columns, ",")
+	if len(idx.Include) > 0 {
+		list += " include(" + strings.Join(idx.Include, ",") + ")"
+	}
+	return list
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// describedSchema membuat tabel users dengan dua index; description adalah
+// deskripsi idx_users_email dari DescribeIndex
+func describedSchema(description string) *datara.SchemaBuilder {
+	return datara.NewSchema().Table(datara.NewTable("users").
+		Column("id", datara.BigInt().PrimaryKey()).
+		Column("email", datara.Varchar(100)).
+		Column("name", datara.Varchar(100)).
+		Index("idx_users_name", "name", "email").
+		UniqueIndex("idx_users_email", "email").
+		DescribeIndex("idx_users_email", description))
+}
+
+func TestIndexesCommand(t *testing.T) {
+	path := withSchema(t, testProject(t), describedSchema("login lookup"))
+	if out, err := runStdout(t, "indexes", "-config", path); err != nil || out != "No indexes in the schema snapshot\n" {
+		t.Fatalf("indexes before generate = %q, %v, want a note that the snapshot has no indexes", out, err)
+	}
+
+	// 1. Deskripsi ditulis di atas CREATE INDEX
+	if _, err := runStdout(t, "generate", "-quiet", "-config", path); err != nil {
+		t.Fatal(err)
+	}
+	if migration := latestMigration(t, path); !strings.Contains(migration, "-- idx_users_email: login lookup\nCREATE UNIQUE INDEX \"idx_users_email\"") {
+		t.Errorf("migration:\n%s\nwant the description above CREATE UNIQUE INDEX", migration)
+	}
+	out, err := runStdout(t, "indexes", "-config", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "users\tidx_users_email\temail\tunique\tbtree\tlogin lookup\n" +
+		"users\tidx_users_name\tname,email\tnon-unique\tbtree\t-\n"
+	if out != want {
+		t.Errorf("indexes =\n%q\nwant\n%q", out, want)
+	}
+
+	// 2. Deskripsi yang berubah hanya memperbarui snapshot
+	withSchema(t, path, describedSchema("login and\n  signup lookup"))
+	if _, err := runStdout(t, "generate", "-quiet", "-config", path); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "migrations", "*.sql")); len(files) != 1 {
+		t.Errorf("description change wrote migrations %v", files)
+	}
+
+	// 3. Blok index di datara.hcl menimpa deskripsi dari schema program
+	config, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = append(config, "index \"users\" \"idx_users_name\" {\n  description = \"name search\"\n}\n"...)
+	if err := os.WriteFile(path, config, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runStdout(t, "generate", "-quiet", "-warnings-as-errors", "-config", path); err != nil {
+		t.Fatal(err)
+	}
+	out, err = runStdout(t, "indexes", "-table", "users", "-config", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "users\tidx_users_email\temail\tunique\tbtree\tlogin and signup lookup\n" +
+		"users\tidx_users_name\tname,email\tnon-unique\tbtree\tname search\n"
+	if out != want {
+		t.Errorf("indexes -table users =\n%q\nwant\n%q", out, want)
+	}
+
+	if _, err := runStdout(t, "indexes", "-table", "missing", "-config", path); exitCode(err) != exitUsage {
+		t.Errorf("indexes -table missing = %v, want exit code %d", err, exitUsage)
+	}
+}
+
+func TestIndexDescriptionForUnknownIndexWarns(t *testing.T) {
+	path := withSchema(t, testProject(t), describedSchema("login lookup"))
+	config, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = append(config, "index \"users\" \"idx_missing\" {\n  description = \"stale\"\n}\n"...)
+	if err := os.WriteFile(path, config, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = runStdout(t, "generate", "-quiet", "-warnings-as-errors", "-config", path)
+	if exitCode(err) != exitValidation {
+		t.Fatalf("Run() = %v, want the index-description warning to fail with exit code %d", err, exitValidation)
+	}
+}
+
+func TestIndexColumnList(t *testing.T) {
+	tests := []struct {
+		name string
+		idx  state.Index
+		want string
+	}{
+		{"single", state.Index{Columns: []string{"email"}}, "email"},
+		{"composite", state.Index{Columns: []string{"name", "email"}}, "name,email"},
+		{"prefix length", state.Index{Columns: []string{"email", "name"}, Lengths: map[string]int{"email": 191}}, "email(191),name"},
+		{"include", state.Index{Columns: []string{"email"}, Include: []string{"name", "bio"}}, "email include(name,bio)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indexColumnList(tt.idx); got != tt.want {
+				t.Errorf("indexColumnList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,15 +1,23 @@
 package main
 
 import (
-	"flag"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/akmalulginan/datara/internal/diff"
 	"github.com/akmalulginan/datara/internal/schema"
+	"github.com/akmalulginan/datara/internal/state"
 	"github.com/hashicorp/hcl/v2/hclsimple"
 )
 
@@ -17,11 +25,65 @@ import (
 type Config struct {
 	Schema struct {
 		Program []string `hcl:"program"`
+		// MigrationMarkers menentukan penanganan output berisi -- migrate:up/down
+		MigrationMarkers string `hcl:"migration_markers,optional"`
+		// KeepQuotedEscapes hanya membuang escape ANSI di luar quote
+		KeepQuotedEscapes bool `hcl:"keep_quoted_escapes,optional"`
 	} `hcl:"schema,block"`
 	Migration struct {
-		Dir    string `hcl:"dir"`
-		Format string `hcl:"format,optional"`
+		Dir               string   `hcl:"dir"`
+		Format            string   `hcl:"format,optional"`
+		Dialect           string   `hcl:"dialect,optional"`
+		Charset           string   `hcl:"charset,optional"`
+		Collation         string   `hcl:"collation,optional"`
+		Engine            string   `hcl:"engine,optional"`
+		Identity          string   `hcl:"identity,optional"`
+		IndexPlacement    string   `hcl:"index_placement,optional"`
+		BatchAlter        *bool    `hcl:"batch_alter,optional"`
+		Online            bool     `hcl:"online,optional"`
+		RowFormat         string   `hcl:"row_format,optional"`
+		TimestampFormat   string   `hcl:"timestamp_format,optional"`
+		TimestampUTC      *bool    `hcl:"timestamp_utc,optional"`
+		SensitivePatterns []string `hcl:"sensitive_patterns,optional"`
+		Pretty            bool     `hcl:"pretty,optional"`
+		ServerVersion     string   `hcl:"server_version,optional"`
+		BatchSeparator    string   `hcl:"batch_separator,optional"`
+		DiffIgnore        []string `hcl:"diff_ignore,optional"`
+		// FilePattern adalah regexp nama file yang dianggap migration; default-nya
+		// versi selebar timestamp_format diikuti .sql
+		FilePattern string `hcl:"file_pattern,optional"`
+		// BackfillBatchSize adalah jumlah baris per UPDATE backfill
+		// -expand-not-null (default 1000)
+		BackfillBatchSize int `hcl:"backfill_batch_size,optional"`
+		// RequireClassification mewajibkan class=... pada kolom baru
+		RequireClassification bool `hcl:"require_classification,optional"`
+		// DropCascade menambahkan CASCADE pada DROP TABLE
+		DropCascade bool `hcl:"drop_cascade,optional"`
+		// HistoryTable adalah template nama tabel history, mis. "{table}_history"
+		HistoryTable string `hcl:"history_table,optional"`
+		// SafeConstraints menambahkan FOREIGN KEY dan CHECK dengan NOT VALID
+		// lalu VALIDATE CONSTRAINT (Postgres)
+		SafeConstraints bool `hcl:"safe_constraints,optional"`
+		// Bookkeeping "embedded" menyimpan checksum dan snapshot di trailer
+		// setiap migration alih-alih di datara.sum, schema.json dan lainnya
+		Bookkeeping string `hcl:"bookkeeping,optional"`
+		// FKActionEquivalence adalah kelas aksi foreign key yang dianggap sama,
+		// mis. [["NO ACTION", "RESTRICT"]]; default-nya tergantung dialect
+		FKActionEquivalence [][]string `hcl:"fk_action_equivalence,optional"`
 	} `hcl:"migration,block"`
+	// RawSQL adalah SQL mentah per tabel, mis. raw_sql "users" { up = "..." down = "..." }
+	RawSQL []struct {
+		Table string `hcl:"table,label"`
+		Up    string `hcl:"up"`
+		Down  string `hcl:"down,optional"`
+	} `hcl:"raw_sql,block"`
+	// Indexes adalah deskripsi index, mis.
+	// index "users" "idx_users_email" { description = "login lookup" }
+	Indexes []struct {
+		Table       string `hcl:"table,label"`
+		Name        string `hcl:"name,label"`
+		Description string `hcl:"description"`
+	} `hcl:"index,block"`
 	Naming struct {
 		Table struct {
 			Plural    bool `hcl:"plural,optional"`
@@ -30,100 +92,1291 @@ type Config struct {
 		Column struct {
 			SnakeCase bool `hcl:"snake_case,optional"`
 		} `hcl:"column,block"`
+		// ColumnOrder mengatur urutan kolom, mis. ["id", "*", "created_at"]
+		ColumnOrder []string `hcl:"column_order,optional"`
+		// ColumnGroups menulis komentar "-- Grup" di antara kelompok kolom
+		// CREATE TABLE sesuai tag group=...
+		ColumnGroups bool `hcl:"column_groups,optional"`
 	} `hcl:"naming,block"`
+	// Seed mengatur datara seed; Values adalah ekspresi SQL per "tabel.kolom"
+	// yang menimpa tag seed=...
+	Seed *struct {
+		Values map[string]string `hcl:"values,optional"`
+	} `hcl:"seed,block"`
+	// Hooks adalah command yang dijalankan sebelum dan sesudah migration ditulis
+	Hooks *struct {
+		PreGenerate  []string `hcl:"pre_generate,optional"`
+		PostGenerate []string `hcl:"post_generate,optional"`
+		// BestEffort membuat post_generate yang gagal tidak membatalkan migration
+		BestEffort bool `hcl:"best_effort,optional"`
+	} `hcl:"hooks,block"`
+
+	// dir adalah direktori tempat path relatif di config di-resolve
+	dir string
+	// filePattern adalah migration.file_pattern yang sudah dikompilasi
+	filePattern *regexp.Regexp
 }
 
+var (
+	configPath       = "datara.hcl"
+	quiet            bool
+	cwdRelativePaths bool
+	strictSum        bool
+	strictRowSize    bool
+	strictTags       bool
+	warningsAsErrors bool
+	allowEmptySchema bool
+	timestamp        string
+	includeSensitive bool
+	planJSON         bool
+	noCache          bool
+
+	// Penanganan ADD COLUMN NOT NULL tanpa default dari
+	// -allow-not-null-without-default dan -expand-not-null
+	allowNotNull  bool
+	expandNotNull bool
+
+	// Override dari -schema, -output dan -format di atas datara.hcl
+	schemaOverride string
+	outputOverride string
+	formatOverride string
+
+	// Tampilan ringkasan perubahan dari -markdown dan -full
+	reportMarkdown bool
+	reportAll      bool
+
+	// debugBundle adalah path zip dari -debug-bundle
+	debugBundle string
+)
+
+// defaultTimestampFormat adalah format timestamp default pada nama file migration
+const defaultTimestampFormat = "20060102150405"
+
 func main() {
-	var cmd string
-	flag.StringVar(&cmd, "cmd", "diff", "Command to execute (diff)")
-	flag.Parse()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	switch cmd {
-	case "diff":
-		if err := generateDiff(); err != nil {
-			fmt.Printf("Error generating diff: %v\n", err)
-			os.Exit(1)
-		}
-	default:
-		fmt.Println("Unknown command. Available commands: diff")
-		os.Exit(1)
+	if err := RunContext(ctx, os.Args[1:]); err != nil {
+		os.Exit(reportError(err, jsonErrorsRequested(os.Args[1:])))
 	}
 }
 
-func generateDiff() error {
+func generateDiff(ctx context.Context, tables []string, syncOrder, twoPhase bool) error {
 	// 1. Baca konfigurasi
 	config, err := readConfig()
 	if err != nil {
 		return fmt.Errorf("failed to read config: %w", err)
 	}
+	// -two-phase selalu memakai safe constraints
+	if twoPhase {
+		config.Migration.SafeConstraints = true
+	}
 
 	// 2. Execute program untuk mendapatkan schema
-	executor := schema.NewExecutor(config.Schema.Program)
-	desiredSchema, err := executor.Execute()
+	var desiredSchema string
+	executor := newExecutor(config)
+	if err := executor.SetTables(tables); err != nil {
+		return &usageError{err}
+	}
+	if syncOrder {
+		executor.SetSyncOrder(config.Migration.Dir)
+	}
+	executor.SetTwoPhase(twoPhase)
+	if planJSON {
+		plan, err := executor.PlanContext(ctx)
+		if err != nil && !errors.Is(err, schema.ErrNoChanges) {
+			return fmt.Errorf("failed to execute schema program: %w", err)
+		}
+		if err := printPlanJSON(config, plan); err != nil {
+			return err
+		}
+		return reportWarnings(planWarnings(plan))
+	}
+	plan, err := executor.PlanContext(ctx)
+	if err == nil {
+		trace.recordPlan(config, plan)
+		if err := reportWarnings(plan.Warnings); err != nil {
+			return err
+		}
+	}
+	if (err == nil || errors.Is(err, schema.ErrNoChanges)) && !quiet {
+		if err := printOrderDrift(config, executor, plan); err != nil {
+			return err
+		}
+	}
+	if err == nil && len(plan.Up) > 0 {
+		report, reportErr := changeReport(config, plan)
+		if reportErr != nil {
+			return reportErr
+		}
+		infof("%s\n", report)
+	}
+	var hooks hookData
+	if err == nil && len(plan.Up) > 0 && config.Hooks != nil {
+		var hookErr error
+		if hooks, hookErr = newHookData(config, plan); hookErr != nil {
+			return hookErr
+		}
+		if hookErr := runHook(ctx, config, hookPreGenerate, config.Hooks.PreGenerate, hooks); hookErr != nil {
+			return hookErr
+		}
+	}
+	// Backup dipakai untuk membatalkan batch migration yang gagal ditulis
+	// dan, kecuali best_effort, jika hook post_generate gagal
+	var backup *generationBackup
+	if err == nil {
+		var backupErr error
+		if backup, backupErr = backupGeneration(config, executor); backupErr != nil {
+			return backupErr
+		}
+	}
+	if err == nil {
+		desiredSchema, err = executor.Apply(plan)
+	}
+	if errors.Is(err, schema.ErrNoChanges) {
+		// Jika tidak ada perubahan, keluar
+		infof("No changes detected\n")
+		return nil
+	}
+	if errors.Is(err, schema.ErrTableSelection) {
+		return &usageError{err}
+	}
 	if err != nil {
 		return fmt.Errorf("failed to execute schema program: %w", err)
 	}
 
-	// Jika tidak ada perubahan, keluar
-	if desiredSchema == "" {
-		fmt.Println("No changes detected")
+	// 3. Generate migration file; plan bisa terdiri dari beberapa langkah
+	steps, err := executor.Steps(plan, desiredSchema)
+	if err != nil {
+		if restoreErr := backup.restore(); restoreErr != nil {
+			return fmt.Errorf("failed to generate migration file: %w; restoring the schema snapshot also failed: %v", err, restoreErr)
+		}
+		return fmt.Errorf("failed to generate migration file: %w", err)
+	}
+	files, err := writeMigrations(config, steps, backup)
+	if err != nil {
+		return fmt.Errorf("failed to generate migration file: %w", err)
+	}
+	trace.recordMigration(files[0])
+	if config.Hooks != nil && len(config.Hooks.PostGenerate) > 0 {
+		if hooks.File, err = filepath.Abs(files[0]); err != nil {
+			return err
+		}
+		hookBackup := backup
+		if config.Hooks.BestEffort {
+			hookBackup = nil
+		}
+		if err := postGenerate(ctx, config, hooks, hookBackup, files); err != nil {
+			return err
+		}
+	}
+
+	infof("Generated new migration\n")
+	return nil
+}
+
+// errPendingChanges dikembalikan oleh check jika schema belum di-generate menjadi migration
+var errPendingChanges = errors.New("schema has pending changes")
+
+// printRangeMigration mencetak satu migration gabungan antara dua versi
+// migration ke stdout. Tidak ada file, snapshot atau datara.sum yang ditulis.
+func printRangeMigration(since, until string) error {
+	if since == "" {
+		return &usageError{errors.New("-until requires -since")}
+	}
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	migration, err := newExecutor(config).RangeMigration(config.Migration.Dir, since, until)
+	if errors.Is(err, schema.ErrNoChanges) {
+		infof("No changes between %s and %s\n", since, rangeEnd(until))
 		return nil
 	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("-- datara: consolidated changes from %s to %s\n%s\n", since, rangeEnd(until), migration)
+	return nil
+}
 
-	// 3. Generate migration file
-	if err := generateMigrationFile(desiredSchema, config.Migration.Dir); err != nil {
-		return fmt.Errorf("failed to generate migration file: %w", err)
+// rangeEnd mendeskripsikan -until untuk output, "latest" jika kosong
+func rangeEnd(until string) string {
+	if until == "" {
+		return "latest"
+	}
+	return until
+}
+
+// checkSchema membandingkan output schema program dengan snapshot tanpa menulis
+// apa pun, ditujukan untuk CI. Perubahan yang belum di-generate membuat check gagal.
+func checkSchema(ctx context.Context, github bool) error {
+	const fix = "run 'datara generate' locally and commit the generated migration"
+
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
 	}
 
-	fmt.Println("Generated new migration")
+	executor := newExecutor(config)
+	plan, err := executor.PlanContext(ctx)
+	if err == nil {
+		trace.recordPlan(config, plan)
+	}
+	if planJSON && (err == nil || errors.Is(err, schema.ErrNoChanges)) {
+		if err := printPlanJSON(config, plan); err != nil {
+			return err
+		}
+		if plan != nil && len(plan.Up) > 0 {
+			return errPendingChanges
+		}
+		return reportWarnings(planWarnings(plan))
+	}
+	if err == nil {
+		if err := reportWarnings(plan.Warnings); err != nil {
+			return err
+		}
+	}
+	if err == nil || errors.Is(err, schema.ErrNoChanges) {
+		if err := printDeprecations(config, executor); err != nil {
+			return err
+		}
+		if err := printOrderDrift(config, executor, plan); err != nil {
+			return err
+		}
+	}
+	if errors.Is(err, schema.ErrNoChanges) || (err == nil && len(plan.Up) == 0) {
+		infof("Schema is up to date\n")
+		return nil
+	}
+	if err != nil {
+		if github {
+			fmt.Printf("::error file=datara.hcl,title=datara check::%s\n", githubEscape(err.Error()))
+		}
+		return err
+	}
+
+	// Statement yang ditampilkan dirender ulang dengan kolom sensitif disamarkan
+	shown := plan.Up
+	if !includeSensitive {
+		redacted := diffConfig(config)
+		redacted.Redact = true
+		if shown, err = diff.NewGenerator(redacted).GenerateStatements(plan.Current, plan.Desired); err != nil {
+			return err
+		}
+	}
+
+	report, err := changeReport(config, plan)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n--- snapshot\n+++ schema program\n", report)
+	for _, stmt := range shown {
+		for _, line := range strings.Split(stmt, "\n") {
+			fmt.Printf("+ %s\n", line)
+		}
+	}
+	fmt.Printf("\n%d pending change(s)\nfix: %s\n", len(plan.Up), fix)
+
+	if github {
+		msg := fmt.Sprintf("Schema has %d pending change(s); %s.", len(plan.Up), fix)
+		fmt.Printf("::error file=datara.hcl,title=datara check::%s\n", githubEscape(msg))
+	}
+	return errPendingChanges
+}
+
+// printDeprecations mencetak kolom deprecated di snapshot beserta lamanya
+// sejak ditandai, agar kolom yang sudah melewati masa deprecation terlihat
+func printDeprecations(config *Config, executor *schema.Executor) error {
+	snapshot, err := executor.Snapshot()
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	deprecations, err := schema.DeprecatedColumns(config.Migration.Dir, snapshot)
+	if err != nil || len(deprecations) == 0 {
+		return err
+	}
+
+	fmt.Println("Deprecated columns:")
+	for _, d := range deprecations {
+		since := "no entry in " + schema.DeprecationFile
+		if !d.Since.IsZero() {
+			days := int(time.Since(d.Since).Hours() / 24)
+			since = fmt.Sprintf("%d day(s) ago in %s", days, d.File)
+		}
+		fmt.Printf("  %s.%s  deprecated %s\n", d.Table, d.Column, since)
+	}
+	fmt.Println()
 	return nil
 }
 
+// printOrderDrift mencetak tabel yang urutan kolom fisiknya berbeda dari
+// model. Drift tidak membuat migration; di MySQL urutannya bisa dikembalikan
+// dengan generate -sync-order. plan nil berarti tidak ada perubahan.
+func printOrderDrift(config *Config, executor *schema.Executor, plan *schema.Plan) error {
+	drifts, err := executor.OrderingDrift(config.Migration.Dir, plan)
+	if err != nil || len(drifts) == 0 {
+		return err
+	}
+
+	fmt.Println("Ordering drift (column order differs from the schema program):")
+	for _, d := range drifts {
+		fmt.Printf("  %s\n    physical: %s\n    model:    %s\n", d.Table, strings.Join(d.Physical, ", "), strings.Join(d.Model, ", "))
+	}
+	if diff.NewGenerator(diffConfig(config)).CanReorderColumns() {
+		fmt.Println("  run 'datara generate -sync-order' to reorder the columns")
+	} else {
+		fmt.Printf("  %s cannot reorder existing columns; reported only\n", config.Migration.Dialect)
+	}
+	fmt.Println()
+	return nil
+}
+
+// printPlanJSON mencetak perubahan plan sebagai diff.PlanDocument. plan nil
+// berarti tidak ada perubahan. Kolom sensitif disamarkan kecuali
+// -include-sensitive diset.
+func printPlanJSON(config *Config, plan *schema.Plan) error {
+	doc, err := planDocument(config, plan)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// planDocument membuat diff.PlanDocument dari plan; plan nil berarti tidak
+// ada perubahan. Kolom sensitif disamarkan kecuali -include-sensitive diset.
+func planDocument(config *Config, plan *schema.Plan) (*diff.PlanDocument, error) {
+	generator := diffConfig(config)
+	generator.Redact = !includeSensitive
+
+	if plan == nil || len(plan.Up) == 0 {
+		return &diff.PlanDocument{Version: diff.PlanVersion, Dialect: generator.Dialect, Changes: []diff.Change{}, Warnings: planWarnings(plan)}, nil
+	}
+	doc, err := diff.NewGenerator(generator).Plan(plan.Current, plan.Desired)
+	if err != nil {
+		return nil, err
+	}
+	// Peringatan directive dan raw_sql hanya ada di plan executor
+	doc.Warnings = plan.Warnings
+	return doc, nil
+}
+
+// planWarnings mengembalikan peringatan plan; plan nil tidak punya peringatan
+func planWarnings(plan *schema.Plan) state.Warnings {
+	if plan == nil {
+		return nil
+	}
+	return plan.Warnings
+}
+
+// changeReport merender ringkasan perubahan plan per tabel. Kolom sensitif
+// disamarkan kecuali -include-sensitive diset.
+func changeReport(config *Config, plan *schema.Plan) (string, error) {
+	generator := diffConfig(config)
+	generator.Redact = !includeSensitive
+	changes, err := diff.NewGenerator(generator).Changes(plan.Current, plan.Desired)
+	if err != nil {
+		return "", err
+	}
+	limit := diff.DefaultReportLimit
+	if reportAll {
+		limit = 0
+	}
+	return diff.Report(changes, diff.ReportOptions{Markdown: reportMarkdown, Limit: limit}), nil
+}
+
+// githubEscape meng-escape pesan untuk workflow command GitHub Actions
+func githubEscape(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// readConfig membaca config dari configPath. Path relatif di config di-resolve
+// terhadap direktori config, kecuali -cwd-relative-paths diset.
 func readConfig() (*Config, error) {
 	var config Config
-	if err := hclsimple.DecodeFile("datara.hcl", nil, &config); err != nil {
-		return nil, err
+	if err := hclsimple.DecodeFile(configPath, nil, &config); err != nil {
+		return nil, &configError{err}
+	}
+	// Hanya nilai dari datara.hcl yang dibatasi; -schema dan -output
+	// ditulis sendiri oleh pemanggil
+	if !allowOutsideRoot {
+		base := ""
+		if !cwdRelativePaths {
+			base = filepath.Dir(configPath)
+		}
+		if err := validateConfigPaths(&config, base); err != nil {
+			return nil, &configError{err}
+		}
+	}
+
+	if schemaOverride != "" && len(config.Schema.Program) > 0 {
+		config.Schema.Program[len(config.Schema.Program)-1] = schemaOverride
+	}
+	if outputOverride != "" {
+		config.Migration.Dir = outputOverride
+	}
+	if formatOverride != "" {
+		config.Migration.Format = formatOverride
+	}
+
+	if !cwdRelativePaths {
+		config.dir = filepath.Dir(configPath)
+	}
+	if config.Migration.Dir != "" && !filepath.IsAbs(config.Migration.Dir) {
+		config.Migration.Dir = filepath.Join(config.dir, config.Migration.Dir)
+	}
+	if config.Migration.TimestampFormat == "" {
+		config.Migration.TimestampFormat = defaultTimestampFormat
+	}
+	if err := validateTimestampFormat(config.Migration.TimestampFormat); err != nil {
+		return nil, &configError{err}
+	}
+	if config.Migration.FilePattern == "" {
+		config.Migration.FilePattern = defaultFilePattern(config.Migration.TimestampFormat)
+	}
+	pattern, err := regexp.Compile(config.Migration.FilePattern)
+	if err != nil {
+		return nil, &configError{fmt.Errorf("invalid migration.file_pattern %q: %w", config.Migration.FilePattern, err)}
+	}
+	config.filePattern = pattern
+	if err := validateServerVersion(&config); err != nil {
+		return nil, &configError{err}
+	}
+	if config.Migration.BackfillBatchSize < 0 {
+		return nil, &configError{fmt.Errorf("invalid migration.backfill_batch_size %d, it must be positive", config.Migration.BackfillBatchSize)}
+	}
+	switch config.Schema.MigrationMarkers {
+	case "", schema.MarkersStrip, schema.MarkersError:
+	default:
+		return nil, &configError{fmt.Errorf("invalid schema.migration_markers %q, use %q or %q",
+			config.Schema.MigrationMarkers, schema.MarkersStrip, schema.MarkersError)}
+	}
+	for _, class := range config.Migration.FKActionEquivalence {
+		for _, action := range class {
+			if !diff.IsForeignKeyAction(action) {
+				return nil, &configError{fmt.Errorf("invalid migration.fk_action_equivalence action %q, use CASCADE, RESTRICT, SET NULL, SET DEFAULT or NO ACTION", action)}
+			}
+		}
+	}
+	switch config.Migration.Bookkeeping {
+	case "", schema.BookkeepingFiles, schema.BookkeepingEmbedded:
+	default:
+		return nil, &configError{fmt.Errorf("invalid migration.bookkeeping %q, use %q or %q",
+			config.Migration.Bookkeeping, schema.BookkeepingFiles, schema.BookkeepingEmbedded)}
 	}
 
 	return &config, nil
 }
 
-func generateMigrationFile(sql, dir string) error {
+// validateTimestampFormat memastikan versi migration hanya berisi angka (dbmate
+// membaca versi dari digit di awal nama file) dan terurut secara leksikografis
+// sesuai waktu, sehingga urutan file sama dengan urutan pembuatannya
+func validateTimestampFormat(format string) error {
+	base := time.Date(2009, time.September, 9, 9, 9, 9, 0, time.UTC)
+	steps := []time.Time{
+		base,
+		base.Add(time.Second),
+		base.Add(time.Minute),
+		base.Add(time.Hour),
+		base.AddDate(0, 0, 1),
+		base.AddDate(0, 1, 0),
+		base.AddDate(1, 0, 0),
+		base.AddDate(1, 3, 1).Add(3 * time.Hour),
+		base.AddDate(10, 3, 22).Add(14 * time.Hour),
+	}
+
+	prev := ""
+	for _, t := range steps {
+		version := t.Format(format)
+		if version == "" || strings.Trim(version, "0123456789") != "" {
+			return fmt.Errorf("invalid migration.timestamp_format %q: it must produce digits only, got %q", format, version)
+		}
+		if prev != "" && len(version) != len(prev) {
+			return fmt.Errorf("invalid migration.timestamp_format %q: it must produce fixed-width versions", format)
+		}
+		if version < prev {
+			return fmt.Errorf("invalid migration.timestamp_format %q: %s does not sort after %s; order the layout from year down to seconds",
+				format, version, prev)
+		}
+		prev = version
+	}
+	return nil
+}
+
+// defaultFilePattern mengembalikan pola nama file migration untuk versi dari
+// format timestamp: jumlah digit yang sama di awal nama, diikuti .sql
+func defaultFilePattern(format string) string {
+	if format == defaultTimestampFormat {
+		return schema.DefaultMigrationPattern
+	}
+	return fmt.Sprintf(`^\d{%d}.*\.sql$`, len(time.Time{}.Format(format)))
+}
+
+// validateServerVersion memastikan migration.server_version bisa dibaca,
+// sesuai dengan dialect, dan mendukung collation default dari config
+func validateServerVersion(config *Config) error {
+	version, err := diff.ParseServerVersion(config.Migration.ServerVersion)
+	if err != nil {
+		return fmt.Errorf("invalid migration.server_version: %w", err)
+	}
+	if !version.Known() {
+		return nil
+	}
+	dialect := config.Migration.Dialect
+	if dialect == "" {
+		dialect = diff.DialectPostgres
+	}
+	if version.Dialect() != dialect {
+		return fmt.Errorf("migration.server_version %q does not match dialect %s", config.Migration.ServerVersion, dialect)
+	}
+	if strings.Contains(strings.ToLower(config.Migration.Collation), "_0900_") && !version.Supports(diff.FeatureCollation0900) {
+		return fmt.Errorf("migration.collation %s requires %s, which %s does not support",
+			config.Migration.Collation, diff.FeatureCollation0900, version)
+	}
+	return nil
+}
+
+// reportWarnings mencetak peringatan plan ke stderr sebagai "warning: ...",
+// kecuali dalam mode -quiet. Dengan -warnings-as-errors, adanya peringatan
+// menjadi warningsError.
+func reportWarnings(warnings state.Warnings) error {
+	if !quiet {
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+		}
+	}
+	if warningsAsErrors && len(warnings) > 0 {
+		return &warningsError{warnings}
+	}
+	return nil
+}
+
+// infof mencetak pesan informasi, kecuali dalam mode -quiet
+func infof(format string, args ...interface{}) {
+	if quiet {
+		return
+	}
+	if progress != nil {
+		progress.around(func() { fmt.Printf(format, args...) })
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// newExecutor membuat executor schema program dari config. Output program
+// di-cache kecuali -no-cache diset; dialect dan opsi naming ikut menjadi
+// bagian cache key karena memengaruhi pemrosesan setelahnya.
+func newExecutor(config *Config) *schema.Executor {
+	executor := schema.NewExecutor(config.Schema.Program, config.dir, diffConfig(config))
+	if len(config.RawSQL) > 0 {
+		raw := make(map[string]*state.RawDDL, len(config.RawSQL))
+		for _, block := range config.RawSQL {
+			raw[block.Table] = state.NewRawDDL(block.Up, block.Down)
+		}
+		executor.SetRawDDL(raw)
+	}
+	if len(config.Indexes) > 0 {
+		descriptions := make(map[string]map[string]string)
+		for _, block := range config.Indexes {
+			if descriptions[block.Table] == nil {
+				descriptions[block.Table] = make(map[string]string)
+			}
+			descriptions[block.Table][block.Name] = block.Description
+		}
+		executor.SetIndexDescriptions(descriptions)
+	}
+	if separator := batchSeparator(config); separator != "" {
+		executor.SetBatchSeparator(separator)
+	}
+	executor.SetAllowEmptySchema(allowEmptySchema)
+	if progress != nil {
+		executor.SetProgress(progress)
+	}
+	executor.SetHistoryTable(config.Migration.HistoryTable)
+	executor.SetMigrationPattern(config.filePattern)
+	if embeddedBookkeeping(config) {
+		executor.SetEmbeddedBookkeeping(config.Migration.Dir)
+	}
+	if config.Schema.MigrationMarkers != "" {
+		executor.SetMigrationMarkers(config.Schema.MigrationMarkers)
+	}
+	executor.SetSanitizeOptions(schema.SanitizeOptions{KeepQuotedEscapes: config.Schema.KeepQuotedEscapes})
+	if config.Migration.Pretty {
+		executor.SetPrettyFormat(schema.FormatOptions{UppercaseKeywords: true, MaxLineWidth: 80})
+	}
+	if trace != nil {
+		trace.configure(config)
+		executor.SetTracer(trace)
+	}
+	// Debug bundle butuh output mentah program, bukan output dari cache
+	if !noCache && trace == nil {
+		executor.EnableCache(
+			"dialect="+config.Migration.Dialect,
+			fmt.Sprintf("naming=%+v %+v", config.Naming.Table, config.Naming.Column),
+			fmt.Sprintf("keep_quoted_escapes=%t", config.Schema.KeepQuotedEscapes),
+		)
+	}
+	return executor
+}
+
+// batchSeparator mengembalikan migration.batch_separator. Default-nya GO untuk
+// mssql dan tidak ada untuk dialect lain; "none" menonaktifkannya.
+func batchSeparator(config *Config) string {
+	switch separator := config.Migration.BatchSeparator; {
+	case strings.EqualFold(separator, "none"):
+		return ""
+	case separator != "":
+		return separator
+	case config.Migration.Dialect == diff.DialectMSSQL:
+		return "GO"
+	}
+	return ""
+}
+
+// diffConfig membuat konfigurasi diff generator dari blok migration.
+// Dialect default adalah postgres, sesuai output gormschema di register.go.
+func diffConfig(config *Config) *diff.Config {
+	c := &diff.Config{
+		Dialect:           config.Migration.Dialect,
+		Charset:           config.Migration.Charset,
+		Collation:         config.Migration.Collation,
+		Engine:            config.Migration.Engine,
+		Identity:          config.Migration.Identity,
+		IndexPlacement:    config.Migration.IndexPlacement,
+		Online:            config.Migration.Online,
+		RowFormat:         config.Migration.RowFormat,
+		SensitivePatterns: config.Migration.SensitivePatterns,
+		Strict:            strictRowSize,
+		StrictTags:        strictTags,
+
+		RequireClassification: config.Migration.RequireClassification,
+		DiffIgnore:            config.Migration.DiffIgnore,
+		DropCascade:           config.Migration.DropCascade,
+		ColumnOrder:           config.Naming.ColumnOrder,
+		ColumnGroups:          config.Naming.ColumnGroups,
+		SafeConstraints:       config.Migration.SafeConstraints,
+
+		AllowNotNullWithoutDefault:  allowNotNull,
+		ExpandNotNull:               expandNotNull,
+		BackfillBatchSize:           config.Migration.BackfillBatchSize,
+		ForeignKeyActionEquivalence: config.Migration.FKActionEquivalence,
+	}
+	// Sudah divalidasi oleh readConfig
+	c.ServerVersion, _ = diff.ParseServerVersion(config.Migration.ServerVersion)
+	if c.Dialect == "" {
+		c.Dialect = diff.DialectPostgres
+	}
+	if c.Dialect == diff.DialectMySQL {
+		if c.Charset == "" {
+			c.Charset = "utf8mb4"
+		}
+		if c.Collation == "" {
+			c.Collation = "utf8mb4_unicode_ci"
+		}
+		if c.Engine == "" {
+			c.Engine = "InnoDB"
+		}
+		// Batching aktif secara default untuk MySQL
+		c.BatchAlter = config.Migration.BatchAlter == nil || *config.Migration.BatchAlter
+	}
+	return c
+}
+
+// embeddedBookkeeping mengecek apakah state disimpan di trailer migration
+func embeddedBookkeeping(config *Config) bool {
+	return config.Migration.Bookkeeping == schema.BookkeepingEmbedded
+}
+
+// writeMigrations menulis setiap langkah plan sebagai file migration dengan
+// timestamp berurutan, lalu mencatat datara.snapshots dan datara.sum sekali
+// untuk seluruh batch. Langkah setelah yang pertama wajib punya alasan; plan
+// yang menghasilkan beberapa file tanpa alasan ditolak sebelum apa pun
+// ditulis. Jika salah satu langkah gagal, semua file batch dihapus dan
+// snapshot serta bookkeeping dikembalikan dari backup.
+func writeMigrations(config *Config, steps []schema.MigrationStep, backup *generationBackup) ([]string, error) {
+	for i, step := range steps[1:] {
+		if step.Reason == "" {
+			return nil, fmt.Errorf("the plan produced %d migrations but step %d does not say why it is separate; refusing to write more than one migration", len(steps), i+2)
+		}
+	}
+	at, err := migrationTime(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	err = func() error {
+		dir := config.Migration.Dir
+		var entries []schema.JournalEntry
+		for i, step := range steps {
+			reportStage(diff.StageWrite, i, len(steps))
+			// Versi langkah sebelumnya sudah terpakai, jadi uniqueVersion
+			// memajukan at satu satuan timestamp_format untuk langkah ini
+			filename, err := writeMigrationFileAt(config, step.SQL, step.Name, at)
+			if err != nil {
+				return err
+			}
+			files = append(files, filename)
+			entries = append(entries, schema.JournalEntry{File: filepath.Base(filename), From: schema.StateHash(step.From), To: schema.StateHash(step.To)})
+		}
+		reportStage(diff.StageWrite, len(steps), len(steps))
+		if embeddedBookkeeping(config) {
+			return schema.RecordDeprecations(dir, files[0], at, steps[0].From, steps[0].To)
+		}
+		if err := schema.RecordJournal(dir, entries...); err != nil {
+			return err
+		}
+		if err := schema.RecordDeprecations(dir, files[0], at, steps[0].From, steps[0].To); err != nil {
+			return err
+		}
+		reportStage(diff.StageChecksum, 0, 1)
+		if err := syncSum(config); err != nil {
+			return err
+		}
+		reportStage(diff.StageChecksum, 1, 1)
+		return nil
+	}()
+	if err != nil {
+		if restoreErr := backup.restore(files...); restoreErr != nil {
+			return nil, fmt.Errorf("%w; removing the partial batch also failed: %v", err, restoreErr)
+		}
+		if len(files) > 0 {
+			infof("Removed the partial batch: %s\n", strings.Join(files, ", "))
+		}
+		return nil, err
+	}
+
+	if len(steps) > 1 {
+		infof("The plan was split into %d migrations:\n", len(steps))
+		for i, step := range steps {
+			reason := "schema changes"
+			if step.Reason != "" {
+				reason = step.Reason
+			}
+			infof("  %s: %s\n", files[i], reason)
+		}
+	}
+	return files, nil
+}
+
+// hashMigrations memverifikasi datara.sum, atau menyinkronkannya dengan file
+// migration di disk jika prune diset. datara.sum yang belum ada adalah error
+// tanpa prune. Dengan deep,
+// migration juga dijalankan ulang dan hash snapshot tiap langkah dicocokkan
+// dengan datara.snapshots. Pada mode embedded yang diperiksa adalah trailer,
+// dan prune menyegel migration manual yang belum punya trailer.
+func hashMigrations(prune, deep bool) error {
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	dir := config.Migration.Dir
+	if embeddedBookkeeping(config) {
+		if prune {
+			return sealMigrations(config)
+		}
+		if err := schema.VerifyTrailers(dir, config.filePattern, deep); err != nil {
+			return err
+		}
+		infof("All migration trailers are up to date\n")
+		return nil
+	}
+	if prune {
+		return syncSum(config)
+	}
+	// Tanpa -prune hash hanya memverifikasi; membuat datara.sum di sini
+	// membuat verify di CI selalu lolos pada checkout tanpa datara.sum
+	if _, err := os.Stat(filepath.Join(dir, schema.SumFile)); os.IsNotExist(err) {
+		return fmt.Errorf("%s missing, run 'datara hash -prune'", schema.SumFile)
+	}
+
+	if err := schema.VerifySum(dir, config.filePattern); err != nil {
+		return err
+	}
+	infof("%s is up to date\n", schema.SumFile)
+	if err := reportIgnoredFiles(config); err != nil {
+		return err
+	}
+	if !deep {
+		return nil
+	}
+
+	snapshot, err := newExecutor(config).Snapshot()
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if err := schema.VerifySnapshots(dir, config.filePattern, snapshot); err != nil {
+		return err
+	}
+	infof("%s matches the replayed migrations\n", schema.JournalFile)
+	return nil
+}
+
+// doctor melaporkan statement yang akan gagal jika semua migration dijalankan
+// berurutan, mis. setelah dua branch menambahkan kolom yang sama
+func doctor(fix bool) error {
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	dir := config.Migration.Dir
+	conflicts, err := schema.Doctor(dir, config.filePattern)
+	if err != nil {
+		return err
+	}
+
+	if fix && len(conflicts) > 0 {
+		fixed, err := schema.FixConflicts(dir, conflicts)
+		if err != nil {
+			return err
+		}
+		for _, name := range fixed {
+			fmt.Printf("Removed duplicate statements from %s\n", filepath.Join(dir, name))
+		}
+		if err := schema.RehashSum(dir, fixed...); err != nil {
+			return err
+		}
+		if conflicts, err = schema.Doctor(dir, config.filePattern); err != nil {
+			return err
+		}
+	}
+
+	if len(conflicts) == 0 {
+		infof("No conflicts found\n")
+		return nil
+	}
+	for _, c := range conflicts {
+		fmt.Printf("%s:%d: %s\n", filepath.Join(dir, c.File), c.Line, c.Problem)
+		fmt.Printf("  %s\n", strings.Join(strings.Fields(c.Statement), " "))
+		fmt.Printf("  suggestion: %s\n", c.Suggestion())
+	}
+	return fmt.Errorf("%d conflicting statement(s) found", len(conflicts))
+}
+
+// importMigrations membuat snapshot awal dengan menjalankan ulang migration
+// dbmate atau golang-migrate di from. File-nya tidak disalin, tetapi dicatat
+// di datara.sum direktori tersebut. Snapshot yang sudah berisi tabel hanya
+// ditimpa dengan -force.
+func importMigrations(from, runner string, force bool) error {
+	if from == "" {
+		return &usageError{errors.New("missing -from: the directory with the existing migrations")}
+	}
+	if runner != schema.RunnerDbmate && runner != schema.RunnerGolangMigrate {
+		return &usageError{fmt.Errorf("unknown -runner %q, use %s or %s", runner, schema.RunnerDbmate, schema.RunnerGolangMigrate)}
+	}
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	executor := newExecutor(config)
+	existing, err := executor.Snapshot()
+	if err != nil {
+		return err
+	}
+	if len(existing.Tables) > 0 && !force {
+		return fmt.Errorf("the schema snapshot already has %d table(s); pass -force to replace it", len(existing.Tables))
+	}
+
+	imported, err := schema.ImportMigrations(from, runner)
+	if err != nil {
+		return err
+	}
+	if len(imported.Files) == 0 {
+		return fmt.Errorf("no %s migrations found in %s", runner, from)
+	}
+	if err := executor.ImportSnapshot(imported.Schema); err != nil {
+		return &configError{err}
+	}
+	if err := schema.RecordSum(from); err != nil {
+		return err
+	}
+
+	for _, skipped := range imported.Skipped {
+		problem := "not interpreted"
+		if skipped.Problem != "" {
+			problem = skipped.Problem
+		}
+		fmt.Printf("%s: %s\n  %s\n", filepath.Join(from, skipped.Location), problem, strings.Join(strings.Fields(skipped.Statement), " "))
+	}
+	infof("Imported %d migration(s) with %d table(s) from %s\n", len(imported.Files), len(imported.Schema.Tables), from)
+	if len(imported.Skipped) > 0 {
+		infof("%d statement(s) were skipped; reconcile them with the schema program before the next generate\n", len(imported.Skipped))
+	}
+	return nil
+}
+
+// migrateState menulis ulang state di direktori migration ke format terbaru
+func migrateState() error {
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	applied, err := newExecutor(config).MigrateState()
+	for _, upgrade := range applied {
+		fmt.Println(upgrade)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		infof("State already uses format %s\n", state.FormatVersion)
+	}
+	return nil
+}
+
+// contract menjalankan "contract validate <file>" atau "contract schema"
+func contract(args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing subcommand: use 'contract validate <file.json>' or 'contract schema'")
+	}
+	switch args[0] {
+	case "schema":
+		out, err := json.MarshalIndent(schema.ContractSchema(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode contract schema: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	case "validate":
+		if len(args) != 2 {
+			return &usageError{errors.New("usage: datara contract validate <file.json>")}
+		}
+		data, err := os.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+		// Dibersihkan seperti output schema program, mis. BOM dari editor Windows
+		data = []byte(schema.SanitizeOutput(string(data), schema.SanitizeOptions{}))
+		var violations schema.ContractErrors
+		if err := schema.ValidateContract(data); errors.As(err, &violations) {
+			for _, v := range violations {
+				fmt.Printf("%s: %s\n", args[1], v)
+			}
+			return &summaryError{fmt.Sprintf("%s violates the schema contract (%d error(s))", args[1], len(violations)), violations}
+		}
+		infof("%s is a valid schema contract %s document\n", args[1], schema.ContractVersion)
+		return nil
+	}
+	return &usageError{fmt.Errorf("unknown contract subcommand %q: use validate or schema", args[0])}
+}
+
+// verifyDownMigrations memeriksa bahwa bagian down setiap migration
+// mengembalikan schema ke keadaan sebelum bagian up dijalankan
+func verifyDownMigrations() error {
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	dir := config.Migration.Dir
+	residues, err := schema.VerifyDown(dir, config.filePattern)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range residues {
+		status := "down does not revert up"
+		if r.Destructive {
+			status = "irreversible (marked destructive)"
+		} else {
+			failed++
+		}
+		fmt.Printf("%s: %s\n", filepath.Join(dir, r.File), status)
+		for _, d := range r.Differences {
+			fmt.Printf("  %s\n", d)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d migration(s) leave residue after down", failed)
+	}
+	infof("All down migrations revert their up migrations\n")
+	return nil
+}
+
+// verifyMigrations memastikan file migration tidak berubah sejak dicatat,
+// lewat datara.sum atau trailer pada mode embedded
+func verifyMigrations(config *Config) error {
+	if embeddedBookkeeping(config) {
+		return schema.VerifyTrailers(config.Migration.Dir, config.filePattern, false)
+	}
+	return schema.VerifySum(config.Migration.Dir, config.filePattern)
+}
+
+// sealMigrations menyegel migration manual tanpa trailer (mode embedded)
+func sealMigrations(config *Config) error {
+	dir := config.Migration.Dir
+	sealed, err := schema.SealMigrations(dir, config.filePattern)
+	if err != nil {
+		return err
+	}
+	for _, name := range sealed {
+		infof("Sealed %s\n", filepath.Join(dir, name))
+	}
+	return nil
+}
+
+// embedBookkeeping mengubah direktori migration mode files ke mode embedded
+func embedBookkeeping() error {
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if !embeddedBookkeeping(config) {
+		return &configError{fmt.Errorf("set migration.bookkeeping = %q in %s before running embed",
+			schema.BookkeepingEmbedded, configPath)}
+	}
+	sealed, err := newExecutor(config).EmbedBookkeeping()
+	if err != nil {
+		return err
+	}
+	for _, name := range sealed {
+		infof("Sealed %s\n", filepath.Join(config.Migration.Dir, name))
+	}
+	infof("Bookkeeping moved into the migration trailers; commit the removed files too\n")
+	return nil
+}
+
+// syncSum memperbarui datara.sum setelah file migration berubah
+func syncSum(config *Config) error {
+	pruned, added, err := schema.UpdateSum(config.Migration.Dir, config.filePattern, strictSum)
+	if err != nil {
+		return err
+	}
+	for _, name := range pruned {
+		fmt.Printf("Pruned deleted migration %s from %s\n", name, schema.SumFile)
+	}
+	for _, name := range added {
+		infof("Added %s to %s\n", name, schema.SumFile)
+	}
+	return reportIgnoredFiles(config)
+}
+
+// reportIgnoredFiles mencetak file *.sql di direktori migration yang tidak
+// dianggap migration sehingga tidak dicatat di datara.sum
+func reportIgnoredFiles(config *Config) error {
+	ignored, err := schema.IgnoredFiles(config.Migration.Dir, config.filePattern)
+	if err != nil {
+		return err
+	}
+	for _, name := range ignored {
+		infof("Ignoring %s: not a migration file (see migration.file_pattern)\n", name)
+	}
+	return nil
+}
+
+// writeMigrationFile menulis migration dengan nama {timestamp}[_{name}].sql
+func writeMigrationFile(config *Config, sql, name string) (string, error) {
+	now, err := migrationTime(config)
+	if err != nil {
+		return "", err
+	}
+	return writeMigrationFileAt(config, sql, name, now)
+}
+
+// writeMigrationFileAt sama dengan writeMigrationFile dengan timestamp at
+func writeMigrationFileAt(config *Config, sql, name string, at time.Time) (string, error) {
+	dir := config.Migration.Dir
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create migrations directory: %w", err)
+		return "", fmt.Errorf("failed to create migrations directory: %w", err)
 	}
 
-	timestamp := time.Now().Format("20060102150405")
-	filename := filepath.Join(dir, fmt.Sprintf("%s.sql", timestamp))
+	version, err := uniqueVersion(dir, at, config.Migration.TimestampFormat)
+	if err != nil {
+		return "", err
+	}
+	if name != "" {
+		version += "_" + name
+	}
+	filename := filepath.Join(dir, fmt.Sprintf("%s.sql", version))
 
 	// Tulis file langsung tanpa menambahkan marker
 	if err := os.WriteFile(filename, []byte(sql), 0644); err != nil {
-		return fmt.Errorf("failed to write migration file: %w", err)
+		return "", fmt.Errorf("failed to write migration file: %w", err)
 	}
 
-	fmt.Printf("Generated migration file: %s\n", filename)
-	return nil
+	infof("Generated migration file: %s\n", filename)
+	return filename, nil
 }
 
-// generateDownMigration menghasilkan SQL untuk rollback
-func generateDownMigration(upSQL string) string {
-	var drops []string
-	tablePattern := regexp.MustCompile(`CREATE TABLE "([^"]+)"`)
+// migrationTime mengembalikan waktu untuk nama file migration: -timestamp,
+// lalu SOURCE_DATE_EPOCH (detik Unix, UTC) agar hasil generate reproducible,
+// lalu waktu sekarang (UTC jika migration.timestamp_utc aktif)
+func migrationTime(config *Config) (time.Time, error) {
+	format := config.Migration.TimestampFormat
+	if timestamp != "" {
+		t, err := time.Parse(format, timestamp)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid -timestamp %q: use the layout %s", timestamp, format)
+		}
+		return t, nil
+	}
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", epoch, err)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	if utc := config.Migration.TimestampUTC; utc != nil && *utc {
+		return time.Now().UTC(), nil
+	}
+	return time.Now(), nil
+}
 
-	// Find all table names
-	matches := tablePattern.FindAllStringSubmatch(upSQL, -1)
-	for _, match := range matches {
-		if len(match) > 1 {
-			tableName := match[1]
-			drops = append(drops, fmt.Sprintf(`DROP TABLE IF EXISTS %q CASCADE;`, tableName))
+// uniqueVersion mengembalikan versi at dalam format, dimajukan satu satuan
+// terkecil format (lihat timestampUnit) selama sudah ada migration dengan
+// versi yang sama. Versi tetap selebar format dan terurut setelah migration
+// yang bertabrakan, termasuk yang bernama ({versi}_{nama}.sql).
+func uniqueVersion(dir string, at time.Time, format string) (string, error) {
+	unit := timestampUnit(format)
+	for {
+		version := at.Format(format)
+		exact, err := filepath.Glob(filepath.Join(dir, version+".sql"))
+		if err != nil {
+			return "", err
+		}
+		named, err := filepath.Glob(filepath.Join(dir, version+"_*.sql"))
+		if err != nil {
+			return "", err
 		}
+		if len(exact) == 0 && len(named) == 0 {
+			return version, nil
+		}
+		at = at.Add(unit)
 	}
+}
 
-	// Reverse order untuk drop tables (handle dependencies)
-	for i := len(drops)/2 - 1; i >= 0; i-- {
-		opp := len(drops) - 1 - i
-		drops[i], drops[opp] = drops[opp], drops[i]
+// timestampUnit mengembalikan satuan waktu terkecil yang mengubah versi dari
+// format: detik untuk format default, menit untuk "200601021504", dan
+// seterusnya. Format yang lebih kasar dari hari dimajukan per hari.
+func timestampUnit(format string) time.Duration {
+	base := time.Date(2009, time.September, 9, 9, 9, 9, 0, time.UTC)
+	for _, unit := range []time.Duration{time.Second, time.Minute, time.Hour} {
+		if base.Add(unit).Format(format) != base.Format(format) {
+			return unit
+		}
+	}
+	return 24 * time.Hour
+}
+
+// manualMigrationMarker menandai migration yang ditulis tangan. Diff engine
+// hanya membandingkan snapshot schema, sehingga isi file ini tidak pernah
+// direkonsiliasi atau di-revert oleh migration yang di-generate berikutnya.
+const manualMigrationMarker = "-- datara:manual"
+
+var migrationNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// newMigration membuat migration kosong untuk perubahan manual (backfill data,
+// tweak index) lalu membukanya di $EDITOR jika berjalan secara interaktif
+func newMigration(name string, empty bool) error {
+	if !migrationNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid migration name %q: use lowercase letters, digits and underscores", name)
+	}
+
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	content := fmt.Sprintf("%s\n-- migrate:up\n\n\n-- migrate:down\n\n", manualMigrationMarker)
+	filename, err := writeMigrationFile(config, content, name)
+	if err != nil {
+		return err
 	}
 
-	return strings.Join(drops, "\n")
+	// Checksum dicatat segera agar hash dan check tetap lolos, lalu dicatat
+	// ulang setelah file selesai diedit
+	record := syncSum
+	if embeddedBookkeeping(config) {
+		record = sealMigrations
+	}
+	if err := record(config); err != nil {
+		return err
+	}
+	editor := os.Getenv("EDITOR")
+	if empty || editor == "" || !isInteractive() {
+		return nil
+	}
+
+	cmd := exec.Command(editor, filename)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor exited with error: %w", err)
+	}
+	return schema.RehashSum(config.Migration.Dir, filepath.Base(filename))
+}
+
+// configTemplate adalah isi datara.hcl yang dibuat oleh init
+const configTemplate = `// Schema configuration
+schema {
+  program = [
+    "go",
+    "run",
+    "./main/register.go"
+  ]
+}
+
+// Migration settings
+migration {
+  dir = "migrations"
+  dialect = "%s"
+  timestamp_utc = true
+}
+
+// Table naming strategy
+naming {
+  table {
+    plural = true
+    snake_case = true
+  }
+  column {
+    snake_case = true
+  }
+}
+`
+
+// initProject membuat datara.hcl dan direktori migration. Config yang sudah
+// ada tidak ditimpa.
+func initProject(dialect string) error {
+	switch dialect {
+	case diff.DialectPostgres, diff.DialectMySQL, diff.DialectMSSQL, diff.DialectCockroach:
+	default:
+		return fmt.Errorf("unsupported dialect %q: use postgres, mysql, mssql or cockroach", dialect)
+	}
+	if _, err := os.Stat(configPath); err == nil {
+		return fmt.Errorf("%s already exists", configPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configTemplate, dialect)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+	dir := filepath.Join(filepath.Dir(configPath), "migrations")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create migration directory: %w", err)
+	}
+	infof("Created %s and %s\n", configPath, dir)
+	return nil
+}
+
+// isInteractive mengecek apakah stdin terhubung ke terminal
+func isInteractive() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
 }
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/schema"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// testConfig mengembalikan config dengan direktori migration sementara
+func testConfig(t *testing.T, format string) *Config {
+	t.Helper()
+	config := &Config{}
+	config.Migration.Dir = t.TempDir()
+	config.Migration.TimestampFormat = format
+	return config
+}
+
+// writeFiles membuat file kosong dengan nama names di dir
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWriteMigrationFileFixedClock(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		timestamp string
+		epoch     string
+		existing  []string
+		migration string
+		want      string
+	}{
+		{
+			name:      "pinned timestamp",
+			format:    defaultTimestampFormat,
+			timestamp: "20300101000000",
+			want:      "20300101000000.sql",
+		},
+		{
+			name:   "SOURCE_DATE_EPOCH",
+			format: defaultTimestampFormat,
+			epoch:  "1893456000",
+			want:   "20300101000000.sql",
+		},
+		{
+			name:      "named migration",
+			format:    defaultTimestampFormat,
+			timestamp: "20300101000000",
+			migration: "backfill",
+			want:      "20300101000000_backfill.sql",
+		},
+		{
+			name:      "collision advances one second",
+			format:    defaultTimestampFormat,
+			timestamp: "20300101000000",
+			existing:  []string{"20300101000000.sql"},
+			want:      "20300101000001.sql",
+		},
+		{
+			name:      "collision with a named migration",
+			format:    defaultTimestampFormat,
+			timestamp: "20300101000000",
+			existing:  []string{"20300101000000_backfill_x.sql", "20300101000001.sql"},
+			want:      "20300101000002.sql",
+		},
+		{
+			name:      "minute format advances one minute",
+			format:    "200601021504",
+			timestamp: "203001010000",
+			existing:  []string{"203001010000.sql"},
+			want:      "203001010001.sql",
+		},
+		{
+			name:      "day format advances one day",
+			format:    "20060102",
+			timestamp: "20301231",
+			existing:  []string{"20301231_init.sql"},
+			want:      "20310101.sql",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags()
+			defer resetFlags()
+			quiet = true
+			timestamp = tt.timestamp
+			t.Setenv("SOURCE_DATE_EPOCH", tt.epoch)
+			config := testConfig(t, tt.format)
+			writeFiles(t, config.Migration.Dir, tt.existing...)
+
+			filename, err := writeMigrationFile(config, "-- migrate:up\n", tt.migration)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := filepath.Base(filename); got != tt.want {
+				t.Errorf("writeMigrationFile() = %s, want %s", got, tt.want)
+			}
+
+			// File baru selalu terurut setelah migration yang sudah ada
+			names := append(append([]string(nil), tt.existing...), tt.want)
+			sorted := append([]string(nil), names...)
+			sort.Strings(sorted)
+			if sorted[len(sorted)-1] != tt.want {
+				t.Errorf("%s sorts before %v", tt.want, sorted[len(sorted)-1])
+			}
+		})
+	}
+}
+
+func TestWriteMigrationFileRepeatable(t *testing.T) {
+	resetFlags()
+	defer resetFlags()
+	quiet = true
+	timestamp = "20300101000000"
+
+	var got []string
+	for i := 0; i < 2; i++ {
+		config := testConfig(t, defaultTimestampFormat)
+		filename, err := writeMigrationFile(config, "-- migrate:up\n", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, filepath.Base(filename))
+	}
+	if got[0] != got[1] {
+		t.Errorf("the same clock produced %s and %s", got[0], got[1])
+	}
+}
+
+func TestWriteMigrationsStepsSortInOrder(t *testing.T) {
+	for _, format := range []string{defaultTimestampFormat, "200601021504"} {
+		t.Run(format, func(t *testing.T) {
+			resetFlags()
+			defer resetFlags()
+			quiet = true
+			timestamp = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC).Format(format)
+			config := testConfig(t, format)
+			snapshot := &state.SchemaState{Tables: map[string]state.Table{}}
+			steps := []schema.MigrationStep{
+				{SQL: "-- migrate:up\n", From: snapshot, To: snapshot},
+				{Name: "validate_constraints", Reason: "test", SQL: "-- migrate:up\n", From: snapshot, To: snapshot},
+			}
+			backup, err := backupGeneration(config, schema.NewExecutor(nil, config.Migration.Dir, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			files, err := writeMigrations(config, steps, backup)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 2 {
+				t.Fatalf("writeMigrations() wrote %v, want 2 files", files)
+			}
+			first, second := filepath.Base(files[0]), filepath.Base(files[1])
+			if !(first < second) {
+				t.Errorf("%s sorts before %s", second, first)
+			}
+			if len(first) != len(timestamp)+len(".sql") {
+				t.Errorf("%s is not a fixed-width version of %s", first, format)
+			}
+		})
+	}
+}
+
+// testProject menulis datara.hcl dengan direktori migration "migrations" di
+// direktori sementara dan mengembalikan path config-nya
+func testProject(t *testing.T, migrations ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	config := `schema {
+  program = ["true"]
+}
+migration {
+  dir     = "migrations"
+  dialect = "postgres"
+}
+naming {
+  table {}
+  column {}
+}
+`
+	path := filepath.Join(dir, "datara.hcl")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "migrations"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range migrations {
+		content := "-- migrate:up\nCREATE TABLE t (id INT);\n\n-- migrate:down\nDROP TABLE t;\n"
+		if err := os.WriteFile(filepath.Join(dir, "migrations", name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestHashMissingSum(t *testing.T) {
+	config := testProject(t, "20300101000000.sql")
+	sum := filepath.Join(filepath.Dir(config), "migrations", schema.SumFile)
+
+	err := Run([]string{"hash", "-quiet", "-config", config})
+	if err == nil || !strings.Contains(err.Error(), "datara hash -prune") {
+		t.Fatalf("hash without datara.sum: err = %v, want a hint to run 'datara hash -prune'", err)
+	}
+	if _, err := os.Stat(sum); !os.IsNotExist(err) {
+		t.Fatalf("hash without -prune wrote %s", schema.SumFile)
+	}
+
+	if err := Run([]string{"hash", "-prune", "-quiet", "-config", config}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run([]string{"hash", "-quiet", "-config", config}); err != nil {
+		t.Fatalf("hash after -prune: %v", err)
+	}
+}
+
+func TestNewEmptyRecordsChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		embedded bool
+	}{
+		{"files", false},
+		{"embedded", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testProject(t)
+			if tt.embedded {
+				content, err := os.ReadFile(config)
+				if err != nil {
+					t.Fatal(err)
+				}
+				content = []byte(strings.Replace(string(content), `dialect = "postgres"`, "dialect = \"postgres\"\n  bookkeeping = \"embedded\"", 1))
+				if err := os.WriteFile(config, content, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			dir := filepath.Join(filepath.Dir(config), "migrations")
+			if _, err := runStdout(t, "new", "-empty", "-quiet", "-config", config, "backfill_user_slugs"); err != nil {
+				t.Fatal(err)
+			}
+			if files, _ := filepath.Glob(filepath.Join(dir, "*_backfill_user_slugs.sql")); len(files) != 1 {
+				t.Fatalf("migrations = %v, want one backfill_user_slugs migration", files)
+			}
+
+			if tt.embedded {
+				if err := schema.VerifyTrailers(dir, nil, false); err != nil {
+					t.Errorf("VerifyTrailers() after new -empty: %v", err)
+				}
+			} else if err := schema.VerifySum(dir, nil); err != nil {
+				t.Errorf("VerifySum() after new -empty: %v", err)
+			}
+			if _, err := runStdout(t, "hash", "-quiet", "-config", config); err != nil {
+				t.Errorf("hash after new -empty: %v", err)
+			}
+		})
+	}
+}
+
+func TestBatchSeparatorDefault(t *testing.T) {
+	tests := []struct {
+		dialect, separator, want string
+	}{
+		{diff.DialectMSSQL, "", "GO"},
+		{diff.DialectMSSQL, "none", ""},
+		{diff.DialectMSSQL, "go", "go"},
+		{diff.DialectPostgres, "", ""},
+		{diff.DialectPostgres, "GO", "GO"},
+	}
+	for _, tt := range tests {
+		config := &Config{}
+		config.Migration.Dialect, config.Migration.BatchSeparator = tt.dialect, tt.separator
+		if got := batchSeparator(config); got != tt.want {
+			t.Errorf("batchSeparator(%s, %q) = %q, want %q", tt.dialect, tt.separator, got, tt.want)
+		}
+	}
+}
+
+func TestDoctorFixRemovesDuplicates(t *testing.T) {
+	path := testProject(t)
+	dir := filepath.Join(filepath.Dir(path), "migrations")
+	writeMigration := func(name, up string) {
+		t.Helper()
+		content := "-- migrate:up\n" + up + "\n-- migrate:down\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Dua branch sama-sama menambahkan users.email
+	writeMigration("20240101000000_users.sql", "CREATE TABLE users (id INT);\n\nALTER TABLE users ADD COLUMN email VARCHAR(100);\n")
+	writeMigration("20240102000000_email_index.sql", "ALTER TABLE users ADD COLUMN email VARCHAR(100);\n\nCREATE INDEX idx_users_email ON users (email);\n")
+	if _, _, err := schema.UpdateSum(dir, nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runStdout(t, "doctor", "-config", path)
+	if err == nil || !strings.Contains(out, "20240102000000_email_index.sql:2: ") || !strings.Contains(out, "duplicates 20240101000000_users.sql:4") {
+		t.Fatalf("doctor = %q, %v, want the duplicate in the later migration", out, err)
+	}
+
+	out, err = runStdout(t, "doctor", "-fix", "-config", path)
+	if err != nil {
+		t.Fatalf("doctor -fix = %q, %v", out, err)
+	}
+	if want := "Removed duplicate statements from " + filepath.Join(dir, "20240102000000_email_index.sql") + "\nNo conflicts found\n"; out != want {
+		t.Errorf("doctor -fix = %q, want %q", out, want)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "20240102000000_email_index.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "-- migrate:up\nCREATE INDEX idx_users_email ON users (email);\n\n-- migrate:down\n"; string(content) != want {
+		t.Errorf("fixed migration =\n%q\nwant\n%q", content, want)
+	}
+	if err := schema.VerifySum(dir, nil); err != nil {
+		t.Errorf("VerifySum after doctor -fix: %v", err)
+	}
+}
+
+func TestVerifyDownReportsIrreversibleMigrations(t *testing.T) {
+	path := testProject(t)
+	dir := filepath.Join(filepath.Dir(path), "migrations")
+	migrations := map[string]string{
+		"20240101000000_users.sql": "-- migrate:up\nCREATE TABLE users (id INT);\n\n-- migrate:down\nDROP TABLE users;\n",
+		"20240102000000_email.sql": "-- migrate:up\nALTER TABLE users ADD COLUMN email VARCHAR(100);\n\n-- migrate:down\n",
+	}
+	for name, content := range migrations {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, err := runStdout(t, "doctor", "-verify-down", "-config", path)
+	want := filepath.Join(dir, "20240102000000_email.sql") + ": down does not revert up\n" +
+		"  users.email: unexpected column VARCHAR(100)\n"
+	if err == nil || out != want {
+		t.Errorf("doctor -verify-down = %q, %v, want %q and an error", out, err, want)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/akmalulginan/datara/internal/diff"
+)
+
+// maskSQL mencetak SQL anonimisasi untuk kolom ber-tag mask=... pada
+// snapshot schema: UPDATE per tabel, atau SELECT per tabel jika selects
+// diset (untuk tool dump yang menerima query sendiri)
+func maskSQL(selects bool) error {
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	snapshot, err := newExecutor(config).Snapshot()
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	generator := diff.NewGenerator(diffConfig(config))
+	build := generator.MaskUpdates
+	if selects {
+		build = generator.MaskSelects
+	}
+	statements, err := build(snapshot)
+	if err != nil {
+		return err
+	}
+	if len(statements) == 0 {
+		infof("No masked columns; add db:\"mask=email|hash|null\" to the columns to anonymize\n")
+		return nil
+	}
+	for _, statement := range statements {
+		fmt.Println(statement)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/akmalulginan/datara"
+)
+
+func TestMaskSQLCommand(t *testing.T) {
+	path := withSchema(t, testProject(t), datara.NewSchema().Table(datara.NewTable("users").
+		Column("id", datara.BigInt().PrimaryKey()).
+		Column("email", datara.Varchar(100).Mask("email")).
+		Column("phone", datara.Varchar(20).Mask("null"))))
+	if out, err := runStdout(t, "mask-sql", "-config", path); err != nil || out != "No masked columns; add db:\"mask=email|hash|null\" to the columns to anonymize\n" {
+		t.Fatalf("mask-sql before generate = %q, %v, want a note that nothing is masked", out, err)
+	}
+	if _, err := runStdout(t, "generate", "-quiet", "-config", path); err != nil {
+		t.Fatal(err)
+	}
+
+	email := `LEFT(regexp_replace("email", '^[^@]*', md5("email"::text)), 100)`
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"updates", []string{"mask-sql", "-config", path},
+			`UPDATE "users" SET "email" = ` + email + `, "phone" = NULL;` + "\n"},
+		{"selects", []string{"mask-sql", "-select", "-config", path},
+			`SELECT "id", ` + email + ` AS "email", NULL AS "phone" FROM "users";` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runStdout(t, tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tt.want {
+				t.Errorf("output =\n%q\nwant\n%q", out, tt.want)
+			}
+		})
+	}
+}
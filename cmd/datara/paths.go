@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// allowOutsideRoot mengizinkan path di config yang keluar dari direktori config
+var allowOutsideRoot bool
+
+// validateConfigPaths memastikan migration.dir dan path di schema.program
+// tetap berada di dalam direktori datara.hcl. Nilai ini berasal dari file
+// yang di-commit ke repo, sehingga "../../etc" atau path absolut tidak boleh
+// diam-diam dibuat dan ditulisi. base adalah direktori tempat path relatif
+// di-resolve.
+func validateConfigPaths(config *Config, base string) error {
+	root, err := realPath(filepath.Dir(configPath))
+	if err != nil {
+		return err
+	}
+	if config.Migration.Dir != "" {
+		if err := checkInsideRoot("migration.dir", root, base, config.Migration.Dir); err != nil {
+			return err
+		}
+	}
+	// Argumen pertama adalah executable, mis. "go" atau "python3". Argumen
+	// lain yang bukan flag diperlakukan sebagai path; kata seperti "run" atau
+	// import path tidak pernah keluar dari root karena tidak ada di disk.
+	for i, arg := range config.Schema.Program {
+		if i == 0 || strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if err := checkInsideRoot(fmt.Sprintf("schema.program[%d]", i), root, base, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkInsideRoot mengecek bahwa path, setelah di-resolve terhadap base dan
+// symlink-nya diikuti, berada di dalam root
+func checkInsideRoot(key, root, base, path string) error {
+	resolved := path
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(base, resolved)
+	}
+	real, err := realPath(resolved)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s resolves to %s, outside the config directory %s; pass -allow-outside-root to allow it", key, real, root)
+	}
+	return nil
+}
+
+// realPath mengembalikan path absolut dengan symlink diikuti. Bagian path
+// yang belum ada (mis. direktori migration baru) ditambahkan apa adanya ke
+// leluhur terdekat yang sudah ada.
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", path, err)
+	}
+	var missing []string
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		real, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				real = filepath.Join(real, missing[i])
+			}
+			return real, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) || filepath.Dir(dir) == dir {
+			return "", fmt.Errorf("resolving %s: %w", path, err)
+		}
+		missing = append(missing, filepath.Base(dir))
+	}
+}
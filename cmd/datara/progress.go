@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// progress menampilkan tahap yang sedang berjalan di stderr; nil jika stderr
+// bukan terminal atau dalam mode -quiet
+var progress *progressLine
+
+// progressLine menulis satu baris status yang ditimpa setiap laporan
+// (mis. "diffing 120/400") dan dihapus saat tahapnya selesai. Log yang ditulis
+// lewat Write atau infof menghapus baris status lebih dulu lalu menggambarnya
+// ulang, agar keduanya tidak tercampur.
+type progressLine struct {
+	mu   sync.Mutex
+	out  io.Writer
+	line string
+}
+
+// OnStage mengimplementasikan schema.Progress
+func (p *progressLine) OnStage(name string, current, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.line = ""
+	if current < total {
+		p.line = fmt.Sprintf("%s %d/%d", name, current, total)
+	}
+	fmt.Fprintf(p.out, "\r\033[K%s", p.line)
+}
+
+func (p *progressLine) Write(b []byte) (n int, err error) {
+	p.around(func() { n, err = p.out.Write(b) })
+	return n, err
+}
+
+// around menjalankan fn, yang menulis ke terminal, tanpa baris status
+func (p *progressLine) around(fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.line != "" {
+		fmt.Fprint(p.out, "\r\033[K")
+	}
+	fn()
+	if p.line != "" {
+		fmt.Fprint(p.out, p.line)
+	}
+}
+
+// newProgress mengembalikan progressLine untuk stderr jika stderr adalah terminal
+func newProgress() *progressLine {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressLine{out: os.Stderr}
+}
+
+// reportStage melaporkan tahap yang dijalankan CLI sendiri, mis. menulis file
+func reportStage(name string, current, total int) {
+	if progress != nil {
+		progress.OnStage(name, current, total)
+	}
+}
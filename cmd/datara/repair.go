@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/akmalulginan/datara/internal/schema"
+)
+
+// repairFromDump mencetak statement yang membuat database dari dump sama
+// dengan schema program. Statement destruktif dipisah di bagian akhir, dan
+// tidak ada file yang ditulis ke direktori migration.
+func repairFromDump(ctx context.Context, dumpPath string, schemas []string) error {
+	if dumpPath == "" {
+		return &usageError{errors.New("-target-dump is required")}
+	}
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	content, err := os.ReadFile(dumpPath)
+	if err != nil {
+		return fmt.Errorf("failed to read dump: %w", err)
+	}
+	if len(schemas) == 0 {
+		schemas = schema.DefaultDumpSchemas(config.Migration.Dialect)
+	}
+	dump, err := schema.ParseDump(string(content), schemas)
+	if err != nil {
+		return err
+	}
+
+	repair, err := newExecutor(config).RepairContext(ctx, dump)
+	if err != nil {
+		return err
+	}
+	if err := reportWarnings(repair.Warnings); err != nil {
+		return err
+	}
+	if !quiet && len(repair.Ignored) > 0 {
+		fmt.Printf("-- datara: left untouched, not managed by datara:\n")
+		for _, ignored := range repair.Ignored {
+			fmt.Printf("--   %s\n", ignored)
+		}
+		fmt.Println()
+	}
+	if len(repair.Statements) == 0 && len(repair.Destructive) == 0 {
+		infof("%s already matches the schema program\n", dumpPath)
+		return nil
+	}
+	for _, statement := range repair.Statements {
+		fmt.Printf("%s\n\n", statement)
+	}
+	if len(repair.Destructive) > 0 {
+		fmt.Printf("-- datara: destructive, these drop tables, columns or data; review before running\n\n")
+		for _, statement := range repair.Destructive {
+			fmt.Printf("%s\n\n", statement)
+		}
+	}
+	return nil
+}
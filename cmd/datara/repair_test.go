@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara"
+	"github.com/akmalulginan/datara/internal/schema"
+)
+
+// driftedDump adalah pg_dump dari database users yang kehilangan kolom name
+// dan NOT NULL email, punya kolom legacy, serta objek yang tidak dikelola
+const driftedDump = `CREATE FUNCTION public.touch() RETURNS trigger LANGUAGE plpgsql AS $$ BEGIN RETURN NEW; END $$;
+
+CREATE TABLE public.users (
+    id bigint NOT NULL,
+    email character varying(100),
+    legacy text
+);
+
+ALTER TABLE ONLY public.users ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+
+CREATE TABLE public.audit_log (id integer);
+
+CREATE TABLE reporting.daily (id integer);
+`
+
+func TestRepairFromDump(t *testing.T) {
+	path := withSchema(t, testProject(t), datara.NewSchema().Table(datara.NewTable("users").
+		Column("id", datara.BigInt().PrimaryKey()).
+		Column("email", datara.Varchar(100).NotNull()).
+		Column("name", datara.Varchar(50))))
+	if _, err := runStdout(t, "generate", "-quiet", "-config", path); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Dir(path)
+	bookkeeping := func() []byte {
+		t.Helper()
+		var b bytes.Buffer
+		for _, name := range []string{schema.SumFile, "schema.json", "schema_hash"} {
+			content, err := os.ReadFile(filepath.Join(dir, "migrations", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.Write(content)
+		}
+		return b.Bytes()
+	}
+	before := bookkeeping()
+
+	dump := filepath.Join(dir, "dump.sql")
+	if err := os.WriteFile(dump, []byte(driftedDump), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runStdout(t, "repair", "-quiet", "-target-dump", dump, "-config", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Primary key users_pkey dari database sama dengan primary key schema
+	// program, sehingga tidak di-drop dan dibuat ulang
+	want := "ALTER TABLE \"users\" ALTER COLUMN \"email\" SET NOT NULL;\n\n" +
+		"ALTER TABLE \"users\" ADD COLUMN \"name\" varchar(50);\n\n" +
+		"-- datara: destructive, these drop tables, columns or data; review before running\n\n" +
+		"ALTER TABLE \"users\" DROP COLUMN \"legacy\";\n\n"
+	if out != want {
+		t.Errorf("repair =\n%s\nwant\n%s", out, want)
+	}
+	if !bytes.Equal(bookkeeping(), before) {
+		t.Error("repair changed datara.sum or the snapshot")
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "migrations", "*.sql")); len(files) != 1 {
+		t.Errorf("repair wrote migrations: %v", files)
+	}
+
+	// Dump setelah statement repair dijalankan sudah sesuai schema program
+	repaired := driftedDump + "\n" + out
+	if err := os.WriteFile(dump, []byte(repaired), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runStdout(t, "repair", "-target-dump", dump, "-config", path); err != nil || !strings.HasSuffix(out, dump+" already matches the schema program\n") {
+		t.Errorf("repair of the repaired dump = %q, %v, want it to match", out, err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara"
+)
+
+// withProgram mengganti schema.program project test dengan skrip shell
+// schema.sh berisi script
+func withProgram(t *testing.T, path, script string) string {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "schema.sh"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = []byte(strings.Replace(string(config), `program = ["true"]`, `program = ["/bin/sh", "schema.sh"]`, 1))
+	if err := os.WriteFile(path, config, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// withSchema membuat schema program yang mencetak dokumen Schema JSON dari b
+func withSchema(t *testing.T, path string, b *datara.SchemaBuilder) string {
+	t.Helper()
+	var doc bytes.Buffer
+	if err := b.WriteJSON(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "schema.json"), doc.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return withProgram(t, path, "cat schema.json\n")
+}
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		// args membuat project test jika perlu dan mengembalikan argumen Run
+		args    func(t *testing.T) []string
+		want    int
+		wantErr string
+	}{
+		{
+			name: "legacy help",
+			args: func(t *testing.T) []string { return []string{"-h"} },
+			want: 0,
+		},
+		{
+			name: "legacy table flag",
+			args: func(t *testing.T) []string {
+				return []string{"-config", testProject(t), "-quiet", "-cmd", "nope", "-table", "users"}
+			},
+			want:    exitUsage,
+			wantErr: `unknown command "nope"`,
+		},
+		{
+			name:    "unknown command",
+			args:    func(t *testing.T) []string { return []string{"nope"} },
+			want:    exitUsage,
+			wantErr: `unknown command "nope"`,
+		},
+		{
+			name:    "unknown flag",
+			args:    func(t *testing.T) []string { return []string{"hash", "-no-such-flag"} },
+			want:    exitUsage,
+			wantErr: "flag provided but not defined",
+		},
+		{
+			name: "missing config",
+			args: func(t *testing.T) []string {
+				return []string{"hash", "-config", filepath.Join(t.TempDir(), "datara.hcl")}
+			},
+			want: exitConfig,
+		},
+		{
+			name: "schema program fails",
+			args: func(t *testing.T) []string {
+				return []string{"generate", "-quiet", "-config", withProgram(t, testProject(t), "echo boom >&2; exit 3\n")}
+			},
+			want: exitSchemaProgram,
+		},
+		{
+			name: "empty schema",
+			args: func(t *testing.T) []string {
+				return []string{"generate", "-quiet", "-config", withProgram(t, testProject(t), "exit 0\n")}
+			},
+			want: exitSchemaProgram,
+		},
+		{
+			name: "pending changes",
+			args: func(t *testing.T) []string {
+				return []string{"check", "-quiet", "-config", withProgram(t, testProject(t), "echo 'CREATE TABLE users (id INT);'\n")}
+			},
+			want: exitPendingChanges,
+		},
+		{
+			name: "pending changes from Schema JSON",
+			args: func(t *testing.T) []string {
+				users := datara.NewTable("users").Column("id", datara.BigInt().PrimaryKey())
+				return []string{"check", "-quiet", "-config", withSchema(t, testProject(t), datara.NewSchema().Table(users))}
+			},
+			want: exitPendingChanges,
+		},
+		{
+			name: "checksum mismatch",
+			args: func(t *testing.T) []string {
+				path := testProject(t, "20300101000000.sql")
+				sum := filepath.Join(filepath.Dir(path), "migrations", "datara.sum")
+				if err := os.WriteFile(sum, []byte("h1:global\n20300101000000.sql h1:tampered\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return []string{"hash", "-quiet", "-config", path}
+			},
+			want: exitChecksumMismatch,
+		},
+		{
+			name: "contract violation",
+			args: func(t *testing.T) []string {
+				return []string{"generate", "-quiet", "-config", withProgram(t, testProject(t), `echo '{"version": 1, "tables": {"users": {"name": "users", "columns": {"id": {"name": "id"}}}}}'`+"\n")}
+			},
+			want: exitContract,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer resetFlags()
+			err := Run(tt.args(t))
+			if tt.want == 0 {
+				if err != nil {
+					t.Fatalf("Run() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Run() succeeded, want exit code %d", tt.want)
+			}
+			if got := exitCode(err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", err, got, tt.want)
+			}
+			if tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/diff"
+)
+
+// seedDir adalah subdirektori migration untuk file seed. File di dalamnya
+// bukan migration, jadi tidak dicatat di datara.sum.
+const seedDir = "seed"
+
+// seedData menulis INSERT data palsu untuk tabel di snapshot schema ke
+// migrations/seed/<tabel>.sql, atau ke stdout jika stdout diset
+func seedData(tables []string, rows int, seed int64, stdout bool) error {
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	snapshot, err := newExecutor(config).Snapshot()
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	opts := diff.SeedOptions{Tables: tables, Rows: rows, Seed: seed}
+	if config.Seed != nil {
+		opts.Values = config.Seed.Values
+	}
+	statements, err := diff.NewGenerator(diffConfig(config)).Seed(snapshot, opts)
+	if err != nil {
+		return err
+	}
+	if len(statements) == 0 {
+		infof("No tables to seed; run datara generate first\n")
+		return nil
+	}
+
+	content := fmt.Sprintf("-- Generated by datara seed -rows %d -seed %d\n\n%s\n", rows, seed, strings.Join(statements, "\n\n"))
+	if stdout {
+		fmt.Print(content)
+		return nil
+	}
+
+	name := "all"
+	if len(tables) > 0 {
+		name = strings.Join(tables, "_")
+	}
+	dir := filepath.Join(config.Migration.Dir, seedDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create seed directory: %w", err)
+	}
+	filename := filepath.Join(dir, name+".sql")
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write seed file: %w", err)
+	}
+	infof("Generated seed file: %s\n", filename)
+	return nil
+}
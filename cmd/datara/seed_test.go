@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSeedCommand(t *testing.T) {
+	path := withSchema(t, testProject(t), describedSchema("login lookup"))
+	if _, err := runStdout(t, "generate", "-quiet", "-config", path); err != nil {
+		t.Fatal(err)
+	}
+	seed := func(n string) string {
+		t.Helper()
+		out, err := runStdout(t, "seed", "-stdout", "-rows", "3", "-seed", n, "-config", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	first := seed("42")
+	if !strings.HasPrefix(first, "-- Generated by datara seed -rows 3 -seed 42\n") || !strings.Contains(first, "INSERT INTO \"users\"") {
+		t.Fatalf("seed output:\n%s\nwant the header and INSERT INTO \"users\"", first)
+	}
+	if again := seed("42"); again != first {
+		t.Errorf("-seed 42 differs between runs:\n%s\n---\n%s", first, again)
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/schema"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// serveShutdownTimeout adalah batas waktu request yang sedang berjalan saat
+// server dihentikan
+const serveShutdownTimeout = 5 * time.Second
+
+// schemaServer melayani snapshot dan perubahan pending sebagai JSON. Semua
+// endpoint read-only: tidak ada yang menulis snapshot atau migration.
+type schemaServer struct {
+	config       *Config
+	executor     *schema.Executor
+	allowRefresh bool
+
+	// refreshMu memastikan hanya satu refresh yang menjalankan program schema
+	refreshMu sync.Mutex
+
+	mu       sync.RWMutex
+	snapshot *state.SchemaState
+	hash     string
+	plan     *diff.PlanDocument
+	planErr  error
+}
+
+// migrationEntry adalah satu file migration di GET /migrations
+type migrationEntry struct {
+	Name     string `json:"name"`
+	Checksum string `json:"checksum"`
+}
+
+// migrationsDocument adalah isi datara.sum di GET /migrations
+type migrationsDocument struct {
+	Global   string           `json:"global"`
+	Verified bool             `json:"verified"`
+	Error    string           `json:"error,omitempty"`
+	Files    []migrationEntry `json:"files"`
+}
+
+// serve menjalankan HTTP server sampai ctx dibatalkan (SIGINT/SIGTERM).
+// Program schema dijalankan sekali saat start untuk GET /diff, dan lagi pada
+// POST /refresh jika allowRefresh diset.
+func serve(ctx context.Context, addr string, allowRefresh bool) error {
+	config, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	s := &schemaServer{config: config, executor: newExecutor(config), allowRefresh: allowRefresh}
+	if err := s.refresh(ctx); err != nil {
+		if s.snapshot == nil {
+			return err
+		}
+		// Snapshot tetap dilayani meskipun program schema gagal
+		log.Printf("Warning: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/schema", s.handleSchema)
+	mux.HandleFunc("/tables/", s.handleTable)
+	mux.HandleFunc("/diff", s.handleDiff)
+	mux.HandleFunc("/migrations", s.handleMigrations)
+	mux.HandleFunc("/refresh", s.handleRefresh)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.ListenAndServe()
+	}()
+	infof("Serving schema on %s\n", addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+	infof("Server stopped\n")
+	return nil
+}
+
+// refresh membaca ulang snapshot dan menjalankan program schema untuk GET /diff.
+// Snapshot tetap diperbarui jika program schema gagal.
+func (s *schemaServer) refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	snapshot, err := s.executor.Snapshot()
+	if err != nil {
+		return fmt.Errorf("failed to load schema snapshot: %w", err)
+	}
+	// /schema dan /tables disamarkan seperti /diff, kecuali -include-sensitive
+	if !includeSensitive {
+		snapshot = diff.NewGenerator(diffConfig(s.config)).RedactState(snapshot)
+	}
+	plan, planErr := s.executor.PlanContext(ctx)
+	if errors.Is(planErr, schema.ErrNoChanges) {
+		plan, planErr = nil, nil
+	}
+	var doc *diff.PlanDocument
+	if planErr == nil {
+		doc, planErr = planDocument(s.config, plan)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot, s.hash = snapshot, s.executor.SnapshotHash()
+	s.plan, s.planErr = doc, planErr
+	if planErr != nil {
+		return fmt.Errorf("failed to plan schema changes: %w", planErr)
+	}
+	return nil
+}
+
+// handleSchema melayani GET /schema
+func (s *schemaServer) handleSchema(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	s.mu.RLock()
+	snapshot, hash := s.snapshot, s.hash
+	s.mu.RUnlock()
+	writeJSON(w, r, hash, snapshot)
+}
+
+// handleTable melayani GET /tables/{name}
+func (s *schemaServer) handleTable(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/tables/")
+	s.mu.RLock()
+	table, ok := s.snapshot.Tables[name]
+	hash := s.hash
+	s.mu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown table %q", name))
+		return
+	}
+	writeJSON(w, r, hash, table)
+}
+
+// handleDiff melayani GET /diff: perubahan pending dari refresh terakhir
+func (s *schemaServer) handleDiff(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	s.mu.RLock()
+	plan, planErr := s.plan, s.planErr
+	s.mu.RUnlock()
+	if planErr != nil {
+		writeError(w, http.StatusInternalServerError, planErr.Error())
+		return
+	}
+	writeJSON(w, r, "", plan)
+}
+
+// handleMigrations melayani GET /migrations: isi datara.sum dan hasil verifikasinya
+func (s *schemaServer) handleMigrations(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	dir := s.config.Migration.Dir
+	sum, err := schema.ReadSum(dir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	doc := migrationsDocument{Global: sum.Global, Verified: true, Files: []migrationEntry{}}
+	if err := verifyMigrations(s.config); err != nil {
+		doc.Verified, doc.Error = false, err.Error()
+	}
+	for name, checksum := range sum.Files {
+		doc.Files = append(doc.Files, migrationEntry{Name: name, Checksum: checksum})
+	}
+	sort.Slice(doc.Files, func(i, j int) bool { return doc.Files[i].Name < doc.Files[j].Name })
+	writeJSON(w, r, "", doc)
+}
+
+// handleRefresh melayani POST /refresh; hanya aktif dengan -allow-refresh
+// karena menjalankan program schema
+func (s *schemaServer) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	if !s.allowRefresh {
+		writeError(w, http.StatusForbidden, "refresh is disabled; start datara serve with -allow-refresh")
+		return
+	}
+	if err := s.refresh(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.mu.RLock()
+	plan := s.plan
+	s.mu.RUnlock()
+	writeJSON(w, r, "", plan)
+}
+
+// allowMethod menolak request dengan method selain method
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method || (method == http.MethodGet && r.Method == http.MethodHead) {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+	return false
+}
+
+// writeJSON menulis value sebagai JSON dengan ETag. ETag dibentuk dari hash
+// schema dan path request; tanpa hash, ETag dihitung dari isi response.
+// Request dengan If-None-Match yang sama dijawab 304.
+func writeJSON(w http.ResponseWriter, r *http.Request, hash string, value interface{}) {
+	body, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to encode response: %v", err))
+		return
+	}
+	key := string(body)
+	if hash != "" {
+		key = hash + " " + r.URL.Path
+	}
+	sum := sha256.Sum256([]byte(key))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if match := r.Header.Get("If-None-Match"); match == etag || match == "*" {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
+
+// writeError menulis error sebagai {"error": "..."}
+func writeError(w http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// sensitiveSnapshot adalah snapshot dengan default rahasia pada kolom sensitif
+var sensitiveSnapshot = &state.SchemaState{Tables: map[string]state.Table{
+	"users": {
+		Name: "users",
+		Columns: map[string]state.Column{
+			"id":           {Name: "id", Type: "INT"},
+			"api_token":    {Name: "api_token", Type: "VARCHAR(64)", DefaultValue: &state.DefaultValue{Kind: state.DefaultString, Value: "hunter2"}},
+			"display_name": {Name: "display_name", Type: "VARCHAR(64)", DefaultValue: &state.DefaultValue{Kind: state.DefaultString, Value: "anonymous"}},
+		},
+	},
+}}
+
+func TestServeRedactsSnapshot(t *testing.T) {
+	tests := []struct {
+		name             string
+		includeSensitive bool
+		path             string
+		handler          func(s *schemaServer) http.HandlerFunc
+		wantSecret       bool
+	}{
+		{"schema", false, "/schema", func(s *schemaServer) http.HandlerFunc { return s.handleSchema }, false},
+		{"table", false, "/tables/users", func(s *schemaServer) http.HandlerFunc { return s.handleTable }, false},
+		{"schema include sensitive", true, "/schema", func(s *schemaServer) http.HandlerFunc { return s.handleSchema }, true},
+		{"table include sensitive", true, "/tables/users", func(s *schemaServer) http.HandlerFunc { return s.handleTable }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags()
+			defer resetFlags()
+			quiet = true
+			configPath = testProject(t)
+			includeSensitive = tt.includeSensitive
+			config, err := readConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			executor := newExecutor(config)
+			if err := executor.ImportSnapshot(sensitiveSnapshot); err != nil {
+				t.Fatal(err)
+			}
+			s := &schemaServer{config: config, executor: executor}
+			// Program schema "true" tidak mencetak apa pun, jadi hanya
+			// snapshot yang dimuat
+			s.refresh(context.Background())
+
+			rec := httptest.NewRecorder()
+			tt.handler(s)(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d: %s", tt.path, rec.Code, rec.Body)
+			}
+			body := rec.Body.String()
+			if got := strings.Contains(body, "hunter2"); got != tt.wantSecret {
+				t.Errorf("GET %s contains the sensitive default = %v, want %v:\n%s", tt.path, got, tt.wantSecret, body)
+			}
+			if !strings.Contains(body, "anonymous") {
+				t.Errorf("GET %s redacted a column that is not sensitive:\n%s", tt.path, body)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ schema {
 migration {
   dir = "migrations"
   format = "sql"
+  timestamp_utc = true
 }
 
 // Table naming strategy
@@ -0,0 +1,190 @@
+// Package dataratest berisi helper untuk mengunci DDL yang dihasilkan datara
+// dari model di test suite aplikasi:
+//
+//	func TestUserSchema(t *testing.T) {
+//		sql := dataratest.SQL(t, "postgres", &User{})
+//		dataratest.Golden(t, sql, "testdata/users.sql")
+//	}
+//
+// Jalankan "go test -update" untuk menulis ulang file golden.
+package dataratest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"ariga.io/atlas-provider-gorm/gormschema"
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/schema"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+var update = flag.Bool("update", false, "update datara golden files")
+
+// gormschema menyimpan statement dari setiap Load secara kumulatif, sehingga
+// output Load sebelumnya dipotong agar setiap SQL hanya berisi model miliknya
+var (
+	loadMu    sync.Mutex
+	loadedSQL string
+)
+
+// SQL merender model GORM menjadi DDL untuk dialect (mysql atau postgres)
+// melalui pipeline yang sama dengan CLI: gormschema, parser, lalu diff generator.
+func SQL(t testing.TB, dialect string, models ...interface{}) string {
+	t.Helper()
+
+	loadMu.Lock()
+	out, err := gormschema.New(dialect).Load(models...)
+	if err == nil {
+		full := out
+		out = strings.TrimPrefix(out, loadedSQL)
+		loadedSQL = full
+	}
+	loadMu.Unlock()
+	if err != nil {
+		t.Fatalf("dataratest: failed to load models: %v", err)
+	}
+
+	desired, err := schema.ParseSQL(schema.SanitizeOutput(out, schema.SanitizeOptions{}))
+	if err != nil {
+		t.Fatalf("dataratest: failed to parse model schema: %v", err)
+	}
+	statements, err := diff.NewGenerator(config(dialect)).GenerateStatements(state.NewSchemaState(), desired)
+	if err != nil {
+		t.Fatalf("dataratest: failed to generate SQL: %v", err)
+	}
+	return strings.Join(statements, "\n\n") + "\n"
+}
+
+// config mengembalikan konfigurasi generator dengan default yang sama dengan CLI
+func config(dialect string) *diff.Config {
+	if dialect == diff.DialectMySQL {
+		return &diff.Config{
+			Dialect:    diff.DialectMySQL,
+			Charset:    "utf8mb4",
+			Collation:  "utf8mb4_unicode_ci",
+			Engine:     "InnoDB",
+			BatchAlter: true,
+		}
+	}
+	return &diff.Config{Dialect: dialect}
+}
+
+// AssertSchemaEqual membandingkan dua DDL secara struktural dan melaporkan
+// perbedaan per tabel dan per kolom, bukan satu perbandingan string besar
+func AssertSchemaEqual(t testing.TB, want, got string) {
+	t.Helper()
+
+	differences, err := Diff(want, got)
+	if err != nil {
+		t.Fatalf("dataratest: %v", err)
+	}
+	if len(differences) > 0 {
+		t.Errorf("schema mismatch:\n  %s", strings.Join(differences, "\n  "))
+	}
+}
+
+// Golden membandingkan sql dengan isi file golden di path. Dengan flag -update
+// file ditulis ulang. Perbedaan dilaporkan per tabel dan per kolom jika kedua
+// DDL bisa dibaca, atau per baris jika hanya format teksnya yang berbeda.
+func Golden(t testing.TB, sql, path string) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("dataratest: failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(sql), 0644); err != nil {
+			t.Fatalf("dataratest: failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("dataratest: golden file %s does not exist, run 'go test -update' to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("dataratest: failed to read golden file: %v", err)
+	}
+	want := string(content)
+	if want == sql {
+		return
+	}
+
+	if differences, err := Diff(want, sql); err == nil && len(differences) > 0 {
+		t.Errorf("schema differs from %s (run 'go test -update' to accept):\n  %s", path, strings.Join(differences, "\n  "))
+		return
+	}
+	t.Errorf("SQL differs from %s (run 'go test -update' to accept):\n%s", path, lineDiff(want, sql))
+}
+
+// Diff mengembalikan perbedaan struktural antara dua DDL dalam bentuk yang
+// mudah dibaca, mis. "users.email: type varchar(100), want varchar(255)".
+// Keduanya dibersihkan dengan aturan yang sama dengan output schema program,
+// sehingga file golden dengan CRLF atau BOM tetap sebanding.
+func Diff(want, got string) ([]string, error) {
+	want = schema.SanitizeOutput(want, schema.SanitizeOptions{})
+	got = schema.SanitizeOutput(got, schema.SanitizeOptions{})
+	wantSchema, err := schema.ParseSQL(want)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse expected schema: %w", err)
+	}
+	gotSchema, err := schema.ParseSQL(got)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse actual schema: %w", err)
+	}
+
+	differences := state.Compare(wantSchema, gotSchema)
+	for _, name := range sortedNames(wantSchema.Tables) {
+		gotTable, ok := gotSchema.Tables[name]
+		if !ok {
+			continue
+		}
+		wantTable := wantSchema.Tables[name]
+		for _, column := range sortedNames(wantTable.Columns) {
+			want := wantTable.Columns[column].Position
+			if got, ok := gotTable.Columns[column]; ok && want != got.Position {
+				differences = append(differences, fmt.Sprintf("%s.%s: position %d, want %d", name, column, got.Position, want))
+			}
+		}
+	}
+	return differences, nil
+}
+
+// lineDiff menampilkan baris yang berbeda antara want dan got
+func lineDiff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	var b strings.Builder
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			fmt.Fprintf(&b, "  line %d:\n    - %s\n    + %s\n", i+1, w, g)
+		}
+	}
+	return b.String()
+}
+
+// sortedNames mengembalikan key map secara berurutan
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
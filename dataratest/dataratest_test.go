@@ -0,0 +1,122 @@
+package dataratest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recorder menangkap laporan Errorf/Fatalf dari helper agar pesannya bisa
+// diperiksa tanpa menggagalkan test yang memanggilnya
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+// fatal dipakai recorder.Fatalf untuk menghentikan helper seperti t.FailNow
+type fatal struct{}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	panic(fatal{})
+}
+
+// record menjalankan fn dengan recorder dan mengembalikan pesan yang dilaporkan
+func record(t *testing.T, fn func(tb testing.TB)) []string {
+	t.Helper()
+	r := &recorder{TB: t}
+	func() {
+		defer func() {
+			if v := recover(); v != nil {
+				if _, ok := v.(fatal); !ok {
+					panic(v)
+				}
+			}
+		}()
+		fn(r)
+	}()
+	return r.errors
+}
+
+const usersDDL = `CREATE TABLE "users" (
+  "id" bigint NOT NULL,
+  "email" varchar(255) NOT NULL,
+  PRIMARY KEY ("id")
+);
+`
+
+func TestAssertSchemaEqual(t *testing.T) {
+	// Format berbeda tetapi struktur sama tidak dilaporkan
+	reformatted := strings.ReplaceAll(usersDDL, "\n", "\r\n")
+	if got := record(t, func(tb testing.TB) { AssertSchemaEqual(tb, usersDDL, reformatted) }); len(got) != 0 {
+		t.Errorf("equal schemas reported %q", got)
+	}
+
+	changed := strings.Replace(usersDDL, "varchar(255)", "varchar(100)", 1)
+	got := record(t, func(tb testing.TB) { AssertSchemaEqual(tb, usersDDL, changed) })
+	want := []string{"schema mismatch:\n  users.email: type varchar(100), want varchar(255)"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("AssertSchemaEqual reported\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "users.sql")
+
+	t.Run("missing", func(t *testing.T) {
+		got := record(t, func(tb testing.TB) { Golden(tb, usersDDL, path) })
+		want := "dataratest: golden file " + path + " does not exist, run 'go test -update' to create it"
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("Golden reported %q, want %q", got, want)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		*update = true
+		defer func() { *update = false }()
+		if got := record(t, func(tb testing.TB) { Golden(tb, usersDDL, path) }); len(got) != 0 {
+			t.Fatalf("Golden -update reported %q", got)
+		}
+		if content, err := os.ReadFile(path); err != nil || string(content) != usersDDL {
+			t.Errorf("golden file = %q, %v, want %q", content, err, usersDDL)
+		}
+	})
+
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{"match", usersDDL, nil},
+		{"column change", strings.Replace(usersDDL, "varchar(255)", "varchar(100)", 1), []string{
+			"schema differs from " + path + " (run 'go test -update' to accept):\n  users.email: type varchar(100), want varchar(255)",
+		}},
+		{"formatting only", strings.Replace(usersDDL, "  ", "    ", -1), []string{
+			"SQL differs from " + path + " (run 'go test -update' to accept):\n" +
+				"  line 2:\n    - " + `  "id" bigint NOT NULL,` + "\n    + " + `    "id" bigint NOT NULL,` + "\n" +
+				"  line 3:\n    - " + `  "email" varchar(255) NOT NULL,` + "\n    + " + `    "email" varchar(255) NOT NULL,` + "\n" +
+				"  line 4:\n    - " + `  PRIMARY KEY ("id")` + "\n    + " + `    PRIMARY KEY ("id")` + "\n",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := record(t, func(tb testing.TB) { Golden(tb, tt.sql, path) })
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Golden reported\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+
+	// Tanpa -update file golden tidak pernah ditulis ulang
+	if content, _ := os.ReadFile(path); string(content) != usersDDL {
+		t.Errorf("golden file changed without -update:\n%s", content)
+	}
+}
@@ -0,0 +1,14 @@
+// Package datara berisi fungsi datara yang bisa dipakai langsung dari kode Go.
+package datara
+
+import "github.com/akmalulginan/datara/internal/schema"
+
+// FormatOptions mengatur gaya FormatSQL
+type FormatOptions = schema.FormatOptions
+
+// FormatSQL merapikan SQL agar mudah dibaca: setiap statement dipisah baris
+// kosong, definisi CREATE TABLE ditulis satu per baris, dan daftar kolom index
+// yang panjang dipecah sesuai opts
+func FormatSQL(sql string, opts FormatOptions) string {
+	return schema.FormatSQL(sql, opts)
+}
@@ -0,0 +1,265 @@
+// Package applier menjalankan migration yang di-generate datara terhadap
+// database lewat database/sql. Versi yang sudah dijalankan beserta hash
+// file-nya dicatat di tabel datara_migrations.
+package applier
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/schema"
+)
+
+// Table adalah tabel yang mencatat migration yang sudah dijalankan. Namanya
+// sengaja bukan schema_migrations: tabel itu milik dbmate (hanya kolom
+// version), dan migration datara juga ditulis dalam format dbmate.
+const Table = "datara_migrations"
+
+// HashMismatchError dikembalikan jika file migration yang sudah dijalankan
+// berubah sejak dicatat di datara_migrations
+type HashMismatchError struct {
+	File     string
+	Recorded string
+	Got      string
+}
+
+func (e *HashMismatchError) Error() string {
+	return fmt.Sprintf("migration %s was changed after it was applied (recorded hash %s, file hash %s)", e.File, e.Recorded, e.Got)
+}
+
+// Applier menjalankan migration di satu direktori terhadap satu database
+type Applier struct {
+	db        *sql.DB
+	dialect   string
+	dir       string
+	separator string
+	pattern   *regexp.Regexp
+}
+
+// New membuat Applier untuk migration di dir. dialect menentukan placeholder,
+// DDL tabel datara_migrations, dan apakah migration dijalankan di dalam
+// transaksi (tidak untuk MySQL, karena DDL-nya selalu auto-commit).
+func New(db *sql.DB, dialect, dir string) *Applier {
+	return &Applier{db: db, dialect: dialect, dir: dir}
+}
+
+// SetBatchSeparator membuang baris batch separator (mis. GO) dari migration
+// sebelum statement dijalankan
+func (a *Applier) SetBatchSeparator(separator string) {
+	a.separator = separator
+}
+
+// SetFilePattern mengatur pola nama file migration (migration.file_pattern);
+// nil berarti schema.DefaultMigrationPattern
+func (a *Applier) SetFilePattern(pattern *regexp.Regexp) {
+	a.pattern = pattern
+}
+
+// Driver mengembalikan nama driver database/sql untuk dialect
+func Driver(dialect string) (string, error) {
+	switch dialect {
+	case diff.DialectPostgres, diff.DialectCockroach:
+		return "pgx", nil
+	case diff.DialectMySQL:
+		return "mysql", nil
+	case diff.DialectMSSQL:
+		return "sqlserver", nil
+	}
+	return "", fmt.Errorf("unsupported dialect %q", dialect)
+}
+
+// Up menjalankan semua migration yang belum tercatat, berurutan menurut versi,
+// lalu mengembalikan nama file yang dijalankan. Hash setiap migration yang
+// sudah tercatat diperiksa lebih dulu; jika ada yang berubah, tidak ada
+// migration yang dijalankan.
+func (a *Applier) Up(ctx context.Context) ([]string, error) {
+	migrations, applied, err := a.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []schema.MigrationFile
+	for _, m := range migrations {
+		hash, ok := applied[m.Version]
+		if !ok {
+			pending = append(pending, m)
+			continue
+		}
+		if hash != m.Hash {
+			return nil, &HashMismatchError{File: m.Name, Recorded: hash, Got: m.Hash}
+		}
+	}
+
+	var done []string
+	for _, m := range pending {
+		err := a.run(ctx, m.Up, func(exec execer) error {
+			_, err := exec.ExecContext(ctx, a.insertSQL(), m.Version, m.Hash)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("applying %s: %w", m.Name, err)
+		}
+		done = append(done, m.Name)
+	}
+	return done, nil
+}
+
+// Down membatalkan n migration terakhir yang tercatat dengan menjalankan
+// bagian down-nya, lalu mengembalikan nama file yang dibatalkan
+func (a *Applier) Down(ctx context.Context, n int) ([]string, error) {
+	migrations, applied, err := a.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[string]schema.MigrationFile, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	versions := make([]string, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+	if n > len(versions) {
+		n = len(versions)
+	}
+
+	// Semua migration diperiksa sebelum ada yang dibatalkan
+	targets := make([]schema.MigrationFile, 0, n)
+	for _, version := range versions[:n] {
+		m, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("applied migration %s has no file in %s", version, a.dir)
+		}
+		if applied[version] != m.Hash {
+			return nil, &HashMismatchError{File: m.Name, Recorded: applied[version], Got: m.Hash}
+		}
+		if len(m.Down) == 0 {
+			return nil, fmt.Errorf("migration %s has no down section", m.Name)
+		}
+		targets = append(targets, m)
+	}
+
+	var done []string
+	for _, m := range targets {
+		version := m.Version
+		err := a.run(ctx, m.Down, func(exec execer) error {
+			_, err := exec.ExecContext(ctx, a.deleteSQL(), version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("rolling back %s: %w", m.Name, err)
+		}
+		done = append(done, m.Name)
+	}
+	return done, nil
+}
+
+// load membaca migration di dir dan versi yang sudah tercatat di database.
+// Tabel datara_migrations dibuat jika belum ada.
+func (a *Applier) load(ctx context.Context) ([]schema.MigrationFile, map[string]string, error) {
+	migrations, err := schema.ReadMigrations(a.dir, a.pattern, a.separator)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := a.db.ExecContext(ctx, a.createTableSQL()); err != nil {
+		return nil, nil, fmt.Errorf("creating %s: %w", Table, err)
+	}
+
+	rows, err := a.db.QueryContext(ctx, fmt.Sprintf("SELECT version, hash FROM %s", Table))
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", Table, err)
+	}
+	defer rows.Close()
+	applied := make(map[string]string)
+	for rows.Next() {
+		var version, hash string
+		if err := rows.Scan(&version, &hash); err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", Table, err)
+		}
+		applied[version] = hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", Table, err)
+	}
+	return migrations, applied, nil
+}
+
+// execer adalah bagian *sql.DB dan *sql.Tx yang dipakai untuk menjalankan statement
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// run menjalankan statements lalu record (pencatatan di datara_migrations).
+// Di Postgres dan SQL Server semuanya berada dalam satu transaksi; di MySQL
+// statement dijalankan langsung karena DDL tidak bisa di-rollback.
+func (a *Applier) run(ctx context.Context, statements []string, record func(exec execer) error) error {
+	if !a.transactional(statements) {
+		return runAll(ctx, a.db, statements, record)
+	}
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := runAll(ctx, tx, statements, record); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+// transactional melaporkan apakah statements dijalankan dalam transaksi.
+// Bagian migration dengan CONCURRENTLY (ditandai "transaction:false") tidak
+// bisa berjalan di dalam transaksi, sama seperti yang dilakukan dbmate.
+func (a *Applier) transactional(statements []string) bool {
+	return a.dialect != diff.DialectMySQL && !diff.NonTransactional(statements)
+}
+
+// runAll menjalankan statements satu per satu, lalu record
+func runAll(ctx context.Context, exec execer, statements []string, record func(exec execer) error) error {
+	for _, stmt := range statements {
+		if _, err := exec.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%w\nstatement: %s", err, stmt)
+		}
+	}
+	return record(exec)
+}
+
+// createTableSQL membuat tabel datara_migrations jika belum ada
+func (a *Applier) createTableSQL() string {
+	switch a.dialect {
+	case diff.DialectMySQL:
+		return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version VARCHAR(128) PRIMARY KEY, hash VARCHAR(64) NOT NULL, applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)", Table)
+	case diff.DialectMSSQL:
+		return fmt.Sprintf("IF OBJECT_ID(N'%s', N'U') IS NULL CREATE TABLE %s (version NVARCHAR(128) PRIMARY KEY, hash NVARCHAR(64) NOT NULL, applied_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME())", Table, Table)
+	default:
+		return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version VARCHAR(128) PRIMARY KEY, hash VARCHAR(64) NOT NULL, applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP)", Table)
+	}
+}
+
+// insertSQL mencatat satu migration yang sudah dijalankan
+func (a *Applier) insertSQL() string {
+	return fmt.Sprintf("INSERT INTO %s (version, hash) VALUES (%s, %s)", Table, a.placeholder(1), a.placeholder(2))
+}
+
+// deleteSQL menghapus catatan migration yang dibatalkan
+func (a *Applier) deleteSQL() string {
+	return fmt.Sprintf("DELETE FROM %s WHERE version = %s", Table, a.placeholder(1))
+}
+
+// placeholder mengembalikan placeholder parameter ke-n sesuai dialect
+func (a *Applier) placeholder(n int) string {
+	switch a.dialect {
+	case diff.DialectMySQL:
+		return "?"
+	case diff.DialectMSSQL:
+		return fmt.Sprintf("@p%d", n)
+	default:
+		return fmt.Sprintf("$%d", n)
+	}
+}
@@ -0,0 +1,43 @@
+package applier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/diff"
+)
+
+func TestTransactional(t *testing.T) {
+	tests := []struct {
+		name       string
+		dialect    string
+		statements []string
+		want       bool
+	}{
+		{"postgres", diff.DialectPostgres, []string{"ALTER TABLE users ADD COLUMN age INT;"}, true},
+		{"mssql", diff.DialectMSSQL, []string{"ALTER TABLE users ADD age INT;"}, true},
+		{"mysql", diff.DialectMySQL, []string{"ALTER TABLE users ADD COLUMN age INT;"}, false},
+		{"postgres concurrently", diff.DialectPostgres, []string{"CREATE INDEX CONCURRENTLY idx_users_age ON users (age);"}, false},
+		{"cockroach concurrently", diff.DialectCockroach, []string{"DROP INDEX CONCURRENTLY idx_users_age;"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(nil, tt.dialect, "migrations")
+			if got := a.transactional(tt.statements); got != tt.want {
+				t.Errorf("transactional() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateTableSQLAvoidsDbmateTable(t *testing.T) {
+	for _, dialect := range []string{diff.DialectPostgres, diff.DialectMySQL, diff.DialectMSSQL} {
+		sql := New(nil, dialect, "migrations").createTableSQL()
+		if !strings.Contains(sql, Table) {
+			t.Errorf("%s: createTableSQL() = %q, want table %s", dialect, sql, Table)
+		}
+		if strings.Contains(sql, "schema_migrations") {
+			t.Errorf("%s: createTableSQL() = %q uses dbmate's schema_migrations", dialect, sql)
+		}
+	}
+}
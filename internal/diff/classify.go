@@ -0,0 +1,51 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// validClasses adalah nilai tag class=... yang dikenali
+var validClasses = map[string]bool{
+	state.ClassPublic:   true,
+	state.ClassInternal: true,
+	state.ClassPII:      true,
+}
+
+// columnComment adalah COMMENT kolom yang dirender: klasifikasi digabung
+// dengan komentar manusia dan penanda DEPRECATED, sehingga perubahan class
+// atau deprecation dianggap perubahan komentar
+func columnComment(col state.Column) string {
+	comment := state.ClassComment(col.Class, col.Tags["comment"])
+	if col.Deprecated {
+		comment = strings.TrimSpace(comment + " " + state.DeprecatedComment)
+	}
+	return comment
+}
+
+// validateClassification memeriksa nilai class=... dan, jika
+// RequireClassification aktif, mewajibkan class pada kolom baru. Kolom yang
+// sudah ada di current tanpa class hanya diperingatkan agar adopsi bisa bertahap.
+func (g *Generator) validateClassification(current, desired *state.SchemaState) error {
+	for _, table := range sortedTables(desired.Tables) {
+		existing, tableExists := current.Tables[table.Name]
+		for _, col := range sortedColumns(table.Columns) {
+			if col.Class != "" && !validClasses[col.Class] {
+				return &ValidationError{Table: table.Name, Column: col.Name, Rule: "classification",
+					Detail: fmt.Sprintf("unknown class %q, use public, internal or pii", col.Class)}
+			}
+			if !g.config.RequireClassification || col.Class != "" {
+				continue
+			}
+			if _, exists := existing.Columns[col.Name]; tableExists && exists {
+				g.warnings.Add("unclassified", table.Name, col.Name, "existing column has no data class")
+				continue
+			}
+			return &ValidationError{Table: table.Name, Column: col.Name, Rule: "classification",
+				Detail: "new columns require a data class (db:\"class=public|internal|pii\")"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Batas bucket_count hash-sharded index CockroachDB; tag sharded tanpa nilai
+// memakai default CockroachDB
+const (
+	cockroachDefaultBuckets = 16
+	cockroachMinBuckets     = 2
+	cockroachMaxBuckets     = 2048
+)
+
+// postgresSyntax mengecek apakah dialect generator memakai sintaks Postgres
+// (quote, ALTER COLUMN per atribut, COMMENT ON, ...). CockroachDB termasuk,
+// kecuali bagian yang ditangani di file ini.
+func (g *Generator) postgresSyntax() bool {
+	return g.config.Dialect == DialectPostgres || g.config.Dialect == DialectCockroach
+}
+
+// distributedKeyDefault mengembalikan DEFAULT kolom auto increment dengan
+// strategi state.IdentityDistributed: gen_random_uuid() untuk kolom UUID dan
+// unique_rowid() untuk kolom integer. Key berurutan membuat hotspot di satu
+// range CockroachDB, sehingga ini strategi default dialect cockroach.
+func distributedKeyDefault(sqlType string) string {
+	if strings.EqualFold(strings.TrimSpace(sqlType), "uuid") {
+		return "gen_random_uuid()"
+	}
+	return "unique_rowid()"
+}
+
+// isDistributedKeyDefault mengecek default yang dihasilkan
+// distributedKeyDefault, mis. dari output SHOW CREATE TABLE
+func isDistributedKeyDefault(d *state.DefaultValue) bool {
+	if d == nil || d.Kind != state.DefaultExpression {
+		return false
+	}
+	expr := strings.ToLower(strings.Join(strings.Fields(d.Value), ""))
+	return expr == "unique_rowid()" || expr == "gen_random_uuid()"
+}
+
+// normalizeDistributedKey menyimpan kolom dengan DEFAULT unique_rowid() atau
+// gen_random_uuid() sebagai auto increment ber-strategi distributed, sehingga
+// schema program yang menulis default tersebut dan model yang hanya menyebut
+// autoincrement dianggap sama
+func (g *Generator) normalizeDistributedKey(col *state.Column) {
+	if g.config.Dialect != DialectCockroach || !isDistributedKeyDefault(col.DefaultValue) {
+		return
+	}
+	col.DefaultValue = nil
+	col.AutoIncrement = true
+	col.Identity = state.IdentityDistributed
+}
+
+// shardBuckets mengembalikan bucket_count dari tag sharded, mis. sharded=8.
+// Tanpa tag hasilnya 0; tanpa nilai, default CockroachDB.
+func shardBuckets(tags map[string]string) int {
+	value, ok := tags["sharded"]
+	if !ok {
+		return 0
+	}
+	if value == "" {
+		return cockroachDefaultBuckets
+	}
+	n, _ := strconv.Atoi(value)
+	return n
+}
+
+// hashShardedClause merender klausa hash-sharded untuk index atau primary
+// key dengan buckets bucket. include adalah klausa INCLUDE yang letaknya
+// berbeda antara sintaks lama dan baru.
+func (g *Generator) hashShardedClause(buckets int, include string) string {
+	if !g.supports(FeatureBucketCountParam) {
+		return fmt.Sprintf(" USING HASH WITH BUCKET_COUNT = %d%s", buckets, include)
+	}
+	return fmt.Sprintf(" USING HASH%s WITH (bucket_count = %d)", include, buckets)
+}
+
+// shardPrimaryKey menambahkan klausa hash-sharded pada primary key tabel
+// jika salah satu kolom primary_key punya tag sharded. Primary key yang
+// sudah memakai USING HASH dibiarkan.
+func (g *Generator) shardPrimaryKey(table *state.Table) {
+	if g.config.Dialect != DialectCockroach {
+		return
+	}
+	buckets := 0
+	for _, col := range table.Columns {
+		if _, ok := col.Tags["primary_key"]; ok && shardBuckets(col.Tags) > buckets {
+			buckets = shardBuckets(col.Tags)
+		}
+	}
+	if buckets == 0 {
+		return
+	}
+	for i, constraint := range table.Constraints {
+		if constraint.Type == "PRIMARY KEY" && !strings.Contains(strings.ToUpper(constraint.Def), "USING HASH") {
+			table.Constraints[i].Def += g.hashShardedClause(buckets, "")
+		}
+	}
+}
+
+// cockroachOnUpdate mengubah ON UPDATE kolom yang sudah ada. CockroachDB
+// mendukung ON UPDATE di definisi kolom, sehingga tidak perlu trigger.
+func (g *Generator) cockroachOnUpdate(tableName string, desired state.Column) []string {
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", g.quote(tableName), g.quote(desired.Name))
+	if desired.OnUpdate == "" {
+		return []string{alter + " DROP ON UPDATE"}
+	}
+	return []string{fmt.Sprintf("%s SET ON UPDATE %s", alter, desired.OnUpdate)}
+}
+
+// foreignKeyActionPattern menemukan aksi foreign key selain RESTRICT dan NO ACTION
+var foreignKeyActionPattern = regexp.MustCompile(`(?i)\bON\s+(DELETE|UPDATE)\s+(CASCADE|SET\s+NULL|SET\s+DEFAULT)\b`)
+
+// validateCockroach menolak strategi distributed di luar CockroachDB dan,
+// untuk dialect cockroach, fitur yang tidak didukungnya: tipe ENUM/SET
+// inline (tidak bisa dibuat maupun di-ALTER), bucket_count di luar 2..2048,
+// dan aksi foreign key yang belum ada di server_version. Tag sharded di
+// dialect lain hanya menghasilkan peringatan karena diabaikan.
+func (g *Generator) validateCockroach(schema *state.SchemaState) error {
+	cockroach := g.config.Dialect == DialectCockroach
+	for _, table := range sortedTables(schema.Tables) {
+		for _, col := range sortedColumns(table.Columns) {
+			if col.AutoIncrement && col.Identity == state.IdentityDistributed && !cockroach {
+				return &ValidationError{Table: table.Name, Column: col.Name, Rule: "identity",
+					Detail: fmt.Sprintf("identity %s is only supported on cockroach", state.IdentityDistributed)}
+			}
+			if _, ok := col.Tags["sharded"]; ok && !cockroach {
+				g.warnings.Add("sharded-index", table.Name, col.Name, "sharded is ignored on %s; hash-sharded indexes are cockroach only", g.config.Dialect)
+				continue
+			}
+			if !cockroach {
+				continue
+			}
+			if base := strings.ToLower(strings.TrimSpace(col.Type)); strings.HasPrefix(base, "enum(") || strings.HasPrefix(base, "set(") {
+				return &ValidationError{Table: table.Name, Column: col.Name, Rule: "cockroach",
+					Detail: fmt.Sprintf("inline %s types are not supported on cockroach; create an enum with CREATE TYPE in raw_sql, "+
+						"change its values with ALTER TYPE ... ADD VALUE in a manual migration, and use the type name", col.Type)}
+			}
+			if _, ok := col.Tags["sharded"]; ok {
+				if buckets := shardBuckets(col.Tags); buckets < cockroachMinBuckets || buckets > cockroachMaxBuckets {
+					return &ValidationError{Table: table.Name, Column: col.Name, Rule: "sharded",
+						Detail: fmt.Sprintf("sharded=%s must be a bucket count between %d and %d", col.Tags["sharded"], cockroachMinBuckets, cockroachMaxBuckets)}
+				}
+			}
+		}
+		if !cockroach || g.supports(FeatureForeignKeyActions) {
+			continue
+		}
+		for _, constraint := range table.Constraints {
+			if constraint.Type == "FOREIGN KEY" && foreignKeyActionPattern.MatchString(constraint.Def) {
+				return &ValidationError{Table: table.Name, Rule: "server-version",
+					Detail: fmt.Sprintf("%s does not support %s in %s; use RESTRICT or NO ACTION", g.config.ServerVersion, FeatureForeignKeyActions, constraint.Name)}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package diff
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// eventsTable membuat tabel events dengan kolom id dan kolom tambahan
+func eventsTable(id state.Column, columns ...state.Column) *state.SchemaState {
+	id.Name, id.Position = "id", 1
+	table := state.Table{Name: "events", Columns: map[string]state.Column{"id": id}}
+	for i, col := range columns {
+		col.Position = i + 2
+		table.Columns[col.Name] = col
+	}
+	return schemaOf(table)
+}
+
+// serverVersion membaca versi server untuk Config test
+func serverVersion(t *testing.T, s string) ServerVersion {
+	t.Helper()
+	v, err := ParseServerVersion(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestCockroachPrimaryKey(t *testing.T) {
+	primaryKey := map[string]string{"primary_key": ""}
+	tests := []struct {
+		name    string
+		dialect string
+		version string
+		id      state.Column
+		want    string
+	}{
+		{"integer key uses unique_rowid", DialectCockroach, "",
+			state.Column{Type: "BIGINT", AutoIncrement: true, Tags: primaryKey},
+			"  \"id\" BIGINT NOT NULL DEFAULT unique_rowid(),\n  PRIMARY KEY (\"id\")\n"},
+		{"uuid key uses gen_random_uuid", DialectCockroach, "",
+			state.Column{Type: "UUID", AutoIncrement: true, Tags: primaryKey},
+			"  \"id\" UUID NOT NULL DEFAULT gen_random_uuid(),\n  PRIMARY KEY (\"id\")\n"},
+		{"explicit identity is kept", DialectCockroach, "",
+			state.Column{Type: "BIGINT", AutoIncrement: true, Identity: state.IdentityByDefault, Tags: primaryKey},
+			"  \"id\" BIGINT NOT NULL GENERATED BY DEFAULT AS IDENTITY,\n"},
+		{"postgres keeps sequential identity", DialectPostgres, "",
+			state.Column{Type: "BIGINT", AutoIncrement: true, Tags: primaryKey},
+			"  \"id\" BIGINT NOT NULL GENERATED BY DEFAULT AS IDENTITY,\n"},
+		{"sharded primary key", DialectCockroach, "",
+			state.Column{Type: "UUID", AutoIncrement: true, Tags: map[string]string{"primary_key": "", "sharded": "8"}},
+			"  PRIMARY KEY (\"id\") USING HASH WITH (bucket_count = 8)\n"},
+		{"sharded primary key before 22.1", DialectCockroach, "cockroach:21.2",
+			state.Column{Type: "UUID", AutoIncrement: true, Tags: map[string]string{"primary_key": "", "sharded": "8"}},
+			"  PRIMARY KEY (\"id\") USING HASH WITH BUCKET_COUNT = 8\n"},
+		{"sharded without a count uses the default", DialectCockroach, "",
+			state.Column{Type: "UUID", Tags: map[string]string{"primary_key": "", "sharded": ""}},
+			"  PRIMARY KEY (\"id\") USING HASH WITH (bucket_count = 16)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator(&Config{Dialect: tt.dialect, ServerVersion: serverVersion(t, tt.version)})
+			statements, err := g.GenerateStatements(schemaOf(), eventsTable(tt.id))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(statements, "\n"); !strings.Contains(got, tt.want) {
+				t.Errorf("got:\n%s\nwant it to contain:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCockroachDistributedKeyChanges(t *testing.T) {
+	primaryKey := map[string]string{"primary_key": ""}
+	autoIncrement := state.Column{Type: "BIGINT", AutoIncrement: true, Tags: primaryKey}
+	rowID := state.Column{Type: "BIGINT", DefaultValue: state.ParseDefault("unique_rowid()"), Tags: primaryKey}
+	byDefault := state.Column{Type: "BIGINT", AutoIncrement: true, Identity: state.IdentityByDefault, Tags: primaryKey}
+	distributed := state.Column{Type: "BIGINT", AutoIncrement: true, Identity: state.IdentityDistributed, Tags: primaryKey}
+	tests := []struct {
+		name     string
+		from, to state.Column
+		want     []string
+	}{
+		{"DEFAULT unique_rowid() equals autoincrement", rowID, autoIncrement, nil},
+		{"autoincrement equals DEFAULT unique_rowid()", autoIncrement, rowID, nil},
+		{"identity to distributed", byDefault, distributed, []string{
+			`ALTER TABLE "events" ALTER COLUMN "id" DROP IDENTITY IF EXISTS;`,
+			`ALTER TABLE "events" ALTER COLUMN "id" SET DEFAULT unique_rowid();`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := NewGenerator(&Config{Dialect: DialectCockroach}).GenerateStatements(eventsTable(tt.from), eventsTable(tt.to))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := strings.Join(statements, "\n"), strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestCockroachShardedIndex(t *testing.T) {
+	id := state.Column{Type: "UUID", Tags: map[string]string{"primary_key": ""}}
+	ts := func(tags map[string]string) state.Column {
+		return state.Column{Name: "ts", Type: "TIMESTAMPTZ", Tags: tags}
+	}
+	tests := []struct {
+		name    string
+		version string
+		tags    map[string]string
+		want    string
+	}{
+		{"bucket count", "", map[string]string{"index": "idx_events_ts", "sharded": "8"},
+			`CREATE INDEX "idx_events_ts" ON "events" ("ts") USING HASH WITH (bucket_count = 8);`},
+		{"default bucket count", "", map[string]string{"index": "idx_events_ts", "sharded": ""},
+			`CREATE INDEX "idx_events_ts" ON "events" ("ts") USING HASH WITH (bucket_count = 16);`},
+		{"unique with include", "", map[string]string{"unique": "", "index": "uq_events_ts", "sharded": "4", "include": "id"},
+			`CREATE UNIQUE INDEX "uq_events_ts" ON "events" ("ts") USING HASH INCLUDE ("id") WITH (bucket_count = 4);`},
+		{"before 22.1", "cockroach:21.2", map[string]string{"index": "idx_events_ts", "sharded": "4", "include": "id"},
+			`CREATE INDEX "idx_events_ts" ON "events" ("ts") USING HASH WITH BUCKET_COUNT = 4 INCLUDE ("id");`},
+		{"not sharded", "", map[string]string{"index": "idx_events_ts"},
+			`CREATE INDEX "idx_events_ts" ON "events" ("ts");`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator(&Config{Dialect: DialectCockroach, ServerVersion: serverVersion(t, tt.version)})
+			statements, err := g.GenerateStatements(schemaOf(), eventsTable(id, ts(tt.tags)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(statements, "\n"); !strings.Contains(got, tt.want) {
+				t.Errorf("got:\n%s\nwant it to contain:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// Jumlah bucket yang berubah membuat ulang index
+	g := NewGenerator(&Config{Dialect: DialectCockroach})
+	statements, err := g.GenerateStatements(
+		eventsTable(id, ts(map[string]string{"index": "idx_events_ts", "sharded": "4"})),
+		eventsTable(id, ts(map[string]string{"index": "idx_events_ts", "sharded": "8"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "DROP INDEX \"idx_events_ts\";\nCREATE INDEX \"idx_events_ts\" ON \"events\" (\"ts\") USING HASH WITH (bucket_count = 8);"
+	if got := strings.Join(statements, "\n"); got != want {
+		t.Errorf("bucket change:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCockroachValidation(t *testing.T) {
+	uuid := state.Column{Type: "UUID", Tags: map[string]string{"primary_key": ""}}
+	sharded := func(value string) state.Column {
+		return state.Column{Type: "UUID", Tags: map[string]string{"primary_key": "", "sharded": value}}
+	}
+	cascade := schemaOf(state.Table{Name: "events", Columns: map[string]state.Column{
+		"id": {Name: "id", Type: "INT", Position: 1},
+	}, Constraints: []state.Constraint{{Name: "fk_events_parent", Type: "FOREIGN KEY",
+		Def: "CONSTRAINT fk_events_parent FOREIGN KEY (id) REFERENCES events (id) ON DELETE CASCADE"}}})
+	tests := []struct {
+		name     string
+		dialect  string
+		version  string
+		schema   *state.SchemaState
+		wantRule string
+		wantWarn string
+	}{
+		{name: "too few buckets", dialect: DialectCockroach, schema: eventsTable(sharded("1")), wantRule: "sharded"},
+		{name: "too many buckets", dialect: DialectCockroach, schema: eventsTable(sharded("4096")), wantRule: "sharded"},
+		{name: "bucket limits", dialect: DialectCockroach, schema: eventsTable(sharded("2048"))},
+		{name: "inline enum", dialect: DialectCockroach, schema: eventsTable(uuid, state.Column{Name: "kind", Type: "ENUM('a','b')"}), wantRule: "cockroach"},
+		{name: "distributed identity outside cockroach", dialect: DialectPostgres,
+			schema: eventsTable(state.Column{Type: "BIGINT", AutoIncrement: true, Identity: state.IdentityDistributed}), wantRule: "identity"},
+		{name: "sharded outside cockroach", dialect: DialectPostgres, schema: eventsTable(sharded("8")), wantWarn: "sharded-index"},
+		{name: "cascade on an old server", dialect: DialectCockroach, version: "cockroach:1.1", schema: cascade, wantRule: "server-version"},
+		{name: "cascade on a current server", dialect: DialectCockroach, version: "cockroach:23.2", schema: cascade},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator(&Config{Dialect: tt.dialect, ServerVersion: serverVersion(t, tt.version)})
+			_, err := g.GenerateStatements(schemaOf(), tt.schema)
+			var validationErr *ValidationError
+			switch {
+			case tt.wantRule != "":
+				if !errors.As(err, &validationErr) || validationErr.Rule != tt.wantRule {
+					t.Fatalf("err = %v, want ValidationError with rule %q", err, tt.wantRule)
+				}
+			case err != nil:
+				t.Fatal(err)
+			}
+			var codes []string
+			for _, w := range g.Warnings() {
+				codes = append(codes, w.Code)
+			}
+			if got := strings.Join(codes, ","); got != tt.wantWarn {
+				t.Errorf("warnings = %q, want %q", got, tt.wantWarn)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// collationSQL merender charset dan collation kolom: CHARACTER SET x COLLATE y
+// di MySQL, COLLATE "y" di Postgres yang tidak punya charset per kolom
+func (g *Generator) collationSQL(col state.Column) string {
+	var b strings.Builder
+	if col.Charset != "" && g.config.Dialect == DialectMySQL {
+		b.WriteString(" CHARACTER SET " + col.Charset)
+	}
+	if col.Collation != "" {
+		collation := col.Collation
+		if g.postgresSyntax() {
+			collation = quoteIdent(DialectPostgres, collation)
+		}
+		b.WriteString(" COLLATE " + collation)
+	}
+	return b.String()
+}
+
+// collationEqual membandingkan charset dan collation dua kolom
+func collationEqual(a, b state.Column) bool {
+	return strings.EqualFold(a.Charset, b.Charset) && strings.EqualFold(a.Collation, b.Collation)
+}
+
+// validateCollation memastikan charset/collation hanya dipakai pada tipe
+// string dan collation MySQL termasuk keluarga charset kolom (atau tabel)
+func (g *Generator) validateCollation(tableName string, col state.Column) error {
+	if col.Charset == "" && col.Collation == "" {
+		return nil
+	}
+	invalid := func(detail string) error {
+		return &ValidationError{Table: tableName, Column: col.Name, Rule: "collation", Detail: detail}
+	}
+
+	if !isStringType(col.Type) {
+		return invalid(fmt.Sprintf("charset and collate only apply to string types, not %s", col.Type))
+	}
+	if g.config.Dialect != DialectMySQL {
+		if col.Charset != "" {
+			return invalid(g.config.Dialect + " has no per-column charset, use collate")
+		}
+		return nil
+	}
+
+	charset := col.Charset
+	if charset == "" {
+		charset = g.config.Charset
+	}
+	if col.Collation != "" && charset != "" && !collationInCharset(col.Collation, charset) {
+		return invalid(fmt.Sprintf("collation %s does not belong to charset %s", col.Collation, charset))
+	}
+	if is0900Collation(col.Collation) && !g.supports(FeatureCollation0900) {
+		return &ValidationError{Table: tableName, Column: col.Name, Rule: "server-version",
+			Detail: fmt.Sprintf("%s does not support %s, use e.g. utf8mb4_unicode_ci", g.config.ServerVersion, FeatureCollation0900)}
+	}
+	return nil
+}
+
+// collationInCharset mengecek apakah collation MySQL milik charset tersebut,
+// mis. utf8mb4_bin untuk utf8mb4. utf8 adalah alias utf8mb3.
+func collationInCharset(collation, charset string) bool {
+	alias := func(s string) string {
+		if s == "utf8" || strings.HasPrefix(s, "utf8_") {
+			return "utf8mb3" + strings.TrimPrefix(s, "utf8")
+		}
+		return s
+	}
+	collation, charset = alias(strings.ToLower(collation)), alias(strings.ToLower(charset))
+	if charset == "binary" {
+		return collation == "binary"
+	}
+	return strings.HasPrefix(collation, charset+"_")
+}
+
+// isStringType mengecek apakah tipe kolom menyimpan teks (CHAR, VARCHAR,
+// TEXT, ENUM, SET, CITEXT), yaitu tipe yang punya charset dan collation
+func isStringType(sqlType string) bool {
+	t := strings.ToLower(strings.TrimSpace(sqlType))
+	if open := strings.Index(t, "("); open != -1 {
+		t = strings.TrimSpace(t[:open])
+	}
+	switch t {
+	case "char", "varchar", "character", "character varying", "enum", "set", "citext":
+		return true
+	}
+	return strings.HasSuffix(t, "text")
+}
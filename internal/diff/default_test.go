@@ -0,0 +1,226 @@
+package diff
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// defaultClause menangkap nilai DEFAULT atau SET DEFAULT di sebuah statement
+var defaultClause = regexp.MustCompile(`DEFAULT (\S+?)[;,\s)]`)
+
+// booleanTable membuat tabel users dengan kolom active BOOLEAN ber-default d
+func booleanTable(d *state.DefaultValue, withActive bool) state.Table {
+	table := state.Table{Name: "users", Columns: map[string]state.Column{
+		"id": {Name: "id", Type: "INT", Position: 1},
+	}}
+	if withActive {
+		table.Columns["active"] = state.Column{Name: "active", Type: "BOOLEAN", Nullable: false, DefaultValue: d, Position: 2}
+	}
+	return table
+}
+
+// schemaOf membuat SchemaState dari tabel-tabel
+func schemaOf(tables ...state.Table) *state.SchemaState {
+	s := state.NewSchemaState()
+	for _, table := range tables {
+		s.Tables[table.Name] = table
+	}
+	return s
+}
+
+// renderedDefault menjalankan diff lalu mengembalikan nilai DEFAULT kolom active
+func renderedDefault(t *testing.T, dialect string, current, desired *state.SchemaState) string {
+	t.Helper()
+	statements, err := NewGenerator(&Config{Dialect: dialect}).GenerateStatements(current, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql := strings.Join(statements, "\n")
+	match := defaultClause.FindStringSubmatch(sql + "\n")
+	if match == nil {
+		t.Fatalf("no DEFAULT rendered:\n%s", sql)
+	}
+	return match[1]
+}
+
+func TestBooleanDefaultCreateMatchesAlter(t *testing.T) {
+	inputs := []struct {
+		name  string
+		value *state.DefaultValue
+		want  bool
+	}{
+		{"tag true", state.ParseDefault("true"), true},
+		{"tag quoted true", state.ParseDefault("'true'"), true},
+		{"tag 1", state.ParseDefault("1"), true},
+		{"json bool false", &state.DefaultValue{Kind: state.DefaultBool, Value: "false"}, false},
+		{"json string false", &state.DefaultValue{Kind: state.DefaultString, Value: "false"}, false},
+		{"json number 0", &state.DefaultValue{Kind: state.DefaultNumber, Value: "0"}, false},
+	}
+	dialects := []struct {
+		dialect     string
+		true, false string
+	}{
+		{DialectMySQL, "1", "0"},
+		{DialectPostgres, "TRUE", "FALSE"},
+	}
+	for _, d := range dialects {
+		for _, tt := range inputs {
+			t.Run(d.dialect+" "+tt.name, func(t *testing.T) {
+				want := d.false
+				if tt.want {
+					want = d.true
+				}
+				desired := schemaOf(booleanTable(tt.value, true))
+
+				create := renderedDefault(t, d.dialect, state.NewSchemaState(), desired)
+				add := renderedDefault(t, d.dialect, schemaOf(booleanTable(nil, false)), desired)
+				// Kolom yang sudah ada tanpa default mendapat SET DEFAULT
+				set := renderedDefault(t, d.dialect, schemaOf(booleanTable(nil, true)), desired)
+				if create != want || add != want || set != want {
+					t.Errorf("DEFAULT on create = %s, add column = %s, alter column = %s; want %s for all", create, add, set, want)
+				}
+			})
+		}
+	}
+}
+
+func TestBooleanDefaultFormsAreEqual(t *testing.T) {
+	current := schemaOf(booleanTable(state.ParseDefault("TRUE"), true))
+	for _, form := range []*state.DefaultValue{
+		state.ParseDefault("1"),
+		state.ParseDefault("'t'"),
+		{Kind: state.DefaultBool, Value: "true"},
+		{Kind: state.DefaultString, Value: "true"},
+	} {
+		for _, dialect := range []string{DialectMySQL, DialectPostgres} {
+			statements, err := NewGenerator(&Config{Dialect: dialect}).GenerateStatements(current, schemaOf(booleanTable(form, true)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(statements) != 0 {
+				t.Errorf("%s: default %s %q differs from TRUE: %v", dialect, form.Kind, form.Value, statements)
+			}
+		}
+	}
+}
+
+// flagsTable membuat tabel users dengan kolom is_active dan is_verified yang
+// ditulis dalam bentuk boolean berbeda
+func flagsTable(activeType, verifiedType string) state.Table {
+	return state.Table{Name: "users", Columns: map[string]state.Column{
+		"id":          {Name: "id", Type: "INT", Position: 1},
+		"is_active":   {Name: "is_active", Type: activeType, DefaultValue: state.ParseDefault("true"), Position: 2},
+		"is_verified": {Name: "is_verified", Type: verifiedType, DefaultValue: state.ParseDefault("0"), Position: 3},
+	}}
+}
+
+func TestBooleanTypePerDialect(t *testing.T) {
+	tests := []struct {
+		dialect          string
+		active, verified string
+	}{
+		{DialectMySQL, "`is_active` TINYINT(1) NOT NULL DEFAULT 1", "`is_verified` TINYINT(1) NOT NULL DEFAULT 0"},
+		{DialectPostgres, `"is_active" BOOLEAN NOT NULL DEFAULT TRUE`, `"is_verified" BOOLEAN NOT NULL DEFAULT FALSE`},
+		{DialectCockroach, `"is_active" BOOLEAN NOT NULL DEFAULT TRUE`, `"is_verified" BOOLEAN NOT NULL DEFAULT FALSE`},
+		{DialectMSSQL, "[is_active] BIT NOT NULL CONSTRAINT [df_users_is_active] DEFAULT 1", "[is_verified] BIT NOT NULL CONSTRAINT [df_users_is_verified] DEFAULT 0"},
+	}
+	for _, tt := range tests {
+		for _, types := range [][2]string{{"BOOLEAN", "TINYINT(1)"}, {"TINYINT(1)", "bool"}} {
+			t.Run(tt.dialect+" "+types[0]+" "+types[1], func(t *testing.T) {
+				statements, err := NewGenerator(&Config{Dialect: tt.dialect}).GenerateStatements(
+					state.NewSchemaState(), schemaOf(flagsTable(types[0], types[1])))
+				if err != nil {
+					t.Fatal(err)
+				}
+				sql := strings.Join(statements, "\n")
+				if !strings.Contains(sql, tt.active) || !strings.Contains(sql, tt.verified) {
+					t.Errorf("want %s and %s in:\n%s", tt.active, tt.verified, sql)
+				}
+			})
+		}
+	}
+}
+
+// TestBooleanRepresentationsAreEqual memastikan snapshot MySQL (TINYINT(1)
+// dengan default 1/0) tidak berbeda dengan model BOOLEAN TRUE/FALSE
+func TestBooleanRepresentationsAreEqual(t *testing.T) {
+	mysql := schemaOf(flagsTable("TINYINT(1)", "TINYINT(1)"))
+	model := flagsTable("BOOLEAN", "BOOLEAN")
+	active := model.Columns["is_active"]
+	active.DefaultValue = &state.DefaultValue{Kind: state.DefaultBool, Value: "true"}
+	model.Columns["is_active"] = active
+	for _, dialect := range []string{DialectMySQL, DialectPostgres, DialectCockroach, DialectMSSQL} {
+		g := NewGenerator(&Config{Dialect: dialect})
+		for _, pair := range [][2]*state.SchemaState{{mysql, schemaOf(model)}, {schemaOf(model), mysql}} {
+			statements, err := g.GenerateStatements(pair[0], pair[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(statements) != 0 {
+				t.Errorf("%s: TINYINT(1) and BOOLEAN columns differ: %v", dialect, statements)
+			}
+		}
+	}
+	if differences := state.Compare(mysql, schemaOf(model)); len(differences) != 0 {
+		t.Errorf("state.Compare() = %v, want no differences", differences)
+	}
+}
+
+// stringDefaults adalah tiga bentuk default kolom VARCHAR yang harus tetap
+// berbeda: string kosong, NULL, dan tanpa default
+var stringDefaults = map[string]*state.DefaultValue{
+	"empty":  state.ParseDefault("''"),
+	"null":   state.ParseDefault("NULL"),
+	"absent": nil,
+}
+
+func TestEmptyNullAbsentDefaults(t *testing.T) {
+	table := func(d *state.DefaultValue) *state.SchemaState {
+		return schemaOf(state.Table{Name: "t", Columns: map[string]state.Column{
+			"id": {Name: "id", Type: "INT", Position: 1},
+			"s":  {Name: "s", Type: "VARCHAR(20)", Nullable: true, DefaultValue: d, Position: 2},
+		}})
+	}
+	tests := []struct {
+		dialect, from, to string
+		want              string
+	}{
+		{DialectPostgres, "absent", "empty", `ALTER TABLE "t" ALTER COLUMN "s" SET DEFAULT '';`},
+		{DialectPostgres, "absent", "null", `ALTER TABLE "t" ALTER COLUMN "s" SET DEFAULT NULL;`},
+		{DialectPostgres, "empty", "null", `ALTER TABLE "t" ALTER COLUMN "s" SET DEFAULT NULL;`},
+		{DialectPostgres, "empty", "absent", `ALTER TABLE "t" ALTER COLUMN "s" DROP DEFAULT;`},
+		{DialectPostgres, "null", "empty", `ALTER TABLE "t" ALTER COLUMN "s" SET DEFAULT '';`},
+		{DialectPostgres, "null", "absent", `ALTER TABLE "t" ALTER COLUMN "s" DROP DEFAULT;`},
+		{DialectMySQL, "absent", "empty", "ALTER TABLE `t` MODIFY COLUMN `s` VARCHAR(20) DEFAULT '';"},
+		{DialectMySQL, "absent", "null", "ALTER TABLE `t` MODIFY COLUMN `s` VARCHAR(20) DEFAULT NULL;"},
+		{DialectMySQL, "empty", "null", "ALTER TABLE `t` MODIFY COLUMN `s` VARCHAR(20) DEFAULT NULL;"},
+		{DialectMySQL, "empty", "absent", "ALTER TABLE `t` MODIFY COLUMN `s` VARCHAR(20);"},
+		{DialectMySQL, "null", "empty", "ALTER TABLE `t` MODIFY COLUMN `s` VARCHAR(20) DEFAULT '';"},
+		{DialectMySQL, "null", "absent", "ALTER TABLE `t` MODIFY COLUMN `s` VARCHAR(20);"},
+		{DialectMSSQL, "absent", "empty", "ALTER TABLE [t] ADD CONSTRAINT [df_t_s] DEFAULT '' FOR [s];"},
+		{DialectMSSQL, "absent", "null", "ALTER TABLE [t] ADD CONSTRAINT [df_t_s] DEFAULT NULL FOR [s];"},
+		{DialectMSSQL, "empty", "null", "ALTER TABLE [t] DROP CONSTRAINT [df_t_s];\nALTER TABLE [t] ADD CONSTRAINT [df_t_s] DEFAULT NULL FOR [s];"},
+		{DialectMSSQL, "empty", "absent", "ALTER TABLE [t] DROP CONSTRAINT [df_t_s];"},
+		{DialectMSSQL, "null", "empty", "ALTER TABLE [t] DROP CONSTRAINT [df_t_s];\nALTER TABLE [t] ADD CONSTRAINT [df_t_s] DEFAULT '' FOR [s];"},
+		{DialectMSSQL, "null", "absent", "ALTER TABLE [t] DROP CONSTRAINT [df_t_s];"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect+" "+tt.from+" to "+tt.to, func(t *testing.T) {
+			g := NewGenerator(&Config{Dialect: tt.dialect})
+			statements, err := g.GenerateStatements(table(stringDefaults[tt.from]), table(stringDefaults[tt.to]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(statements, "\n"); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+			same, err := g.GenerateStatements(table(stringDefaults[tt.to]), table(stringDefaults[tt.to]))
+			if err != nil || len(same) != 0 {
+				t.Errorf("unchanged %s default produced %v, %v", tt.to, same, err)
+			}
+		})
+	}
+}
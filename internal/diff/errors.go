@@ -0,0 +1,23 @@
+package diff
+
+import "fmt"
+
+// ValidationError dikembalikan ketika schema tidak bisa dirender menjadi DDL yang valid
+type ValidationError struct {
+	Table  string
+	Column string
+	Rule   string
+	Detail string
+}
+
+func (e *ValidationError) Error() string {
+	target := e.Table
+	if e.Column != "" {
+		target += "." + e.Column
+	}
+	msg := fmt.Sprintf("validation failed for %s (%s)", target, e.Rule)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
@@ -0,0 +1,71 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// CarryExtras menyalin Column.Extra dari current ke kolom desired yang sama
+// tetapi tanpa Extra. Schema program umumnya tidak menulis atribut yang tidak
+// dimodelkan (STORAGE, opsi identity), sehingga tanpa ini MODIFY
+// COLUMN akan menghapusnya dari database.
+func CarryExtras(current, desired *state.SchemaState) {
+	for name, table := range desired.Tables {
+		existing, ok := current.Tables[name]
+		if !ok {
+			continue
+		}
+		for colName, col := range table.Columns {
+			if col.Extra != "" {
+				continue
+			}
+			if currentCol, ok := existing.Columns[colName]; ok && currentCol.Extra != "" {
+				col.Extra = currentCol.Extra
+				table.Columns[colName] = col
+			}
+		}
+	}
+}
+
+// splitExtra memisahkan opsi identity Postgres (grup dalam kurung, mis.
+// "(CACHE 10)") dari atribut lain yang ditulis setelah tipe kolom
+func splitExtra(extra string) (attributes, identity string) {
+	var attrs []string
+	depth, start := 0, -1
+	for i, r := range extra {
+		switch {
+		case r == '(':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+			if depth == 0 && start >= 0 {
+				identity = extra[start : i+1]
+				start = -1
+			}
+		case depth == 0:
+			attrs = append(attrs, string(r))
+		}
+	}
+	return strings.Join(strings.Fields(strings.Join(attrs, "")), " "), identity
+}
+
+// postgresColumnAttributes mengembalikan atribut STORAGE dan COMPRESSION di
+// Extra, mis. "STORAGE EXTERNAL", yang bisa dipasang ulang dengan
+// ALTER COLUMN ... SET
+func postgresColumnAttributes(extra string) []string {
+	attributes, _ := splitExtra(extra)
+	fields := strings.Fields(attributes)
+	var result []string
+	for i := 0; i+1 < len(fields); i++ {
+		switch strings.ToUpper(fields[i]) {
+		case "STORAGE", "COMPRESSION":
+			result = append(result, strings.ToUpper(fields[i])+" "+fields[i+1])
+			i++
+		}
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+func TestSplitExtra(t *testing.T) {
+	tests := []struct {
+		extra, attributes, identity string
+	}{
+		{"", "", ""},
+		{"STORAGE EXTERNAL", "STORAGE EXTERNAL", ""},
+		{"(START WITH 100 CACHE 10)", "", "(START WITH 100 CACHE 10)"},
+		{"(SEQUENCE NAME s (x)) COMPRESSION  lz4", "COMPRESSION lz4", "(SEQUENCE NAME s (x))"},
+	}
+	for _, tt := range tests {
+		attributes, identity := splitExtra(tt.extra)
+		if attributes != tt.attributes || identity != tt.identity {
+			t.Errorf("splitExtra(%q) = %q, %q, want %q, %q", tt.extra, attributes, identity, tt.attributes, tt.identity)
+		}
+	}
+}
+
+func TestExtraPreserved(t *testing.T) {
+	id := state.Column{Type: "BIGINT", AutoIncrement: true, Identity: state.IdentityByDefault, Extra: "(START WITH 100 CACHE 10)"}
+	bareID := id
+	bareID.Extra = ""
+	intID := id
+	intID.Type = "INT"
+	body := state.Column{Name: "body", Type: "TEXT", Nullable: true, Extra: "STORAGE EXTERNAL"}
+	loc := state.Column{Name: "loc", Type: "POINT", SRID: 4326}
+	nullableLoc := loc
+	nullableLoc.Nullable = true
+	tests := []struct {
+		name             string
+		dialect          string
+		current, desired *state.SchemaState
+		want             []string
+	}{
+		{"identity options in CREATE TABLE", DialectPostgres, schemaOf(), eventsTable(id),
+			[]string{"CREATE TABLE \"events\" (\n  \"id\" BIGINT NOT NULL GENERATED BY DEFAULT AS IDENTITY (START WITH 100 CACHE 10)\n);"}},
+		{"identity options missing from the program", DialectPostgres, eventsTable(id), eventsTable(bareID), nil},
+		{"identity options survive a type change", DialectPostgres, eventsTable(intID), eventsTable(bareID),
+			[]string{"ALTER TABLE \"events\" ALTER COLUMN \"id\" TYPE BIGINT;"}},
+		{"storage re-applied after a type change", DialectPostgres, usersWith(body), usersWith(state.Column{Name: "body", Type: "VARCHAR(500)", Nullable: true}),
+			[]string{
+				"ALTER TABLE \"users\" ALTER COLUMN \"body\" TYPE VARCHAR(500);",
+				"ALTER TABLE \"users\" ALTER COLUMN \"body\" SET STORAGE EXTERNAL;",
+			}},
+		{"SRID unchanged", DialectMySQL, usersWith(loc), usersWith(loc), nil},
+		{"SRID kept by MODIFY COLUMN", DialectMySQL, usersWith(nullableLoc), usersWith(loc),
+			[]string{"ALTER TABLE `users` MODIFY COLUMN `loc` POINT SRID 4326 NOT NULL;"}},
+		{"SRID in CREATE TABLE", DialectMySQL, schemaOf(), usersWith(loc),
+			[]string{"CREATE TABLE `users` (\n  `id` INT NOT NULL,\n  `loc` POINT SRID 4326 NOT NULL\n);"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewGenerator(&Config{Dialect: tt.dialect}).GenerateStatements(tt.current, tt.desired)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements:\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCarryExtras(t *testing.T) {
+	current := usersWith(state.Column{Name: "body", Type: "TEXT", Extra: "STORAGE EXTERNAL"},
+		state.Column{Name: "note", Type: "TEXT", Extra: "STORAGE MAIN"})
+	desired := usersWith(state.Column{Name: "body", Type: "TEXT"},
+		state.Column{Name: "note", Type: "TEXT", Extra: "COMPRESSION lz4"})
+	desired.Tables["posts"] = state.Table{Name: "posts", Columns: map[string]state.Column{
+		"body": {Name: "body", Type: "TEXT"},
+	}}
+	CarryExtras(current, desired)
+	for table, want := range map[string]map[string]string{
+		"users": {"id": "", "body": "STORAGE EXTERNAL", "note": "COMPRESSION lz4"},
+		"posts": {"body": ""},
+	} {
+		for column, extra := range want {
+			if got := desired.Tables[table].Columns[column].Extra; got != extra {
+				t.Errorf("%s.%s extra = %q, want %q", table, column, got, extra)
+			}
+		}
+	}
+}
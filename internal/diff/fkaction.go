@@ -0,0 +1,141 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// ForeignKeyActionDefault adalah aksi ON DELETE/ON UPDATE yang dipakai engine
+// jika foreign key tidak menyebutnya, di semua dialect yang didukung
+const ForeignKeyActionDefault = "NO ACTION"
+
+// foreignKeyActions adalah aksi ON DELETE/ON UPDATE yang dikenali
+var foreignKeyActions = []string{"CASCADE", "RESTRICT", "SET NULL", "SET DEFAULT", "NO ACTION"}
+
+// IsForeignKeyAction mengecek apakah action adalah aksi foreign key yang dikenali
+func IsForeignKeyAction(action string) bool {
+	action = normalizeAction(action)
+	for _, known := range foreignKeyActions {
+		if action == known {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultForeignKeyActionEquivalence mengembalikan kelas aksi yang dianggap
+// sama oleh dialect. MySQL memperlakukan NO ACTION sebagai RESTRICT dan SQL
+// Server tidak mengenal RESTRICT, sedangkan Postgres dan CockroachDB
+// membedakan keduanya (NO ACTION bisa ditunda sampai akhir transaksi).
+func DefaultForeignKeyActionEquivalence(dialect string) [][]string {
+	switch dialect {
+	case DialectMySQL, DialectMSSQL:
+		return [][]string{{"NO ACTION", "RESTRICT"}}
+	}
+	return nil
+}
+
+// canonicalAction mengembalikan bentuk kanonik aksi foreign key: aksi kosong
+// menjadi default engine, lalu anggota pertama kelas ekuivalensinya
+func (g *Generator) canonicalAction(action string) string {
+	action = normalizeAction(action)
+	if action == "" {
+		action = ForeignKeyActionDefault
+	}
+	classes := g.config.ForeignKeyActionEquivalence
+	if classes == nil {
+		classes = DefaultForeignKeyActionEquivalence(g.config.Dialect)
+	}
+	for _, class := range classes {
+		for _, member := range class {
+			if normalizeAction(member) == action {
+				return normalizeAction(class[0])
+			}
+		}
+	}
+	return action
+}
+
+// canonicalActions menulis ulang klausa setelah REFERENCES tabel (kolom) dalam
+// bentuk kanonik: ON DELETE lalu ON UPDATE, dengan aksi kanonik dan tanpa aksi
+// yang sama dengan default engine. Klausa lain (MATCH, DEFERRABLE) dibiarkan
+// di posisinya, sebelum atau sesudah aksi.
+func (g *Generator) canonicalActions(rest string) string {
+	words := strings.Fields(rest)
+	var before, after []string
+	actions := map[string]string{}
+	found := false
+	for i := 0; i < len(words); i++ {
+		upper := strings.ToUpper(words[i])
+		if upper == "ON" && i+2 < len(words) {
+			event := strings.ToUpper(words[i+1])
+			if event == "DELETE" || event == "UPDATE" {
+				action := strings.ToUpper(words[i+2])
+				i += 2
+				// SET NULL, SET DEFAULT dan NO ACTION terdiri dari dua kata
+				if (action == "SET" || action == "NO") && i+1 < len(words) {
+					action += " " + strings.ToUpper(words[i+1])
+					i++
+				}
+				actions[event] = action
+				found = true
+				continue
+			}
+		}
+		if found {
+			after = append(after, words[i])
+		} else {
+			before = append(before, words[i])
+		}
+	}
+
+	parts := before
+	for _, event := range []string{"DELETE", "UPDATE"} {
+		if action := g.canonicalAction(actions[event]); action != g.canonicalAction("") {
+			parts = append(parts, "ON "+event+" "+action)
+		}
+	}
+	return strings.Join(append(parts, after...), " ")
+}
+
+// CanonicalForeignKeys menulis ulang aksi setiap FOREIGN KEY di schema ke
+// bentuk kanonik yang juga dipakai saat render, sehingga snapshot sama dengan
+// hasil menjalankan ulang migration yang di-generate darinya
+func (g *Generator) CanonicalForeignKeys(schema *state.SchemaState) {
+	for name, table := range schema.Tables {
+		for i, c := range table.Constraints {
+			if c.Type == "FOREIGN KEY" {
+				table.Constraints[i].Def = g.canonicalForeignKeyDef(c.Def)
+			}
+		}
+		schema.Tables[name] = table
+	}
+}
+
+// canonicalForeignKeyDef menerapkan canonicalActions pada definisi foreign
+// key tanpa mengubah quote identifier-nya
+func (g *Generator) canonicalForeignKeyDef(def string) string {
+	at := strings.Index(strings.ToUpper(def), "REFERENCES ")
+	if at == -1 {
+		return def
+	}
+	_, after := nextIdent(def[at+len("REFERENCES"):])
+	if strings.HasPrefix(after, "(") {
+		end := matchingParen(after)
+		if end == -1 {
+			return def
+		}
+		after = after[end+1:]
+	}
+	head := strings.TrimSpace(def[:len(def)-len(after)])
+	if tail := g.canonicalActions(after); tail != "" {
+		return head + " " + tail
+	}
+	return head
+}
+
+// normalizeAction menyeragamkan huruf dan spasi aksi foreign key
+func normalizeAction(action string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(action), " "))
+}
@@ -0,0 +1,123 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// postsWithActions membuat users dan posts dengan foreign key posts.user_id
+// yang diakhiri klausa aksi actions
+func postsWithActions(actions string) *state.SchemaState {
+	return schemaOf(
+		state.Table{Name: "users", Columns: map[string]state.Column{
+			"id": {Name: "id", Type: "INT", Position: 1},
+		}, Constraints: []state.Constraint{{Name: "pk_users", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"}}},
+		state.Table{Name: "posts", Columns: map[string]state.Column{
+			"id":      {Name: "id", Type: "INT", Position: 1},
+			"user_id": {Name: "user_id", Type: "INT", Position: 2},
+		}, Constraints: []state.Constraint{{Name: "fk_posts_user_id", Type: "FOREIGN KEY",
+			Def: "CONSTRAINT fk_posts_user_id FOREIGN KEY (user_id) REFERENCES users (id)" + actions}}},
+	)
+}
+
+var allDialects = []string{DialectPostgres, DialectMySQL, DialectMSSQL, DialectCockroach}
+
+func TestForeignKeyActionEquivalence(t *testing.T) {
+	tests := []struct {
+		name        string
+		from, to    string
+		equivalence [][]string
+		// changed berisi dialect yang harus menghasilkan perubahan
+		changed []string
+	}{
+		{name: "unspecified vs introspected NO ACTION", from: "", to: " ON DELETE NO ACTION ON UPDATE NO ACTION"},
+		{name: "unspecified vs NO ACTION on delete only", from: "", to: " ON DELETE NO ACTION"},
+		{name: "unspecified vs RESTRICT", from: "", to: " ON DELETE RESTRICT",
+			changed: []string{DialectPostgres, DialectCockroach}},
+		{name: "NO ACTION vs RESTRICT", from: " ON DELETE NO ACTION", to: " ON DELETE RESTRICT",
+			changed: []string{DialectPostgres, DialectCockroach}},
+		{name: "case and spacing", from: " ON DELETE SET NULL", to: " on  delete  set   null"},
+		{name: "clause order", from: " ON UPDATE CASCADE ON DELETE SET NULL", to: " ON DELETE SET NULL ON UPDATE CASCADE"},
+		{name: "CASCADE removed", from: " ON DELETE CASCADE", to: "",
+			changed: allDialects},
+		{name: "CASCADE vs SET NULL", from: " ON DELETE CASCADE", to: " ON DELETE SET NULL",
+			changed: allDialects},
+		{name: "configured equivalence", from: "", to: " ON DELETE RESTRICT",
+			equivalence: [][]string{{"no action", "restrict"}}},
+		{name: "empty equivalence makes every action distinct", from: "", to: " ON DELETE RESTRICT",
+			equivalence: [][]string{}, changed: allDialects},
+	}
+	for _, tt := range tests {
+		for _, dialect := range allDialects {
+			t.Run(tt.name+" "+dialect, func(t *testing.T) {
+				g := NewGenerator(&Config{Dialect: dialect, ForeignKeyActionEquivalence: tt.equivalence})
+				statements, err := g.GenerateStatements(postsWithActions(tt.from), postsWithActions(tt.to))
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := false
+				for _, d := range tt.changed {
+					want = want || d == dialect
+				}
+				if got := len(statements) > 0; got != want {
+					t.Errorf("changed = %v, want %v: %q", got, want, statements)
+				}
+			})
+		}
+	}
+}
+
+func TestForeignKeyActionRendering(t *testing.T) {
+	tests := []struct {
+		dialect string
+		actions string
+		want    string
+	}{
+		{DialectPostgres, " ON DELETE NO ACTION ON UPDATE NO ACTION", `REFERENCES "users" ("id")` + "\n"},
+		{DialectPostgres, " ON DELETE RESTRICT ON UPDATE NO ACTION", `REFERENCES "users" ("id") ON DELETE RESTRICT` + "\n"},
+		{DialectPostgres, " on update cascade on delete set null", `REFERENCES "users" ("id") ON DELETE SET NULL ON UPDATE CASCADE` + "\n"},
+		{DialectCockroach, " ON DELETE RESTRICT", `REFERENCES "users" ("id") ON DELETE RESTRICT` + "\n"},
+		{DialectMySQL, " ON DELETE RESTRICT ON UPDATE RESTRICT", "REFERENCES `users` (`id`)\n"},
+		{DialectMySQL, " ON DELETE CASCADE ON UPDATE RESTRICT", "REFERENCES `users` (`id`) ON DELETE CASCADE\n"},
+		// SQL Server tidak mengenal RESTRICT
+		{DialectMSSQL, " ON DELETE RESTRICT", "REFERENCES [users] ([id])\n"},
+		{DialectMSSQL, " ON DELETE SET DEFAULT", "REFERENCES [users] ([id]) ON DELETE SET DEFAULT\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect+tt.actions, func(t *testing.T) {
+			statements, err := NewGenerator(&Config{Dialect: tt.dialect}).GenerateStatements(schemaOf(), postsWithActions(tt.actions))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(statements, "\n"); !strings.Contains(got, tt.want) {
+				t.Errorf("got:\n%s\nwant foreign key ending in %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalForeignKeys(t *testing.T) {
+	tests := []struct {
+		dialect string
+		def     string
+		want    string
+	}{
+		{DialectPostgres, "FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE NO ACTION", "FOREIGN KEY (user_id) REFERENCES users (id)"},
+		{DialectPostgres, `FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE RESTRICT`, `FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE RESTRICT`},
+		{DialectPostgres, "FOREIGN KEY (user_id) REFERENCES users (id) MATCH FULL ON UPDATE CASCADE DEFERRABLE", "FOREIGN KEY (user_id) REFERENCES users (id) MATCH FULL ON UPDATE CASCADE DEFERRABLE"},
+		{DialectMySQL, "FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE", "FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON UPDATE CASCADE"},
+		{DialectMySQL, "FOREIGN KEY (user_id) REFERENCES users", "FOREIGN KEY (user_id) REFERENCES users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect+" "+tt.def, func(t *testing.T) {
+			schema := schemaOf(state.Table{Name: "posts", Columns: map[string]state.Column{},
+				Constraints: []state.Constraint{{Name: "fk", Type: "FOREIGN KEY", Def: tt.def}}})
+			NewGenerator(&Config{Dialect: tt.dialect}).CanonicalForeignKeys(schema)
+			if got := schema.Tables["posts"].Constraints[0].Def; got != tt.want {
+				t.Errorf("CanonicalForeignKeys() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// integerTypes adalah tipe integer MySQL dengan lebar yang harus sama
+// antara kolom foreign key dan kolom yang direferensikan
+var integerTypes = map[string]string{
+	"tinyint":   "tinyint",
+	"smallint":  "smallint",
+	"mediumint": "mediumint",
+	"int":       "int",
+	"integer":   "int",
+	"bigint":    "bigint",
+}
+
+// foreignKey adalah kolom lokal dan kolom referensi dari definisi FOREIGN KEY
+type foreignKey struct {
+	columns    []string
+	refTable   string
+	refColumns []string
+}
+
+// parseForeignKey membaca definisi constraint FOREIGN KEY
+func parseForeignKey(def string) (foreignKey, bool) {
+	rest := strings.TrimSpace(def)
+	if hasKeyword(rest, "CONSTRAINT") {
+		_, rest = nextIdent(rest[len("CONSTRAINT"):])
+		rest = strings.TrimSpace(rest)
+	}
+	if !hasKeyword(rest, "FOREIGN KEY") {
+		return foreignKey{}, false
+	}
+	var fk foreignKey
+	var ok bool
+	if fk.columns, rest, ok = identNames(rest[len("FOREIGN KEY"):]); !ok {
+		return foreignKey{}, false
+	}
+	rest = strings.TrimSpace(rest)
+	if !hasKeyword(rest, "REFERENCES") {
+		return foreignKey{}, false
+	}
+	fk.refTable, rest = nextIdent(rest[len("REFERENCES"):])
+	if fk.refColumns, _, ok = identNames(rest); !ok {
+		return foreignKey{}, false
+	}
+	return fk, true
+}
+
+// identNames membaca nama-nama kolom dalam tanda kurung di awal s
+func identNames(s string) ([]string, string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") {
+		return nil, s, false
+	}
+	end := matchingParen(s)
+	if end == -1 {
+		return nil, s, false
+	}
+	var names []string
+	for _, part := range splitOutsideParens(s[1:end]) {
+		if name, _ := nextIdent(part); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, s[end+1:], true
+}
+
+// validateForeignKeyTypes memastikan kolom integer foreign key punya lebar dan
+// sign yang sama dengan kolom yang direferensikan. MySQL menolak membuat
+// constraint antara mis. BIGINT UNSIGNED dan BIGINT.
+func (g *Generator) validateForeignKeyTypes(schema *state.SchemaState) error {
+	if g.config.Dialect != DialectMySQL {
+		return nil
+	}
+	for _, table := range sortedTables(schema.Tables) {
+		for _, constraint := range table.Constraints {
+			fk, ok := parseForeignKey(constraint.Def)
+			if !ok || len(fk.columns) != len(fk.refColumns) {
+				continue
+			}
+			refTable, ok := schema.Tables[fk.refTable]
+			if !ok {
+				continue
+			}
+			for i, name := range fk.columns {
+				col, ok := table.Columns[name]
+				ref, refOK := refTable.Columns[fk.refColumns[i]]
+				if !ok || !refOK {
+					continue
+				}
+				colBase, colUnsigned, colInt := integerType(col.Type)
+				refBase, refUnsigned, refInt := integerType(ref.Type)
+				if !colInt || !refInt || (colBase == refBase && colUnsigned == refUnsigned) {
+					continue
+				}
+				return &ValidationError{
+					Table:  table.Name,
+					Column: col.Name,
+					Rule:   "fk-type",
+					Detail: fmt.Sprintf("type %s does not match %s.%s type %s; foreign key columns must have the same integer width and sign",
+						col.Type, refTable.Name, ref.Name, ref.Type),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// integerType mengembalikan tipe integer dasar (tanpa display width) dan
+// apakah tipe tersebut UNSIGNED
+func integerType(sqlType string) (string, bool, bool) {
+	fields := strings.Fields(strings.ToLower(sqlType))
+	if len(fields) == 0 {
+		return "", false, false
+	}
+	base := fields[0]
+	if open := strings.Index(base, "("); open != -1 {
+		base = base[:open]
+	}
+	base, ok := integerTypes[base]
+	if !ok {
+		return "", false, false
+	}
+	unsigned := false
+	for _, field := range fields[1:] {
+		if field == "unsigned" {
+			unsigned = true
+		}
+	}
+	return base, unsigned, true
+}
@@ -2,6 +2,8 @@ package diff
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,229 +13,1411 @@ import (
 // Generator menangani pembuatan diff antara dua schema
 type Generator struct {
 	config *Config
+	// warnings dikumpulkan oleh GenerateStatements atau Changes terakhir
+	warnings state.Warnings
+	// progress diisi SetProgress
+	progress Progress
+	// down aktif selama GenerateDownStatements
+	down bool
+	// tracer diisi SetTracer
+	tracer Tracer
 }
 
+// Dialect yang didukung oleh generator
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+	DialectMSSQL    = "mssql"
+	// DialectCockroach adalah CockroachDB: sintaks Postgres dengan perbedaan
+	// yang ditangani di cockroach.go
+	DialectCockroach = "cockroach"
+)
+
+// Penempatan index saat membuat tabel baru
+const (
+	// IndexPlacementSeparate membuat index dengan CREATE INDEX setelah CREATE TABLE
+	IndexPlacementSeparate = "separate"
+	// IndexPlacementInline menulis index sebagai KEY di dalam CREATE TABLE (MySQL saja)
+	IndexPlacementInline = "inline"
+)
+
 // Config menyimpan konfigurasi untuk generator
 type Config struct {
+	Dialect   string
 	Charset   string
 	Collation string
 	Engine    string
+	// Identity adalah strategi auto increment default untuk Postgres;
+	// kosong berarti state.IdentityByDefault
+	Identity string
+	// IndexPlacement menentukan letak index pada tabel baru; kosong berarti
+	// IndexPlacementSeparate. Postgres selalu memakai IndexPlacementSeparate.
+	IndexPlacement string
+	// RowFormat adalah row format InnoDB (dynamic, compressed, compact, redundant)
+	// yang menentukan batas ukuran key index; kosong berarti dynamic
+	RowFormat string
+	// BatchAlter menggabungkan semua perubahan satu tabel menjadi satu
+	// ALTER TABLE agar MySQL hanya me-rebuild tabel sekali. Diabaikan di Postgres.
+	BatchAlter bool
+	// SensitivePatterns adalah potongan nama kolom yang otomatis ditandai
+	// sensitif; nil berarti DefaultSensitivePatterns
+	SensitivePatterns []string
+	// Strict mengubah peringatan (mis. ukuran baris MySQL) menjadi ValidationError
+	Strict bool
+	// StrictTags mengubah peringatan key tag yang tidak dikenal menjadi ValidationError
+	StrictTags bool
+	// Redact menyembunyikan default dan komentar kolom sensitif. Hanya untuk
+	// statement yang ditampilkan, bukan yang ditulis ke file migration.
+	Redact bool
+	// Online menambahkan ALGORITHM=INPLACE, LOCK=NONE (MySQL; ALGORITHM=INSTANT
+	// untuk ADD COLUMN jika ServerVersion mendukungnya) atau CONCURRENTLY
+	// (index Postgres) pada perubahan tabel yang sudah ada, dan
+	// komentar peringatan untuk operasi yang tidak bisa dijalankan online
+	Online bool
+	// RequireClassification mewajibkan class=... pada setiap kolom baru.
+	// Kolom lama tanpa class hanya menghasilkan peringatan.
+	RequireClassification bool
+	// DiffIgnore adalah pola "tabel.kolom[:kebijakan]" untuk kolom yang
+	// perubahannya tidak dijadikan migration, sama dengan tag diff=...
+	DiffIgnore []string
+	// DropCascade menambahkan CASCADE pada DROP TABLE (Postgres) atau
+	// mematikan FOREIGN_KEY_CHECKS selama DROP TABLE (MySQL). Tanpa opsi ini
+	// objek di luar datara yang bergantung pada tabel membuat drop gagal.
+	DropCascade bool
+	// ColumnOrder mengatur urutan kolom saat CREATE TABLE dan posisi AFTER
+	// kolom baru (MySQL), mis. ["id", "*", "created_at"]. "*" berarti kolom
+	// lain sesuai urutan deklarasi; tanpa "*" kolom lain diletakkan di akhir.
+	// Urutan hanya memengaruhi rendering, bukan perbandingan kolom.
+	ColumnOrder []string
+	// ColumnGroups mengelompokkan kolom CREATE TABLE per tag group=... (juga
+	// diisi dari batas struct yang di-flatten) dan menulis komentar "-- Grup"
+	// sebelum setiap kelompok. Komentar tidak dibaca parser dan tidak ikut
+	// hash, sehingga tidak pernah menghasilkan perubahan.
+	ColumnGroups bool
+	// ServerVersion adalah versi server target dari migration.server_version.
+	// Sintaks yang tidak didukung versi tersebut dirender dengan fallback
+	// (mis. CHECK menjadi komentar) atau ditolak saat validasi.
+	ServerVersion ServerVersion
+	// SafeConstraints menambahkan FOREIGN KEY dan CHECK pada tabel yang sudah
+	// ada dengan NOT VALID, lalu VALIDATE CONSTRAINT di statement terpisah,
+	// sehingga tabel besar tidak dikunci selama data lama diperiksa
+	// (Postgres). MySQL tetap memakai satu statement.
+	SafeConstraints bool
+	// AllowNotNullWithoutDefault menurunkan ADD COLUMN NOT NULL tanpa default
+	// pada tabel yang sudah ada dari ValidationError menjadi peringatan
+	AllowNotNullWithoutDefault bool
+	// ExpandNotNull menulis ADD COLUMN NOT NULL tanpa default pada tabel yang
+	// sudah ada sebagai tiga langkah: kolom nullable, backfill, SET NOT NULL
+	ExpandNotNull bool
+	// BackfillBatchSize adalah jumlah baris per UPDATE backfill ExpandNotNull;
+	// 0 berarti DefaultBackfillBatchSize
+	BackfillBatchSize int
+	// ForeignKeyActionEquivalence adalah kelas aksi ON DELETE/ON UPDATE yang
+	// dianggap sama, mis. [["NO ACTION", "RESTRICT"]]; anggota pertama dipakai
+	// saat render. Nil berarti DefaultForeignKeyActionEquivalence dialect.
+	ForeignKeyActionEquivalence [][]string
+}
+
+// DefaultSensitivePatterns menandai kolom seperti password_hash atau api_token
+// sebagai sensitif jika Config.SensitivePatterns tidak diisi
+var DefaultSensitivePatterns = []string{"password", "secret", "token"}
+
+// Warnings mengembalikan peringatan dari pemanggilan GenerateStatements,
+// Changes atau Plan terakhir
+func (g *Generator) Warnings() state.Warnings {
+	return g.warnings
 }
 
 // NewGenerator membuat instance baru dari Generator
 func NewGenerator(config *Config) *Generator {
 	if config == nil {
 		config = &Config{
-			Charset:   "utf8mb4",
-			Collation: "utf8mb4_unicode_ci",
-			Engine:    "InnoDB",
+			Dialect:    DialectMySQL,
+			Charset:    "utf8mb4",
+			Collation:  "utf8mb4_unicode_ci",
+			Engine:     "InnoDB",
+			BatchAlter: true,
 		}
 	}
+	if config.Dialect == "" {
+		config.Dialect = DialectMySQL
+	}
 	return &Generator{config: config}
 }
 
+// GenerateDownStatements sama dengan GenerateStatements untuk down migration
+// dari desired kembali ke current. Kolom yang dikembalikan down tidak
+// diperiksa atau di-expand sebagai kolom NOT NULL baru: datanya sudah hilang
+// bersama DROP COLUMN di up.
+func (g *Generator) GenerateDownStatements(desired, current *state.SchemaState) ([]string, error) {
+	g.down = true
+	defer func() { g.down = false }()
+	return g.GenerateStatements(desired, current)
+}
+
 // GenerateDiff membuat diff antara dua schema
 func (g *Generator) GenerateDiff(current, desired *state.SchemaState) (string, error) {
+	statements, err := g.GenerateStatements(current, desired)
+	if err != nil {
+		return "", err
+	}
+
+	if len(statements) == 0 {
+		return "", nil // No changes
+	}
+
+	// CREATE INDEX CONCURRENTLY tidak bisa dijalankan di dalam transaksi
+	if NonTransactional(statements) {
+		return fmt.Sprintf("-- Generated by Datara at %s\n\n%s\n",
+			time.Now().Format("2006-01-02 15:04:05"),
+			strings.Join(statements, "\n\n")), nil
+	}
+
+	// Wrap in transaction
+	return fmt.Sprintf("-- Generated by Datara at %s\n\nBEGIN;\n\n%s\n\nCOMMIT;\n",
+		time.Now().Format("2006-01-02 15:04:05"),
+		strings.Join(statements, "\n\n")), nil
+}
+
+// GenerateStatements membuat statement SQL yang mengubah current menjadi desired.
+// Urutan output deterministik dan mengikuti foreign key: drop foreign key ke
+// tabel yang di-drop, drop tabel (yang mereferensikan lebih dulu), lalu create
+// dan alter tabel (yang direferensikan lebih dulu).
+func (g *Generator) GenerateStatements(current, desired *state.SchemaState) ([]string, error) {
 	var statements []string
 
-	// 1. Handle dropped tables
-	for tableName := range current.Tables {
-		if _, exists := desired.Tables[tableName]; !exists {
-			stmt := fmt.Sprintf("DROP TABLE `%s`;", tableName)
-			statements = append(statements, stmt)
-		}
+	g.warnings = nil
+	current = g.applyTags(current)
+	desired = g.applyTags(desired)
+	CarryExtras(current, desired)
+	g.trace(TraceNormalizedSnapshot, current)
+	g.trace(TraceNormalizedSchema, desired)
+	if err := g.validate(current, desired); err != nil {
+		return nil, err
+	}
+	desired, ignored := g.applyDiffPolicy(current, desired)
+	g.warnIgnored(ignored)
+
+	// 1. Handle foreign keys to dropped tables
+	for _, fk := range detachDroppedReferences(current, desired) {
+		statements = append(statements, g.generateDetach(fk))
 	}
 
-	// 2. Handle new and modified tables
-	for tableName, desiredTable := range desired.Tables {
-		if currentTable, exists := current.Tables[tableName]; !exists {
+	// 2. Handle dropped tables
+	dropped := droppedTables(current, desired)
+	for _, fk := range g.cyclicReferences(dropped) {
+		statements = append(statements, g.generateDetach(fk))
+	}
+	for _, table := range dropped {
+		statements = append(statements, g.generateDropTable(table))
+	}
+
+	// 3. Handle new and modified tables
+	for i, desiredTable := range dependencyOrder(sortedTables(desired.Tables)) {
+		g.stage(StageDiff, i, len(desired.Tables))
+		if currentTable, exists := current.Tables[desiredTable.Name]; !exists {
 			// New table
 			stmt, err := g.generateCreateTable(desiredTable)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
-			statements = append(statements, stmt)
+			warning, err := g.checkRowSize(desiredTable)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, withWarning(warning, stmt))
+			statements = appendRaw(statements, rawUp(desiredTable))
 		} else {
 			// Existing table - check for modifications
 			stmts, err := g.generateAlterTable(currentTable, desiredTable)
 			if err != nil {
-				return "", err
+				return nil, err
+			}
+			if len(stmts) > 0 && g.columnsChanged(currentTable, desiredTable) {
+				warning, err := g.checkRowSize(desiredTable)
+				if err != nil {
+					return nil, err
+				}
+				stmts[0] = withWarning(warning, stmts[0])
+			}
+			// Raw DDL yang berubah: down versi lama, lalu up versi baru
+			rawChanged := !currentTable.RawDDL.Equal(desiredTable.RawDDL)
+			if rawChanged {
+				statements = appendRaw(statements, rawDown(currentTable))
 			}
 			statements = append(statements, stmts...)
+			if rawChanged {
+				statements = appendRaw(statements, rawUp(desiredTable))
+			}
 		}
 	}
+	g.stage(StageDiff, len(desired.Tables), len(desired.Tables))
 
-	if len(statements) == 0 {
-		return "", nil // No changes
+	return statements, nil
+}
+
+// columnsChanged mengecek apakah ukuran baris atau jumlah kolom tabel berubah
+func (g *Generator) columnsChanged(current, desired state.Table) bool {
+	if len(current.Columns) != len(desired.Columns) {
+		return true
 	}
+	currentSize, _ := g.rowSize(current)
+	desiredSize, _ := g.rowSize(desired)
+	return currentSize != desiredSize
+}
 
-	// Wrap in transaction
-	return fmt.Sprintf("-- Generated by Datara at %s\n\nBEGIN;\n\n%s\n\nCOMMIT;\n",
-		time.Now().Format("2006-01-02 15:04:05"),
-		strings.Join(statements, "\n\n")), nil
+// withWarning menambahkan komentar peringatan di atas statement
+func withWarning(warning, stmt string) string {
+	if warning == "" {
+		return stmt
+	}
+	return warning + "\n" + stmt
+}
+
+// generateDropTable membuat statement DROP TABLE. Index yang dibuat terpisah
+// di-drop lebih dulu, kebalikan dari urutan generateCreateTable.
+func (g *Generator) generateDropTable(table state.Table) string {
+	var b strings.Builder
+	if down := rawDown(table); down != "" {
+		b.WriteString(down + "\n\n")
+	}
+	if !g.inlineIndexes() {
+		indexes := sortedIndexes(table.Indexes)
+		for i := len(indexes) - 1; i >= 0; i-- {
+			fmt.Fprintf(&b, "%s;\n\n", g.generateDropIndex(table.Name, indexes[i].Name))
+		}
+	}
+
+	switch {
+	case g.postgresSyntax() && g.config.DropCascade:
+		fmt.Fprintf(&b, "DROP TABLE IF EXISTS %s CASCADE;", g.quote(table.Name))
+	case g.postgresSyntax():
+		fmt.Fprintf(&b, "DROP TABLE IF EXISTS %s;", g.quote(table.Name))
+	case g.config.Dialect == DialectMySQL && g.config.DropCascade:
+		// MySQL mengabaikan CASCADE pada DROP TABLE
+		fmt.Fprintf(&b, "SET FOREIGN_KEY_CHECKS=0;\nDROP TABLE %s;\nSET FOREIGN_KEY_CHECKS=1;", g.quote(table.Name))
+	default:
+		fmt.Fprintf(&b, "DROP TABLE %s;", g.quote(table.Name))
+	}
+	// Trigger ikut ter-drop bersama tabel, fungsinya tidak
+	for _, col := range sortedColumns(table.Columns) {
+		if col.OnUpdate != "" && g.config.Dialect == DialectPostgres {
+			fmt.Fprintf(&b, "\n\n%s;", g.dropOnUpdateFunction(table.Name, col.Name))
+		}
+	}
+	return b.String()
+}
+
+// inlineIndexes mengecek apakah index tabel baru ditulis di dalam CREATE TABLE
+func (g *Generator) inlineIndexes() bool {
+	return g.config.IndexPlacement == IndexPlacementInline && g.config.Dialect == DialectMySQL
 }
 
 // generateCreateTable membuat statement CREATE TABLE
 func (g *Generator) generateCreateTable(table state.Table) (string, error) {
 	var b strings.Builder
 
-	fmt.Fprintf(&b, "CREATE TABLE `%s` (\n", table.Name)
+	fmt.Fprintf(&b, "CREATE TABLE %s (\n", g.quote(table.Name))
 
 	// Columns
 	var columnDefs []string
-	for _, col := range table.Columns {
-		def := fmt.Sprintf("  `%s` %s", col.Name, col.Type)
-		if !col.Nullable {
-			def += " NOT NULL"
-		}
-		if col.AutoIncrement {
-			def += " AUTO_INCREMENT"
-		}
-		if col.DefaultValue != nil {
-			def += fmt.Sprintf(" DEFAULT %v", col.DefaultValue)
+	group := ""
+	for _, col := range g.orderedColumns(table.Columns) {
+		def := fmt.Sprintf("  %s %s", g.quote(col.Name), g.tableColumnDef(table.Name, col))
+		if name := columnGroup(col); g.config.ColumnGroups && name != group {
+			// Kolom tanpa group setelah sebuah kelompok diberi penanda sendiri
+			// agar tidak terbaca sebagai bagian kelompok sebelumnya
+			label := name
+			if label == "" {
+				label = ungroupedLabel
+			}
+			def = "  -- " + label + "\n" + def
+			group = name
 		}
 		columnDefs = append(columnDefs, def)
 	}
 
 	// Constraints
+	var skipped []string
 	for _, constraint := range table.Constraints {
-		columnDefs = append(columnDefs, fmt.Sprintf("  %s", constraint.Def))
+		if note := g.unsupportedConstraint(constraint); note != "" {
+			skipped = append(skipped, note)
+			continue
+		}
+		columnDefs = append(columnDefs, fmt.Sprintf("  %s", g.formatConstraint(constraint.Def)))
+	}
+
+	// Inline indexes (MySQL)
+	if g.inlineIndexes() {
+		for _, idx := range sortedIndexes(table.Indexes) {
+			columns, err := g.indexColumns(table, idx)
+			if err != nil {
+				return "", err
+			}
+			key := "KEY"
+			switch {
+			case idx.Unique:
+				key = "UNIQUE KEY"
+			case idx.Spatial:
+				key = "SPATIAL KEY"
+			}
+			def := fmt.Sprintf("  %s %s (%s)", key, g.quote(idx.Name), strings.Join(columns, ", "))
+			if comment := indexComment(idx); comment != "" {
+				def = "  " + comment + "\n" + def
+			}
+			columnDefs = append(columnDefs, def)
+		}
 	}
 
 	b.WriteString(strings.Join(columnDefs, ",\n"))
 	b.WriteString("\n)")
 
 	// Table options
-	fmt.Fprintf(&b, " ENGINE=%s", g.config.Engine)
-	fmt.Fprintf(&b, " DEFAULT CHARSET=%s", g.config.Charset)
-	fmt.Fprintf(&b, " COLLATE=%s;", g.config.Collation)
+	if g.config.Dialect == DialectMySQL {
+		b.WriteString(g.tableOptionsSQL(table))
+	}
+	b.WriteString(";")
 
 	// Indexes (created after table)
-	for _, idx := range table.Indexes {
-		unique := ""
-		if idx.Unique {
-			unique = "UNIQUE "
+	if !g.inlineIndexes() {
+		for _, idx := range sortedIndexes(table.Indexes) {
+			stmt, err := g.generateCreateIndex(table, idx)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "\n\n%s", withWarning(indexComment(idx), stmt+";"))
+		}
+	}
+
+	// Postgres tidak mendukung COMMENT inline
+	if g.postgresSyntax() {
+		for _, col := range sortedColumns(table.Columns) {
+			if columnComment(col) != "" {
+				fmt.Fprintf(&b, "\n\n%s;", g.generateColumnComment(table.Name, col))
+			}
+		}
+	}
+	for _, col := range sortedColumns(table.Columns) {
+		for _, stmt := range g.onUpdateStatements(table.Name, col) {
+			fmt.Fprintf(&b, "\n\n%s", terminate(stmt))
 		}
-		fmt.Fprintf(&b, "\n\nCREATE %sINDEX `%s` ON `%s` (%s);",
-			unique, idx.Name, table.Name,
-			strings.Join(quoteColumns(idx.Columns), ", "))
 	}
 
-	return b.String(), nil
+	return withWarning(strings.Join(skipped, "\n"), b.String()), nil
 }
 
 // generateAlterTable membuat statements ALTER TABLE untuk modifikasi
 func (g *Generator) generateAlterTable(current, desired state.Table) ([]string, error) {
 	var statements []string
+	tableName := g.quote(desired.Name)
 
-	// 1. Handle column changes
-	for colName, desiredCol := range desired.Columns {
+	currentConstraints := constraintsByName(current.Constraints)
+	desiredConstraints := constraintsByName(desired.Constraints)
+
+	// 1. Handle table options (MySQL); ENGINE diubah lebih dulu karena
+	// menentukan index yang bisa dibuat, mis. FULLTEXT di MyISAM
+	if g.config.Dialect == DialectMySQL {
+		if clauses := g.tableOptionChanges(current, desired); len(clauses) > 0 {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s %s", tableName, strings.Join(clauses, " ")))
+		}
+	}
+
+	// 2. Handle dropped or modified constraints
+	var skipped []string
+	for _, constraint := range current.Constraints {
+		if desiredConstraint, exists := desiredConstraints[constraintKey(constraint)]; !exists || !g.constraintsEqual(desiredConstraint, constraint) {
+			if g.unsupportedConstraint(constraint) != "" {
+				continue
+			}
+			statements = append(statements, g.generateDropConstraint(desired.Name, constraint))
+		}
+	}
+
+	// 3. Handle column changes
+	var recollated, backfills []string
+	desiredColumns := g.orderedColumns(desired.Columns)
+	for i, desiredCol := range desiredColumns {
+		colName := desiredCol.Name
 		if currentCol, exists := current.Columns[colName]; !exists {
-			// New column
-			stmt := fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s",
-				desired.Name, colName, g.generateColumnDef(desiredCol))
+			// New column; SQL Server tidak memakai keyword COLUMN
+			add := "ADD COLUMN"
+			if g.config.Dialect == DialectMSSQL {
+				add = "ADD"
+			}
+			if g.config.ExpandNotNull && !g.down && requiresBackfill(desiredCol) {
+				var rest []string
+				desiredCol, rest = g.expandNotNull(desired.Name, desiredCol)
+				backfills = append(backfills, rest...)
+			}
+			stmt := fmt.Sprintf("ALTER TABLE %s %s %s %s",
+				tableName, add, g.quote(colName), g.tableColumnDef(desired.Name, desiredCol))
+			if position := g.columnPosition(desiredColumns, i); position != "" {
+				stmt += " " + position
+			}
 			statements = append(statements, stmt)
+			// Postgres tidak mendukung COMMENT inline
+			if g.postgresSyntax() && columnComment(desiredCol) != "" {
+				statements = append(statements, g.generateColumnComment(desired.Name, desiredCol))
+			}
+			statements = append(statements, g.onUpdateStatements(desired.Name, desiredCol)...)
 		} else if !columnsEqual(currentCol, desiredCol) {
 			// Modified column
-			stmt := fmt.Sprintf("ALTER TABLE `%s` MODIFY COLUMN `%s` %s",
-				desired.Name, colName, g.generateColumnDef(desiredCol))
-			statements = append(statements, stmt)
+			if !collationEqual(currentCol, desiredCol) {
+				recollated = append(recollated, desired.Name+"."+colName)
+			}
+			statements = append(statements, g.generateModifyColumn(desired.Name, currentCol, desiredCol)...)
 		}
 	}
 
-	// 2. Handle dropped columns
-	for colName := range current.Columns {
-		if _, exists := desired.Columns[colName]; !exists {
-			stmt := fmt.Sprintf("ALTER TABLE `%s` DROP COLUMN `%s`",
-				desired.Name, colName)
+	// 4. Handle dropped indexes; dihapus sebelum kolomnya karena DROP COLUMN
+	// ikut menghapus index pada kolom itu
+	createIndex, dropIndex := g.generateCreateIndex, g.generateDropIndex
+	if g.batchAlter() {
+		createIndex, dropIndex = g.generateAddIndex, g.generateAlterDropIndex
+	}
+	for _, currentIdx := range sortedIndexes(current.Indexes) {
+		if _, exists := desired.Indexes[currentIdx.Name]; !exists {
+			statements = append(statements, dropIndex(desired.Name, currentIdx.Name))
+		}
+	}
+
+	// 5. Handle dropped columns
+	for _, currentCol := range sortedColumns(current.Columns) {
+		if _, exists := desired.Columns[currentCol.Name]; !exists {
+			if g.config.Dialect == DialectMSSQL && currentCol.DefaultValue != nil {
+				statements = append(statements, g.mssqlDropDefault(desired.Name, currentCol.Name))
+			}
+			stmt := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
+				tableName, g.quote(currentCol.Name))
 			statements = append(statements, stmt)
+			statements = append(statements, g.dropOnUpdate(desired.Name, currentCol)...)
 		}
 	}
 
-	// 3. Handle index changes
-	for idxName, desiredIdx := range desired.Indexes {
-		if currentIdx, exists := current.Indexes[idxName]; !exists {
+	// 6. Handle index changes
+	// Deskripsi index ditulis di atas statement yang membuatnya
+	indexComments := map[int]string{}
+	for _, desiredIdx := range sortedIndexes(desired.Indexes) {
+		if currentIdx, exists := current.Indexes[desiredIdx.Name]; !exists {
 			// New index
-			unique := ""
-			if desiredIdx.Unique {
-				unique = "UNIQUE "
+			stmt, err := createIndex(desired, desiredIdx)
+			if err != nil {
+				return nil, err
 			}
-			stmt := fmt.Sprintf("CREATE %sINDEX `%s` ON `%s` (%s)",
-				unique, idxName, desired.Name,
-				strings.Join(quoteColumns(desiredIdx.Columns), ", "))
+			indexComments[len(statements)] = indexComment(desiredIdx)
 			statements = append(statements, stmt)
 		} else if !indexesEqual(currentIdx, desiredIdx) {
 			// Modified index - drop and recreate
-			statements = append(statements,
-				fmt.Sprintf("DROP INDEX `%s` ON `%s`", idxName, desired.Name))
-			unique := ""
-			if desiredIdx.Unique {
-				unique = "UNIQUE "
-			}
-			stmt := fmt.Sprintf("CREATE %sINDEX `%s` ON `%s` (%s)",
-				unique, idxName, desired.Name,
-				strings.Join(quoteColumns(desiredIdx.Columns), ", "))
+			statements = append(statements, dropIndex(desired.Name, desiredIdx.Name))
+			stmt, err := createIndex(desired, desiredIdx)
+			if err != nil {
+				return nil, err
+			}
+			indexComments[len(statements)] = indexComment(desiredIdx)
 			statements = append(statements, stmt)
 		}
 	}
 
-	// 4. Handle dropped indexes
-	for idxName := range current.Indexes {
-		if _, exists := desired.Indexes[idxName]; !exists {
-			stmt := fmt.Sprintf("DROP INDEX `%s` ON `%s`",
-				idxName, desired.Name)
-			statements = append(statements, stmt)
+	// 7. Handle new or modified constraints
+	var validations []string
+	for _, constraint := range desired.Constraints {
+		if currentConstraint, exists := currentConstraints[constraintKey(constraint)]; !exists || !g.constraintsEqual(currentConstraint, constraint) {
+			if note := g.unsupportedConstraint(constraint); note != "" {
+				skipped = append(skipped, note)
+				continue
+			}
+			if g.addNotValid(constraint) {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD %s NOT VALID", tableName, g.namedConstraint(constraint)))
+				if !constraint.NotValid {
+					validations = append(validations, fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s", tableName, g.quote(constraint.Name)))
+				}
+				continue
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD %s", tableName, g.formatConstraint(constraint.Def)))
 		}
 	}
 
+	// 8. Gabungkan menjadi satu ALTER TABLE; urutan klausa dipertahankan
+	// sehingga constraint tetap di-drop sebelum kolom yang dicakupnya
+	if g.batchAlter() && len(statements) > 1 {
+		prefix := fmt.Sprintf("ALTER TABLE %s ", tableName)
+		clauses := make([]string, len(statements))
+		for i, stmt := range statements {
+			clauses[i] = strings.TrimPrefix(stmt, prefix)
+		}
+		batched := fmt.Sprintf("ALTER TABLE %s\n  %s", tableName, strings.Join(clauses, ",\n  "))
+		if g.config.Online {
+			batched = g.applyOnline(tableName, statements, batched)
+		}
+		var comments []string
+		for i := range statements {
+			if comment := indexComments[i]; comment != "" {
+				comments = append(comments, comment)
+			}
+		}
+		statements = []string{batched}
+		indexComments = map[int]string{0: strings.Join(comments, "\n")}
+	} else if g.config.Online {
+		for i, stmt := range statements {
+			statements[i] = g.applyOnline(tableName, []string{stmt}, stmt)
+		}
+	}
+	statements = append(statements, backfills...)
+	statements = append(statements, validations...)
+
 	// Add semicolons
 	for i := range statements {
-		statements[i] += ";"
+		statements[i] = withWarning(indexComments[i], terminate(statements[i]))
+	}
+
+	// Perubahan collation menulis ulang isi kolom (dan index yang memakainya)
+	if len(recollated) > 0 {
+		statements[0] = withWarning(fmt.Sprintf("-- datara: collation change on %s rewrites the column; expect locking on large tables",
+			strings.Join(recollated, ", ")), statements[0])
+	}
+
+	// CHECK yang tidak didukung server hanya ditulis sebagai komentar
+	if len(skipped) > 0 {
+		if len(statements) == 0 {
+			return []string{strings.Join(skipped, "\n")}, nil
+		}
+		statements[0] = withWarning(strings.Join(skipped, "\n"), statements[0])
 	}
 
 	return statements, nil
 }
 
+// addNotValid mengecek apakah constraint yang ditambahkan ke tabel yang sudah
+// ada memakai NOT VALID: karena SafeConstraints, atau karena model-nya memang
+// NOT VALID. Hanya FOREIGN KEY dan CHECK di Postgres yang mendukungnya.
+func (g *Generator) addNotValid(c state.Constraint) bool {
+	if !g.postgresSyntax() || (c.Type != "FOREIGN KEY" && c.Type != "CHECK") {
+		return false
+	}
+	return c.NotValid || g.config.SafeConstraints
+}
+
+// namedConstraint merender constraint dengan CONSTRAINT <nama>, agar nama
+// yang dipakai VALIDATE CONSTRAINT sama dengan nama di snapshot
+func (g *Generator) namedConstraint(c state.Constraint) string {
+	def := g.formatConstraint(c.Def)
+	if hasKeyword(strings.TrimSpace(c.Def), "CONSTRAINT") {
+		return def
+	}
+	return fmt.Sprintf("CONSTRAINT %s %s", g.quote(c.Name), def)
+}
+
+// IsValidation mengecek apakah statement adalah VALIDATE CONSTRAINT dari
+// SafeConstraints, mis. untuk ditulis ke migration terpisah
+func IsValidation(stmt string) bool {
+	return strings.HasPrefix(stmt, "ALTER TABLE ") && strings.Contains(stmt, " VALIDATE CONSTRAINT ")
+}
+
+// batchAlter mengecek apakah perubahan satu tabel digabung menjadi satu ALTER TABLE
+func (g *Generator) batchAlter() bool {
+	return g.config.BatchAlter && g.config.Dialect == DialectMySQL
+}
+
+// generateAddIndex membuat index sebagai klausa ALTER TABLE (MySQL)
+func (g *Generator) generateAddIndex(table state.Table, idx state.Index) (string, error) {
+	columns, err := g.indexColumns(table, idx)
+	if err != nil {
+		return "", err
+	}
+	kind := ""
+	switch {
+	case idx.Unique:
+		kind = "UNIQUE "
+	case idx.Spatial:
+		kind = "SPATIAL "
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD %sINDEX %s (%s)",
+		g.quote(table.Name), kind, g.quote(idx.Name), strings.Join(columns, ", ")), nil
+}
+
+// generateAlterDropIndex menghapus index sebagai klausa ALTER TABLE (MySQL)
+func (g *Generator) generateAlterDropIndex(tableName, indexName string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", g.quote(tableName), g.quote(indexName))
+}
+
+// generateModifyColumn membuat statement untuk mengubah definisi kolom.
+// MySQL memakai MODIFY COLUMN, Postgres memakai ALTER COLUMN per atribut, dan
+// SQL Server memakai mssqlModifyColumn.
+func (g *Generator) generateModifyColumn(tableName string, current, desired state.Column) []string {
+	table := g.quote(tableName)
+	column := g.quote(desired.Name)
+
+	if g.config.Dialect == DialectMSSQL {
+		return g.mssqlModifyColumn(tableName, current, desired)
+	}
+	if !g.postgresSyntax() {
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s",
+			table, column, g.generateColumnDef(desired))}
+	}
+
+	var statements []string
+	if g.columnType(current) != g.columnType(desired) || !collationEqual(current, desired) {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s%s%s",
+			table, column, g.columnType(desired), g.collationSQL(desired), g.sridUsing(current, desired)))
+		// Mengganti tipe mengembalikan STORAGE/COMPRESSION ke bawaan tipe baru
+		for _, attr := range postgresColumnAttributes(desired.Extra) {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET %s", table, column, attr))
+		}
+	}
+	if current.AutoIncrement != desired.AutoIncrement || !identityEqual(current, desired) {
+		statements = append(statements, g.generateIdentityChange(tableName, current, desired)...)
+	}
+	if current.Nullable != desired.Nullable {
+		action := "SET NOT NULL"
+		if desired.Nullable {
+			action = "DROP NOT NULL"
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", table, column, action))
+	}
+	if !current.DefaultValue.Equal(desired.DefaultValue) {
+		action := "DROP DEFAULT"
+		if desired.DefaultValue != nil {
+			action = fmt.Sprintf("SET DEFAULT %s", g.defaultSQL(desired))
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", table, column, action))
+	}
+	if columnComment(current) != columnComment(desired) {
+		statements = append(statements, g.generateColumnComment(tableName, desired))
+	}
+	return append(statements, g.modifyOnUpdate(tableName, current, desired)...)
+}
+
+// generateIdentityChange membuat statement untuk berpindah antar strategi auto
+// increment Postgres. Sequence lama dilepas, strategi baru dipasang, lalu nilai
+// sequence disesuaikan dengan data yang sudah ada.
+func (g *Generator) generateIdentityChange(tableName string, current, desired state.Column) []string {
+	var from, to string
+	if current.AutoIncrement {
+		from = g.identity(current)
+	}
+	if desired.AutoIncrement {
+		to = g.identity(desired)
+	}
+	if from == to {
+		return nil
+	}
+
+	table := g.quote(tableName)
+	column := g.quote(desired.Name)
+	sequence := g.quote(fmt.Sprintf("%s_%s_seq", tableName, desired.Name))
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", table, column)
+	setval := fmt.Sprintf("SELECT setval(pg_get_serial_sequence(%s, %s), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+		quoteString(table), quoteString(desired.Name), column, table)
+
+	// Identity ke identity cukup mengganti mode GENERATED
+	if generatedIdentity(from) && generatedIdentity(to) {
+		return []string{fmt.Sprintf("%s SET GENERATED %s", alter, identityClause(to))}
+	}
+
+	var statements []string
+	switch from {
+	case "":
+	case state.IdentitySerial:
+		statements = append(statements,
+			fmt.Sprintf("%s DROP DEFAULT", alter),
+			fmt.Sprintf("DROP SEQUENCE IF EXISTS %s", sequence))
+	case state.IdentityDistributed:
+		statements = append(statements, fmt.Sprintf("%s DROP DEFAULT", alter))
+	default:
+		statements = append(statements, fmt.Sprintf("%s DROP IDENTITY IF EXISTS", alter))
+	}
+
+	switch to {
+	case "":
+	case state.IdentityDistributed:
+		// Nilai baru tidak berurutan, sehingga tidak perlu setval
+		statements = append(statements, fmt.Sprintf("%s SET DEFAULT %s", alter, distributedKeyDefault(desired.Type)))
+	case state.IdentitySerial:
+		statements = append(statements,
+			fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s OWNED BY %s.%s", sequence, table, column),
+			fmt.Sprintf("%s SET DEFAULT nextval(%s)", alter, quoteString(sequence)),
+			setval)
+	default:
+		statements = append(statements,
+			fmt.Sprintf("%s ADD GENERATED %s AS IDENTITY", alter, identityClause(to)),
+			setval)
+	}
+	return statements
+}
+
+// identity mengembalikan strategi auto increment efektif sebuah kolom
+func (g *Generator) identity(col state.Column) string {
+	if col.Identity != "" {
+		return col.Identity
+	}
+	if g.config.Identity != "" {
+		return g.config.Identity
+	}
+	if g.config.Dialect == DialectCockroach {
+		return state.IdentityDistributed
+	}
+	return state.IdentityByDefault
+}
+
+// generateColumnComment membuat statement COMMENT ON COLUMN (Postgres)
+func (g *Generator) generateColumnComment(tableName string, col state.Column) string {
+	comment := "NULL"
+	if c := g.comment(col); c != "" {
+		comment = quoteString(c)
+	}
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", g.quote(tableName), g.quote(col.Name), comment)
+}
+
+// generateDropIndex membuat statement DROP INDEX sesuai dialect
+func (g *Generator) generateDropIndex(tableName, indexName string) string {
+	if g.postgresSyntax() {
+		return fmt.Sprintf("DROP INDEX %s", g.quote(indexName))
+	}
+	return fmt.Sprintf("DROP INDEX %s ON %s", g.quote(indexName), g.quote(tableName))
+}
+
+// generateDropConstraint membuat statement untuk menghapus table constraint
+func (g *Generator) generateDropConstraint(tableName string, constraint state.Constraint) string {
+	table := g.quote(tableName)
+	if g.config.Dialect != DialectMySQL {
+		return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", table, g.quote(constraint.Name))
+	}
+
+	switch constraint.Type {
+	case "PRIMARY KEY":
+		return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY", table)
+	case "FOREIGN KEY":
+		return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", table, g.quote(constraint.Name))
+	case "CHECK":
+		return fmt.Sprintf("ALTER TABLE %s DROP CHECK %s", table, g.quote(constraint.Name))
+	default:
+		return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", table, g.quote(constraint.Name))
+	}
+}
+
+// generateCreateIndex membuat statement CREATE INDEX sesuai dialect. Index
+// spatial menjadi CREATE SPATIAL INDEX di MySQL dan USING GIST di Postgres.
+func (g *Generator) generateCreateIndex(table state.Table, idx state.Index) (string, error) {
+	kind, using := "", ""
+	switch {
+	case idx.Unique:
+		kind = "UNIQUE "
+	case idx.Spatial && g.config.Dialect == DialectMySQL:
+		kind = "SPATIAL "
+	case idx.Spatial && g.postgresSyntax():
+		using = "USING GIST "
+	case idx.Spatial && g.config.Dialect == DialectMSSQL:
+		kind = "SPATIAL "
+	}
+
+	columns, err := g.indexColumns(table, idx)
+	if err != nil {
+		return "", err
+	}
+
+	stmt := fmt.Sprintf("CREATE %sINDEX %s ON %s %s(%s)",
+		kind, g.quote(idx.Name), g.quote(table.Name), using, strings.Join(columns, ", "))
+	include := ""
+	if len(idx.Include) > 0 && g.config.Dialect != DialectMySQL {
+		include = fmt.Sprintf(" INCLUDE (%s)", strings.Join(g.quoteColumns(idx.Include), ", "))
+	}
+	if idx.Buckets > 0 && g.config.Dialect == DialectCockroach {
+		return stmt + g.hashShardedClause(idx.Buckets, include), nil
+	}
+	return stmt + include, nil
+}
+
+// indexComment merender deskripsi index sebagai komentar SQL, mis.
+// "-- idx_users_email: login lookup by email"
+func indexComment(idx state.Index) string {
+	description := strings.Join(strings.Fields(idx.Description), " ")
+	if description == "" {
+		return ""
+	}
+	return fmt.Sprintf("-- %s: %s", idx.Name, description)
+}
+
+// IndexMethod mengembalikan metode index di dialect: "gist" atau "spatial"
+// untuk index spatial, "hash-sharded" untuk index ber-bucket CockroachDB,
+// dan "btree" untuk index lainnya
+func IndexMethod(dialect string, idx state.Index) string {
+	switch {
+	case idx.Spatial && (dialect == DialectPostgres || dialect == DialectCockroach):
+		return "gist"
+	case idx.Spatial:
+		return "spatial"
+	case idx.Buckets > 0 && dialect == DialectCockroach:
+		return "hash-sharded"
+	}
+	return "btree"
+}
+
+// indexColumns merender daftar kolom index beserta prefix length MySQL
+func (g *Generator) indexColumns(table state.Table, idx state.Index) ([]string, error) {
+	lengths := idx.Lengths
+	if g.config.Dialect == DialectMySQL {
+		var err error
+		if lengths, err = g.fitIndexKey(table, idx); err != nil {
+			return nil, err
+		}
+	}
+
+	columns := make([]string, len(idx.Columns))
+	for i, colName := range idx.Columns {
+		columns[i] = g.quote(colName)
+		length := lengths[colName]
+		if g.config.Dialect == DialectMySQL {
+			if length > 0 {
+				columns[i] += fmt.Sprintf("(%d)", length)
+			} else if col, ok := table.Columns[colName]; ok && isTextType(col.Type) {
+				return nil, &ValidationError{
+					Table:  table.Name,
+					Column: colName,
+					Rule:   "text-index-prefix",
+					Detail: fmt.Sprintf("index %q on column of type %s requires a prefix length on mysql", idx.Name, col.Type),
+				}
+			}
+		}
+		// SQL Server tidak bisa memakai kolom (MAX) sebagai key index
+		if col, ok := table.Columns[colName]; ok && g.config.Dialect == DialectMSSQL && strings.HasSuffix(g.sqlType(col.Type), "(MAX)") {
+			return nil, &ValidationError{
+				Table:  table.Name,
+				Column: colName,
+				Rule:   "max-index-key",
+				Detail: fmt.Sprintf("index %q on column of type %s (%s) is not allowed on mssql; give the column a length", idx.Name, col.Type, g.sqlType(col.Type)),
+			}
+		}
+	}
+	return columns, nil
+}
+
+// sqlType mengembalikan tipe kolom sesuai dialect. Postgres hanya punya satu
+// tipe biner, sehingga BINARY/VARBINARY/BLOB dirender sebagai bytea. SQL
+// Server memakai mssqlType (NVARCHAR, DATETIME2, BIT, ...).
+func (g *Generator) sqlType(t string) string {
+	if g.postgresSyntax() && isBinaryType(t) {
+		return "bytea"
+	}
+	if g.config.Dialect == DialectMSSQL {
+		return mssqlType(t)
+	}
+	// Boolean disimpan sebagai state.TypeBoolean; MySQL memakai TINYINT(1)
+	if g.config.Dialect == DialectMySQL && isBooleanType(t) {
+		return "TINYINT(1)"
+	}
+	return t
+}
+
 // generateColumnDef generates the column definition part of SQL
 func (g *Generator) generateColumnDef(col state.Column) string {
 	def := col.Type
+	postgresSerial := col.AutoIncrement && g.postgresSyntax() && g.identity(col) == state.IdentitySerial
+	if postgresSerial {
+		def = serialType(col.Type)
+	} else {
+		def = g.columnType(col)
+	}
+	def += g.sridClause(col)
+	extra, identityOptions := splitExtra(col.Extra)
+	if extra != "" {
+		def += " " + extra
+	}
+	def += g.collationSQL(col)
 	if !col.Nullable {
 		def += " NOT NULL"
 	}
 	if col.AutoIncrement {
-		def += " AUTO_INCREMENT"
+		switch {
+		case g.config.Dialect == DialectMySQL:
+			def += " AUTO_INCREMENT"
+		case g.config.Dialect == DialectMSSQL:
+			def += " IDENTITY(1,1)"
+		case g.identity(col) == state.IdentityDistributed:
+			def += " DEFAULT " + distributedKeyDefault(col.Type)
+		case !postgresSerial:
+			def += fmt.Sprintf(" GENERATED %s AS IDENTITY", identityClause(g.identity(col)))
+			if identityOptions != "" {
+				def += " " + identityOptions
+			}
+		}
+	}
+	// MySQL tidak mengizinkan DEFAULT literal pada kolom TEXT/BLOB; default
+	// SQL Server ditulis sebagai constraint bernama oleh tableColumnDef
+	if col.DefaultValue != nil && !(g.config.Dialect == DialectMySQL && isTextType(col.Type)) && g.config.Dialect != DialectMSSQL &&
+		!(col.AutoIncrement && g.identity(col) == state.IdentityDistributed) {
+		def += fmt.Sprintf(" DEFAULT %s", g.defaultSQL(col))
+	}
+	// Dialect lain memakai trigger dari onUpdateStatements
+	if col.OnUpdate != "" && (g.config.Dialect == DialectMySQL || g.config.Dialect == DialectCockroach) {
+		def += " ON UPDATE " + col.OnUpdate
 	}
-	if col.DefaultValue != nil {
-		def += fmt.Sprintf(" DEFAULT %v", col.DefaultValue)
+	if comment := g.comment(col); comment != "" && g.config.Dialect == DialectMySQL {
+		def += " COMMENT " + quoteString(comment)
 	}
 	return def
 }
 
+// defaultSQL merender nilai DEFAULT sesuai dialect. Default boolean menjadi
+// 1/0 di MySQL (BOOLEAN adalah TINYINT(1)) dan SQL Server (BIT), dan
+// TRUE/FALSE di Postgres, baik
+// saat tabel dibuat maupun saat kolom ditambahkan atau diubah.
+func (g *Generator) defaultSQL(col state.Column) string {
+	d := col.DefaultValue
+	if g.config.Redact && col.Sensitive {
+		return quoteString(state.Redacted)
+	}
+	if d.Kind == state.DefaultBool && !g.postgresSyntax() {
+		if d.Value == "true" {
+			return "1"
+		}
+		return "0"
+	}
+	if g.config.Dialect == DialectMySQL && isJSONType(col.Type) && d.Kind != state.DefaultNull {
+		return jsonDefaultSQL(d)
+	}
+	return d.SQL()
+}
+
+// isSensitive mengecek apakah nama kolom cocok dengan pola kolom sensitif
+func (g *Generator) isSensitive(name string) bool {
+	patterns := g.config.SensitivePatterns
+	if patterns == nil {
+		patterns = DefaultSensitivePatterns
+	}
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		if pattern != "" && strings.Contains(name, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// comment mengembalikan komentar kolom, disamarkan jika kolom sensitif
+func (g *Generator) comment(col state.Column) string {
+	comment := columnComment(col)
+	if comment != "" && g.config.Redact && col.Sensitive {
+		return state.Redacted
+	}
+	return comment
+}
+
+// normalizeDefault menyeragamkan default kolom boolean yang berasal dari tag,
+// SQL atau snapshot JSON (true, "true", 1, '1', 't') menjadi state.DefaultBool
+// agar kolom yang sama tidak dianggap berubah hanya karena bentuk default-nya
+func normalizeDefault(col state.Column) *state.DefaultValue {
+	d := col.DefaultValue
+	if d == nil {
+		return nil
+	}
+	// Snapshot lama menyimpan TRUE/FALSE sebagai keyword
+	if d.Kind != state.DefaultKeyword && !isBooleanType(col.Type) {
+		return d
+	}
+	value, ok := d.Bool()
+	if !ok || (d.Kind == state.DefaultKeyword && !strings.EqualFold(d.Value, strconv.FormatBool(value))) {
+		return d
+	}
+	return &state.DefaultValue{Kind: state.DefaultBool, Value: strconv.FormatBool(value)}
+}
+
+// isBooleanType mengecek apakah tipe kolom menyimpan boolean
+func isBooleanType(sqlType string) bool {
+	return state.IsBooleanType(sqlType)
+}
+
+// applyTags mengembalikan salinan schema dengan opsi dari Column.Tags
+// diterapkan ke field kolom, index dan constraint tabel.
+func (g *Generator) applyTags(schema *state.SchemaState) *state.SchemaState {
+	result := state.NewSchemaState()
+	result.Version = schema.Version
+	for _, table := range schema.Tables {
+		result.AddTable(g.applyTableTags(table))
+	}
+	return result
+}
+
+// applyTableTags menerapkan tags dari setiap kolom ke tabel.
+// Key yang tidak dikenali dibiarkan apa adanya.
+func (g *Generator) applyTableTags(table state.Table) state.Table {
+	result := state.Table{
+		Name:        table.Name,
+		Position:    table.Position,
+		Columns:     make(map[string]state.Column, len(table.Columns)),
+		Indexes:     make(map[string]state.Index, len(table.Indexes)),
+		Constraints: append([]state.Constraint(nil), table.Constraints...),
+		RawDDL:      table.RawDDL,
+		Options:     g.normalizeTableOptions(table.Options),
+	}
+	for name, idx := range table.Indexes {
+		result.Indexes[name] = idx
+	}
+
+	var primaryKeys []string
+	for name, col := range table.Columns {
+		for key, value := range col.Tags {
+			switch key {
+			case "primary_key":
+				col.Nullable = false
+				primaryKeys = append(primaryKeys, col.Name)
+			case "autoincrement", "auto_increment":
+				col.AutoIncrement = true
+			case "serial":
+				col.AutoIncrement = true
+				col.Identity = state.IdentitySerial
+			case "identity":
+				col.AutoIncrement = true
+				col.Identity = value
+			case "notnull":
+				col.Nullable = false
+			case "nullable":
+				col.Nullable = true
+			case "sensitive":
+				col.Sensitive = true
+			case "deprecated":
+				col.Deprecated = true
+			case "class":
+				if col.Class == "" {
+					col.Class = value
+				}
+			case "mask":
+				if col.Mask == "" {
+					col.Mask = value
+				}
+			case "on_update", "onupdate":
+				if col.OnUpdate == "" {
+					col.OnUpdate = state.NormalizeOnUpdate(value)
+				}
+			case "charset":
+				if col.Charset == "" {
+					col.Charset = value
+				}
+			case "collate", "collation":
+				if col.Collation == "" {
+					col.Collation = value
+				}
+			case "default":
+				if col.DefaultValue == nil {
+					col.DefaultValue = state.ParseDefault(value)
+				}
+			}
+		}
+		col.LiftOnUpdate()
+		applySpatialTags(&col)
+		if isBooleanType(col.Type) {
+			col.Type = state.TypeBoolean
+		}
+		col.Type = g.normalizeTimePrecision(col.Type)
+		col.DefaultValue = g.timePrecisionDefault(col, normalizeDefault(col))
+		if col.OnUpdate != "" {
+			col.OnUpdate = g.timePrecisionDefault(col, state.ParseDefault(col.OnUpdate)).SQL()
+		}
+		if g.isSensitive(col.Name) {
+			col.Sensitive = true
+		}
+		// Tipe serial disimpan sebagai tipe integer dasarnya dengan strategi serial,
+		// sehingga "bigserial" dan "bigint" + serial dianggap sama
+		if g.postgresSyntax() && isSerialType(col.Type) {
+			col.Type = serialBaseType(col.Type)
+			col.AutoIncrement = true
+			col.Identity = state.IdentitySerial
+		}
+		g.normalizeDistributedKey(&col)
+		if !g.postgresSyntax() {
+			col.Identity = ""
+		}
+		result.Columns[name] = col
+
+		if idxName, ok := spatialIndexName(col); ok {
+			if _, exists := result.Indexes[idxName]; !exists {
+				result.Indexes[idxName] = state.Index{Name: idxName, Columns: []string{col.Name}, Spatial: true}
+			}
+			continue
+		}
+		idxName, hasIndex := col.Tags["index"]
+		_, unique := col.Tags["unique"]
+		if !hasIndex && !unique {
+			continue
+		}
+		if idxName == "" {
+			idxName = fmt.Sprintf("idx_%s", col.Name)
+		}
+		if _, exists := result.Indexes[idxName]; !exists {
+			idx := state.Index{
+				Name:    idxName,
+				Columns: []string{col.Name},
+				Unique:  unique,
+			}
+			if length, err := strconv.Atoi(col.Tags["length"]); err == nil && length > 0 {
+				idx.Lengths = map[string]int{col.Name: length}
+			}
+			if include := col.Tags["include"]; include != "" {
+				idx.Include = strings.Split(include, "|")
+			}
+			idx.Buckets = shardBuckets(col.Tags)
+			result.Indexes[idxName] = idx
+		}
+	}
+
+	if len(primaryKeys) > 0 && !hasConstraint(result.Constraints, "PRIMARY KEY") {
+		sort.Strings(primaryKeys)
+		result.Constraints = append(result.Constraints, state.Constraint{
+			Name: fmt.Sprintf("pk_%s", table.Name),
+			Type: "PRIMARY KEY",
+			Def:  fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(g.quoteColumns(primaryKeys), ", ")),
+		})
+	}
+	g.shardPrimaryKey(&result)
+
+	return result
+}
+
+// quote meng-quote identifier sesuai dialect generator
+func (g *Generator) quote(name string) string {
+	return quoteIdent(g.config.Dialect, name)
+}
+
+func (g *Generator) quoteColumns(columns []string) []string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = g.quote(col)
+	}
+	return quoted
+}
+
 // Helper functions
 
 func columnsEqual(a, b state.Column) bool {
 	return a.Type == b.Type &&
+		a.SRID == b.SRID &&
 		a.Nullable == b.Nullable &&
 		a.AutoIncrement == b.AutoIncrement &&
-		a.DefaultValue == b.DefaultValue
+		strings.EqualFold(a.OnUpdate, b.OnUpdate) &&
+		identityEqual(a, b) &&
+		collationEqual(a, b) &&
+		a.DefaultValue.Equal(b.DefaultValue) &&
+		columnComment(a) == columnComment(b)
+}
+
+// constraintsEqual membandingkan definisi constraint setelah identifier-nya
+// dirender ulang, sehingga "id", `id` dan id dianggap sama
+func (g *Generator) constraintsEqual(a, b state.Constraint) bool {
+	return g.formatConstraint(a.Def) == g.formatConstraint(b.Def)
+}
+
+func hasConstraint(constraints []state.Constraint, constraintType string) bool {
+	for _, c := range constraints {
+		if c.Type == constraintType {
+			return true
+		}
+	}
+	return false
+}
+
+// constraintKey mengembalikan key untuk mencocokkan constraint antar schema
+func constraintKey(c state.Constraint) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Def
+}
+
+func constraintsByName(constraints []state.Constraint) map[string]state.Constraint {
+	result := make(map[string]state.Constraint, len(constraints))
+	for _, c := range constraints {
+		result[constraintKey(c)] = c
+	}
+	return result
 }
 
 func indexesEqual(a, b state.Index) bool {
-	if a.Unique != b.Unique || len(a.Columns) != len(b.Columns) {
+	if a.Unique != b.Unique || a.Spatial != b.Spatial || a.Buckets != b.Buckets || len(a.Columns) != len(b.Columns) || len(a.Include) != len(b.Include) {
 		return false
 	}
 	for i := range a.Columns {
-		if a.Columns[i] != b.Columns[i] {
+		if a.Columns[i] != b.Columns[i] || a.Lengths[a.Columns[i]] != b.Lengths[b.Columns[i]] {
+			return false
+		}
+	}
+	for i := range a.Include {
+		if a.Include[i] != b.Include[i] {
 			return false
 		}
 	}
 	return true
 }
 
-func quoteColumns(columns []string) []string {
-	quoted := make([]string, len(columns))
-	for i, col := range columns {
-		quoted[i] = fmt.Sprintf("`%s`", col)
+// isTextType mengecek apakah tipe kolom termasuk keluarga TEXT/BLOB
+func isTextType(sqlType string) bool {
+	t := strings.ToUpper(strings.TrimSpace(sqlType))
+	return strings.HasSuffix(t, "TEXT") || strings.HasSuffix(t, "BLOB")
+}
+
+// identityEqual membandingkan strategi auto increment. Kolom tanpa strategi
+// eksplisit cocok dengan strategi apa pun agar model yang hanya menyebut
+// autoincrement tidak menghasilkan diff terus-menerus.
+func identityEqual(a, b state.Column) bool {
+	return !a.AutoIncrement || !b.AutoIncrement ||
+		a.Identity == "" || b.Identity == "" || a.Identity == b.Identity
+}
+
+// generatedIdentity mengecek strategi yang dirender sebagai GENERATED ... AS IDENTITY
+func generatedIdentity(identity string) bool {
+	return identity == state.IdentityAlways || identity == state.IdentityByDefault
+}
+
+// identityClause mengembalikan mode GENERATED untuk strategi identity
+func identityClause(identity string) string {
+	if identity == state.IdentityAlways {
+		return "ALWAYS"
 	}
-	return quoted
+	return "BY DEFAULT"
+}
+
+// isSerialType mengecek apakah tipe kolom adalah serial Postgres
+func isSerialType(sqlType string) bool {
+	return strings.Contains(strings.ToLower(sqlType), "serial")
+}
+
+// serialBaseType mengembalikan tipe integer di balik tipe serial
+func serialBaseType(sqlType string) string {
+	switch strings.ToLower(sqlType) {
+	case "bigserial", "serial8":
+		return "bigint"
+	case "smallserial", "serial2":
+		return "smallint"
+	default:
+		return "integer"
+	}
+}
+
+// serialType mengembalikan tipe serial untuk tipe integer
+func serialType(sqlType string) string {
+	switch strings.ToLower(sqlType) {
+	case "bigint", "int8":
+		return "bigserial"
+	case "smallint", "int2":
+		return "smallserial"
+	default:
+		return "serial"
+	}
+}
+
+// sortedTables mengurutkan tabel berdasarkan Position lalu nama
+func sortedTables(tables map[string]state.Table) []state.Table {
+	result := make([]state.Table, 0, len(tables))
+	for _, table := range tables {
+		result = append(result, table)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Position != result[j].Position {
+			return result[i].Position < result[j].Position
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// sortedColumns mengurutkan kolom berdasarkan Position lalu nama
+func sortedColumns(columns map[string]state.Column) []state.Column {
+	result := make([]state.Column, 0, len(columns))
+	for _, col := range columns {
+		result = append(result, col)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Position != result[j].Position {
+			return result[i].Position < result[j].Position
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// orderedColumns mengurutkan kolom seperti sortedColumns, lalu menerapkan
+// Config.ColumnOrder dan Config.ColumnGroups. Pengurutannya stabil sehingga kolom di bagian "*" tetap
+// sesuai urutan deklarasi.
+func (g *Generator) orderedColumns(columns map[string]state.Column) []state.Column {
+	result := sortedColumns(columns)
+	if len(g.config.ColumnOrder) == 0 {
+		return g.groupedColumns(result, func(state.Column) int { return 0 })
+	}
+	rank := make(map[string]int, len(g.config.ColumnOrder))
+	rest := len(g.config.ColumnOrder)
+	for i, name := range g.config.ColumnOrder {
+		if name == "*" {
+			rest = i
+			continue
+		}
+		if _, exists := rank[name]; !exists {
+			rank[name] = i
+		}
+	}
+	position := func(col state.Column) int {
+		if i, exists := rank[col.Name]; exists {
+			return i
+		}
+		return rest
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return position(result[i]) < position(result[j])
+	})
+	return g.groupedColumns(result, position)
+}
+
+// groupedColumns mengumpulkan kolom dengan group yang sama jika
+// Config.ColumnGroups aktif. Kelompok diurutkan sesuai kemunculan pertamanya
+// dan hanya di dalam bagian ColumnOrder yang sama, sehingga kolom yang
+// posisinya ditentukan ColumnOrder tidak berpindah.
+func (g *Generator) groupedColumns(columns []state.Column, position func(state.Column) int) []state.Column {
+	if !g.config.ColumnGroups {
+		return columns
+	}
+	rank := make(map[string]int)
+	for _, col := range columns {
+		if _, exists := rank[columnGroup(col)]; !exists {
+			rank[columnGroup(col)] = len(rank)
+		}
+	}
+	sort.SliceStable(columns, func(i, j int) bool {
+		if a, b := position(columns[i]), position(columns[j]); a != b {
+			return a < b
+		}
+		return rank[columnGroup(columns[i])] < rank[columnGroup(columns[j])]
+	})
+	return columns
+}
+
+// ungroupedLabel adalah komentar untuk kolom tanpa group yang ditulis setelah
+// kelompok lain di CREATE TABLE
+const ungroupedLabel = "(ungrouped)"
+
+// columnGroup mengembalikan nama group kolom dari tag group=... dalam satu
+// baris, agar aman ditulis sebagai komentar SQL
+func columnGroup(col state.Column) string {
+	return strings.Join(strings.Fields(col.Tags["group"]), " ")
+}
+
+// columnPosition mengembalikan klausa AFTER/FIRST untuk kolom ke-i dari
+// orderedColumns. Hanya MySQL dengan Config.ColumnOrder yang memakainya;
+// tanpa ColumnOrder kolom baru tetap ditambahkan di akhir tabel.
+func (g *Generator) columnPosition(columns []state.Column, i int) string {
+	if g.config.Dialect != DialectMySQL || len(g.config.ColumnOrder) == 0 {
+		return ""
+	}
+	if i == 0 {
+		return "FIRST"
+	}
+	return "AFTER " + g.quote(columns[i-1].Name)
+}
+
+// sortedIndexes mengurutkan index berdasarkan nama
+func sortedIndexes(indexes map[string]state.Index) []state.Index {
+	result := make([]state.Index, 0, len(indexes))
+	for _, idx := range indexes {
+		result = append(result, idx)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
 }
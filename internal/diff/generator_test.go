@@ -0,0 +1,161 @@
+package diff
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// renderMigration merender statement up dan down dari current ke desired
+// seperti yang ditulis ke file migration
+func renderMigration(t *testing.T, g *Generator, current, desired *state.SchemaState) []byte {
+	t.Helper()
+	up, err := g.GenerateStatements(current, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	down, err := g.GenerateDownStatements(desired, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte("-- migrate:up\n" + strings.Join(up, "\n\n") + "\n\n-- migrate:down\n" + strings.Join(down, "\n\n") + "\n")
+}
+
+// TestDefaultConfigOutput mengunci output generator dengan Config kosong dan
+// dengan config bawaan NewGenerator(nil), agar knob baru di Config tidak
+// mengubah SQL yang sudah dihasilkan tanpa diminta
+func TestDefaultConfigOutput(t *testing.T) {
+	configs := []struct {
+		name   string
+		config func() *Config
+	}{
+		{"zero", func() *Config { return &Config{} }},
+		{"nil", func() *Config { return nil }},
+	}
+	for _, c := range configs {
+		for _, scenario := range planScenarios() {
+			name := scenario.name + "_" + c.name
+			t.Run(name, func(t *testing.T) {
+				got := renderMigration(t, NewGenerator(c.config()), scenario.current, scenario.desired)
+				assertGolden(t, filepath.Join("testdata", "defaults", name+".sql"), got,
+					"a zero Config must keep producing the same SQL; put new behavior behind a Config field")
+			})
+		}
+	}
+}
+
+func TestZeroConfigIsMySQL(t *testing.T) {
+	for _, scenario := range planScenarios() {
+		zero := renderMigration(t, NewGenerator(&Config{}), scenario.current, scenario.desired)
+		mysql := renderMigration(t, NewGenerator(&Config{Dialect: DialectMySQL}), scenario.current, scenario.desired)
+		if string(zero) != string(mysql) {
+			t.Errorf("%s: Config{} differs from Config{Dialect: mysql}:\n%s\n---\n%s", scenario.name, zero, mysql)
+		}
+	}
+}
+
+// groupedAccounts adalah tabel accounts dengan kolom bertag group=..., dalam
+// urutan deklarasi yang mencampur kelompoknya
+func groupedAccounts() *state.SchemaState {
+	group := func(name string) map[string]string { return map[string]string{"group": name} }
+	return schemaOf(state.Table{Name: "accounts", Columns: map[string]state.Column{
+		"id":            {Name: "id", Type: "BIGINT", Position: 1},
+		"name":          {Name: "name", Type: "VARCHAR(100)", Position: 2},
+		"billing_plan":  {Name: "billing_plan", Type: "VARCHAR(20)", Position: 3, Tags: group("Billing")},
+		"email":         {Name: "email", Type: "VARCHAR(100)", Position: 4, Tags: group("Contact")},
+		"billing_cycle": {Name: "billing_cycle", Type: "INT", Position: 5, Tags: group("  Billing ")},
+		"phone":         {Name: "phone", Type: "VARCHAR(20)", Position: 6, Tags: group("Contact")},
+		"created_at":    {Name: "created_at", Type: "TIMESTAMP", Position: 7, Tags: group("Audit")},
+		"updated_at":    {Name: "updated_at", Type: "TIMESTAMP", Position: 8},
+	}, Constraints: []state.Constraint{{Name: "pk_accounts", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"}}})
+}
+
+func TestColumnGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{
+			name:   "disabled keeps declaration order",
+			config: Config{Dialect: DialectPostgres},
+			want: `CREATE TABLE "accounts" (
+  "id" BIGINT NOT NULL,
+  "name" VARCHAR(100) NOT NULL,
+  "billing_plan" VARCHAR(20) NOT NULL,
+  "email" VARCHAR(100) NOT NULL,
+  "billing_cycle" INT NOT NULL,
+  "phone" VARCHAR(20) NOT NULL,
+  "created_at" TIMESTAMP NOT NULL,
+  "updated_at" TIMESTAMP NOT NULL,
+  PRIMARY KEY ("id")
+);`,
+		},
+		{
+			name:   "groups in order of first appearance",
+			config: Config{Dialect: DialectPostgres, ColumnGroups: true},
+			want: `CREATE TABLE "accounts" (
+  "id" BIGINT NOT NULL,
+  "name" VARCHAR(100) NOT NULL,
+  "updated_at" TIMESTAMP NOT NULL,
+  -- Billing
+  "billing_plan" VARCHAR(20) NOT NULL,
+  "billing_cycle" INT NOT NULL,
+  -- Contact
+  "email" VARCHAR(100) NOT NULL,
+  "phone" VARCHAR(20) NOT NULL,
+  -- Audit
+  "created_at" TIMESTAMP NOT NULL,
+  PRIMARY KEY ("id")
+);`,
+		},
+		{
+			name:   "groups stay inside their column_order section",
+			config: Config{Dialect: DialectMySQL, ColumnGroups: true, ColumnOrder: []string{"id", "*", "updated_at"}},
+			want: "CREATE TABLE `accounts` (\n" +
+				"  `id` BIGINT NOT NULL,\n" +
+				"  `name` VARCHAR(100) NOT NULL,\n" +
+				"  -- Billing\n" +
+				"  `billing_plan` VARCHAR(20) NOT NULL,\n" +
+				"  `billing_cycle` INT NOT NULL,\n" +
+				"  -- Contact\n" +
+				"  `email` VARCHAR(100) NOT NULL,\n" +
+				"  `phone` VARCHAR(20) NOT NULL,\n" +
+				"  -- Audit\n" +
+				"  `created_at` TIMESTAMP NOT NULL,\n" +
+				"  -- (ungrouped)\n" +
+				"  `updated_at` TIMESTAMP NOT NULL,\n" +
+				"  PRIMARY KEY (`id`)\n" +
+				");",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := NewGenerator(&tt.config).GenerateStatements(state.NewSchemaState(), groupedAccounts())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(statements, "\n"); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnGroupsAreNotAChange(t *testing.T) {
+	ungrouped := groupedAccounts()
+	for name, col := range ungrouped.Tables["accounts"].Columns {
+		col.Tags = nil
+		ungrouped.Tables["accounts"].Columns[name] = col
+	}
+	g := NewGenerator(&Config{Dialect: DialectPostgres, ColumnGroups: true})
+	statements, err := g.GenerateStatements(ungrouped, groupedAccounts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) > 0 {
+		t.Errorf("adding groups produced %q", statements)
+	}
+}
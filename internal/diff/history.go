@@ -0,0 +1,161 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Kolom periode yang ditambahkan pada tabel history
+const (
+	HistoryValidFrom = "valid_from"
+	HistoryValidTo   = "valid_to"
+)
+
+// DefaultHistoryTable adalah template nama tabel history; {table} diganti
+// nama tabel sumber
+const DefaultHistoryTable = "{table}_history"
+
+// HistoryTableName mengembalikan nama tabel history untuk table dari template
+func HistoryTableName(template, table string) string {
+	if template == "" {
+		template = DefaultHistoryTable
+	}
+	return strings.ReplaceAll(template, "{table}", table)
+}
+
+// historyTriggerName adalah nama fungsi dan trigger Postgres yang mengisi
+// tabel history tableName
+func historyTriggerName(tableName string) string {
+	return fmt.Sprintf("datara_history_%s", tableName)
+}
+
+// HistoryTable membuat tabel history (shadow) bernama name untuk source:
+// kolom yang sama tanpa default, auto increment, ON UPDATE, key dan index,
+// ditambah valid_from dan valid_to. Karena tabel ini ikut di schema desired,
+// setiap perubahan kolom sumber juga menghasilkan ALTER yang sama pada tabel
+// history. Trigger Postgres yang mengisinya (atau catatan untuk dialect lain)
+// disimpan sebagai RawDDL, sehingga dibuat ulang saat kolomnya berubah.
+func (g *Generator) HistoryTable(source state.Table, name string) state.Table {
+	history := state.Table{
+		Name:        name,
+		Position:    source.Position,
+		Columns:     make(map[string]state.Column, len(source.Columns)+2),
+		Indexes:     make(map[string]state.Index),
+		Constraints: make([]state.Constraint, 0),
+	}
+
+	last := 0
+	columns := g.orderedColumns(source.Columns)
+	for _, col := range columns {
+		history.Columns[col.Name] = state.Column{
+			Name:       col.Name,
+			Position:   col.Position,
+			Type:       historyType(col.Type),
+			Nullable:   col.Nullable,
+			Charset:    col.Charset,
+			Collation:  col.Collation,
+			Sensitive:  col.Sensitive,
+			Class:      col.Class,
+			Mask:       col.Mask,
+			Deprecated: col.Deprecated,
+		}
+		if col.Position > last {
+			last = col.Position
+		}
+	}
+	history.Columns[HistoryValidFrom] = state.Column{
+		Name:         HistoryValidFrom,
+		Position:     last + 1,
+		Type:         "timestamp",
+		DefaultValue: state.ParseDefault("CURRENT_TIMESTAMP"),
+	}
+	history.Columns[HistoryValidTo] = state.Column{
+		Name:     HistoryValidTo,
+		Position: last + 2,
+		Type:     "timestamp",
+		Nullable: true,
+	}
+
+	up, down := g.historyTrigger(source, name, columns)
+	history.RawDDL = state.NewRawDDL(up, down)
+	return history
+}
+
+// serialTypes adalah tipe serial Postgres beserta tipe integer-nya; kolom
+// history tidak boleh punya sequence sendiri
+var serialTypes = map[string]string{
+	"smallserial": "smallint",
+	"serial":      "integer",
+	"bigserial":   "bigint",
+}
+
+// historyType mengembalikan tipe kolom history untuk tipe kolom sumber
+func historyType(sqlType string) string {
+	if base, ok := serialTypes[strings.ToLower(strings.TrimSpace(sqlType))]; ok {
+		return base
+	}
+	return sqlType
+}
+
+// historyTrigger membuat fungsi dan trigger AFTER INSERT OR UPDATE Postgres
+// yang menyalin baris sumber ke tabel history. Saat UPDATE, baris history
+// sebelumnya ditutup dengan valid_to jika tabel sumber punya primary key.
+// Dialect lain hanya mendapat komentar bahwa aplikasi harus mengisinya.
+func (g *Generator) historyTrigger(source state.Table, history string, columns []state.Column) (up, down string) {
+	if g.config.Dialect != DialectPostgres {
+		note := fmt.Sprintf("-- datara: %s is not filled automatically on %s; write history rows for %s from the application",
+			history, g.config.Dialect, source.Name)
+		return note, ""
+	}
+
+	names := make([]string, len(columns))
+	values := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = g.quote(col.Name)
+		values[i] = "NEW." + g.quote(col.Name)
+	}
+
+	var body strings.Builder
+	if keys := primaryKeyColumns(source); len(keys) > 0 {
+		conditions := make([]string, len(keys))
+		for i, key := range keys {
+			conditions[i] = fmt.Sprintf("%s = OLD.%s", g.quote(key), g.quote(key))
+		}
+		fmt.Fprintf(&body, "IF TG_OP = 'UPDATE' THEN UPDATE %s SET %s = CURRENT_TIMESTAMP WHERE %s AND %s IS NULL; END IF; ",
+			g.quote(history), g.quote(HistoryValidTo), strings.Join(conditions, " AND "), g.quote(HistoryValidTo))
+	}
+	fmt.Fprintf(&body, "INSERT INTO %s (%s) VALUES (%s); RETURN NEW;",
+		g.quote(history), strings.Join(names, ", "), strings.Join(values, ", "))
+
+	name := g.quote(historyTriggerName(source.Name))
+	up = fmt.Sprintf("CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$ BEGIN %s END; $$ LANGUAGE plpgsql;\n\n"+
+		"CREATE TRIGGER %s AFTER INSERT OR UPDATE ON %s FOR EACH ROW EXECUTE FUNCTION %s()",
+		name, body.String(), name, g.quote(source.Name), name)
+	// CASCADE ikut menghapus trigger tanpa bergantung pada tabel sumber,
+	// yang mungkin sudah di-drop lebih dulu
+	down = fmt.Sprintf("DROP FUNCTION IF EXISTS %s() CASCADE", name)
+	return up, down
+}
+
+// primaryKeyColumns mengembalikan kolom primary key tabel, atau nil
+func primaryKeyColumns(table state.Table) []string {
+	for _, constraint := range table.Constraints {
+		if constraint.Type != "PRIMARY KEY" {
+			continue
+		}
+		rest := strings.TrimSpace(constraint.Def)
+		if hasKeyword(rest, "CONSTRAINT") {
+			_, rest = nextIdent(rest[len("CONSTRAINT"):])
+			rest = strings.TrimSpace(rest)
+		}
+		if !hasKeyword(rest, "PRIMARY KEY") {
+			continue
+		}
+		if columns, _, ok := identNames(rest[len("PRIMARY KEY"):]); ok {
+			return columns
+		}
+	}
+	return nil
+}
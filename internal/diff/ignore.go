@@ -0,0 +1,114 @@
+package diff
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Kebijakan diff kolom dari tag diff=... atau Config.DiffIgnore. Kolom tetap
+// dibuat dan di-drop oleh datara, tetapi perubahannya tidak dijadikan migration.
+const (
+	// DiffIgnore mengabaikan semua perubahan kolom
+	DiffIgnore = "ignore"
+	// DiffIgnoreWidth hanya mengabaikan perubahan panjang, mis. VARCHAR(100)
+	// yang dilebarkan manual menjadi VARCHAR(255)
+	DiffIgnoreWidth = "ignore-width"
+)
+
+// ignoredDifference adalah perbedaan kolom yang sengaja tidak dijadikan migration
+type ignoredDifference struct {
+	table, column, detail string
+}
+
+func (d ignoredDifference) String() string {
+	return fmt.Sprintf("%s.%s: %s, ignored by policy", d.table, d.column, d.detail)
+}
+
+// diffPolicy mengembalikan kebijakan diff kolom. Tag diff=... didahulukan,
+// lalu pola "tabel.kolom[:kebijakan]" dari Config.DiffIgnore (glob, mis.
+// "legacy_*.*"); pola tanpa kebijakan berarti DiffIgnore.
+func (g *Generator) diffPolicy(tableName string, col state.Column) string {
+	if policy := col.Tags["diff"]; policy != "" {
+		return policy
+	}
+	for _, pattern := range g.config.DiffIgnore {
+		target, policy, ok := strings.Cut(pattern, ":")
+		if !ok {
+			policy = DiffIgnore
+		}
+		if matched, _ := path.Match(target, tableName+"."+col.Name); matched {
+			return policy
+		}
+	}
+	return ""
+}
+
+// validateDiffPolicy menolak nilai diff=... yang tidak dikenal
+func (g *Generator) validateDiffPolicy(tableName string, col state.Column) error {
+	switch policy := g.diffPolicy(tableName, col); policy {
+	case "", DiffIgnore, DiffIgnoreWidth:
+		return nil
+	default:
+		return &ValidationError{Table: tableName, Column: col.Name, Rule: "diff-policy",
+			Detail: fmt.Sprintf("unknown diff policy %q, use ignore or ignore-width", policy)}
+	}
+}
+
+// applyDiffPolicy mengembalikan desired dengan atribut kolom yang diabaikan
+// disalin dari current, beserta perbedaan yang diabaikan. desired tidak diubah.
+func (g *Generator) applyDiffPolicy(current, desired *state.SchemaState) (*state.SchemaState, []ignoredDifference) {
+	var ignored []ignoredDifference
+	result := desired
+	for _, table := range sortedTables(desired.Tables) {
+		currentTable, exists := current.Tables[table.Name]
+		if !exists {
+			continue
+		}
+		for _, col := range sortedColumns(table.Columns) {
+			currentCol, exists := currentTable.Columns[col.Name]
+			if !exists || columnsEqual(currentCol, col) {
+				continue
+			}
+			kept, detail := col, ""
+			switch g.diffPolicy(table.Name, col) {
+			case DiffIgnore:
+				kept = currentCol
+				detail = g.columnDetail(currentCol, col)
+			case DiffIgnoreWidth:
+				if typeWithoutWidth(currentCol.Type) != typeWithoutWidth(col.Type) || strings.EqualFold(currentCol.Type, col.Type) {
+					continue
+				}
+				kept.Type, detail = currentCol.Type, "width differs"
+			default:
+				continue
+			}
+			if result == desired {
+				result = desired.Clone()
+			}
+			result.Tables[table.Name].Columns[col.Name] = kept
+			ignored = append(ignored, ignoredDifference{table: table.Name, column: col.Name, detail: detail})
+		}
+	}
+	return result, ignored
+}
+
+// typeWithoutWidth membuang argumen panjang dari tipe, mis. "varchar(100)" menjadi "varchar"
+func typeWithoutWidth(sqlType string) string {
+	t := strings.ToLower(strings.TrimSpace(sqlType))
+	if open := strings.Index(t, "("); open != -1 {
+		if closing := strings.Index(t[open:], ")"); closing != -1 {
+			t = strings.TrimSpace(t[:open]) + t[open+closing+1:]
+		}
+	}
+	return t
+}
+
+// warnIgnored mencatat perbedaan yang diabaikan agar tetap terlihat
+func (g *Generator) warnIgnored(ignored []ignoredDifference) {
+	for _, d := range ignored {
+		g.warnings.Add("ignored-diff", d.table, d.column, "%s, ignored by policy", d.detail)
+	}
+}
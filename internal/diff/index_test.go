@@ -0,0 +1,170 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// TestDuplicateIndexWarnings memastikan hanya index yang identik di semua
+// atribut yang diperingatkan, dan semua index tetap dirender
+func TestDuplicateIndexWarnings(t *testing.T) {
+	tests := []struct {
+		name    string
+		indexes []state.Index
+		want    []string
+	}{
+		{
+			name: "identical",
+			indexes: []state.Index{
+				{Name: "idx_a", Columns: []string{"author_id"}},
+				{Name: "idx_b", Columns: []string{"author_id"}},
+			},
+			want: []string{"posts: index idx_b duplicates idx_a [duplicate-index]"},
+		},
+		{
+			// Index unique tidak boleh menutupi index non-unique yang
+			// dibutuhkan foreign key, begitu pula sebaliknya
+			name: "unique and non-unique",
+			indexes: []state.Index{
+				{Name: "idx_posts_author_id", Columns: []string{"author_id"}},
+				{Name: "uni_posts_author_id", Columns: []string{"author_id"}, Unique: true},
+			},
+		},
+		{
+			name: "spatial and btree",
+			indexes: []state.Index{
+				{Name: "idx_posts_location", Columns: []string{"location"}},
+				{Name: "sp_posts_location", Columns: []string{"location"}, Spatial: true},
+			},
+		},
+		{
+			name: "prefix length",
+			indexes: []state.Index{
+				{Name: "idx_title", Columns: []string{"title"}},
+				{Name: "idx_title_prefix", Columns: []string{"title"}, Lengths: map[string]int{"title": 50}},
+			},
+		},
+		{
+			name: "column order",
+			indexes: []state.Index{
+				{Name: "idx_author_title", Columns: []string{"author_id", "title"}},
+				{Name: "idx_title_author", Columns: []string{"title", "author_id"}},
+			},
+		},
+		{
+			name: "include",
+			indexes: []state.Index{
+				{Name: "idx_author", Columns: []string{"author_id"}},
+				{Name: "idx_author_cover", Columns: []string{"author_id"}, Include: []string{"title"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := state.Table{
+				Name: "posts",
+				Columns: map[string]state.Column{
+					"id":        {Name: "id", Type: "BIGINT", Position: 1},
+					"author_id": {Name: "author_id", Type: "BIGINT", Position: 2},
+					"title":     {Name: "title", Type: "VARCHAR(200)", Position: 3},
+					"location":  {Name: "location", Type: "POINT", Position: 4},
+				},
+				Indexes:     map[string]state.Index{},
+				Constraints: []state.Constraint{{Name: "pk_posts", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"}},
+			}
+			for _, idx := range tt.indexes {
+				table.Indexes[idx.Name] = idx
+			}
+			g := NewGenerator(&Config{Dialect: DialectPostgres})
+			statements, err := g.GenerateStatements(state.NewSchemaState(), schemaOf(table))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, w := range g.Warnings() {
+				if w.Code == "duplicate-index" {
+					got = append(got, w.String())
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("warnings = %q, want %q", got, tt.want)
+			}
+			sql := strings.Join(statements, "\n")
+			for _, idx := range tt.indexes {
+				if !strings.Contains(sql, `"`+idx.Name+`"`) {
+					t.Errorf("index %s not rendered:\n%s", idx.Name, sql)
+				}
+			}
+		})
+	}
+}
+
+// describedUsers membuat tabel users dengan index email berdeskripsi
+// description dan index name tanpa deskripsi; indexes false membuat tabel
+// yang sama tanpa index
+func describedUsers(description string, indexes bool) *state.SchemaState {
+	table := state.Table{Name: "users", Columns: map[string]state.Column{
+		"id":    {Name: "id", Type: "INT", Position: 1},
+		"email": {Name: "email", Type: "VARCHAR(100)", Position: 2},
+		"name":  {Name: "name", Type: "VARCHAR(100)", Position: 3},
+	}, Indexes: map[string]state.Index{}}
+	if indexes {
+		table.Indexes["idx_users_email"] = state.Index{Name: "idx_users_email", Columns: []string{"email"}, Unique: true, Description: description}
+		table.Indexes["idx_users_name"] = state.Index{Name: "idx_users_name", Columns: []string{"name"}}
+	}
+	return schemaOf(table)
+}
+
+func TestIndexDescriptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		current *state.SchemaState
+		// want adalah komentar deskripsi beserta baris setelahnya
+		want string
+	}{
+		{"postgres create", Config{Dialect: DialectPostgres}, schemaOf(),
+			"-- idx_users_email: login lookup\nCREATE UNIQUE INDEX \"idx_users_email\""},
+		{"postgres alter", Config{Dialect: DialectPostgres}, describedUsers("", false),
+			"-- idx_users_email: login lookup\nCREATE UNIQUE INDEX \"idx_users_email\""},
+		{"mysql inline", Config{Dialect: DialectMySQL, IndexPlacement: IndexPlacementInline}, schemaOf(),
+			"  -- idx_users_email: login lookup\n  UNIQUE KEY `idx_users_email`"},
+		{"mysql batched alter", Config{Dialect: DialectMySQL, BatchAlter: true}, describedUsers("", false),
+			"-- idx_users_email: login lookup\nALTER TABLE `users`\n  ADD UNIQUE INDEX `idx_users_email`"},
+		{"mssql create", Config{Dialect: DialectMSSQL}, schemaOf(),
+			"-- idx_users_email: login lookup\nCREATE UNIQUE INDEX [idx_users_email]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := NewGenerator(&tt.config).GenerateStatements(tt.current, describedUsers("login\n   lookup ", true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Join(statements, "\n")
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("got:\n%s\nwant the description above the index:\n%s", got, tt.want)
+			}
+			if strings.Count(got, "-- ") != 1 {
+				t.Errorf("got:\n%s\nwant one comment, for the described index only", got)
+			}
+		})
+	}
+}
+
+func TestIndexDescriptionIsNotAChange(t *testing.T) {
+	for _, dialect := range allDialects {
+		for _, pair := range [][2]string{{"old", "new"}, {"", "new"}, {"old", ""}} {
+			statements, err := NewGenerator(&Config{Dialect: dialect}).GenerateStatements(describedUsers(pair[0], true), describedUsers(pair[1], true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(statements) > 0 {
+				t.Errorf("%s: description %q -> %q produced %q", dialect, pair[0], pair[1], statements)
+			}
+		}
+	}
+}
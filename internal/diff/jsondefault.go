@@ -0,0 +1,37 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// isJSONType mengecek tipe JSON, termasuk jsonb Postgres
+func isJSONType(sqlType string) bool {
+	t := strings.ToLower(strings.TrimSpace(sqlType))
+	return t == "json" || t == "jsonb"
+}
+
+// jsonDefaultSQL merender DEFAULT kolom JSON MySQL sebagai ekspresi dalam
+// kurung, mis. DEFAULT ('{}'). MySQL 8 menolak literal biasa pada JSON.
+func jsonDefaultSQL(d *state.DefaultValue) string {
+	sql := d.SQL()
+	if _, wrapped := state.UnwrapParens(sql); wrapped {
+		return sql
+	}
+	return "(" + sql + ")"
+}
+
+// validateJSONDefault menolak DEFAULT pada kolom JSON untuk server yang tidak
+// mendukungnya, mis. MySQL 5.7 yang hanya menerima DEFAULT NULL
+func (g *Generator) validateJSONDefault(tableName string, col state.Column) error {
+	if !isJSONType(col.Type) || col.DefaultValue == nil || col.DefaultValue.Kind == state.DefaultNull {
+		return nil
+	}
+	if g.supports(FeatureJSONDefault) {
+		return nil
+	}
+	return &ValidationError{Table: tableName, Column: col.Name, Rule: "server-version",
+		Detail: fmt.Sprintf("%s does not support %s; set the value in the application instead", g.config.ServerVersion, FeatureJSONDefault)}
+}
@@ -0,0 +1,148 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// charsetBytes adalah jumlah byte maksimum per karakter untuk charset MySQL.
+// Charset yang tidak dikenal dianggap 4 byte seperti utf8mb4.
+var charsetBytes = map[string]int{
+	"utf8mb4": 4,
+	"utf8mb3": 3,
+	"utf8":    3,
+	"ucs2":    2,
+	"utf16":   4,
+	"utf32":   4,
+	"latin1":  1,
+	"ascii":   1,
+	"binary":  1,
+}
+
+// Batas ukuran key index InnoDB berdasarkan row format
+const (
+	indexKeyLimitDynamic = 3072 // DYNAMIC dan COMPRESSED (default InnoDB)
+	indexKeyLimitCompact = 767  // COMPACT dan REDUNDANT
+)
+
+// fixedKeyBytes adalah ukuran key untuk tipe dengan panjang tetap
+var fixedKeyBytes = map[string]int{
+	"tinyint":   1,
+	"smallint":  2,
+	"mediumint": 3,
+	"int":       4,
+	"integer":   4,
+	"bigint":    8,
+	"date":      3,
+	"datetime":  8,
+	"timestamp": 4,
+}
+
+var typeLengthPattern = regexp.MustCompile(`^([a-z ]+)\((\d+)\)`)
+
+// bytesPerChar mengembalikan byte per karakter dari charset yang dikonfigurasi
+func (g *Generator) bytesPerChar() int {
+	if n, ok := charsetBytes[strings.ToLower(g.config.Charset)]; ok {
+		return n
+	}
+	return 4
+}
+
+// indexKeyLimit mengembalikan batas ukuran key index sesuai row format
+// tabel, yaitu opsi ROW_FORMAT tabel atau row format dari config
+func (g *Generator) indexKeyLimit(table state.Table) int {
+	rowFormat := g.config.RowFormat
+	if value, ok := table.Options[state.OptionRowFormat]; ok {
+		rowFormat = value
+	}
+	switch strings.ToLower(rowFormat) {
+	case "compact", "redundant":
+		return indexKeyLimitCompact
+	default:
+		return indexKeyLimitDynamic
+	}
+}
+
+// keyBytes menghitung ukuran key sebuah kolom di index. prefix > 0 berarti
+// hanya prefix karakter pertama yang di-index. isString bernilai true untuk
+// kolom yang bisa dipendekkan dengan prefix length.
+func (g *Generator) keyBytes(col state.Column, prefix int) (size int, isString bool) {
+	t := strings.ToLower(strings.TrimSpace(col.Type))
+	base, length := t, 0
+	if m := typeLengthPattern.FindStringSubmatch(t); m != nil {
+		base = strings.TrimSpace(m[1])
+		length, _ = strconv.Atoi(m[2])
+	}
+
+	bpc := 0
+	switch base {
+	case "varchar", "char", "character varying", "character":
+		bpc = g.bytesPerChar()
+	case "varbinary", "binary":
+		bpc = 1
+	default:
+		if !isTextType(base) {
+			return fixedKeyBytes[base], false
+		}
+		// TEXT/BLOB hanya bisa di-index dengan prefix length
+		bpc, length = g.bytesPerChar(), 0
+		if strings.HasSuffix(base, "blob") {
+			bpc = 1
+		}
+	}
+	if prefix > 0 && (length == 0 || prefix < length) {
+		length = prefix
+	}
+	return length * bpc, true
+}
+
+// fitIndexKey memastikan ukuran key index MySQL tidak melebihi batas InnoDB.
+// Untuk index non-unique, prefix length diterapkan otomatis pada kolom string
+// terpanjang. Index unique tidak dipendekkan karena akan mengubah arti
+// keunikannya, sehingga ValidationError dikembalikan beserta ukuran key-nya.
+func (g *Generator) fitIndexKey(table state.Table, idx state.Index) (map[string]int, error) {
+	lengths := make(map[string]int, len(idx.Lengths))
+	for col, length := range idx.Lengths {
+		lengths[col] = length
+	}
+
+	total, longest, longestBytes := 0, "", 0
+	for _, name := range idx.Columns {
+		col, ok := table.Columns[name]
+		if !ok {
+			continue
+		}
+		size, isString := g.keyBytes(col, lengths[name])
+		total += size
+		if isString && lengths[name] == 0 && size > longestBytes {
+			longest, longestBytes = name, size
+		}
+	}
+
+	limit := g.indexKeyLimit(table)
+	if total <= limit {
+		return lengths, nil
+	}
+
+	bpc := g.bytesPerChar()
+	maxChars := 0
+	if longest != "" {
+		maxChars = (limit - (total - longestBytes)) / bpc
+	}
+
+	if idx.Unique || maxChars <= 0 {
+		detail := fmt.Sprintf("index %q key is %d bytes with charset %s (%d bytes/char), exceeding the %d-byte limit",
+			idx.Name, total, g.config.Charset, bpc, limit)
+		if longest != "" && maxChars > 0 {
+			detail += fmt.Sprintf("; shorten %s to at most %d characters", longest, maxChars)
+		}
+		return nil, &ValidationError{Table: table.Name, Column: longest, Rule: "index-key-size", Detail: detail}
+	}
+
+	lengths[longest] = maxChars
+	return lengths, nil
+}
@@ -0,0 +1,132 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// validMasks adalah nilai tag mask=... yang dikenali
+var validMasks = map[string]bool{
+	state.MaskEmail: true,
+	state.MaskHash:  true,
+	state.MaskNull:  true,
+}
+
+// validateMask memeriksa tag mask=...: nilainya harus dikenal, mask=email dan
+// mask=hash hanya untuk kolom string, dan mask=null hanya untuk kolom nullable
+func (g *Generator) validateMask(tableName string, col state.Column) error {
+	if col.Mask == "" {
+		return nil
+	}
+	if !validMasks[col.Mask] {
+		return &ValidationError{Table: tableName, Column: col.Name, Rule: "mask",
+			Detail: fmt.Sprintf("unknown mask %q, use email, hash or null", col.Mask)}
+	}
+	if col.Mask == state.MaskNull && !col.Nullable {
+		return &ValidationError{Table: tableName, Column: col.Name, Rule: "mask",
+			Detail: "mask=null requires a nullable column"}
+	}
+	if col.Mask != state.MaskNull && !isStringType(col.Type) {
+		return &ValidationError{Table: tableName, Column: col.Name, Rule: "mask",
+			Detail: fmt.Sprintf("mask=%s requires a string column, got %s", col.Mask, col.Type)}
+	}
+	return nil
+}
+
+// MaskUpdates membuat satu UPDATE per tabel yang mengganti kolom ber-tag
+// mask=... dengan nilai anonim, untuk dijalankan pada salinan database
+// sebelum dibagikan. Tabel tanpa kolom ter-mask dilewati.
+func (g *Generator) MaskUpdates(schema *state.SchemaState) ([]string, error) {
+	var statements []string
+	err := g.eachMaskedTable(schema, func(table state.Table, columns []state.Column) {
+		var sets []string
+		for _, col := range columns {
+			if col.Mask != "" {
+				sets = append(sets, fmt.Sprintf("%s = %s", g.quote(col.Name), g.maskExpr(col)))
+			}
+		}
+		statements = append(statements, fmt.Sprintf("UPDATE %s SET %s;", g.quote(table.Name), strings.Join(sets, ", ")))
+	})
+	return statements, err
+}
+
+// MaskSelects membuat satu SELECT per tabel yang punya kolom ber-tag
+// mask=..., berisi semua kolom sesuai urutan dengan ekspresi mask di tempat
+// kolom ter-mask, untuk tool dump yang menerima query sendiri
+func (g *Generator) MaskSelects(schema *state.SchemaState) ([]string, error) {
+	var statements []string
+	err := g.eachMaskedTable(schema, func(table state.Table, columns []state.Column) {
+		list := make([]string, len(columns))
+		for i, col := range columns {
+			list[i] = g.quote(col.Name)
+			if col.Mask != "" {
+				list[i] = fmt.Sprintf("%s AS %s", g.maskExpr(col), g.quote(col.Name))
+			}
+		}
+		statements = append(statements, fmt.Sprintf("SELECT %s FROM %s;", strings.Join(list, ", "), g.quote(table.Name)))
+	})
+	return statements, err
+}
+
+// eachMaskedTable menerapkan tag dan memvalidasi mask, lalu memanggil fn
+// untuk setiap tabel yang punya kolom ter-mask
+func (g *Generator) eachMaskedTable(schema *state.SchemaState, fn func(state.Table, []state.Column)) error {
+	schema = g.applyTags(schema)
+	for _, table := range sortedTables(schema.Tables) {
+		columns := g.orderedColumns(table.Columns)
+		masked := false
+		for _, col := range columns {
+			if err := g.validateMask(table.Name, col); err != nil {
+				return err
+			}
+			masked = masked || col.Mask != ""
+		}
+		if masked {
+			fn(table, columns)
+		}
+	}
+	return nil
+}
+
+// maskExpr mengembalikan ekspresi SQL pengganti nilai kolom. Hasil hash dan
+// email dipotong ke panjang kolom agar UPDATE tidak gagal pada VARCHAR pendek.
+func (g *Generator) maskExpr(col state.Column) string {
+	name := g.quote(col.Name)
+	var expr string
+	switch col.Mask {
+	case state.MaskNull:
+		return "NULL"
+	case state.MaskHash:
+		expr = g.md5Expr(name)
+	case state.MaskEmail:
+		// Bagian lokal diganti hash, domain dipertahankan
+		switch g.config.Dialect {
+		case DialectPostgres, DialectCockroach:
+			expr = fmt.Sprintf("regexp_replace(%s, '^[^@]*', %s)", name, g.md5Expr(name))
+		case DialectMSSQL:
+			expr = fmt.Sprintf("%s + IIF(CHARINDEX('@', %s) > 0, SUBSTRING(%s, CHARINDEX('@', %s), LEN(%s)), '')",
+				g.md5Expr(name), name, name, name, name)
+		default:
+			expr = fmt.Sprintf("CONCAT(%s, IF(LOCATE('@', %s) > 0, SUBSTRING(%s, LOCATE('@', %s)), ''))",
+				g.md5Expr(name), name, name, name)
+		}
+	}
+	if _, _, length, ok := stringType(col.Type); ok && length > 0 {
+		expr = fmt.Sprintf("LEFT(%s, %d)", expr, length)
+	}
+	return expr
+}
+
+// md5Expr mengembalikan hash MD5 heksadesimal dari ekspresi expr
+func (g *Generator) md5Expr(expr string) string {
+	switch g.config.Dialect {
+	case DialectPostgres, DialectCockroach:
+		return fmt.Sprintf("md5(%s::text)", expr)
+	case DialectMSSQL:
+		return fmt.Sprintf("CONVERT(VARCHAR(32), HASHBYTES('MD5', %s), 2)", expr)
+	default:
+		return fmt.Sprintf("MD5(%s)", expr)
+	}
+}
@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// maskedUsers membuat tabel users dengan satu kolom per aturan mask, plus
+// tabel logs tanpa mask yang harus dilewati
+func maskedUsers() *state.SchemaState {
+	return schemaOf(state.Table{Name: "users", Columns: map[string]state.Column{
+		"id":    {Name: "id", Type: "INT", Position: 1},
+		"email": {Name: "email", Type: "VARCHAR(100)", Position: 2, Tags: map[string]string{"mask": "email"}},
+		"token": {Name: "token", Type: "TEXT", Position: 3, Tags: map[string]string{"mask": "hash"}},
+		"phone": {Name: "phone", Type: "VARCHAR(20)", Nullable: true, Position: 4, Tags: map[string]string{"mask": "null"}},
+	}}, state.Table{Name: "logs", Columns: map[string]state.Column{
+		"id": {Name: "id", Type: "INT", Position: 1},
+	}})
+}
+
+func TestMaskStatements(t *testing.T) {
+	pgEmail := `LEFT(regexp_replace("email", '^[^@]*', md5("email"::text)), 100)`
+	tests := []struct {
+		dialect    string
+		wantUpdate string
+		wantSelect string
+	}{
+		{DialectPostgres,
+			`UPDATE "users" SET "email" = ` + pgEmail + `, "token" = md5("token"::text), "phone" = NULL;`,
+			`SELECT "id", ` + pgEmail + ` AS "email", md5("token"::text) AS "token", NULL AS "phone" FROM "users";`},
+		{DialectCockroach,
+			`UPDATE "users" SET "email" = ` + pgEmail + `, "token" = md5("token"::text), "phone" = NULL;`,
+			`SELECT "id", ` + pgEmail + ` AS "email", md5("token"::text) AS "token", NULL AS "phone" FROM "users";`},
+		{DialectMySQL,
+			"UPDATE `users` SET `email` = LEFT(CONCAT(MD5(`email`), IF(LOCATE('@', `email`) > 0, SUBSTRING(`email`, LOCATE('@', `email`)), '')), 100), `token` = MD5(`token`), `phone` = NULL;",
+			"SELECT `id`, LEFT(CONCAT(MD5(`email`), IF(LOCATE('@', `email`) > 0, SUBSTRING(`email`, LOCATE('@', `email`)), '')), 100) AS `email`, MD5(`token`) AS `token`, NULL AS `phone` FROM `users`;"},
+		{DialectMSSQL,
+			"UPDATE [users] SET [email] = LEFT(CONVERT(VARCHAR(32), HASHBYTES('MD5', [email]), 2) + IIF(CHARINDEX('@', [email]) > 0, SUBSTRING([email], CHARINDEX('@', [email]), LEN([email])), ''), 100), [token] = CONVERT(VARCHAR(32), HASHBYTES('MD5', [token]), 2), [phone] = NULL;",
+			"SELECT [id], LEFT(CONVERT(VARCHAR(32), HASHBYTES('MD5', [email]), 2) + IIF(CHARINDEX('@', [email]) > 0, SUBSTRING([email], CHARINDEX('@', [email]), LEN([email])), ''), 100) AS [email], CONVERT(VARCHAR(32), HASHBYTES('MD5', [token]), 2) AS [token], NULL AS [phone] FROM [users];"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			g := NewGenerator(&Config{Dialect: tt.dialect})
+			updates, err := g.MaskUpdates(maskedUsers())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{tt.wantUpdate}; !reflect.DeepEqual(updates, want) {
+				t.Errorf("updates:\n got %q\nwant %q", updates, want)
+			}
+			selects, err := g.MaskSelects(maskedUsers())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{tt.wantSelect}; !reflect.DeepEqual(selects, want) {
+				t.Errorf("selects:\n got %q\nwant %q", selects, want)
+			}
+		})
+	}
+}
+
+func TestMaskValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		col  state.Column
+	}{
+		{"unknown mask", state.Column{Type: "TEXT", Tags: map[string]string{"mask": "shuffle"}}},
+		{"null on a not null column", state.Column{Type: "TEXT", Tags: map[string]string{"mask": "null"}}},
+		{"hash on a non-string column", state.Column{Type: "INT", Tags: map[string]string{"mask": "hash"}}},
+		{"email on a non-string column", state.Column{Type: "BIGINT", Tags: map[string]string{"mask": "email"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.col.Name, tt.col.Position = "secret", 1
+			s := schemaOf(state.Table{Name: "users", Columns: map[string]state.Column{"secret": tt.col}})
+			_, err := NewGenerator(&Config{Dialect: DialectPostgres}).MaskUpdates(s)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Rule != "mask" || validationErr.Column != "secret" {
+				t.Fatalf("err = %v, want ValidationError with rule \"mask\" on secret", err)
+			}
+		})
+	}
+}
+
+func TestMaskWithoutMaskedColumns(t *testing.T) {
+	s := schemaOf(state.Table{Name: "logs", Columns: map[string]state.Column{
+		"id": {Name: "id", Type: "INT", Position: 1},
+	}})
+	updates, err := NewGenerator(&Config{Dialect: DialectPostgres}).MaskUpdates(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 0 {
+		t.Errorf("updates = %q, want none", updates)
+	}
+}
@@ -0,0 +1,130 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// mssqlMaxNVarchar adalah panjang NVARCHAR terbesar sebelum harus memakai NVARCHAR(MAX)
+const mssqlMaxNVarchar = 4000
+
+// mssqlTypes memetakan tipe dasar (huruf kecil, tanpa argumen) dari schema
+// program ke tipe SQL Server. Tipe yang tidak tercantum ditulis apa adanya.
+var mssqlTypes = map[string]string{
+	"varchar":                     "NVARCHAR",
+	"character varying":           "NVARCHAR",
+	"char":                        "NCHAR",
+	"character":                   "NCHAR",
+	"text":                        "NVARCHAR(MAX)",
+	"tinytext":                    "NVARCHAR(MAX)",
+	"mediumtext":                  "NVARCHAR(MAX)",
+	"longtext":                    "NVARCHAR(MAX)",
+	"json":                        "NVARCHAR(MAX)",
+	"jsonb":                       "NVARCHAR(MAX)",
+	"datetime":                    "DATETIME2",
+	"timestamp":                   "DATETIME2",
+	"timestamp without time zone": "DATETIME2",
+	"timestamptz":                 "DATETIMEOFFSET",
+	"timestamp with time zone":    "DATETIMEOFFSET",
+	"bool":                        "BIT",
+	"boolean":                     "BIT",
+	"double":                      "FLOAT",
+	"double precision":            "FLOAT",
+	"float8":                      "FLOAT",
+	"float4":                      "REAL",
+	"integer":                     "INT",
+	"int4":                        "INT",
+	"int8":                        "BIGINT",
+	"int2":                        "SMALLINT",
+	"serial":                      "INT",
+	"bigserial":                   "BIGINT",
+	"smallserial":                 "SMALLINT",
+	"bytea":                       "VARBINARY(MAX)",
+	"blob":                        "VARBINARY(MAX)",
+	"tinyblob":                    "VARBINARY(MAX)",
+	"mediumblob":                  "VARBINARY(MAX)",
+	"longblob":                    "VARBINARY(MAX)",
+	"uuid":                        "UNIQUEIDENTIFIER",
+}
+
+// mssqlType mengubah tipe kolom menjadi tipe SQL Server. Panjang dan
+// precision dipertahankan, mis. varchar(100) menjadi NVARCHAR(100) dan
+// timestamp(3) menjadi DATETIME2(3).
+func mssqlType(sqlType string) string {
+	t := strings.TrimSpace(sqlType)
+	if strings.EqualFold(t, "tinyint(1)") {
+		return "BIT"
+	}
+	base, args := t, ""
+	if open := strings.Index(t, "("); open != -1 && strings.HasSuffix(t, ")") {
+		base, args = strings.TrimSpace(t[:open]), t[open+1:len(t)-1]
+	}
+	mapped, ok := mssqlTypes[strings.ToLower(base)]
+	if !ok {
+		return t
+	}
+	if args == "" || strings.Contains(mapped, "(") {
+		return mapped
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(args)); err == nil && mapped == "NVARCHAR" && n > mssqlMaxNVarchar {
+		return "NVARCHAR(MAX)"
+	}
+	return mapped + "(" + args + ")"
+}
+
+// mssqlDefaultName adalah nama default constraint kolom. SQL Server menyimpan
+// DEFAULT sebagai constraint, sehingga namanya harus tetap agar bisa di-drop.
+func mssqlDefaultName(tableName, columnName string) string {
+	return fmt.Sprintf("df_%s_%s", tableName, columnName)
+}
+
+// tableColumnDef adalah generateColumnDef untuk kolom milik tableName. Di SQL
+// Server default ditulis sebagai default constraint bernama.
+func (g *Generator) tableColumnDef(tableName string, col state.Column) string {
+	def := g.generateColumnDef(col)
+	if g.config.Dialect == DialectMSSQL && col.DefaultValue != nil {
+		def += fmt.Sprintf(" CONSTRAINT %s DEFAULT %s", g.quote(mssqlDefaultName(tableName, col.Name)), g.defaultSQL(col))
+	}
+	return def
+}
+
+// mssqlDropDefault membuat statement yang men-drop default constraint kolom
+func (g *Generator) mssqlDropDefault(tableName, columnName string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", g.quote(tableName), g.quote(mssqlDefaultName(tableName, columnName)))
+}
+
+// mssqlModifyColumn mengubah kolom dengan ALTER COLUMN. ALTER COLUMN selalu
+// menulis ulang tipe dan nullability, dan default constraint harus di-drop
+// sebelum tipe kolomnya diubah lalu dibuat kembali.
+func (g *Generator) mssqlModifyColumn(tableName string, current, desired state.Column) []string {
+	table := g.quote(tableName)
+	column := g.quote(desired.Name)
+
+	var statements []string
+	if current.AutoIncrement != desired.AutoIncrement {
+		statements = append(statements, fmt.Sprintf("-- datara: %s.%s: mssql cannot add or remove IDENTITY on an existing column; rebuild the table manually",
+			tableName, desired.Name))
+	}
+
+	retyped := g.columnType(current) != g.columnType(desired) || !collationEqual(current, desired) || current.Nullable != desired.Nullable
+	redefault := !current.DefaultValue.Equal(desired.DefaultValue) || (retyped && desired.DefaultValue != nil)
+	if current.DefaultValue != nil && redefault {
+		statements = append(statements, g.mssqlDropDefault(tableName, current.Name))
+	}
+	if retyped {
+		nullability := " NULL"
+		if !desired.Nullable {
+			nullability = " NOT NULL"
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s%s%s",
+			table, column, g.columnType(desired), g.collationSQL(desired), nullability))
+	}
+	if desired.DefaultValue != nil && redefault {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s DEFAULT %s FOR %s",
+			table, g.quote(mssqlDefaultName(tableName, desired.Name)), g.defaultSQL(desired), column))
+	}
+	return append(statements, g.modifyOnUpdate(tableName, current, desired)...)
+}
@@ -0,0 +1,88 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+func TestMSSQLType(t *testing.T) {
+	tests := map[string]string{
+		"varchar(100)":             "NVARCHAR(100)",
+		"VARCHAR(8000)":            "NVARCHAR(MAX)",
+		"char(2)":                  "NCHAR(2)",
+		"text":                     "NVARCHAR(MAX)",
+		"jsonb":                    "NVARCHAR(MAX)",
+		"timestamp":                "DATETIME2",
+		"timestamp(3)":             "DATETIME2(3)",
+		"timestamp with time zone": "DATETIMEOFFSET",
+		"boolean":                  "BIT",
+		"tinyint(1)":               "BIT",
+		"double precision":         "FLOAT",
+		"bigserial":                "BIGINT",
+		"bytea":                    "VARBINARY(MAX)",
+		"uuid":                     "UNIQUEIDENTIFIER",
+		"DECIMAL(10,2)":            "DECIMAL(10,2)",
+		"MONEY":                    "MONEY",
+	}
+	for input, want := range tests {
+		if got := mssqlType(input); got != want {
+			t.Errorf("mssqlType(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestMSSQLModifyColumn(t *testing.T) {
+	str := func(s string) *state.DefaultValue { return &state.DefaultValue{Kind: state.DefaultString, Value: s} }
+	name := state.Column{Name: "name", Type: "VARCHAR(50)", Nullable: true}
+	with := func(col state.Column, change func(*state.Column)) state.Column {
+		change(&col)
+		return col
+	}
+	named := with(name, func(c *state.Column) { c.DefaultValue = str("a") })
+	tests := []struct {
+		name             string
+		current, desired *state.SchemaState
+		want             []string
+	}{
+		{"type change rewrites nullability", usersWith(name), usersWith(with(name, func(c *state.Column) { c.Type = "VARCHAR(100)" })),
+			[]string{"ALTER TABLE [users] ALTER COLUMN [name] NVARCHAR(100) NULL;"}},
+		{"not null", usersWith(name), usersWith(with(name, func(c *state.Column) { c.Nullable = false })),
+			[]string{"ALTER TABLE [users] ALTER COLUMN [name] NVARCHAR(50) NOT NULL;"}},
+		{"default change replaces the named constraint", usersWith(named), usersWith(with(named, func(c *state.Column) { c.DefaultValue = str("b") })),
+			[]string{
+				"ALTER TABLE [users] DROP CONSTRAINT [df_users_name];",
+				"ALTER TABLE [users] ADD CONSTRAINT [df_users_name] DEFAULT 'b' FOR [name];",
+			}},
+		{"dropped default", usersWith(named), usersWith(name),
+			[]string{"ALTER TABLE [users] DROP CONSTRAINT [df_users_name];"}},
+		{"type change around a default", usersWith(named), usersWith(with(named, func(c *state.Column) { c.Type = "TEXT" })),
+			[]string{
+				"ALTER TABLE [users] DROP CONSTRAINT [df_users_name];",
+				"ALTER TABLE [users] ALTER COLUMN [name] NVARCHAR(MAX) NULL;",
+				"ALTER TABLE [users] ADD CONSTRAINT [df_users_name] DEFAULT 'a' FOR [name];",
+			}},
+		{"dropped column drops its default first", usersWith(named), usersWith(),
+			[]string{
+				"ALTER TABLE [users] DROP CONSTRAINT [df_users_name];",
+				"ALTER TABLE [users] DROP COLUMN [name];",
+			}},
+		{"added column names its default", usersWith(), usersWith(named),
+			[]string{"ALTER TABLE [users] ADD [name] NVARCHAR(50) CONSTRAINT [df_users_name] DEFAULT 'a';"}},
+		{"identity cannot be altered", usersWith(state.Column{Name: "seq", Type: "INT", Nullable: true}),
+			usersWith(state.Column{Name: "seq", Type: "INT", Nullable: true, AutoIncrement: true}),
+			[]string{"-- datara: users.seq: mssql cannot add or remove IDENTITY on an existing column; rebuild the table manually"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewGenerator(&Config{Dialect: DialectMSSQL}).GenerateStatements(tt.current, tt.desired)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements:\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
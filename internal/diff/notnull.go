@@ -0,0 +1,130 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// notNullAdvice adalah saran pola backfill untuk kolom NOT NULL tanpa default
+const notNullAdvice = "add it nullable, backfill existing rows, then set NOT NULL " +
+	"(-expand-not-null writes these three steps), give it a default, " +
+	"or pass -allow-not-null-without-default if the table is empty"
+
+// DefaultBackfillBatchSize adalah jumlah baris per UPDATE backfill jika
+// Config.BackfillBatchSize tidak diisi
+const DefaultBackfillBatchSize = 1000
+
+// backfillPlaceholder adalah nilai backfill kolom tanpa tag backfill=...
+// Sengaja bukan SQL yang valid: dengan NULL, UPDATE per batch akan terus
+// menemukan baris yang sama dan tidak pernah selesai.
+const backfillPlaceholder = "/* TODO: backfill value */"
+
+// requiresBackfill mengecek apakah kolom baru pada tabel yang sudah ada tidak
+// bisa diisi untuk baris lama: NOT NULL tanpa default, bukan auto increment
+// dan bukan generated column
+func requiresBackfill(col state.Column) bool {
+	return !col.Nullable && col.DefaultValue == nil && !col.AutoIncrement &&
+		!generatedColumnPattern.MatchString(col.Extra)
+}
+
+// validateNotNullAdds menolak ADD COLUMN NOT NULL tanpa default pada tabel
+// yang sudah ada di snapshot: Postgres menolaknya jika tabel berisi data dan
+// MySQL lama me-rebuild tabel. ExpandNotNull memecahnya menjadi tiga langkah
+// (backfill tanpa tag backfill=... diberi peringatan karena berisi TODO),
+// AllowNotNullWithoutDefault menurunkannya menjadi peringatan.
+func (g *Generator) validateNotNullAdds(current, desired *state.SchemaState) error {
+	if g.down {
+		return nil
+	}
+	for _, table := range sortedTables(desired.Tables) {
+		existing, ok := current.Tables[table.Name]
+		if !ok {
+			continue
+		}
+		for _, col := range sortedColumns(table.Columns) {
+			if _, exists := existing.Columns[col.Name]; exists || !requiresBackfill(col) {
+				continue
+			}
+			switch {
+			case g.config.ExpandNotNull:
+				if backfillValue(col) == "" {
+					g.warnings.Add("backfill-todo", table.Name, col.Name,
+						"backfill value is a TODO placeholder; fill it in or tag the column with backfill=<expression>")
+				}
+			case g.config.AllowNotNullWithoutDefault:
+				g.warnings.Add("not-null-without-default", table.Name, col.Name,
+					"new NOT NULL column without a default fails on a populated table")
+			default:
+				return &ValidationError{Table: table.Name, Column: col.Name, Rule: "not-null-without-default",
+					Detail: "new NOT NULL column without a default on an existing table; " + notNullAdvice}
+			}
+		}
+	}
+	return nil
+}
+
+// backfillValue mengembalikan ekspresi backfill dari tag backfill=...
+func backfillValue(col state.Column) string {
+	return strings.TrimSpace(col.Tags["backfill"])
+}
+
+// needsBackfillTodo mengecek apakah kolom baru akan di-backfill dengan
+// placeholder TODO oleh expandNotNull
+func (g *Generator) needsBackfillTodo(col state.Column) bool {
+	return g.config.ExpandNotNull && !g.down && requiresBackfill(col) && backfillValue(col) == ""
+}
+
+// expandNotNull memecah ADD COLUMN NOT NULL tanpa default menjadi kolom
+// nullable, UPDATE untuk baris lama dan SET NOT NULL. nullable dipakai untuk
+// ADD COLUMN; rest dijalankan setelah semua ALTER TABLE tabel tersebut.
+func (g *Generator) expandNotNull(tableName string, col state.Column) (nullable state.Column, rest []string) {
+	nullable = col
+	nullable.Nullable = true
+	// batchedBackfill sudah diakhiri ';' sehingga tidak diakhiri lagi oleh terminate
+	rest = append(rest, withWarning(fmt.Sprintf("-- datara: backfill %s.%s before it becomes NOT NULL", tableName, col.Name),
+		g.batchedBackfill(tableName, col)))
+	return nullable, append(rest, g.generateModifyColumn(tableName, nullable, col)...)
+}
+
+// batchedBackfill membuat UPDATE yang mengisi baris lama per batch agar tabel
+// besar tidak dikunci oleh satu UPDATE raksasa: blok DO dengan jeda pg_sleep
+// di Postgres, WHILE di SQL Server, dan UPDATE ... LIMIT yang diulang sampai
+// tidak ada baris tersisa di MySQL dan CockroachDB
+func (g *Generator) batchedBackfill(tableName string, col state.Column) string {
+	table, column := g.quote(tableName), g.quote(col.Name)
+	value := backfillValue(col)
+	if value == "" {
+		value = backfillPlaceholder
+	}
+	size := g.config.BackfillBatchSize
+	if size <= 0 {
+		size = DefaultBackfillBatchSize
+	}
+
+	switch g.config.Dialect {
+	case DialectPostgres:
+		return fmt.Sprintf(`DO $$
+DECLARE
+  updated integer;
+BEGIN
+  LOOP
+    UPDATE %[1]s SET %[2]s = %[3]s
+    WHERE ctid IN (SELECT ctid FROM %[1]s WHERE %[2]s IS NULL LIMIT %[4]d);
+    GET DIAGNOSTICS updated = ROW_COUNT;
+    EXIT WHEN updated = 0;
+    PERFORM pg_sleep(0.1);
+  END LOOP;
+END
+$$;`, table, column, value, size)
+	case DialectMSSQL:
+		return fmt.Sprintf(`WHILE 1 = 1
+BEGIN
+  UPDATE TOP (%[4]d) %[1]s SET %[2]s = %[3]s WHERE %[2]s IS NULL;
+  IF @@ROWCOUNT = 0 BREAK;
+END;`, table, column, value, size)
+	}
+	return fmt.Sprintf("-- datara: repeat until no rows are updated\n%s",
+		terminate(fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IS NULL LIMIT %d", table, column, value, column, size)))
+}
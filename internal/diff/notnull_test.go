@@ -0,0 +1,184 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// usersWith membuat tabel users dengan kolom id dan kolom tambahan
+func usersWith(columns ...state.Column) *state.SchemaState {
+	table := state.Table{Name: "users", Columns: map[string]state.Column{
+		"id": {Name: "id", Type: "INT", Position: 1},
+	}}
+	for i, col := range columns {
+		col.Position = i + 2
+		table.Columns[col.Name] = col
+	}
+	return schemaOf(table)
+}
+
+func TestNotNullWithoutDefault(t *testing.T) {
+	age := state.Column{Name: "age", Type: "INT"}
+	tests := []struct {
+		name     string
+		current  *state.SchemaState
+		desired  *state.SchemaState
+		config   Config
+		wantErr  bool
+		wantWarn string
+	}{
+		{name: "NOT NULL without default on an existing table", current: usersWith(), desired: usersWith(age), wantErr: true},
+		{name: "new table", current: schemaOf(), desired: usersWith(age)},
+		{name: "nullable", current: usersWith(), desired: usersWith(state.Column{Name: "age", Type: "INT", Nullable: true})},
+		{name: "with default", current: usersWith(), desired: usersWith(state.Column{Name: "age", Type: "INT", DefaultValue: state.ParseDefault("0")})},
+		{name: "with NULL default", current: usersWith(), desired: usersWith(state.Column{Name: "age", Type: "INT", DefaultValue: state.ParseDefault("NULL")})},
+		{name: "auto increment", current: usersWith(), desired: usersWith(state.Column{Name: "seq", Type: "BIGINT", AutoIncrement: true})},
+		{name: "generated column", current: usersWith(), desired: usersWith(state.Column{Name: "age", Type: "INT", Extra: "GENERATED ALWAYS AS (id * 2) STORED"})},
+		{name: "existing column becoming NOT NULL", current: usersWith(state.Column{Name: "age", Type: "INT", Nullable: true}), desired: usersWith(age)},
+		{name: "allowed", current: usersWith(), desired: usersWith(age), config: Config{AllowNotNullWithoutDefault: true}, wantWarn: "not-null-without-default"},
+		{name: "expanded", current: usersWith(), desired: usersWith(age), config: Config{ExpandNotNull: true}, wantWarn: "backfill-todo"},
+		{name: "expanded with backfill tag", current: usersWith(), desired: usersWith(state.Column{Name: "age", Type: "INT", Tags: map[string]string{"backfill": "0"}}), config: Config{ExpandNotNull: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Dialect = DialectPostgres
+			g := NewGenerator(&config)
+			_, err := g.GenerateStatements(tt.current, tt.desired)
+			var validationErr *ValidationError
+			if tt.wantErr {
+				if !errors.As(err, &validationErr) || validationErr.Rule != "not-null-without-default" ||
+					validationErr.Table != "users" || validationErr.Column != "age" || !strings.Contains(err.Error(), "backfill") {
+					t.Fatalf("err = %v, want not-null-without-default ValidationError on users.age suggesting a backfill", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var codes []string
+			for _, w := range g.Warnings() {
+				codes = append(codes, w.Code)
+			}
+			if got := strings.Join(codes, ","); got != tt.wantWarn {
+				t.Errorf("warnings = %q, want %q", got, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestNotNullWithoutDefaultDown(t *testing.T) {
+	// Down yang mengembalikan kolom NOT NULL yang di-drop tidak diperiksa
+	g := NewGenerator(&Config{Dialect: DialectPostgres, ExpandNotNull: true})
+	down, err := g.GenerateDownStatements(usersWith(), usersWith(state.Column{Name: "age", Type: "INT"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `ALTER TABLE "users" ADD COLUMN "age" INT NOT NULL;`
+	if got := strings.Join(down, "\n"); got != want {
+		t.Errorf("down = %q, want %q", got, want)
+	}
+}
+
+func TestExpandNotNull(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		column state.Column
+		want   []string
+	}{
+		{
+			name:   "postgres",
+			config: Config{Dialect: DialectPostgres},
+			column: state.Column{Name: "age", Type: "INT"},
+			want: []string{
+				`ALTER TABLE "users" ADD COLUMN "age" INT;`,
+				"-- datara: backfill users.age before it becomes NOT NULL\n" + fmt.Sprintf(postgresBackfill, "/* TODO: backfill value */", 1000),
+				`ALTER TABLE "users" ALTER COLUMN "age" SET NOT NULL;`,
+			},
+		},
+		{
+			name:   "postgres with backfill tag and batch size",
+			config: Config{Dialect: DialectPostgres, BackfillBatchSize: 200},
+			column: state.Column{Name: "age", Type: "INT", Tags: map[string]string{"backfill": "18"}},
+			want: []string{
+				`ALTER TABLE "users" ADD COLUMN "age" INT;`,
+				"-- datara: backfill users.age before it becomes NOT NULL\n" + fmt.Sprintf(postgresBackfill, "18", 200),
+				`ALTER TABLE "users" ALTER COLUMN "age" SET NOT NULL;`,
+			},
+		},
+		{
+			name:   "mysql",
+			config: Config{Dialect: DialectMySQL},
+			column: state.Column{Name: "age", Type: "INT", Tags: map[string]string{"backfill": "18"}},
+			want: []string{
+				"ALTER TABLE `users` ADD COLUMN `age` INT;",
+				"-- datara: backfill users.age before it becomes NOT NULL\n-- datara: repeat until no rows are updated\nUPDATE `users` SET `age` = 18 WHERE `age` IS NULL LIMIT 1000;",
+				"ALTER TABLE `users` MODIFY COLUMN `age` INT NOT NULL;",
+			},
+		},
+		{
+			name:   "mysql batched alter",
+			config: Config{Dialect: DialectMySQL, BatchAlter: true},
+			column: state.Column{Name: "age", Type: "INT", Tags: map[string]string{"backfill": "18"}},
+			want: []string{
+				"ALTER TABLE `users` ADD COLUMN `age` INT;",
+				"-- datara: backfill users.age before it becomes NOT NULL\n-- datara: repeat until no rows are updated\nUPDATE `users` SET `age` = 18 WHERE `age` IS NULL LIMIT 1000;",
+				"ALTER TABLE `users` MODIFY COLUMN `age` INT NOT NULL;",
+			},
+		},
+		{
+			name:   "cockroach",
+			config: Config{Dialect: DialectCockroach},
+			column: state.Column{Name: "age", Type: "INT", Tags: map[string]string{"backfill": "18"}},
+			want: []string{
+				`ALTER TABLE "users" ADD COLUMN "age" INT;`,
+				"-- datara: backfill users.age before it becomes NOT NULL\n-- datara: repeat until no rows are updated\nUPDATE \"users\" SET \"age\" = 18 WHERE \"age\" IS NULL LIMIT 1000;",
+				`ALTER TABLE "users" ALTER COLUMN "age" SET NOT NULL;`,
+			},
+		},
+		{
+			name:   "mssql",
+			config: Config{Dialect: DialectMSSQL, BackfillBatchSize: 50},
+			column: state.Column{Name: "age", Type: "INT", Tags: map[string]string{"backfill": "18"}},
+			want: []string{
+				"ALTER TABLE [users] ADD [age] INT;",
+				"-- datara: backfill users.age before it becomes NOT NULL\nWHILE 1 = 1\nBEGIN\n  UPDATE TOP (50) [users] SET [age] = 18 WHERE [age] IS NULL;\n  IF @@ROWCOUNT = 0 BREAK;\nEND;",
+				"ALTER TABLE [users] ALTER COLUMN [age] INT NOT NULL;",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.ExpandNotNull = true
+			statements, err := NewGenerator(&config).GenerateStatements(usersWith(), usersWith(tt.column))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := strings.Join(statements, "\n"), strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+// postgresBackfill adalah blok DO backfill users.age di Postgres dengan
+// nilai dan ukuran batch sebagai argumen format
+const postgresBackfill = `DO $$
+DECLARE
+  updated integer;
+BEGIN
+  LOOP
+    UPDATE "users" SET "age" = %s
+    WHERE ctid IN (SELECT ctid FROM "users" WHERE "age" IS NULL LIMIT %d);
+    GET DIAGNOSTICS updated = ROW_COUNT;
+    EXIT WHEN updated = 0;
+    PERFORM pg_sleep(0.1);
+  END LOOP;
+END
+$$;`
@@ -0,0 +1,194 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// onlineOperations memetakan operasi per dialect ke dukungan perubahan online
+// (MySQL: ALGORITHM=INPLACE, LOCK=NONE; Postgres: tanpa lock/rewrite panjang).
+// Operasi yang bernilai false diberi komentar peringatan saat Config.Online aktif.
+var onlineOperations = map[string]map[string]bool{
+	DialectMySQL: {
+		"ADD COLUMN":          true,
+		"DROP COLUMN":         true,
+		"ADD INDEX":           true,
+		"ADD UNIQUE INDEX":    true,
+		"CREATE INDEX":        true,
+		"CREATE UNIQUE INDEX": true,
+		"DROP INDEX":          true,
+		"ADD PRIMARY KEY":     true,
+		"ADD UNIQUE":          true,
+		"DROP FOREIGN KEY":    true,
+		"DROP CHECK":          true,
+		"MODIFY COLUMN":       false,
+		"DROP PRIMARY KEY":    false,
+		"ADD FOREIGN KEY":     false,
+		"ADD CHECK":           false,
+	},
+	DialectPostgres: {
+		"ADD COLUMN":          true,
+		"DROP COLUMN":         true,
+		"CREATE INDEX":        true,
+		"CREATE UNIQUE INDEX": true,
+		"DROP INDEX":          true,
+		"DROP CONSTRAINT":     true,
+		"SET DEFAULT":         true,
+		"DROP DEFAULT":        true,
+		"DROP NOT NULL":       true,
+		"ADD GENERATED":       true,
+		"SET GENERATED":       true,
+		"DROP IDENTITY":       true,
+		"TYPE":                false,
+		"SET NOT NULL":        false,
+		"ADD PRIMARY KEY":     false,
+		"ADD UNIQUE":          false,
+		"ADD FOREIGN KEY":     false,
+		"ADD CHECK":           false,
+	},
+	// Perubahan schema CockroachDB berjalan online di background; hanya
+	// perubahan tipe kolom yang menulis ulang data
+	DialectCockroach: {
+		"ADD COLUMN":          true,
+		"DROP COLUMN":         true,
+		"CREATE INDEX":        true,
+		"CREATE UNIQUE INDEX": true,
+		"DROP INDEX":          true,
+		"DROP CONSTRAINT":     true,
+		"SET DEFAULT":         true,
+		"DROP DEFAULT":        true,
+		"DROP NOT NULL":       true,
+		"SET NOT NULL":        true,
+		"ADD GENERATED":       true,
+		"SET GENERATED":       true,
+		"DROP IDENTITY":       true,
+		"ADD PRIMARY KEY":     true,
+		"ADD UNIQUE":          true,
+		"ADD FOREIGN KEY":     true,
+		"ADD CHECK":           true,
+		"TYPE":                false,
+	},
+}
+
+// NonTransactional mengecek apakah statements harus dijalankan di luar
+// transaksi, mis. CREATE INDEX CONCURRENTLY di Postgres
+func NonTransactional(statements []string) bool {
+	for _, stmt := range statements {
+		if strings.Contains(strings.ToUpper(stmt), " CONCURRENTLY ") {
+			return true
+		}
+	}
+	return false
+}
+
+// applyOnline menambahkan klausa online ke stmt jika semua operasi di parts
+// mendukungnya, atau komentar peringatan jika ada yang tidak
+func (g *Generator) applyOnline(table string, parts []string, stmt string) string {
+	var blocked []string
+	// ALGORITHM=INSTANT hanya dipakai jika server_version diisi dan semua
+	// operasinya ADD COLUMN
+	instant := g.config.ServerVersion.Known() && g.supports(FeatureInstantAddColumn)
+	for _, part := range parts {
+		op := g.operation(table, part)
+		if op != "ADD COLUMN" {
+			instant = false
+		}
+		if op == "" {
+			continue
+		}
+		if !onlineOperations[g.config.Dialect][op] {
+			blocked = append(blocked, op)
+		}
+	}
+	if len(blocked) > 0 {
+		return fmt.Sprintf("-- datara: %s cannot run online on %s; expect locking or a table rewrite\n%s",
+			strings.Join(blocked, ", "), g.config.Dialect, stmt)
+	}
+
+	switch {
+	case g.config.Dialect == DialectMySQL && strings.HasPrefix(stmt, "ALTER TABLE") && instant:
+		// INSTANT tidak menerima klausa LOCK selain DEFAULT
+		if strings.Contains(stmt, "\n") {
+			return stmt + ",\n  ALGORITHM=INSTANT"
+		}
+		return stmt + ", ALGORITHM=INSTANT"
+	case g.config.Dialect == DialectMySQL && strings.HasPrefix(stmt, "ALTER TABLE"):
+		if strings.Contains(stmt, "\n") {
+			return stmt + ",\n  ALGORITHM=INPLACE, LOCK=NONE"
+		}
+		return stmt + ", ALGORITHM=INPLACE, LOCK=NONE"
+	case g.config.Dialect == DialectMySQL && (strings.HasPrefix(stmt, "CREATE") || strings.HasPrefix(stmt, "DROP INDEX")):
+		return stmt + " ALGORITHM=INPLACE LOCK=NONE"
+	case g.config.Dialect == DialectPostgres && (strings.HasPrefix(stmt, "CREATE") || strings.HasPrefix(stmt, "DROP INDEX")):
+		return strings.Replace(stmt, "INDEX ", "INDEX CONCURRENTLY ", 1)
+	}
+	return stmt
+}
+
+// operation mengembalikan nama operasi sebuah statement hasil generator,
+// atau string kosong untuk statement di luar onlineOperations (mis. COMMENT ON)
+func (g *Generator) operation(table, stmt string) string {
+	upper := strings.ToUpper(stmt)
+	for _, op := range []string{"CREATE UNIQUE INDEX", "CREATE INDEX", "DROP INDEX"} {
+		if strings.HasPrefix(upper, op) {
+			return op
+		}
+	}
+
+	prefix := fmt.Sprintf("ALTER TABLE %s ", table)
+	if !strings.HasPrefix(stmt, prefix) {
+		return ""
+	}
+	clause := stmt[len(prefix):]
+	upper = strings.ToUpper(clause)
+
+	switch {
+	case strings.HasPrefix(upper, "ALTER COLUMN "):
+		clause = skipIdent(clause[len("ALTER COLUMN "):])
+	case strings.HasPrefix(upper, "ADD CONSTRAINT "):
+		clause = "ADD " + skipIdent(clause[len("ADD CONSTRAINT "):])
+	}
+	upper = strings.ToUpper(clause)
+
+	// Cocokkan operasi terpanjang lebih dulu (ADD UNIQUE INDEX sebelum ADD UNIQUE)
+	ops := make([]string, 0, len(onlineOperations[g.config.Dialect]))
+	for op := range onlineOperations[g.config.Dialect] {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if len(ops[i]) != len(ops[j]) {
+			return len(ops[i]) > len(ops[j])
+		}
+		return ops[i] < ops[j]
+	})
+	for _, op := range ops {
+		if strings.HasPrefix(upper, op) {
+			return op
+		}
+	}
+
+	// Operasi ALTER TABLE yang tidak dikenal dianggap tidak bisa online
+	if fields := strings.Fields(upper); len(fields) >= 2 {
+		return fields[0] + " " + fields[1]
+	}
+	return upper
+}
+
+// skipIdent membuang identifier (ber-quote atau tidak) di awal s
+func skipIdent(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return s
+	}
+	if quote := s[0]; quote == '"' || quote == '`' {
+		if end := strings.IndexByte(s[1:], quote); end != -1 {
+			return strings.TrimSpace(s[end+2:])
+		}
+		return ""
+	}
+	if space := strings.IndexByte(s, ' '); space != -1 {
+		return strings.TrimSpace(s[space:])
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+func TestOnlineClausePlacement(t *testing.T) {
+	addColumn := func(tb *state.Table) {
+		tb.Columns["age"] = state.Column{Name: "age", Type: "INT", Nullable: true, Position: 9}
+	}
+	widen := func(tb *state.Table) {
+		c := tb.Columns["nickname"]
+		c.Type = "VARCHAR(200)"
+		tb.Columns["nickname"] = c
+	}
+	addIndex := func(tb *state.Table) {
+		tb.Indexes["idx_users_email"] = state.Index{Name: "idx_users_email", Columns: []string{"email"}}
+	}
+	dropIndex := func(tb *state.Table) { delete(tb.Indexes, "idx_users_nickname") }
+
+	tests := []struct {
+		name    string
+		config  Config
+		changes []func(tb *state.Table)
+		want    []string
+	}{
+		{
+			name:    "mysql add column",
+			config:  Config{Dialect: DialectMySQL},
+			changes: []func(*state.Table){addColumn},
+			want:    []string{"ALTER TABLE `users` ADD COLUMN `age` INT, ALGORITHM=INPLACE, LOCK=NONE;"},
+		},
+		{
+			name:    "mysql add column instant",
+			config:  Config{Dialect: DialectMySQL, ServerVersion: mustServerVersion(t, "mysql:8.0.29")},
+			changes: []func(*state.Table){addColumn},
+			want:    []string{"ALTER TABLE `users` ADD COLUMN `age` INT, ALGORITHM=INSTANT;"},
+		},
+		{
+			name:    "mysql create index",
+			config:  Config{Dialect: DialectMySQL},
+			changes: []func(*state.Table){addIndex},
+			want:    []string{"CREATE INDEX `idx_users_email` ON `users` (`email`) ALGORITHM=INPLACE LOCK=NONE;"},
+		},
+		{
+			name:    "mysql drop index",
+			config:  Config{Dialect: DialectMySQL},
+			changes: []func(*state.Table){dropIndex},
+			want:    []string{"DROP INDEX `idx_users_nickname` ON `users` ALGORITHM=INPLACE LOCK=NONE;"},
+		},
+		{
+			name:    "mysql modify column",
+			config:  Config{Dialect: DialectMySQL},
+			changes: []func(*state.Table){widen},
+			want: []string{"-- datara: MODIFY COLUMN cannot run online on mysql; expect locking or a table rewrite\n" +
+				"ALTER TABLE `users` MODIFY COLUMN `nickname` VARCHAR(200);"},
+		},
+		{
+			name:    "mysql batch with a blocking clause",
+			config:  Config{Dialect: DialectMySQL, BatchAlter: true},
+			changes: []func(*state.Table){addColumn, widen},
+			want: []string{"-- datara: MODIFY COLUMN cannot run online on mysql; expect locking or a table rewrite\n" +
+				"ALTER TABLE `users`\n  MODIFY COLUMN `nickname` VARCHAR(200),\n  ADD COLUMN `age` INT;"},
+		},
+		{
+			name:    "mysql batch online",
+			config:  Config{Dialect: DialectMySQL, BatchAlter: true},
+			changes: []func(*state.Table){addColumn, dropIndex},
+			want:    []string{"ALTER TABLE `users`\n  ADD COLUMN `age` INT,\n  DROP INDEX `idx_users_nickname`,\n  ALGORITHM=INPLACE, LOCK=NONE;"},
+		},
+		{
+			name:    "postgres create index",
+			config:  Config{Dialect: DialectPostgres},
+			changes: []func(*state.Table){addIndex},
+			want:    []string{`CREATE INDEX CONCURRENTLY "idx_users_email" ON "users" ("email");`},
+		},
+		{
+			name:    "postgres drop index",
+			config:  Config{Dialect: DialectPostgres},
+			changes: []func(*state.Table){dropIndex},
+			want:    []string{`DROP INDEX CONCURRENTLY "idx_users_nickname";`},
+		},
+		{
+			name:    "postgres add column",
+			config:  Config{Dialect: DialectPostgres},
+			changes: []func(*state.Table){addColumn},
+			want:    []string{`ALTER TABLE "users" ADD COLUMN "age" INT;`},
+		},
+		{
+			name:    "postgres column type",
+			config:  Config{Dialect: DialectPostgres},
+			changes: []func(*state.Table){widen},
+			want: []string{"-- datara: TYPE cannot run online on postgres; expect locking or a table rewrite\n" +
+				`ALTER TABLE "users" ALTER COLUMN "nickname" TYPE VARCHAR(200);`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := planUsers()
+			desired := current.Clone()
+			for _, change := range tt.changes {
+				change(&desired)
+			}
+			config := tt.config
+			config.Online = true
+			got, err := NewGenerator(&config).GenerateStatements(schemaOf(current), schemaOf(desired))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GenerateStatements() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNonTransactional(t *testing.T) {
+	tests := []struct {
+		statements []string
+		want       bool
+	}{
+		{[]string{`CREATE INDEX CONCURRENTLY "idx" ON "users" ("email");`}, true},
+		{[]string{`ALTER TABLE "users" ADD COLUMN "age" INT;`, `DROP INDEX CONCURRENTLY "idx";`}, true},
+		{[]string{"ALTER TABLE `users` ADD COLUMN `age` INT, ALGORITHM=INPLACE, LOCK=NONE;"}, false},
+	}
+	for _, tt := range tests {
+		if got := NonTransactional(tt.statements); got != tt.want {
+			t.Errorf("NonTransactional(%q) = %v, want %v", tt.statements, got, tt.want)
+		}
+	}
+}
+
+// mustServerVersion membaca server version untuk test
+func mustServerVersion(t *testing.T, s string) ServerVersion {
+	t.Helper()
+	version, err := ParseServerVersion(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
@@ -0,0 +1,90 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// onUpdateName adalah nama fungsi dan trigger Postgres yang meniru ON UPDATE
+// kolom tableName.columnName
+func onUpdateName(tableName, columnName string) string {
+	return fmt.Sprintf("datara_on_update_%s_%s", tableName, columnName)
+}
+
+// onUpdateStatements membuat pengganti ON UPDATE untuk dialect tanpa klausa
+// tersebut: fungsi dan trigger BEFORE UPDATE di Postgres, atau komentar
+// peringatan di SQL Server. MySQL merender ON UPDATE di definisi kolom.
+func (g *Generator) onUpdateStatements(tableName string, col state.Column) []string {
+	if col.OnUpdate == "" {
+		return nil
+	}
+	switch g.config.Dialect {
+	case DialectPostgres:
+		name := g.quote(onUpdateName(tableName, col.Name))
+		return []string{
+			g.createOnUpdateFunction(tableName, col),
+			fmt.Sprintf("CREATE TRIGGER %s BEFORE UPDATE ON %s FOR EACH ROW EXECUTE FUNCTION %s()",
+				name, g.quote(tableName), name),
+		}
+	case DialectMSSQL:
+		return []string{fmt.Sprintf("-- datara: %s.%s: mssql has no ON UPDATE; set %s in an AFTER UPDATE trigger or in the application",
+			tableName, col.Name, col.OnUpdate)}
+	}
+	return nil
+}
+
+// createOnUpdateFunction membuat fungsi trigger Postgres yang mengisi kolom
+// dengan ekspresi ON UPDATE. CREATE OR REPLACE juga dipakai saat ekspresinya berubah.
+func (g *Generator) createOnUpdateFunction(tableName string, col state.Column) string {
+	return fmt.Sprintf("CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$ BEGIN NEW.%s := %s; RETURN NEW; END; $$ LANGUAGE plpgsql",
+		g.quote(onUpdateName(tableName, col.Name)), g.quote(col.Name), col.OnUpdate)
+}
+
+// dropOnUpdate menghapus trigger dan fungsi ON UPDATE kolom di Postgres
+func (g *Generator) dropOnUpdate(tableName string, col state.Column) []string {
+	if col.OnUpdate == "" || g.config.Dialect != DialectPostgres {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", g.quote(onUpdateName(tableName, col.Name)), g.quote(tableName)),
+		g.dropOnUpdateFunction(tableName, col.Name),
+	}
+}
+
+// dropOnUpdateFunction menghapus fungsi trigger ON UPDATE kolom
+func (g *Generator) dropOnUpdateFunction(tableName, columnName string) string {
+	return fmt.Sprintf("DROP FUNCTION IF EXISTS %s()", g.quote(onUpdateName(tableName, columnName)))
+}
+
+// modifyOnUpdate menyesuaikan trigger ON UPDATE Postgres dengan kolom yang
+// berubah: dibuat, diganti ekspresinya, atau dihapus. CockroachDB mengubah
+// klausa ON UPDATE kolomnya langsung.
+func (g *Generator) modifyOnUpdate(tableName string, current, desired state.Column) []string {
+	switch {
+	case strings.EqualFold(current.OnUpdate, desired.OnUpdate):
+		return nil
+	case g.config.Dialect == DialectCockroach:
+		return g.cockroachOnUpdate(tableName, desired)
+	case current.OnUpdate == "":
+		return g.onUpdateStatements(tableName, desired)
+	case desired.OnUpdate == "":
+		return g.dropOnUpdate(tableName, current)
+	case g.config.Dialect == DialectPostgres:
+		return []string{g.createOnUpdateFunction(tableName, desired)}
+	}
+	return g.onUpdateStatements(tableName, desired)
+}
+
+// terminate menambahkan ';' pada statement, kecuali komentar dan statement
+// yang sudah diakhiri ';' (mis. blok backfill). Statement yang diawali
+// komentar peringatan (mis. dari applyOnline) tetap diberi ';'.
+func terminate(stmt string) string {
+	lines := strings.Split(stmt, "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if strings.HasPrefix(last, "--") || strings.HasSuffix(last, ";") {
+		return stmt
+	}
+	return stmt + ";"
+}
@@ -0,0 +1,149 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// defaultTableOptions mengembalikan engine, charset dan collation dari config
+// yang dipakai tabel tanpa Options
+func (g *Generator) defaultTableOptions() map[string]string {
+	defaults := make(map[string]string, 3)
+	for key, value := range map[string]string{
+		state.OptionEngine:  g.config.Engine,
+		state.OptionCharset: g.config.Charset,
+		state.OptionCollate: g.config.Collation,
+	} {
+		if value != "" {
+			defaults[key] = value
+		}
+	}
+	return defaults
+}
+
+// normalizeTableOptions menyeragamkan key Table.Options dan membuang opsi
+// yang sama dengan default config, sehingga footer ENGINE=InnoDB dari SQL
+// schema program sama dengan tabel tanpa Options
+func (g *Generator) normalizeTableOptions(options map[string]string) map[string]string {
+	options = state.NormalizeTableOptions(options)
+	for key, value := range g.defaultTableOptions() {
+		if strings.EqualFold(options[key], value) {
+			delete(options, key)
+		}
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return options
+}
+
+// effectiveTableOptions mengembalikan opsi tabel yang berlaku: default config
+// ditimpa Table.Options. Charset tanpa collation memakai collation bawaan
+// charset tersebut, sehingga collation config tidak ikut.
+func (g *Generator) effectiveTableOptions(table state.Table) map[string]string {
+	options := g.defaultTableOptions()
+	if _, ok := table.Options[state.OptionCharset]; ok {
+		if _, ok := table.Options[state.OptionCollate]; !ok {
+			delete(options, state.OptionCollate)
+		}
+	}
+	for key, value := range table.Options {
+		options[key] = value
+	}
+	return options
+}
+
+// tableOptionKeys mengembalikan key options sesuai urutan
+// state.TableOptionKeys, diikuti key lain secara alfabetis
+func tableOptionKeys(options map[string]string) []string {
+	var keys, other []string
+	for _, key := range state.TableOptionKeys {
+		if _, ok := options[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	for key := range options {
+		if !state.IsTableOptionKey(key) {
+			other = append(other, key)
+		}
+	}
+	sort.Strings(other)
+	return append(keys, other...)
+}
+
+// tableOptionClause merender satu opsi tabel, mis. ROW_FORMAT=COMPRESSED
+func tableOptionClause(key, value string) string {
+	if key == state.OptionCharset {
+		return "DEFAULT CHARSET=" + value
+	}
+	return key + "=" + value
+}
+
+// tableOptionsSQL merender opsi tabel setelah daftar kolom CREATE TABLE (MySQL)
+func (g *Generator) tableOptionsSQL(table state.Table) string {
+	options := g.effectiveTableOptions(table)
+	var b strings.Builder
+	for _, key := range tableOptionKeys(options) {
+		b.WriteString(" " + tableOptionClause(key, options[key]))
+	}
+	return b.String()
+}
+
+// resetTableOptions adalah nilai yang mengembalikan opsi ke bawaan server
+// saat opsi dihapus dari tabel yang sudah ada
+var resetTableOptions = map[string]string{
+	state.OptionRowFormat:    "DEFAULT",
+	state.OptionKeyBlockSize: "0",
+}
+
+// tableOptionChanges mengembalikan opsi tabel yang berubah sebagai klausa
+// ALTER TABLE, mis. ["ENGINE=MyISAM", "ROW_FORMAT=COMPRESSED"]. AUTO_INCREMENT
+// hanya berlaku saat tabel dibuat: nilainya di database terus berubah.
+func (g *Generator) tableOptionChanges(current, desired state.Table) []string {
+	from, to := g.effectiveTableOptions(current), g.effectiveTableOptions(desired)
+	var clauses []string
+	for _, key := range tableOptionKeys(to) {
+		if key != state.OptionAutoIncrement && !strings.EqualFold(from[key], to[key]) {
+			clauses = append(clauses, tableOptionClause(key, to[key]))
+		}
+	}
+	for _, key := range tableOptionKeys(from) {
+		if _, kept := to[key]; kept {
+			continue
+		}
+		if value, ok := resetTableOptions[key]; ok {
+			clauses = append(clauses, tableOptionClause(key, value))
+		}
+	}
+	return clauses
+}
+
+// tableOptionsDetail meringkas perubahan opsi tabel untuk Change.Detail, mis.
+// "ENGINE InnoDB→MyISAM"
+func (g *Generator) tableOptionsDetail(current, desired state.Table) string {
+	from := g.effectiveTableOptions(current)
+	var parts []string
+	for _, clause := range g.tableOptionChanges(current, desired) {
+		key, value, _ := strings.Cut(clause, "=")
+		key = strings.TrimPrefix(key, "DEFAULT ")
+		parts = append(parts, fmt.Sprintf("%s %s→%s", key, from[key], value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// validateTableOptions memperingatkan Options pada dialect selain MySQL,
+// yang tidak merendernya
+func (g *Generator) validateTableOptions(schema *state.SchemaState) {
+	if g.config.Dialect == DialectMySQL {
+		return
+	}
+	for _, table := range sortedTables(schema.Tables) {
+		if len(table.Options) > 0 {
+			g.warnings.Add("table-options", table.Name, "", "table options %s are ignored on %s",
+				strings.Join(tableOptionKeys(table.Options), ", "), g.config.Dialect)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package diff
+
+import (
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// tableConstraint adalah constraint beserta nama tabelnya
+type tableConstraint struct {
+	table      string
+	constraint state.Constraint
+}
+
+// dependencyOrder mengurutkan tabel sehingga tabel yang direferensikan foreign
+// key muncul sebelum tabel yang mereferensikannya. Selain itu urutan tables
+// dipertahankan; siklus foreign key dipecah dengan urutan tersebut.
+func dependencyOrder(tables []state.Table) []state.Table {
+	byName := make(map[string]state.Table, len(tables))
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+
+	result := make([]state.Table, 0, len(tables))
+	done := map[string]bool{}
+	visiting := map[string]bool{}
+	var visit func(table state.Table)
+	visit = func(table state.Table) {
+		if done[table.Name] || visiting[table.Name] {
+			return
+		}
+		visiting[table.Name] = true
+		for _, ref := range ReferencedTables(table) {
+			if dep, ok := byName[ref]; ok && ref != table.Name {
+				visit(dep)
+			}
+		}
+		visiting[table.Name] = false
+		done[table.Name] = true
+		result = append(result, table)
+	}
+	for _, table := range tables {
+		visit(table)
+	}
+	return result
+}
+
+// ReferencedTables mengembalikan tabel yang direferensikan foreign key tabel
+func ReferencedTables(table state.Table) []string {
+	var refs []string
+	for _, constraint := range table.Constraints {
+		if fk, ok := parseForeignKey(constraint.Def); ok {
+			refs = append(refs, fk.refTable)
+		}
+	}
+	return refs
+}
+
+// detachDroppedReferences mengeluarkan foreign key tabel yang tetap ada ke
+// tabel yang akan di-drop dari current, lalu mengembalikannya. Constraint ini
+// harus di-drop sebelum DROP TABLE, mis. di down migration yang membatalkan
+// tabel baru beserta kolom foreign key ke tabel tersebut.
+func detachDroppedReferences(current, desired *state.SchemaState) []tableConstraint {
+	var detached []tableConstraint
+	for _, table := range sortedTables(current.Tables) {
+		if _, exists := desired.Tables[table.Name]; !exists {
+			continue
+		}
+		kept := table.Constraints
+		for _, constraint := range table.Constraints {
+			fk, ok := parseForeignKey(constraint.Def)
+			if !ok {
+				continue
+			}
+			_, existed := current.Tables[fk.refTable]
+			if _, remains := desired.Tables[fk.refTable]; !existed || remains {
+				continue
+			}
+			detached = append(detached, tableConstraint{table: table.Name, constraint: constraint})
+			kept = withoutConstraint(kept, constraintKey(constraint))
+		}
+		table.Constraints = kept
+		current.Tables[table.Name] = table
+	}
+	return detached
+}
+
+// droppedTables mengembalikan tabel current yang tidak ada di desired dengan
+// urutan drop: tabel yang mereferensikan di-drop sebelum tabel yang direferensikan
+func droppedTables(current, desired *state.SchemaState) []state.Table {
+	var dropped []state.Table
+	for _, table := range sortedTables(current.Tables) {
+		if _, exists := desired.Tables[table.Name]; !exists {
+			dropped = append(dropped, table)
+		}
+	}
+	ordered := dependencyOrder(dropped)
+	for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	}
+	return ordered
+}
+
+// cyclicReferences mengembalikan foreign key antar tabel yang di-drop yang
+// tetap melanggar urutan dropped, yaitu siklus foreign key. Constraint ini
+// di-drop lebih dulu agar DROP TABLE tidak gagal. Dengan DropCascade,
+// CASCADE (Postgres) atau FOREIGN_KEY_CHECKS=0 (MySQL) sudah menanganinya.
+func (g *Generator) cyclicReferences(dropped []state.Table) []tableConstraint {
+	if g.config.DropCascade && g.config.Dialect != DialectMSSQL {
+		return nil
+	}
+	position := make(map[string]int, len(dropped))
+	for i, table := range dropped {
+		position[table.Name] = i
+	}
+	var cyclic []tableConstraint
+	for i, table := range dropped {
+		for _, constraint := range table.Constraints {
+			fk, ok := parseForeignKey(constraint.Def)
+			if !ok || fk.refTable == table.Name {
+				continue
+			}
+			if j, ok := position[fk.refTable]; ok && j < i {
+				cyclic = append(cyclic, tableConstraint{table: table.Name, constraint: constraint})
+			}
+		}
+	}
+	return cyclic
+}
+
+// generateDetach membuat statement yang men-drop foreign key dari detachDroppedReferences
+func (g *Generator) generateDetach(fk tableConstraint) string {
+	stmt := g.generateDropConstraint(fk.table, fk.constraint)
+	if g.config.Online {
+		stmt = g.applyOnline(g.quote(fk.table), []string{stmt}, stmt)
+	}
+	return stmt + ";"
+}
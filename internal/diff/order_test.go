@@ -0,0 +1,429 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// fkScenario adalah plan yang menambah tabel comments yang mereferensikan
+// kolom baru posts.slug, serta kolom posts.category_id yang mereferensikan
+// tabel baru categories
+func fkScenario() (current, desired *state.SchemaState) {
+	posts := state.Table{
+		Name: "posts",
+		Columns: map[string]state.Column{
+			"id":    {Name: "id", Type: "BIGINT", Position: 1},
+			"title": {Name: "title", Type: "VARCHAR(200)", Position: 2},
+		},
+		Constraints: []state.Constraint{{Name: "pk_posts", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"}},
+	}
+	current = schemaOf(posts)
+
+	grown := posts.Clone()
+	grown.Columns["slug"] = state.Column{Name: "slug", Type: "VARCHAR(100)", Nullable: true, Position: 3}
+	grown.Columns["category_id"] = state.Column{Name: "category_id", Type: "BIGINT", Nullable: true, Position: 4}
+	grown.Indexes = map[string]state.Index{"uni_posts_slug": {Name: "uni_posts_slug", Columns: []string{"slug"}, Unique: true}}
+	grown.Constraints = append(grown.Constraints, state.Constraint{
+		Name: "fk_posts_category_id", Type: "FOREIGN KEY",
+		Def: "CONSTRAINT fk_posts_category_id FOREIGN KEY (category_id) REFERENCES categories (id)",
+	})
+	categories := state.Table{
+		Name:        "categories",
+		Columns:     map[string]state.Column{"id": {Name: "id", Type: "BIGINT", Position: 1}},
+		Constraints: []state.Constraint{{Name: "pk_categories", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"}},
+	}
+	comments := state.Table{
+		Name: "comments",
+		Columns: map[string]state.Column{
+			"id":        {Name: "id", Type: "BIGINT", Position: 1},
+			"post_slug": {Name: "post_slug", Type: "VARCHAR(100)", Position: 2},
+		},
+		Constraints: []state.Constraint{
+			{Name: "pk_comments", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"},
+			{Name: "fk_comments_post_slug", Type: "FOREIGN KEY", Def: "CONSTRAINT fk_comments_post_slug FOREIGN KEY (post_slug) REFERENCES posts (slug)"},
+		},
+	}
+	desired = schemaOf(comments, grown, categories)
+	return current, desired
+}
+
+// sandbox menerapkan statement hasil generator ke model skema sederhana dan
+// menolak operasi yang juga ditolak database: drop tabel/kolom yang masih
+// direferensikan foreign key, atau drop index yang sudah tidak ada
+type sandbox struct {
+	tables  map[string]*sandboxTable
+	indexes map[string]sandboxIndex
+	// unchecked meniru SET FOREIGN_KEY_CHECKS=0 di MySQL
+	unchecked bool
+}
+
+type sandboxTable struct {
+	columns map[string]bool
+	fks     map[string]sandboxRef
+}
+
+type sandboxIndex struct {
+	table   string
+	columns []string
+}
+
+// sandboxRef adalah foreign key dari kolom column ke refTable.refColumn
+type sandboxRef struct {
+	column, refTable, refColumn string
+}
+
+var (
+	sbCreateTable = regexp.MustCompile(`(?s)^CREATE TABLE (?:IF NOT EXISTS )?(\w+) \((.*)\)$`)
+	sbCreateIndex = regexp.MustCompile(`^CREATE (?:UNIQUE )?INDEX (\w+) ON (\w+) \(([^)]*)\)$`)
+	sbDropIndex   = regexp.MustCompile(`^DROP INDEX (\w+)(?: ON \w+)?$`)
+	sbDropTable   = regexp.MustCompile(`^DROP TABLE (?:IF EXISTS )?(\w+)( CASCADE)?$`)
+	sbChecks      = regexp.MustCompile(`^SET FOREIGN_KEY_CHECKS=([01])$`)
+	sbAlterTable  = regexp.MustCompile(`(?s)^ALTER TABLE (\w+)\s+(.*)$`)
+	sbForeignKey  = regexp.MustCompile(`^(?:ADD )?CONSTRAINT (\w+) FOREIGN KEY \((\w+)\) REFERENCES (\w+) \((\w+)\)$`)
+	sbAddIndex    = regexp.MustCompile(`^ADD (?:UNIQUE )?INDEX (\w+) \(([^)]*)\)$`)
+	sbClause      = regexp.MustCompile(`^(ADD COLUMN|DROP COLUMN|DROP INDEX|DROP FOREIGN KEY|DROP CONSTRAINT) (\w+)`)
+)
+
+func newSandbox() *sandbox {
+	return &sandbox{tables: map[string]*sandboxTable{}, indexes: map[string]sandboxIndex{}}
+}
+
+// sandboxOf membuat sandbox berisi schema tanpa menjalankan statement, mis.
+// untuk tabel dengan siklus foreign key
+func sandboxOf(schema *state.SchemaState) *sandbox {
+	s := newSandbox()
+	for _, table := range schema.Tables {
+		t := &sandboxTable{columns: map[string]bool{}, fks: map[string]sandboxRef{}}
+		for name := range table.Columns {
+			t.columns[name] = true
+		}
+		for _, constraint := range table.Constraints {
+			if fk, ok := parseForeignKey(constraint.Def); ok {
+				t.fks[constraint.Name] = sandboxRef{column: fk.columns[0], refTable: fk.refTable, refColumn: fk.refColumns[0]}
+			}
+		}
+		s.tables[table.Name] = t
+		for _, idx := range table.Indexes {
+			s.indexes[idx.Name] = sandboxIndex{table: table.Name, columns: idx.Columns}
+		}
+	}
+	return s
+}
+
+// apply menerapkan satu statement hasil generator, yang bisa berisi beberapa
+// statement SQL, mis. DROP TABLE yang diapit SET FOREIGN_KEY_CHECKS
+func (s *sandbox) apply(stmt string) error {
+	for _, part := range strings.Split(strings.TrimSpace(stmt), ";\n") {
+		if err := s.applyOne(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sandbox) applyOne(stmt string) error {
+	stmt = strings.NewReplacer("`", "", `"`, "", "[", "", "]", "").Replace(strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+	switch {
+	case sbChecks.MatchString(stmt):
+		s.unchecked = sbChecks.FindStringSubmatch(stmt)[1] == "0"
+	case sbCreateTable.MatchString(stmt):
+		m := sbCreateTable.FindStringSubmatch(stmt)
+		if s.tables[m[1]] != nil {
+			return fmt.Errorf("table %s already exists", m[1])
+		}
+		table := &sandboxTable{columns: map[string]bool{}, fks: map[string]sandboxRef{}}
+		s.tables[m[1]] = table
+		for _, line := range strings.Split(m[2], "\n") {
+			line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+			switch {
+			case line == "" || strings.HasPrefix(line, "PRIMARY KEY"):
+			case sbForeignKey.MatchString(line):
+				if err := s.addForeignKey(m[1], sbForeignKey.FindStringSubmatch(line)); err != nil {
+					return err
+				}
+			default:
+				table.columns[strings.Fields(line)[0]] = true
+			}
+		}
+	case sbCreateIndex.MatchString(stmt):
+		m := sbCreateIndex.FindStringSubmatch(stmt)
+		return s.addIndex(m[2], m[1], m[3])
+	case sbDropIndex.MatchString(stmt):
+		return s.dropIndex(sbDropIndex.FindStringSubmatch(stmt)[1])
+	case sbDropTable.MatchString(stmt):
+		m := sbDropTable.FindStringSubmatch(stmt)
+		name, cascade := m[1], m[2] != ""
+		if s.tables[name] == nil {
+			return fmt.Errorf("table %s does not exist", name)
+		}
+		for other, table := range s.tables {
+			for fk, ref := range table.fks {
+				if ref.refTable != name || other == name {
+					continue
+				}
+				if !cascade && !s.unchecked {
+					return fmt.Errorf("cannot drop table %s: referenced by %s.%s", name, other, fk)
+				}
+				// CASCADE ikut menghapus foreign key yang mereferensikan tabel
+				if cascade {
+					delete(table.fks, fk)
+				}
+			}
+		}
+		delete(s.tables, name)
+		for idx, index := range s.indexes {
+			if index.table == name {
+				delete(s.indexes, idx)
+			}
+		}
+	case sbAlterTable.MatchString(stmt):
+		m := sbAlterTable.FindStringSubmatch(stmt)
+		if s.tables[m[1]] == nil {
+			return fmt.Errorf("table %s does not exist", m[1])
+		}
+		for _, clause := range strings.Split(m[2], ",\n") {
+			if err := s.alter(m[1], strings.TrimSpace(clause)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("sandbox does not understand %q", stmt)
+	}
+	return nil
+}
+
+func (s *sandbox) alter(tableName, clause string) error {
+	table := s.tables[tableName]
+	if m := sbForeignKey.FindStringSubmatch(clause); m != nil {
+		return s.addForeignKey(tableName, m)
+	}
+	if m := sbAddIndex.FindStringSubmatch(clause); m != nil {
+		return s.addIndex(tableName, m[1], m[2])
+	}
+	m := sbClause.FindStringSubmatch(clause)
+	if m == nil {
+		return fmt.Errorf("sandbox does not understand %q", clause)
+	}
+	switch name := m[2]; m[1] {
+	case "ADD COLUMN":
+		if table.columns[name] {
+			return fmt.Errorf("column %s.%s already exists", tableName, name)
+		}
+		table.columns[name] = true
+	case "DROP COLUMN":
+		if !table.columns[name] {
+			return fmt.Errorf("column %s.%s does not exist", tableName, name)
+		}
+		for other, t := range s.tables {
+			for fk, ref := range t.fks {
+				if (other == tableName && ref.column == name) || (ref.refTable == tableName && ref.refColumn == name) {
+					return fmt.Errorf("cannot drop column %s.%s: used by %s.%s", tableName, name, other, fk)
+				}
+			}
+		}
+		delete(table.columns, name)
+		// Seperti MySQL dan Postgres, index pada kolom itu ikut terhapus
+		for idx, index := range s.indexes {
+			for _, column := range index.columns {
+				if index.table == tableName && column == name {
+					delete(s.indexes, idx)
+				}
+			}
+		}
+	case "DROP INDEX":
+		return s.dropIndex(name)
+	default:
+		if _, exists := table.fks[name]; !exists {
+			return fmt.Errorf("foreign key %s.%s does not exist", tableName, name)
+		}
+		delete(table.fks, name)
+	}
+	return nil
+}
+
+func (s *sandbox) addForeignKey(tableName string, m []string) error {
+	table, ref := s.tables[tableName], s.tables[m[3]]
+	if !table.columns[m[2]] {
+		return fmt.Errorf("foreign key %s: column %s.%s does not exist", m[1], tableName, m[2])
+	}
+	if ref == nil || !ref.columns[m[4]] {
+		return fmt.Errorf("foreign key %s: referenced column %s.%s does not exist", m[1], m[3], m[4])
+	}
+	table.fks[m[1]] = sandboxRef{column: m[2], refTable: m[3], refColumn: m[4]}
+	return nil
+}
+
+func (s *sandbox) addIndex(tableName, name, columns string) error {
+	if _, exists := s.indexes[name]; exists {
+		return fmt.Errorf("index %s already exists", name)
+	}
+	index := sandboxIndex{table: tableName}
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.TrimSpace(column)
+		if table := s.tables[tableName]; table == nil || !table.columns[column] {
+			return fmt.Errorf("index %s: column %s.%s does not exist", name, tableName, column)
+		}
+		index.columns = append(index.columns, column)
+	}
+	s.indexes[name] = index
+	return nil
+}
+
+func (s *sandbox) dropIndex(name string) error {
+	if _, exists := s.indexes[name]; !exists {
+		return fmt.Errorf("index %s does not exist", name)
+	}
+	delete(s.indexes, name)
+	return nil
+}
+
+// TestDownMigrationAppliesCleanly menerapkan up lalu down dari fkScenario ke
+// sandbox; down harus menghapus foreign key sebelum tabel dan kolom yang
+// direferensikan, dan index sebelum kolomnya
+func TestDownMigrationAppliesCleanly(t *testing.T) {
+	configs := []struct {
+		name   string
+		config Config
+	}{
+		{"mysql", Config{Dialect: DialectMySQL}},
+		{"mysql batch", Config{Dialect: DialectMySQL, BatchAlter: true}},
+		{"postgres", Config{Dialect: DialectPostgres}},
+	}
+	for _, c := range configs {
+		t.Run(c.name, func(t *testing.T) {
+			g := NewGenerator(&c.config)
+			current, desired := fkScenario()
+			setup, err := g.GenerateStatements(state.NewSchemaState(), current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			up, err := g.GenerateStatements(current, desired)
+			if err != nil {
+				t.Fatal(err)
+			}
+			down, err := g.GenerateDownStatements(desired, current)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sb := newSandbox()
+			for _, phase := range []struct {
+				name       string
+				statements []string
+			}{{"setup", setup}, {"up", up}, {"down", down}} {
+				for _, stmt := range phase.statements {
+					if err := sb.apply(stmt); err != nil {
+						t.Fatalf("%s: %v\n%s", phase.name, err, strings.Join(phase.statements, "\n"))
+					}
+				}
+			}
+
+			if len(sb.tables) != 1 || sb.tables["posts"] == nil {
+				t.Fatalf("down left tables %v, want only posts", sb.tables)
+			}
+			if got := sb.tables["posts"].columns; !reflect.DeepEqual(got, map[string]bool{"id": true, "title": true}) {
+				t.Errorf("down left posts columns %v, want id and title", got)
+			}
+			if len(sb.indexes) != 0 {
+				t.Errorf("down left indexes %v", sb.indexes)
+			}
+		})
+	}
+}
+
+// chainTable membuat tabel dengan kolom <ref>_id yang mereferensikan id
+// setiap tabel refs
+func chainTable(name string, position int, refs ...string) state.Table {
+	table := state.Table{
+		Name:        name,
+		Position:    position,
+		Columns:     map[string]state.Column{"id": {Name: "id", Type: "BIGINT", Position: 1}},
+		Constraints: []state.Constraint{{Name: "pk_" + name, Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"}},
+	}
+	for i, ref := range refs {
+		column := ref + "_id"
+		table.Columns[column] = state.Column{Name: column, Type: "BIGINT", Nullable: true, Position: 2 + i}
+		fk := "fk_" + name + "_" + column
+		table.Constraints = append(table.Constraints, state.Constraint{
+			Name: fk, Type: "FOREIGN KEY",
+			Def: fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (id)", fk, column, ref),
+		})
+	}
+	return table
+}
+
+// TestDropOrderFollowsForeignKeys memakai rantai comments -> posts -> users
+// yang dideklarasikan terbalik dari urutan dependensinya, ditambah siklus
+// x <-> y, dengan dan tanpa drop_cascade
+func TestDropOrderFollowsForeignKeys(t *testing.T) {
+	chain := schemaOf(chainTable("comments", 1, "posts"), chainTable("posts", 2, "users"), chainTable("users", 3))
+	full := schemaOf(chainTable("comments", 1, "posts"), chainTable("posts", 2, "users"), chainTable("users", 3),
+		chainTable("x", 4, "y"), chainTable("y", 5, "x"))
+
+	tests := []struct {
+		name   string
+		config Config
+		want   string // ada di down jika tidak kosong
+		absent string // tidak boleh ada di down
+	}{
+		{"postgres", Config{Dialect: DialectPostgres}, "", "CASCADE"},
+		{"postgres cascade", Config{Dialect: DialectPostgres, DropCascade: true}, `DROP TABLE IF EXISTS "users" CASCADE`, "DROP CONSTRAINT"},
+		{"cockroach", Config{Dialect: DialectCockroach}, "", "CASCADE"},
+		{"mysql", Config{Dialect: DialectMySQL}, "", "FOREIGN_KEY_CHECKS"},
+		{"mysql cascade", Config{Dialect: DialectMySQL, DropCascade: true}, "SET FOREIGN_KEY_CHECKS=0;\nDROP TABLE `users`;\nSET FOREIGN_KEY_CHECKS=1;", "DROP FOREIGN KEY"},
+		{"mssql", Config{Dialect: DialectMSSQL}, "", "CASCADE"},
+		{"mssql cascade", Config{Dialect: DialectMSSQL, DropCascade: true}, "DROP CONSTRAINT [fk_y_x_id]", "CASCADE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator(&tt.config)
+
+			// Rantai tanpa siklus bisa dibuat dan di-drop lewat statement
+			sb := newSandbox()
+			up, err := g.GenerateStatements(state.NewSchemaState(), chain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			down, err := g.GenerateDownStatements(chain, state.NewSchemaState())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, stmt := range append(up, down...) {
+				if err := sb.apply(stmt); err != nil {
+					t.Fatalf("%v\nup:\n%s\ndown:\n%s", err, strings.Join(up, "\n"), strings.Join(down, "\n"))
+				}
+			}
+			if len(sb.tables) != 0 {
+				t.Errorf("chain down left tables %v", sb.tables)
+			}
+
+			// Siklus tidak bisa dibuat dengan CREATE TABLE saja, jadi sandbox
+			// diisi langsung dari schema
+			sb = sandboxOf(full)
+			down, err = g.GenerateDownStatements(full, state.NewSchemaState())
+			if err != nil {
+				t.Fatal(err)
+			}
+			sql := strings.Join(down, "\n")
+			for _, stmt := range down {
+				if err := sb.apply(stmt); err != nil {
+					t.Fatalf("%v\ndown:\n%s", err, sql)
+				}
+			}
+			if len(sb.tables) != 0 {
+				t.Errorf("down left tables %v", sb.tables)
+			}
+			if !strings.Contains(sql, tt.want) {
+				t.Errorf("down does not contain %q:\n%s", tt.want, sql)
+			}
+			if strings.Contains(sql, tt.absent) {
+				t.Errorf("down contains %q:\n%s", tt.absent, sql)
+			}
+		})
+	}
+}
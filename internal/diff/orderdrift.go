@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// OrderDrift adalah tabel yang urutan kolom fisiknya berbeda dari model.
+// Urutan kolom tidak dianggap perubahan schema, tetapi memengaruhi konsumen
+// SELECT * seperti ETL.
+type OrderDrift struct {
+	Table string `json:"table"`
+	// Physical adalah urutan kolom di database, hasil menjalankan ulang migration
+	Physical []string `json:"physical"`
+	// Model adalah urutan kolom di schema program
+	Model []string `json:"model"`
+}
+
+// OrderingDrift membandingkan urutan kolom physical dengan model untuk setiap
+// tabel yang ada di keduanya. Hanya kolom yang ada di keduanya yang
+// dibandingkan, sehingga kolom yang belum dibuat atau sudah di-drop tidak
+// dihitung sebagai drift.
+func (g *Generator) OrderingDrift(physical, model *state.SchemaState) []OrderDrift {
+	var drifts []OrderDrift
+	for _, table := range sortedTables(model.Tables) {
+		current, ok := physical.Tables[table.Name]
+		if !ok {
+			continue
+		}
+		table = g.applyTableTags(table)
+		var want []string
+		for _, col := range g.orderedColumns(table.Columns) {
+			if _, ok := current.Columns[col.Name]; ok {
+				want = append(want, col.Name)
+			}
+		}
+		var have []string
+		for _, name := range current.ColumnNames() {
+			if _, ok := table.Columns[name]; ok {
+				have = append(have, name)
+			}
+		}
+		if strings.Join(have, "\x00") != strings.Join(want, "\x00") {
+			drifts = append(drifts, OrderDrift{Table: table.Name, Physical: have, Model: want})
+		}
+	}
+	return drifts
+}
+
+// CanReorderColumns melaporkan apakah dialect bisa memindahkan kolom yang
+// sudah ada. Postgres dan SQL Server hanya bisa melaporkan drift.
+func (g *Generator) CanReorderColumns() bool {
+	return g.config.Dialect == DialectMySQL
+}
+
+// SyncOrder membuat rantai MODIFY COLUMN ... FIRST/AFTER (MySQL) yang
+// mengembalikan urutan kolom tabel drifts ke urutan model. Kolom ditulis
+// ulang dengan definisi dari model. Dialect lain tidak menghasilkan statement.
+func (g *Generator) SyncOrder(model *state.SchemaState, drifts []OrderDrift) []string {
+	if !g.CanReorderColumns() {
+		return nil
+	}
+	var statements []string
+	for _, drift := range drifts {
+		table := g.applyTableTags(model.Tables[drift.Table])
+		columns := g.orderedColumns(table.Columns)
+		tableName := g.quote(table.Name)
+
+		// Kolom di awal yang sudah pada tempatnya tidak perlu dipindahkan
+		start := 0
+		for start < len(columns) && start < len(drift.Physical) && columns[start].Name == drift.Physical[start] {
+			start++
+		}
+		var clauses []string
+		for i, col := range columns {
+			if i < start {
+				continue
+			}
+			position := "FIRST"
+			if i > 0 {
+				position = "AFTER " + g.quote(columns[i-1].Name)
+			}
+			clauses = append(clauses, fmt.Sprintf("MODIFY COLUMN %s %s %s", g.quote(col.Name), g.generateColumnDef(col), position))
+		}
+		if g.batchAlter() {
+			statements = append(statements, terminate(fmt.Sprintf("ALTER TABLE %s\n  %s", tableName, strings.Join(clauses, ",\n  "))))
+			continue
+		}
+		for _, clause := range clauses {
+			statements = append(statements, terminate(fmt.Sprintf("ALTER TABLE %s %s", tableName, clause)))
+		}
+	}
+	return statements
+}
@@ -0,0 +1,367 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// PlanVersion adalah versi format dokumen PlanDocument. Versi dinaikkan
+// setiap kali field dihapus atau artinya berubah; field baru tidak mengubah versi.
+const PlanVersion = 1
+
+// Jenis perubahan pada Change.Kind
+const (
+	ChangeCreateTable      = "create_table"
+	ChangeDropTable        = "drop_table"
+	ChangeAddColumn        = "add_column"
+	ChangeModifyColumn     = "modify_column"
+	ChangeDropColumn       = "drop_column"
+	ChangeAddIndex         = "add_index"
+	ChangeModifyIndex      = "modify_index"
+	ChangeDropIndex        = "drop_index"
+	ChangeAddConstraint    = "add_constraint"
+	ChangeModifyConstraint = "modify_constraint"
+	ChangeDropConstraint   = "drop_constraint"
+	ChangeRawDDL           = "raw_ddl"
+	ChangeTableOptions     = "table_options"
+	// ChangeIgnored adalah perbedaan kolom yang diabaikan oleh diff=... dan tidak punya SQL
+	ChangeIgnored = "ignored"
+)
+
+// PlanDocument adalah daftar perubahan schema dalam bentuk yang stabil untuk
+// diperiksa policy engine eksternal (mis. OPA)
+type PlanDocument struct {
+	Version int      `json:"version"`
+	Dialect string   `json:"dialect"`
+	Changes []Change `json:"changes"`
+	// Warnings adalah peringatan yang muncul saat perubahan dibuat
+	Warnings state.Warnings `json:"warnings,omitempty"`
+}
+
+// Change adalah satu perubahan schema beserta SQL yang dihasilkan untuknya.
+// Old dan New berisi definisi objek sebelum dan sesudah perubahan.
+type Change struct {
+	Kind       string `json:"kind"`
+	Table      string `json:"table"`
+	Column     string `json:"column,omitempty"`
+	Index      string `json:"index,omitempty"`
+	Constraint string `json:"constraint,omitempty"`
+	Old        string `json:"old,omitempty"`
+	New        string `json:"new,omitempty"`
+	// Detail adalah ringkasan singkat perubahan, mis. "type varchar(100)→varchar(255)"
+	Detail string `json:"detail,omitempty"`
+	// Length mengklasifikasikan perubahan tipe string: LengthWiden,
+	// LengthNarrow (juga Destructive) atau LengthRetype
+	Length string `json:"length,omitempty"`
+	// Destructive bernilai true untuk perubahan yang menghapus data
+	Destructive bool `json:"destructive"`
+	// Incomplete bernilai true jika SQL berisi placeholder TODO yang harus
+	// diisi sebelum migration dijalankan, mis. nilai backfill
+	Incomplete bool   `json:"incomplete,omitempty"`
+	SQL        string `json:"sql"`
+}
+
+// Plan membuat PlanDocument dari perubahan yang mengubah current menjadi desired
+func (g *Generator) Plan(current, desired *state.SchemaState) (*PlanDocument, error) {
+	changes, err := g.Changes(current, desired)
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []Change{}
+	}
+	return &PlanDocument{Version: PlanVersion, Dialect: g.config.Dialect, Changes: changes, Warnings: g.warnings}, nil
+}
+
+// Changes mengembalikan perubahan satu per satu dengan urutan yang sama seperti
+// GenerateStatements. SQL setiap perubahan dirender tanpa BatchAlter, sehingga
+// satu perubahan selalu punya statement sendiri.
+func (g *Generator) Changes(current, desired *state.SchemaState) ([]Change, error) {
+	g.warnings = nil
+	current = g.applyTags(current)
+	desired = g.applyTags(desired)
+	CarryExtras(current, desired)
+	if err := g.validate(current, desired); err != nil {
+		return nil, err
+	}
+	desired, ignored := g.applyDiffPolicy(current, desired)
+	g.warnIgnored(ignored)
+
+	config := *g.config
+	config.BatchAlter = false
+	single := &Generator{config: &config}
+
+	var changes []Change
+	for _, fk := range detachDroppedReferences(current, desired) {
+		changes = append(changes, Change{
+			Kind:       ChangeDropConstraint,
+			Table:      fk.table,
+			Constraint: constraintKey(fk.constraint),
+			Old:        single.formatConstraint(fk.constraint.Def),
+			SQL:        single.generateDetach(fk),
+		})
+	}
+
+	dropped := droppedTables(current, desired)
+	for _, fk := range single.cyclicReferences(dropped) {
+		changes = append(changes, Change{
+			Kind:       ChangeDropConstraint,
+			Table:      fk.table,
+			Constraint: constraintKey(fk.constraint),
+			Old:        single.formatConstraint(fk.constraint.Def),
+			SQL:        single.generateDetach(fk),
+		})
+	}
+	for _, table := range dropped {
+		changes = append(changes, Change{
+			Kind:        ChangeDropTable,
+			Table:       table.Name,
+			Destructive: true,
+			SQL:         single.generateDropTable(table),
+		})
+	}
+
+	for _, desiredTable := range dependencyOrder(sortedTables(desired.Tables)) {
+		currentTable, exists := current.Tables[desiredTable.Name]
+		if !exists {
+			stmt, err := single.generateCreateTable(desiredTable)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, Change{
+				Kind:   ChangeCreateTable,
+				Table:  desiredTable.Name,
+				New:    stmt,
+				Detail: tableDetail(desiredTable),
+				SQL:    strings.Join(appendRaw([]string{stmt}, rawUp(desiredTable)), "\n\n"),
+			})
+			continue
+		}
+		tableChanges, err := single.tableChanges(currentTable, desiredTable)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, tableChanges...)
+		if !currentTable.RawDDL.Equal(desiredTable.RawDDL) {
+			changes = append(changes, Change{
+				Kind:  ChangeRawDDL,
+				Table: desiredTable.Name,
+				Old:   rawUp(currentTable),
+				New:   rawUp(desiredTable),
+				SQL:   strings.Join(appendRaw(appendRaw(nil, rawDown(currentTable)), rawUp(desiredTable)), "\n\n"),
+			})
+		}
+	}
+	// Perbedaan yang diabaikan tetap dilaporkan, tanpa SQL
+	for _, d := range ignored {
+		changes = append(changes, Change{Kind: ChangeIgnored, Table: d.table, Column: d.column, Detail: d.detail})
+	}
+	return changes, nil
+}
+
+// tableChanges mengembalikan perubahan kolom, index dan constraint satu tabel.
+// SQL setiap perubahan dibuat dengan generateAlterTable terhadap salinan tabel
+// current yang hanya berisi perubahan tersebut.
+func (g *Generator) tableChanges(current, desired state.Table) ([]Change, error) {
+	var changes []Change
+	add := func(change Change, apply func(t *state.Table)) error {
+		modified := current.Clone()
+		apply(&modified)
+		stmts, err := g.generateAlterTable(current, modified)
+		if err != nil {
+			return err
+		}
+		change.Table = desired.Name
+		change.SQL = strings.Join(stmts, "\n\n")
+		changes = append(changes, change)
+		return nil
+	}
+
+	if g.config.Dialect == DialectMySQL && len(g.tableOptionChanges(current, desired)) > 0 {
+		change := Change{
+			Kind:   ChangeTableOptions,
+			Old:    strings.TrimSpace(g.tableOptionsSQL(current)),
+			New:    strings.TrimSpace(g.tableOptionsSQL(desired)),
+			Detail: g.tableOptionsDetail(current, desired),
+		}
+		if err := add(change, func(t *state.Table) { t.Options = desired.Options }); err != nil {
+			return nil, err
+		}
+	}
+
+	currentConstraints := constraintsByName(current.Constraints)
+	desiredConstraints := constraintsByName(desired.Constraints)
+	for _, constraint := range current.Constraints {
+		key := constraintKey(constraint)
+		if _, exists := desiredConstraints[key]; exists {
+			continue
+		}
+		err := add(Change{Kind: ChangeDropConstraint, Constraint: key, Old: g.formatConstraint(constraint.Def)},
+			func(t *state.Table) { t.Constraints = withoutConstraint(t.Constraints, key) })
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, desiredCol := range sortedColumns(desired.Columns) {
+		col := desiredCol
+		currentCol, exists := current.Columns[col.Name]
+		change := Change{Kind: ChangeAddColumn, Column: col.Name, New: g.columnDef(desired.Name, col)}
+		if exists {
+			if columnsEqual(currentCol, col) {
+				continue
+			}
+			change.Kind, change.Old = ChangeModifyColumn, g.columnDef(desired.Name, currentCol)
+			change.Detail = g.columnDetail(currentCol, col)
+			change.Length = lengthChange(currentCol.Type, col.Type)
+			change.Destructive = change.Length == LengthNarrow
+		} else {
+			change.Incomplete = g.needsBackfillTodo(col)
+		}
+		if err := add(change, func(t *state.Table) { t.Columns[col.Name] = col }); err != nil {
+			return nil, err
+		}
+	}
+
+	// Index dihapus sebelum kolomnya, sama dengan urutan generateAlterTable
+	for _, currentIdx := range sortedIndexes(current.Indexes) {
+		name := currentIdx.Name
+		if _, exists := desired.Indexes[name]; exists {
+			continue
+		}
+		old, err := g.generateCreateIndex(current, currentIdx)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(Change{Kind: ChangeDropIndex, Index: name, Old: old},
+			func(t *state.Table) { delete(t.Indexes, name) }); err != nil {
+			return nil, err
+		}
+	}
+	for _, currentCol := range sortedColumns(current.Columns) {
+		name := currentCol.Name
+		if _, exists := desired.Columns[name]; exists {
+			continue
+		}
+		err := add(Change{Kind: ChangeDropColumn, Column: name, Old: g.columnDef(desired.Name, currentCol), Destructive: true},
+			func(t *state.Table) { delete(t.Columns, name) })
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, desiredIdx := range sortedIndexes(desired.Indexes) {
+		idx := desiredIdx
+		def, err := g.generateCreateIndex(desired, idx)
+		if err != nil {
+			return nil, err
+		}
+		change := Change{Kind: ChangeAddIndex, Index: idx.Name, New: def}
+		if currentIdx, exists := current.Indexes[idx.Name]; exists {
+			if indexesEqual(currentIdx, idx) {
+				continue
+			}
+			if change.Old, err = g.generateCreateIndex(current, currentIdx); err != nil {
+				return nil, err
+			}
+			change.Kind = ChangeModifyIndex
+		}
+		if err := add(change, func(t *state.Table) { t.Indexes[idx.Name] = idx }); err != nil {
+			return nil, err
+		}
+	}
+	for _, desiredConstraint := range desired.Constraints {
+		constraint := desiredConstraint
+		key := constraintKey(constraint)
+		change := Change{Kind: ChangeAddConstraint, Constraint: key, New: g.formatConstraint(constraint.Def)}
+		if currentConstraint, exists := currentConstraints[key]; exists {
+			if g.constraintsEqual(currentConstraint, constraint) {
+				continue
+			}
+			change.Kind, change.Old = ChangeModifyConstraint, g.formatConstraint(currentConstraint.Def)
+		}
+		err := add(change, func(t *state.Table) {
+			t.Constraints = append(withoutConstraint(t.Constraints, key), constraint)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// columnDetail meringkas atribut kolom yang berubah
+func (g *Generator) columnDetail(current, desired state.Column) string {
+	var parts []string
+	if current.Type != desired.Type || current.SRID != desired.SRID {
+		part := fmt.Sprintf("type %s→%s", g.columnType(current), g.columnType(desired))
+		if note := lengthNote(lengthChange(current.Type, desired.Type)); note != "" {
+			part += " (" + note + ")"
+		}
+		parts = append(parts, part)
+	}
+	if current.Nullable != desired.Nullable {
+		parts = append(parts, map[bool]string{true: "null", false: "not null"}[desired.Nullable])
+	}
+	if !current.DefaultValue.Equal(desired.DefaultValue) {
+		parts = append(parts, fmt.Sprintf("default %s→%s", g.describeDefault(current), g.describeDefault(desired)))
+	}
+	if !strings.EqualFold(current.OnUpdate, desired.OnUpdate) {
+		parts = append(parts, fmt.Sprintf("on update %s→%s", orNone(current.OnUpdate), orNone(desired.OnUpdate)))
+	}
+	if current.AutoIncrement != desired.AutoIncrement || !identityEqual(current, desired) {
+		parts = append(parts, "auto increment")
+	}
+	if !collationEqual(current, desired) {
+		parts = append(parts, "collation")
+	}
+	if current.Class != desired.Class {
+		parts = append(parts, fmt.Sprintf("class %s→%s", orNone(current.Class), orNone(desired.Class)))
+	} else if columnComment(current) != columnComment(desired) {
+		parts = append(parts, "comment")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// orNone merender atribut kolom untuk ringkasan, "none" jika kosong
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+// describeDefault merender default kolom untuk ringkasan, "none" jika kosong
+func (g *Generator) describeDefault(col state.Column) string {
+	if col.DefaultValue == nil {
+		return "none"
+	}
+	return g.defaultSQL(col)
+}
+
+// tableDetail meringkas isi tabel baru
+func tableDetail(table state.Table) string {
+	detail := fmt.Sprintf("%d %s", len(table.Columns), plural(len(table.Columns), "column"))
+	if len(table.Indexes) > 0 {
+		detail += fmt.Sprintf(", %d %s", len(table.Indexes), plural(len(table.Indexes), "index"))
+	}
+	return detail
+}
+
+// columnDef merender definisi lengkap kolom, termasuk namanya
+func (g *Generator) columnDef(tableName string, col state.Column) string {
+	return g.quote(col.Name) + " " + g.tableColumnDef(tableName, col)
+}
+
+// withoutConstraint mengembalikan constraints tanpa constraint dengan key tersebut
+func withoutConstraint(constraints []state.Constraint, key string) []state.Constraint {
+	var kept []state.Constraint
+	for _, c := range constraints {
+		if constraintKey(c) != key {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,149 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// update menulis ulang file golden di testdata/plan: go test ./internal/diff -run Plan -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// assertGolden membandingkan got dengan file golden, atau menulis ulang file
+// tersebut dengan -update. hint ditampilkan saat isinya berbeda.
+func assertGolden(t *testing.T, golden string, got []byte, hint string) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v; run go test ./internal/diff -update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s; %s:\n%s", golden, hint, got)
+	}
+}
+
+// planUsers adalah tabel users sebelum perubahan
+func planUsers() state.Table {
+	return state.Table{
+		Name: "users",
+		Columns: map[string]state.Column{
+			"id":       {Name: "id", Type: "BIGINT", AutoIncrement: true, Position: 1},
+			"email":    {Name: "email", Type: "VARCHAR(255)", Position: 2},
+			"nickname": {Name: "nickname", Type: "VARCHAR(100)", Nullable: true, Position: 3},
+			"legacy":   {Name: "legacy", Type: "TEXT", Nullable: true, Position: 4},
+		},
+		Indexes: map[string]state.Index{
+			"idx_users_nickname": {Name: "idx_users_nickname", Columns: []string{"nickname"}},
+		},
+		Constraints: []state.Constraint{{Name: "pk_users", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"}},
+	}
+}
+
+// planScenarios adalah pasangan current dan desired untuk golden test plan
+func planScenarios() []struct {
+	name             string
+	current, desired *state.SchemaState
+} {
+	users := planUsers()
+
+	altered := planUsers().Clone()
+	delete(altered.Columns, "legacy")
+	nickname := altered.Columns["nickname"]
+	nickname.Type = "VARCHAR(50)"
+	altered.Columns["nickname"] = nickname
+	altered.Columns["name"] = state.Column{Name: "name", Type: "VARCHAR(100)", DefaultValue: &state.DefaultValue{Kind: state.DefaultString, Value: ""}, Position: 5}
+	delete(altered.Indexes, "idx_users_nickname")
+	altered.Indexes["uni_users_email"] = state.Index{Name: "uni_users_email", Columns: []string{"email"}, Unique: true}
+
+	posts := state.Table{
+		Name: "posts",
+		Columns: map[string]state.Column{
+			"id":      {Name: "id", Type: "BIGINT", AutoIncrement: true, Position: 1},
+			"user_id": {Name: "user_id", Type: "BIGINT", Position: 2},
+			"title":   {Name: "title", Type: "VARCHAR(200)", Position: 3},
+		},
+		Indexes: map[string]state.Index{
+			"idx_posts_user_id": {Name: "idx_posts_user_id", Columns: []string{"user_id"}},
+		},
+		Constraints: []state.Constraint{
+			{Name: "pk_posts", Type: "PRIMARY KEY", Def: "PRIMARY KEY (id)"},
+			{Name: "fk_posts_user_id", Type: "FOREIGN KEY", Def: "FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE"},
+		},
+	}
+
+	return []struct {
+		name             string
+		current, desired *state.SchemaState
+	}{
+		{"create", state.NewSchemaState(), schemaOf(users, posts)},
+		{"alter", schemaOf(users), schemaOf(altered)},
+		{"drop", schemaOf(users, posts), schemaOf(users)},
+	}
+}
+
+func TestPlanGolden(t *testing.T) {
+	for _, dialect := range []string{DialectMySQL, DialectPostgres} {
+		for _, scenario := range planScenarios() {
+			name := scenario.name + "_" + dialect
+			t.Run(name, func(t *testing.T) {
+				plan, err := NewGenerator(&Config{Dialect: dialect}).Plan(scenario.current, scenario.desired)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := json.MarshalIndent(plan, "", "  ")
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, '\n')
+
+				assertGolden(t, filepath.Join("testdata", "plan", name+".json"), got,
+					"if the change is intended, bump PlanVersion for removed or changed fields and run with -update")
+			})
+		}
+	}
+}
+
+// TestPlanMatchesStatements memastikan urutan Changes sama dengan urutan
+// GenerateStatements, agar policy yang membaca plan melihat SQL yang sama
+// dengan migration yang ditulis
+func TestPlanMatchesStatements(t *testing.T) {
+	for _, dialect := range []string{DialectMySQL, DialectPostgres} {
+		for _, scenario := range planScenarios() {
+			t.Run(scenario.name+"_"+dialect, func(t *testing.T) {
+				g := NewGenerator(&Config{Dialect: dialect})
+				changes, err := g.Changes(scenario.current, scenario.desired)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var fromChanges []string
+				for _, change := range changes {
+					if change.SQL != "" {
+						fromChanges = append(fromChanges, change.SQL)
+					}
+				}
+				statements, err := g.GenerateStatements(scenario.current, scenario.desired)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, want := strings.Join(fromChanges, "\n\n"), strings.Join(statements, "\n\n")
+				if got != want {
+					t.Errorf("Changes SQL drifted from GenerateStatements:\n--- changes\n%s\n--- statements\n%s", got, want)
+				}
+			})
+		}
+	}
+}
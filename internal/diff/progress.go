@@ -0,0 +1,30 @@
+package diff
+
+// Progress menerima laporan kemajuan operasi yang lama, mis. diff ratusan
+// tabel. current bernilai 0 saat tahap dimulai dan sama dengan total saat
+// tahap selesai.
+type Progress interface {
+	OnStage(name string, current, total int)
+}
+
+// Tahap yang dilaporkan ke Progress, sesuai urutan generate
+const (
+	StageProgram  = "schema program"
+	StageParse    = "parsing"
+	StageDiff     = "diffing"
+	StageWrite    = "writing files"
+	StageChecksum = "checksum update"
+)
+
+// SetProgress memasang Progress untuk tahap StageDiff, dilaporkan per tabel
+// oleh GenerateStatements; nil menonaktifkannya
+func (g *Generator) SetProgress(progress Progress) {
+	g.progress = progress
+}
+
+// stage melaporkan kemajuan ke Progress jika terpasang
+func (g *Generator) stage(name string, current, total int) {
+	if g.progress != nil {
+		g.progress.OnStage(name, current, total)
+	}
+}
@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// recordingProgress mencatat setiap laporan sebagai "tahap current/total"
+type recordingProgress []string
+
+func (p *recordingProgress) OnStage(name string, current, total int) {
+	*p = append(*p, fmt.Sprintf("%s %d/%d", name, current, total))
+}
+
+func TestGenerateStatementsProgress(t *testing.T) {
+	table := func(name string) state.Table {
+		return state.Table{Name: name, Columns: map[string]state.Column{
+			"id": {Name: "id", Type: "INT", Position: 1},
+		}}
+	}
+	current := schemaOf(table("accounts"))
+	desired := schemaOf(table("accounts"), table("orders"), table("users"))
+
+	var progress recordingProgress
+	g := NewGenerator(&Config{Dialect: DialectPostgres})
+	g.SetProgress(&progress)
+	if _, err := g.GenerateStatements(current, desired); err != nil {
+		t.Fatal(err)
+	}
+	want := recordingProgress{"diffing 0/3", "diffing 1/3", "diffing 2/3", "diffing 3/3"}
+	if !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress = %q, want %q", progress, want)
+	}
+
+	// Progress nil menonaktifkan laporan
+	progress = nil
+	g.SetProgress(nil)
+	if _, err := g.GenerateStatements(current, desired); err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 0 {
+		t.Errorf("progress after SetProgress(nil) = %q, want none", progress)
+	}
+}
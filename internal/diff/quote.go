@@ -0,0 +1,220 @@
+package diff
+
+import (
+	"strings"
+)
+
+// quoteIdent meng-quote identifier sesuai dialect. Semua identifier di SQL
+// yang dihasilkan generator harus melewati fungsi ini, sehingga nama kolom
+// seperti order atau group tetap valid. Karakter quote di dalam nama digandakan.
+func quoteIdent(dialect, name string) string {
+	switch dialect {
+	case DialectPostgres, DialectCockroach:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	case DialectMSSQL:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// quoteString membuat string literal SQL dengan single quote yang di-escape
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// formatConstraint merender ulang definisi table constraint dengan identifier
+// yang di-quote sesuai dialect. Definisi dari snapshot bisa ber-quote ("id"),
+// memakai backtick, atau tanpa quote sama sekali; semuanya menghasilkan output
+// yang sama. Ekspresi CHECK dan EXCLUDE dibiarkan apa adanya.
+func (g *Generator) formatConstraint(def string) string {
+	rest := strings.TrimSpace(def)
+	var b strings.Builder
+
+	if hasKeyword(rest, "CONSTRAINT") {
+		var name string
+		name, rest = nextIdent(rest[len("CONSTRAINT"):])
+		if name == "" {
+			return def
+		}
+		b.WriteString("CONSTRAINT " + g.quote(name) + " ")
+		rest = strings.TrimSpace(rest)
+	}
+
+	foreign := false
+	switch {
+	case hasKeyword(rest, "PRIMARY KEY"):
+		b.WriteString("PRIMARY KEY ")
+		rest = rest[len("PRIMARY KEY"):]
+	case hasKeyword(rest, "FOREIGN KEY"):
+		b.WriteString("FOREIGN KEY ")
+		rest = rest[len("FOREIGN KEY"):]
+		foreign = true
+	case hasKeyword(rest, "UNIQUE"):
+		b.WriteString("UNIQUE ")
+		rest = strings.TrimSpace(rest[len("UNIQUE"):])
+		// MySQL: UNIQUE [KEY|INDEX] [nama] (kolom)
+		for _, keyword := range []string{"KEY", "INDEX"} {
+			if hasKeyword(rest, keyword) {
+				b.WriteString(keyword + " ")
+				rest = strings.TrimSpace(rest[len(keyword):])
+				break
+			}
+		}
+		if rest != "" && rest[0] != '(' {
+			var name string
+			name, rest = nextIdent(rest)
+			b.WriteString(g.quote(name) + " ")
+		}
+	default:
+		b.WriteString(rest)
+		return b.String()
+	}
+
+	columns, rest, ok := g.identList(rest)
+	if !ok {
+		return def
+	}
+	b.WriteString(columns)
+
+	rest = strings.TrimSpace(rest)
+	if foreign && hasKeyword(rest, "REFERENCES") {
+		table, after := nextIdent(rest[len("REFERENCES"):])
+		b.WriteString(" REFERENCES " + g.quote(table))
+		if refColumns, remaining, ok := g.identList(after); ok {
+			b.WriteString(" " + refColumns)
+			after = remaining
+		}
+		rest = g.canonicalActions(after)
+	}
+	if rest != "" {
+		b.WriteString(" " + rest)
+	}
+	return b.String()
+}
+
+// identList merender daftar kolom dalam tanda kurung di awal s dengan quote
+// dialect, mempertahankan prefix length dan urutan (mis. `bio`(191) DESC)
+func (g *Generator) identList(s string) (string, string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") {
+		return "", s, false
+	}
+	end := matchingParen(s)
+	if end == -1 {
+		return "", s, false
+	}
+
+	var columns []string
+	for _, part := range splitOutsideParens(s[1:end]) {
+		name, suffix := nextIdent(part)
+		if name == "" {
+			continue
+		}
+		column := g.quote(name)
+		if suffix = strings.TrimSpace(suffix); suffix != "" {
+			if suffix[0] != '(' {
+				column += " "
+			}
+			column += suffix
+		}
+		columns = append(columns, column)
+	}
+	return "(" + strings.Join(columns, ", ") + ")", s[end+1:], true
+}
+
+// nextIdent membaca satu identifier (ber-quote atau tidak, dengan schema
+// prefix opsional) di awal s dan mengembalikan nama tanpa quote beserta sisanya
+func nextIdent(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	var name string
+	for {
+		if s == "" {
+			return name, s
+		}
+		var part string
+		closer := byte(0)
+		switch s[0] {
+		case '"', '`':
+			closer = s[0]
+		case '[':
+			closer = ']'
+		}
+		if closer != 0 {
+			i := 1
+			var b strings.Builder
+			for ; i < len(s); i++ {
+				if s[i] == closer {
+					// Quote yang digandakan adalah bagian dari nama
+					if i+1 < len(s) && s[i+1] == closer && closer != ']' {
+						b.WriteByte(closer)
+						i++
+						continue
+					}
+					break
+				}
+				b.WriteByte(s[i])
+			}
+			part, s = b.String(), s[min(i+1, len(s)):]
+		} else {
+			end := strings.IndexAny(s, " (),.")
+			if end == -1 {
+				end = len(s)
+			}
+			part, s = s[:end], s[end:]
+		}
+		name = part
+
+		// Schema prefix ("public"."users") dibuang, sama seperti parser
+		if strings.HasPrefix(s, ".") {
+			s = s[1:]
+			continue
+		}
+		return name, s
+	}
+}
+
+// hasKeyword mengecek apakah s diawali keyword (tanpa membedakan huruf besar)
+// yang diikuti spasi, tanda kurung atau akhir string
+func hasKeyword(s, keyword string) bool {
+	if len(s) < len(keyword) || !strings.EqualFold(s[:len(keyword)], keyword) {
+		return false
+	}
+	return len(s) == len(keyword) || strings.ContainsRune(" (", rune(s[len(keyword)]))
+}
+
+// matchingParen mengembalikan posisi ')' yang menutup '(' di awal string
+func matchingParen(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitOutsideParens memisahkan s dengan koma di luar tanda kurung
+func splitOutsideParens(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,109 @@
+package diff
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// keywordTables adalah tabel order dan group yang nama tabel, kolom, index
+// dan constraint-nya adalah keyword SQL
+func keywordTables() (state.Table, state.Table) {
+	group := state.Table{
+		Name: "group",
+		Columns: map[string]state.Column{
+			"key": {Name: "key", Type: "INT", Position: 1},
+		},
+		Constraints: []state.Constraint{{Name: "pk_group", Type: "PRIMARY KEY", Def: "PRIMARY KEY (key)"}},
+	}
+	order := state.Table{
+		Name: "order",
+		Columns: map[string]state.Column{
+			"select": {Name: "select", Type: "INT", Position: 1},
+			"group":  {Name: "group", Type: "INT", Nullable: true, Position: 2},
+			"desc":   {Name: "desc", Type: "VARCHAR(50)", Nullable: true, Position: 3},
+		},
+		Indexes: map[string]state.Index{
+			"index": {Name: "index", Columns: []string{"desc", "group"}},
+		},
+		Constraints: []state.Constraint{
+			{Name: "pk_order", Type: "PRIMARY KEY", Def: "PRIMARY KEY (select)"},
+			{Name: "fk_order_group", Type: "FOREIGN KEY", Def: "CONSTRAINT fk_order_group FOREIGN KEY (group) REFERENCES group (key)"},
+		},
+	}
+	return group, order
+}
+
+// quotedIdent menangkap identifier ber-quote dan string literal
+var quotedIdent = regexp.MustCompile("`[^`]*`|\"[^\"]*\"|\\[[^\\]]*\\]|'[^']*'")
+
+// bareKeyword menangkap keyword yang dipakai sebagai identifier tanpa quote
+var bareKeyword = regexp.MustCompile(`(?i)\b(order|group|select|key|desc|index)\b`)
+
+func TestReservedIdentifiersQuoted(t *testing.T) {
+	group, order := keywordTables()
+
+	altered := order.Clone()
+	delete(altered.Columns, "desc")
+	c := altered.Columns["group"]
+	c.Nullable = false
+	c.DefaultValue = &state.DefaultValue{Kind: state.DefaultNumber, Value: "0"}
+	altered.Columns["group"] = c
+	altered.Columns["from"] = state.Column{Name: "from", Type: "INT", Nullable: true, Position: 4}
+	altered.Indexes = map[string]state.Index{"index": {Name: "index", Columns: []string{"from", "group"}, Unique: true}}
+
+	scenarios := []struct {
+		name             string
+		current, desired *state.SchemaState
+	}{
+		{"create", state.NewSchemaState(), schemaOf(group, order)},
+		{"alter", schemaOf(group, order), schemaOf(group, altered)},
+		{"drop", schemaOf(group, order), schemaOf(group)},
+	}
+	for _, dialect := range []string{DialectMySQL, DialectPostgres, DialectMSSQL, DialectCockroach} {
+		for _, scenario := range scenarios {
+			t.Run(dialect+" "+scenario.name, func(t *testing.T) {
+				g := NewGenerator(&Config{Dialect: dialect})
+				up, err := g.GenerateStatements(scenario.current, scenario.desired)
+				if err != nil {
+					t.Fatal(err)
+				}
+				down, err := g.GenerateDownStatements(scenario.desired, scenario.current)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, stmt := range append(up, down...) {
+					// Kata kunci SQL yang benar (ORDER BY, GROUP BY, INDEX)
+					// ditulis huruf besar oleh generator
+					for _, word := range bareKeyword.FindAllString(quotedIdent.ReplaceAllString(stmt, ""), -1) {
+						if word != strings.ToUpper(word) {
+							t.Errorf("identifier %s is not quoted in:\n%s", word, stmt)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestFormatConstraintQuoting(t *testing.T) {
+	defs := []string{
+		"CONSTRAINT fk_order_group FOREIGN KEY (group) REFERENCES group (key)",
+		"CONSTRAINT `fk_order_group` FOREIGN KEY (`group`) REFERENCES `group` (`key`)",
+		`CONSTRAINT "fk_order_group" FOREIGN KEY ("group") REFERENCES "group" ("key")`,
+	}
+	want := map[string]string{
+		DialectMySQL:    "CONSTRAINT `fk_order_group` FOREIGN KEY (`group`) REFERENCES `group` (`key`)",
+		DialectPostgres: `CONSTRAINT "fk_order_group" FOREIGN KEY ("group") REFERENCES "group" ("key")`,
+	}
+	for dialect, w := range want {
+		g := NewGenerator(&Config{Dialect: dialect})
+		for _, def := range defs {
+			if got := g.formatConstraint(def); got != w {
+				t.Errorf("%s formatConstraint(%s) = %s, want %s", dialect, def, got, w)
+			}
+		}
+	}
+}
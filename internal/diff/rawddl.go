@@ -0,0 +1,45 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// rawUp mengembalikan bagian up raw DDL tabel yang siap ditulis, atau string
+// kosong jika tidak ada
+func rawUp(table state.Table) string {
+	if table.RawDDL == nil {
+		return ""
+	}
+	return rawStatement(table.RawDDL.Up)
+}
+
+// rawDown mengembalikan bagian down raw DDL tabel yang siap ditulis
+func rawDown(table state.Table) string {
+	if table.RawDDL == nil {
+		return ""
+	}
+	return rawStatement(table.RawDDL.Down)
+}
+
+// rawStatement merapikan SQL mentah dan memastikan diakhiri ';', kecuali
+// komentar satu baris
+func rawStatement(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if strings.HasPrefix(sql, "--") && !strings.Contains(sql, "\n") {
+		return sql
+	}
+	if sql != "" && !strings.HasSuffix(sql, ";") {
+		sql += ";"
+	}
+	return sql
+}
+
+// appendRaw menambahkan SQL mentah ke statements jika tidak kosong
+func appendRaw(statements []string, sql string) []string {
+	if sql == "" {
+		return statements
+	}
+	return append(statements, sql)
+}
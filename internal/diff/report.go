@@ -0,0 +1,180 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultReportLimit adalah jumlah nama per kelompok perubahan yang
+// ditampilkan Report sebelum sisanya diringkas menjadi "+N more"
+const DefaultReportLimit = 5
+
+// ReportOptions mengatur tampilan Report
+type ReportOptions struct {
+	// Markdown merender ringkasan sebagai tabel Markdown, mis. untuk deskripsi PR
+	Markdown bool
+	// Limit adalah jumlah nama per kelompok; 0 menampilkan semuanya
+	Limit int
+}
+
+// reportGroup adalah satu kelompok perubahan di Report, mis. "+2 columns"
+type reportGroup struct {
+	sign  string
+	noun  string
+	items []string
+}
+
+// reportKinds menentukan urutan kelompok per tabel beserta tanda dan kata bendanya
+var reportKinds = []struct {
+	kind string
+	sign string
+	noun string
+}{
+	{ChangeAddColumn, "+", "column"},
+	{ChangeModifyColumn, "~", "column"},
+	{ChangeDropColumn, "-", "column"},
+	{ChangeAddIndex, "+", "index"},
+	{ChangeModifyIndex, "~", "index"},
+	{ChangeDropIndex, "-", "index"},
+	{ChangeAddConstraint, "+", "constraint"},
+	{ChangeModifyConstraint, "~", "constraint"},
+	{ChangeDropConstraint, "-", "constraint"},
+	{ChangeTableOptions, "~", "table option"},
+	{ChangeRawDDL, "~", "raw DDL block"},
+	{ChangeIgnored, "!", "ignored difference"},
+}
+
+// Report merender ringkasan perubahan per tabel, mis.
+//
+//	users: +2 columns (slug, bio), ~1 column (email type varchar(255)→varchar(320)), +1 index (idx_users_slug)
+//
+// Tabel muncul dengan urutan yang sama seperti changes. Perubahan Incomplete
+// juga dihitung sebagai "!N TODO placeholders" agar migration yang belum
+// lengkap terlihat.
+func Report(changes []Change, opts ReportOptions) string {
+	var tables []string
+	summaries := map[string][]string{}
+	groups := map[string]map[string]*reportGroup{}
+	todos := map[string]*reportGroup{}
+	for _, change := range changes {
+		if _, seen := groups[change.Table]; !seen {
+			tables = append(tables, change.Table)
+			groups[change.Table] = map[string]*reportGroup{}
+		}
+		if change.Incomplete {
+			if todos[change.Table] == nil {
+				todos[change.Table] = &reportGroup{sign: "!", noun: "TODO placeholder"}
+			}
+			todos[change.Table].items = append(todos[change.Table].items, change.Column+" backfill")
+		}
+		switch change.Kind {
+		case ChangeCreateTable:
+			summaries[change.Table] = append(summaries[change.Table], "created ("+change.Detail+")")
+			continue
+		case ChangeDropTable:
+			summaries[change.Table] = append(summaries[change.Table], "dropped")
+			continue
+		}
+		group, ok := groups[change.Table][change.Kind]
+		if !ok {
+			for _, k := range reportKinds {
+				if k.kind == change.Kind {
+					group = &reportGroup{sign: k.sign, noun: k.noun}
+				}
+			}
+			if group == nil {
+				continue
+			}
+			groups[change.Table][change.Kind] = group
+		}
+		group.items = append(group.items, changeName(change))
+	}
+
+	rows := make([][2]string, 0, len(tables))
+	for _, table := range tables {
+		parts := summaries[table]
+		for _, k := range reportKinds {
+			if group, ok := groups[table][k.kind]; ok {
+				parts = append(parts, group.render(opts.Limit))
+			}
+		}
+		if todo, ok := todos[table]; ok {
+			parts = append(parts, todo.render(opts.Limit))
+		}
+		rows = append(rows, [2]string{table, strings.Join(parts, ", ")})
+	}
+
+	if opts.Markdown {
+		return markdownReport(rows)
+	}
+	return textReport(rows)
+}
+
+// render merender kelompok, mis. "+2 columns (slug, bio)"
+func (g *reportGroup) render(limit int) string {
+	items := g.items
+	more := 0
+	if limit > 0 && len(items) > limit {
+		items, more = items[:limit], len(items)-limit
+	}
+	list := strings.Join(items, ", ")
+	if more > 0 {
+		list += fmt.Sprintf(", +%d more", more)
+	}
+	summary := fmt.Sprintf("%s%d %s", g.sign, len(g.items), plural(len(g.items), g.noun))
+	if strings.TrimSpace(list) == "" {
+		return summary
+	}
+	return summary + " (" + list + ")"
+}
+
+// changeName mengembalikan nama objek yang berubah beserta detailnya
+func changeName(change Change) string {
+	name := change.Column
+	if name == "" {
+		name = change.Index
+	}
+	if name == "" {
+		name = change.Constraint
+	}
+	if change.Detail != "" {
+		name = strings.TrimSpace(name + " " + change.Detail)
+	}
+	return name
+}
+
+// textReport merender baris dengan nama tabel yang disejajarkan
+func textReport(rows [][2]string) string {
+	width := 0
+	for _, row := range rows {
+		if len(row[0]) > width {
+			width = len(row[0])
+		}
+	}
+	var b strings.Builder
+	for _, row := range rows {
+		fmt.Fprintf(&b, "%-*s  %s\n", width+1, row[0]+":", row[1])
+	}
+	return b.String()
+}
+
+// markdownReport merender baris sebagai tabel Markdown
+func markdownReport(rows [][2]string) string {
+	var b strings.Builder
+	b.WriteString("| Table | Changes |\n|---|---|\n")
+	for _, row := range rows {
+		fmt.Fprintf(&b, "| `%s` | %s |\n", row[0], strings.ReplaceAll(row[1], "|", "\\|"))
+	}
+	return b.String()
+}
+
+// plural menambahkan akhiran jamak bahasa Inggris jika n bukan 1
+func plural(n int, noun string) string {
+	if n == 1 {
+		return noun
+	}
+	if strings.HasSuffix(noun, "x") {
+		return noun + "es"
+	}
+	return noun + "s"
+}
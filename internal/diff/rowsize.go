@@ -0,0 +1,195 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Batas ukuran baris dan jumlah kolom per dialect
+const (
+	mysqlMaxRowSize     = 65535 // byte, di luar isi TEXT/BLOB
+	mysqlMaxColumns     = 1017  // batas kolom tabel InnoDB
+	postgresMaxColumns  = 1600
+	mysqlOffPageColumn  = 12 // pointer TEXT/BLOB/JSON yang dihitung di baris
+	mysqlRowSuggestions = 3  // jumlah kolom terbesar yang disarankan menjadi TEXT
+)
+
+// rowTypeBytes adalah ukuran tipe MySQL dengan panjang tetap di dalam baris
+var rowTypeBytes = map[string]int{
+	"tinyint":   1,
+	"bool":      1,
+	"boolean":   1,
+	"smallint":  2,
+	"mediumint": 3,
+	"int":       4,
+	"integer":   4,
+	"bigint":    8,
+	"float":     4,
+	"real":      8,
+	"double":    8,
+	"date":      3,
+	"time":      3,
+	"year":      1,
+	"datetime":  5,
+	"timestamp": 4,
+	"enum":      2,
+	"set":       8,
+}
+
+// rowSize menghitung perkiraan ukuran baris MySQL beserta ukuran setiap kolom
+// string yang bisa dipindahkan keluar baris dengan mengubahnya menjadi TEXT
+func (g *Generator) rowSize(table state.Table) (int, map[string]int) {
+	total, nullable := 0, 0
+	variable := make(map[string]int)
+	for _, col := range table.Columns {
+		if col.Nullable {
+			nullable++
+		}
+		size, isString := g.columnRowBytes(col)
+		total += size
+		if isString {
+			variable[col.Name] = size
+		}
+	}
+	// Bitmap NULL: satu bit per kolom nullable
+	return total + (nullable+7)/8, variable
+}
+
+// columnRowBytes mengembalikan ukuran maksimum kolom di dalam baris MySQL.
+// isString bernilai true untuk CHAR/VARCHAR/BINARY/VARBINARY.
+func (g *Generator) columnRowBytes(col state.Column) (size int, isString bool) {
+	t := strings.ToLower(strings.TrimSpace(col.Type))
+	t = strings.TrimSpace(strings.TrimSuffix(t, "unsigned"))
+	base, length := t, 0
+	if m := typeLengthPattern.FindStringSubmatch(t); m != nil {
+		base = strings.TrimSpace(m[1])
+		length, _ = strconv.Atoi(m[2])
+	} else if open := strings.Index(t, "("); open != -1 {
+		base = strings.TrimSpace(t[:open])
+	}
+
+	lengthBytes := func(n int) int {
+		if n > 255 {
+			return 2
+		}
+		return 1
+	}
+
+	switch {
+	case base == "varchar" || base == "character varying":
+		n := length * g.bytesPerChar()
+		return n + lengthBytes(n), true
+	case base == "char" || base == "character":
+		if length == 0 {
+			length = 1
+		}
+		return length * g.bytesPerChar(), true
+	case base == "varbinary":
+		return length + lengthBytes(length), true
+	case base == "binary":
+		if length == 0 {
+			length = 1
+		}
+		return length, true
+	case base == "decimal" || base == "numeric":
+		return decimalBytes(t), false
+	case base == "bit":
+		if length == 0 {
+			length = 1
+		}
+		return (length + 7) / 8, false
+	case isTextType(base) || base == "json" || base == "geometry":
+		return mysqlOffPageColumn, false
+	}
+	if size, ok := rowTypeBytes[base]; ok {
+		// Fractional seconds: 1 byte per 2 digit
+		if base == "datetime" || base == "timestamp" || base == "time" {
+			size += (length + 1) / 2
+		}
+		return size, false
+	}
+	// Tipe yang tidak dikenal dianggap seukuran pointer off-page
+	return mysqlOffPageColumn, false
+}
+
+// decimalBytes menghitung ukuran DECIMAL(p,s): 4 byte per 9 digit, sisanya
+// 1-4 byte, untuk bagian bulat dan pecahan secara terpisah
+func decimalBytes(t string) int {
+	precision, scale := 10, 0
+	if open := strings.Index(t, "("); open != -1 {
+		parts := strings.Split(strings.Trim(t[open:], "()"), ",")
+		if p, err := strconv.Atoi(strings.TrimSpace(parts[0])); err == nil {
+			precision = p
+		}
+		if len(parts) > 1 {
+			if s, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil {
+				scale = s
+			}
+		}
+	}
+	leftover := []int{0, 1, 1, 2, 2, 3, 3, 4, 4, 4}
+	digits := func(n int) int {
+		if n < 0 {
+			return 0
+		}
+		return n/9*4 + leftover[n%9]
+	}
+	return digits(precision-scale) + digits(scale)
+}
+
+// checkRowSize memeriksa jumlah kolom dan ukuran baris tabel. Tabel yang
+// melebihi batas menghasilkan peringatan, atau ValidationError dengan Config.Strict.
+func (g *Generator) checkRowSize(table state.Table) (string, error) {
+	var warning string
+	switch g.config.Dialect {
+	case DialectMySQL:
+		if len(table.Columns) > mysqlMaxColumns {
+			warning = fmt.Sprintf("table has %d columns, InnoDB allows at most %d", len(table.Columns), mysqlMaxColumns)
+			break
+		}
+		size, variable := g.rowSize(table)
+		if size <= mysqlMaxRowSize {
+			return "", nil
+		}
+		warning = fmt.Sprintf("row size is about %d bytes with charset %s, exceeding the %d-byte limit",
+			size, g.config.Charset, mysqlMaxRowSize)
+		if largest := largestColumns(variable, mysqlRowSuggestions); len(largest) > 0 {
+			warning += fmt.Sprintf("; consider TEXT for %s", strings.Join(largest, ", "))
+		}
+	case DialectPostgres:
+		if len(table.Columns) <= postgresMaxColumns {
+			return "", nil
+		}
+		warning = fmt.Sprintf("table has %d columns, postgres allows at most %d", len(table.Columns), postgresMaxColumns)
+	default:
+		return "", nil
+	}
+
+	if g.config.Strict {
+		return "", &ValidationError{Table: table.Name, Rule: "row-size", Detail: warning}
+	}
+	g.warnings.Add("row-size", table.Name, "", "%s", warning)
+	return fmt.Sprintf("-- datara: %s: %s", table.Name, warning), nil
+}
+
+// largestColumns mengembalikan hingga n kolom terbesar, terbesar lebih dulu
+func largestColumns(sizes map[string]int, n int) []string {
+	names := make([]string, 0, len(sizes))
+	for name := range sizes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if sizes[names[i]] != sizes[names[j]] {
+			return sizes[names[i]] > sizes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
+}
@@ -0,0 +1,92 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// wideTable membuat tabel dengan kolom id INT dan kolom-kolom types
+func wideTable(types ...string) state.Table {
+	table := state.Table{Name: "wide", Columns: map[string]state.Column{
+		"id": {Name: "id", Type: "INT", Position: 1},
+	}}
+	for i, t := range types {
+		name := fmt.Sprintf("c%d", i)
+		table.Columns[name] = state.Column{Name: name, Type: t, Position: i + 2}
+	}
+	return table
+}
+
+// repeatType mengembalikan n salinan t
+func repeatType(t string, n int) []string {
+	types := make([]string, n)
+	for i := range types {
+		types[i] = t
+	}
+	return types
+}
+
+func TestCheckRowSizeBoundaries(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		charset string
+		table   state.Table
+		// want bernilai true jika tabel melebihi batas
+		want bool
+	}{
+		// id (4) + VARCHAR (n + 2 byte panjang)
+		{"latin1 at the limit", DialectMySQL, "latin1", wideTable("VARCHAR(65529)"), false},
+		{"latin1 one byte over", DialectMySQL, "latin1", wideTable("VARCHAR(65530)"), true},
+		{"utf8mb4 under the limit", DialectMySQL, "utf8mb4", wideTable("VARCHAR(16382)"), false},
+		{"utf8mb4 over the limit", DialectMySQL, "utf8mb4", wideTable("VARCHAR(16383)"), true},
+		{"text is off-page", DialectMySQL, "utf8mb4", wideTable(repeatType("TEXT", 80)...), false},
+		{"eighty varchar(255) utf8mb4", DialectMySQL, "utf8mb4", wideTable(repeatType("VARCHAR(255)", 80)...), true},
+		{"mysql column limit", DialectMySQL, "utf8mb4", wideTable(repeatType("INT", mysqlMaxColumns-1)...), false},
+		{"mysql one column over", DialectMySQL, "utf8mb4", wideTable(repeatType("INT", mysqlMaxColumns)...), true},
+		{"postgres column limit", DialectPostgres, "", wideTable(repeatType("INT", postgresMaxColumns-1)...), false},
+		{"postgres one column over", DialectPostgres, "", wideTable(repeatType("INT", postgresMaxColumns)...), true},
+		{"postgres ignores row size", DialectPostgres, "", wideTable("VARCHAR(65530)"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator(&Config{Dialect: tt.dialect, Charset: tt.charset})
+			warning, err := g.checkRowSize(tt.table)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := warning != ""; got != tt.want {
+				t.Errorf("checkRowSize() warning = %q, want exceeded = %v", warning, tt.want)
+			}
+			if got := len(g.Warnings()) > 0; got != tt.want {
+				t.Errorf("Warnings() = %v, want exceeded = %v", g.Warnings(), tt.want)
+			}
+
+			g = NewGenerator(&Config{Dialect: tt.dialect, Charset: tt.charset, Strict: true})
+			_, err = g.checkRowSize(tt.table)
+			var validationErr *ValidationError
+			if got := errors.As(err, &validationErr); got != tt.want {
+				t.Errorf("strict checkRowSize() err = %v, want ValidationError = %v", err, tt.want)
+			}
+			if tt.want && validationErr.Rule != "row-size" {
+				t.Errorf("strict checkRowSize() rule = %q, want row-size", validationErr.Rule)
+			}
+		})
+	}
+}
+
+func TestCheckRowSizeSuggestsText(t *testing.T) {
+	g := NewGenerator(&Config{Dialect: DialectMySQL, Charset: "utf8mb4"})
+	table := wideTable(append(repeatType("VARCHAR(255)", 60), "VARCHAR(4000)", "VARCHAR(3000)")...)
+	warning, err := g.checkRowSize(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(warning, "consider TEXT for c60, c61") {
+		t.Errorf("checkRowSize() = %q, want the two largest columns suggested first", warning)
+	}
+}
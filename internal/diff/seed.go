@@ -0,0 +1,672 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// SeedOptions mengatur Seed
+type SeedOptions struct {
+	// Tables adalah tabel yang diisi; kosong berarti semua tabel. Tabel yang
+	// direferensikan foreign key ikut diisi agar baris anak punya induk.
+	Tables []string
+	// Rows adalah jumlah baris per tabel
+	Rows int
+	// Seed menentukan nilai acak; seed yang sama menghasilkan SQL yang sama
+	Seed int64
+	// Values adalah ekspresi SQL per "tabel.kolom" yang menimpa tag seed=...
+	Values map[string]string
+}
+
+// SeedRowPlaceholder diganti nomor baris (mulai dari 1) di ekspresi seed=...
+const SeedRowPlaceholder = "{n}"
+
+// seedChunkRows adalah jumlah baris paling banyak per INSERT; SQL Server
+// menolak lebih dari 1000 baris dalam satu VALUES
+const seedChunkRows = 100
+
+// seedEpoch adalah titik awal nilai tanggal dan waktu acak
+var seedEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// seedWords dipakai untuk nilai teks acak
+var seedWords = []string{
+	"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel",
+	"india", "juliet", "kilo", "lima", "mike", "november", "oscar", "papa",
+}
+
+// seedNames dipakai untuk kolom nama
+var seedNames = []string{
+	"Ayu", "Budi", "Citra", "Dewi", "Eko", "Fitri", "Gilang", "Hana",
+	"Indra", "Joko", "Kartika", "Lestari", "Made", "Nanda", "Putri", "Rizky",
+}
+
+// generatedColumnPattern mengenali kolom generated, mis. AS (price * qty) STORED
+var generatedColumnPattern = regexp.MustCompile(`(?i)\bAS\s*\(`)
+
+// Seed membuat INSERT berisi data palsu untuk tabel di snapshot schema, dalam
+// urutan dependency foreign key. Nilai mengikuti tipe kolom, kolom unique
+// memakai nomor baris, kolom ENUM memilih dari daftar nilainya dan kolom
+// foreign key mereferensikan baris induk yang dibuat sebelumnya.
+func (g *Generator) Seed(schema *state.SchemaState, opts SeedOptions) ([]string, error) {
+	if opts.Rows <= 0 {
+		return nil, fmt.Errorf("seed rows must be positive, got %d", opts.Rows)
+	}
+	schema = g.applyTags(schema)
+	tables, err := seedTables(schema, opts.Tables)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range sortedSeedKeys(opts.Values) {
+		tableName, column, _ := strings.Cut(key, ".")
+		if _, ok := schema.Tables[tableName].Columns[column]; !ok {
+			return nil, fmt.Errorf("seed value %q does not match a table.column in the schema snapshot", key)
+		}
+	}
+
+	s := &seeder{
+		g:          g,
+		opts:       opts,
+		rng:        rand.New(rand.NewSource(opts.Seed)),
+		values:     make(map[string]map[string][]string, len(tables)),
+		referenced: make(map[string]bool),
+	}
+	for _, table := range tables {
+		for _, fk := range foreignKeys(table) {
+			for _, column := range fk.refColumns {
+				s.referenced[fk.refTable+"."+column] = true
+			}
+		}
+	}
+
+	var statements []string
+	for _, table := range dependencyOrder(tables) {
+		stmts, err := s.table(table)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, stmts...)
+	}
+	return statements, nil
+}
+
+// seedTables mengembalikan tabel yang dipilih beserta semua tabel yang
+// direferensikannya, terurut berdasarkan nama
+func seedTables(schema *state.SchemaState, names []string) ([]state.Table, error) {
+	if len(names) == 0 {
+		return sortedTables(schema.Tables), nil
+	}
+	selected := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if selected[name] {
+			return
+		}
+		selected[name] = true
+		for _, ref := range ReferencedTables(schema.Tables[name]) {
+			if _, ok := schema.Tables[ref]; ok {
+				visit(ref)
+			}
+		}
+	}
+	for _, name := range names {
+		if _, ok := schema.Tables[name]; !ok {
+			return nil, fmt.Errorf("table %q does not exist in the schema snapshot", name)
+		}
+		visit(name)
+	}
+	var tables []state.Table
+	for _, table := range sortedTables(schema.Tables) {
+		if selected[table.Name] {
+			tables = append(tables, table)
+		}
+	}
+	return tables, nil
+}
+
+// seeder menyimpan state satu pemanggilan Seed
+type seeder struct {
+	g    *Generator
+	opts SeedOptions
+	rng  *rand.Rand
+	// values adalah literal yang sudah dibuat per tabel dan kolom, dipakai
+	// kolom foreign key tabel anak
+	values map[string]map[string][]string
+	// referenced adalah "tabel.kolom" yang direferensikan foreign key, yang
+	// selalu diisi walaupun punya DEFAULT
+	referenced map[string]bool
+}
+
+// table membuat INSERT untuk satu tabel dan menyimpan nilainya untuk tabel anak
+func (s *seeder) table(table state.Table) ([]string, error) {
+	n := s.opts.Rows
+	unique := uniqueColumnSets(table)
+	// Foreign key dengan ekspresi seed di salah satu kolomnya diisi dari
+	// ekspresi tersebut
+	var fks []foreignKey
+	for _, fk := range foreignKeys(table) {
+		overridden := false
+		for _, column := range fk.columns {
+			overridden = overridden || s.expr(table, table.Columns[column]) != ""
+		}
+		if !overridden {
+			fks = append(fks, fk)
+		}
+	}
+	fkColumns := make(map[string]bool)
+	for _, fk := range fks {
+		for _, column := range fk.columns {
+			fkColumns[column] = true
+		}
+	}
+
+	var columns []state.Column
+	for _, col := range s.g.orderedColumns(table.Columns) {
+		if fkColumns[col.Name] || s.expr(table, col) != "" || !s.skip(table, col) {
+			columns = append(columns, col)
+		}
+	}
+	values := make(map[string][]string, len(columns))
+	s.values[table.Name] = values
+
+	// Kolom biasa lebih dulu, sehingga foreign key ke tabel sendiri bisa
+	// mereferensikan baris sebelumnya
+	for _, col := range columns {
+		if fkColumns[col.Name] {
+			continue
+		}
+		isUnique := inUniqueSet(unique, col.Name)
+		column := make([]string, n)
+		for i := 1; i <= n; i++ {
+			value, err := s.value(table, col, i, isUnique)
+			if err != nil {
+				return nil, err
+			}
+			column[i-1] = value
+		}
+		values[col.Name] = column
+	}
+	for k := range fks {
+		if err := s.foreignKey(table, fks, k, unique, values); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.inserts(table, columns, values), nil
+}
+
+// skip melaporkan kolom yang dibiarkan memakai DEFAULT-nya: kolom generated
+// dan kolom dengan DEFAULT berupa ekspresi, mis. CURRENT_TIMESTAMP, kecuali
+// kolom primary key atau yang direferensikan foreign key
+func (s *seeder) skip(table state.Table, col state.Column) bool {
+	if generatedColumnPattern.MatchString(col.Extra) {
+		return true
+	}
+	if s.referenced[table.Name+"."+col.Name] || containsColumn(primaryKeyColumns(table), col.Name) {
+		return false
+	}
+	if col.DefaultValue == nil {
+		return false
+	}
+	kind := col.DefaultValue.Kind
+	return kind == state.DefaultKeyword || kind == state.DefaultExpression
+}
+
+// expr mengembalikan ekspresi seed kolom dari SeedOptions.Values atau tag
+// seed=...
+func (s *seeder) expr(table state.Table, col state.Column) string {
+	if expr, ok := s.opts.Values[table.Name+"."+col.Name]; ok {
+		return expr
+	}
+	return col.Tags["seed"]
+}
+
+// value membuat literal SQL kolom untuk baris ke-i
+func (s *seeder) value(table state.Table, col state.Column, i int, unique bool) (string, error) {
+	if expr := s.expr(table, col); expr != "" {
+		return strings.ReplaceAll(expr, SeedRowPlaceholder, strconv.Itoa(i)), nil
+	}
+	keyed := unique || containsColumn(primaryKeyColumns(table), col.Name) || s.referenced[table.Name+"."+col.Name]
+	if col.Nullable && !keyed && s.rng.Intn(10) == 0 {
+		return "NULL", nil
+	}
+	if value, ok := s.fake(col, i, keyed); ok {
+		return value, nil
+	}
+	switch {
+	case col.DefaultValue != nil:
+		return "DEFAULT", nil
+	case col.Nullable:
+		return "NULL", nil
+	}
+	return "", &ValidationError{Table: table.Name, Column: col.Name, Rule: "seed",
+		Detail: fmt.Sprintf("no fake value for type %s; add db:\"seed=<expr>\" or a seed.values entry", col.Type)}
+}
+
+// fake membuat nilai palsu sesuai tipe kolom. Jika unique, nilainya memuat
+// nomor baris i sehingga tidak pernah sama antarbaris.
+func (s *seeder) fake(col state.Column, i int, unique bool) (string, bool) {
+	t := strings.ToLower(strings.TrimSpace(col.Type))
+	base := t
+	if end := strings.IndexAny(t, "( "); end != -1 {
+		base = t[:end]
+	}
+
+	switch {
+	case strings.HasSuffix(t, "[]"):
+		return "'{}'", true
+	case isBooleanType(col.Type) || base == "bit":
+		return s.g.seedBool(s.rng.Intn(2) == 0), true
+	case base == "enum" || base == "set":
+		values := enumValues(col.Type)
+		if len(values) == 0 {
+			return "", false
+		}
+		if unique {
+			return quoteLiteral(values[(i-1)%len(values)]), true
+		}
+		return quoteLiteral(values[s.rng.Intn(len(values))]), true
+	}
+	if kind, _, _ := integerType(col.Type); kind != "" || isSerialType(base) {
+		if unique {
+			return strconv.Itoa(i), true
+		}
+		limit := 10000
+		switch kind {
+		case "tinyint":
+			limit = 100
+		case "smallint":
+			limit = 1000
+		}
+		return strconv.Itoa(s.rng.Intn(limit)), true
+	}
+
+	switch base {
+	case "decimal", "numeric", "money", "smallmoney":
+		precision, scale := decimalSpec(t)
+		max := 10000.0
+		if digits := precision - scale; digits < 4 {
+			max = 1
+			for d := 0; d < digits; d++ {
+				max *= 10
+			}
+		}
+		value := s.rng.Float64() * max
+		if unique {
+			value = float64(i % int(max))
+		}
+		return strconv.FormatFloat(value, 'f', scale, 64), true
+	case "float", "double", "real":
+		if unique {
+			return strconv.Itoa(i), true
+		}
+		return strconv.FormatFloat(s.rng.Float64()*1000, 'f', 2, 64), true
+	case "uuid", "uniqueidentifier":
+		b := make([]byte, 16)
+		s.rng.Read(b)
+		b[6], b[8] = b[6]&0x0f|0x40, b[8]&0x3f|0x80
+		return fmt.Sprintf("'%x-%x-%x-%x-%x'", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), true
+	case "date":
+		return seedEpoch.AddDate(0, 0, s.rng.Intn(365)).Format("'2006-01-02'"), true
+	case "time":
+		return seedEpoch.Add(time.Duration(s.rng.Intn(86400)) * time.Second).Format("'15:04:05'"), true
+	case "datetime", "datetime2", "timestamp", "timestamptz", "smalldatetime", "datetimeoffset":
+		return seedEpoch.Add(time.Duration(s.rng.Intn(365*86400)) * time.Second).Format("'2006-01-02 15:04:05'"), true
+	case "year":
+		return strconv.Itoa(2000 + s.rng.Intn(25)), true
+	case "json", "jsonb":
+		return "'{}'", true
+	case "inet", "cidr":
+		return fmt.Sprintf("'10.%d.%d.%d'", i/65536%256, i/256%256, i%256), true
+	case "bytea", "binary", "varbinary", "blob", "tinyblob", "mediumblob", "longblob", "image":
+		b := make([]byte, 8)
+		s.rng.Read(b)
+		if unique {
+			b = []byte(fmt.Sprintf("%08d", i))
+		}
+		return s.g.seedBytes(b), true
+	}
+
+	if !isStringType(col.Type) {
+		return "", false
+	}
+	_, _, length, ok := stringType(col.Type)
+	if !ok {
+		length = -1
+	}
+	return quoteLiteral(s.fakeString(col.Name, i, unique, length)), true
+}
+
+// fakeString membuat teks palsu berdasarkan nama kolom, mis. alamat email
+// untuk kolom email, dipotong ke panjang kolom
+func (s *seeder) fakeString(column string, i int, unique bool, length int) string {
+	name := strings.ToLower(column)
+	word := seedWords[s.rng.Intn(len(seedWords))]
+	var value string
+	switch {
+	case strings.Contains(name, "email"):
+		value = fmt.Sprintf("%s%d@example.com", word, i)
+	case strings.Contains(name, "url") || strings.Contains(name, "website") || strings.Contains(name, "link"):
+		value = fmt.Sprintf("https://example.com/%s/%d", word, i)
+	case strings.Contains(name, "phone"):
+		value = fmt.Sprintf("+62812%07d", s.rng.Intn(10000000))
+		if unique {
+			value = fmt.Sprintf("+62812%07d", i)
+		}
+	case strings.Contains(name, "slug") || strings.Contains(name, "code") || strings.Contains(name, "username"):
+		value = fmt.Sprintf("%s-%d", word, i)
+	case strings.Contains(name, "name"):
+		value = seedNames[s.rng.Intn(len(seedNames))] + " " + strings.ToUpper(word[:1]) + word[1:]
+		if unique {
+			value += " " + strconv.Itoa(i)
+		}
+	default:
+		value = word + " " + seedWords[s.rng.Intn(len(seedWords))]
+		if unique {
+			value += " " + strconv.Itoa(i)
+		}
+	}
+	if length >= 0 && len(value) > length {
+		value = value[:length]
+		if suffix := strconv.Itoa(i); unique && len(suffix) <= length {
+			value = value[:length-len(suffix)] + suffix
+		}
+	}
+	return value
+}
+
+// foreignKey mengisi kolom foreign key dari nilai baris induk. Foreign key
+// yang seluruh kolomnya bagian dari unique key memilih induk secara
+// berurutan agar kombinasinya tidak berulang; lainnya memilih acak.
+func (s *seeder) foreignKey(table state.Table, fks []foreignKey, k int, unique [][]string, values map[string][]string) error {
+	n, fk := s.opts.Rows, fks[k]
+	nullable := true
+	for _, column := range fk.columns {
+		if col, ok := table.Columns[column]; !ok || !col.Nullable {
+			nullable = false
+		}
+	}
+	parent, ok := s.values[fk.refTable]
+	for _, column := range fk.refColumns {
+		if _, has := parent[column]; !has {
+			ok = false
+		}
+	}
+	if !ok {
+		if !nullable {
+			return &ValidationError{Table: table.Name, Column: strings.Join(fk.columns, ", "), Rule: "seed",
+				Detail: fmt.Sprintf("references %s, which is not seeded before this table; add db:\"seed=<expr>\" or a seed.values entry", fk.refTable)}
+		}
+		for _, column := range fk.columns {
+			values[column] = repeat("NULL", n)
+		}
+		return nil
+	}
+
+	// Posisi foreign key di antara foreign key lain dalam unique key yang sama
+	position := -1
+	for _, set := range unique {
+		if !containsAll(set, fk.columns) {
+			continue
+		}
+		position = 0
+		for _, other := range fks[:k] {
+			if containsAll(set, other.columns) {
+				position++
+			}
+		}
+		break
+	}
+
+	for _, column := range fk.columns {
+		values[column] = make([]string, n)
+	}
+	for i := 1; i <= n; i++ {
+		rows := n
+		if fk.refTable == table.Name {
+			// Foreign key ke tabel sendiri hanya bisa menunjuk baris sebelumnya
+			rows = i - 1
+		}
+		row := -1
+		switch {
+		case rows == 0 && nullable:
+		case rows == 0:
+			row = i - 1
+		case position >= 0:
+			step := 1
+			for p := 0; p < position; p++ {
+				step *= rows
+			}
+			row = (i - 1) / step % rows
+		default:
+			row = s.rng.Intn(rows)
+		}
+		for c, column := range fk.columns {
+			values[column][i-1] = "NULL"
+			if row >= 0 {
+				values[column][i-1] = parent[fk.refColumns[c]][row]
+			}
+		}
+	}
+	return nil
+}
+
+// inserts merender nilai tabel sebagai INSERT per seedChunkRows baris, beserta
+// statement yang diperlukan dialect untuk mengisi kolom identity secara eksplisit
+func (s *seeder) inserts(table state.Table, columns []state.Column, values map[string][]string) []string {
+	name := s.g.quote(table.Name)
+	names := make([]string, len(columns))
+	var identity *state.Column
+	for k, col := range columns {
+		names[k] = s.g.quote(col.Name)
+		if col.AutoIncrement && identity == nil {
+			identity = &columns[k]
+		}
+	}
+
+	var before, after []string
+	override := ""
+	if identity != nil {
+		switch s.g.config.Dialect {
+		case DialectPostgres:
+			if s.g.identity(*identity) == state.IdentityAlways {
+				override = " OVERRIDING SYSTEM VALUE"
+			}
+			// Sequence tidak maju saat nilai ditulis eksplisit
+			after = append(after, fmt.Sprintf("SELECT setval(pg_get_serial_sequence(%s, %s), (SELECT MAX(%s) FROM %s));",
+				quoteLiteral(name), quoteLiteral(identity.Name), s.g.quote(identity.Name), name))
+		case DialectMSSQL:
+			before = append(before, fmt.Sprintf("SET IDENTITY_INSERT %s ON;", name))
+			after = append(after, fmt.Sprintf("SET IDENTITY_INSERT %s OFF;", name))
+		}
+	}
+
+	statements := before
+	for start := 0; start < s.opts.Rows; start += seedChunkRows {
+		end := min(start+seedChunkRows, s.opts.Rows)
+		rows := make([]string, 0, end-start)
+		for i := start; i < end; i++ {
+			row := make([]string, len(columns))
+			for k, col := range columns {
+				row[k] = values[col.Name][i]
+			}
+			rows = append(rows, "("+strings.Join(row, ", ")+")")
+		}
+		statements = append(statements, fmt.Sprintf("INSERT INTO %s (%s)%s VALUES\n  %s;",
+			name, strings.Join(names, ", "), override, strings.Join(rows, ",\n  ")))
+	}
+	return append(statements, after...)
+}
+
+// seedBool merender literal boolean sesuai dialect
+func (g *Generator) seedBool(value bool) string {
+	if g.postgresSyntax() {
+		return strings.ToUpper(strconv.FormatBool(value))
+	}
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+// seedBytes merender literal biner sesuai dialect
+func (g *Generator) seedBytes(b []byte) string {
+	switch {
+	case g.postgresSyntax():
+		return fmt.Sprintf("'\\x%x'", b)
+	case g.config.Dialect == DialectMSSQL:
+		return fmt.Sprintf("0x%x", b)
+	}
+	return fmt.Sprintf("X'%x'", b)
+}
+
+// foreignKeys mengembalikan foreign key tabel sesuai urutan constraint
+func foreignKeys(table state.Table) []foreignKey {
+	var fks []foreignKey
+	for _, constraint := range table.Constraints {
+		if fk, ok := parseForeignKey(constraint.Def); ok && len(fk.columns) == len(fk.refColumns) {
+			fks = append(fks, fk)
+		}
+	}
+	return fks
+}
+
+// uniqueColumnSets mengembalikan kolom primary key, constraint UNIQUE dan
+// unique index tabel
+func uniqueColumnSets(table state.Table) [][]string {
+	var sets [][]string
+	if pk := primaryKeyColumns(table); len(pk) > 0 {
+		sets = append(sets, pk)
+	}
+	for _, constraint := range table.Constraints {
+		rest := strings.TrimSpace(constraint.Def)
+		if hasKeyword(rest, "CONSTRAINT") {
+			_, rest = nextIdent(rest[len("CONSTRAINT"):])
+			rest = strings.TrimSpace(rest)
+		}
+		if !hasKeyword(rest, "UNIQUE") {
+			continue
+		}
+		rest = strings.TrimSpace(rest[len("UNIQUE"):])
+		for _, keyword := range []string{"KEY", "INDEX"} {
+			if hasKeyword(rest, keyword) {
+				rest = strings.TrimSpace(rest[len(keyword):])
+			}
+		}
+		if rest != "" && rest[0] != '(' {
+			_, rest = nextIdent(rest)
+		}
+		if columns, _, ok := identNames(rest); ok {
+			sets = append(sets, columns)
+		}
+	}
+	for _, idx := range sortedIndexes(table.Indexes) {
+		if idx.Unique {
+			sets = append(sets, idx.Columns)
+		}
+	}
+	return sets
+}
+
+// enumValues mengembalikan daftar nilai tipe ENUM('a','b') atau SET(...)
+func enumValues(sqlType string) []string {
+	open, end := strings.Index(sqlType, "("), strings.LastIndex(sqlType, ")")
+	if open == -1 || end < open {
+		return nil
+	}
+	var values []string
+	var current strings.Builder
+	inQuote := false
+	list := sqlType[open+1 : end]
+	for i := 0; i < len(list); i++ {
+		switch c := list[i]; {
+		case c == '\'' && inQuote && i+1 < len(list) && list[i+1] == '\'':
+			current.WriteByte(c)
+			i++
+		case c == '\'':
+			if inQuote {
+				values = append(values, current.String())
+				current.Reset()
+			}
+			inQuote = !inQuote
+		case inQuote:
+			current.WriteByte(c)
+		}
+	}
+	return values
+}
+
+// decimalSpec mengembalikan precision dan scale tipe DECIMAL(p,s); tanpa
+// argumen dianggap DECIMAL(10,2)
+func decimalSpec(t string) (int, int) {
+	open, end := strings.Index(t, "("), strings.Index(t, ")")
+	if open == -1 || end < open {
+		return 10, 2
+	}
+	parts := strings.Split(t[open+1:end], ",")
+	precision, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 10, 2
+	}
+	scale := 0
+	if len(parts) > 1 {
+		scale, _ = strconv.Atoi(strings.TrimSpace(parts[1]))
+	}
+	return precision, scale
+}
+
+// quoteLiteral merender s sebagai string literal SQL
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// containsColumn melaporkan apakah columns memuat column
+func containsColumn(columns []string, column string) bool {
+	return slices.Contains(columns, column)
+}
+
+// inUniqueSet melaporkan apakah column bagian dari salah satu unique key
+func inUniqueSet(sets [][]string, column string) bool {
+	for _, set := range sets {
+		if containsColumn(set, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAll melaporkan apakah set memuat semua columns
+func containsAll(set, columns []string) bool {
+	for _, column := range columns {
+		if !containsColumn(set, column) {
+			return false
+		}
+	}
+	return true
+}
+
+// repeat mengembalikan n salinan value
+func repeat(value string, n int) []string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+// sortedSeedKeys mengembalikan key map secara terurut
+func sortedSeedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
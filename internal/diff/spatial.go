@@ -0,0 +1,103 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// columnType merender tipe kolom sesuai dialect. Kolom spatial dirender
+// sebagai geometry(Point,4326) atau geography(...) di Postgres, geometry atau
+// geography di SQL Server, dan jenis geometrinya (mis. POINT) di MySQL, yang
+// menulis SRID sebagai atribut kolom terpisah.
+func (g *Generator) columnType(col state.Column) string {
+	kind, geography, _, ok := state.SpatialType(col.Type)
+	if !ok {
+		return g.sqlType(col.Type)
+	}
+	name := "geometry"
+	if geography {
+		name = "geography"
+	}
+	switch {
+	case g.config.Dialect == DialectMSSQL:
+		return name
+	case !g.postgresSyntax():
+		return kind
+	case col.SRID != 0:
+		return fmt.Sprintf("%s(%s,%d)", name, state.SpatialTypeName(kind), col.SRID)
+	case kind != "GEOMETRY":
+		return fmt.Sprintf("%s(%s)", name, state.SpatialTypeName(kind))
+	}
+	return name
+}
+
+// sridClause mengembalikan atribut SRID kolom untuk MySQL
+func (g *Generator) sridClause(col state.Column) string {
+	if col.SRID == 0 || g.config.Dialect != DialectMySQL || !state.IsSpatialType(col.Type) {
+		return ""
+	}
+	return fmt.Sprintf(" SRID %d", col.SRID)
+}
+
+// sridUsing mengembalikan klausa USING untuk ALTER COLUMN ... TYPE Postgres
+// yang mengganti SRID kolom geometry; nilai lama ditandai ulang dengan
+// ST_SetSRID, bukan ditransformasi
+func (g *Generator) sridUsing(current, desired state.Column) string {
+	_, geography, _, ok := state.SpatialType(desired.Type)
+	if !ok || geography || current.SRID == desired.SRID || !state.IsSpatialType(current.Type) {
+		return ""
+	}
+	return fmt.Sprintf(" USING ST_SetSRID(%s, %d)", g.quote(desired.Name), desired.SRID)
+}
+
+// applySpatialTags menerapkan tag srid=... dan menyeragamkan tipe spatial
+func applySpatialTags(col *state.Column) {
+	if srid, err := strconv.Atoi(col.Tags["srid"]); err == nil && col.SRID == 0 {
+		col.SRID = srid
+	}
+	col.NormalizeSpatial()
+}
+
+// spatialIndexName mengembalikan nama index dari tag spatial, atau false jika
+// kolom tidak bertag spatial. Nama diambil dari spatial=... lalu index=...
+func spatialIndexName(col state.Column) (string, bool) {
+	name, ok := col.Tags["spatial"]
+	if !ok {
+		return "", false
+	}
+	if name == "" {
+		name = col.Tags["index"]
+	}
+	if name == "" {
+		name = fmt.Sprintf("idx_%s", col.Name)
+	}
+	return name, true
+}
+
+// validateSpatialIndexes memastikan index spatial hanya mencakup kolom
+// spatial, dan di MySQL kolom tersebut NOT NULL karena SPATIAL index menolak
+// kolom nullable
+func (g *Generator) validateSpatialIndexes(table state.Table) error {
+	for _, idx := range sortedIndexes(table.Indexes) {
+		if !idx.Spatial {
+			continue
+		}
+		for _, name := range idx.Columns {
+			col, ok := table.Columns[name]
+			if !ok {
+				continue
+			}
+			if !state.IsSpatialType(col.Type) {
+				return &ValidationError{Table: table.Name, Column: name, Rule: "spatial-index",
+					Detail: fmt.Sprintf("spatial index %q on column of type %s; use GEOMETRY, POINT, LINESTRING, POLYGON or a geometry(...) type", idx.Name, col.Type)}
+			}
+			if g.config.Dialect == DialectMySQL && col.Nullable {
+				return &ValidationError{Table: table.Name, Column: name, Rule: "spatial-index",
+					Detail: fmt.Sprintf("spatial index %q requires the column to be NOT NULL on mysql (add notnull)", idx.Name)}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Klasifikasi perubahan tipe string pada Change.Length
+const (
+	// LengthWiden memperpanjang kolom, mis. VARCHAR(100)→VARCHAR(255); aman
+	LengthWiden = "widen"
+	// LengthNarrow memperpendek kolom sehingga data bisa terpotong atau
+	// ALTER gagal; dianggap destructive
+	LengthNarrow = "narrow"
+	// LengthRetype mengganti VARCHAR dengan CHAR (atau sebaliknya) tanpa
+	// memperpendek; CHAR mengisi nilai dengan spasi sampai panjangnya
+	LengthRetype = "retype"
+)
+
+// stringFamilies mengelompokkan tipe string menjadi panjang variabel atau tetap
+var stringFamilies = map[string]struct{ kind, family string }{
+	"varchar":           {"char", "varying"},
+	"character varying": {"char", "varying"},
+	"nvarchar":          {"char", "varying"},
+	"text":              {"char", "varying"},
+	"char":              {"char", "fixed"},
+	"character":         {"char", "fixed"},
+	"nchar":             {"char", "fixed"},
+	"varbinary":         {"binary", "varying"},
+	"binary":            {"binary", "fixed"},
+}
+
+// stringType memecah tipe string menjadi jenis (char/binary), family
+// (varying/fixed) dan panjangnya, mis. VARCHAR(100). Panjang -1 berarti tanpa
+// batas, mis. TEXT atau varchar tanpa panjang di Postgres.
+func stringType(t string) (kind, family string, length int, ok bool) {
+	t = strings.ToLower(strings.TrimSpace(t))
+	base, length := t, -1
+	if m := typeLengthPattern.FindStringSubmatch(t); m != nil {
+		base = strings.TrimSpace(m[1])
+		length, _ = strconv.Atoi(m[2])
+	} else if strings.Contains(t, "(") {
+		// Mis. NVARCHAR(MAX)
+		return "", "", 0, false
+	}
+	f, ok := stringFamilies[base]
+	if !ok {
+		return "", "", 0, false
+	}
+	if length == -1 && f.family == "fixed" {
+		// CHAR tanpa panjang sama dengan CHAR(1)
+		length = 1
+	}
+	return f.kind, f.family, length, true
+}
+
+// lengthChange mengklasifikasikan perubahan tipe string current→desired
+// sebagai LengthWiden, LengthNarrow atau LengthRetype. String kosong berarti
+// bukan perubahan panjang, mis. tipe non-string atau VARCHAR menjadi VARBINARY.
+func lengthChange(current, desired string) string {
+	currentKind, currentFamily, currentLength, ok := stringType(current)
+	if !ok {
+		return ""
+	}
+	desiredKind, desiredFamily, desiredLength, ok := stringType(desired)
+	if !ok || currentKind != desiredKind {
+		return ""
+	}
+	switch {
+	case longer(currentLength, desiredLength):
+		return LengthNarrow
+	case currentFamily != desiredFamily:
+		return LengthRetype
+	case longer(desiredLength, currentLength):
+		return LengthWiden
+	}
+	return ""
+}
+
+// longer mengecek apakah panjang a melebihi b; -1 berarti tanpa batas
+func longer(a, b int) bool {
+	if a == b || a != -1 && b == -1 {
+		return false
+	}
+	return a == -1 || a > b
+}
+
+// lengthNote menjelaskan klasifikasi panjang untuk ringkasan perubahan
+func lengthNote(length string) string {
+	switch length {
+	case LengthWiden:
+		return "widening"
+	case LengthNarrow:
+		return "narrowing, values may be truncated"
+	case LengthRetype:
+		return "fixed-length types pad values with spaces"
+	}
+	return ""
+}
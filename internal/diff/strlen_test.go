@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+func TestLengthChange(t *testing.T) {
+	tests := []struct {
+		current, desired string
+		want             string
+	}{
+		{"VARCHAR(100)", "VARCHAR(255)", LengthWiden},
+		{"varchar(100)", "TEXT", LengthWiden},
+		{"character varying(100)", "character varying", LengthWiden},
+		{"VARBINARY(16)", "VARBINARY(32)", LengthWiden},
+		{"VARCHAR(255)", "VARCHAR(100)", LengthNarrow},
+		{"TEXT", "VARCHAR(255)", LengthNarrow},
+		{"CHAR(10)", "CHAR", LengthNarrow},
+		{"VARCHAR(100)", "CHAR(50)", LengthNarrow},
+		{"VARCHAR(100)", "CHAR(100)", LengthRetype},
+		{"CHAR(100)", "VARCHAR(100)", LengthRetype},
+		{"CHAR(10)", "VARCHAR(20)", LengthRetype},
+		{"VARCHAR(100)", "VARCHAR(100)", ""},
+		{"CHAR", "CHAR(1)", ""},
+		{"INT", "BIGINT", ""},
+		{"VARCHAR(100)", "INT", ""},
+		{"VARCHAR(100)", "VARBINARY(100)", ""},
+		{"NVARCHAR(100)", "NVARCHAR(MAX)", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.current+" to "+tt.desired, func(t *testing.T) {
+			if got := lengthChange(tt.current, tt.desired); got != tt.want {
+				t.Errorf("lengthChange(%q, %q) = %q, want %q", tt.current, tt.desired, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPlanLengthClassification memastikan klasifikasi panjang sampai ke plan:
+// hanya narrowing yang destructive, dan ringkasan memuat catatannya
+func TestPlanLengthClassification(t *testing.T) {
+	tests := []struct {
+		desired     string
+		length      string
+		destructive bool
+		note        string
+	}{
+		{"VARCHAR(255)", LengthWiden, false, "(widening)"},
+		{"VARCHAR(50)", LengthNarrow, true, "(narrowing, values may be truncated)"},
+		{"CHAR(100)", LengthRetype, false, "(fixed-length types pad values with spaces)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desired, func(t *testing.T) {
+			table := func(sqlType string) *state.SchemaState {
+				return schemaOf(state.Table{Name: "users", Columns: map[string]state.Column{
+					"id":   {Name: "id", Type: "INT", Position: 1},
+					"name": {Name: "name", Type: sqlType, Nullable: true, Position: 2},
+				}})
+			}
+			changes, err := NewGenerator(&Config{Dialect: DialectPostgres}).Changes(table("VARCHAR(100)"), table(tt.desired))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(changes) != 1 || changes[0].Kind != ChangeModifyColumn {
+				t.Fatalf("Changes() = %+v, want one modify_column", changes)
+			}
+			change := changes[0]
+			if change.Length != tt.length || change.Destructive != tt.destructive {
+				t.Errorf("Length = %q, Destructive = %v; want %q, %v", change.Length, change.Destructive, tt.length, tt.destructive)
+			}
+			if !strings.HasSuffix(change.Detail, tt.note) {
+				t.Errorf("Detail = %q, want the note %q", change.Detail, tt.note)
+			}
+		})
+	}
+}
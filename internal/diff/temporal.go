@@ -0,0 +1,95 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// maxTimePrecision adalah jumlah digit fractional seconds maksimum (mikrodetik)
+const maxTimePrecision = 6
+
+// timePrecision memecah tipe waktu menjadi tipe dasar, precision dan sisa
+// tipenya, mis. "timestamp(3) with time zone" menjadi ("timestamp", 3, " with time zone").
+// precision bernilai -1 jika tidak ditulis.
+func timePrecision(sqlType string) (base string, precision int, suffix string, ok bool) {
+	t := strings.TrimSpace(sqlType)
+	end := strings.IndexAny(t, "( ")
+	if end == -1 {
+		end = len(t)
+	}
+	base, rest := t[:end], t[end:]
+	switch strings.ToLower(base) {
+	case "datetime", "timestamp", "time":
+	default:
+		return "", 0, "", false
+	}
+
+	precision = -1
+	if strings.HasPrefix(rest, "(") {
+		closing := strings.Index(rest, ")")
+		if closing == -1 {
+			return "", 0, "", false
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(rest[1:closing]))
+		if err != nil {
+			return "", 0, "", false
+		}
+		precision, rest = n, rest[closing+1:]
+	}
+	return base, precision, rest, true
+}
+
+// defaultTimePrecision adalah precision yang dipakai database jika tidak ditulis
+func (g *Generator) defaultTimePrecision() int {
+	if g.postgresSyntax() {
+		return 6
+	}
+	return 0
+}
+
+// normalizeTimePrecision membuang precision yang sama dengan default dialect,
+// sehingga DATETIME dan DATETIME(0) (atau timestamp dan timestamp(6) di
+// Postgres) tidak dianggap berubah
+func (g *Generator) normalizeTimePrecision(sqlType string) string {
+	base, precision, suffix, ok := timePrecision(sqlType)
+	if !ok || precision != g.defaultTimePrecision() {
+		return sqlType
+	}
+	return base + suffix
+}
+
+// timePrecisionDefault menyamakan precision default CURRENT_TIMESTAMP dengan
+// kolomnya di MySQL, yang menolak DATETIME(6) DEFAULT CURRENT_TIMESTAMP
+func (g *Generator) timePrecisionDefault(col state.Column, def *state.DefaultValue) *state.DefaultValue {
+	if def == nil || def.Kind != state.DefaultKeyword || g.config.Dialect != DialectMySQL {
+		return def
+	}
+	_, precision, _, ok := timePrecision(col.Type)
+	if !ok || precision <= 0 || strings.Contains(def.Value, "(") {
+		return def
+	}
+	switch def.Value {
+	case "CURRENT_TIMESTAMP", "LOCALTIMESTAMP", "LOCALTIME", "CURRENT_TIME":
+		return &state.DefaultValue{Kind: state.DefaultKeyword, Value: fmt.Sprintf("%s(%d)", def.Value, precision)}
+	case "NOW()":
+		return &state.DefaultValue{Kind: state.DefaultKeyword, Value: fmt.Sprintf("NOW(%d)", precision)}
+	}
+	return def
+}
+
+// validateTimePrecision memastikan fractional seconds berada di antara 0 dan 6
+func validateTimePrecision(tableName string, col state.Column) error {
+	_, precision, _, ok := timePrecision(col.Type)
+	if !ok || precision <= maxTimePrecision {
+		return nil
+	}
+	return &ValidationError{
+		Table:  tableName,
+		Column: col.Name,
+		Rule:   "time-precision",
+		Detail: fmt.Sprintf("%s precision must be between 0 and %d", col.Type, maxTimePrecision),
+	}
+}
@@ -0,0 +1,15 @@
+-- migrate:up
+ALTER TABLE `users`
+  MODIFY COLUMN `nickname` VARCHAR(50),
+  ADD COLUMN `name` VARCHAR(100) NOT NULL DEFAULT '',
+  DROP INDEX `idx_users_nickname`,
+  DROP COLUMN `legacy`,
+  ADD UNIQUE INDEX `uni_users_email` (`email`);
+
+-- migrate:down
+ALTER TABLE `users`
+  MODIFY COLUMN `nickname` VARCHAR(100),
+  ADD COLUMN `legacy` TEXT,
+  DROP INDEX `uni_users_email`,
+  DROP COLUMN `name`,
+  ADD INDEX `idx_users_nickname` (`nickname`);
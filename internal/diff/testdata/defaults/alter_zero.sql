@@ -0,0 +1,21 @@
+-- migrate:up
+ALTER TABLE `users` MODIFY COLUMN `nickname` VARCHAR(50);
+
+ALTER TABLE `users` ADD COLUMN `name` VARCHAR(100) NOT NULL DEFAULT '';
+
+DROP INDEX `idx_users_nickname` ON `users`;
+
+ALTER TABLE `users` DROP COLUMN `legacy`;
+
+CREATE UNIQUE INDEX `uni_users_email` ON `users` (`email`);
+
+-- migrate:down
+ALTER TABLE `users` MODIFY COLUMN `nickname` VARCHAR(100);
+
+ALTER TABLE `users` ADD COLUMN `legacy` TEXT;
+
+DROP INDEX `uni_users_email` ON `users`;
+
+ALTER TABLE `users` DROP COLUMN `name`;
+
+CREATE INDEX `idx_users_nickname` ON `users` (`nickname`);
@@ -0,0 +1,29 @@
+-- migrate:up
+CREATE TABLE `users` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `email` VARCHAR(255) NOT NULL,
+  `nickname` VARCHAR(100),
+  `legacy` TEXT,
+  PRIMARY KEY (`id`)
+);
+
+CREATE INDEX `idx_users_nickname` ON `users` (`nickname`);
+
+CREATE TABLE `posts` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `user_id` BIGINT NOT NULL,
+  `title` VARCHAR(200) NOT NULL,
+  PRIMARY KEY (`id`),
+  FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_posts_user_id` ON `posts` (`user_id`);
+
+-- migrate:down
+DROP INDEX `idx_posts_user_id` ON `posts`;
+
+DROP TABLE `posts`;
+
+DROP INDEX `idx_users_nickname` ON `users`;
+
+DROP TABLE `users`;
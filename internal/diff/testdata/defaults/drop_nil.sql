@@ -0,0 +1,15 @@
+-- migrate:up
+DROP INDEX `idx_posts_user_id` ON `posts`;
+
+DROP TABLE `posts`;
+
+-- migrate:down
+CREATE TABLE `posts` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `user_id` BIGINT NOT NULL,
+  `title` VARCHAR(200) NOT NULL,
+  PRIMARY KEY (`id`),
+  FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE INDEX `idx_posts_user_id` ON `posts` (`user_id`);
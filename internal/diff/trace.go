@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Tracer menerima artefak setiap tahap transformasi, mis. untuk debug bundle.
+// artifact berupa string (output mentah) atau *state.SchemaState; nilainya
+// belum disamarkan, penerima yang menyamarkan kolom sensitif.
+type Tracer interface {
+	Trace(name string, artifact interface{})
+}
+
+// Nama artefak yang dilaporkan ke Tracer, sesuai urutan generate
+const (
+	TraceRawOutput          = "raw_output.txt"
+	TraceSanitizedOutput    = "sanitized_output.txt"
+	TraceParsedSchema       = "parsed_schema.json"
+	TraceSnapshot           = "snapshot.json"
+	TraceNormalizedSnapshot = "normalized_snapshot.json"
+	TraceNormalizedSchema   = "normalized_schema.json"
+	TracePlan               = "plan.json"
+	TraceMigration          = "migration.sql"
+)
+
+// SetTracer memasang Tracer untuk bentuk ternormalisasi snapshot dan schema
+// yang dibandingkan GenerateStatements; nil menonaktifkannya
+func (g *Generator) SetTracer(tracer Tracer) {
+	g.tracer = tracer
+}
+
+// trace melaporkan artefak ke Tracer jika terpasang. Down migration tidak
+// dilaporkan karena current dan desired-nya tertukar.
+func (g *Generator) trace(name string, artifact interface{}) {
+	if g.tracer != nil && !g.down {
+		g.tracer.Trace(name, artifact)
+	}
+}
+
+// SensitiveColumns mengembalikan nama kolom (huruf kecil) yang sensitif di
+// salah satu schema: bertag sensitive, bertanda Sensitive, atau cocok dengan
+// SensitivePatterns
+func (g *Generator) SensitiveColumns(schemas ...*state.SchemaState) map[string]bool {
+	names := make(map[string]bool)
+	for _, schema := range schemas {
+		if schema == nil {
+			continue
+		}
+		for _, table := range schema.Tables {
+			for _, col := range table.Columns {
+				if g.sensitiveColumn(col) {
+					names[strings.ToLower(col.Name)] = true
+				}
+			}
+		}
+	}
+	return names
+}
+
+// RedactState mengembalikan salinan schema dengan default dan tag
+// default/comment/seed kolom sensitif diganti state.Redacted
+func (g *Generator) RedactState(schema *state.SchemaState) *state.SchemaState {
+	if schema == nil {
+		return nil
+	}
+	redacted := schema.Clone()
+	for name, table := range redacted.Tables {
+		for colName, col := range table.Columns {
+			if !g.sensitiveColumn(col) {
+				continue
+			}
+			if col.DefaultValue != nil {
+				col.DefaultValue = &state.DefaultValue{Kind: state.DefaultString, Value: state.Redacted}
+			}
+			if col.Tags != nil {
+				tags := make(map[string]string, len(col.Tags))
+				for key, value := range col.Tags {
+					switch key {
+					case "default", "comment", "seed":
+						value = state.Redacted
+					}
+					tags[key] = value
+				}
+				col.Tags = tags
+			}
+			table.Columns[colName] = col
+		}
+		redacted.Tables[name] = table
+	}
+	return redacted
+}
+
+// sensitiveColumn mengecek apakah kolom sensitif sebelum maupun sesudah applyTags
+func (g *Generator) sensitiveColumn(col state.Column) bool {
+	_, tagged := col.Tags["sensitive"]
+	return col.Sensitive || tagged || g.isSensitive(col.Name)
+}
@@ -0,0 +1,142 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// blobTypes adalah kelas BLOB yang valid di MySQL
+var blobTypes = map[string]bool{
+	"TINYBLOB":   true,
+	"BLOB":       true,
+	"MEDIUMBLOB": true,
+	"LONGBLOB":   true,
+}
+
+// validate memeriksa schema yang akan dirender sebelum statement dibuat.
+// current dipakai untuk aturan yang hanya berlaku pada kolom baru.
+func (g *Generator) validate(current, schema *state.SchemaState) error {
+	if err := g.validateClassification(current, schema); err != nil {
+		return err
+	}
+	if err := g.validateNotNullAdds(current, schema); err != nil {
+		return err
+	}
+	for _, table := range sortedTables(schema.Tables) {
+		g.warnDuplicateIndexes(table)
+		if err := g.validateSpatialIndexes(table); err != nil {
+			return err
+		}
+		for _, col := range sortedColumns(table.Columns) {
+			if err := g.validateColumnType(table.Name, col); err != nil {
+				return err
+			}
+			if err := validateTimePrecision(table.Name, col); err != nil {
+				return err
+			}
+			if err := g.validateCollation(table.Name, col); err != nil {
+				return err
+			}
+			if err := g.validateJSONDefault(table.Name, col); err != nil {
+				return err
+			}
+			if err := g.validateMask(table.Name, col); err != nil {
+				return err
+			}
+			if err := g.validateDiffPolicy(table.Name, col); err != nil {
+				return err
+			}
+			if err := g.validateTagKeys(table.Name, col); err != nil {
+				return err
+			}
+		}
+	}
+	if err := g.validateCockroach(schema); err != nil {
+		return err
+	}
+	g.validateTableOptions(schema)
+	return g.validateForeignKeyTypes(schema)
+}
+
+// validateTagKeys melaporkan key tag kolom yang tidak dikenal, mis. "notnul",
+// sebagai peringatan atau ValidationError jika StrictTags diset
+func (g *Generator) validateTagKeys(tableName string, col state.Column) error {
+	for _, msg := range state.UnknownTagKeys(col.Tags, state.TagKeys) {
+		if g.config.StrictTags {
+			return &ValidationError{Table: tableName, Column: col.Name, Rule: "tag", Detail: msg}
+		}
+		g.warnings.Add("unknown-tag", tableName, col.Name, "db tag: %s", msg)
+	}
+	return nil
+}
+
+// validateColumnType memeriksa nama dan panjang tipe biner (BINARY, VARBINARY, BLOB)
+func (g *Generator) validateColumnType(tableName string, col state.Column) error {
+	if g.config.Dialect != DialectMySQL {
+		return nil
+	}
+
+	t := strings.ToUpper(strings.TrimSpace(col.Type))
+	base, length, hasLength := t, 0, false
+	if open := strings.Index(t, "("); open != -1 && strings.HasSuffix(t, ")") {
+		base = strings.TrimSpace(t[:open])
+		n, err := strconv.Atoi(strings.TrimSpace(t[open+1 : len(t)-1]))
+		length, hasLength = n, err == nil
+	}
+
+	invalid := func(detail string) error {
+		return &ValidationError{Table: tableName, Column: col.Name, Rule: "binary-type", Detail: detail}
+	}
+	switch {
+	case base == "VARBINARY":
+		if !hasLength || length < 1 || length > 65535 {
+			return invalid(fmt.Sprintf("%s requires a length between 1 and 65535 (use length=N)", col.Type))
+		}
+	case base == "BINARY":
+		if hasLength && (length < 0 || length > 255) {
+			return invalid(fmt.Sprintf("%s length must be between 0 and 255", col.Type))
+		}
+	case strings.HasSuffix(base, "BLOB"):
+		if !blobTypes[base] {
+			return invalid(fmt.Sprintf("unknown BLOB type %s, use TINYBLOB, BLOB, MEDIUMBLOB or LONGBLOB", col.Type))
+		}
+	}
+	return nil
+}
+
+// isBinaryType mengecek apakah tipe kolom termasuk keluarga BINARY/VARBINARY/BLOB
+func isBinaryType(sqlType string) bool {
+	t := strings.ToUpper(strings.TrimSpace(sqlType))
+	if open := strings.Index(t, "("); open != -1 {
+		t = strings.TrimSpace(t[:open])
+	}
+	return t == "BINARY" || t == "VARBINARY" || strings.HasSuffix(t, "BLOB")
+}
+
+// warnDuplicateIndexes memperingatkan index yang identik di semua atribut
+// (kolom, unique, spatial, prefix length, include) tetapi berbeda nama. Index dengan
+// kolom sama dan atribut lain berbeda, mis. unique dan non-unique, tetap
+// dianggap sah. Keduanya tetap dirender apa adanya.
+func (g *Generator) warnDuplicateIndexes(table state.Table) {
+	seen := make(map[string]string)
+	for _, idx := range sortedIndexes(table.Indexes) {
+		key := indexSignature(idx)
+		if first, ok := seen[key]; ok {
+			g.warnings.Add("duplicate-index", table.Name, "", "index %s duplicates %s", idx.Name, first)
+			continue
+		}
+		seen[key] = idx.Name
+	}
+}
+
+// indexSignature merangkum semua atribut index kecuali namanya
+func indexSignature(idx state.Index) string {
+	parts := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		parts[i] = fmt.Sprintf("%s(%d)", col, idx.Lengths[col])
+	}
+	return fmt.Sprintf("%s|%t|%t|%s|%d", strings.Join(parts, ","), idx.Unique, idx.Spatial, strings.Join(idx.Include, ","), idx.Buckets)
+}
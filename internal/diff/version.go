@@ -0,0 +1,192 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Flavor server database untuk migration.server_version
+const (
+	FlavorMySQL     = "mysql"
+	FlavorMariaDB   = "mariadb"
+	FlavorPostgres  = "postgres"
+	FlavorMSSQL     = "mssql"
+	FlavorCockroach = "cockroach"
+)
+
+// ServerVersion adalah server database target, mis. mysql 8.0 atau mariadb
+// 10.6. Nilai kosong berarti versi tidak diketahui dan semua fitur dianggap
+// didukung, sesuai perilaku sebelum server_version ada.
+type ServerVersion struct {
+	Flavor string
+	Major  int
+	Minor  int
+	// Patch -1 berarti rilis patch terbaru dari Major.Minor
+	Patch int
+}
+
+// Feature adalah sintaks yang dukungannya bergantung pada versi server
+type Feature string
+
+const (
+	// FeatureCheckConstraint adalah CHECK constraint yang benar-benar ditegakkan
+	FeatureCheckConstraint Feature = "CHECK constraints"
+	// FeatureInstantAddColumn adalah ADD COLUMN dengan ALGORITHM=INSTANT
+	FeatureInstantAddColumn Feature = "instant ADD COLUMN"
+	// FeatureCollation0900 adalah collation utf8mb4_0900_*, default MySQL 8.0
+	FeatureCollation0900 Feature = "utf8mb4_0900 collations"
+	// FeatureJSONDefault adalah DEFAULT pada kolom JSON; MySQL 8.0.13 ke atas
+	// hanya menerimanya sebagai ekspresi dalam kurung
+	FeatureJSONDefault Feature = "DEFAULT on JSON columns"
+	// FeatureForeignKeyActions adalah ON DELETE/ON UPDATE CASCADE, SET NULL
+	// dan SET DEFAULT; CockroachDB sebelum 2.0 hanya menerima RESTRICT dan
+	// NO ACTION
+	FeatureForeignKeyActions Feature = "foreign key CASCADE/SET NULL/SET DEFAULT actions"
+	// FeatureBucketCountParam adalah WITH (bucket_count = N) pada hash-sharded
+	// index CockroachDB 22.1 ke atas; versi lama memakai WITH BUCKET_COUNT = N
+	FeatureBucketCountParam Feature = "hash-sharded index storage parameter"
+)
+
+// capabilities adalah versi minimum per flavor untuk setiap fitur. Flavor
+// yang tidak tercantum tidak mendukung fitur tersebut.
+var capabilities = map[Feature]map[string]ServerVersion{
+	FeatureCheckConstraint: {
+		FlavorMySQL:     {Major: 8, Minor: 0, Patch: 16},
+		FlavorMariaDB:   {Major: 10, Minor: 2, Patch: 1},
+		FlavorPostgres:  {},
+		FlavorMSSQL:     {},
+		FlavorCockroach: {},
+	},
+	FeatureInstantAddColumn: {
+		FlavorMySQL:   {Major: 8, Minor: 0, Patch: 12},
+		FlavorMariaDB: {Major: 10, Minor: 3, Patch: 2},
+	},
+	FeatureCollation0900: {
+		FlavorMySQL: {Major: 8, Minor: 0, Patch: 1},
+	},
+	FeatureJSONDefault: {
+		FlavorMySQL:     {Major: 8, Minor: 0, Patch: 13},
+		FlavorMariaDB:   {Major: 10, Minor: 2, Patch: 1},
+		FlavorPostgres:  {},
+		FlavorMSSQL:     {},
+		FlavorCockroach: {},
+	},
+	FeatureForeignKeyActions: {
+		FlavorMySQL:     {},
+		FlavorMariaDB:   {},
+		FlavorPostgres:  {},
+		FlavorMSSQL:     {},
+		FlavorCockroach: {Major: 2, Minor: 0, Patch: 0},
+	},
+	FeatureBucketCountParam: {
+		FlavorCockroach: {Major: 22, Minor: 1, Patch: 0},
+	},
+}
+
+// ParseServerVersion membaca "flavor:major.minor[.patch]", mis. "mysql:8.0"
+// atau "mariadb:10.6". Tanpa patch, versi dianggap rilis patch terbaru.
+// String kosong menghasilkan ServerVersion kosong.
+func ParseServerVersion(s string) (ServerVersion, error) {
+	if strings.TrimSpace(s) == "" {
+		return ServerVersion{}, nil
+	}
+	flavor, number, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return ServerVersion{}, fmt.Errorf("%q must look like mysql:8.0, mariadb:10.6, postgres:16 or cockroach:23.2", s)
+	}
+	v := ServerVersion{Flavor: strings.ToLower(strings.TrimSpace(flavor))}
+	switch v.Flavor {
+	case FlavorMySQL, FlavorMariaDB, FlavorPostgres, FlavorMSSQL, FlavorCockroach:
+	default:
+		return ServerVersion{}, fmt.Errorf("unknown server flavor %q, use mysql, mariadb, postgres, mssql or cockroach", flavor)
+	}
+
+	parts := strings.Split(strings.TrimSpace(number), ".")
+	if len(parts) > 3 {
+		return ServerVersion{}, fmt.Errorf("invalid version %q", number)
+	}
+	v.Patch = -1
+	fields := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return ServerVersion{}, fmt.Errorf("invalid version %q", number)
+		}
+		*fields[i] = n
+	}
+	return v, nil
+}
+
+// Known mengecek apakah versi server diisi
+func (v ServerVersion) Known() bool {
+	return v.Flavor != ""
+}
+
+// Dialect mengembalikan dialect generator untuk flavor server
+func (v ServerVersion) Dialect() string {
+	switch v.Flavor {
+	case FlavorPostgres:
+		return DialectPostgres
+	case FlavorMSSQL:
+		return DialectMSSQL
+	case FlavorCockroach:
+		return DialectCockroach
+	}
+	return DialectMySQL
+}
+
+// Supports mengecek apakah server mendukung feature. Versi yang tidak
+// diketahui dianggap mendukung semua fitur.
+func (v ServerVersion) Supports(feature Feature) bool {
+	if !v.Known() {
+		return true
+	}
+	min, ok := capabilities[feature][v.Flavor]
+	return ok && v.atLeast(min)
+}
+
+// atLeast mengecek apakah v sama dengan atau lebih baru dari min
+func (v ServerVersion) atLeast(min ServerVersion) bool {
+	if v.Major != min.Major {
+		return v.Major > min.Major
+	}
+	if v.Minor != min.Minor {
+		return v.Minor > min.Minor
+	}
+	return v.Patch < 0 || v.Patch >= min.Patch
+}
+
+func (v ServerVersion) String() string {
+	if !v.Known() {
+		return "unknown server"
+	}
+	s := fmt.Sprintf("%s %d.%d", v.Flavor, v.Major, v.Minor)
+	if v.Patch >= 0 {
+		s += fmt.Sprintf(".%d", v.Patch)
+	}
+	return s
+}
+
+// supports mengecek fitur terhadap Config.ServerVersion
+func (g *Generator) supports(feature Feature) bool {
+	return g.config.ServerVersion.Supports(feature)
+}
+
+// is0900Collation mengecek collation keluarga utf8mb4_0900_*
+func is0900Collation(collation string) bool {
+	return strings.Contains(strings.ToLower(collation), "_0900_")
+}
+
+// unsupportedConstraint mengembalikan komentar pengganti jika constraint tidak
+// didukung server target, mis. CHECK di MySQL 5.7 yang diterima parser tapi
+// tidak ditegakkan dan tidak bisa di-drop
+func (g *Generator) unsupportedConstraint(constraint state.Constraint) string {
+	if constraint.Type != "CHECK" || g.supports(FeatureCheckConstraint) {
+		return ""
+	}
+	return fmt.Sprintf("-- datara: %s does not support %s; skipped %s",
+		g.config.ServerVersion, FeatureCheckConstraint, g.formatConstraint(constraint.Def))
+}
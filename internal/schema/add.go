@@ -0,0 +1,182 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Addition adalah migration dari luar datara (Atlas, SQL tulisan tangan) yang
+// didaftarkan dengan 'datara add'
+type Addition struct {
+	// Body adalah isi migration yang ditulis ke direktori migration. File
+	// tanpa marker dbmate seluruhnya dijadikan bagian up.
+	Body string
+	// Before adalah snapshot terakhir dan After hasil menjalankan ulang bagian
+	// up Body di atasnya
+	Before, After *state.SchemaState
+	// Skipped adalah statement yang tidak dipahami atau gagal diterapkan
+	// ke snapshot; jika ada, snapshot tidak dimajukan
+	Skipped []SkippedStatement
+
+	// snapshot dan hash disimpan oleh ApplyAddition; snapshot nil berarti
+	// snapshot tidak berubah
+	snapshot *state.SchemaState
+	hash     string
+	// refreshed menandai snapshot yang diambil dari schema program
+	refreshed bool
+}
+
+// PrepareAddition memvalidasi migration sql bernama name dan menjalankan
+// ulang bagian up-nya di atas snapshot terakhir, seperti doctor
+func (e *Executor) PrepareAddition(name, sql string) (*Addition, error) {
+	// Baris yang dilaporkan tetap mengacu ke file asli
+	shift := 0
+	if !strings.Contains(sql, migrateUpMarker) {
+		sql = fmt.Sprintf("%s\n%s\n\n%s\n", migrateUpMarker, strings.TrimRight(sql, "\n"), migrateDownMarker)
+		shift = 1
+	}
+	upStart, upEnd := upSection(sql)
+	spans := splitStatementSpans(sql[upStart:upEnd])
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("%s has no statements in its up section", name)
+	}
+	for _, span := range spans {
+		if problem := unbalanced(span.Text); problem != "" {
+			line := strings.Count(sql[:upStart+span.Start], "\n") + 1 - shift
+			return nil, fmt.Errorf("failed to parse %s:%d: %s", name, line, problem)
+		}
+	}
+
+	before, err := e.loadSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema snapshot: %w", err)
+	}
+	r := newReplay()
+	r.schema = before.Clone()
+	for _, span := range spans {
+		line := strings.Count(sql[:upStart+span.Start], "\n") + 1 - shift
+		location := fmt.Sprintf("%s:%d", name, line)
+		if problem, _ := r.apply(normalizeDefinition(span.Text), location); problem != "" {
+			r.skipped = append(r.skipped, SkippedStatement{Location: location, Statement: span.Text, Problem: problem})
+		}
+	}
+
+	upperAddedTypes(before, r.schema)
+	a := &Addition{Body: sql, Before: before, After: r.schema, Skipped: r.skipped}
+	if a.Advanced() {
+		a.snapshot = a.After
+	}
+	return a, nil
+}
+
+// upperAddedTypes menulis tipe kolom yang ditambah atau diubah migration
+// dengan huruf besar seperti output schema program, agar tipe dari tool lain
+// (mis. "text" dari Atlas) tidak dianggap perubahan oleh generate berikutnya.
+// Tipe dengan literal (enum) dibiarkan.
+func upperAddedTypes(before, after *state.SchemaState) {
+	for name, table := range after.Tables {
+		for colName, col := range table.Columns {
+			if old, ok := before.Tables[name].Columns[colName]; ok && old.Type == col.Type {
+				continue
+			}
+			if !strings.Contains(col.Type, "'") {
+				col.Type = strings.ToUpper(col.Type)
+				table.Columns[colName] = col
+			}
+		}
+	}
+}
+
+// unbalanced mengembalikan masalah sintaks yang membuat statement pasti
+// gagal: quote yang tidak ditutup atau kurung yang tidak seimbang
+func unbalanced(stmt string) string {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '$' && dollarTag(stmt[i:]) != "":
+			// Body fungsi Postgres dilewati seperti di splitStatementSpans
+			tag := dollarTag(stmt[i:])
+			end := strings.Index(stmt[i+len(tag):], tag)
+			if end == -1 {
+				return "unterminated " + tag + " quote"
+			}
+			i += len(tag) + end + len(tag) - 1
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth < 0 {
+				return "unexpected )"
+			}
+		}
+	}
+	switch {
+	case quote != 0:
+		return fmt.Sprintf("unterminated %c quote", quote)
+	case depth > 0:
+		return "unclosed ("
+	}
+	return ""
+}
+
+// Advanced mengecek apakah semua statement bisa diterapkan, sehingga
+// snapshot dimajukan ke After
+func (a *Addition) Advanced() bool {
+	return len(a.Skipped) == 0
+}
+
+// Refreshed mengecek apakah snapshot diambil dari schema program dengan
+// RefreshAddition
+func (a *Addition) Refreshed() bool {
+	return a.refreshed
+}
+
+// RefreshAddition mengganti snapshot yang akan disimpan ApplyAddition dengan
+// output schema program, mis. jika migration tidak bisa dijalankan ulang
+func (e *Executor) RefreshAddition(ctx context.Context, a *Addition) error {
+	plan, err := e.PlanContext(ctx)
+	switch {
+	case errors.Is(err, ErrNoChanges):
+		// Hash schema sama, snapshot terakhir sudah sesuai schema program
+		a.snapshot, a.hash = a.Before, e.SnapshotHash()
+	case err != nil:
+		return err
+	default:
+		a.snapshot, a.hash = plan.Desired, plan.hash
+	}
+	a.refreshed = true
+	return nil
+}
+
+// ApplyAddition menyimpan snapshot addition dan mengembalikan isi file
+// migration. Pada mode embedded snapshot ditulis ke trailer migration;
+// datara.snapshots dan datara.sum dicatat pemanggil seperti pada generate.
+func (e *Executor) ApplyAddition(a *Addition) (string, error) {
+	if e.embedded != "" {
+		trailer := Trailer{From: StateHash(a.Before), To: StateHash(a.After), SchemaHash: a.hash}
+		if a.snapshot != nil {
+			a.snapshot.Version = state.FormatVersion
+			trailer.Snapshot = a.snapshot
+		}
+		return withTrailer(a.Body, trailer)
+	}
+	if a.snapshot != nil {
+		// Tanpa hash schema program, generate berikutnya selalu diff
+		plan := &Plan{Desired: a.snapshot, hash: a.hash, partial: a.hash == ""}
+		if err := e.saveSchemaState(plan); err != nil {
+			return "", fmt.Errorf("failed to save schema state: %w", err)
+		}
+	}
+	return a.Body, nil
+}
@@ -0,0 +1,135 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// cacheDir menyimpan output schema program per cache key
+const cacheDir = ".datara/cache"
+
+// EnableCache menyimpan output schema program di .datara/cache dan memakainya
+// lagi selama argumen program, file Go di module program, versi datara dan
+// keyParts (mis. opsi config yang memengaruhi pemrosesan) tidak berubah.
+// Program di luar module Go (python, script) tidak di-cache: file yang
+// di-import-nya tidak diketahui, sehingga perubahannya tidak terdeteksi.
+func (e *Executor) EnableCache(keyParts ...string) {
+	e.cache = true
+	e.cacheKeyParts = keyParts
+}
+
+// cachedOutput membaca output program dari cache untuk key
+func (e *Executor) cachedOutput(key string) (string, bool) {
+	content, err := os.ReadFile(e.path(filepath.Join(cacheDir, key)))
+	if err != nil {
+		return "", false
+	}
+	return string(content), true
+}
+
+// storeOutput menulis output program ke cache. Kegagalan hanya dicatat
+// karena cache tidak boleh menggagalkan diff.
+func (e *Executor) storeOutput(key, output string) {
+	dir := e.path(cacheDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("Failed to create cache directory: %v", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, key), []byte(output), 0644); err != nil {
+		log.Printf("Failed to write schema cache: %v", err)
+	}
+}
+
+// cacheKey menghitung hash dari argumen program, versi datara, keyParts, isi
+// file program itu sendiri, serta go.mod, go.sum dan file .go di module Go
+// tempat program berada. Key kosong berarti program tidak berada di module Go
+// dan output-nya tidak boleh di-cache.
+func (e *Executor) cacheKey(programFile string) (string, error) {
+	root, ok := moduleRoot(filepath.Dir(programFile))
+	if !ok {
+		return "", nil
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "program:%q\n", e.program)
+	fmt.Fprintf(h, "datara:%s\n", buildVersion())
+	for _, part := range e.cacheKeyParts {
+		fmt.Fprintf(h, "key:%s\n", part)
+	}
+
+	// File program sendiri, mis. binary hasil build-schema-program
+	if content, err := os.ReadFile(programFile); err == nil {
+		fmt.Fprintf(h, "main:%d\n", len(content))
+		h.Write(content)
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") && name != "go.mod" && name != "go.sum" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fmt.Fprintf(h, "file:%s\n", filepath.ToSlash(rel))
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash schema program sources: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// moduleRoot mencari direktori go.mod terdekat di atas dir
+func moduleRoot(dir string) (string, bool) {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, "go.mod")); err == nil {
+			return current, true
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", false
+		}
+		current = parent
+	}
+}
+
+// buildVersion mengembalikan versi binary datara: versi module dan revisi VCS
+// jika tersedia, sehingga upgrade datara selalu membuang cache lama
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" || setting.Key == "vcs.modified" {
+			version += " " + setting.Value
+		}
+	}
+	return version
+}
@@ -0,0 +1,64 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFile menulis content ke dir/name
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCacheKeyOutsideGoModule(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok := moduleRoot(dir); ok {
+		t.Skip("the temporary directory is inside a Go module")
+	}
+	program := writeTestFile(t, dir, "schema.py", "from models import *\n")
+	e := NewExecutor([]string{"python3", program}, dir, nil)
+	key, err := e.cacheKey(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "" {
+		t.Errorf("cacheKey() = %q, want no key for a program outside a Go module", key)
+	}
+}
+
+func TestCacheKeyTracksModuleSources(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "go.mod", "module example.com/app\n")
+	models := writeTestFile(t, dir, "models/user.go", "package models\n\ntype User struct{ ID int }\n")
+	program := writeTestFile(t, dir, "cmd/register/main.go", "package main\n\nfunc main() {}\n")
+	e := NewExecutor([]string{"go", "run", program}, dir, nil)
+
+	before, err := e.cacheKey(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before == "" {
+		t.Fatal("cacheKey() is empty inside a Go module")
+	}
+	if again, _ := e.cacheKey(program); again != before {
+		t.Errorf("cacheKey() changed without source changes: %s, then %s", before, again)
+	}
+
+	writeTestFile(t, dir, "models/user.go", "package models\n\ntype User struct{ ID, Age int }\n")
+	after, err := e.cacheKey(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after == before {
+		t.Errorf("cacheKey() did not change after %s changed", models)
+	}
+}
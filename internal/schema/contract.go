@@ -0,0 +1,517 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// ContractVersion adalah versi dokumen Schema JSON yang diterima dari schema
+// program, sama dengan format snapshot. Dokumen dengan version lain ditolak.
+const ContractVersion = state.FormatVersion
+
+// ContractError adalah satu pelanggaran contract beserta lokasinya di dokumen,
+// mis. "tables.users.columns.email.type"
+type ContractError struct {
+	Path    string
+	Message string
+}
+
+func (e ContractError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ContractErrors adalah semua pelanggaran contract dalam satu dokumen
+type ContractErrors []ContractError
+
+func (e ContractErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// IsContract mengecek apakah output schema program berupa dokumen Schema JSON,
+// bukan SQL
+func IsContract(output string) bool {
+	return strings.HasPrefix(strings.TrimSpace(output), "{")
+}
+
+// DecodeContract memvalidasi dokumen Schema JSON lalu mengembalikan schema-nya
+// beserta peringatan untuk tabel yang ditulis dua kali dengan isi identik.
+// Error validasi bertipe ContractErrors.
+func DecodeContract(data []byte) (*state.SchemaState, state.Warnings, error) {
+	if err := ValidateContract(data); err != nil {
+		return nil, nil, err
+	}
+	warnings, _ := duplicateTables(data)
+	var schema state.SchemaState
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, nil, err
+	}
+	if schema.Tables == nil {
+		schema.Tables = make(map[string]state.Table)
+	}
+	for name, table := range schema.Tables {
+		if table.Columns == nil {
+			table.Columns = make(map[string]state.Column)
+		}
+		if table.Indexes == nil {
+			table.Indexes = make(map[string]state.Index)
+		}
+		if table.RawDDL != nil {
+			table.RawDDL = state.NewRawDDL(table.RawDDL.Up, table.RawDDL.Down)
+		}
+		table.Options = state.NormalizeTableOptions(table.Options)
+		schema.Tables[name] = declaredKeys(table)
+	}
+	return &schema, warnings, nil
+}
+
+// duplicateTables memeriksa key "tables" yang muncul lebih dari sekali, yang
+// oleh encoding/json diam-diam ditimpa entry terakhir. Entry dengan struktur
+// identik menghasilkan peringatan, yang berbeda menghasilkan ContractError.
+// Dokumen yang tidak bisa dibaca diabaikan; kesalahannya sudah dilaporkan
+// validasi lain.
+func duplicateTables(data []byte) (state.Warnings, ContractErrors) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil || doc["tables"] == nil {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(doc["tables"]))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, nil
+	}
+
+	tables := newTableSet(state.NewSchemaState())
+	var errs ContractErrors
+	for i := 1; decoder.More(); i++ {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil
+		}
+		name, _ := token.(string)
+		var table state.Table
+		if err := decoder.Decode(&table); err != nil {
+			return nil, nil
+		}
+		table.Name = name
+		if table.Columns == nil {
+			table.Columns = make(map[string]state.Column)
+		}
+		err = tables.add(declaredKeys(table), fmt.Sprintf("entry %d of tables", i))
+		var duplicate *DuplicateTableError
+		if errors.As(err, &duplicate) {
+			errs = append(errs, ContractError{Path: "tables." + name,
+				Message: fmt.Sprintf("defined twice with different definitions, by %s and by %s", duplicate.First, duplicate.Second)})
+		}
+	}
+	return tables.warnings, errs
+}
+
+// foreignKeyActions adalah nilai on_delete/on_update yang diterima contract
+var foreignKeyActions = map[string]bool{
+	"CASCADE":     true,
+	"RESTRICT":    true,
+	"SET NULL":    true,
+	"SET DEFAULT": true,
+	"NO ACTION":   true,
+}
+
+// declaredKeys mengubah primary_key dan foreign_keys menjadi Constraints.
+// Constraint yang dideklarasikan menggantikan primary key lain dan foreign
+// key lain pada kolom yang sama, termasuk yang disimpulkan dari tag.
+func declaredKeys(table state.Table) state.Table {
+	if len(table.PrimaryKey) > 0 {
+		var constraints []state.Constraint
+		for _, constraint := range table.Constraints {
+			if constraint.Type != "PRIMARY KEY" {
+				constraints = append(constraints, constraint)
+			}
+		}
+		table.Constraints = append(constraints, state.Constraint{
+			Name: fmt.Sprintf("pk_%s", table.Name),
+			Type: "PRIMARY KEY",
+			Def:  fmt.Sprintf("PRIMARY KEY (%s)", backtickList(table.PrimaryKey)),
+		})
+		for _, name := range table.PrimaryKey {
+			column := table.Columns[name]
+			column.Nullable = false
+			table.Columns[name] = column
+		}
+	}
+	for _, fk := range table.ForeignKeys {
+		name := fk.Name
+		if name == "" {
+			name = fmt.Sprintf("fk_%s_%s", table.Name, strings.Join(fk.Columns, "_"))
+		}
+		def := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES `%s` (%s)", backtickList(fk.Columns), fk.RefTable, backtickList(fk.RefColumns))
+		if fk.OnDelete != "" {
+			def += " ON DELETE " + strings.ToUpper(fk.OnDelete)
+		}
+		if fk.OnUpdate != "" {
+			def += " ON UPDATE " + strings.ToUpper(fk.OnUpdate)
+		}
+		constraints := table.Constraints
+		for _, column := range fk.Columns {
+			constraints = withoutForeignKeys(constraints, column)
+		}
+		table.Constraints = append(constraints, state.Constraint{Name: name, Type: "FOREIGN KEY", Def: def, NotValid: fk.NotValid})
+	}
+	table.PrimaryKey, table.ForeignKeys = nil, nil
+	return table
+}
+
+// backtickList menulis nama kolom sebagai `a`, `b`
+func backtickList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// ValidateContract memeriksa dokumen terhadap ContractSchema dan aturan yang
+// tidak bisa dinyatakan di JSON Schema (nama sama dengan key, kolom index,
+// primary key dan foreign key ada)
+func ValidateContract(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return ContractErrors{{Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+
+	errs := validateValue("", doc, ContractSchema())
+	if len(errs) == 0 {
+		errs = validateReferences(doc.(map[string]interface{}))
+	}
+	if len(errs) == 0 {
+		_, errs = duplicateTables(data)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateReferences memeriksa versi, kecocokan nama dengan key map, dan
+// kolom yang dipakai index, primary key dan foreign key
+func validateReferences(doc map[string]interface{}) ContractErrors {
+	var errs ContractErrors
+	if version := doc["version"]; version != ContractVersion {
+		errs = append(errs, ContractError{Path: "version", Message: fmt.Sprintf("unsupported version %v, want %q", version, ContractVersion)})
+	}
+
+	tables, _ := doc["tables"].(map[string]interface{})
+	for _, tableName := range sortedKeys(tables) {
+		table := tables[tableName].(map[string]interface{})
+		path := "tables." + tableName
+		if name := table["name"]; name != tableName {
+			errs = append(errs, ContractError{Path: path + ".name", Message: fmt.Sprintf("%q does not match its key %q", name, tableName)})
+		}
+
+		columns, _ := table["columns"].(map[string]interface{})
+		for _, columnName := range sortedKeys(columns) {
+			column := columns[columnName].(map[string]interface{})
+			if name := column["name"]; name != columnName {
+				errs = append(errs, ContractError{Path: path + ".columns." + columnName + ".name", Message: fmt.Sprintf("%q does not match its key %q", name, columnName)})
+			}
+			if message := emptyDefault(column["default_value"]); message != "" {
+				errs = append(errs, ContractError{Path: path + ".columns." + columnName + ".default_value", Message: message})
+			}
+		}
+
+		indexes, _ := table["indexes"].(map[string]interface{})
+		for _, indexName := range sortedKeys(indexes) {
+			index := indexes[indexName].(map[string]interface{})
+			indexPath := path + ".indexes." + indexName
+			if name := index["name"]; name != indexName {
+				errs = append(errs, ContractError{Path: indexPath + ".name", Message: fmt.Sprintf("%q does not match its key %q", name, indexName)})
+			}
+			for _, field := range []string{"columns", "include"} {
+				list, _ := index[field].([]interface{})
+				for i, column := range list {
+					if _, ok := columns[column.(string)]; !ok {
+						errs = append(errs, ContractError{Path: fmt.Sprintf("%s.%s[%d]", indexPath, field, i), Message: fmt.Sprintf("unknown column %q", column)})
+					}
+				}
+			}
+		}
+
+		primaryKey, _ := table["primary_key"].([]interface{})
+		for i, column := range primaryKey {
+			if _, ok := columns[column.(string)]; !ok {
+				errs = append(errs, ContractError{Path: fmt.Sprintf("%s.primary_key[%d]", path, i), Message: fmt.Sprintf("unknown column %q", column)})
+			}
+		}
+
+		foreignKeys, _ := table["foreign_keys"].([]interface{})
+		for i, fk := range foreignKeys {
+			errs = append(errs, validateForeignKey(fmt.Sprintf("%s.foreign_keys[%d]", path, i), fk.(map[string]interface{}), columns, tables)...)
+		}
+
+		options, _ := table["options"].(map[string]interface{})
+		for _, key := range sortedKeys(options) {
+			if !state.IsTableOptionKey(key) {
+				errs = append(errs, ContractError{Path: path + ".options." + key, Message: fmt.Sprintf("unknown table option, use one of %s", strings.Join(state.TableOptionKeys, ", "))})
+			}
+		}
+	}
+	return errs
+}
+
+// emptyDefault mengembalikan pesan jika default_value kosong tetapi bukan
+// string kosong atau NULL yang eksplisit, mis. "" atau {"kind":"expression"}.
+// Tanpa aturan ini default tersebut tidak bisa dibedakan dari "tidak ada
+// default".
+func emptyDefault(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return `is empty; write "''" for an empty string, "NULL" for NULL, or omit it for no default`
+		}
+	case map[string]interface{}:
+		kind, _ := v["kind"].(string)
+		text, _ := v["value"].(string)
+		if kind != string(state.DefaultString) && kind != string(state.DefaultNull) && strings.TrimSpace(text) == "" {
+			return fmt.Sprintf("value is empty for kind %q; use kind \"string\" for an empty string", kind)
+		}
+	}
+	return ""
+}
+
+// validateForeignKey memeriksa kolom, tabel dan kolom yang direferensikan,
+// serta aksi satu foreign key di contract
+func validateForeignKey(path string, fk map[string]interface{}, columns, tables map[string]interface{}) ContractErrors {
+	var errs ContractErrors
+	local, _ := fk["columns"].([]interface{})
+	remote, _ := fk["ref_columns"].([]interface{})
+	if len(local) == 0 {
+		errs = append(errs, ContractError{Path: path + ".columns", Message: "must list at least one column"})
+	}
+	if len(local) != len(remote) {
+		errs = append(errs, ContractError{Path: path + ".ref_columns", Message: fmt.Sprintf("has %d columns, want %d", len(remote), len(local))})
+	}
+	for i, column := range local {
+		if _, ok := columns[column.(string)]; !ok {
+			errs = append(errs, ContractError{Path: fmt.Sprintf("%s.columns[%d]", path, i), Message: fmt.Sprintf("unknown column %q", column)})
+		}
+	}
+	for _, field := range []string{"on_delete", "on_update"} {
+		if action, ok := fk[field].(string); ok && !foreignKeyActions[strings.ToUpper(action)] {
+			errs = append(errs, ContractError{Path: path + "." + field, Message: fmt.Sprintf("unknown action %q, use CASCADE, RESTRICT, SET NULL, SET DEFAULT or NO ACTION", action)})
+		}
+	}
+
+	refTable, ok := tables[fk["ref_table"].(string)].(map[string]interface{})
+	if !ok {
+		return append(errs, ContractError{Path: path + ".ref_table", Message: fmt.Sprintf("unknown table %q", fk["ref_table"])})
+	}
+	refColumns, _ := refTable["columns"].(map[string]interface{})
+	for i, column := range remote {
+		if _, ok := refColumns[column.(string)]; !ok {
+			errs = append(errs, ContractError{Path: fmt.Sprintf("%s.ref_columns[%d]", path, i), Message: fmt.Sprintf("unknown column %q of table %s", column, fk["ref_table"])})
+		}
+	}
+	return errs
+}
+
+// validateValue memeriksa value terhadap subset JSON Schema yang dihasilkan
+// ContractSchema: type, properties, required, additionalProperties, items,
+// enum dan anyOf
+func validateValue(path string, value interface{}, schema map[string]interface{}) ContractErrors {
+	at := func(format string, args ...interface{}) ContractErrors {
+		return ContractErrors{{Path: path, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]map[string]interface{}); ok {
+		var kinds []string
+		for _, option := range anyOf {
+			if len(validateValue(path, value, option)) == 0 {
+				return nil
+			}
+			kinds = append(kinds, option["type"].(string))
+		}
+		return at("must be one of %s", strings.Join(kinds, ", "))
+	}
+
+	switch want := schema["type"]; want {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return at("must be an object, got %s", jsonKind(value))
+		}
+		var errs ContractErrors
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]string)
+		for _, key := range required {
+			if _, ok := object[key]; !ok {
+				errs = append(errs, ContractError{Path: joinPath(path, key), Message: "is required"})
+			}
+		}
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for _, key := range sortedKeys(object) {
+			if property, ok := properties[key]; ok {
+				errs = append(errs, validateValue(joinPath(path, key), object[key], property.(map[string]interface{}))...)
+			} else if additional != nil {
+				errs = append(errs, validateValue(joinPath(path, key), object[key], additional)...)
+			} else {
+				errs = append(errs, ContractError{Path: joinPath(path, key), Message: "unknown field"})
+			}
+		}
+		return errs
+	case "array":
+		list, ok := value.([]interface{})
+		if !ok {
+			return at("must be an array, got %s", jsonKind(value))
+		}
+		var errs ContractErrors
+		items := schema["items"].(map[string]interface{})
+		for i, item := range list {
+			errs = append(errs, validateValue(fmt.Sprintf("%s[%d]", path, i), item, items)...)
+		}
+		return errs
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return at("must be a string, got %s", jsonKind(value))
+		}
+		if enum, ok := schema["enum"].([]string); ok {
+			for _, allowed := range enum {
+				if s == allowed {
+					return nil
+				}
+			}
+			return at("must be one of %s, got %q", strings.Join(enum, ", "), s)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return at("must be a boolean, got %s", jsonKind(value))
+		}
+	case "integer":
+		n, ok := value.(json.Number)
+		if _, err := n.Int64(); !ok || err != nil {
+			return at("must be an integer, got %s", jsonKind(value))
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return at("must be a number, got %s", jsonKind(value))
+		}
+	}
+	return nil
+}
+
+// ContractSchema mengembalikan JSON Schema dokumen Schema JSON, dibuat dari
+// tipe state.SchemaState sehingga selalu sama dengan yang dibaca datara
+func ContractSchema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(state.SchemaState{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "datara schema contract " + ContractVersion
+	return schema
+}
+
+// typeSchema membuat JSON Schema untuk tipe Go. Field tanpa omitempty yang
+// bertipe string wajib ada; field lain boleh dihilangkan.
+func typeSchema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case reflect.TypeOf(state.DefaultKind("")):
+		return map[string]interface{}{"type": "string", "enum": []string{
+			string(state.DefaultNull), string(state.DefaultKeyword), string(state.DefaultString),
+			string(state.DefaultNumber), string(state.DefaultBool), string(state.DefaultExpression),
+		}}
+	case reflect.TypeOf(state.DefaultValue{}):
+		// Default juga boleh ditulis sebagai ekspresi SQL, angka atau boolean
+		return map[string]interface{}{"anyOf": []map[string]interface{}{
+			{"type": "string"}, {"type": "number"}, {"type": "boolean"}, structSchema(t),
+		}}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema membuat JSON Schema object dari field ber-tag json
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		properties[name] = typeSchema(field.Type)
+		if field.Type.Kind() == reflect.String && !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// jsonKind menamai tipe nilai JSON untuk pesan error
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// joinPath menambahkan key ke path dokumen
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// sortedKeys mengembalikan key map secara berurutan agar error stabil
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
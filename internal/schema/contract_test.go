@@ -0,0 +1,162 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// contractWithDefault membuat dokumen Schema JSON satu tabel dengan kolom
+// s yang default_value-nya diberikan sebagai JSON mentah
+func contractWithDefault(value string) []byte {
+	column := `"name": "s", "type": "VARCHAR(20)", "nullable": true`
+	if value != "" {
+		column += `, "default_value": ` + value
+	}
+	return []byte(`{"version": "` + ContractVersion + `", "tables": {"t": {"name": "t", "columns": {"s": {` + column + `}}}}}`)
+}
+
+func TestContractDefaults(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  *state.DefaultValue
+		err   string
+	}{
+		{name: "absent", value: "", want: nil},
+		{name: "quoted empty string", value: `"''"`, want: &state.DefaultValue{Kind: state.DefaultString}},
+		{name: "string kind", value: `{"kind": "string"}`, want: &state.DefaultValue{Kind: state.DefaultString}},
+		{name: "null", value: `"NULL"`, want: &state.DefaultValue{Kind: state.DefaultNull}},
+		{name: "null kind", value: `{"kind": "null"}`, want: &state.DefaultValue{Kind: state.DefaultNull}},
+		{name: "bare empty", value: `""`, err: "is empty"},
+		{name: "blank", value: `"  "`, err: "is empty"},
+		{name: "empty expression", value: `{"kind": "expression", "value": ""}`, err: `value is empty for kind "expression"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, _, err := DecodeContract(contractWithDefault(tt.value))
+			if tt.err != "" {
+				var errs ContractErrors
+				if !errors.As(err, &errs) || !strings.Contains(err.Error(), "columns.s.default_value") || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got %v, want ContractErrors on default_value containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := schema.Tables["t"].Columns["s"].DefaultValue
+			if (got == nil) != (tt.want == nil) || got != nil && !got.Equal(tt.want) {
+				t.Errorf("default = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// contractWithKeys membuat dokumen Schema JSON users dan posts; users dan
+// posts adalah field tambahan (JSON mentah, diawali koma) untuk tabelnya
+func contractWithKeys(users, posts string) []byte {
+	return []byte(`{"version": "` + ContractVersion + `", "tables": {
+  "users": {"name": "users", "columns": {"id": {"name": "id", "type": "BIGINT"}, "email": {"name": "email", "type": "VARCHAR(100)"}}` + users + `},
+  "posts": {"name": "posts", "columns": {"id": {"name": "id", "type": "BIGINT"}, "user_id": {"name": "user_id", "type": "BIGINT", "nullable": true}}` + posts + `}}}`)
+}
+
+func TestContractKeysRoundTrip(t *testing.T) {
+	doc := contractWithKeys(
+		`, "primary_key": ["id"], "indexes": {"uq_users_email": {"name": "uq_users_email", "columns": ["email"], "unique": true, "include": ["id"]}}`,
+		`, "primary_key": ["id"], "foreign_keys": [{"columns": ["user_id"], "ref_table": "users", "ref_columns": ["id"], "on_delete": "set null"}]`)
+	schema, _, err := DecodeContract(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users, posts := schema.Tables["users"], schema.Tables["posts"]
+	if users.PrimaryKey != nil || posts.ForeignKeys != nil {
+		t.Errorf("primary_key and foreign_keys kept after decoding: %v, %v", users.PrimaryKey, posts.ForeignKeys)
+	}
+	wantUsers := []state.Constraint{{Name: "pk_users", Type: "PRIMARY KEY", Def: "PRIMARY KEY (`id`)"}}
+	if !reflect.DeepEqual(users.Constraints, wantUsers) {
+		t.Errorf("users constraints = %+v, want %+v", users.Constraints, wantUsers)
+	}
+	wantPosts := []state.Constraint{
+		{Name: "pk_posts", Type: "PRIMARY KEY", Def: "PRIMARY KEY (`id`)"},
+		{Name: "fk_posts_user_id", Type: "FOREIGN KEY", Def: "FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE SET NULL"},
+	}
+	if !reflect.DeepEqual(posts.Constraints, wantPosts) {
+		t.Errorf("posts constraints = %+v, want %+v", posts.Constraints, wantPosts)
+	}
+	wantIndex := state.Index{Name: "uq_users_email", Columns: []string{"email"}, Unique: true, Include: []string{"id"}}
+	if index := users.Indexes["uq_users_email"]; !reflect.DeepEqual(index, wantIndex) {
+		t.Errorf("index = %+v, want %+v", index, wantIndex)
+	}
+	if users.Columns["id"].Nullable || posts.Columns["id"].Nullable {
+		t.Error("primary key columns are nullable")
+	}
+
+	// Schema hasil decode adalah dokumen yang valid dan terbaca sama persis
+	encoded, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _, err := DecodeContract(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, schema) {
+		t.Errorf("round trip changed the schema:\n got %+v\nwant %+v", again, schema)
+	}
+}
+
+func TestContractKeyValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		users       string
+		posts       string
+		wantPath    string
+		wantMessage string
+	}{
+		{name: "index name mismatch", users: `, "indexes": {"idx_a": {"name": "idx_b", "columns": ["email"], "unique": false}}`,
+			wantPath: "tables.users.indexes.idx_a.name", wantMessage: `"idx_b" does not match its key "idx_a"`},
+		{name: "index unknown column", users: `, "indexes": {"idx_a": {"name": "idx_a", "columns": ["id", "mail"], "unique": false}}`,
+			wantPath: "tables.users.indexes.idx_a.columns[1]", wantMessage: `unknown column "mail"`},
+		{name: "index unknown include", users: `, "indexes": {"idx_a": {"name": "idx_a", "columns": ["id"], "unique": false, "include": ["mail"]}}`,
+			wantPath: "tables.users.indexes.idx_a.include[0]", wantMessage: `unknown column "mail"`},
+		{name: "primary key unknown column", users: `, "primary_key": ["uid"]`,
+			wantPath: "tables.users.primary_key[0]", wantMessage: `unknown column "uid"`},
+		{name: "primary key is not an array", users: `, "primary_key": "id"`,
+			wantPath: "tables.users.primary_key", wantMessage: "must be an array, got string"},
+		{name: "foreign key without columns", posts: `, "foreign_keys": [{"columns": [], "ref_table": "users", "ref_columns": []}]`,
+			wantPath: "tables.posts.foreign_keys[0].columns", wantMessage: "must list at least one column"},
+		{name: "foreign key unknown column", posts: `, "foreign_keys": [{"columns": ["author_id"], "ref_table": "users", "ref_columns": ["id"]}]`,
+			wantPath: "tables.posts.foreign_keys[0].columns[0]", wantMessage: `unknown column "author_id"`},
+		{name: "foreign key column count", posts: `, "foreign_keys": [{"columns": ["user_id"], "ref_table": "users", "ref_columns": ["id", "email"]}]`,
+			wantPath: "tables.posts.foreign_keys[0].ref_columns", wantMessage: "has 2 columns, want 1"},
+		{name: "foreign key unknown table", posts: `, "foreign_keys": [{"columns": ["user_id"], "ref_table": "accounts", "ref_columns": ["id"]}]`,
+			wantPath: "tables.posts.foreign_keys[0].ref_table", wantMessage: `unknown table "accounts"`},
+		{name: "foreign key unknown referenced column", posts: `, "foreign_keys": [{"columns": ["user_id"], "ref_table": "users", "ref_columns": ["uid"]}]`,
+			wantPath: "tables.posts.foreign_keys[0].ref_columns[0]", wantMessage: `unknown column "uid" of table users`},
+		{name: "foreign key unknown action", posts: `, "foreign_keys": [{"columns": ["user_id"], "ref_table": "users", "ref_columns": ["id"], "on_delete": "nullify"}]`,
+			wantPath: "tables.posts.foreign_keys[0].on_delete", wantMessage: `unknown action "nullify"`},
+		{name: "foreign key missing ref_table", posts: `, "foreign_keys": [{"columns": ["user_id"], "ref_columns": ["id"]}]`,
+			wantPath: "tables.posts.foreign_keys[0].ref_table", wantMessage: "is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateContract(contractWithKeys(tt.users, tt.posts))
+			var errs ContractErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("ValidateContract() = %v, want ContractErrors", err)
+			}
+			for _, e := range errs {
+				if e.Path == tt.wantPath && strings.Contains(e.Message, tt.wantMessage) {
+					return
+				}
+			}
+			t.Errorf("ValidateContract() = %v, want %s: %s", err, tt.wantPath, tt.wantMessage)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package schema
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// DeprecationFile adalah nama file di direktori migration yang mencatat kapan
+// kolom ditandai deprecated, satu baris "waktu tabel.kolom file" per kolom
+const DeprecationFile = "datara.deprecations"
+
+// Deprecation adalah kolom deprecated beserta waktu dan migration yang
+// pertama kali menandainya. Since kosong jika tidak ada catatan di journal,
+// mis. kolom yang ditandai sebelum journal ini ada.
+type Deprecation struct {
+	Table  string
+	Column string
+	Since  time.Time
+	File   string
+}
+
+// RecordDeprecations menambahkan entry ke datara.deprecations untuk setiap
+// kolom yang deprecated di to tetapi belum di from, dengan waktu at dan
+// migration file yang menandainya
+func RecordDeprecations(dir, file string, at time.Time, from, to *state.SchemaState) error {
+	var lines []string
+	for _, name := range sortedNames(to.Tables) {
+		table := to.Tables[name]
+		for _, colName := range sortedNames(table.Columns) {
+			if !isDeprecated(table.Columns[colName]) || isDeprecated(from.Tables[name].Columns[colName]) {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s %s.%s %s\n", at.UTC().Format(time.RFC3339), name, colName, filepath.Base(file)))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, DeprecationFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", DeprecationFile, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(strings.Join(lines, "")); err != nil {
+		return fmt.Errorf("failed to write %s: %w", DeprecationFile, err)
+	}
+	return nil
+}
+
+// DeprecatedColumns mengembalikan kolom deprecated di snapshot, terurut,
+// dengan waktu dari entry terakhir datara.deprecations untuk kolom tersebut
+func DeprecatedColumns(dir string, snapshot *state.SchemaState) ([]Deprecation, error) {
+	recorded, err := readDeprecations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Deprecation
+	for _, name := range sortedNames(snapshot.Tables) {
+		table := snapshot.Tables[name]
+		for _, colName := range sortedNames(table.Columns) {
+			if !isDeprecated(table.Columns[colName]) {
+				continue
+			}
+			deprecation := Deprecation{Table: name, Column: colName}
+			if entry, ok := recorded[name+"."+colName]; ok {
+				deprecation.Since, deprecation.File = entry.Since, entry.File
+			}
+			result = append(result, deprecation)
+		}
+	}
+	return result, nil
+}
+
+// readDeprecations membaca datara.deprecations menjadi entry terakhir per
+// "tabel.kolom". Hasilnya kosong jika file belum ada.
+func readDeprecations(dir string) (map[string]Deprecation, error) {
+	f, err := os.Open(filepath.Join(dir, DeprecationFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", DeprecationFile, err)
+	}
+	defer f.Close()
+
+	entries := make(map[string]Deprecation)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid %s entry: %q", DeprecationFile, line)
+		}
+		since, err := time.Parse(time.RFC3339, fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry: %q: %w", DeprecationFile, line, err)
+		}
+		table, column, _ := strings.Cut(fields[1], ".")
+		entries[fields[1]] = Deprecation{Table: table, Column: column, Since: since, File: fields[2]}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", DeprecationFile, err)
+	}
+	return entries, nil
+}
+
+// undeprecatedDrops memperingatkan kolom yang di-drop dari tabel yang masih
+// ada tanpa pernah ditandai deprecated di snapshot sebelumnya. Kolom yang
+// sudah deprecated melewati masa deprecation dan boleh di-drop tanpa
+// peringatan; directive destructive-ok mematikan pemeriksaan ini.
+func undeprecatedDrops(current, desired *state.SchemaState) state.Warnings {
+	var warnings state.Warnings
+	for _, name := range sortedNames(current.Tables) {
+		desiredTable, exists := desired.Tables[name]
+		if !exists {
+			continue
+		}
+		currentTable := current.Tables[name]
+		for _, colName := range sortedNames(currentTable.Columns) {
+			if _, kept := desiredTable.Columns[colName]; kept || isDeprecated(currentTable.Columns[colName]) {
+				continue
+			}
+			warnings.Add("undeprecated-drop", name, colName,
+				"column is dropped without being marked db:\"deprecated\" in an earlier migration; deprecate it first or add -- datara:destructive-ok")
+		}
+	}
+	return warnings
+}
+
+// isDeprecated mengecek field Deprecated maupun tag mentahnya, karena
+// snapshot dari Schema JSON bisa hanya membawa tag
+func isDeprecated(col state.Column) bool {
+	_, tagged := col.Tags["deprecated"]
+	return col.Deprecated || tagged
+}
@@ -0,0 +1,181 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// directivePrefix mengawali directive yang ditulis schema program sebagai
+// komentar SQL, satu per baris: "-- datara:<nama> [argumen ...]"
+const directivePrefix = "-- datara:"
+
+// Directive yang dikenali
+const (
+	// DirectiveSkipTable: tabel tidak dikelola datara, mis. "-- datara:skip-table audit_log"
+	DirectiveSkipTable = "skip-table"
+	// DirectiveNoFK: foreign key tidak dibuat, mis. "-- datara:no-fk orders.external_id".
+	// Tanpa kolom ("orders"), semua foreign key tabel tersebut diabaikan.
+	DirectiveNoFK = "no-fk"
+	// DirectiveDestructiveOK: migration yang di-generate ditandai -- datara:destructive
+	DirectiveDestructiveOK = "destructive-ok"
+	// DirectiveHistory: tabel mendapat tabel history (shadow), mis. "-- datara:history users"
+	DirectiveHistory = "history"
+)
+
+// Directive adalah petunjuk dari schema program untuk datara
+type Directive struct {
+	Name string
+	Args []string
+	Line int
+}
+
+// String merender directive seperti ditulis di SQL
+func (d Directive) String() string {
+	return strings.TrimSpace(directivePrefix + d.Name + " " + strings.Join(d.Args, " "))
+}
+
+// Directives adalah directive yang sudah diterapkan ke sebuah schema
+type Directives struct {
+	list []Directive
+
+	// DestructiveOK bernilai true jika ada directive destructive-ok
+	DestructiveOK bool
+	// History adalah tabel desired yang diberi tabel history oleh directive history
+	History []string
+}
+
+// ParseDirectives mengambil directive dari output schema program dan
+// mengembalikan SQL tanpa baris directive, sehingga directive tidak ikut
+// dibandingkan maupun ditulis ke migration
+func ParseDirectives(sql string) (*Directives, string) {
+	directives := &Directives{}
+	lines := strings.Split(sql, "\n")
+	kept := lines[:0]
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, directivePrefix) {
+			kept = append(kept, line)
+			continue
+		}
+		fields := strings.Fields(trimmed[len(directivePrefix):])
+		if len(fields) == 0 {
+			continue
+		}
+		directives.list = append(directives.list, Directive{Name: fields[0], Args: fields[1:], Line: i + 1})
+	}
+	return directives, strings.Join(kept, "\n")
+}
+
+// String merender directive dalam bentuk kanonik, dipakai sebagai bagian
+// hash schema agar perubahan directive saja tetap terdeteksi
+func (d *Directives) String() string {
+	lines := make([]string, len(d.list))
+	for i, directive := range d.list {
+		lines[i] = directive.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Apply menerapkan directive ke desired. Tabel yang di-skip mengikuti versi
+// current (jika ada) sehingga tidak pernah dibuat, diubah, atau di-drop.
+// Directive yang tidak dikenal atau tidak valid hanya menghasilkan peringatan.
+func (d *Directives) Apply(current, desired *state.SchemaState) []string {
+	var warnings []string
+	warn := func(directive Directive, format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf("line %d: %s: %s", directive.Line, directive, fmt.Sprintf(format, args...)))
+	}
+
+	for _, directive := range d.list {
+		switch directive.Name {
+		case DirectiveSkipTable:
+			if len(directive.Args) == 0 {
+				warn(directive, "missing table name")
+			}
+			for _, name := range directive.Args {
+				if table, exists := current.Tables[name]; exists {
+					desired.Tables[name] = table.Clone()
+				} else {
+					desired.RemoveTable(name)
+				}
+			}
+		case DirectiveNoFK:
+			if len(directive.Args) == 0 {
+				warn(directive, "missing table or table.column")
+			}
+			for _, target := range directive.Args {
+				tableName, column, _ := strings.Cut(target, ".")
+				table, exists := desired.Tables[tableName]
+				if !exists {
+					warn(directive, "unknown table %s", tableName)
+					continue
+				}
+				table.Constraints = withoutForeignKeys(table.Constraints, column)
+				desired.Tables[tableName] = table
+			}
+		case DirectiveDestructiveOK:
+			d.DestructiveOK = true
+		case DirectiveHistory:
+			if len(directive.Args) == 0 {
+				warn(directive, "missing table name")
+			}
+			for _, name := range directive.Args {
+				if _, exists := desired.Tables[name]; !exists {
+					warn(directive, "unknown table %s", name)
+					continue
+				}
+				d.History = append(d.History, name)
+			}
+		default:
+			warn(directive, "unknown directive")
+		}
+	}
+	return warnings
+}
+
+// withoutForeignKeys membuang foreign key yang mencakup column, atau semua
+// foreign key jika column kosong
+func withoutForeignKeys(constraints []state.Constraint, column string) []state.Constraint {
+	var kept []state.Constraint
+	for _, c := range constraints {
+		if c.Type == "FOREIGN KEY" && (column == "" || foreignKeyCovers(c.Def, column)) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+// foreignKeyCovers mengecek apakah kolom lokal foreign key memuat column
+func foreignKeyCovers(def, column string) bool {
+	upper := strings.ToUpper(def)
+	start := strings.Index(upper, "FOREIGN KEY")
+	if start == -1 {
+		return false
+	}
+	rest := def[start+len("FOREIGN KEY"):]
+	open := strings.Index(rest, "(")
+	if open == -1 {
+		return false
+	}
+	end := matchingParen(rest[open:])
+	if end == -1 {
+		return false
+	}
+	for _, name := range splitIdentList(rest[open : open+end+1]) {
+		if name == column {
+			return true
+		}
+	}
+	return false
+}
+
+// directiveWarnings mengubah peringatan directive menjadi Warning
+func directiveWarnings(warnings []string) state.Warnings {
+	var result state.Warnings
+	for _, warning := range warnings {
+		result.Add("directive", "", "", "schema directive %s", warning)
+	}
+	return result
+}
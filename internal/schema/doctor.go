@@ -0,0 +1,726 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Marker dbmate yang memisahkan bagian up dan down sebuah migration
+const (
+	migrateUpMarker   = "-- migrate:up"
+	migrateDownMarker = "-- migrate:down"
+)
+
+// Conflict adalah statement migration yang akan gagal jika semua migration
+// dijalankan berurutan, mis. kolom yang ditambahkan dua kali setelah merge branch
+type Conflict struct {
+	File      string
+	Line      int
+	Statement string
+	Problem   string
+
+	// Origin adalah lokasi (file:line) yang lebih dulu membuat atau menghapus objek
+	Origin string
+	// Duplicate bernilai true jika statement identik sudah ada di Origin
+	Duplicate bool
+
+	start, end int
+}
+
+// Suggestion mengembalikan saran perbaikan untuk konflik
+func (c Conflict) Suggestion() string {
+	switch {
+	case c.Duplicate:
+		return fmt.Sprintf("delete the statement, it duplicates %s", c.Origin)
+	case c.Origin != "":
+		return fmt.Sprintf("merge %s with the migration at %s", c.File, c.Origin)
+	default:
+		return "remove the statement or add the missing object in an earlier migration"
+	}
+}
+
+// replay menyimpan state kumulatif saat migration dijalankan ulang
+type replay struct {
+	schema     *state.SchemaState
+	origins    map[string]string // objek -> file:line yang terakhir mengubahnya
+	statements map[string]string // statement ternormalisasi -> file:line pertama
+	// skipped adalah statement yang tidak bisa diterapkan ke schema
+	skipped []SkippedStatement
+}
+
+// SkippedStatement adalah statement migration yang tidak dipahami replay,
+// mis. CREATE EXTENSION atau ALTER TABLE ... SET TABLESPACE
+type SkippedStatement struct {
+	Location  string
+	Statement string
+	// Problem diisi jika statement dipahami tetapi gagal diterapkan, mis.
+	// "table users already exists"
+	Problem string
+}
+
+// skip mencatat statement yang tidak bisa diterapkan
+func (r *replay) skip(stmt, location string) {
+	r.skipped = append(r.skipped, SkippedStatement{Location: location, Statement: stmt})
+}
+
+// dataStatements adalah awalan statement yang tidak mengubah schema
+var dataStatements = []string{"INSERT ", "UPDATE ", "DELETE ", "SELECT ", "BEGIN", "COMMIT", "START TRANSACTION"}
+
+// newReplay membuat replay dengan schema kosong
+func newReplay() *replay {
+	return &replay{
+		schema:     state.NewSchemaState(),
+		origins:    make(map[string]string),
+		statements: make(map[string]string),
+	}
+}
+
+// Doctor menjalankan ulang bagian up dari semua migration di dir secara
+// berurutan dan melaporkan statement yang akan gagal terhadap state kumulatif
+func Doctor(dir string, pattern *regexp.Regexp) ([]Conflict, error) {
+	files, err := migrationFiles(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newReplay()
+	var conflicts []Conflict
+	for _, name := range files {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		sql := string(content)
+
+		upStart, upEnd := upSection(sql)
+		for _, span := range splitStatementSpans(sql[upStart:upEnd]) {
+			stmt := normalizeDefinition(span.Text)
+			line := strings.Count(sql[:upStart+span.Start], "\n") + 1
+			location := fmt.Sprintf("%s:%d", name, line)
+
+			key := strings.ToUpper(stmt)
+			problem, origin := r.apply(stmt, location)
+			if problem == "" {
+				if _, seen := r.statements[key]; !seen {
+					r.statements[key] = location
+				}
+				continue
+			}
+
+			conflict := Conflict{
+				File:      name,
+				Line:      line,
+				Statement: span.Text,
+				Problem:   problem,
+				Origin:    origin,
+				start:     upStart + span.Start,
+				end:       upStart + span.End,
+			}
+			if first, seen := r.statements[key]; seen {
+				conflict.Origin = first
+				conflict.Duplicate = true
+			}
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts, nil
+}
+
+// FixConflicts menghapus statement duplikat dari file migration yang lebih baru
+// dan mengembalikan nama file yang diubah. Konflik lain dibiarkan.
+func FixConflicts(dir string, conflicts []Conflict) ([]string, error) {
+	byFile := make(map[string][]Conflict)
+	var files []string
+	for _, c := range conflicts {
+		if !c.Duplicate {
+			continue
+		}
+		if _, ok := byFile[c.File]; !ok {
+			files = append(files, c.File)
+		}
+		byFile[c.File] = append(byFile[c.File], c)
+	}
+
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		sql := string(content)
+
+		// Hapus dari belakang agar offset konflik sebelumnya tetap valid
+		fileConflicts := byFile[name]
+		for i := len(fileConflicts) - 1; i >= 0; i-- {
+			c := fileConflicts[i]
+			end := c.end
+			for end < len(sql) && (sql[end] == ' ' || sql[end] == '\t') {
+				end++
+			}
+			// Buang juga newline penutup dan satu baris kosong pemisah statement
+			for n := 0; n < 2 && end < len(sql) && sql[end] == '\n'; n++ {
+				end++
+			}
+			sql = sql[:c.start] + sql[end:]
+		}
+
+		if err := os.WriteFile(path, []byte(sql), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write migration %s: %w", name, err)
+		}
+	}
+	return files, nil
+}
+
+// upSection mengembalikan rentang bagian up sebuah migration. File tanpa
+// marker dbmate dianggap seluruhnya bagian up.
+func upSection(sql string) (int, int) {
+	start := 0
+	if i := strings.Index(sql, migrateUpMarker); i != -1 {
+		start = i + len(migrateUpMarker)
+	}
+	end := len(sql)
+	if i := strings.Index(sql[start:], migrateDownMarker); i != -1 {
+		end = start + i
+	}
+	return start, end
+}
+
+// apply menerapkan satu statement ke state kumulatif. Jika statement akan gagal,
+// deskripsi masalah dan lokasi yang lebih dulu mengubah objek dikembalikan.
+func (r *replay) apply(stmt, location string) (string, string) {
+	upper := strings.ToUpper(stmt)
+	tokens := splitTokens(stmt)
+
+	switch {
+	case strings.HasPrefix(upper, "CREATE TABLE"):
+		table, err := parseCreateTable(stmt)
+		if err != nil {
+			r.skip(stmt, location)
+			return "", ""
+		}
+		if _, exists := r.schema.GetTable(table.Name); exists {
+			if strings.Contains(upper, "IF NOT EXISTS") {
+				return "", ""
+			}
+			return fmt.Sprintf("table %s already exists", table.Name), r.origins[table.Name]
+		}
+		r.schema.AddTable(table)
+		r.origins[table.Name] = location
+
+	case strings.HasPrefix(upper, "DROP TABLE"):
+		for _, name := range dropTargets(tokens[2:]) {
+			if _, exists := r.schema.GetTable(name); !exists {
+				if strings.Contains(upper, "IF EXISTS") {
+					continue
+				}
+				return fmt.Sprintf("table %s does not exist", name), r.origins[name]
+			}
+			r.schema.RemoveTable(name)
+			r.origins[name] = location
+		}
+
+	case isCreateIndex(upper):
+		tableName, idx, err := parseCreateIndex(stmt)
+		if err != nil {
+			r.skip(stmt, location)
+			return "", ""
+		}
+		table, exists := r.schema.GetTable(tableName)
+		if !exists {
+			return fmt.Sprintf("index %s references missing table %s", idx.Name, tableName), r.origins[tableName]
+		}
+		if _, exists := table.Indexes[idx.Name]; exists {
+			if strings.Contains(upper, "IF NOT EXISTS") {
+				return "", ""
+			}
+			return fmt.Sprintf("index %s already exists", idx.Name), r.origins["index:"+idx.Name]
+		}
+		table.Indexes[idx.Name] = idx
+		r.origins["index:"+idx.Name] = location
+
+	case strings.HasPrefix(upper, "DROP INDEX"):
+		for _, name := range dropTargets(tokens[2:]) {
+			table, found := r.indexTable(name)
+			if !found {
+				if strings.Contains(upper, "IF EXISTS") {
+					continue
+				}
+				return fmt.Sprintf("index %s does not exist", name), r.origins["index:"+name]
+			}
+			delete(table.Indexes, name)
+			r.origins["index:"+name] = location
+		}
+
+	case strings.HasPrefix(upper, "ALTER TABLE"):
+		return r.applyAlterTable(tokens, location)
+
+	case strings.HasPrefix(upper, "RENAME TABLE"):
+		// MySQL: RENAME TABLE a TO b[, c TO d]
+		for _, pair := range splitKeepingParentheses(strings.Join(tokens[2:], " ")) {
+			words := splitTokens(strings.TrimSpace(pair))
+			if len(words) != 3 || strings.ToUpper(words[1]) != "TO" {
+				r.skip(stmt, location)
+				return "", ""
+			}
+			if problem := r.renameTable(unquoteIdent(words[0]), unquoteIdent(words[2]), location); problem != "" {
+				return problem, r.origins[unquoteIdent(words[0])]
+			}
+		}
+
+	case strings.HasPrefix(upper, "ALTER INDEX"):
+		// Postgres: ALTER INDEX [IF EXISTS] a RENAME TO b
+		rest := tokens[2:]
+		for len(rest) > 0 && isIndexModifier(rest[0]) {
+			rest = rest[1:]
+		}
+		if len(rest) != 4 || strings.ToUpper(rest[1]) != "RENAME" || strings.ToUpper(rest[2]) != "TO" {
+			r.skip(stmt, location)
+			return "", ""
+		}
+		table, found := r.indexTable(unquoteIdent(rest[0]))
+		if !found {
+			return fmt.Sprintf("index %s does not exist", unquoteIdent(rest[0])), r.origins["index:"+unquoteIdent(rest[0])]
+		}
+		renameIndex(table, unquoteIdent(rest[0]), unquoteIdent(rest[3]))
+		r.origins["index:"+unquoteIdent(rest[3])] = location
+
+	default:
+		for _, prefix := range dataStatements {
+			if strings.HasPrefix(upper, prefix) {
+				return "", ""
+			}
+		}
+		r.skip(stmt, location)
+	}
+	return "", ""
+}
+
+// renameTable mengganti nama tabel di state kumulatif
+func (r *replay) renameTable(from, to, location string) string {
+	table, exists := r.schema.GetTable(from)
+	if !exists {
+		return fmt.Sprintf("table %s does not exist", from)
+	}
+	if _, exists := r.schema.GetTable(to); exists {
+		return fmt.Sprintf("table %s already exists", to)
+	}
+	r.schema.RemoveTable(from)
+	table.Name = to
+	r.schema.AddTable(table)
+	r.origins[from], r.origins[to] = location, location
+	return ""
+}
+
+// renameIndex mengganti nama index tabel
+func renameIndex(table state.Table, from, to string) {
+	idx := table.Indexes[from]
+	delete(table.Indexes, from)
+	idx.Name = to
+	table.Indexes[to] = idx
+}
+
+// placement adalah klausa FIRST atau AFTER kolom (MySQL) di ADD, MODIFY dan
+// CHANGE COLUMN; nilai kosong berarti posisi kolom tidak berubah
+type placement struct {
+	first bool
+	after string
+}
+
+// columnPlacement memisahkan placement di akhir definisi kolom
+func columnPlacement(def []string) ([]string, placement) {
+	n := len(def)
+	switch {
+	case n >= 1 && strings.ToUpper(def[n-1]) == "FIRST":
+		return def[:n-1], placement{first: true}
+	case n >= 2 && strings.ToUpper(def[n-2]) == "AFTER":
+		return def[:n-2], placement{after: unquoteIdent(def[n-1])}
+	}
+	return def, placement{}
+}
+
+// placeColumn memindahkan kolom name sesuai p, lalu menomori ulang Position
+// semua kolom tabel
+func placeColumn(table state.Table, name string, p placement) {
+	if !p.first && p.after == "" {
+		return
+	}
+	var order []string
+	for _, column := range table.ColumnNames() {
+		if column != name {
+			order = append(order, column)
+		}
+	}
+	at := 0
+	if p.after != "" {
+		at = len(order)
+		for i, column := range order {
+			if column == p.after {
+				at = i + 1
+			}
+		}
+	}
+	order = append(order[:at], append([]string{name}, order[at:]...)...)
+	for i, column := range order {
+		col := table.Columns[column]
+		col.Position = i + 1
+		table.Columns[column] = col
+	}
+}
+
+// renameColumn mengganti nama kolom beserta referensinya di index tabel
+func renameColumn(table state.Table, from, to string) {
+	column := table.Columns[from]
+	delete(table.Columns, from)
+	column.Name = to
+	table.Columns[to] = column
+	for name, idx := range table.Indexes {
+		for i, col := range idx.Columns {
+			if col == from {
+				idx.Columns[i] = to
+			}
+		}
+		table.Indexes[name] = idx
+	}
+}
+
+// applyAlterTable menerapkan ADD/DROP/MODIFY/ALTER COLUMN dari ALTER TABLE, termasuk
+// beberapa aksi yang dipisah koma
+func (r *replay) applyAlterTable(tokens []string, location string) (string, string) {
+	i := 2
+	for i < len(tokens) && (isIndexModifier(tokens[i]) || strings.ToUpper(tokens[i]) == "ONLY") {
+		i++
+	}
+	if i >= len(tokens) {
+		return "", ""
+	}
+	tableName := unquoteIdent(tokens[i])
+	table, exists := r.schema.GetTable(tableName)
+	if !exists {
+		return fmt.Sprintf("table %s does not exist", tableName), r.origins[tableName]
+	}
+
+	for _, action := range splitKeepingParentheses(strings.Join(tokens[i+1:], " ")) {
+		words := splitTokens(strings.TrimSpace(action))
+		if len(words) < 2 {
+			continue
+		}
+		verb := strings.ToUpper(words[0])
+		rest := words[1:]
+		switch verb {
+		case "MODIFY", "ALTER":
+			if problem := r.applyColumnChange(table, verb, rest); problem != "" {
+				return problem, r.origins[tableName+"."+unquoteIdent(rest[len(rest)-1])]
+			}
+			continue
+		case "RENAME", "CHANGE":
+			problem, renamed := r.applyRename(table, verb, rest, location)
+			if problem == "" && !renamed {
+				r.skip("ALTER TABLE "+tableName+" "+strings.TrimSpace(action), location)
+			}
+			if problem != "" {
+				return problem, ""
+			}
+			if _, exists := r.schema.GetTable(tableName); !exists {
+				// Tabel sudah berganti nama; aksi berikutnya tidak diterapkan
+				return "", ""
+			}
+			continue
+		case "VALIDATE":
+			if problem := r.applyValidate(table, rest); problem != "" {
+				return problem, r.origins[tableName]
+			}
+			continue
+		case "ADD", "DROP":
+		default:
+			r.skip("ALTER TABLE "+tableName+" "+strings.TrimSpace(action), location)
+			continue
+		}
+		if strings.ToUpper(rest[0]) == "COLUMN" {
+			rest = rest[1:]
+		} else if !isColumnAction(rest) {
+			if !r.applyKeyChange(&table, verb, rest, location) {
+				r.skip("ALTER TABLE "+tableName+" "+strings.TrimSpace(action), location)
+			}
+			continue
+		}
+
+		ifClause := false
+		for len(rest) > 0 && isIndexModifier(rest[0]) {
+			ifClause = true
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			continue
+		}
+
+		key := tableName + "." + unquoteIdent(rest[0])
+		switch verb {
+		case "ADD":
+			def, place := columnPlacement(rest)
+			column, _ := parseColumnDef(tableName, strings.Join(def, " "))
+			if _, exists := table.Columns[column.Name]; exists {
+				if ifClause {
+					continue
+				}
+				return fmt.Sprintf("column %s already exists", key), r.origins[key]
+			}
+			// Kolom baru ditambahkan di akhir tabel kecuali ada FIRST/AFTER
+			for _, col := range table.Columns {
+				column.Position = max(column.Position, col.Position)
+			}
+			column.Position++
+			table.Columns[column.Name] = column
+			placeColumn(table, column.Name, place)
+		case "DROP":
+			name := unquoteIdent(rest[0])
+			if _, exists := table.Columns[name]; !exists {
+				if ifClause {
+					continue
+				}
+				return fmt.Sprintf("column %s does not exist", key), r.origins[key]
+			}
+			delete(table.Columns, name)
+		}
+		r.origins[key] = location
+	}
+	return "", ""
+}
+
+// applyRename menerapkan RENAME [TO|AS] nama, RENAME COLUMN a TO b,
+// RENAME INDEX|KEY a TO b dan CHANGE [COLUMN] lama baru definisi (MySQL).
+// renamed bernilai false jika bentuknya tidak dikenal.
+func (r *replay) applyRename(table state.Table, verb string, rest []string, location string) (problem string, renamed bool) {
+	if verb == "CHANGE" {
+		if strings.ToUpper(rest[0]) == "COLUMN" {
+			rest = rest[1:]
+		}
+		if len(rest) < 3 {
+			return "", false
+		}
+		from := unquoteIdent(rest[0])
+		column, exists := table.Columns[from]
+		if !exists {
+			return fmt.Sprintf("column %s.%s does not exist", table.Name, from), true
+		}
+		renameColumn(table, from, unquoteIdent(rest[1]))
+		def, place := columnPlacement(rest[1:])
+		modified, _ := parseColumnDef(table.Name, strings.Join(def, " "))
+		modified.Position = column.Position
+		table.Columns[modified.Name] = modified
+		placeColumn(table, modified.Name, place)
+		r.origins[table.Name+"."+modified.Name] = location
+		return "", true
+	}
+
+	switch kind := strings.ToUpper(rest[0]); {
+	case (kind == "COLUMN" || kind == "INDEX" || kind == "KEY") && len(rest) == 4 && strings.ToUpper(rest[2]) == "TO":
+		from, to := unquoteIdent(rest[1]), unquoteIdent(rest[3])
+		if kind == "COLUMN" {
+			if _, exists := table.Columns[from]; !exists {
+				return fmt.Sprintf("column %s.%s does not exist", table.Name, from), true
+			}
+			renameColumn(table, from, to)
+			r.origins[table.Name+"."+to] = location
+			return "", true
+		}
+		if _, exists := table.Indexes[from]; !exists {
+			return fmt.Sprintf("index %s does not exist", from), true
+		}
+		renameIndex(table, from, to)
+		r.origins["index:"+to] = location
+		return "", true
+	case (kind == "TO" || kind == "AS") && len(rest) == 2:
+		return r.renameTable(table.Name, unquoteIdent(rest[1]), location), true
+	case len(rest) == 1:
+		return r.renameTable(table.Name, unquoteIdent(rest[0]), location), true
+	}
+	return "", false
+}
+
+// applyKeyChange menerapkan ADD/DROP constraint dan index MySQL dari
+// ALTER TABLE. false dikembalikan jika aksinya tidak dikenal.
+func (r *replay) applyKeyChange(table *state.Table, verb string, rest []string, location string) bool {
+	def := strings.Join(rest, " ")
+	if verb == "ADD" {
+		if idx, ok := parseInlineIndex(def); ok {
+			table.Indexes[idx.Name] = idx
+			r.origins["index:"+idx.Name] = location
+			return true
+		}
+		if !isTableConstraint(def) {
+			return false
+		}
+		constraint := newConstraint(table.Name, def)
+		if constraint.NotValid {
+			constraint.Def = withoutDefaultName(table.Name, constraint.Def)
+		}
+		table.Constraints = append(withoutConstraintName(table.Constraints, constraint.Name), constraint)
+		r.schema.AddTable(*table)
+		return true
+	}
+
+	if last := strings.ToUpper(rest[len(rest)-1]); last == "CASCADE" || last == "RESTRICT" {
+		rest = rest[:len(rest)-1]
+	}
+	switch kind := strings.ToUpper(rest[0]); {
+	case kind == "PRIMARY" && len(rest) == 2:
+		var kept []state.Constraint
+		for _, c := range table.Constraints {
+			if c.Type != "PRIMARY KEY" {
+				kept = append(kept, c)
+			}
+		}
+		table.Constraints = kept
+	case kind == "INDEX" || kind == "KEY":
+		delete(table.Indexes, unquoteIdent(rest[len(rest)-1]))
+		return true
+	case kind == "CONSTRAINT" || kind == "FOREIGN" || kind == "CHECK":
+		table.Constraints = withoutConstraintName(table.Constraints, unquoteIdent(rest[len(rest)-1]))
+	default:
+		return false
+	}
+	r.schema.AddTable(*table)
+	return true
+}
+
+// applyValidate menerapkan VALIDATE CONSTRAINT (Postgres) ke constraint yang
+// ditambahkan dengan NOT VALID
+func (r *replay) applyValidate(table state.Table, rest []string) string {
+	name := unquoteIdent(rest[len(rest)-1])
+	for i, c := range table.Constraints {
+		if c.Name == name {
+			table.Constraints[i].NotValid = false
+			r.schema.AddTable(table)
+			return ""
+		}
+	}
+	return fmt.Sprintf("constraint %s.%s does not exist", table.Name, name)
+}
+
+// withoutDefaultName membuang "CONSTRAINT <nama>" dari def jika namanya sama
+// dengan nama default constraintName. Generator menulis nama tersebut pada
+// ADD ... NOT VALID agar bisa dirujuk VALIDATE CONSTRAINT, sedangkan
+// snapshot menyimpan definisi tanpa nama.
+func withoutDefaultName(tableName, def string) string {
+	parts := strings.SplitN(def, " ", 3)
+	if len(parts) == 3 && strings.EqualFold(parts[0], "CONSTRAINT") &&
+		unquoteIdent(parts[1]) == constraintName(tableName, parts[2]) {
+		return parts[2]
+	}
+	return def
+}
+
+// withoutConstraintName mengembalikan constraints tanpa constraint bernama name
+func withoutConstraintName(constraints []state.Constraint, name string) []state.Constraint {
+	var kept []state.Constraint
+	for _, c := range constraints {
+		if c.Name != name {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// applyColumnChange menerapkan MODIFY [COLUMN] (MySQL) dan ALTER [COLUMN]
+// (Postgres) ke kolom yang sudah ada
+func (r *replay) applyColumnChange(table state.Table, verb string, rest []string) string {
+	if strings.ToUpper(rest[0]) == "COLUMN" {
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		return ""
+	}
+	name := unquoteIdent(rest[0])
+	column, exists := table.Columns[name]
+	if !exists {
+		return fmt.Sprintf("column %s.%s does not exist", table.Name, name)
+	}
+
+	if verb == "MODIFY" {
+		def, place := columnPlacement(rest)
+		modified, _ := parseColumnDef(table.Name, strings.Join(def, " "))
+		modified.Position = column.Position
+		table.Columns[name] = modified
+		placeColumn(table, name, place)
+		return ""
+	}
+
+	action := strings.ToUpper(strings.Join(rest[1:], " "))
+	switch {
+	case strings.HasPrefix(action, "TYPE "), strings.HasPrefix(action, "SET DATA TYPE "):
+		typ := rest[2:]
+		if strings.HasPrefix(action, "SET DATA TYPE ") {
+			typ = rest[4:]
+		}
+		for i, tok := range typ {
+			if strings.ToUpper(tok) == "USING" || strings.ToUpper(tok) == "COLLATE" {
+				typ = typ[:i]
+				break
+			}
+		}
+		// SRID geometry(Point,4326) ikut berganti bersama tipenya
+		column.Type, column.SRID = strings.Join(typ, " "), 0
+		column.NormalizeSpatial()
+	case strings.HasPrefix(action, "SET DEFAULT "):
+		column.DefaultValue = state.ParseDefault(strings.Join(rest[3:], " "))
+	case action == "DROP DEFAULT":
+		column.DefaultValue = nil
+	case action == "SET NOT NULL":
+		column.Nullable = false
+	case action == "DROP NOT NULL":
+		column.Nullable = true
+	case strings.HasPrefix(action, "ADD GENERATED "):
+		column.AutoIncrement = true
+	case strings.HasPrefix(action, "DROP IDENTITY"):
+		column.AutoIncrement = false
+	}
+	table.Columns[name] = column
+	return ""
+}
+
+// indexTable mencari tabel yang memiliki index dengan nama tersebut
+func (r *replay) indexTable(name string) (state.Table, bool) {
+	for _, table := range r.schema.Tables {
+		if _, ok := table.Indexes[name]; ok {
+			return table, true
+		}
+	}
+	return state.Table{}, false
+}
+
+// dropTargets membaca daftar nama dari DROP TABLE/INDEX, melewati IF EXISTS,
+// CONCURRENTLY dan klausa ON/CASCADE
+func dropTargets(tokens []string) []string {
+	var names []string
+	for _, tok := range tokens {
+		upper := strings.ToUpper(tok)
+		if isIndexModifier(tok) {
+			continue
+		}
+		if upper == "ON" || upper == "CASCADE" || upper == "RESTRICT" {
+			break
+		}
+		for _, name := range strings.Split(tok, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, unquoteIdent(name))
+			}
+		}
+	}
+	return names
+}
+
+// isColumnAction mengecek apakah ADD/DROP tanpa keyword COLUMN mengubah kolom
+// (MySQL mengizinkan "DROP name"), bukan constraint atau index
+func isColumnAction(rest []string) bool {
+	switch strings.ToUpper(rest[0]) {
+	case "CONSTRAINT", "INDEX", "KEY", "PRIMARY", "FOREIGN", "UNIQUE", "CHECK",
+		"EXCLUDE", "FULLTEXT", "SPATIAL", "DEFAULT":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// DuplicateTableError dikembalikan ketika dua definisi yang berbeda
+// menghasilkan nama tabel yang sama, mis. struct User dari dua package atau
+// User dan Users setelah pluralisasi. First dan Second menyebut asal
+// masing-masing definisi.
+type DuplicateTableError struct {
+	Table  string
+	First  string
+	Second string
+}
+
+func (e *DuplicateTableError) Error() string {
+	return fmt.Sprintf("table %q is defined twice with different definitions: by %s and by %s", e.Table, e.First, e.Second)
+}
+
+// tableSet menambahkan tabel ke schema sambil mengingat asal dan hash
+// struktur setiap nama tabel, agar definisi kedua dengan nama yang sama tidak
+// diam-diam menimpa yang pertama
+type tableSet struct {
+	schema   *state.SchemaState
+	origins  map[string]tableOrigin
+	warnings state.Warnings
+}
+
+type tableOrigin struct {
+	origin string
+	hash   string
+}
+
+func newTableSet(schema *state.SchemaState) *tableSet {
+	return &tableSet{schema: schema, origins: make(map[string]tableOrigin)}
+}
+
+// add menambahkan table yang berasal dari origin. Jika nama tabel sudah
+// dipakai, definisi yang strukturnya identik digabung dengan peringatan dan
+// definisi yang berbeda menghasilkan *DuplicateTableError. Hash dihitung saat
+// tabel ditambahkan, sehingga index yang menyusul lewat CREATE INDEX tidak
+// ikut dibandingkan.
+func (s *tableSet) add(table state.Table, origin string) error {
+	hash := tableHash(table)
+	first, exists := s.origins[table.Name]
+	if !exists {
+		s.origins[table.Name] = tableOrigin{origin: origin, hash: hash}
+		s.schema.AddTable(table)
+		return nil
+	}
+	if first.hash != hash {
+		return &DuplicateTableError{Table: table.Name, First: first.origin, Second: origin}
+	}
+	s.warnings.Add("duplicate-table", table.Name, "", "defined by both %s and %s with the same structure; using %s",
+		first.origin, origin, first.origin)
+	return nil
+}
+
+// tableHash adalah StateHash schema yang hanya berisi table
+func tableHash(table state.Table) string {
+	return StateHash(&state.SchemaState{Tables: map[string]state.Table{table.Name: table}})
+}
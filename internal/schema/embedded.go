@@ -0,0 +1,436 @@
+package schema
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Mode pencatatan state di migration.bookkeeping
+const (
+	// BookkeepingFiles menyimpan state di datara.sum, datara.snapshots,
+	// schema.json dan schema_hash (default)
+	BookkeepingFiles = "files"
+	// BookkeepingEmbedded menyimpan state di trailer setiap migration,
+	// tanpa file pencatatan terpisah
+	BookkeepingEmbedded = "embedded"
+)
+
+// trailerMarker membuka blok trailer di akhir migration mode embedded
+const trailerMarker = "-- datara:trailer"
+
+// snapshotLineWidth adalah panjang potongan base64 snapshot per baris trailer
+const snapshotLineWidth = 96
+
+// Trailer adalah blok komentar di akhir migration pada mode embedded yang
+// menggantikan datara.sum, datara.snapshots, schema.json dan schema_hash
+type Trailer struct {
+	// Sum adalah checksum isi file sebelum trailer
+	Sum string
+	// From dan To adalah StateHash sebelum dan sesudah migration
+	From, To string
+	// SchemaHash adalah SchemaHash schema program; kosong untuk migration
+	// manual dan generate yang dibatasi -table
+	SchemaHash string
+	// Snapshot adalah snapshot sesudah migration; nil untuk migration manual
+	Snapshot *state.SchemaState
+}
+
+// MixedBookkeepingError dikembalikan jika direktori migration mode embedded
+// berisi migration tanpa trailer yang tidak bisa disegel begitu saja, atau
+// masih berisi file pencatatan mode files
+type MixedBookkeepingError struct {
+	Dir    string
+	Legacy []string
+}
+
+func (e *MixedBookkeepingError) Error() string {
+	return fmt.Sprintf("%s mixes embedded bookkeeping with separate bookkeeping files (%s); run 'datara embed' to convert it",
+		e.Dir, strings.Join(e.Legacy, ", "))
+}
+
+// render menulis trailer sebagai komentar SQL
+func (t *Trailer) render() (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n-- sum %s\n-- from %s\n-- to %s\n", trailerMarker, t.Sum, t.From, t.To)
+	if t.SchemaHash != "" {
+		fmt.Fprintf(&b, "-- schema-hash %s\n", t.SchemaHash)
+	}
+	if t.Snapshot != nil {
+		encoded, err := encodeSnapshot(t.Snapshot)
+		if err != nil {
+			return "", err
+		}
+		for len(encoded) > 0 {
+			n := min(snapshotLineWidth, len(encoded))
+			fmt.Fprintf(&b, "-- snapshot %s\n", encoded[:n])
+			encoded = encoded[n:]
+		}
+	}
+	return b.String(), nil
+}
+
+// encodeSnapshot mengompres snapshot menjadi base64 dari JSON ber-gzip
+func encodeSnapshot(snapshot *state.SchemaState) (string, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeSnapshot membaca snapshot dari encodeSnapshot
+func decodeSnapshot(source, encoded string) (*state.SchemaState, error) {
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot in %s: %w", source, err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot in %s: %w", source, err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot in %s: %w", source, err)
+	}
+	return state.Decode(source, data)
+}
+
+// withTrailer menambahkan trailer ke isi migration. Sum dihitung dari body.
+func withTrailer(body string, t Trailer) (string, error) {
+	if !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	t.Sum = calculateHash(body)
+	trailer, err := t.render()
+	if err != nil {
+		return "", err
+	}
+	return body + "\n" + trailer, nil
+}
+
+// splitTrailer memisahkan isi migration menjadi body dan trailer. Trailer nil
+// jika file tidak punya trailer.
+func splitTrailer(name, content string) (string, *Trailer, error) {
+	at := strings.LastIndex(content, "\n"+trailerMarker+"\n")
+	if at < 0 {
+		return content, nil, nil
+	}
+	// withTrailer memisahkan body dan trailer dengan satu baris kosong
+	body := content[:at]
+
+	t := &Trailer{}
+	var snapshot strings.Builder
+	for _, line := range strings.Split(content[at+len(trailerMarker)+2:], "\n") {
+		key, value, _ := strings.Cut(strings.TrimPrefix(line, "-- "), " ")
+		switch key {
+		case "sum":
+			t.Sum = value
+		case "from":
+			t.From = value
+		case "to":
+			t.To = value
+		case "schema-hash":
+			t.SchemaHash = value
+		case "snapshot":
+			snapshot.WriteString(value)
+		}
+	}
+	if t.Sum == "" {
+		return "", nil, fmt.Errorf("migration %s has a %s block without a sum", name, trailerMarker)
+	}
+	if snapshot.Len() > 0 {
+		var err error
+		if t.Snapshot, err = decodeSnapshot(name, snapshot.String()); err != nil {
+			return "", nil, err
+		}
+	}
+	return body, t, nil
+}
+
+// readTrailer membaca body dan trailer satu file migration
+func readTrailer(dir, name string) (string, *Trailer, error) {
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+	}
+	return splitTrailer(name, string(content))
+}
+
+// sealedMigration adalah satu file migration beserta trailer-nya (nil jika
+// belum disegel)
+type sealedMigration struct {
+	name    string
+	body    string
+	trailer *Trailer
+}
+
+// readSealed membaca semua migration di dir. Jika dir masih berisi file
+// pencatatan mode files, atau migration tanpa trailer berada sebelum
+// migration bertrailer (atau tidak ada yang bertrailer sama sekali),
+// *MixedBookkeepingError dikembalikan. Dengan allowUnsealed, migration tanpa
+// trailer selalu diterima agar bisa disegel; dengan converting, file
+// pencatatan mode files juga diterima (EmbedBookkeeping).
+func readSealed(dir string, pattern *regexp.Regexp, allowUnsealed, converting bool) ([]sealedMigration, error) {
+	files, err := migrationFiles(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	var migrations []sealedMigration
+	lastSealed := -1
+	for i, name := range files {
+		body, trailer, err := readTrailer(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		if trailer != nil {
+			lastSealed = i
+		}
+		migrations = append(migrations, sealedMigration{name: name, body: body, trailer: trailer})
+	}
+
+	var legacy []string
+	for _, file := range []string{SumFile, JournalFile} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil && !converting {
+			legacy = append(legacy, file)
+		}
+	}
+	for i, m := range migrations {
+		if !allowUnsealed && m.trailer == nil && (i < lastSealed || lastSealed < 0) {
+			legacy = append(legacy, m.name)
+		}
+	}
+	if len(legacy) > 0 {
+		return nil, &MixedBookkeepingError{Dir: dir, Legacy: legacy}
+	}
+	return migrations, nil
+}
+
+// EmbeddedState mengembalikan snapshot dan SchemaHash dari trailer terbaru
+// yang menyimpan snapshot. Snapshot kosong dikembalikan jika belum ada.
+func EmbeddedState(dir string, pattern *regexp.Regexp) (*state.SchemaState, string, error) {
+	migrations, err := readSealed(dir, pattern, false, false)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if t := migrations[i].trailer; t != nil && t.Snapshot != nil {
+			return t.Snapshot, t.SchemaHash, nil
+		}
+	}
+	return state.NewSchemaState(), "", nil
+}
+
+// SealMigrations menambahkan trailer ke migration tanpa trailer, mis.
+// migration manual dari 'datara new' setelah diedit. From dan To dihitung
+// dengan menjalankan ulang migration.
+func SealMigrations(dir string, pattern *regexp.Regexp) ([]string, error) {
+	return sealMigrations(dir, pattern, nil)
+}
+
+// sealMigrations sama dengan SealMigrations. journal diisi saat konversi dari
+// mode files: From dan To diambil dari entry datara.snapshots jika ada.
+func sealMigrations(dir string, pattern *regexp.Regexp, journal map[string]JournalEntry) ([]string, error) {
+	migrations, err := readSealed(dir, pattern, true, journal != nil)
+	if err != nil {
+		return nil, err
+	}
+	var sealed []string
+	r := newReplay()
+	for _, m := range migrations {
+		from := StateHash(r.schema)
+		upStart, upEnd := upSection(m.body)
+		r.applyAll(m.body[upStart:upEnd], m.name)
+		if m.trailer != nil {
+			continue
+		}
+		trailer := Trailer{From: from, To: StateHash(r.schema)}
+		if entry, ok := journal[m.name]; ok {
+			trailer.From, trailer.To = entry.From, entry.To
+		}
+		if err := writeTrailer(dir, m.name, m.body, trailer); err != nil {
+			return nil, err
+		}
+		sealed = append(sealed, m.name)
+	}
+	return sealed, nil
+}
+
+// writeTrailer menulis ulang file migration dengan body dan trailer t
+func writeTrailer(dir, name, body string, t Trailer) error {
+	content, err := withTrailer(body, t)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to seal migration %s: %w", name, err)
+	}
+	return nil
+}
+
+// VerifyTrailers memeriksa checksum setiap migration terhadap trailer-nya,
+// seperti VerifySum. Dengan deep, migration juga dijalankan ulang dan hash
+// snapshot sebelum dan sesudahnya dicocokkan dengan From dan To, seperti
+// VerifySnapshots.
+func VerifyTrailers(dir string, pattern *regexp.Regexp, deep bool) error {
+	migrations, err := readSealed(dir, pattern, false, false)
+	if err != nil {
+		return err
+	}
+	r := newReplay()
+	for _, m := range migrations {
+		if m.trailer == nil {
+			return fmt.Errorf("migration %s has no trailer, run 'datara hash -prune'", m.name)
+		}
+		if got := calculateHash(m.body); got != m.trailer.Sum {
+			return &ChecksumMismatchError{File: m.name, Want: m.trailer.Sum, Got: got}
+		}
+		if !deep {
+			continue
+		}
+		before := StateHash(r.schema)
+		upStart, upEnd := upSection(m.body)
+		r.applyAll(m.body[upStart:upEnd], m.name)
+		if m.trailer.From != before {
+			return &SnapshotDivergenceError{File: m.name, Reason: "it was generated from a snapshot the earlier migrations do not produce",
+				Want: m.trailer.From, Got: before}
+		}
+		if after := StateHash(r.schema); after != m.trailer.To {
+			return &SnapshotDivergenceError{File: m.name, Reason: "replaying it does not produce the snapshot recorded when it was generated",
+				Want: m.trailer.To, Got: after}
+		}
+	}
+	return nil
+}
+
+// resealSum menghitung ulang Sum trailer file yang sengaja diubah, mis. oleh
+// hook post_generate atau FixConflicts
+func resealSum(dir, name string) error {
+	body, trailer, err := readTrailer(dir, name)
+	if err != nil || trailer == nil {
+		return err
+	}
+	return writeTrailer(dir, name, body, *trailer)
+}
+
+// SetEmbeddedBookkeeping menyimpan state di trailer migration di
+// migrationDir (migration.bookkeeping = "embedded") alih-alih di schema.json,
+// schema_hash, datara.sum dan datara.snapshots; string kosong berarti mode
+// files
+func (e *Executor) SetEmbeddedBookkeeping(migrationDir string) {
+	e.embedded = migrationDir
+}
+
+// loadEmbeddedState membaca snapshot dan SchemaHash dari trailer. Snapshot
+// mode files yang masih ada membuat direktori dianggap campuran.
+func (e *Executor) loadEmbeddedState() (*state.SchemaState, string, error) {
+	var legacy []string
+	for _, file := range []string{snapshotFile, hashFile, legacySchemaFile} {
+		if _, err := os.Stat(e.path(file)); err == nil {
+			legacy = append(legacy, e.path(file))
+		}
+	}
+	if len(legacy) > 0 {
+		return nil, "", &MixedBookkeepingError{Dir: e.embedded, Legacy: legacy}
+	}
+	return EmbeddedState(e.embedded, e.migrationPattern)
+}
+
+// embedTrailer menambahkan trailer hasil plan ke isi migration. Snapshot
+// hanya ditulis jika withSnapshot, mis. tidak untuk migration VALIDATE
+// CONSTRAINT kedua.
+func (e *Executor) embedTrailer(migration string, plan *Plan, withSnapshot bool) (string, error) {
+	trailer := Trailer{From: StateHash(plan.Current), To: StateHash(plan.Desired)}
+	if withSnapshot {
+		plan.Desired.Version = state.FormatVersion
+		trailer.Snapshot = plan.Desired
+		if !plan.partial {
+			trailer.SchemaHash = plan.hash
+		}
+	} else {
+		trailer.From = trailer.To
+	}
+	return withTrailer(migration, trailer)
+}
+
+// EmbedBookkeeping mengubah direktori migration mode files ke mode embedded:
+// datara.sum diverifikasi, setiap migration diberi trailer (From/To dari
+// datara.snapshots jika ada), snapshot dan SchemaHash ditulis ke trailer
+// migration terakhir, lalu file pencatatan lama dihapus. Nama migration yang
+// diberi trailer dikembalikan.
+func (e *Executor) EmbedBookkeeping() ([]string, error) {
+	dir := e.embedded
+	if dir == "" {
+		return nil, fmt.Errorf("migration.bookkeeping is not %q", BookkeepingEmbedded)
+	}
+	if _, err := os.Stat(e.path(snapshotFile)); os.IsNotExist(err) && !hasLegacySnapshot(e) {
+		return nil, fmt.Errorf("no schema snapshot at %s to embed; %s already uses embedded bookkeeping", e.path(snapshotFile), dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, SumFile)); err == nil {
+		if err := VerifySum(dir, e.migrationPattern); err != nil {
+			return nil, err
+		}
+	}
+	files, err := migrationFiles(dir, e.migrationPattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no migrations in %s to embed the bookkeeping into", dir)
+	}
+
+	snapshot, err := e.loadFileSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema snapshot: %w", err)
+	}
+	hash := e.fileSnapshotHash()
+	entries, err := ReadJournal(dir)
+	if err != nil {
+		return nil, err
+	}
+	journal := make(map[string]JournalEntry, len(entries))
+	for _, entry := range entries {
+		journal[entry.File] = entry
+	}
+
+	sealed, err := sealMigrations(dir, e.migrationPattern, journal)
+	if err != nil {
+		return nil, err
+	}
+	// Snapshot dan hash schema disimpan di trailer migration terakhir
+	last := files[len(files)-1]
+	body, trailer, err := readTrailer(dir, last)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Version = state.FormatVersion
+	trailer.Snapshot, trailer.SchemaHash = snapshot, hash
+	if err := writeTrailer(dir, last, body, *trailer); err != nil {
+		return nil, err
+	}
+
+	for _, path := range []string{filepath.Join(dir, SumFile), filepath.Join(dir, JournalFile),
+		e.path(snapshotFile), e.path(hashFile), e.path(legacySchemaFile)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return sealed, nil
+}
@@ -0,0 +1,119 @@
+package schema
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// editMigration mengganti from dengan to di body migration tanpa menyentuh
+// trailer-nya, seperti hook post_generate yang mengedit file
+func editMigration(t *testing.T, dir, name, from, to string) {
+	t.Helper()
+	body, trailer, err := readTrailer(dir, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(body, from, to, 1)
+	content, err := withTrailer(edited, *trailer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sum lama dipertahankan agar file terlihat diedit setelah disegel
+	content = strings.Replace(content, "-- sum "+calculateHash(edited), "-- sum "+trailer.Sum, 1)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSealVerifyReseal(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "20240101000000_users.sql", "-- migrate:up\nCREATE TABLE users (id INT);\n\n-- migrate:down\nDROP TABLE users;\n")
+	writeTestFile(t, dir, "20240102000000_email.sql", "-- migrate:up\nALTER TABLE users ADD COLUMN email TEXT;\n\n-- migrate:down\nALTER TABLE users DROP COLUMN email;\n")
+
+	// 1. Seal menambahkan trailer ke semua migration, sekali saja
+	sealed, err := SealMigrations(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"20240101000000_users.sql", "20240102000000_email.sql"}; !reflect.DeepEqual(sealed, want) {
+		t.Fatalf("sealed = %v, want %v", sealed, want)
+	}
+	if again, err := SealMigrations(dir, nil); err != nil || len(again) != 0 {
+		t.Errorf("second SealMigrations = %v, %v, want nothing sealed", again, err)
+	}
+	if err := VerifyTrailers(dir, nil, true); err != nil {
+		t.Fatalf("VerifyTrailers after seal = %v", err)
+	}
+
+	// 2. Edit setelah seal terdeteksi sebagai checksum mismatch
+	editMigration(t, dir, "20240102000000_email.sql", "-- migrate:up\n", "-- migrate:up\n-- reviewed\n")
+	var mismatch *ChecksumMismatchError
+	if err := VerifyTrailers(dir, nil, false); !errors.As(err, &mismatch) || mismatch.File != "20240102000000_email.sql" {
+		t.Fatalf("VerifyTrailers after edit = %v, want a checksum mismatch for 20240102000000_email.sql", err)
+	}
+
+	// 3. RehashSum menyegel ulang; edit tanpa perubahan schema lolos deep verify
+	if err := RehashSum(dir, "20240102000000_email.sql"); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyTrailers(dir, nil, true); err != nil {
+		t.Errorf("VerifyTrailers after reseal = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, SumFile)); !os.IsNotExist(err) {
+		t.Errorf("RehashSum created %s in embedded mode", SumFile)
+	}
+
+	// 4. Reseal hanya mencatat ulang Sum, bukan From dan To
+	editMigration(t, dir, "20240102000000_email.sql", "email TEXT;\n", "email TEXT;\nALTER TABLE users ADD COLUMN name TEXT;\n")
+	if err := RehashSum(dir, "20240102000000_email.sql"); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyTrailers(dir, nil, false); err != nil {
+		t.Errorf("VerifyTrailers after reseal = %v", err)
+	}
+	var divergence *SnapshotDivergenceError
+	if err := VerifyTrailers(dir, nil, true); !errors.As(err, &divergence) || divergence.File != "20240102000000_email.sql" {
+		t.Errorf("deep VerifyTrailers after a schema edit = %v, want divergence at 20240102000000_email.sql", err)
+	}
+}
+
+func TestTrailerRoundTrip(t *testing.T) {
+	snapshot, err := ParseSQL("CREATE TABLE users (id INT);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := "-- migrate:up\nCREATE TABLE users (id INT);\n\n-- migrate:down\nDROP TABLE users;\n"
+	want := Trailer{From: "a", To: "b", SchemaHash: "c", Snapshot: snapshot}
+	content, err := withTrailer(body, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotBody, got, err := splitTrailer("m.sql", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Sum = calculateHash(body)
+	if gotBody != body || got == nil || !reflect.DeepEqual(*got, want) {
+		t.Errorf("splitTrailer = %q, %+v, want %q, %+v", gotBody, got, body, want)
+	}
+
+	if _, _, err := splitTrailer("m.sql", body+"\n"+trailerMarker+"\n-- from a\n"); err == nil {
+		t.Error("splitTrailer accepted a trailer without a sum")
+	}
+}
+
+func TestResealWithoutTrailer(t *testing.T) {
+	dir := t.TempDir()
+	content := "-- migrate:up\nCREATE TABLE users (id INT);\n"
+	writeTestFile(t, dir, "20240101000000_users.sql", content)
+	if err := resealSum(dir, "20240101000000_users.sql"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "20240101000000_users.sql")); string(got) != content {
+		t.Errorf("resealSum changed a file without a trailer:\n%s", got)
+	}
+}
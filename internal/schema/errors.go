@@ -0,0 +1,77 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoChanges dikembalikan ketika schema program tidak menghasilkan perubahan
+var ErrNoChanges = errors.New("no changes detected")
+
+// SchemaProgramError dikembalikan ketika program schema keluar dengan status non-zero
+type SchemaProgramError struct {
+	ExitCode int
+	Stderr   string
+}
+
+func (e *SchemaProgramError) Error() string {
+	msg := fmt.Sprintf("schema program failed with exit code %d", e.ExitCode)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += "\n" + stderr
+	}
+	return msg
+}
+
+// emptySchemaStderrLines adalah jumlah baris terakhir stderr yang ditampilkan
+// EmptySchemaError
+const emptySchemaStderrLines = 20
+
+// EmptySchemaError dikembalikan ketika program schema sukses tetapi tidak
+// menulis apa pun ke stdout, mis. karena schema dicetak dengan log alih-alih
+// fmt. Stderr berisi baris terakhir stderr program.
+type EmptySchemaError struct {
+	Stderr string
+}
+
+func (e *EmptySchemaError) Error() string {
+	msg := "schema program printed nothing to stdout; it must print SQL or schema JSON to stdout " +
+		"(log.Print writes to stderr, use fmt.Print), or pass -allow-empty-schema to drop every table"
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += "\nstderr:\n" + stderr
+	}
+	return msg
+}
+
+// tailLines mengembalikan n baris terakhir s
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ChecksumMismatchError dikembalikan ketika checksum file tidak sama dengan yang tersimpan
+type ChecksumMismatchError struct {
+	File string
+	Want string
+	Got  string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: want %s, got %s", e.File, e.Want, e.Got)
+}
+
+// GoNotFoundError dikembalikan ketika schema program dijalankan dengan go
+// tetapi toolchain Go tidak ada, mis. di image deploy yang ramping
+type GoNotFoundError struct {
+	Program []string
+}
+
+func (e *GoNotFoundError) Error() string {
+	return fmt.Sprintf("schema program %q needs the go command, which is not installed here; "+
+		"build it ahead of time with `datara build-schema-program -out ./bin/register` "+
+		"and set schema.program = [\"./bin/register\"] so only the two binaries are needed at runtime",
+		strings.Join(e.Program, " "))
+}
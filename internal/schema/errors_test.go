@@ -0,0 +1,100 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// scriptExecutor membuat Executor dengan schema program skrip shell script
+func scriptExecutor(t *testing.T, script string) *Executor {
+	t.Helper()
+	dir := t.TempDir()
+	writeTestFile(t, dir, "schema.sh", script)
+	return NewExecutor([]string{"/bin/sh", "schema.sh"}, dir, nil)
+}
+
+func TestSchemaProgramError(t *testing.T) {
+	e := scriptExecutor(t, "echo 'model User: unknown type' >&2\nexit 3\n")
+	_, err := e.PlanContext(context.Background())
+	var programErr *SchemaProgramError
+	if !errors.As(err, &programErr) {
+		t.Fatalf("PlanContext() = %v, want *SchemaProgramError", err)
+	}
+	if programErr.ExitCode != 3 || !strings.Contains(programErr.Stderr, "unknown type") {
+		t.Errorf("SchemaProgramError = {ExitCode: %d, Stderr: %q}, want exit code 3 and the program's stderr", programErr.ExitCode, programErr.Stderr)
+	}
+}
+
+func TestEmptySchemaError(t *testing.T) {
+	e := scriptExecutor(t, "echo 'log output' >&2\n")
+	_, err := e.PlanContext(context.Background())
+	var emptyErr *EmptySchemaError
+	if !errors.As(err, &emptyErr) || !strings.Contains(emptyErr.Stderr, "log output") {
+		t.Fatalf("PlanContext() = %v, want *EmptySchemaError with the program's stderr", err)
+	}
+}
+
+func TestErrNoChanges(t *testing.T) {
+	e := scriptExecutor(t, "echo 'CREATE TABLE users (id INT NOT NULL, PRIMARY KEY (id));'\n")
+	if _, err := e.Execute(); err != nil {
+		t.Fatalf("first Execute() = %v", err)
+	}
+	if _, err := e.Execute(); !errors.Is(err, ErrNoChanges) {
+		t.Fatalf("second Execute() = %v, want ErrNoChanges", err)
+	}
+}
+
+func TestChecksumMismatchError(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, dir string)
+		file   string
+	}{
+		{
+			name: "edited migration",
+			tamper: func(t *testing.T, dir string) {
+				writeTestFile(t, dir, "20300101000000.sql", "-- migrate:up\nDROP TABLE users;\n")
+			},
+			file: "20300101000000.sql",
+		},
+		{
+			name: "edited sum",
+			tamper: func(t *testing.T, dir string) {
+				path := filepath.Join(dir, SumFile)
+				sum, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				lines := strings.SplitN(string(sum), "\n", 2)
+				writeTestFile(t, dir, SumFile, "h1:tampered\n"+lines[1])
+			},
+			file: SumFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFile(t, dir, "20300101000000.sql", "-- migrate:up\nCREATE TABLE users (id INT);\n")
+			if _, _, err := UpdateSum(dir, nil, false); err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifySum(dir, nil); err != nil {
+				t.Fatalf("VerifySum() before tampering = %v", err)
+			}
+			tt.tamper(t, dir)
+
+			err := VerifySum(dir, nil)
+			var checksumErr *ChecksumMismatchError
+			if !errors.As(err, &checksumErr) {
+				t.Fatalf("VerifySum() = %v, want *ChecksumMismatchError", err)
+			}
+			if checksumErr.File != tt.file || checksumErr.Want == checksumErr.Got {
+				t.Errorf("ChecksumMismatchError = %+v, want file %s with differing checksums", checksumErr, tt.file)
+			}
+		})
+	}
+}
@@ -1,566 +1,790 @@
 package schema
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/state"
 )
 
 const (
 	migrationsDir = "migrations"
-	schemaFile    = "migrations/schema.sql"
+	snapshotFile  = "migrations/schema.json"
 	hashFile      = "migrations/schema_hash"
+
+	// legacySchemaFile adalah snapshot SQL dari versi lama, di-upgrade ke snapshotFile
+	legacySchemaFile = "migrations/schema.sql"
 )
 
 // Executor menangani eksekusi program schema
 type Executor struct {
 	program []string
-}
+	dir     string
+	diff    *diff.Generator
 
-// NewExecutor membuat instance baru dari Executor
-func NewExecutor(program []string) *Executor {
-	return &Executor{
-		program: program,
-	}
-}
+	// migrationPattern adalah pola nama file migration dari
+	// SetMigrationPattern; nil berarti DefaultMigrationPattern
+	migrationPattern *regexp.Regexp
 
-// Execute menjalankan program schema dan mengembalikan SQL statements
-func (e *Executor) Execute() (string, error) {
-	log.Printf("Starting schema execution with program: %v", e.program)
+	// cache aktif setelah EnableCache
+	cache         bool
+	cacheKeyParts []string
 
-	// Pastikan direktori migrations ada
-	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create migrations directory: %w", err)
-	}
-	log.Printf("Migrations directory ensured: %s", migrationsDir)
+	// pretty diisi SetPrettyFormat; nil berarti statement ditulis apa adanya
+	pretty *FormatOptions
 
-	// Simpan current working directory
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return "", fmt.Errorf("failed to get current directory: %w", err)
-	}
+	// rawDDL adalah raw DDL per nama tabel dari SetRawDDL
+	rawDDL map[string]*state.RawDDL
 
-	// Pastikan path ke register.go relatif terhadap lokasi datara.hcl
-	registerPath := e.program[len(e.program)-1]
-	if !filepath.IsAbs(registerPath) {
-		registerPath = filepath.Join(currentDir, registerPath)
-	}
-	e.program[len(e.program)-1] = registerPath
-	log.Printf("Using register file: %s", registerPath)
+	// indexDescriptions adalah deskripsi index per tabel dan nama index dari
+	// SetIndexDescriptions
+	indexDescriptions map[string]map[string]string
 
-	// Execute program
-	cmd := exec.Command(e.program[0], e.program[1:]...)
-	cmd.Env = os.Environ()               // Pass environment variables
-	cmd.Dir = filepath.Dir(registerPath) // Set working directory ke lokasi register.go
+	// batchSeparator ditulis di baris sendiri setelah setiap statement, mis. GO
+	batchSeparator string
 
-	output, err := cmd.Output()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("schema program failed: %s\n%s", err, exitErr.Stderr)
-		}
-		return "", fmt.Errorf("failed to execute schema program: %w", err)
-	}
-	log.Printf("Successfully executed schema program")
+	// migrationMarkers diisi SetMigrationMarkers; kosong berarti MarkersStrip
+	migrationMarkers string
 
-	// Format output untuk konsistensi
-	newSchema := strings.TrimSpace(string(output))
-	if newSchema == "" {
-		log.Printf("No schema output received")
-		return "", nil
-	}
+	// sanitize diisi SetSanitizeOptions
+	sanitize SanitizeOptions
 
-	// Bersihkan output dari karakter tidak perlu
-	newSchema = cleanOutput(newSchema)
+	// allowEmpty menerima output program yang kosong sebagai schema tanpa tabel
+	allowEmpty bool
 
-	// Format SQL untuk readability
-	newSchema = formatSQL(newSchema)
-	log.Printf("Formatted new schema (length: %d chars)", len(newSchema))
+	// historyTable adalah template nama tabel history; kosong berarti
+	// diff.DefaultHistoryTable
+	historyTable string
 
-	// Baca schema lama
-	oldSchema, err := os.ReadFile(schemaFile)
-	if err != nil && !os.IsNotExist(err) {
-		return "", fmt.Errorf("failed to read schema file: %w", err)
-	}
+	// tables adalah pattern nama tabel dari SetTables; kosong berarti semua tabel
+	tables []string
 
-	// Jika tidak ada schema lama, ini adalah migration pertama
-	if os.IsNotExist(err) {
-		log.Printf("No previous schema found, this is the first migration")
-		// Simpan schema baru
-		if err := saveSchemaState(newSchema); err != nil {
-			return "", fmt.Errorf("failed to save schema state: %w", err)
-		}
-		return formatMigration(
-			newSchema,
-			"DROP TABLE IF EXISTS \"profiles\" CASCADE;\nDROP TABLE IF EXISTS \"users\" CASCADE;",
-		), nil
-	}
+	// syncOrder adalah direktori migration dari SetSyncOrder; kosong berarti
+	// urutan kolom tidak disinkronkan
+	syncOrder string
 
-	log.Printf("Found existing schema (length: %d chars)", len(oldSchema))
+	// twoPhase diisi SetTwoPhase
+	twoPhase bool
 
-	// Generate diff antara schema lama dan baru
-	upSQL, downSQL, err := generateSchemaDiff(string(oldSchema), newSchema)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate schema diff: %w", err)
-	}
+	// embedded adalah direktori migration dari SetEmbeddedBookkeeping;
+	// kosong berarti state disimpan di file terpisah
+	embedded string
 
-	// Jika tidak ada perubahan, return empty
-	if upSQL == "" {
-		return "", nil
-	}
+	// progress diisi SetProgress
+	progress Progress
 
-	// Format migration dengan up dan down
-	migration := formatMigration(upSQL, downSQL)
+	// tracer diisi SetTracer
+	tracer diff.Tracer
+}
 
-	// Simpan schema baru
-	if err := saveSchemaState(newSchema); err != nil {
-		return "", fmt.Errorf("failed to save schema state: %w", err)
-	}
+// Progress menerima laporan kemajuan PlanContext: diff.StageProgram,
+// diff.StageParse lalu diff.StageDiff per tabel
+type Progress = diff.Progress
 
-	return migration, nil
+// SetProgress memasang Progress untuk operasi yang lama, mis. schema program
+// yang lambat atau diff ratusan tabel; nil menonaktifkannya
+func (e *Executor) SetProgress(progress Progress) {
+	e.progress = progress
+	e.diff.SetProgress(progress)
 }
 
-// formatMigration memformat migration dengan up dan down statements
-func formatMigration(upSQL, downSQL string) string {
-	return fmt.Sprintf("-- migrate:up\n\n%s\n\n-- migrate:down\n\n%s", upSQL, downSQL)
+// SetTracer memasang Tracer yang menerima output program, schema hasil parse,
+// snapshot dan bentuk ternormalisasinya; nil menonaktifkannya
+func (e *Executor) SetTracer(tracer diff.Tracer) {
+	e.tracer = tracer
+	e.diff.SetTracer(tracer)
 }
 
-// generateSchemaDiff membandingkan dua schema dan menghasilkan ALTER statements
-func generateSchemaDiff(oldSchema, newSchema string) (string, string, error) {
-	log.Printf("Generating schema diff")
+// trace melaporkan artefak ke Tracer jika terpasang
+func (e *Executor) trace(name string, artifact interface{}) {
+	if e.tracer != nil {
+		e.tracer.Trace(name, artifact)
+	}
+}
 
-	// Parse schema lama dan baru
-	oldTables := parseTables(oldSchema)
-	newTables := parseTables(newSchema)
+// stage melaporkan kemajuan ke Progress jika terpasang
+func (e *Executor) stage(name string, current, total int) {
+	if e.progress != nil {
+		e.progress.OnStage(name, current, total)
+	}
+}
 
-	log.Printf("Found tables - Old: %d, New: %d", len(oldTables), len(newTables))
+// NewExecutor membuat instance baru dari Executor. Path relatif pada program
+// dan direktori snapshot di-resolve terhadap dir; dir kosong berarti cwd.
+func NewExecutor(program []string, dir string, config *diff.Config) *Executor {
+	if config == nil {
+		config = &diff.Config{Dialect: diff.DialectPostgres}
+	}
+	return &Executor{
+		program: program,
+		dir:     dir,
+		diff:    diff.NewGenerator(config),
+	}
+}
 
-	var upStatements, downStatements []string
+// SetMigrationPattern mengatur pola nama file migration
+// (migration.file_pattern) untuk operasi yang membaca direktori migration
+func (e *Executor) SetMigrationPattern(pattern *regexp.Regexp) {
+	e.migrationPattern = pattern
+}
 
-	// 1. Handle dropped tables
-	for tableName := range oldTables {
-		if _, exists := newTables[tableName]; !exists {
-			log.Printf("Table dropped: %s", tableName)
-			// Down: Create table
-			downStatements = append(downStatements, oldTables[tableName])
+// SetBatchSeparator menulis separator (mis. GO untuk sqlcmd dan SSMS) di baris
+// sendiri setelah setiap statement migration; string kosong menonaktifkannya
+func (e *Executor) SetBatchSeparator(separator string) {
+	e.batchSeparator = separator
+}
 
-			// Up: Drop table
-			upStatements = append(upStatements, fmt.Sprintf("DROP TABLE IF EXISTS %q CASCADE", tableName))
-		}
+// separateBatches menambahkan batchSeparator setelah setiap statement
+func (e *Executor) separateBatches(statements []string) []string {
+	separated := make([]string, len(statements))
+	for i, stmt := range statements {
+		separated[i] = stmt + "\n" + e.batchSeparator
 	}
+	return separated
+}
 
-	// 2. Handle new tables
-	for tableName, newTable := range newTables {
-		if _, exists := oldTables[tableName]; !exists {
-			log.Printf("New table added: %s", tableName)
-			// Down: Drop table
-			downStatements = append(downStatements, fmt.Sprintf("DROP TABLE IF EXISTS %q CASCADE", tableName))
+// SetTwoPhase memisahkan VALIDATE CONSTRAINT dari diff.Config.SafeConstraints
+// ke Plan.Validate, sehingga validasi data lama bisa dijalankan sebagai
+// migration kedua, lihat ValidateMigration
+func (e *Executor) SetTwoPhase(twoPhase bool) {
+	e.twoPhase = twoPhase
+}
 
-			// Up: Create table
-			upStatements = append(upStatements, newTable)
+// splitValidations memisahkan statement VALIDATE CONSTRAINT dari statements
+func splitValidations(statements []string) (rest, validations []string) {
+	for _, stmt := range statements {
+		if diff.IsValidation(stmt) {
+			validations = append(validations, stmt)
+		} else {
+			rest = append(rest, stmt)
 		}
 	}
+	return rest, validations
+}
 
-	// 3. Handle modified tables
-	for tableName, newTable := range newTables {
-		oldTable, exists := oldTables[tableName]
-		if !exists {
-			continue // New table, already handled
-		}
+// ValidateMigration merender Plan.Validate sebagai isi migration kedua.
+// Down-nya kosong: constraint di-drop oleh down migration pertama.
+func (e *Executor) ValidateMigration(plan *Plan) (string, error) {
+	migration := e.renderMigration(plan.Validate, nil)
+	if e.embedded != "" {
+		return e.embedTrailer(migration, plan, false)
+	}
+	return migration, nil
+}
 
-		// Compare and generate ALTER TABLE statements
-		upStmts, downStmts := compareTableDefinitions(tableName, oldTable, newTable)
-		if len(upStmts) > 0 {
-			log.Printf("Table modified: %s (%d changes)", tableName, len(upStmts))
-			upStatements = append(upStatements, upStmts...)
-			downStatements = append(downStatements, downStmts...)
+// SetSanitizeOptions mengatur pembersihan output schema program, lihat
+// SanitizeOutput
+func (e *Executor) SetSanitizeOptions(opts SanitizeOptions) {
+	e.sanitize = opts
+}
+
+// SetAllowEmptySchema menerima output schema program yang kosong sebagai
+// schema tanpa tabel, mis. untuk membongkar project. Tanpa ini, output kosong
+// menghasilkan EmptySchemaError.
+func (e *Executor) SetAllowEmptySchema(allow bool) {
+	e.allowEmpty = allow
+}
+
+// SetHistoryTable mengatur template nama tabel history dari directive
+// history, mis. "{table}_history"
+func (e *Executor) SetHistoryTable(template string) {
+	e.historyTable = template
+}
+
+// addHistoryTables menambahkan tabel history untuk setiap tabel dari
+// directive history. Tabel history diletakkan setelah semua tabel lain agar
+// trigger-nya dibuat setelah tabel sumber ada.
+func (e *Executor) addHistoryTables(desired *state.SchemaState, tables []string) state.Warnings {
+	var warnings state.Warnings
+	position := 0
+	for _, table := range desired.Tables {
+		if table.Position > position {
+			position = table.Position
 		}
 	}
-
-	if len(upStatements) == 0 {
-		log.Printf("No changes detected in schema diff")
-		return "", "", nil
+	for _, name := range tables {
+		historyName := diff.HistoryTableName(e.historyTable, name)
+		if _, exists := desired.Tables[historyName]; exists {
+			warnings.Add("history", name, "", "history table %s already exists in the schema", historyName)
+			continue
+		}
+		history := e.diff.HistoryTable(desired.Tables[name], historyName)
+		position++
+		history.Position = position
+		desired.Tables[historyName] = history
 	}
+	return warnings
+}
 
-	// Format statements
-	upSQL := strings.Join(upStatements, ";\n") + ";"
-	downSQL := strings.Join(downStatements, ";\n") + ";"
-
-	log.Printf("[TRACE] Generated upSQL in diff: %s", upSQL)
-	log.Printf("[TRACE] Generated downSQL in diff: %s", downSQL)
+// SetRawDDL memasang SQL mentah per tabel (raw_sql di datara.hcl) ke schema
+// yang dihasilkan program. Raw DDL dari Schema JSON ditimpa untuk tabel yang sama.
+func (e *Executor) SetRawDDL(raw map[string]*state.RawDDL) {
+	e.rawDDL = raw
+}
 
-	return upSQL, downSQL, nil
+// rawDDLString merender raw DDL dalam bentuk kanonik untuk hash schema
+func (e *Executor) rawDDLString() string {
+	var b strings.Builder
+	for _, name := range sortedRawTables(e.rawDDL) {
+		fmt.Fprintf(&b, "-- raw_sql %s %s\n", name, e.rawDDL[name].Hash)
+	}
+	return b.String()
 }
 
-// parseTables mengekstrak definisi tabel dari schema SQL
-func parseTables(schema string) map[string]string {
-	tables := make(map[string]string)
-	statements := strings.Split(schema, ";")
+// sortedRawTables mengembalikan nama tabel raw DDL secara berurutan
+func sortedRawTables(raw map[string]*state.RawDDL) []string {
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt == "" {
+// applyRawDDL memasang raw DDL ke tabel desired. raw_sql untuk tabel yang
+// tidak ada dikembalikan sebagai peringatan.
+func (e *Executor) applyRawDDL(desired *state.SchemaState) state.Warnings {
+	var warnings state.Warnings
+	for _, name := range sortedRawTables(e.rawDDL) {
+		raw := e.rawDDL[name]
+		table, exists := desired.Tables[name]
+		if !exists {
+			warnings.Add("raw-sql", name, "", "raw_sql for unknown table is ignored")
 			continue
 		}
+		table.RawDDL = raw
+		desired.Tables[name] = table
+	}
+	return warnings
+}
 
-		if strings.HasPrefix(stmt, "CREATE TABLE") {
-			// Extract table name
-			tableName := extractTableName(stmt)
-			if tableName != "" {
-				tables[tableName] = stmt
+// SetIndexDescriptions menetapkan deskripsi index per tabel dan nama index,
+// mis. dari blok index di datara.hcl. Deskripsi ini menimpa deskripsi dari
+// schema program dan ikut hash schema.
+func (e *Executor) SetIndexDescriptions(descriptions map[string]map[string]string) {
+	e.indexDescriptions = descriptions
+}
+
+// applyIndexDescriptions memasang deskripsi dari SetIndexDescriptions ke index
+// desired. Deskripsi untuk index yang tidak ada dikembalikan sebagai peringatan.
+func (e *Executor) applyIndexDescriptions(desired *state.SchemaState) state.Warnings {
+	var warnings state.Warnings
+	for _, tableName := range sortedNames(e.indexDescriptions) {
+		descriptions := e.indexDescriptions[tableName]
+		table, exists := desired.Tables[tableName]
+		for _, name := range sortedNames(descriptions) {
+			idx, ok := table.Indexes[name]
+			if !exists || !ok {
+				warnings.Add("index-description", tableName, "", "description for unknown index %s is ignored", name)
+				continue
 			}
+			idx.Description = descriptions[name]
+			table.Indexes[name] = idx
 		}
 	}
+	return warnings
+}
 
-	return tables
+// SetPrettyFormat membuat statement migration dirapikan dengan FormatSQL
+func (e *Executor) SetPrettyFormat(opts FormatOptions) {
+	e.pretty = &opts
 }
 
-// extractTableName mengekstrak nama tabel dari CREATE TABLE statement
-func extractTableName(stmt string) string {
-	parts := strings.Split(stmt, " ")
-	for i, part := range parts {
-		if part == "TABLE" && i+1 < len(parts) {
-			// Remove quotes and any trailing characters
-			name := strings.Trim(parts[i+1], `"() `)
-			return name
-		}
+// prettyStatements memformat setiap statement dengan opsi pretty
+func (e *Executor) prettyStatements(statements []string) []string {
+	formatted := make([]string, len(statements))
+	for i, stmt := range statements {
+		formatted[i] = FormatSQL(stmt, *e.pretty)
 	}
-	return ""
+	return formatted
 }
 
-// compareTableDefinitions membandingkan dua definisi tabel dan menghasilkan ALTER statements
-func compareTableDefinitions(tableName, oldDef, newDef string) ([]string, []string) {
-	var upStatements, downStatements []string
+// path me-resolve path snapshot relatif terhadap direktori executor
+func (e *Executor) path(name string) string {
+	return filepath.Join(e.dir, name)
+}
 
-	// Parse column definitions
-	oldColumns := parseColumns(oldDef)
-	newColumns := parseColumns(newDef)
+// StateFiles mengembalikan path file snapshot yang ditulis Apply, mis. untuk
+// dicadangkan dan dipulihkan jika generate dibatalkan
+func (e *Executor) StateFiles() []string {
+	if e.embedded != "" {
+		return nil
+	}
+	return []string{e.path(snapshotFile), e.path(hashFile), e.path(legacySchemaFile)}
+}
 
-	log.Printf("Comparing table %q - Old columns: %d, New columns: %d",
-		tableName, len(oldColumns), len(newColumns))
+// Plan berisi hasil perbandingan output schema program dengan snapshot terakhir
+type Plan struct {
+	Current *state.SchemaState
+	Desired *state.SchemaState
+	Up      []string
+	Down    []string
+	// Directives adalah directive dari komentar SQL output schema program
+	Directives *Directives
+	// Warnings adalah peringatan dari directive, raw_sql dan diff generator
+	Warnings state.Warnings
+	// Validate adalah VALIDATE CONSTRAINT yang dipisahkan dari Up oleh
+	// SetTwoPhase, untuk ditulis ke migration kedua
+	Validate []string
+
+	// hash adalah SchemaHash schema program, disimpan bersama snapshot
+	hash string
+	// partial menandai plan yang dibatasi SetTables; hash schema lengkap
+	// tidak disimpan agar tabel lain tetap pending
+	partial bool
+}
 
-	// 1. Handle dropped columns
-	for colName := range oldColumns {
-		if _, exists := newColumns[colName]; !exists {
-			log.Printf("Column dropped from %q: %s", tableName, colName)
-			// Down: Add column back
-			stmt := fmt.Sprintf("ALTER TABLE %q ADD COLUMN %s", tableName, oldColumns[colName])
-			downStatements = append(downStatements, stmt)
+// Execute menjalankan program schema dan mengembalikan SQL statements.
+// ErrNoChanges dikembalikan jika schema tidak berubah sejak snapshot terakhir.
+func (e *Executor) Execute() (string, error) {
+	return e.ExecuteContext(context.Background())
+}
 
-			// Up: Drop column
-			stmt = fmt.Sprintf("ALTER TABLE %q DROP COLUMN %q", tableName, colName)
-			upStatements = append(upStatements, stmt)
+// ExecuteContext sama dengan Execute, tetapi program schema dihentikan
+// ketika ctx dibatalkan
+func (e *Executor) ExecuteContext(ctx context.Context) (string, error) {
+	plan, err := e.PlanContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return e.Apply(plan)
+}
+
+// Apply merender migration dari plan hasil PlanContext dan menyimpan
+// snapshot schema-nya. ErrNoChanges dikembalikan jika plan tidak berisi
+// perubahan.
+func (e *Executor) Apply(plan *Plan) (string, error) {
+	// Jika tidak ada perubahan, simpan state (hash mungkin berubah) dan return
+	// empty. Mode embedded hanya menyimpan state bersama migration.
+	if len(plan.Up) == 0 {
+		if e.embedded != "" {
+			return "", ErrNoChanges
 		}
+		if err := e.saveSchemaState(plan); err != nil {
+			return "", fmt.Errorf("failed to save schema state: %w", err)
+		}
+		return "", ErrNoChanges
 	}
 
-	// 2. Handle new columns
-	for colName, colDef := range newColumns {
-		if _, exists := oldColumns[colName]; !exists {
-			log.Printf("New column added to %q: %s", tableName, colName)
-			// Down: Drop column
-			stmt := fmt.Sprintf("ALTER TABLE %q DROP COLUMN %q", tableName, colName)
-			downStatements = append(downStatements, stmt)
+	migration := e.renderMigration(plan.Up, plan.Down)
+	if plan.Directives.DestructiveOK {
+		migration = destructiveMarker + "\n" + migration
+	}
 
-			// Up: Add column
-			// Bersihkan definisi kolom dari karakter yang tidak perlu
-			colDef = cleanColumnDef(colDef)
-			stmt = fmt.Sprintf("ALTER TABLE %q ADD COLUMN %s", tableName, colDef)
-			upStatements = append(upStatements, stmt)
-		}
+	if e.embedded != "" {
+		return e.embedTrailer(migration, plan, true)
 	}
 
-	// 3. Handle modified columns
-	for colName, newColDef := range newColumns {
-		oldColDef, exists := oldColumns[colName]
-		if !exists {
-			continue // New column, already handled
-		}
+	// Simpan schema baru
+	if err := e.saveSchemaState(plan); err != nil {
+		return "", fmt.Errorf("failed to save schema state: %w", err)
+	}
 
-		if oldColDef != newColDef {
-			log.Printf("Column modified in %q: %s", tableName, colName)
-			// Extract type from column definition
-			newType := extractColumnType(newColDef)
-			oldType := extractColumnType(oldColDef)
+	return migration, nil
+}
 
-			// Down: Restore old type
-			downStmt := fmt.Sprintf("ALTER TABLE %q ALTER COLUMN %q TYPE %s", tableName, colName, oldType)
-			downStatements = append(downStatements, downStmt)
+// renderMigration memformat up dan down menjadi isi file migration, dengan
+// pretty format dan batch separator jika diset
+func (e *Executor) renderMigration(up, down []string) string {
+	if e.pretty != nil {
+		up, down = e.prettyStatements(up), e.prettyStatements(down)
+	}
+	if e.batchSeparator != "" {
+		up, down = e.separateBatches(up), e.separateBatches(down)
+	}
+	return formatMigration(up, down)
+}
 
-			// Up: Apply new type
-			upStmt := fmt.Sprintf("ALTER TABLE %q ALTER COLUMN %q TYPE %s", tableName, colName, newType)
-			upStatements = append(upStatements, upStmt)
+// RangeMigration membuat satu migration gabungan dari schema setelah
+// migration since ke schema setelah migration until (kosong berarti migration
+// terakhir), keduanya direkonstruksi dari migration di dir. Perubahan yang
+// dibatalkan di dalam rentang, mis. kolom yang ditambah lalu di-drop, tidak
+// muncul. Snapshot dan datara.sum tidak disentuh.
+func (e *Executor) RangeMigration(dir, since, until string) (string, error) {
+	from, err := SnapshotAt(dir, e.migrationPattern, since)
+	if err != nil {
+		return "", err
+	}
+	to, err := SnapshotAt(dir, e.migrationPattern, until)
+	if err != nil {
+		return "", err
+	}
+	up, err := e.diff.GenerateStatements(from, to)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate up statements: %w", err)
+	}
+	if len(up) == 0 {
+		return "", ErrNoChanges
+	}
+	down, err := e.diff.GenerateDownStatements(to, from)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate down statements: %w", err)
+	}
+	return e.renderMigration(up, down), nil
+}
+
+// PlanContext menjalankan program schema dan membandingkannya dengan snapshot
+// tanpa menulis apa pun. ErrNoChanges dikembalikan jika hash schema tidak berubah.
+func (e *Executor) PlanContext(ctx context.Context) (*Plan, error) {
+	desired, directives, warnings, err := e.programSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.diff.CanonicalForeignKeys(desired)
+
+	// Hash dihitung dari schema terstruktur, sehingga output yang hanya berbeda
+	// urutan statement atau format tidak dianggap perubahan
+	newHash := SchemaHash(desired, directives.String(), e.rawDDLString())
+	log.Printf("Parsed new schema (%d tables, hash %s)", len(desired.Tables), newHash[:12])
+
+	// Baca snapshot terakhir (termasuk format lama)
+	current, err := e.loadSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema snapshot: %w", err)
+	}
+	e.trace(diff.TraceSnapshot, current)
+
+	// Jika hash schema sama dengan yang tersimpan, tidak ada perubahan
+	// Sync order tetap diperiksa karena urutan fisik tidak ikut hash
+	if oldHash := e.SnapshotHash(); oldHash != "" && len(current.Tables) > 0 &&
+		oldHash == newHash && e.syncOrder == "" {
+		log.Printf("Schema hash unchanged, skipping diff")
+		return nil, ErrNoChanges
+	}
+
+	// Atribut yang tidak dimodelkan dari snapshot ikut disimpan ke snapshot baru
+	diff.CarryExtras(current, desired)
+	warnings = append(warnings, directiveWarnings(directives.Apply(current, desired))...)
+	warnings = append(warnings, e.addHistoryTables(desired, directives.History)...)
+	warnings = append(warnings, e.applyRawDDL(desired)...)
+	log.Printf("Found tables - Old: %d, New: %d", len(current.Tables), len(desired.Tables))
+
+	// Generate diff antara snapshot lama dan schema baru
+	plan := &Plan{Current: current, Desired: desired, Directives: directives, hash: newHash}
+	if len(e.tables) > 0 {
+		if plan.Desired, err = e.selectTables(current, desired); err != nil {
+			return nil, err
 		}
+		desired, plan.partial = plan.Desired, true
+	}
+	plan.Up, err = e.diff.GenerateStatements(current, desired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate schema diff: %w", err)
+	}
+	if !directives.DestructiveOK {
+		warnings = append(warnings, undeprecatedDrops(current, desired)...)
+	}
+	plan.Warnings = append(warnings, e.diff.Warnings()...)
+	if err := e.appendSyncOrder(plan); err != nil {
+		return nil, err
+	}
+	if e.twoPhase {
+		plan.Up, plan.Validate = splitValidations(plan.Up)
+	}
+	if len(plan.Up) == 0 {
+		log.Printf("No changes detected in schema diff")
+		return plan, nil
 	}
 
-	return upStatements, downStatements
-}
+	// Down tidak dilaporkan lagi; StageDiff sudah mencakup setiap tabel
+	e.diff.SetProgress(nil)
+	plan.Down, err = e.diff.GenerateDownStatements(desired, current)
+	e.diff.SetProgress(e.progress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate down migration: %w", err)
+	}
 
-// cleanColumnDef membersihkan definisi kolom dari karakter yang tidak perlu
-func cleanColumnDef(def string) string {
-	// Hapus karakter yang tidak perlu
-	def = strings.TrimSpace(def)
-	def = strings.TrimRight(def, ";")
+	return plan, nil
+}
 
-	// Perbaiki format decimal
-	if strings.Contains(def, "decimal(") {
-		// Ekstrak nama kolom
-		parts := strings.SplitN(def, " ", 2)
-		if len(parts) == 2 {
-			colName := parts[0]
-			rest := parts[1]
+// programSchema menjalankan program schema dan mem-parse output-nya, baik
+// SQL maupun Schema JSON, beserta directive dan peringatannya
+func (e *Executor) programSchema(ctx context.Context) (*state.SchemaState, *Directives, state.Warnings, error) {
+	e.stage(diff.StageProgram, 0, 1)
+	rawSchema, err := e.runProgram(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	e.stage(diff.StageProgram, 1, 1)
+
+	// Program boleh menulis dokumen Schema JSON (contract) alih-alih SQL
+	var desired *state.SchemaState
+	var warnings state.Warnings
+	directives := &Directives{}
+	if IsContract(rawSchema) {
+		var contractWarnings state.Warnings
+		if desired, contractWarnings, err = DecodeContract([]byte(rawSchema)); err != nil {
+			return nil, nil, nil, fmt.Errorf("schema program output violates the schema contract:\n%w", err)
+		}
+		warnings = append(warnings, contractWarnings...)
+	} else {
+		// Output yang sudah berupa migration dbmate hanya dipakai bagian up-nya
+		if up, found := stripMigrationMarkers(rawSchema); found {
+			if e.migrationMarkers == MarkersError {
+				return nil, nil, nil, ErrMigrationMarkers
+			}
+			warnings.Add("migration-markers", "", "", "schema program output contains -- migrate:up/down markers; only the up section is used")
+			rawSchema = up
+		}
 
-			// Perbaiki format decimal
-			rest = strings.Replace(rest, "decimal(", "decimal (", -1)
-			rest = strings.Replace(rest, ",", ", ", -1)
+		// Directive dibuang dari SQL, tetapi tetap ikut hash schema
+		directives, rawSchema = ParseDirectives(rawSchema)
 
-			def = colName + " " + rest
+		e.stage(diff.StageParse, 0, 1)
+		var parseWarnings state.Warnings
+		if desired, parseWarnings, err = parseSQL(rawSchema); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to parse schema: %w", err)
 		}
+		warnings = append(warnings, parseWarnings...)
+		e.stage(diff.StageParse, 1, 1)
 	}
 
-	return def
+	warnings = append(warnings, e.applyIndexDescriptions(desired)...)
+	e.trace(diff.TraceParsedSchema, desired)
+	return desired, directives, warnings, nil
 }
 
-// parseColumns mengekstrak definisi kolom dari CREATE TABLE statement
-func parseColumns(tableDef string) map[string]string {
-	columns := make(map[string]string)
+// runProgram menjalankan program schema dan mengembalikan output yang sudah dibersihkan
+func (e *Executor) runProgram(ctx context.Context) (string, error) {
+	log.Printf("Starting schema execution with program: %v", e.program)
 
-	// Extract content between parentheses
-	start := strings.Index(tableDef, "(")
-	end := strings.LastIndex(tableDef, ")")
-	if start == -1 || end == -1 {
-		return columns
+	// Pastikan direktori migrations ada
+	if err := os.MkdirAll(e.path(migrationsDir), 0755); err != nil {
+		return "", fmt.Errorf("failed to create migrations directory: %w", err)
 	}
+	log.Printf("Migrations directory ensured: %s", e.path(migrationsDir))
 
-	// Split dengan mempertahankan tanda kurung
-	columnDefs := splitKeepingParentheses(tableDef[start+1 : end])
-	for _, colDef := range columnDefs {
-		colDef = strings.TrimSpace(colDef)
-		if colDef == "" || strings.HasPrefix(colDef, "PRIMARY KEY") {
-			continue
+	// Pastikan path ke register.go relatif terhadap lokasi datara.hcl
+	registerPath := e.program[len(e.program)-1]
+	if !filepath.IsAbs(registerPath) {
+		base, err := filepath.Abs(e.dir)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve schema directory: %w", err)
 		}
+		registerPath = filepath.Join(base, registerPath)
+	}
+	e.program[len(e.program)-1] = registerPath
+	log.Printf("Using register file: %s", registerPath)
 
-		// Handle decimal type dengan precision
-		if strings.Contains(colDef, "decimal(") {
-			// Ekstrak nama kolom dan tipe data
-			parts := strings.SplitN(colDef, " ", 2)
-			if len(parts) == 2 {
-				colName := strings.Trim(parts[0], `"`)
-				rest := parts[1]
-
-				// Ekstrak precision dan scale
-				precisionStart := strings.Index(rest, "(")
-				precisionEnd := strings.LastIndex(rest, ")")
-				if precisionStart != -1 && precisionEnd != -1 {
-					precision := rest[precisionStart+1 : precisionEnd]
-					precisionParts := strings.Split(precision, ",")
-
-					if len(precisionParts) == 2 {
-						p := strings.TrimSpace(precisionParts[0])
-						s := strings.TrimSpace(precisionParts[1])
-
-						// Tambahkan default value jika ada
-						defaultValue := ""
-						if precisionEnd+1 < len(rest) {
-							defaultValue = strings.TrimSpace(rest[precisionEnd+1:])
-						}
-
-						rest = fmt.Sprintf("decimal(%s,%s) %s", p, s, defaultValue)
-					}
-				}
-
-				columns[colName] = colName + " " + rest
-				continue
+	var cacheKey string
+	if e.cache {
+		key, err := e.cacheKey(registerPath)
+		switch {
+		case err != nil:
+			log.Printf("Schema cache disabled: %v", err)
+		case key == "":
+			log.Printf("Schema cache disabled: %s is not in a Go module", registerPath)
+		default:
+			if cached, ok := e.cachedOutput(key); ok {
+				log.Printf("Using cached schema program output %s", key[:12])
+				return cached, nil
 			}
 		}
+		cacheKey = key
+	}
 
-		parts := strings.SplitN(colDef, " ", 2)
-		if len(parts) == 2 {
-			name := strings.Trim(parts[0], `"`)
-			columns[name] = colDef
+	// Execute program
+	cmd := exec.CommandContext(ctx, e.program[0], e.program[1:]...)
+	cmd.Env = os.Environ()               // Pass environment variables
+	cmd.Dir = filepath.Dir(registerPath) // Set working directory ke lokasi register.go
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", fmt.Errorf("schema program interrupted: %w", ctxErr)
+		}
+		if goNotFound(e.program, err, stderr.String()) {
+			return "", &GoNotFoundError{Program: e.program}
 		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", &SchemaProgramError{ExitCode: exitErr.ExitCode(), Stderr: stderr.String()}
+		}
+		return "", fmt.Errorf("failed to execute schema program: %w", err)
 	}
+	log.Printf("Successfully executed schema program")
 
-	return columns
-}
-
-// splitKeepingParentheses memisahkan string dengan koma tapi mempertahankan tanda kurung
-func splitKeepingParentheses(s string) []string {
-	var result []string
-	var current strings.Builder
-	parenCount := 0
-
-	for i := 0; i < len(s); i++ {
-		switch s[i] {
-		case '(':
-			parenCount++
-			current.WriteByte(s[i])
-		case ')':
-			parenCount--
-			current.WriteByte(s[i])
-		case ',':
-			if parenCount == 0 {
-				result = append(result, current.String())
-				current.Reset()
-			} else {
-				current.WriteByte(s[i])
-			}
-		default:
-			current.WriteByte(s[i])
+	// Buang artefak terminal, lalu bersihkan dari karakter tidak perlu
+	e.trace(diff.TraceRawOutput, string(output))
+	newSchema := cleanOutput(SanitizeOutput(string(output), e.sanitize))
+	e.trace(diff.TraceSanitizedOutput, newSchema)
+	if strings.TrimSpace(newSchema) == "" {
+		if !e.allowEmpty {
+			return "", &EmptySchemaError{Stderr: tailLines(stderr.String(), emptySchemaStderrLines)}
 		}
+		log.Printf("Schema program printed an empty schema")
+		newSchema = ""
 	}
-
-	if current.Len() > 0 {
-		result = append(result, current.String())
+	if cacheKey != "" {
+		e.storeOutput(cacheKey, newSchema)
 	}
+	return newSchema, nil
+}
 
-	return result
+// goNotFound melaporkan apakah program gagal karena command go tidak ada:
+// langsung (program[0] adalah go) atau dari shell yang menjalankannya dan
+// keluar dengan status 127
+func goNotFound(program []string, err error, stderr string) bool {
+	if errors.Is(err, exec.ErrNotFound) {
+		return filepath.Base(program[0]) == "go"
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 127 {
+		return false
+	}
+	return strings.Contains(stderr, "go: command not found") || strings.Contains(stderr, "go: not found")
 }
 
-// extractColumnType mengekstrak tipe data dari definisi kolom
-func extractColumnType(colDef string) string {
-	parts := strings.SplitN(colDef, " ", 2)
-	if len(parts) != 2 {
-		return ""
+// loadSnapshot membaca snapshot schema terakhir. Jika hanya ada snapshot SQL
+// dari versi lama (schema.sql), snapshot tersebut di-parse; file lamanya
+// diganti schema.json saat state disimpan berikutnya.
+func (e *Executor) loadSnapshot() (*state.SchemaState, error) {
+	if e.embedded != "" {
+		snapshot, _, err := e.loadEmbeddedState()
+		return snapshot, err
 	}
-	// Ambil bagian setelah nama kolom dan hapus constraint
-	typeParts := strings.Split(parts[1], " ")
-	return typeParts[0]
+	return e.loadFileSnapshot()
 }
 
-// cleanOutput membersihkan output dari karakter tidak perlu
-func cleanOutput(sql string) string {
-	// Hapus karakter % di akhir dan whitespace
-	sql = strings.TrimRight(sql, "% \t\n\r")
+// loadFileSnapshot membaca snapshot dari schema.json (atau schema.sql lama)
+func (e *Executor) loadFileSnapshot() (*state.SchemaState, error) {
+	e.pendingUpgradeNotice()
+	if _, err := os.Stat(e.path(snapshotFile)); err == nil {
+		return state.LoadFromFile(e.path(snapshotFile))
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to stat snapshot file: %w", err)
+	}
 
-	// Hapus whitespace berlebih di setiap baris
-	lines := strings.Split(sql, "\n")
-	var cleaned []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			cleaned = append(cleaned, line)
-		}
+	if !hasLegacySnapshot(e) {
+		log.Printf("No previous schema found, this is the first migration")
+		return state.NewSchemaState(), nil
 	}
-	return strings.Join(cleaned, "\n")
+	log.Printf("Reading legacy snapshot %s", legacySchemaFile)
+	return e.readLegacySnapshot()
 }
 
-// calculateHash menghitung hash SHA-256 dari string
-func calculateHash(s string) string {
-	h := sha256.New()
-	h.Write([]byte(s))
-	return hex.EncodeToString(h.Sum(nil))
+// Snapshot membaca snapshot schema terakhir tanpa menjalankan program schema
+func (e *Executor) Snapshot() (*state.SchemaState, error) {
+	return e.loadSnapshot()
 }
 
-// saveSchemaState menyimpan state schema ke file
-func saveSchemaState(schema string) error {
-	// Simpan schema
-	if err := os.WriteFile(schemaFile, []byte(schema), 0644); err != nil {
-		return fmt.Errorf("failed to save schema file: %w", err)
+// ImportSnapshot menyimpan snapshot hasil ImportMigrations. Hash schema
+// dihapus agar generate berikutnya selalu membandingkan output schema program
+// dengan snapshot ini.
+func (e *Executor) ImportSnapshot(snapshot *state.SchemaState) error {
+	if e.embedded != "" {
+		return fmt.Errorf("import needs migration.bookkeeping = %q; import first, then switch to %q and run 'datara embed'",
+			BookkeepingFiles, BookkeepingEmbedded)
 	}
-
-	// Hitung dan simpan hash
-	hash := calculateHash(normalizeSchema(schema))
-	if err := os.WriteFile(hashFile, []byte(hash), 0644); err != nil {
-		return fmt.Errorf("failed to save hash file: %w", err)
+	snapshot.Version = state.FormatVersion
+	if err := snapshot.SaveToFile(e.path(snapshotFile)); err != nil {
+		return fmt.Errorf("failed to save snapshot file: %w", err)
+	}
+	if err := os.Remove(e.path(hashFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove hash file: %w", err)
 	}
-
 	return nil
 }
 
-// formatSQL memformat SQL untuk readability
-func formatSQL(sql string) string {
-	// Split statements
-	statements := strings.Split(sql, ";")
-	var formatted []string
-
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt == "" {
-			continue
-		}
-
-		// Format berdasarkan tipe statement
-		if strings.HasPrefix(stmt, "CREATE TABLE") {
-			stmt = formatCreateTable(stmt)
-		} else if strings.HasPrefix(stmt, "CREATE") {
-			stmt = formatCreateIndex(stmt)
+// SnapshotHash mengembalikan hash schema yang disimpan bersama snapshot, atau
+// string kosong jika belum ada
+func (e *Executor) SnapshotHash() string {
+	if e.embedded != "" {
+		_, hash, err := e.loadEmbeddedState()
+		if err != nil {
+			return ""
 		}
+		return hash
+	}
+	return e.fileSnapshotHash()
+}
 
-		formatted = append(formatted, stmt)
+// fileSnapshotHash membaca SchemaHash dari schema_hash
+func (e *Executor) fileSnapshotHash() string {
+	hash, err := os.ReadFile(e.path(hashFile))
+	if err != nil {
+		return ""
 	}
+	return strings.TrimSpace(string(hash))
+}
 
-	return strings.Join(formatted, ";\n\n") + ";"
+// formatMigration memformat migration dengan up dan down statements. Bagian
+// yang berisi operasi non-transaksional (CONCURRENTLY) ditandai untuk dbmate.
+func formatMigration(up, down []string) string {
+	return fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
+		migrationMarker("-- migrate:up", up), strings.Join(up, "\n\n"),
+		migrationMarker("-- migrate:down", down), strings.Join(down, "\n\n"))
 }
 
-// formatCreateTable memformat CREATE TABLE statement
-func formatCreateTable(sql string) string {
-	// Extract table name dan column definitions
-	parts := strings.SplitN(sql, "(", 2)
-	if len(parts) != 2 {
-		return sql
+func migrationMarker(marker string, statements []string) string {
+	if diff.NonTransactional(statements) {
+		return marker + " transaction:false"
 	}
+	return marker
+}
 
-	tableDef := strings.TrimSpace(parts[0])
-	columnDefs := strings.TrimSpace(strings.TrimSuffix(parts[1], ")"))
-
-	// Format column definitions
-	columns := strings.Split(columnDefs, ",")
-	var formattedColumns []string
-	for _, col := range columns {
-		col = strings.TrimSpace(col)
-		if strings.HasPrefix(col, "PRIMARY KEY") {
-			formattedColumns = append(formattedColumns, "  "+col)
-		} else {
-			formattedColumns = append(formattedColumns, "  "+col)
+// cleanOutput membersihkan output yang sudah melewati SanitizeOutput dari
+// karakter tidak perlu
+func cleanOutput(sql string) string {
+	// Hapus whitespace berlebih di setiap baris
+	lines := strings.Split(sql, "\n")
+	var cleaned []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			cleaned = append(cleaned, line)
 		}
 	}
-
-	return fmt.Sprintf("%s (\n%s\n)", tableDef, strings.Join(formattedColumns, ",\n"))
+	return strings.Join(cleaned, "\n")
 }
 
-// formatCreateIndex memformat CREATE INDEX statement
-func formatCreateIndex(sql string) string {
-	return strings.TrimSpace(sql)
+// calculateHash menghitung hash SHA-256 dari string
+func calculateHash(s string) string {
+	h := sha256.New()
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))
 }
 
-// normalizeSchema menormalkan schema untuk perbandingan yang konsisten
-func normalizeSchema(schema string) string {
-	// Split menjadi statements individual
-	statements := strings.Split(schema, ";")
-	var normalized []string
+// saveSchemaState menyimpan snapshot schema plan beserta SchemaHash-nya
+func (e *Executor) saveSchemaState(plan *Plan) error {
+	// Simpan snapshot dengan format terbaru
+	snapshot := plan.Desired
+	snapshot.Version = state.FormatVersion
+	if err := snapshot.SaveToFile(e.path(snapshotFile)); err != nil {
+		return fmt.Errorf("failed to save snapshot file: %w", err)
+	}
 
-	// Group statements berdasarkan tipe
-	var creates, indexes, others []string
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt == "" {
-			continue
+	// Hitung dan simpan hash. Snapshot parsial tidak sesuai dengan schema
+	// lengkap, jadi hash lama dihapus agar generate berikutnya tetap diff.
+	if plan.partial {
+		if err := os.Remove(e.path(hashFile)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove hash file: %w", err)
 		}
-
-		switch {
-		case strings.HasPrefix(stmt, "CREATE TABLE"):
-			creates = append(creates, stmt)
-		case strings.HasPrefix(stmt, "CREATE UNIQUE INDEX") || strings.HasPrefix(stmt, "CREATE INDEX"):
-			indexes = append(indexes, stmt)
-		default:
-			others = append(others, stmt)
+	} else {
+		if err := os.WriteFile(e.path(hashFile), []byte(plan.hash), 0644); err != nil {
+			return fmt.Errorf("failed to save hash file: %w", err)
 		}
 	}
 
-	// Sort setiap group
-	sort.Strings(creates)
-	sort.Strings(indexes)
-	sort.Strings(others)
-
-	// Gabungkan kembali dalam urutan yang konsisten
-	normalized = append(normalized, creates...)
-	normalized = append(normalized, indexes...)
-	normalized = append(normalized, others...)
+	// Snapshot SQL lama sudah digantikan schema.json
+	if err := os.Remove(e.path(legacySchemaFile)); err == nil {
+		log.Printf("Upgraded legacy snapshot %s to %s", legacySchemaFile, snapshotFile)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove legacy schema file: %w", err)
+	}
 
-	return strings.Join(normalized, ";\n") + ";"
+	return nil
 }
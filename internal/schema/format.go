@@ -0,0 +1,309 @@
+package schema
+
+import (
+	"strings"
+)
+
+// FormatOptions mengatur gaya FormatSQL
+type FormatOptions struct {
+	// Indent dipakai untuk definisi kolom dan constraint; default dua spasi
+	Indent string
+	// UppercaseKeywords menulis keyword SQL (CREATE, NOT NULL, ...) dengan huruf besar
+	UppercaseKeywords bool
+	// MaxLineWidth membuat daftar kolom index yang lebih panjang dari nilai ini
+	// ditulis satu kolom per baris; 0 berarti tidak pernah dipecah
+	MaxLineWidth int
+}
+
+// formatKeywords adalah keyword yang di-uppercase dengan UppercaseKeywords.
+// Kata yang sering dipakai sebagai nama kolom (type, comment) tidak ikut.
+var formatKeywords = map[string]bool{
+	"ADD": true, "ALTER": true, "AUTO_INCREMENT": true, "CASCADE": true,
+	"CHARACTER": true, "CHARSET": true, "CHECK": true, "COLLATE": true,
+	"COLUMN": true, "CONCURRENTLY": true, "CONSTRAINT": true, "CREATE": true,
+	"DEFAULT": true, "DELETE": true, "DROP": true, "EXISTS": true,
+	"FOREIGN": true, "IF": true, "INCLUDE": true, "INDEX": true, "KEY": true, "MODIFY": true,
+	"NOT": true, "NULL": true, "ON": true, "PRIMARY": true, "REFERENCES": true,
+	"RENAME": true, "RESTRICT": true, "SET": true, "TABLE": true, "TO": true,
+	"UNIQUE": true, "UPDATE": true, "USING": true,
+}
+
+// formatStatement adalah satu statement beserta komentar baris di atasnya
+type formatStatement struct {
+	comments []string
+	body     string
+}
+
+// FormatSQL merapikan SQL: setiap statement diakhiri ';' dan dipisah baris
+// kosong, definisi CREATE TABLE ditulis satu per baris, dan daftar kolom
+// CREATE INDEX yang panjang dipecah. Koma di dalam tanda kurung (mis.
+// decimal(10,2)) dan string literal tidak memecah definisi. Komentar baris di
+// atas statement dipertahankan; statement yang berisi komentar di tengahnya
+// tidak diubah.
+func FormatSQL(sql string, opts FormatOptions) string {
+	if opts.Indent == "" {
+		opts.Indent = "  "
+	}
+
+	var formatted []string
+	for _, stmt := range splitFormatStatements(sql) {
+		var b strings.Builder
+		for _, comment := range stmt.comments {
+			b.WriteString(comment + "\n")
+		}
+		if stmt.body != "" {
+			b.WriteString(formatBody(stmt.body, opts) + ";")
+		}
+		formatted = append(formatted, strings.TrimRight(b.String(), "\n"))
+	}
+	return strings.Join(formatted, "\n\n")
+}
+
+// formatBody memformat satu statement tanpa ';'
+func formatBody(body string, opts FormatOptions) string {
+	// Body dollar quote adalah kode prosedural yang tidak boleh dirapikan
+	if hasLineComment(body) || hasDollarQuote(body) {
+		return body
+	}
+	body = collapseSpaces(body)
+	if opts.UppercaseKeywords {
+		body = uppercaseKeywords(body)
+	}
+
+	upper := strings.ToUpper(body)
+	switch {
+	case strings.HasPrefix(upper, "CREATE TABLE"):
+		return formatTable(body, opts)
+	case strings.HasPrefix(upper, "CREATE INDEX"), strings.HasPrefix(upper, "CREATE UNIQUE INDEX"):
+		return formatIndex(body, opts)
+	}
+	return body
+}
+
+// formatTable menulis setiap definisi CREATE TABLE di barisnya sendiri
+func formatTable(body string, opts FormatOptions) string {
+	open := indexOutsideQuotes(body, '(')
+	if open == -1 {
+		return body
+	}
+	end := closingParen(body, open)
+	if end == -1 {
+		return body
+	}
+
+	var defs []string
+	for _, def := range splitKeepingParentheses(body[open+1 : end]) {
+		if def = strings.TrimSpace(def); def != "" {
+			defs = append(defs, opts.Indent+def)
+		}
+	}
+	out := strings.TrimSpace(body[:open]) + " (\n" + strings.Join(defs, ",\n") + "\n)"
+	if rest := strings.TrimSpace(body[end+1:]); rest != "" {
+		out += " " + rest
+	}
+	return out
+}
+
+// formatIndex memecah daftar kolom CREATE INDEX yang melebihi MaxLineWidth
+func formatIndex(body string, opts FormatOptions) string {
+	if opts.MaxLineWidth <= 0 || len(body) <= opts.MaxLineWidth {
+		return body
+	}
+	open := indexOutsideQuotes(body, '(')
+	if open == -1 {
+		return body
+	}
+	end := closingParen(body, open)
+	if end == -1 {
+		return body
+	}
+
+	var columns []string
+	for _, col := range splitKeepingParentheses(body[open+1 : end]) {
+		columns = append(columns, opts.Indent+strings.TrimSpace(col))
+	}
+	out := strings.TrimSpace(body[:open]) + " (\n" + strings.Join(columns, ",\n") + "\n)"
+	if rest := strings.TrimSpace(body[end+1:]); rest != "" {
+		out += " " + rest
+	}
+	return out
+}
+
+// splitFormatStatements memisahkan SQL dengan splitStatementSpans, sehingga
+// ';' di dalam string literal dan body dollar quote ($$ atau $tag$) tidak
+// memecah statement. Komentar baris sebelum statement disimpan di comments;
+// komentar setelah statement terakhir menjadi statement tanpa body.
+func splitFormatStatements(sql string) []formatStatement {
+	var statements []formatStatement
+	prev := 0
+	for _, span := range splitStatementSpans(sql) {
+		body := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(sql[span.Start:span.End]), ";"))
+		statements = append(statements, formatStatement{comments: lineComments(sql[prev:span.Start]), body: body})
+		prev = span.End
+	}
+	if comments := lineComments(sql[prev:]); len(comments) > 0 {
+		statements = append(statements, formatStatement{comments: comments})
+	}
+	return statements
+}
+
+// lineComments mengembalikan komentar baris '--' di antara dua statement
+func lineComments(sql string) []string {
+	var comments []string
+	for _, line := range strings.Split(sql, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "--") {
+			comments = append(comments, line)
+		}
+	}
+	return comments
+}
+
+// hasDollarQuote mengecek apakah statement berisi dollar quote Postgres,
+// mis. body DO block atau fungsi trigger
+func hasDollarQuote(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '$' && dollarTag(s[i:]) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLineComment mengecek apakah statement berisi komentar '--' di luar quote
+func hasLineComment(s string) bool {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && i+1 < len(s) && s[i+1] == '-':
+			return true
+		}
+	}
+	return false
+}
+
+// collapseSpaces mengganti deretan whitespace di luar quote dengan satu spasi
+// dan membuang spasi tepat setelah '(' atau sebelum ')'
+func collapseSpaces(s string) string {
+	var b strings.Builder
+	var quote, last byte
+	space := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			b.WriteByte(c)
+			last = c
+			continue
+		}
+		if isSpace(c) {
+			space = true
+			continue
+		}
+		if space && last != 0 && last != '(' && c != ')' && c != ',' {
+			b.WriteByte(' ')
+		}
+		space = false
+		if c == '\'' || c == '"' || c == '`' {
+			quote = c
+		}
+		b.WriteByte(c)
+		last = c
+	}
+	return b.String()
+}
+
+// uppercaseKeywords menulis formatKeywords dengan huruf besar, kecuali di
+// dalam string literal dan identifier ber-quote
+func uppercaseKeywords(s string) string {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			b.WriteByte(c)
+			i++
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			b.WriteByte(c)
+			i++
+		case isWordByte(c):
+			j := i
+			for j < len(s) && isWordByte(s[j]) {
+				j++
+			}
+			word := s[i:j]
+			if formatKeywords[strings.ToUpper(word)] {
+				word = strings.ToUpper(word)
+			}
+			b.WriteString(word)
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// isWordByte mengecek karakter yang bisa menjadi bagian keyword atau identifier
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// indexOutsideQuotes mengembalikan posisi pertama target di luar quote
+func indexOutsideQuotes(s string, target byte) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == target:
+			return i
+		}
+	}
+	return -1
+}
+
+// closingParen mengembalikan posisi ')' yang menutup '(' di open, dengan
+// mengabaikan tanda kurung di dalam quote
+func closingParen(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
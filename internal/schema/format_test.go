@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+// backfillBlock adalah DO block backfill -expand-not-null (Postgres)
+const backfillBlock = `DO $$
+DECLARE
+  updated integer;
+BEGIN
+  LOOP
+    UPDATE "users" SET "age" = 0
+    WHERE ctid IN (SELECT ctid FROM "users" WHERE "age" IS NULL LIMIT 1000);
+    GET DIAGNOSTICS updated = ROW_COUNT;
+    EXIT WHEN updated = 0;
+    PERFORM pg_sleep(0.1);
+  END LOOP;
+END
+$$;`
+
+// onUpdateFunction adalah fungsi trigger ON UPDATE (Postgres)
+const onUpdateFunction = `CREATE OR REPLACE FUNCTION users_set_updated_at() RETURNS trigger AS $$ BEGIN NEW.updated_at := now(); RETURN NEW; END; $$ LANGUAGE plpgsql;`
+
+func TestFormatSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		opts FormatOptions
+		want string
+	}{
+		{
+			name: "decimal precision stays in one definition",
+			sql:  "CREATE TABLE orders (id INT NOT NULL, total decimal(10,2) NOT NULL DEFAULT 0.00, PRIMARY KEY (id));",
+			want: "CREATE TABLE orders (\n  id INT NOT NULL,\n  total decimal(10,2) NOT NULL DEFAULT 0.00,\n  PRIMARY KEY (id)\n);",
+		},
+		{
+			name: "commas in string literals",
+			sql:  "CREATE TABLE t (status ENUM('a,b','c') DEFAULT 'a,b');",
+			want: "CREATE TABLE t (\n  status ENUM('a,b','c') DEFAULT 'a,b'\n);",
+		},
+		{
+			name: "uppercase keywords",
+			sql:  "create table t (id int not null);",
+			opts: FormatOptions{UppercaseKeywords: true},
+			want: "CREATE TABLE t (\n  id int NOT NULL\n);",
+		},
+		{
+			name: "long index column list",
+			sql:  "CREATE INDEX idx_orders_customer_created ON orders (customer_id, created_at, status);",
+			opts: FormatOptions{MaxLineWidth: 40},
+			want: "CREATE INDEX idx_orders_customer_created ON orders (\n  customer_id,\n  created_at,\n  status\n);",
+		},
+		{
+			name: "comments above statements",
+			sql:  "-- datara: users\nDROP TABLE users;\n-- trailing",
+			want: "-- datara: users\nDROP TABLE users;\n\n-- trailing",
+		},
+		{
+			name: "semicolons inside a DO block",
+			sql:  "ALTER TABLE users ADD COLUMN age INT;\n\n" + backfillBlock + "\n\nALTER TABLE users ALTER COLUMN age SET NOT NULL;",
+			want: "ALTER TABLE users ADD COLUMN age INT;\n\n" + backfillBlock + "\n\nALTER TABLE users ALTER COLUMN age SET NOT NULL;",
+		},
+		{
+			name: "trigger function body is not reflowed",
+			sql:  onUpdateFunction,
+			opts: FormatOptions{UppercaseKeywords: true},
+			want: onUpdateFunction,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatSQL(tt.sql, tt.opts); got != tt.want {
+				t.Errorf("FormatSQL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSQLStatementCount(t *testing.T) {
+	sql := backfillBlock + "\n" + onUpdateFunction
+	if got := strings.Count(FormatSQL(sql, FormatOptions{}), "\n\n"); got != 1 {
+		t.Errorf("FormatSQL() wrote %d statements, want 2", got+1)
+	}
+}
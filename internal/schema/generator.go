@@ -2,15 +2,21 @@ package schema
 
 import (
 	"fmt"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 
+	"github.com/akmalulginan/datara/internal/diff"
 	"github.com/akmalulginan/datara/internal/state"
 )
 
 // Generator menangani konversi dari struct Go ke schema database
 type Generator struct {
 	config *Config
+	// warnings dikumpulkan oleh GenerateSchema terakhir
+	warnings state.Warnings
 }
 
 // Config menyimpan konfigurasi untuk generator
@@ -19,6 +25,52 @@ type Config struct {
 	TableSuffix  string
 	UseSnakeCase bool
 	UsePlural    bool
+
+	// SpecialFields memberi definisi default untuk kolom dengan nama tertentu
+	// (mis. "created_at"), berlaku untuk tipe Go apa pun
+	SpecialFields map[string]ColumnSpec
+	// GoTypes memberi definisi default untuk field dengan tipe Go tertentu
+	// (tanpa pointer), mis. "geo.Location" ke POINT dengan SRID 4326. Tipe
+	// orb dari github.com/paulmach/orb sudah dipetakan ke tipe spatial.
+	GoTypes map[string]ColumnSpec
+	// StringHeuristics memberi definisi default untuk field string yang namanya
+	// cocok dengan pola. Aturan pertama yang cocok dipakai.
+	StringHeuristics []HeuristicRule
+	// StrictTags mengubah peringatan key db atau rel tag yang tidak dikenal
+	// menjadi error
+	StrictTags bool
+	// DefaultStringLength adalah panjang VARCHAR untuk field string tanpa
+	// type atau length; 0 berarti 255. Jika diisi, kolom dengan panjang
+	// default diberi diff=ignore-width agar mengubah nilai ini hanya
+	// berlaku untuk kolom baru.
+	//
+	// DefaultStringLength dan TextThreshold hanya bisa diisi oleh pemanggil
+	// Generator dari kode Go; CLI membaca schema dari output schema program
+	// dan tidak memakai Generator, jadi tidak ada key datara.hcl untuknya.
+	DefaultStringLength int
+	// TextThreshold memetakan field string dengan size=N di atas nilai ini
+	// ke TEXT, MEDIUMTEXT atau LONGTEXT; 0 berarti size diabaikan
+	TextThreshold int
+}
+
+// ColumnSpec adalah definisi kolom bawaan untuk SpecialFields dan
+// StringHeuristics. Field kosong berarti tidak mengubah hasil tipe Go.
+// Tag db pada field selalu menang atas ColumnSpec.
+type ColumnSpec struct {
+	Type          string
+	Nullable      *bool
+	Default       string
+	Unique        bool
+	AutoIncrement bool
+	// SRID untuk tipe spatial, mis. 4326
+	SRID int
+}
+
+// HeuristicRule memetakan nama kolom yang cocok dengan Pattern (glob path.Match,
+// mis. "*_email") ke ColumnSpec
+type HeuristicRule struct {
+	Pattern string
+	ColumnSpec
 }
 
 // NewGenerator membuat instance baru dari Generator
@@ -32,29 +84,71 @@ func NewGenerator(config *Config) *Generator {
 	return &Generator{config: config}
 }
 
-// GenerateSchema mengkonversi struct Go ke SchemaState
+// GenerateSchema mengkonversi struct Go ke SchemaState. Foreign key dari
+// rel tag dibuat setelah semua tabel ada. Dua struct yang menghasilkan nama
+// tabel yang sama (mis. User dari dua package, atau User dan Users) ditolak
+// dengan *DuplicateTableError, kecuali strukturnya identik.
 func (g *Generator) GenerateSchema(models ...interface{}) (*state.SchemaState, error) {
 	schema := state.NewSchemaState()
+	g.warnings = nil
+	unique := newTableSet(schema)
 
+	tables := make(map[string]string)
 	for _, model := range models {
-		table, err := g.generateTable(model)
+		if modelInfo, ok := model.(*modelInfo); ok {
+			tables[modelInfo.Name] = g.formatTableName(modelInfo.Name)
+		}
+	}
+
+	var relations []relation
+	for _, model := range models {
+		table, tableRelations, err := g.generateTable(model, tables)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate table for model: %w", err)
 		}
-		schema.AddTable(table)
+		if err := unique.add(table, modelOrigin(model.(*modelInfo))); err != nil {
+			return nil, err
+		}
+		relations = append(relations, tableRelations...)
 	}
+	g.warnings = append(g.warnings, unique.warnings...)
 
+	if err := g.resolveRelations(schema, tables, relations); err != nil {
+		return nil, err
+	}
 	return schema, nil
 }
 
-// generateTable mengkonversi struct ke Table
-func (g *Generator) generateTable(model interface{}) (state.Table, error) {
-	modelInfo, ok := model.(*struct {
-		Name   string
-		Fields map[string]interface{}
-	})
+// Warnings mengembalikan peringatan dari GenerateSchema terakhir, mis. key
+// tag yang tidak dikenal
+func (g *Generator) Warnings() state.Warnings {
+	return g.warnings
+}
+
+// modelInfo adalah bentuk model yang diterima GenerateSchema. PkgPath
+// (reflect.Type.PkgPath) hanya dipakai untuk menyebut asal struct di pesan
+// error, mis. saat dua struct menghasilkan tabel yang sama.
+type modelInfo = struct {
+	Name    string
+	PkgPath string
+	Fields  map[string]interface{}
+}
+
+// modelOrigin menyebut struct asal model, mis. "struct example.com/app/billing.User"
+func modelOrigin(model *modelInfo) string {
+	if model.PkgPath == "" {
+		return "struct " + model.Name
+	}
+	return "struct " + model.PkgPath + "." + model.Name
+}
+
+// generateTable mengkonversi struct ke Table. Field dengan rel tag dikembalikan
+// sebagai relation; field bertipe model lain (lihat models) tidak menjadi kolom
+// sampai relation-nya di-resolve.
+func (g *Generator) generateTable(model interface{}, models map[string]string) (state.Table, []relation, error) {
+	modelInfo, ok := model.(*modelInfo)
 	if !ok {
-		return state.Table{}, fmt.Errorf("invalid model format")
+		return state.Table{}, nil, fmt.Errorf("invalid model format")
 	}
 
 	tableName := g.formatTableName(modelInfo.Name)
@@ -65,57 +159,479 @@ func (g *Generator) generateTable(model interface{}) (state.Table, error) {
 		Constraints: make([]state.Constraint, 0),
 	}
 
+	var relations []relation
 	for fieldName, fieldInfo := range modelInfo.Fields {
 		info, ok := fieldInfo.(map[string]interface{})
 		if !ok {
 			continue
 		}
 
-		// Generate column
-		column := g.generateColumnFromInfo(fieldName, info)
-		table.Columns[column.Name] = column
+		if relTag, ok := info["rel_tag"].(string); ok {
+			rel, err := parseRelTag(relTag)
+			if err != nil {
+				return state.Table{}, nil, fmt.Errorf("model %s field %s: rel tag: %w", modelInfo.Name, fieldName, err)
+			}
+			if err := g.checkTagKeys(modelInfo.Name, fieldName, "rel", rel.options, state.RelTagKeys); err != nil {
+				return state.Table{}, nil, err
+			}
+			rel.table, rel.field = tableName, fieldName
+			fieldType, _ := info["type"].(string)
+			if _, isModel := models[strings.TrimPrefix(fieldType, "*")]; isModel {
+				rel.model = strings.TrimPrefix(fieldType, "*")
+				rel.column = g.getColumnName(fieldName) + "_id"
+				rel.nullable = strings.HasPrefix(fieldType, "*")
+				relations = append(relations, rel)
+				continue
+			}
+			rel.column = g.getColumnName(fieldName)
+			relations = append(relations, rel)
+		}
 
-		// Check untuk index dan constraints dari db_tag
 		if dbTag, ok := info["db_tag"].(string); ok {
-			if idx := g.generateIndexFromTag(fieldName, dbTag); idx != nil {
-				table.Indexes[idx.Name] = *idx
+			tags, err := parseTags(dbTag)
+			if err != nil {
+				return state.Table{}, nil, fmt.Errorf("model %s field %s: db tag: %w", modelInfo.Name, fieldName, err)
+			}
+			if _, ok := tags["table_options"]; ok {
+				if err := g.checkTagKeys(modelInfo.Name, fieldName, "db", tags, state.TableOptionTagKeys); err != nil {
+					return state.Table{}, nil, err
+				}
+				addTableOptions(&table, tags)
+				continue
+			}
+			if err := g.checkTagKeys(modelInfo.Name, fieldName, "db", tags, state.TagKeys); err != nil {
+				return state.Table{}, nil, err
+			}
+			if _, flatten := tags["flatten"]; flatten {
+				prefix := g.flattenPrefix(fieldName, tags)
+				declared := columnNames(table)
+				if err := g.flattenField(&table, modelInfo.Name, fieldName, prefix, info, 1); err != nil {
+					return state.Table{}, nil, err
+				}
+				groupColumns(table, declared, flattenGroup(fieldName, tags))
+				continue
 			}
+		}
+
+		if err := g.addColumn(&table, modelInfo.Name, fieldName, "", fieldName, info); err != nil {
+			return state.Table{}, nil, err
+		}
+	}
+
+	return table, relations, nil
+}
+
+// addTableOptions menyimpan tag field penanda table_options sebagai
+// Table.Options; field tersebut tidak menjadi kolom. Key yang tidak dikenal
+// sudah dilaporkan checkTagKeys dan tidak dirender.
+func addTableOptions(table *state.Table, tags map[string]string) {
+	options := make(map[string]string, len(table.Options)+len(tags))
+	for key, value := range table.Options {
+		options[key] = value
+	}
+	for key, value := range tags {
+		if state.IsTableOptionKey(key) {
+			options[key] = value
+		}
+	}
+	table.Options = state.NormalizeTableOptions(options)
+}
+
+// maxFlattenDepth membatasi kedalaman value object bersarang yang di-flatten
+const maxFlattenDepth = 4
+
+// flattenField menambahkan field value object bertag flatten sebagai kolom
+// dengan prefix, mis. Price Money `db:"flatten,prefix=price_"` menjadi
+// price_amount dan price_currency. Field struct di info["fields"] boleh
+// di-flatten lagi sampai maxFlattenDepth.
+func (g *Generator) flattenField(table *state.Table, model, field, prefix string, info map[string]interface{}, depth int) error {
+	if depth > maxFlattenDepth {
+		return fmt.Errorf("model %s field %s: flatten is nested deeper than %d levels", model, field, maxFlattenDepth)
+	}
+	fields, ok := info["fields"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("model %s field %s: flatten requires a struct field", model, field)
+	}
 
-			if constraint := g.generateConstraintFromTag(fieldName, dbTag); constraint != nil {
-				table.Constraints = append(table.Constraints, *constraint)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sub, ok := fields[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := field + "." + name
+		if dbTag, ok := sub["db_tag"].(string); ok {
+			tags, err := parseTags(dbTag)
+			if err != nil {
+				return fmt.Errorf("model %s field %s: db tag: %w", model, path, err)
 			}
+			if err := g.checkTagKeys(model, path, "db", tags, state.TagKeys); err != nil {
+				return err
+			}
+			if _, flatten := tags["flatten"]; flatten {
+				declared := columnNames(*table)
+				if err := g.flattenField(table, model, path, prefix+g.flattenPrefix(name, tags), sub, depth+1); err != nil {
+					return err
+				}
+				if group, ok := tags["group"]; ok {
+					groupColumns(*table, declared, group)
+				}
+				continue
+			}
+		}
+		if err := g.addColumn(table, model, path, prefix, name, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flattenPrefix mengembalikan prefix kolom field flatten: prefix=... jika
+// ada (boleh kosong), atau nama kolom field diikuti underscore
+func (g *Generator) flattenPrefix(field string, tags map[string]string) string {
+	if prefix, ok := tags["prefix"]; ok {
+		return prefix
+	}
+	return g.getColumnName(field) + "_"
+}
+
+// flattenGroup mengembalikan group kolom hasil flatten: tag group=... pada
+// field, atau nama field sebagai batas struct-nya
+func flattenGroup(field string, tags map[string]string) string {
+	if group := tags["group"]; group != "" {
+		return group
+	}
+	return field
+}
+
+// columnNames mengembalikan nama kolom table yang sudah ada
+func columnNames(table state.Table) map[string]bool {
+	names := make(map[string]bool, len(table.Columns))
+	for name := range table.Columns {
+		names[name] = true
+	}
+	return names
+}
+
+// groupColumns memberi tag group=... pada kolom yang tidak ada di declared
+// dan belum punya group sendiri
+func groupColumns(table state.Table, declared map[string]bool, group string) {
+	for name, col := range table.Columns {
+		if declared[name] || col.Tags["group"] != "" {
+			continue
 		}
+		tags := make(map[string]string, len(col.Tags)+1)
+		for key, value := range col.Tags {
+			tags[key] = value
+		}
+		tags["group"] = group
+		col.Tags = tags
+		table.Columns[name] = col
+	}
+}
+
+// addColumn membuat kolom beserta index dan constraint dari tag-nya. prefix
+// diisi untuk field value object yang di-flatten; nama kolom yang sudah
+// dipakai field lain ditolak.
+func (g *Generator) addColumn(table *state.Table, model, path, prefix, fieldName string, info map[string]interface{}) error {
+	column := g.generateColumnFromInfo(prefix+g.getColumnName(fieldName), info)
+	if _, exists := table.Columns[column.Name]; exists {
+		return fmt.Errorf("model %s field %s: column %s is already declared by another field", model, path, column.Name)
+	}
+	table.Columns[column.Name] = column
+
+	// Check untuk index dan constraints dari tags
+	if idx := g.generateIndexFromTags(column.Name, prefix, column.Tags); idx != nil {
+		table.Indexes[idx.Name] = *idx
+	}
+
+	if constraint := g.generateConstraintFromTags(column.Name, column.Tags); constraint != nil {
+		table.Constraints = append(table.Constraints, *constraint)
 	}
+	return nil
+}
 
-	return table, nil
+// checkTagKeys melaporkan key tag yang tidak dikenal, mis. db:"defualt=1",
+// sebagai peringatan atau error jika StrictTags diset
+func (g *Generator) checkTagKeys(model, field, tag string, tags map[string]string, known []string) error {
+	for _, msg := range state.UnknownTagKeys(tags, known) {
+		if g.config.StrictTags {
+			return fmt.Errorf("model %s field %s: %s tag: %s", model, field, tag, msg)
+		}
+		g.warnings.Add("unknown-tag", model, field, "%s tag: %s", tag, msg)
+	}
+	return nil
 }
 
-// generateColumnFromInfo membuat Column dari informasi field
-func (g *Generator) generateColumnFromInfo(fieldName string, info map[string]interface{}) state.Column {
+// generateColumnFromInfo membuat Column bernama columnName dari informasi field
+func (g *Generator) generateColumnFromInfo(columnName string, info map[string]interface{}) state.Column {
 	fieldType, _ := info["type"].(string)
 
 	column := state.Column{
-		Name:     g.getColumnName(fieldName),
+		Name:     columnName,
 		Type:     g.getSQLTypeFromGoType(fieldType),
 		Nullable: g.isNullableType(fieldType),
 	}
+	isString := strings.TrimPrefix(fieldType, "*") == "string"
+	defaultLength := fieldType == "string"
+	if spec, ok := g.columnSpec(column.Name, fieldType); ok {
+		applyColumnSpec(&column, spec)
+		defaultLength = defaultLength && spec.Type == ""
+	}
 
 	// Parse db_tag untuk opsi tambahan
 	if dbTag, ok := info["db_tag"].(string); ok {
-		parts := strings.Split(dbTag, ",")
-		for _, part := range parts {
-			switch {
-			case part == "auto_increment":
+		// Tag yang tidak valid sudah ditolak saat key-nya diperiksa
+		tags, _ := parseTags(dbTag)
+		if column.Tags == nil {
+			column.Tags = tags
+		}
+		for key, value := range tags {
+			column.Tags[key] = value
+		}
+		for key, value := range tags {
+			switch key {
+			case "type":
+				column.Type = precisionType(sizedType(value, column.Tags["length"]), column.Tags["precision"])
+			case "auto_increment", "autoincrement":
+				column.AutoIncrement = true
+			case "serial":
 				column.AutoIncrement = true
-			case strings.HasPrefix(part, "default="):
-				column.DefaultValue = strings.TrimPrefix(part, "default=")
+				column.Identity = state.IdentitySerial
+			case "identity":
+				column.AutoIncrement = true
+				column.Identity = value
+			case "default":
+				column.DefaultValue = state.ParseDefault(value)
+			case "precision":
+				column.Type = precisionType(column.Type, value)
+			case "srid":
+				column.SRID, _ = strconv.Atoi(value)
+			case "charset":
+				column.Charset = value
+			case "collate", "collation":
+				column.Collation = value
+			case "class":
+				column.Class = value
+			case "mask":
+				column.Mask = value
+			case "deprecated":
+				column.Deprecated = true
+			case "on_update", "onupdate":
+				column.OnUpdate = state.NormalizeOnUpdate(value)
+			case "notnull", "primary_key":
+				column.Nullable = false
+			case "nullable":
+				column.Nullable = true
+			}
+		}
+		column.LiftOnUpdate()
+		column.NormalizeSpatial()
+
+		// Tanpa type eksplisit, size=... memilih kelas BLOB untuk []byte dan
+		// length=... menggantikan panjang bawaan tipe Go, mis. VARCHAR(255)
+		// menjadi VARCHAR(100). Panjang hanya disimpan di Type.
+		if _, hasType := column.Tags["type"]; !hasType {
+			if blobType, ok := blobTypeForSize(column.Tags["size"]); ok && fieldType == "[]byte" {
+				column.Type = blobType
+			}
+			if textType, ok := g.textTypeForSize(column.Tags["size"]); ok && isString {
+				column.Type = textType
 			}
+			if length := column.Tags["length"]; length != "" {
+				column.Type = sizedType(unsizedType(column.Type), length)
+			}
+		}
+	}
+
+	// Kolom lama tetap memakai panjang di snapshot saat DefaultStringLength diubah
+	if defaultLength && g.config.DefaultStringLength > 0 && column.Type == g.getSQLTypeFromGoType(fieldType) {
+		if column.Tags == nil {
+			column.Tags = make(map[string]string)
+		}
+		if column.Tags["diff"] == "" {
+			column.Tags["diff"] = diff.DiffIgnoreWidth
 		}
 	}
 
 	return column
 }
 
+// textTypeForSize memilih tipe TEXT untuk field string dengan size=... di
+// atas TextThreshold
+func (g *Generator) textTypeForSize(size string) (string, bool) {
+	n, ok := parseSize(size)
+	if !ok || g.config.TextThreshold <= 0 || n <= int64(g.config.TextThreshold) {
+		return "", false
+	}
+	switch {
+	case n <= 65535:
+		return "TEXT", true
+	case n <= 16777215:
+		return "MEDIUMTEXT", true
+	default:
+		return "LONGTEXT", true
+	}
+}
+
+// columnSpec mencari definisi bawaan untuk kolom: SpecialFields lebih dulu,
+// lalu GoTypes, lalu StringHeuristics untuk field string
+func (g *Generator) columnSpec(columnName, goType string) (ColumnSpec, bool) {
+	if spec, ok := g.config.SpecialFields[columnName]; ok {
+		return spec, true
+	}
+	if spec, ok := g.config.GoTypes[strings.TrimPrefix(goType, "*")]; ok {
+		return spec, true
+	}
+	if strings.TrimPrefix(goType, "*") != "string" {
+		return ColumnSpec{}, false
+	}
+	for _, rule := range g.config.StringHeuristics {
+		if matched, _ := path.Match(rule.Pattern, columnName); matched {
+			return rule.ColumnSpec, true
+		}
+	}
+	return ColumnSpec{}, false
+}
+
+// applyColumnSpec menerapkan ColumnSpec ke kolom sebelum tag db dibaca
+func applyColumnSpec(column *state.Column, spec ColumnSpec) {
+	if spec.Type != "" {
+		column.Type = spec.Type
+	}
+	if spec.Nullable != nil {
+		column.Nullable = *spec.Nullable
+	}
+	if spec.Default != "" {
+		column.DefaultValue = state.ParseDefault(spec.Default)
+	}
+	column.AutoIncrement = column.AutoIncrement || spec.AutoIncrement
+	if spec.SRID != 0 {
+		column.SRID = spec.SRID
+	}
+	if spec.Unique {
+		column.Tags = map[string]string{"unique": ""}
+	}
+}
+
+// sizedTypes adalah tipe yang menerima panjang dari tag length,
+// mis. type=VARBINARY,length=16 menjadi VARBINARY(16)
+var sizedTypes = map[string]bool{
+	"VARCHAR":   true,
+	"CHAR":      true,
+	"VARBINARY": true,
+	"BINARY":    true,
+}
+
+// sizedType menambahkan panjang dari tag length ke tipe yang belum memilikinya
+func sizedType(sqlType, length string) string {
+	if length == "" || strings.Contains(sqlType, "(") || !sizedTypes[strings.ToUpper(sqlType)] {
+		return sqlType
+	}
+	return fmt.Sprintf("%s(%s)", sqlType, length)
+}
+
+// unsizedType membuang panjang dari tipe yang menerima tag length,
+// mis. VARCHAR(255) menjadi VARCHAR. Tipe lain tidak berubah.
+func unsizedType(sqlType string) string {
+	base, _, hasLength := strings.Cut(sqlType, "(")
+	if !hasLength || !sizedTypes[strings.ToUpper(base)] {
+		return sqlType
+	}
+	return base
+}
+
+// precisionType menambahkan fractional seconds ke tipe waktu, mis.
+// DATETIME dengan precision=6 menjadi DATETIME(6). Tipe lain tidak berubah.
+func precisionType(sqlType, precision string) string {
+	if precision == "" || strings.Contains(sqlType, "(") {
+		return sqlType
+	}
+	base, suffix, _ := strings.Cut(sqlType, " ")
+	switch strings.ToUpper(base) {
+	case "DATETIME", "TIMESTAMP", "TIME":
+	default:
+		return sqlType
+	}
+	if suffix != "" {
+		// Postgres: timestamp(6) with time zone
+		suffix = " " + suffix
+	}
+	return fmt.Sprintf("%s(%s)%s", base, precision, suffix)
+}
+
+// blobTypeForSize memilih kelas BLOB terkecil yang menampung size byte.
+// size boleh memakai suffix KB, MB atau GB (mis. size=16MB).
+func blobTypeForSize(size string) (string, bool) {
+	bytes, ok := parseSize(size)
+	if !ok {
+		return "", false
+	}
+
+	switch {
+	case bytes <= 255:
+		return "TINYBLOB", true
+	case bytes <= 65535:
+		return "BLOB", true
+	case bytes <= 16777215:
+		return "MEDIUMBLOB", true
+	default:
+		return "LONGBLOB", true
+	}
+}
+
+// parseSize membaca nilai size=..., boleh dengan suffix KB, MB atau GB
+func parseSize(size string) (int64, bool) {
+	size = strings.ToUpper(strings.TrimSpace(size))
+	if size == "" {
+		return 0, false
+	}
+
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if strings.HasSuffix(size, suffix) {
+			multiplier = m
+			size = strings.TrimSpace(strings.TrimSuffix(size, suffix))
+			break
+		}
+	}
+	n, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n * multiplier, true
+}
+
+// parseTags memecah db tag menjadi map key/value.
+// Opsi tanpa nilai (mis. "unique") disimpan dengan value kosong. Nilai boleh
+// diapit quote tunggal agar bisa berisi koma (comment='a, b'); quote nilai
+// default dipertahankan karena menandai literal string SQL.
+func parseTags(tag string) (map[string]string, error) {
+	parts, err := splitTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string)
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		// default, seed dan backfill adalah ekspresi SQL, quote-nya bagian dari nilai
+		if key != "default" && key != "seed" && key != "backfill" {
+			value = unquoteTagValue(value)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
 // getSQLTypeFromGoType mengkonversi tipe Go ke tipe SQL
 func (g *Generator) getSQLTypeFromGoType(goType string) string {
 	switch goType {
@@ -130,14 +646,34 @@ func (g *Generator) getSQLTypeFromGoType(goType string) string {
 	case "float64":
 		return "DOUBLE"
 	case "string":
+		if g.config.DefaultStringLength > 0 {
+			return fmt.Sprintf("VARCHAR(%d)", g.config.DefaultStringLength)
+		}
 		return "VARCHAR(255)"
+	case "[]byte":
+		return "BLOB"
 	case "*time.Time", "time.Time":
 		return "DATETIME"
 	default:
+		if spatial, ok := orbTypes[strings.TrimPrefix(goType, "*")]; ok {
+			return spatial
+		}
 		return "TEXT"
 	}
 }
 
+// orbTypes memetakan tipe geometri github.com/paulmach/orb ke tipe spatial
+var orbTypes = map[string]string{
+	"orb.Geometry":        "GEOMETRY",
+	"orb.Point":           "POINT",
+	"orb.LineString":      "LINESTRING",
+	"orb.Polygon":         "POLYGON",
+	"orb.MultiPoint":      "MULTIPOINT",
+	"orb.MultiLineString": "MULTILINESTRING",
+	"orb.MultiPolygon":    "MULTIPOLYGON",
+	"orb.Collection":      "GEOMETRYCOLLECTION",
+}
+
 // isNullableType menentukan apakah tipe bisa null
 func (g *Generator) isNullableType(goType string) bool {
 	return strings.HasPrefix(goType, "*")
@@ -154,26 +690,66 @@ func (g *Generator) formatTableName(name string) string {
 	return g.config.TablePrefix + name + g.config.TableSuffix
 }
 
-// generateIndexFromTag membuat Index dari tag
-func (g *Generator) generateIndexFromTag(fieldName, tag string) *state.Index {
-	if strings.Contains(tag, "index") || strings.Contains(tag, "unique") {
-		name := fmt.Sprintf("idx_%s", g.getColumnName(fieldName))
-		return &state.Index{
-			Name:    name,
-			Columns: []string{g.getColumnName(fieldName)},
-			Unique:  strings.Contains(tag, "unique"),
+// generateIndexFromTags membuat Index dari tags. prefix kolom flatten juga
+// dipasang pada nama index eksplisit dan kolom include. Tag spatial (boleh
+// dengan nama index) membuat SPATIAL index MySQL atau index GiST Postgres.
+// index_comment=... menjadi deskripsi index.
+func (g *Generator) generateIndexFromTags(columnName, prefix string, tags map[string]string) *state.Index {
+	if name, ok := tags["spatial"]; ok {
+		if name == "" {
+			name = tags["index"]
 		}
+		if name == "" {
+			name = fmt.Sprintf("idx_%s", columnName)
+		} else {
+			name = prefix + name
+		}
+		return &state.Index{Name: name, Columns: []string{columnName}, Spatial: true, Description: tags["index_comment"]}
 	}
-	return nil
+	indexName, hasIndex := tags["index"]
+	_, unique := tags["unique"]
+	if !hasIndex && !unique {
+		return nil
+	}
+
+	if indexName == "" {
+		indexName = fmt.Sprintf("idx_%s", columnName)
+	} else {
+		indexName = prefix + indexName
+	}
+	idx := &state.Index{
+		Name:        indexName,
+		Columns:     []string{columnName},
+		Unique:      unique,
+		Description: tags["index_comment"],
+	}
+
+	// Prefix length (index=name,length=191) dan covering columns (include=a|b)
+	if length, err := strconv.Atoi(tags["length"]); err == nil && length > 0 {
+		idx.Lengths = map[string]int{columnName: length}
+	}
+	if include := tags["include"]; include != "" {
+		for _, col := range strings.Split(include, "|") {
+			idx.Include = append(idx.Include, prefix+g.getColumnName(col))
+		}
+	}
+	// Hash-sharded index CockroachDB (sharded=8); tanpa nilai memakai default 16
+	if buckets, ok := tags["sharded"]; ok {
+		idx.Buckets = 16
+		if buckets != "" {
+			idx.Buckets, _ = strconv.Atoi(buckets)
+		}
+	}
+	return idx
 }
 
-// generateConstraintFromTag membuat Constraint dari tag
-func (g *Generator) generateConstraintFromTag(fieldName, tag string) *state.Constraint {
-	if strings.Contains(tag, "primary_key") {
+// generateConstraintFromTags membuat Constraint dari tags
+func (g *Generator) generateConstraintFromTags(columnName string, tags map[string]string) *state.Constraint {
+	if _, ok := tags["primary_key"]; ok {
 		return &state.Constraint{
-			Name: fmt.Sprintf("pk_%s", g.getColumnName(fieldName)),
+			Name: fmt.Sprintf("pk_%s", columnName),
 			Type: "PRIMARY KEY",
-			Def:  fmt.Sprintf("PRIMARY KEY (`%s`)", g.getColumnName(fieldName)),
+			Def:  fmt.Sprintf("PRIMARY KEY (`%s`)", columnName),
 		}
 	}
 	return nil
@@ -0,0 +1,204 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// testModel membuat model GenerateSchema dengan field nama ke tipe Go dan tag db
+func testModel(name string, fields map[string][2]string) *modelInfo {
+	model := &modelInfo{Name: name, Fields: make(map[string]interface{})}
+	for field, f := range fields {
+		info := map[string]interface{}{"type": f[0]}
+		if f[1] != "" {
+			info["db_tag"] = f[1]
+		}
+		model.Fields[field] = info
+	}
+	return model
+}
+
+func TestColumnLengthRenderedOnce(t *testing.T) {
+	tests := []struct {
+		name  string
+		field [2]string
+		want  string
+	}{
+		{"default string", [2]string{"string", ""}, "VARCHAR(255)"},
+		{"length tag", [2]string{"string", "length=100"}, "VARCHAR(100)"},
+		{"type with width", [2]string{"string", "type=VARCHAR(255)"}, "VARCHAR(255)"},
+		{"type with width and length tag", [2]string{"string", "type=VARCHAR(255),length=255"}, "VARCHAR(255)"},
+		{"type without width and length tag", [2]string{"string", "type=VARCHAR,length=64"}, "VARCHAR(64)"},
+		{"char", [2]string{"string", "type=CHAR(2)"}, "CHAR(2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator(nil)
+			desired, err := g.GenerateSchema(testModel("User", map[string][2]string{
+				"Id":   {"int64", "primary_key"},
+				"Name": tt.field,
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := desired.Tables["users"].Columns["name"].Type; got != tt.want {
+				t.Fatalf("column type = %q, want %q", got, tt.want)
+			}
+
+			for _, dialect := range []string{diff.DialectMySQL, diff.DialectPostgres} {
+				d := diff.NewGenerator(&diff.Config{Dialect: dialect})
+				create, err := d.GenerateDiff(state.NewSchemaState(), desired)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertLengthOnce(t, dialect+" CREATE", create, tt.want)
+
+				// Snapshot dengan kolom yang lebih sempit memaksa ALTER kolom
+				current := cloneSchema(desired)
+				column := current.Tables["users"].Columns["name"]
+				column.Type = "TEXT"
+				current.Tables["users"].Columns["name"] = column
+				alter, err := d.GenerateDiff(current, desired)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertLengthOnce(t, dialect+" ALTER", alter, tt.want)
+			}
+		})
+	}
+}
+
+// assertLengthOnce memastikan sql memuat want tepat sekali dan tidak ada
+// panjang yang ditulis dua kali, mis. VARCHAR(255)(255)
+func assertLengthOnce(t *testing.T, path, sql, want string) {
+	t.Helper()
+	if strings.Contains(sql, ")(") {
+		t.Errorf("%s renders the length twice:\n%s", path, sql)
+	}
+	if got := strings.Count(strings.ToUpper(sql), want); got != 1 {
+		t.Errorf("%s contains %s %d times, want once:\n%s", path, want, got, sql)
+	}
+}
+
+// cloneSchema menyalin tabel dan kolom schema agar bisa diubah tanpa
+// mengubah aslinya
+func cloneSchema(s *state.SchemaState) *state.SchemaState {
+	clone := state.NewSchemaState()
+	for name, table := range s.Tables {
+		columns := make(map[string]state.Column, len(table.Columns))
+		for columnName, column := range table.Columns {
+			columns[columnName] = column
+		}
+		table.Columns = columns
+		clone.Tables[name] = table
+	}
+	return clone
+}
+
+func TestColumnGroupTags(t *testing.T) {
+	model := testModel("Account", map[string][2]string{
+		"Id":    {"int64", "primary_key"},
+		"Name":  {"string", ""},
+		"Email": {"string", "group=Contact"},
+	})
+	model.Fields["Billing"] = map[string]interface{}{"type": "Billing", "db_tag": "flatten", "fields": map[string]interface{}{
+		"Plan":  map[string]interface{}{"type": "string"},
+		"Cycle": map[string]interface{}{"type": "int"},
+		"Email": map[string]interface{}{"type": "string", "db_tag": "group=Contact"},
+	}}
+	model.Fields["Address"] = map[string]interface{}{"type": "Address", "db_tag": "flatten,prefix=,group=Location", "fields": map[string]interface{}{
+		"City": map[string]interface{}{"type": "string"},
+	}}
+	desired, err := NewGenerator(nil).GenerateSchema(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"id":            "",
+		"name":          "",
+		"email":         "Contact",
+		"billing_plan":  "Billing",
+		"billing_cycle": "Billing",
+		"billing_email": "Contact",
+		"city":          "Location",
+	}
+	columns := desired.Tables["accounts"].Columns
+	if len(columns) != len(want) {
+		t.Errorf("columns = %v, want %d columns", columns, len(want))
+	}
+	for name, group := range want {
+		if got := columns[name].Tags["group"]; got != group {
+			t.Errorf("%s group = %q, want %q", name, got, group)
+		}
+	}
+
+	// Group hanya mengatur tampilan, tidak mengubah hash atau diff
+	ungrouped := cloneSchema(desired)
+	for name, col := range ungrouped.Tables["accounts"].Columns {
+		tags := map[string]string{}
+		for key, value := range col.Tags {
+			if key != "group" {
+				tags[key] = value
+			}
+		}
+		col.Tags = tags
+		ungrouped.Tables["accounts"].Columns[name] = col
+	}
+	if desired.Tables["accounts"].Columns["email"].Tags["group"] == "" {
+		t.Fatal("removing groups from the copy changed the original schema")
+	}
+	if SchemaHash(ungrouped) != SchemaHash(desired) {
+		t.Error("SchemaHash() changes with column groups")
+	}
+}
+
+// TestParseSQLIgnoresGroupComments memastikan CREATE TABLE yang ditulis
+// dengan naming.column_groups dibaca sama dengan versi tanpa komentar group
+func TestParseSQLIgnoresGroupComments(t *testing.T) {
+	model := testModel("Account", map[string][2]string{
+		"Id":    {"int64", "primary_key"},
+		"Plan":  {"string", "group=Billing"},
+		"Email": {"string", "group=Contact"},
+		"Name":  {"string", ""},
+	})
+	desired, err := NewGenerator(nil).GenerateSchema(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dialect := range []string{diff.DialectMySQL, diff.DialectPostgres} {
+		render := func(groups bool) *state.SchemaState {
+			sql, err := diff.NewGenerator(&diff.Config{Dialect: dialect, ColumnGroups: groups}).GenerateDiff(state.NewSchemaState(), desired)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if groups && !strings.Contains(sql, "-- Billing") {
+				t.Fatalf("%s: expected group comments in\n%s", dialect, sql)
+			}
+			parsed, err := ParseSQL(sql)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return parsed
+		}
+		grouped, plain := render(true), render(false)
+		if SchemaHash(grouped) != SchemaHash(plain) {
+			t.Errorf("%s: group comments change the schema hash", dialect)
+		}
+		for name, col := range grouped.Tables["accounts"].Columns {
+			if strings.Contains(col.Name+col.Type+col.Extra, "--") {
+				t.Errorf("%s: column %s picked up a group comment: %+v", dialect, name, col)
+			}
+		}
+		statements, err := diff.NewGenerator(&diff.Config{Dialect: dialect}).GenerateStatements(plain, grouped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(statements) > 0 {
+			t.Errorf("%s: group comments produced %q", dialect, statements)
+		}
+	}
+}
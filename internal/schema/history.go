@@ -0,0 +1,62 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// migrationVersion mengambil versi migration, yaitu digit di awal nama file
+// (sama seperti dbmate), mis. "20240101120000" dari "20240101120000_add_users.sql"
+func migrationVersion(name string) string {
+	end := 0
+	for end < len(name) && name[end] >= '0' && name[end] <= '9' {
+		end++
+	}
+	return name[:end]
+}
+
+// SnapshotAt merekonstruksi schema setelah semua migration dengan versi <=
+// version dijalankan, dengan menjalankan ulang bagian up seperti doctor.
+// version kosong berarti semua migration. File .down.sql dilewati.
+func SnapshotAt(dir string, pattern *regexp.Regexp, version string) (*state.SchemaState, error) {
+	files, err := migrationFiles(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if version != "" && !hasMigrationVersion(files, version) {
+		return nil, fmt.Errorf("no migration with version %s in %s", version, dir)
+	}
+
+	r := newReplay()
+	for _, name := range files {
+		if strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+		if version != "" && migrationVersion(name) > version {
+			break
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		sql := string(content)
+		upStart, upEnd := upSection(sql)
+		r.applyAll(sql[upStart:upEnd], name)
+	}
+	return r.schema, nil
+}
+
+// hasMigrationVersion mengecek apakah ada migration dengan versi tersebut
+func hasMigrationVersion(files []string, version string) bool {
+	for _, name := range files {
+		if migrationVersion(name) == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Runner migration yang direktorinya bisa diimpor
+const (
+	// RunnerDbmate memakai satu file per migration dengan marker -- migrate:up/down
+	RunnerDbmate = "dbmate"
+	// RunnerGolangMigrate memakai pasangan file N_name.up.sql dan N_name.down.sql
+	RunnerGolangMigrate = "golang-migrate"
+)
+
+// Import adalah schema hasil menjalankan ulang migration runner lain
+type Import struct {
+	Schema *state.SchemaState
+	// Files adalah file migration yang bagian up-nya dijalankan ulang
+	Files []string
+	// Skipped adalah statement yang tidak dipahami atau gagal diterapkan dan
+	// perlu dicocokkan manual dengan schema program
+	Skipped []SkippedStatement
+}
+
+// ImportMigrations menjalankan ulang bagian up migration di dir secara
+// berurutan ke schema kosong, seperti doctor, untuk membuat snapshot awal
+func ImportMigrations(dir, runner string) (*Import, error) {
+	if runner != RunnerDbmate && runner != RunnerGolangMigrate {
+		return nil, fmt.Errorf("unknown runner %q, use %s or %s", runner, RunnerDbmate, RunnerGolangMigrate)
+	}
+	// File runner lain tidak harus cocok dengan pola migration datara
+	files, err := sqlFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newReplay()
+	result := &Import{}
+	for _, name := range files {
+		if runner == RunnerGolangMigrate && !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		sql := string(content)
+
+		upStart, upEnd := 0, len(sql)
+		if runner == RunnerDbmate {
+			upStart, upEnd = upSection(sql)
+		}
+		for _, span := range splitStatementSpans(sql[upStart:upEnd]) {
+			line := strings.Count(sql[:upStart+span.Start], "\n") + 1
+			location := fmt.Sprintf("%s:%d", name, line)
+			if problem, _ := r.apply(normalizeDefinition(span.Text), location); problem != "" {
+				r.skipped = append(r.skipped, SkippedStatement{Location: location, Statement: span.Text, Problem: problem})
+			}
+		}
+		result.Files = append(result.Files, name)
+	}
+
+	result.Schema, result.Skipped = r.schema, r.skipped
+	return result, nil
+}
@@ -0,0 +1,206 @@
+package schema
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// JournalFile adalah nama file di direktori migration yang mencatat hash
+// snapshot sebelum dan sesudah setiap migration hasil generate
+const JournalFile = "datara.snapshots"
+
+// JournalEntry adalah satu baris "file from to" di datara.snapshots: hash
+// snapshot yang menjadi titik awal migration dan snapshot yang dihasilkannya
+type JournalEntry struct {
+	File string
+	From string
+	To   string
+}
+
+// ReadJournal membaca datara.snapshots dari dir. Hasilnya kosong jika file
+// belum ada, mis. untuk migration yang dibuat sebelum journal diperkenalkan.
+func ReadJournal(dir string) ([]JournalEntry, error) {
+	f, err := os.Open(filepath.Join(dir, JournalFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", JournalFile, err)
+	}
+	defer f.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid %s entry: %q", JournalFile, line)
+		}
+		entries = append(entries, JournalEntry{File: fields[0], From: fields[1], To: fields[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", JournalFile, err)
+	}
+	return entries, nil
+}
+
+// RecordSnapshots menambahkan entry untuk migration file ke datara.snapshots
+// dengan hash snapshot from (sebelum) dan to (sesudah)
+func RecordSnapshots(dir, file string, from, to *state.SchemaState) error {
+	return RecordJournal(dir, JournalEntry{File: filepath.Base(file), From: StateHash(from), To: StateHash(to)})
+}
+
+// RecordJournal menambahkan entries ke datara.snapshots dalam satu penulisan,
+// mis. untuk semua migration yang ditulis dari satu plan
+func RecordJournal(dir string, entries ...JournalEntry) error {
+	var b strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "%s %s %s\n", entry.File, entry.From, entry.To)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, JournalFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", JournalFile, err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", JournalFile, err)
+	}
+	return nil
+}
+
+// StateHash menghitung hash struktur schema: tabel, tipe, nullability dan
+// default kolom, index serta constraint. Posisi, tag, komentar dan RawDDL
+// tidak ikut, sehingga snapshot hasil generate dan hasil replay migration
+// yang sama menghasilkan hash yang sama.
+func StateHash(s *state.SchemaState) string {
+	var b strings.Builder
+	if s != nil {
+		for _, name := range sortedNames(s.Tables) {
+			table := s.Tables[name]
+			fmt.Fprintf(&b, "table %s\n", name)
+			for _, colName := range sortedNames(table.Columns) {
+				col := table.Columns[colName]
+				def := ""
+				if col.DefaultValue != nil {
+					def = col.DefaultValue.SQL()
+				}
+				fmt.Fprintf(&b, "column %s %s null=%t default=%s auto=%t\n",
+					colName, normalizeHashSQL(col.Type), col.Nullable, normalizeHashSQL(def), col.AutoIncrement)
+			}
+			for _, idxName := range sortedNames(table.Indexes) {
+				idx := table.Indexes[idxName]
+				fmt.Fprintf(&b, "index %s unique=%t %s\n", idxName, idx.Unique, strings.Join(idx.Columns, ","))
+			}
+			constraints := make([]string, len(table.Constraints))
+			for i, c := range table.Constraints {
+				constraints[i] = normalizeHashSQL(c.Def)
+			}
+			sort.Strings(constraints)
+			for _, c := range constraints {
+				fmt.Fprintf(&b, "constraint %s\n", c)
+			}
+		}
+	}
+	return calculateHash(b.String())
+}
+
+// normalizeHashSQL menyamakan penulisan SQL antar dialect dan antara output
+// schema program dan migration: quote identifier dibuang, spasi dirapikan
+// dan huruf dikecilkan
+func normalizeHashSQL(sql string) string {
+	sql = strings.NewReplacer("`", "", `"`, "", "[", "", "]", "").Replace(sql)
+	return strings.ToLower(strings.Join(strings.Fields(sql), " "))
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SnapshotDivergenceError dikembalikan VerifySnapshots pada langkah pertama
+// yang hash snapshot hasil replay-nya berbeda dengan yang tercatat di
+// datara.snapshots
+type SnapshotDivergenceError struct {
+	File   string
+	Reason string
+	Want   string
+	Got    string
+}
+
+func (e *SnapshotDivergenceError) Error() string {
+	return fmt.Sprintf("snapshot diverges at %s: %s (want %s, got %s)", e.File, e.Reason, e.Want, e.Got)
+}
+
+// VerifySnapshots menjalankan ulang bagian up semua migration di dir dan
+// membandingkan hash snapshot sebelum dan sesudah setiap migration dengan
+// datara.snapshots. Migration tanpa entry (dibuat manual atau sebelum journal
+// ada) tetap dijalankan tetapi tidak dicek. Terakhir snapshot (schema.json,
+// boleh nil) dibandingkan dengan hasil replay semua migration. Divergensi
+// pertama dikembalikan sebagai *SnapshotDivergenceError.
+func VerifySnapshots(dir string, pattern *regexp.Regexp, snapshot *state.SchemaState) error {
+	entries, err := ReadJournal(dir)
+	if err != nil {
+		return err
+	}
+	journal := make(map[string]JournalEntry, len(entries))
+	for _, entry := range entries {
+		journal[entry.File] = entry
+	}
+
+	files, err := migrationFiles(dir, pattern)
+	if err != nil {
+		return err
+	}
+	r := newReplay()
+	for _, name := range files {
+		if strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+		before := StateHash(r.schema)
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		sql := string(content)
+		upStart, upEnd := upSection(sql)
+		r.applyAll(sql[upStart:upEnd], name)
+
+		entry, ok := journal[name]
+		if !ok {
+			continue
+		}
+		if entry.From != before {
+			return &SnapshotDivergenceError{File: name, Reason: "it was generated from a snapshot the earlier migrations do not produce",
+				Want: entry.From, Got: before}
+		}
+		if after := StateHash(r.schema); entry.To != after {
+			return &SnapshotDivergenceError{File: name, Reason: "replaying it does not produce the snapshot recorded when it was generated",
+				Want: entry.To, Got: after}
+		}
+	}
+
+	if snapshot == nil {
+		return nil
+	}
+	if want, got := StateHash(r.schema), StateHash(snapshot); want != got {
+		return &SnapshotDivergenceError{File: snapshotFile, Reason: "it does not match replaying all migrations; it was changed outside datara generate",
+			Want: want, Got: got}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+func TestJournalReadAndAppend(t *testing.T) {
+	dir := t.TempDir()
+	if entries, err := ReadJournal(dir); err != nil || entries != nil {
+		t.Fatalf("ReadJournal without %s = %v, %v, want no entries", JournalFile, entries, err)
+	}
+
+	first := []JournalEntry{{File: "1_users.sql", From: "a", To: "b"}, {File: "2_posts.sql", From: "b", To: "c"}}
+	if err := RecordJournal(dir, first...); err != nil {
+		t.Fatal(err)
+	}
+	users, err := ParseSQL("CREATE TABLE users (id INT);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordSnapshots(dir, filepath.Join(dir, "3_users.sql"), nil, users); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ReadJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(first, JournalEntry{File: "3_users.sql", From: StateHash(nil), To: StateHash(users)})
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries = %+v, want %+v", entries, want)
+	}
+}
+
+func TestJournalRejectsCorruptEntries(t *testing.T) {
+	tests := map[string]string{
+		"missing hash":   "1_users.sql a b\n2_posts.sql b\n",
+		"extra field":    "1_users.sql a b c\n",
+		"merge conflict": "<<<<<<< HEAD\n1_users.sql a b\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFile(t, dir, JournalFile, content)
+			if _, err := ReadJournal(dir); err == nil || !strings.Contains(err.Error(), "invalid "+JournalFile+" entry") {
+				t.Errorf("ReadJournal = %v, want an invalid entry error", err)
+			}
+		})
+	}
+
+	// Baris kosong bukan entry yang rusak
+	dir := t.TempDir()
+	writeTestFile(t, dir, JournalFile, "\n1_users.sql a b\n\n")
+	if entries, err := ReadJournal(dir); err != nil || len(entries) != 1 {
+		t.Errorf("ReadJournal with blank lines = %v, %v, want one entry", entries, err)
+	}
+}
+
+func TestVerifySnapshots(t *testing.T) {
+	dir := t.TempDir()
+	users := "CREATE TABLE users (id INT);"
+	writeTestFile(t, dir, "20240101000000_users.sql", "-- migrate:up\n"+users+"\n\n-- migrate:down\nDROP TABLE users;\n")
+	snapshot, err := ParseSQL(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordSnapshots(dir, "20240101000000_users.sql", state.NewSchemaState(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySnapshots(dir, nil, snapshot); err != nil {
+		t.Fatalf("VerifySnapshots = %v", err)
+	}
+
+	// Snapshot yang diubah di luar generate
+	changed, err := ParseSQL("CREATE TABLE users (id BIGINT);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var divergence *SnapshotDivergenceError
+	if err := VerifySnapshots(dir, nil, changed); !errors.As(err, &divergence) || divergence.File != snapshotFile {
+		t.Errorf("VerifySnapshots with a changed snapshot = %v, want divergence at %s", err, snapshotFile)
+	}
+
+	// Migration yang diedit setelah dicatat
+	writeTestFile(t, dir, "20240101000000_users.sql", "-- migrate:up\nCREATE TABLE users (id INT, email TEXT);\n")
+	if err := VerifySnapshots(dir, nil, nil); !errors.As(err, &divergence) || divergence.File != "20240101000000_users.sql" {
+		t.Errorf("VerifySnapshots with an edited migration = %v, want divergence at 20240101000000_users.sql", err)
+	}
+
+	// Journal yang rusak dilaporkan, bukan dilewati
+	if err := os.WriteFile(filepath.Join(dir, JournalFile), []byte("20240101000000_users.sql\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySnapshots(dir, nil, nil); err == nil || !strings.Contains(err.Error(), "invalid "+JournalFile+" entry") {
+		t.Errorf("VerifySnapshots with a corrupt journal = %v, want an invalid entry error", err)
+	}
+}
@@ -0,0 +1,92 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+)
+
+// Nilai schema.migration_markers di datara.hcl
+const (
+	// MarkersStrip memakai bagian up saja dan menghasilkan peringatan
+	MarkersStrip = "strip"
+	// MarkersError menolak output yang berisi marker migration
+	MarkersError = "error"
+)
+
+// ErrMigrationMarkers dikembalikan jika output schema program berisi marker
+// -- migrate:up/down dan schema.migration_markers = "error"
+var ErrMigrationMarkers = errors.New("schema program printed a migration (-- migrate:up/down markers) instead of a schema; " +
+	"point schema.program at the bare schema or set schema.migration_markers = \"strip\"")
+
+// SetMigrationMarkers menentukan penanganan output yang berisi marker
+// migration: MarkersStrip (default) atau MarkersError
+func (e *Executor) SetMigrationMarkers(mode string) {
+	e.migrationMarkers = mode
+}
+
+// stripMigrationMarkers mengembalikan bagian up jika sql berisi baris marker
+// dbmate. Marker di dalam string literal atau body $$ tidak dihitung. Bagian
+// down yang ditulis sebelum marker up diabaikan.
+func stripMigrationMarkers(sql string) (string, bool) {
+	lines := markerLines(sql)
+	up, down := -1, -1
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(sql[line.start:line.end]), migrateUpMarker) {
+			up = line.end
+			break
+		}
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(sql[line.start:line.end]), migrateDownMarker) && line.start >= up {
+			down = line.start
+			break
+		}
+	}
+	if up == -1 && down == -1 {
+		return sql, false
+	}
+	start, end := 0, len(sql)
+	if up != -1 {
+		start = up
+	}
+	if down != -1 {
+		end = down
+	}
+	return sql[start:end], true
+}
+
+// lineSpan adalah rentang satu baris di SQL sumber
+type lineSpan struct{ start, end int }
+
+// markerLines mengembalikan baris yang diawali "-- migrate:" di luar string
+// literal dan body $$
+func markerLines(sql string) []lineSpan {
+	var lines []lineSpan
+	inQuote, inDollar := false, false
+	for start := 0; start < len(sql); {
+		end := strings.IndexByte(sql[start:], '\n')
+		if end == -1 {
+			end = len(sql)
+		} else {
+			end += start
+		}
+		line := sql[start:end]
+		if !inQuote && !inDollar && strings.HasPrefix(strings.TrimSpace(line), "-- migrate:") {
+			lines = append(lines, lineSpan{start, end})
+		} else {
+			for i := 0; i < len(line); i++ {
+				switch {
+				case line[i] == '$' && !inQuote && i+1 < len(line) && line[i+1] == '$':
+					inDollar = !inDollar
+					i++
+				case line[i] == '\'' && !inDollar:
+					inQuote = !inQuote
+				case line[i] == '-' && !inQuote && !inDollar && i+1 < len(line) && line[i+1] == '-':
+					i = len(line)
+				}
+			}
+		}
+		start = end + 1
+	}
+	return lines
+}
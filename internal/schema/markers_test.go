@@ -0,0 +1,101 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStripMigrationMarkers(t *testing.T) {
+	tests := []struct {
+		name  string
+		sql   string
+		want  string
+		found bool
+	}{
+		{
+			name: "bare schema",
+			sql:  "CREATE TABLE a (id INT);\n",
+			want: "CREATE TABLE a (id INT);\n",
+		},
+		{
+			name:  "full migration",
+			sql:   "-- migrate:up\nCREATE TABLE a (id INT);\n\n-- migrate:down\nDROP TABLE a;\n",
+			want:  "\nCREATE TABLE a (id INT);\n\n",
+			found: true,
+		},
+		{
+			name:  "up options and indentation",
+			sql:   "  -- migrate:up transaction:false\nCREATE TABLE a (id INT);\n  -- migrate:down\nDROP TABLE a;\n",
+			want:  "\nCREATE TABLE a (id INT);\n",
+			found: true,
+		},
+		{
+			name:  "up only",
+			sql:   "-- migrate:up\nCREATE TABLE a (id INT);\n",
+			want:  "\nCREATE TABLE a (id INT);\n",
+			found: true,
+		},
+		{
+			name:  "down before up",
+			sql:   "-- migrate:down\nDROP TABLE a;\n-- migrate:up\nCREATE TABLE a (id INT);\n",
+			want:  "\nCREATE TABLE a (id INT);\n",
+			found: true,
+		},
+		{
+			name:  "quote in a comment",
+			sql:   "-- it's generated\n-- migrate:up\nCREATE TABLE a (id INT);\n-- migrate:down\nDROP TABLE a;\n",
+			want:  "\nCREATE TABLE a (id INT);\n",
+			found: true,
+		},
+		{
+			name: "string literal",
+			sql:  "CREATE TABLE a (id INT);\nCOMMENT ON TABLE a IS 'usage:\n-- migrate:up\nCREATE TABLE a';\n",
+			want: "CREATE TABLE a (id INT);\nCOMMENT ON TABLE a IS 'usage:\n-- migrate:up\nCREATE TABLE a';\n",
+		},
+		{
+			name: "single line literal",
+			sql:  "INSERT INTO notes VALUES ('-- migrate:down');\n",
+			want: "INSERT INTO notes VALUES ('-- migrate:down');\n",
+		},
+		{
+			name: "dollar body",
+			sql:  "CREATE FUNCTION f() RETURNS void AS $$\n-- migrate:down\nSELECT 1;\n$$ LANGUAGE sql;\n",
+			want: "CREATE FUNCTION f() RETURNS void AS $$\n-- migrate:down\nSELECT 1;\n$$ LANGUAGE sql;\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := stripMigrationMarkers(tt.sql)
+			if got != tt.want || found != tt.found {
+				t.Errorf("stripMigrationMarkers() = %q, %v; want %q, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestMigrationMarkersMode(t *testing.T) {
+	script := "cat <<'EOF'\n-- migrate:up\nCREATE TABLE users (id INT NOT NULL, PRIMARY KEY (id));\n\n-- migrate:down\nDROP TABLE users;\nEOF\n"
+
+	e := scriptExecutor(t, script)
+	plan, err := e.PlanContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := plan.Desired.Tables["users"]; !ok {
+		t.Errorf("desired schema = %v, want the users table from the up section", plan.Desired.Tables)
+	}
+	var warned bool
+	for _, w := range plan.Warnings {
+		warned = warned || w.Code == "migration-markers"
+	}
+	if !warned {
+		t.Errorf("warnings = %v, want migration-markers", plan.Warnings)
+	}
+
+	e = scriptExecutor(t, script)
+	e.SetMigrationMarkers(MarkersError)
+	if _, err := e.PlanContext(context.Background()); !errors.Is(err, ErrMigrationMarkers) {
+		t.Errorf("PlanContext() = %v, want ErrMigrationMarkers", err)
+	}
+}
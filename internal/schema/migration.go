@@ -0,0 +1,67 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MigrationFile adalah satu file migration yang sudah dipecah menjadi
+// statement bagian up dan down
+type MigrationFile struct {
+	Name    string
+	Version string
+	// Hash adalah checksum isi file, sama dengan entry-nya di datara.sum
+	Hash string
+	Up   []string
+	Down []string
+}
+
+// ReadMigrations membaca semua migration di dir secara berurutan. separator
+// (mis. GO) adalah batch separator yang ditulis di baris sendiri setelah
+// statement; baris tersebut dibuang. Kosong berarti tidak ada separator.
+// pattern adalah pola nama file migration; nil berarti DefaultMigrationPattern.
+func ReadMigrations(dir string, pattern *regexp.Regexp, separator string) ([]MigrationFile, error) {
+	files, err := migrationFiles(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]MigrationFile, 0, len(files))
+	for _, name := range files {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		sql := string(content)
+		if migrationVersion(name) == "" {
+			return nil, fmt.Errorf("migration %s has no version prefix", name)
+		}
+
+		upStart, upEnd := upSection(sql)
+		migrations = append(migrations, MigrationFile{
+			Name:    name,
+			Version: migrationVersion(name),
+			Hash:    calculateHash(sql),
+			Up:      splitStatements(withoutSeparator(sql[upStart:upEnd], separator)),
+			Down:    splitStatements(withoutSeparator(sql[downSection(sql):], separator)),
+		})
+	}
+	return migrations, nil
+}
+
+// withoutSeparator membuang baris yang hanya berisi batch separator
+func withoutSeparator(sql, separator string) string {
+	if separator == "" {
+		return sql
+	}
+	lines := strings.Split(sql, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.EqualFold(strings.TrimSpace(line), separator) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/diff"
+)
+
+func TestBatchSeparator(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "schema.sh", "echo 'CREATE TABLE users (id INT NOT NULL, name VARCHAR(50));'\n")
+	e := NewExecutor([]string{"/bin/sh", "schema.sh"}, dir, &diff.Config{Dialect: diff.DialectMSSQL})
+	e.SetBatchSeparator("GO")
+	plan, err := e.PlanContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	migration, err := e.Apply(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-- migrate:up\n\n" +
+		"CREATE TABLE [users] (\n  [id] INT NOT NULL,\n  [name] NVARCHAR(50)\n);\nGO\n\n" +
+		"-- migrate:down\n\n" +
+		"DROP TABLE [users];\nGO"
+	if migration != want {
+		t.Errorf("migration =\n%s\nwant\n%s", migration, want)
+	}
+
+	// Baris GO dibuang lagi saat migration dibaca
+	writeTestFile(t, dir, "migrations/20240101000000_users.sql", migration)
+	files, err := ReadMigrations(dir+"/migrations", nil, "GO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("read %d migrations, want 1", len(files))
+	}
+	wantUp := []string{"CREATE TABLE [users] (\n  [id] INT NOT NULL,\n  [name] NVARCHAR(50)\n)"}
+	wantDown := []string{"DROP TABLE [users]"}
+	if !reflect.DeepEqual(files[0].Up, wantUp) || !reflect.DeepEqual(files[0].Down, wantDown) {
+		t.Errorf("read back up %q, down %q, want %q, %q", files[0].Up, files[0].Down, wantUp, wantDown)
+	}
+}
@@ -0,0 +1,82 @@
+package schema
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// SetSyncOrder mengaktifkan penambahan statement yang mengembalikan urutan
+// kolom fisik ke urutan model pada plan (hanya MySQL). Urutan fisik diambil
+// dari menjalankan ulang migration dbmate di migrationDir; string kosong
+// menonaktifkannya.
+func (e *Executor) SetSyncOrder(migrationDir string) {
+	e.syncOrder = migrationDir
+}
+
+// OrderingDrift melaporkan tabel yang urutan kolom fisiknya berbeda dari
+// model. Urutan fisik adalah hasil menjalankan ulang migration di migrationDir
+// ditambah plan.Up yang belum ditulis. plan nil (ErrNoChanges) berarti model
+// diambil dari snapshot.
+func (e *Executor) OrderingDrift(migrationDir string, plan *Plan) ([]diff.OrderDrift, error) {
+	var up []string
+	var model *state.SchemaState
+	if plan != nil {
+		up, model = plan.Up, plan.Desired
+	} else {
+		var err error
+		if model, err = e.loadSnapshot(); err != nil {
+			return nil, fmt.Errorf("failed to load schema snapshot: %w", err)
+		}
+	}
+	physical, err := physicalSchema(migrationDir, up)
+	if err != nil {
+		return nil, err
+	}
+	return e.diff.OrderingDrift(physical, model), nil
+}
+
+// physicalSchema menjalankan ulang migration dbmate di dir lalu statement
+// pending. Direktori yang belum ada berarti belum ada tabel.
+func physicalSchema(dir string, pending []string) (*state.SchemaState, error) {
+	r := newReplay()
+	if _, err := os.Stat(dir); err == nil {
+		imported, err := ImportMigrations(dir, RunnerDbmate)
+		if err != nil {
+			return nil, err
+		}
+		r.schema = imported.Schema
+	}
+	for i, stmt := range pending {
+		for _, span := range splitStatementSpans(stmt) {
+			r.apply(normalizeDefinition(span.Text), fmt.Sprintf("pending:%d", i+1))
+		}
+	}
+	return r.schema, nil
+}
+
+// appendSyncOrder menambahkan statement sync order ke plan.Up jika
+// SetSyncOrder aktif dan dialect bisa memindahkan kolom
+func (e *Executor) appendSyncOrder(plan *Plan) error {
+	if e.syncOrder == "" || !e.diff.CanReorderColumns() {
+		return nil
+	}
+	drifts, err := e.OrderingDrift(e.syncOrder, plan)
+	if err != nil {
+		return fmt.Errorf("failed to check column order: %w", err)
+	}
+	if len(drifts) == 0 {
+		return nil
+	}
+	var tables []string
+	for _, drift := range drifts {
+		tables = append(tables, drift.Table)
+	}
+	log.Printf("Reordering columns of %s", strings.Join(tables, ", "))
+	plan.Up = append(plan.Up, e.diff.SyncOrder(plan.Desired, drifts)...)
+	return nil
+}
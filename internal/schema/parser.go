@@ -0,0 +1,839 @@
+package schema
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// columnKeywords menandai akhir dari tipe kolom di dalam definisi kolom
+var columnKeywords = map[string]bool{
+	"NOT":            true,
+	"NULL":           true,
+	"DEFAULT":        true,
+	"PRIMARY":        true,
+	"UNIQUE":         true,
+	"REFERENCES":     true,
+	"CHECK":          true,
+	"CONSTRAINT":     true,
+	"AUTO_INCREMENT": true,
+	"AUTOINCREMENT":  true,
+	"COMMENT":        true,
+	"COLLATE":        true,
+	"CHARACTER":      true,
+	"CHARSET":        true,
+	"GENERATED":      true,
+	"ON":             true,
+	"SRID":           true,
+	"STORAGE":        true,
+	"COMPRESSION":    true,
+}
+
+// ParseSQL mengkonversi DDL (output schema program atau snapshot SQL lama)
+// menjadi SchemaState. Statement selain CREATE TABLE, CREATE INDEX dan
+// ALTER TABLE ... ADD diabaikan. CREATE TABLE ganda dengan definisi berbeda
+// menghasilkan *DuplicateTableError; yang identik digabung dan dicatat di log.
+func ParseSQL(sql string) (*state.SchemaState, error) {
+	schema, warnings, err := parseSQL(sql)
+	for _, warning := range warnings {
+		log.Printf("Warning: %s", warning)
+	}
+	return schema, err
+}
+
+// parseSQL adalah ParseSQL yang mengembalikan peringatan CREATE TABLE ganda
+// alih-alih mencetaknya
+func parseSQL(sql string) (*state.SchemaState, state.Warnings, error) {
+	schema := state.NewSchemaState()
+	tables := newTableSet(schema)
+
+	for i, stmt := range splitStatements(sql) {
+		stmt = normalizeDefinition(stmt)
+		upper := strings.ToUpper(stmt)
+
+		switch {
+		case strings.HasPrefix(upper, "CREATE TABLE"):
+			table, err := parseCreateTable(stmt)
+			if err != nil {
+				return nil, nil, err
+			}
+			table.Position = len(schema.Tables) + 1
+			if err := tables.add(table, fmt.Sprintf("the CREATE TABLE at statement %d", i+1)); err != nil {
+				return nil, nil, err
+			}
+		case isCreateIndex(upper):
+			tableName, idx, err := parseCreateIndex(stmt)
+			if err != nil {
+				return nil, nil, err
+			}
+			table, ok := schema.GetTable(tableName)
+			if !ok {
+				return nil, nil, fmt.Errorf("index %q references unknown table %q", idx.Name, tableName)
+			}
+			table.Indexes[idx.Name] = idx
+		case strings.HasPrefix(upper, "ALTER TABLE"):
+			tableName, constraint, ok := parseAlterAddConstraint(stmt)
+			if !ok {
+				log.Printf("Skipping unsupported statement: %s", stmt)
+				continue
+			}
+			table, exists := schema.GetTable(tableName)
+			if !exists {
+				return nil, nil, fmt.Errorf("constraint %q references unknown table %q", constraint.Name, tableName)
+			}
+			table.Constraints = append(table.Constraints, constraint)
+			schema.AddTable(table)
+		default:
+			log.Printf("Skipping unsupported statement: %s", stmt)
+		}
+	}
+
+	return schema, tables.warnings, nil
+}
+
+// parseCreateTable mengkonversi satu statement CREATE TABLE menjadi Table
+func parseCreateTable(stmt string) (state.Table, error) {
+	start := strings.Index(stmt, "(")
+	end := strings.LastIndex(stmt, ")")
+	if start == -1 || end < start {
+		return state.Table{}, fmt.Errorf("invalid CREATE TABLE statement: %s", stmt)
+	}
+
+	header := strings.Fields(stmt[:start])
+	if len(header) < 3 {
+		return state.Table{}, fmt.Errorf("missing table name in statement: %s", stmt)
+	}
+
+	table := state.Table{
+		Name:        unquoteIdent(header[len(header)-1]),
+		Columns:     make(map[string]state.Column),
+		Indexes:     make(map[string]state.Index),
+		Constraints: make([]state.Constraint, 0),
+	}
+
+	for _, def := range splitKeepingParentheses(stmt[start+1 : end]) {
+		def = normalizeDefinition(def)
+		if def == "" {
+			continue // trailing comma
+		}
+
+		if idx, ok := parseInlineIndex(def); ok {
+			table.Indexes[idx.Name] = idx
+			continue
+		}
+
+		if isTableConstraint(def) {
+			table.Constraints = append(table.Constraints, newConstraint(table.Name, def))
+			continue
+		}
+
+		column, constraints := parseColumnDef(table.Name, def)
+		if column.Name == "" {
+			continue
+		}
+		column.Position = len(table.Columns) + 1
+		table.Columns[column.Name] = column
+		table.Constraints = append(table.Constraints, constraints...)
+	}
+	table.Options = parseTableOptions(stmt[end+1:])
+
+	return table, nil
+}
+
+// tableOptionPattern menemukan opsi tabel di belakang CREATE TABLE, mis.
+// ENGINE=InnoDB, DEFAULT CHARSET=utf8mb4 atau ROW_FORMAT=COMPRESSED
+var tableOptionPattern = regexp.MustCompile(`(?i)\b(?:DEFAULT\s+)?(ENGINE|CHARACTER\s+SET|CHARSET|COLLATE|ROW_FORMAT|AUTO_INCREMENT|KEY_BLOCK_SIZE)\s*=?\s*('[^']*'|"[^"]*"|[^\s,;]+)`)
+
+// parseTableOptions membaca opsi tabel dari bagian setelah daftar kolom
+// CREATE TABLE ke bentuk Table.Options; nil jika tidak ada
+func parseTableOptions(footer string) map[string]string {
+	options := make(map[string]string)
+	for _, match := range tableOptionPattern.FindAllStringSubmatch(footer, -1) {
+		options[match[1]] = strings.Trim(match[2], `'"`)
+	}
+	return state.NormalizeTableOptions(options)
+}
+
+// parseColumnDef mengkonversi definisi kolom menjadi Column beserta
+// constraint inline (PRIMARY KEY, UNIQUE, REFERENCES) sebagai table constraint
+func parseColumnDef(tableName, def string) (state.Column, []state.Constraint) {
+	tokens := splitDefaultToken(splitTokens(def))
+	if len(tokens) < 2 {
+		return state.Column{}, nil
+	}
+
+	column := state.Column{
+		Name:     unquoteIdent(tokens[0]),
+		Nullable: true,
+	}
+	quotedName := tokens[0]
+
+	// Tipe bisa terdiri dari beberapa kata (mis. "timestamp with time zone")
+	i := 2
+	for i < len(tokens) && !columnKeywords[strings.ToUpper(tokens[i])] {
+		i++
+	}
+	column.Type = strings.Join(tokens[1:i], " ")
+	if strings.Contains(strings.ToLower(column.Type), "serial") {
+		column.AutoIncrement = true
+		column.Identity = state.IdentitySerial
+	}
+
+	var constraints []state.Constraint
+	var extra []string
+	for i < len(tokens) {
+		keyword := strings.ToUpper(tokens[i])
+		i++
+
+		switch keyword {
+		case "NOT":
+			if i < len(tokens) && strings.ToUpper(tokens[i]) == "NULL" {
+				column.Nullable = false
+				i++
+			}
+		case "NULL":
+			column.Nullable = true
+		case "DEFAULT":
+			var value []string
+			for i < len(tokens) && !columnKeywords[strings.ToUpper(tokens[i])] {
+				value = append(value, tokens[i])
+				i++
+			}
+			// NULL juga keyword kolom, jadi DEFAULT NULL perlu diambil di sini
+			if len(value) == 0 && i < len(tokens) && strings.ToUpper(tokens[i]) == "NULL" {
+				value = append(value, tokens[i])
+				i++
+			}
+			column.DefaultValue = state.ParseDefault(strings.Join(value, " "))
+		case "PRIMARY":
+			if i < len(tokens) && strings.ToUpper(tokens[i]) == "KEY" {
+				i++
+			}
+			column.Nullable = false
+			constraints = append(constraints, newConstraint(tableName, fmt.Sprintf("PRIMARY KEY(%s)", quotedName)))
+		case "UNIQUE":
+			constraints = append(constraints, newConstraint(tableName, fmt.Sprintf("UNIQUE(%s)", quotedName)))
+		case "REFERENCES":
+			// ON DELETE/ON UPDATE adalah bagian dari REFERENCES
+			ref := []string{"REFERENCES"}
+			for i < len(tokens) && (!columnKeywords[strings.ToUpper(tokens[i])] || strings.ToUpper(tokens[i]) == "ON") {
+				ref = append(ref, tokens[i])
+				i++
+			}
+			constraints = append(constraints, newConstraint(tableName,
+				fmt.Sprintf("FOREIGN KEY(%s) %s", quotedName, strings.Join(ref, " "))))
+		case "ON":
+			// ON UPDATE expr (MySQL)
+			if i < len(tokens) && strings.ToUpper(tokens[i]) == "UPDATE" {
+				i++
+				var value []string
+				for i < len(tokens) && !columnKeywords[strings.ToUpper(tokens[i])] {
+					value = append(value, tokens[i])
+					i++
+				}
+				column.OnUpdate = state.NormalizeOnUpdate(strings.Join(value, " "))
+			}
+		case "AUTO_INCREMENT", "AUTOINCREMENT":
+			column.AutoIncrement = true
+		case "CHARACTER", "CHARSET":
+			// CHARACTER SET x atau CHARSET x
+			if keyword == "CHARACTER" && i < len(tokens) && strings.ToUpper(tokens[i]) == "SET" {
+				i++
+			}
+			if i < len(tokens) {
+				column.Charset = strings.ToLower(unquoteIdent(tokens[i]))
+				i++
+			}
+		case "COLLATE":
+			if i < len(tokens) {
+				column.Collation = unquoteIdent(tokens[i])
+				i++
+			}
+		case "GENERATED":
+			// GENERATED {ALWAYS | BY DEFAULT} AS IDENTITY; kolom computed (STORED) dilewati
+			for j := i; j < len(tokens); j++ {
+				upper := strings.ToUpper(tokens[j])
+				if upper == "IDENTITY" || strings.HasPrefix(upper, "IDENTITY(") {
+					column.AutoIncrement = true
+					column.Identity = state.IdentityByDefault
+					if strings.ToUpper(tokens[i]) == "ALWAYS" {
+						column.Identity = state.IdentityAlways
+					}
+					i = j + 1
+					// Opsi sequence, mis. (START WITH 1 CACHE 10), disimpan apa adanya
+					if options := tokens[j][len("IDENTITY"):]; options != "" {
+						extra = append(extra, options)
+					} else if i < len(tokens) && strings.HasPrefix(tokens[i], "(") {
+						extra = append(extra, tokens[i])
+						i++
+					}
+					break
+				}
+			}
+		case "SRID":
+			// SRID 4326 (MySQL)
+			if i < len(tokens) {
+				column.SRID, _ = strconv.Atoi(tokens[i])
+				i++
+			}
+		case "STORAGE", "COMPRESSION":
+			// Atribut yang tidak dimodelkan (STORAGE/COMPRESSION Postgres)
+			// disimpan di Extra agar tidak hilang saat kolom diubah
+			if i < len(tokens) {
+				extra = append(extra, keyword+" "+tokens[i])
+				i++
+			}
+		case "COMMENT":
+			if i < len(tokens) {
+				if column.Tags == nil {
+					column.Tags = make(map[string]string)
+				}
+				class, comment := state.SplitClassComment(unquoteString(tokens[i]))
+				column.Class = class
+				if comment != "" {
+					column.Tags["comment"] = comment
+				}
+				i++
+			}
+		}
+	}
+
+	column.Extra = strings.Join(extra, " ")
+	column.NormalizeSpatial()
+	return column, constraints
+}
+
+// isCreateIndex mengecek statement CREATE [UNIQUE | SPATIAL] INDEX
+func isCreateIndex(upper string) bool {
+	for _, prefix := range []string{"CREATE INDEX", "CREATE UNIQUE INDEX", "CREATE SPATIAL INDEX"} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCreateIndex mengkonversi CREATE [UNIQUE | SPATIAL] INDEX menjadi
+// Index. SPATIAL (MySQL) dan USING GIST (Postgres) menandai index spatial.
+func parseCreateIndex(stmt string) (string, state.Index, error) {
+	tokens := splitTokens(stmt)
+	idx := state.Index{}
+
+	var tableName string
+	for i := 0; i < len(tokens); i++ {
+		switch strings.ToUpper(tokens[i]) {
+		case "UNIQUE":
+			idx.Unique = true
+		case "SPATIAL":
+			idx.Spatial = true
+		case "USING":
+			if i+1 < len(tokens) && strings.HasPrefix(strings.ToUpper(tokens[i+1]), "GIST") {
+				idx.Spatial = true
+			}
+		case "INDEX":
+			// Lewati CONCURRENTLY dan IF NOT EXISTS
+			j := i + 1
+			for j < len(tokens) && isIndexModifier(tokens[j]) {
+				j++
+			}
+			if j < len(tokens) && strings.ToUpper(tokens[j]) != "ON" {
+				idx.Name = unquoteIdent(tokens[j])
+			}
+		case "ON":
+			if i+1 >= len(tokens) {
+				break
+			}
+			target := tokens[i+1]
+			if strings.ToUpper(target) == "ONLY" && i+2 < len(tokens) {
+				target = tokens[i+2]
+			}
+
+			// Nama tabel dan kolom bisa menempel: "users"("email")
+			if open := strings.Index(target, "("); open != -1 {
+				tableName = unquoteIdent(target[:open])
+				parseIndexColumns(&idx, target[open:])
+			} else {
+				tableName = unquoteIdent(target)
+				// Daftar kolom bisa menempel pada metode: USING btree("email")
+				for _, tok := range tokens[i+2:] {
+					if open := strings.Index(tok, "("); open != -1 {
+						parseIndexColumns(&idx, tok[open:])
+						break
+					}
+				}
+			}
+		case "INCLUDE":
+			if i+1 < len(tokens) {
+				idx.Include = splitIdentList(tokens[i+1])
+			}
+		default:
+			if strings.HasPrefix(strings.ToUpper(tokens[i]), "INCLUDE(") {
+				idx.Include = splitIdentList(tokens[i][len("INCLUDE"):])
+			}
+		}
+	}
+
+	if idx.Name == "" || tableName == "" || len(idx.Columns) == 0 {
+		return "", idx, fmt.Errorf("invalid CREATE INDEX statement: %s", stmt)
+	}
+	idx.Buckets = bucketCount(stmt)
+	return tableName, idx, nil
+}
+
+// parseInlineIndex membaca definisi index MySQL di dalam CREATE TABLE
+// ([UNIQUE | SPATIAL] KEY|INDEX name (cols)) sehingga setara dengan CREATE
+// INDEX terpisah
+func parseInlineIndex(def string) (state.Index, bool) {
+	tokens := splitTokens(def)
+	idx := state.Index{}
+
+	i := 0
+	if i < len(tokens) {
+		switch strings.ToUpper(tokens[i]) {
+		case "UNIQUE":
+			idx.Unique = true
+			i++
+		case "SPATIAL":
+			idx.Spatial = true
+			i++
+		}
+	}
+	if i >= len(tokens) {
+		return idx, false
+	}
+	if keyword := strings.ToUpper(tokens[i]); keyword != "KEY" && keyword != "INDEX" {
+		return idx, false
+	}
+	i++
+
+	// Nama dan daftar kolom bisa menempel: `idx_email`(`email`)
+	rest := strings.Join(tokens[i:], " ")
+	open := strings.Index(rest, "(")
+	if open <= 0 {
+		return idx, false
+	}
+	idx.Name = unquoteIdent(strings.TrimSpace(rest[:open]))
+	parseIndexColumns(&idx, rest[open:])
+	idx.Buckets = bucketCount(rest)
+
+	// Kolom tanpa quote bernama key/index (valid di Postgres), mis. "key varchar(10)",
+	// bukan index: isi tanda kurungnya angka, bukan nama kolom
+	for _, col := range idx.Columns {
+		if _, err := strconv.Atoi(col); err == nil {
+			return idx, false
+		}
+	}
+	return idx, idx.Name != "" && len(idx.Columns) > 0
+}
+
+// bucketCountPattern menemukan jumlah bucket hash-sharded index CockroachDB,
+// baik WITH (bucket_count = 8) maupun sintaks lama WITH BUCKET_COUNT = 8
+var bucketCountPattern = regexp.MustCompile(`(?i)\bbucket_count\s*=\s*(\d+)`)
+
+// bucketCount mengembalikan bucket_count dari definisi index, atau 0
+func bucketCount(def string) int {
+	match := bucketCountPattern.FindStringSubmatch(def)
+	if match == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(match[1])
+	return n
+}
+
+// parseIndexColumns membaca daftar kolom index termasuk prefix length, mis. ("bio"(191))
+func parseIndexColumns(idx *state.Index, list string) {
+	list = strings.TrimSpace(list)
+	if !strings.HasPrefix(list, "(") {
+		return
+	}
+	if end := matchingParen(list); end != -1 {
+		list = list[1:end]
+	}
+
+	for _, part := range splitKeepingParentheses(list) {
+		fields := strings.Fields(strings.TrimSpace(part))
+		if len(fields) == 0 {
+			continue
+		}
+		name := fields[0]
+		if open := strings.Index(name, "("); open != -1 {
+			if length, err := strconv.Atoi(strings.Trim(name[open:], "()")); err == nil {
+				if idx.Lengths == nil {
+					idx.Lengths = make(map[string]int)
+				}
+				idx.Lengths[unquoteIdent(name[:open])] = length
+			}
+			name = name[:open]
+		}
+		idx.Columns = append(idx.Columns, unquoteIdent(name))
+	}
+}
+
+// parseAlterAddConstraint membaca ALTER TABLE x ADD [CONSTRAINT n] ...
+func parseAlterAddConstraint(stmt string) (string, state.Constraint, bool) {
+	tokens := splitTokens(stmt)
+	for i := 2; i < len(tokens)-1; i++ {
+		if strings.ToUpper(tokens[i]) != "ADD" {
+			continue
+		}
+		def := strings.Join(tokens[i+1:], " ")
+		if !isTableConstraint(def) {
+			return "", state.Constraint{}, false
+		}
+		tableName := unquoteIdent(tokens[i-1])
+		return tableName, newConstraint(tableName, def), true
+	}
+	return "", state.Constraint{}, false
+}
+
+// newConstraint membuat Constraint dari definisi table constraint. NOT VALID
+// di akhir definisi dipisahkan ke Constraint.NotValid.
+func newConstraint(tableName, def string) state.Constraint {
+	upper := strings.ToUpper(def)
+	notValid := strings.HasSuffix(upper, " NOT VALID")
+	if notValid {
+		def = strings.TrimSpace(def[:len(def)-len(" NOT VALID")])
+		upper = strings.ToUpper(def)
+	}
+	constraint := state.Constraint{
+		Name:     constraintName(tableName, def),
+		Def:      def,
+		NotValid: notValid,
+	}
+	for _, constraintType := range []string{"PRIMARY KEY", "FOREIGN KEY", "UNIQUE", "CHECK", "EXCLUDE"} {
+		if strings.Contains(upper, constraintType) {
+			constraint.Type = constraintType
+			break
+		}
+	}
+	return constraint
+}
+
+// isTableConstraint mengecek apakah elemen CREATE TABLE adalah table constraint
+func isTableConstraint(def string) bool {
+	upper := strings.ToUpper(def)
+	for _, keyword := range []string{"CONSTRAINT", "PRIMARY KEY", "FOREIGN KEY", "UNIQUE", "CHECK", "EXCLUDE"} {
+		if upper == keyword || strings.HasPrefix(upper, keyword+" ") || strings.HasPrefix(upper, keyword+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+// constraintName mengembalikan nama constraint. Untuk constraint tanpa nama,
+// nama default Postgres ({table}_pkey, {table}_{cols}_key, {table}_{cols}_fkey) digunakan.
+func constraintName(tableName, def string) string {
+	upper := strings.ToUpper(def)
+	if strings.HasPrefix(upper, "CONSTRAINT ") {
+		parts := strings.SplitN(def, " ", 3)
+		if len(parts) >= 2 {
+			return unquoteIdent(parts[1])
+		}
+	}
+
+	columns := func() string {
+		start := strings.Index(def, "(")
+		end := strings.Index(def, ")")
+		if start == -1 || end < start {
+			return ""
+		}
+		return strings.Join(splitIdentList(def[start:end+1]), "_")
+	}
+
+	switch {
+	case strings.HasPrefix(upper, "PRIMARY KEY"):
+		return tableName + "_pkey"
+	case strings.HasPrefix(upper, "FOREIGN KEY"):
+		return fmt.Sprintf("%s_%s_fkey", tableName, columns())
+	case strings.HasPrefix(upper, "UNIQUE"):
+		return fmt.Sprintf("%s_%s_key", tableName, columns())
+	case strings.HasPrefix(upper, "CHECK"):
+		return tableName + "_check"
+	}
+	return ""
+}
+
+// normalizeDefinition menormalkan whitespace pada satu definisi kolom/constraint.
+// Whitespace berturut-turut (termasuk newline) dijadikan satu spasi dan spasi di
+// sekitar tanda kurung dan koma dihapus, kecuali di dalam string literal.
+func normalizeDefinition(def string) string {
+	var b strings.Builder
+	inQuote := false
+	pendingSpace := false
+
+	for i := 0; i < len(def); i++ {
+		c := def[i]
+		if inQuote {
+			b.WriteByte(c)
+			if c == '\'' {
+				inQuote = false
+			}
+			continue
+		}
+
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			pendingSpace = true
+			continue
+		case '(', ')', ',':
+			// Spasi di sekitar ( ) , tidak signifikan
+			pendingSpace = false
+			b.WriteByte(c)
+			for i+1 < len(def) && strings.ContainsRune(" \t\n\r", rune(def[i+1])) && c != ')' {
+				i++
+			}
+			continue
+		case '\'':
+			inQuote = true
+		}
+
+		if pendingSpace && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		pendingSpace = false
+		b.WriteByte(c)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// splitStatements memisahkan SQL menjadi statement individual berdasarkan ';'
+// di luar string literal dan body dollar quote ($$ atau $tag$). Komentar baris (--) dibuang.
+func splitStatements(sql string) []string {
+	var statements []string
+	for _, span := range splitStatementSpans(sql) {
+		statements = append(statements, span.Text)
+	}
+	return statements
+}
+
+// statementSpan adalah satu statement beserta posisinya di SQL sumber.
+// Start menunjuk karakter pertama statement, End tepat setelah ';' (jika ada).
+type statementSpan struct {
+	Text       string
+	Start, End int
+}
+
+// splitStatementSpans sama dengan splitStatements, tetapi juga mencatat posisi
+// setiap statement agar bisa dilaporkan atau dihapus dari file aslinya
+func splitStatementSpans(sql string) []statementSpan {
+	var spans []statementSpan
+	var current strings.Builder
+	inQuote := false
+	dollar := "" // tag $$ atau $name$ yang sedang terbuka
+	start := -1
+
+	flush := func(end int) {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			spans = append(spans, statementSpan{Text: stmt, Start: start, End: end})
+		}
+		current.Reset()
+		start = -1
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if start == -1 && !inQuote && c != ';' && !isSpace(c) &&
+			!(c == '-' && i+1 < len(sql) && sql[i+1] == '-') {
+			start = i
+		}
+		switch {
+		case c == '$' && !inQuote && (dollar == "" && dollarTag(sql[i:]) != "" || dollar != "" && strings.HasPrefix(sql[i:], dollar)):
+			// Body fungsi Postgres ($$ ... $$ atau $body$ ... $body$) boleh berisi ';'
+			tag := dollar
+			if tag == "" {
+				tag = dollarTag(sql[i:])
+				dollar = tag
+			} else {
+				dollar = ""
+			}
+			current.WriteString(tag)
+			i += len(tag) - 1
+		case dollar != "":
+			current.WriteByte(c)
+		case c == '\'':
+			inQuote = !inQuote
+			current.WriteByte(c)
+		case inQuote:
+			current.WriteByte(c)
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == ';':
+			flush(i + 1)
+		default:
+			current.WriteByte(c)
+		}
+	}
+
+	flush(len(sql))
+	return spans
+}
+
+// dollarTag mengembalikan tag dollar quote Postgres di awal s, mis. $$ atau
+// $_$; kosong jika s tidak diawali tag (mis. parameter $1)
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9':
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// splitKeepingParentheses memisahkan string dengan koma tapi mempertahankan
+// tanda kurung dan string literal
+func splitKeepingParentheses(s string) []string {
+	var result []string
+	var current strings.Builder
+	parenCount := 0
+	inQuote := false
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'':
+			inQuote = !inQuote
+			current.WriteByte(s[i])
+		case inQuote:
+			current.WriteByte(s[i])
+		case s[i] == '(':
+			parenCount++
+			current.WriteByte(s[i])
+		case s[i] == ')':
+			parenCount--
+			current.WriteByte(s[i])
+		case s[i] == ',' && parenCount == 0:
+			result = append(result, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(s[i])
+		}
+	}
+
+	if current.Len() > 0 {
+		result = append(result, current.String())
+	}
+
+	return result
+}
+
+// splitTokens memisahkan definisi yang sudah dinormalisasi berdasarkan spasi,
+// dengan mempertahankan tanda kurung dan string literal sebagai satu token
+// splitDefaultToken memisahkan DEFAULT dari ekspresi dalam kurung yang
+// menempel karena normalizeDefinition, mis. DEFAULT('{}') menjadi DEFAULT dan ('{}')
+func splitDefaultToken(tokens []string) []string {
+	result := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if len(token) > len("DEFAULT") && strings.EqualFold(token[:len("DEFAULT")], "DEFAULT") && token[len("DEFAULT")] == '(' {
+			result = append(result, token[:len("DEFAULT")], token[len("DEFAULT"):])
+			continue
+		}
+		result = append(result, token)
+	}
+	return result
+}
+
+func splitTokens(s string) []string {
+	var tokens []string
+	var current strings.Builder
+	depth := 0
+	inQuote := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ' ' && depth == 0:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteByte(c)
+	}
+
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// splitIdentList membaca daftar identifier dalam tanda kurung, mis. ("a","b")
+func splitIdentList(list string) []string {
+	list = strings.TrimSpace(list)
+	list = strings.TrimPrefix(list, "(")
+	list = strings.TrimSuffix(list, ")")
+
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, unquoteIdent(name))
+		}
+	}
+	return names
+}
+
+// matchingParen mengembalikan posisi ')' yang menutup '(' di awal string
+func matchingParen(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isIndexModifier mengecek kata kunci opsional setelah CREATE INDEX
+func isIndexModifier(token string) bool {
+	switch strings.ToUpper(token) {
+	case "CONCURRENTLY", "IF", "NOT", "EXISTS":
+		return true
+	}
+	return false
+}
+
+// unquoteIdent menghapus quote dari identifier, termasuk schema prefix ("public"."users")
+func unquoteIdent(name string) string {
+	name = strings.TrimSpace(name)
+	if dot := strings.LastIndex(name, "."); dot != -1 {
+		name = name[dot+1:]
+	}
+	return strings.Trim(name, "\"`[]")
+}
+
+// unquoteString menghapus quote dari string literal SQL
+func unquoteString(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		s = s[1 : len(s)-1]
+	}
+	return strings.ReplaceAll(s, "''", "'")
+}
@@ -0,0 +1,261 @@
+package schema
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// gormPostgres adalah output gormschema.New("postgres") untuk model User dan
+// Profile dengan unique index dan foreign key
+const gormPostgres = `CREATE TABLE "users" ("id" bigserial,"username" varchar(100) NOT NULL,"email" varchar(255) NOT NULL,"is_active" boolean NOT NULL DEFAULT true,"created_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,PRIMARY KEY ("id"),CONSTRAINT "uni_users_username" UNIQUE ("username"));
+CREATE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE TABLE "profiles" ("id" bigserial,"user_id" bigint NOT NULL,"bio" varchar(500),PRIMARY KEY ("id"),CONSTRAINT "fk_profiles_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),UNIQUE ("user_id"));
+`
+
+// gormPostgresFormatted adalah gormPostgres setelah dirapikan: satu definisi
+// per baris, constraint yang dipecah beberapa baris dan koma di akhir
+const gormPostgresFormatted = `CREATE TABLE "users" (
+  "id" bigserial,
+  "username" varchar(100) NOT NULL,
+  "email"    varchar(255) NOT NULL,
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "uni_users_username"
+    UNIQUE ("username")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+
+CREATE TABLE "profiles" (
+  "id" bigserial,
+  "user_id" bigint NOT NULL,
+  "bio" varchar(500),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_profiles_user"
+    FOREIGN KEY ("user_id")
+    REFERENCES "users"("id"),
+  UNIQUE ("user_id"),
+);
+`
+
+func TestParseSQLTableConstraints(t *testing.T) {
+	tests := []struct {
+		table       string
+		columns     []string
+		constraints map[string]string
+	}{
+		{
+			table:   "users",
+			columns: []string{"created_at", "email", "id", "is_active", "username"},
+			constraints: map[string]string{
+				"users_pkey":         "PRIMARY KEY",
+				"uni_users_username": "UNIQUE",
+			},
+		},
+		{
+			table:   "profiles",
+			columns: []string{"bio", "id", "user_id"},
+			constraints: map[string]string{
+				"profiles_pkey":        "PRIMARY KEY",
+				"fk_profiles_user":     "FOREIGN KEY",
+				"profiles_user_id_key": "UNIQUE",
+			},
+		},
+	}
+	for _, fixture := range []struct{ name, sql string }{{"gormschema", gormPostgres}, {"formatted", gormPostgresFormatted}} {
+		parsed, err := ParseSQL(fixture.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", fixture.name, err)
+		}
+		for _, tt := range tests {
+			table, ok := parsed.Tables[tt.table]
+			if !ok {
+				t.Fatalf("%s: table %s not parsed", fixture.name, tt.table)
+			}
+			var columns []string
+			for name := range table.Columns {
+				columns = append(columns, name)
+			}
+			sort.Strings(columns)
+			if !reflect.DeepEqual(columns, tt.columns) {
+				t.Errorf("%s: %s columns = %v, want %v", fixture.name, tt.table, columns, tt.columns)
+			}
+			constraints := make(map[string]string)
+			for _, c := range table.Constraints {
+				constraints[c.Name] = c.Type
+			}
+			if !reflect.DeepEqual(constraints, tt.constraints) {
+				t.Errorf("%s: %s constraints = %v, want %v", fixture.name, tt.table, constraints, tt.constraints)
+			}
+		}
+	}
+}
+
+func TestParseSQLFormattingIsNotAChange(t *testing.T) {
+	compact, err := ParseSQL(gormPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatted, err := ParseSQL(gormPostgresFormatted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, err := diff.NewGenerator(&diff.Config{Dialect: diff.DialectPostgres}).GenerateStatements(compact, formatted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 0 {
+		t.Errorf("reformatting the schema produced changes: %v", statements)
+	}
+}
+
+func TestParseSQLDefaultsRoundTrip(t *testing.T) {
+	sql := `CREATE TABLE settings (
+  id INT NOT NULL,
+  note VARCHAR(50) DEFAULT NULL,
+  retries INT NOT NULL DEFAULT 0,
+  code VARCHAR(10) NOT NULL DEFAULT '0',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  label VARCHAR(50) NOT NULL DEFAULT 'it''s quoted',
+  empty VARCHAR(50) NOT NULL DEFAULT '',
+  plain VARCHAR(50),
+  PRIMARY KEY (id)
+);`
+	parsed, err := ParseSQL(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dialect := range []string{diff.DialectMySQL, diff.DialectPostgres} {
+		g := diff.NewGenerator(&diff.Config{Dialect: dialect})
+		statements, err := g.GenerateStatements(state.NewSchemaState(), parsed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rendered, err := ParseSQL(strings.Join(statements, "\n\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, column := range parsed.Tables["settings"].Columns {
+			if got := rendered.Tables["settings"].Columns[name].DefaultValue; !got.Equal(column.DefaultValue) {
+				t.Errorf("%s: %s default after rendering = %+v, want %+v", dialect, name, got, column.DefaultValue)
+			}
+		}
+		changes, err := g.GenerateStatements(parsed, rendered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("%s: re-parsing the rendered table produced changes: %v", dialect, changes)
+		}
+	}
+}
+
+// TestParseSQLBooleanDumps memastikan dump MySQL (TINYINT(1) dengan default
+// '1'/'0') dan dump Postgres (boolean true/false) dari tabel yang sama tidak
+// saling berbeda, di dialect mana pun
+func TestParseSQLBooleanDumps(t *testing.T) {
+	mysql, err := ParseSQL("CREATE TABLE `users` (\n  `id` int NOT NULL,\n  `is_active` tinyint(1) NOT NULL DEFAULT '1',\n  `is_verified` tinyint(1) NOT NULL DEFAULT '0',\n  PRIMARY KEY (`id`)\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres, err := ParseSQL(`CREATE TABLE public.users (
+    id integer NOT NULL,
+    is_active boolean DEFAULT true NOT NULL,
+    is_verified boolean DEFAULT false NOT NULL,
+    PRIMARY KEY (id)
+);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dialect := range []string{diff.DialectMySQL, diff.DialectPostgres} {
+		statements, err := diff.NewGenerator(&diff.Config{Dialect: dialect}).GenerateStatements(mysql, postgres)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stmt := range statements {
+			if strings.Contains(stmt, "is_") {
+				t.Errorf("%s: boolean columns differ between dumps: %s", dialect, stmt)
+			}
+		}
+	}
+}
+
+// TestParseSQLForeignKeyDefaultActions memastikan foreign key model tanpa
+// aksi tidak berbeda dengan dump database yang menulis NO ACTION eksplisit,
+// di engine mana pun
+func TestParseSQLForeignKeyDefaultActions(t *testing.T) {
+	model, err := ParseSQL(`CREATE TABLE users (id INT NOT NULL, PRIMARY KEY (id));
+CREATE TABLE posts (
+  id INT NOT NULL,
+  user_id INT NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_posts_user_id FOREIGN KEY (user_id) REFERENCES users (id)
+);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump, err := ParseSQL(`CREATE TABLE users (id INT NOT NULL, PRIMARY KEY (id));
+CREATE TABLE posts (
+  id INT NOT NULL,
+  user_id INT NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_posts_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE NO ACTION ON UPDATE NO ACTION
+);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dialect := range []string{diff.DialectMySQL, diff.DialectPostgres, diff.DialectMSSQL, diff.DialectCockroach} {
+		g := diff.NewGenerator(&diff.Config{Dialect: dialect})
+		for _, pair := range [][2]*state.SchemaState{{model, dump}, {dump, model}} {
+			statements, err := g.GenerateStatements(pair[0], pair[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(statements) > 0 {
+				t.Errorf("%s: foreign key actions differ: %q", dialect, statements)
+			}
+		}
+	}
+}
+
+func TestParseSQLQuotedIdentifiers(t *testing.T) {
+	forms := []string{
+		"CREATE TABLE `order` (`select` INT NOT NULL, `group` INT, PRIMARY KEY (`select`));\nCREATE INDEX `index` ON `order` (`group`);",
+		`CREATE TABLE "order" ("select" INT NOT NULL, "group" INT, PRIMARY KEY ("select"));` + "\n" + `CREATE INDEX "index" ON "order" ("group");`,
+		"CREATE TABLE [order] ([select] INT NOT NULL, [group] INT, PRIMARY KEY ([select]));\nCREATE INDEX [index] ON [order] ([group]);",
+	}
+	var first *state.SchemaState
+	for _, sql := range forms {
+		parsed, err := ParseSQL(sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		table, ok := parsed.Tables["order"]
+		if !ok {
+			t.Fatalf("table order not parsed from:\n%s", sql)
+		}
+		if _, ok := table.Columns["group"]; !ok {
+			t.Errorf("column group not parsed from:\n%s\ncolumns: %v", sql, table.Columns)
+		}
+		if idx, ok := table.Indexes["index"]; !ok || !reflect.DeepEqual(idx.Columns, []string{"group"}) {
+			t.Errorf("index on group not parsed from:\n%s\nindexes: %v", sql, table.Indexes)
+		}
+		if first == nil {
+			first = parsed
+			continue
+		}
+		statements, err := diff.NewGenerator(&diff.Config{Dialect: diff.DialectMySQL}).GenerateStatements(first, parsed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(statements) != 0 {
+			t.Errorf("quoting style produced changes: %v", statements)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// recordingProgress mencatat setiap laporan sebagai "tahap current/total"
+type recordingProgress []string
+
+func (p *recordingProgress) OnStage(name string, current, total int) {
+	*p = append(*p, fmt.Sprintf("%s %d/%d", name, current, total))
+}
+
+func TestPlanProgress(t *testing.T) {
+	e := scriptExecutor(t, "cat <<'SQL'\n"+
+		"CREATE TABLE users (id BIGINT PRIMARY KEY);\n"+
+		"CREATE TABLE orders (id BIGINT PRIMARY KEY, user_id BIGINT REFERENCES users (id));\n"+
+		"SQL\n")
+	var progress recordingProgress
+	e.SetProgress(&progress)
+	plan, err := e.PlanContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Down) == 0 {
+		t.Fatal("plan has no down statements")
+	}
+	// Down tidak dilaporkan lagi, jadi diffing hanya muncul sekali per tabel
+	want := recordingProgress{
+		"schema program 0/1", "schema program 1/1",
+		"parsing 0/1", "parsing 1/1",
+		"diffing 0/2", "diffing 1/2", "diffing 2/2",
+	}
+	if !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress = %q, want %q", progress, want)
+	}
+}
@@ -0,0 +1,123 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// defaultRelAction adalah aksi ON DELETE/ON UPDATE jika rel tag tidak menyebutnya
+const defaultRelAction = "CASCADE"
+
+// relation adalah foreign key dari rel tag yang baru bisa di-resolve setelah
+// semua tabel dibuat
+type relation struct {
+	table  string
+	field  string
+	column string
+	// model adalah nama model jika field bertipe struct model lain
+	model    string
+	nullable bool
+
+	refTable  string
+	refColumn string
+	onDelete  string
+	onUpdate  string
+	// options adalah opsi key=value mentah, untuk melaporkan key yang tidak dikenal
+	options map[string]string
+}
+
+// parseRelTag membaca rel tag "table,column,ondelete=...,onupdate=...".
+// table dan column boleh kosong; aksi default-nya CASCADE. Quote dan kurung
+// diperlakukan sama seperti db tag.
+func parseRelTag(tag string) (relation, error) {
+	rel := relation{onDelete: defaultRelAction, onUpdate: defaultRelAction, options: map[string]string{}}
+	parts, err := splitTag(tag)
+	if err != nil {
+		return rel, err
+	}
+	var positional []string
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		key, value, hasValue := strings.Cut(part, "=")
+		if !hasValue {
+			positional = append(positional, unquoteTagValue(part))
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = unquoteTagValue(strings.TrimSpace(value))
+		rel.options[key] = value
+		switch key {
+		case "ondelete":
+			rel.onDelete = strings.ToUpper(value)
+		case "onupdate":
+			rel.onUpdate = strings.ToUpper(value)
+		}
+	}
+	if len(positional) > 0 {
+		rel.refTable = positional[0]
+	}
+	if len(positional) > 1 {
+		rel.refColumn = positional[1]
+	}
+	return rel, nil
+}
+
+// resolveRelations membuat foreign key untuk setiap rel tag. Foreign key lain
+// pada kolom yang sama diganti. Tabel dan kolom yang direferensikan harus ada
+// di schema akhir.
+func (g *Generator) resolveRelations(schema *state.SchemaState, models map[string]string, relations []relation) error {
+	for _, rel := range relations {
+		if rel.model != "" && rel.refTable == "" {
+			rel.refTable = models[rel.model]
+		}
+		refTable, exists := schema.Tables[rel.refTable]
+		if rel.refTable == "" || !exists {
+			return fmt.Errorf("field %s of table %s: rel references unknown table %q", rel.field, rel.table, rel.refTable)
+		}
+		if rel.refColumn == "" {
+			rel.refColumn = primaryKeyColumn(refTable)
+		}
+		refColumn, exists := refTable.Columns[rel.refColumn]
+		if rel.refColumn == "" || !exists {
+			return fmt.Errorf("field %s of table %s: rel references unknown column %q of table %s", rel.field, rel.table, rel.refColumn, rel.refTable)
+		}
+
+		// Kolom foreign key mengikuti tipe kolom yang direferensikan agar lebar
+		// dan sign-nya sama, kecuali tipenya ditulis eksplisit dengan tag type
+		table := schema.Tables[rel.table]
+		if rel.model != "" {
+			table.Columns[rel.column] = state.Column{
+				Name:     rel.column,
+				Type:     refColumn.Type,
+				Nullable: rel.nullable,
+			}
+		} else if column := table.Columns[rel.column]; column.Tags["type"] == "" {
+			column.Type = refColumn.Type
+			table.Columns[rel.column] = column
+		}
+		table.Constraints = append(withoutForeignKeys(table.Constraints, rel.column), state.Constraint{
+			Name: fmt.Sprintf("fk_%s_%s", rel.table, rel.column),
+			Type: "FOREIGN KEY",
+			Def: fmt.Sprintf("FOREIGN KEY (`%s`) REFERENCES `%s` (`%s`) ON DELETE %s ON UPDATE %s",
+				rel.column, rel.refTable, rel.refColumn, rel.onDelete, rel.onUpdate),
+		})
+		schema.Tables[rel.table] = table
+	}
+	return nil
+}
+
+// primaryKeyColumn mengembalikan kolom primary key tunggal tabel, atau
+// string kosong jika tidak ada
+func primaryKeyColumn(table state.Table) string {
+	for _, column := range table.Columns {
+		if _, ok := column.Tags["primary_key"]; ok {
+			return column.Name
+		}
+	}
+	if _, ok := table.Columns["id"]; ok {
+		return "id"
+	}
+	return ""
+}
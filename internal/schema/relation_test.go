@@ -0,0 +1,91 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+func TestForeignKeyColumnMatchesReferencedKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		idTag  string
+		author map[string]interface{}
+		column string
+		want   string
+	}{
+		{
+			name:   "rel tag on an integer field",
+			idTag:  "type=BIGINT UNSIGNED,primary_key,autoincrement",
+			author: map[string]interface{}{"type": "int", "rel_tag": "users,id"},
+			column: "author",
+			want:   "BIGINT UNSIGNED",
+		},
+		{
+			name:   "model field",
+			idTag:  "type=BIGINT UNSIGNED,primary_key,autoincrement",
+			author: map[string]interface{}{"type": "*User", "rel_tag": "ondelete=CASCADE"},
+			column: "author_id",
+			want:   "BIGINT UNSIGNED",
+		},
+		{
+			name:   "signed key",
+			idTag:  "primary_key,autoincrement",
+			author: map[string]interface{}{"type": "uint64", "rel_tag": "users,id"},
+			column: "author",
+			want:   "BIGINT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &modelInfo{Name: "User", Fields: map[string]interface{}{
+				"Id": map[string]interface{}{"type": "int64", "db_tag": tt.idTag},
+			}}
+			post := &modelInfo{Name: "Post", Fields: map[string]interface{}{
+				"Id":     map[string]interface{}{"type": "int64", "db_tag": "primary_key,autoincrement"},
+				"Author": tt.author,
+			}}
+			desired, err := NewGenerator(nil).GenerateSchema(user, post)
+			if err != nil {
+				t.Fatal(err)
+			}
+			column, ok := desired.Tables["posts"].Columns[tt.column]
+			if !ok {
+				t.Fatalf("posts has no column %s: %v", tt.column, desired.Tables["posts"].Columns)
+			}
+			if ref := desired.Tables["users"].Columns["id"].Type; column.Type != ref || column.Type != tt.want {
+				t.Errorf("posts.%s type = %q, want the users.id type %q (%s)", tt.column, column.Type, ref, tt.want)
+			}
+
+			// MySQL hanya membuat constraint jika lebar dan sign-nya sama
+			g := diff.NewGenerator(&diff.Config{Dialect: diff.DialectMySQL})
+			if _, err := g.GenerateDiff(state.NewSchemaState(), desired); err != nil {
+				t.Fatalf("GenerateDiff() = %v, want the foreign key to validate", err)
+			}
+		})
+	}
+}
+
+func TestForeignKeyTypeMismatch(t *testing.T) {
+	user := &modelInfo{Name: "User", Fields: map[string]interface{}{
+		"Id": map[string]interface{}{"type": "int64", "db_tag": "primary_key,autoincrement"},
+	}}
+	post := &modelInfo{Name: "Post", Fields: map[string]interface{}{
+		"Id":     map[string]interface{}{"type": "int64", "db_tag": "primary_key,autoincrement"},
+		"Author": map[string]interface{}{"type": "uint64", "db_tag": "type=BIGINT UNSIGNED", "rel_tag": "users,id"},
+	}}
+	desired, err := NewGenerator(nil).GenerateSchema(user, post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = diff.NewGenerator(&diff.Config{Dialect: diff.DialectMySQL}).GenerateDiff(state.NewSchemaState(), desired)
+	var validationErr *diff.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Rule != "fk-type" {
+		t.Fatalf("GenerateDiff() = %v, want an fk-type validation error", err)
+	}
+	if validationErr.Table != "posts" || validationErr.Column != "author" {
+		t.Errorf("fk-type error on %s.%s, want posts.author", validationErr.Table, validationErr.Column)
+	}
+}
@@ -0,0 +1,407 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// Dump adalah schema yang dibaca ParseDump dari dump SQL
+type Dump struct {
+	Schema *state.SchemaState
+	// Ignored mendeskripsikan objek dump yang tidak dikelola datara, mis.
+	// "CREATE FUNCTION (2 statements)" atau tabel di schema lain
+	Ignored []string
+}
+
+// DefaultDumpSchemas mengembalikan schema database yang dianggap milik datara
+// pada dump: public untuk Postgres dan CockroachDB, dbo untuk SQL Server.
+// Nil berarti semua nama berkualifikasi diterima (MySQL).
+func DefaultDumpSchemas(dialect string) []string {
+	switch dialect {
+	case "postgres", "cockroach":
+		return []string{"public"}
+	case "mssql":
+		return []string{"dbo"}
+	}
+	return nil
+}
+
+// ParseDump membaca tabel, index dan constraint dari dump schema (mis.
+// pg_dump --schema-only atau mysqldump --no-data). Statement dijalankan ulang
+// seperti migration, sehingga ALTER TABLE ... ADD CONSTRAINT dan ALTER COLUMN
+// ... SET DEFAULT ikut diterapkan. Objek di schema selain schemas, routine,
+// view, sequence dan statement lain yang tidak dimodelkan dicatat di Ignored.
+func ParseDump(sql string, schemas []string) (*Dump, error) {
+	sql, routines := stripDelimiterBlocks(sql)
+	ignored := newIgnoredSet()
+	if routines > 0 {
+		ignored.add("MySQL routines and triggers (DELIMITER blocks)", routines)
+	}
+
+	r := newReplay()
+	for _, span := range splitStatementSpans(sql) {
+		stmt := normalizeDefinition(span.Text)
+		upper := strings.ToUpper(stmt)
+		name, ok := dumpObject(stmt, upper)
+		if !ok {
+			ignored.add(statementKind(upper), 1)
+			continue
+		}
+		if qualifier := nameQualifier(name); qualifier != "" && len(schemas) > 0 && !containsFold(schemas, qualifier) {
+			ignored.add(fmt.Sprintf("objects in schema %s", qualifier), 1)
+			continue
+		}
+		if problem, _ := r.apply(stmt, "dump"); problem != "" {
+			return nil, fmt.Errorf("failed to read dump: %s: %s", problem, truncateStatement(stmt))
+		}
+	}
+	for _, skipped := range r.skipped {
+		ignored.add(statementKind(strings.ToUpper(skipped.Statement)), 1)
+	}
+	normalizeDumpColumns(r.schema)
+	for name, table := range r.schema.Tables {
+		// pg_dump menamai setiap constraint, termasuk yang bernama default
+		for i, c := range table.Constraints {
+			table.Constraints[i].Def = withoutDefaultName(name, c.Def)
+		}
+	}
+	return &Dump{Schema: r.schema, Ignored: ignored.list()}, nil
+}
+
+// Repair adalah hasil RepairContext: statement yang membuat database dari
+// dump sama dengan schema program
+type Repair struct {
+	// Statements tidak menghapus data, dalam urutan yang sama seperti generate
+	Statements []string
+	// Destructive menghapus tabel, kolom atau data (mis. mempersempit tipe)
+	// dan sebaiknya ditinjau sebelum dijalankan
+	Destructive []string
+	// Ignored adalah objek dump yang tidak dikelola datara dan dibiarkan
+	Ignored  []string
+	Warnings state.Warnings
+}
+
+// RepairContext menjalankan program schema dan menghasilkan statement yang
+// menyamakan dump dengan schema tersebut. Tabel dump yang tidak ada di schema
+// program maupun snapshot dianggap bukan milik datara dan tidak di-drop.
+// Snapshot, datara.sum dan direktori migration tidak disentuh.
+func (e *Executor) RepairContext(ctx context.Context, dump *Dump) (*Repair, error) {
+	desired, directives, warnings, err := e.programSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := e.loadSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema snapshot: %w", err)
+	}
+
+	repair := &Repair{Ignored: dump.Ignored}
+	current := dump.Schema.Clone()
+	for _, name := range sortedTableNames(current) {
+		_, modeled := desired.Tables[name]
+		_, managed := snapshot.Tables[name]
+		if !modeled && !managed {
+			repair.Ignored = append(repair.Ignored, fmt.Sprintf("table %s (not in the schema program or snapshot)", name))
+			current.RemoveTable(name)
+		}
+	}
+	alignDumpTypes(current, desired)
+	alignDumpPrimaryKeys(current, desired)
+
+	warnings = append(warnings, directiveWarnings(directives.Apply(current, desired))...)
+	warnings = append(warnings, e.addHistoryTables(desired, directives.History)...)
+	warnings = append(warnings, e.applyRawDDL(desired)...)
+	log.Printf("Found tables - Dump: %d, Schema: %d", len(current.Tables), len(desired.Tables))
+
+	changes, err := e.diff.Changes(current, desired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate schema diff: %w", err)
+	}
+	for _, change := range changes {
+		if change.SQL == "" {
+			continue
+		}
+		if change.Destructive {
+			repair.Destructive = append(repair.Destructive, change.SQL)
+		} else {
+			repair.Statements = append(repair.Statements, change.SQL)
+		}
+	}
+	repair.Warnings = append(warnings, e.diff.Warnings()...)
+	return repair, nil
+}
+
+// stripDelimiterBlocks membuang blok DELIMITER ;; ... DELIMITER ; dari dump
+// MySQL, yang berisi routine dan trigger, dan mengembalikan jumlah bloknya
+func stripDelimiterBlocks(sql string) (string, int) {
+	var kept []string
+	blocks, inBlock := 0, false
+	for _, line := range strings.Split(sql, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.EqualFold(fields[0], "DELIMITER") {
+			inBlock = fields[1] != ";"
+			if inBlock {
+				blocks++
+			}
+			continue
+		}
+		if !inBlock {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n"), blocks
+}
+
+// dumpObject mengembalikan nama tabel yang diubah statement CREATE TABLE,
+// CREATE INDEX atau ALTER TABLE; false untuk statement lain
+func dumpObject(stmt, upper string) (string, bool) {
+	tokens := splitTokens(stmt)
+	switch {
+	case strings.HasPrefix(upper, "CREATE TABLE"):
+		open := strings.Index(stmt, "(")
+		if open == -1 {
+			return "", false
+		}
+		header := splitTokens(stmt[:open])
+		return header[len(header)-1], true
+	case isCreateIndex(upper):
+		for i, tok := range tokens {
+			if strings.ToUpper(tok) != "ON" {
+				continue
+			}
+			for i++; i < len(tokens) && strings.ToUpper(tokens[i]) == "ONLY"; i++ {
+			}
+			if i < len(tokens) {
+				return strings.SplitN(tokens[i], "(", 2)[0], true
+			}
+		}
+	case strings.HasPrefix(upper, "ALTER TABLE"):
+		i := 2
+		for i < len(tokens) && (isIndexModifier(tokens[i]) || strings.ToUpper(tokens[i]) == "ONLY") {
+			i++
+		}
+		if i < len(tokens) {
+			return tokens[i], true
+		}
+	}
+	return "", false
+}
+
+// nameQualifier mengembalikan schema dari nama berkualifikasi, mis. "public"
+// dari public.users; kosong jika nama tidak berkualifikasi
+func nameQualifier(name string) string {
+	dot := strings.LastIndex(name, ".")
+	if dot == -1 {
+		return ""
+	}
+	return unquoteIdent(name[:dot])
+}
+
+// statementKind mendeskripsikan jenis statement untuk Ignored, mis.
+// "CREATE FUNCTION" atau "SET"
+func statementKind(upper string) string {
+	if strings.HasPrefix(upper, "/*!") {
+		return "MySQL conditional comments"
+	}
+	words := strings.Fields(upper)
+	if len(words) == 0 {
+		return "empty statements"
+	}
+	// ALTER TABLE yang tidak dimodelkan, mis. OWNER TO atau ENABLE TRIGGER
+	if len(words) > 3 && words[0] == "ALTER" && words[1] == "TABLE" {
+		return "ALTER TABLE ... " + words[3]
+	}
+	kind := []string{words[0]}
+	for _, word := range words[1:] {
+		switch word {
+		case "OR", "REPLACE", "TEMP", "TEMPORARY", "UNLOGGED", "MATERIALIZED", "DEFINER", "ON":
+			kind = append(kind, word)
+			continue
+		}
+		if kind[0] == "CREATE" || kind[0] == "ALTER" || kind[0] == "DROP" || kind[0] == "COMMENT" {
+			kind = append(kind, strings.TrimFunc(word, func(r rune) bool { return r == '(' || r == ';' }))
+		}
+		break
+	}
+	return strings.Join(kind, " ")
+}
+
+// ignoredSet menghitung statement yang diabaikan per jenis, dengan urutan
+// kemunculan pertama
+type ignoredSet struct {
+	kinds  []string
+	counts map[string]int
+}
+
+func newIgnoredSet() *ignoredSet {
+	return &ignoredSet{counts: make(map[string]int)}
+}
+
+func (s *ignoredSet) add(kind string, n int) {
+	if _, ok := s.counts[kind]; !ok {
+		s.kinds = append(s.kinds, kind)
+	}
+	s.counts[kind] += n
+}
+
+func (s *ignoredSet) list() []string {
+	var list []string
+	for _, kind := range s.kinds {
+		noun := "statements"
+		if s.counts[kind] == 1 {
+			noun = "statement"
+		}
+		list = append(list, fmt.Sprintf("%s (%d %s)", kind, s.counts[kind], noun))
+	}
+	return list
+}
+
+// normalizeDumpColumns menyeragamkan cara dump menulis kolom dengan cara
+// schema program: DEFAULT nextval(...) dari pg_dump menjadi serial, dan
+// DEFAULT NULL eksplisit dari mysqldump dibuang
+func normalizeDumpColumns(schema *state.SchemaState) {
+	for name, table := range schema.Tables {
+		for colName, col := range table.Columns {
+			switch {
+			case col.DefaultValue == nil:
+				continue
+			case col.DefaultValue.Kind == state.DefaultNull:
+				col.DefaultValue = nil
+			case strings.HasPrefix(strings.ToLower(col.DefaultValue.SQL()), "nextval("):
+				col.DefaultValue = nil
+				col.AutoIncrement = true
+				col.Identity = state.IdentitySerial
+			}
+			table.Columns[colName] = col
+		}
+		schema.Tables[name] = table
+	}
+}
+
+// dumpTypeAliases menyeragamkan nama tipe yang ditulis katalog database di
+// dump, mis. "character varying" dari pg_dump atau int(11) dari mysqldump
+var dumpTypeAliases = map[string]string{
+	"character varying":           "varchar",
+	"character":                   "char",
+	"integer":                     "int",
+	"int4":                        "int",
+	"int8":                        "bigint",
+	"int2":                        "smallint",
+	"bool":                        "boolean",
+	"float8":                      "double precision",
+	"decimal":                     "numeric",
+	"timestamp without time zone": "timestamp",
+	"timestamp with time zone":    "timestamptz",
+	"time without time zone":      "time",
+}
+
+// displayWidthPattern menangkap display width integer MySQL, mis. int(11)
+var displayWidthPattern = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+
+// canonicalDumpType mengembalikan bentuk tipe untuk membandingkan dump dengan
+// schema program
+func canonicalDumpType(sqlType string) string {
+	t := strings.ToLower(strings.Join(strings.Fields(sqlType), " "))
+	t = strings.ReplaceAll(t, ", ", ",")
+	if t != "tinyint(1)" {
+		t = displayWidthPattern.ReplaceAllString(t, "$1")
+	}
+	base, args := t, ""
+	if open := strings.Index(t, "("); open != -1 {
+		base, args = strings.TrimSpace(t[:open]), t[open:]
+		// timestamp(3) with time zone: presisi di tengah nama tipe
+		if close := strings.Index(args, ")"); close != -1 && close+1 < len(args) {
+			base, args = base+args[close+1:], args[:close+1]
+		}
+	}
+	if alias, ok := dumpTypeAliases[base]; ok {
+		base = alias
+	}
+	return base + args
+}
+
+// alignDumpTypes memakai ejaan tipe schema program untuk kolom dump yang
+// tipenya sama setelah alias dan display width diseragamkan, serta membuang
+// AUTO_INCREMENT tabel (counter, bukan schema) yang tidak ditulis program
+func alignDumpTypes(dump, desired *state.SchemaState) {
+	for name, table := range dump.Tables {
+		model, ok := desired.Tables[name]
+		if !ok {
+			continue
+		}
+		for colName, col := range table.Columns {
+			if want, ok := model.Columns[colName]; ok && col.Type != want.Type &&
+				canonicalDumpType(col.Type) == canonicalDumpType(want.Type) {
+				col.Type = want.Type
+				table.Columns[colName] = col
+			}
+		}
+		if _, ok := model.Options[state.OptionAutoIncrement]; !ok && table.Options != nil {
+			delete(table.Options, state.OptionAutoIncrement)
+		}
+		dump.Tables[name] = table
+	}
+}
+
+// alignDumpPrimaryKeys memakai constraint primary key schema program untuk
+// primary key dump dengan kolom yang sama. Database menamai sendiri primary
+// key tanpa nama (users_pkey di Postgres), sehingga tanpa ini repair selalu
+// men-drop lalu membuat ulang primary key yang sebenarnya sudah sesuai.
+func alignDumpPrimaryKeys(dump, desired *state.SchemaState) {
+	for name, table := range dump.Tables {
+		for _, want := range desired.Tables[name].Constraints {
+			if want.Type != "PRIMARY KEY" {
+				continue
+			}
+			for i, c := range table.Constraints {
+				if c.Type == "PRIMARY KEY" && primaryKeyDefinition(c.Def) == primaryKeyDefinition(want.Def) {
+					table.Constraints[i] = want
+				}
+			}
+		}
+	}
+}
+
+// primaryKeyDefinition menyeragamkan definisi primary key untuk
+// dibandingkan: nama constraint, quote identifier dan spasi dibuang
+func primaryKeyDefinition(def string) string {
+	def = strings.ReplaceAll(normalizeHashSQL(def), " ", "")
+	if i := strings.Index(def, "primarykey"); i != -1 {
+		def = def[i:]
+	}
+	return def
+}
+
+// sortedTableNames mengembalikan nama tabel schema secara berurutan
+func sortedTableNames(schema *state.SchemaState) []string {
+	names := make([]string, 0, len(schema.Tables))
+	for name := range schema.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// containsFold mengecek apakah values berisi value tanpa membedakan huruf besar
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateStatement memendekkan statement untuk pesan error
+func truncateStatement(stmt string) string {
+	if len(stmt) > 80 {
+		return stmt[:77] + "..."
+	}
+	return stmt
+}
@@ -0,0 +1,115 @@
+package schema
+
+import "strings"
+
+// SanitizeOptions mengatur SanitizeOutput
+type SanitizeOptions struct {
+	// KeepQuotedEscapes mempertahankan escape sequence di dalam string
+	// literal, identifier ber-quote dan body $$; hanya escape di luar quote
+	// yang dibuang. Dipakai jika SQL memang berisi karakter ESC, mis. default
+	// kolom berisi kode warna terminal.
+	KeepQuotedEscapes bool
+}
+
+// byteOrderMark adalah BOM UTF-8 yang ditulis sebagian tool Windows
+const byteOrderMark = "\ufeff"
+
+// SanitizeOutput membersihkan artefak terminal dari output schema program
+// sebelum di-parse: BOM, escape sequence ANSI (mis. warna dari logger
+// program), line ending CRLF/CR dan karakter % di akhir output (penanda
+// baris tanpa newline dari zsh). Semua jalur yang membaca output program
+// memakai fungsi ini agar hasilnya sama.
+func SanitizeOutput(output string, opts SanitizeOptions) string {
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	output = strings.ReplaceAll(output, "\r", "\n")
+	output = stripEscapes(output, opts.KeepQuotedEscapes)
+
+	// BOM bisa muncul di awal setiap baris jika output berasal dari beberapa
+	// file yang digabung
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, byteOrderMark)
+	}
+	output = strings.Join(lines, "\n")
+
+	return strings.TrimRight(strings.TrimSpace(output), "% \t\n")
+}
+
+// stripEscapes membuang escape sequence ANSI: CSI (ESC [ ... final), OSC
+// (ESC ] ... BEL atau ESC \) dan escape dua karakter lainnya. Jika
+// keepQuoted, escape di dalam quote dan body $$ dibiarkan; quote di dalam
+// komentar -- tidak dihitung.
+func stripEscapes(s string, keepQuoted bool) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	var quote byte
+	inDollar, inComment := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\x1b' && (!keepQuoted || (quote == 0 && !inDollar)) {
+			i += escapeLength(s[i:]) - 1
+			continue
+		}
+		switch {
+		case inComment:
+			inComment = c != '\n'
+		case c == '$' && quote == 0 && i+1 < len(s) && s[i+1] == '$':
+			inDollar = !inDollar
+			b.WriteByte(c)
+			i++
+		case inDollar:
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && i+1 < len(s) && s[i+1] == '-':
+			inComment = true
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// escapeLength mengembalikan panjang escape sequence di awal s (s[0] adalah
+// ESC). Sequence yang terpotong di akhir output dibuang seluruhnya.
+func escapeLength(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case '[':
+		// Parameter dan intermediate byte, lalu satu final byte 0x40-0x7e
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+			if s[i] < 0x20 || s[i] > 0x3f {
+				return i
+			}
+		}
+		return len(s)
+	case ']':
+		// OSC (mis. judul terminal atau hyperlink) diakhiri BEL atau ESC \
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	case '(', ')':
+		// Pemilihan charset, mis. ESC ( B dari tput sgr0
+		if len(s) < 3 {
+			return len(s)
+		}
+		return 3
+	}
+	return 2
+}
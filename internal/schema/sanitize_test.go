@@ -0,0 +1,153 @@
+package schema
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// cleanUsers adalah output schema program yang bersih; semua kasus kotor di
+// bawah harus menghasilkan SQL yang sama
+const cleanUsers = "CREATE TABLE users (\n  id INT NOT NULL,\n  PRIMARY KEY (id)\n);"
+
+func TestSanitizeOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		opts   SanitizeOptions
+		want   string
+	}{
+		{
+			name:   "clean output is unchanged",
+			output: cleanUsers + "\n",
+			want:   cleanUsers,
+		},
+		{
+			name:   "zsh prompt marker after output without newline",
+			output: cleanUsers + "%",
+			want:   cleanUsers,
+		},
+		{
+			name:   "zsh prompt marker with trailing spaces",
+			output: cleanUsers + "\n%   \n",
+			want:   cleanUsers,
+		},
+		{
+			name:   "BOM from a Windows editor",
+			output: "\ufeff" + cleanUsers,
+			want:   cleanUsers,
+		},
+		{
+			name:   "BOM at the start of every concatenated file",
+			output: "\ufeffCREATE TABLE a (id INT);\n\ufeffCREATE TABLE b (id INT);",
+			want:   "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);",
+		},
+		{
+			name:   "CRLF line endings",
+			output: "CREATE TABLE users (\r\n  id INT NOT NULL,\r\n  PRIMARY KEY (id)\r\n);\r\n",
+			want:   cleanUsers,
+		},
+		{
+			name:   "bare CR line endings",
+			output: "CREATE TABLE users (\r  id INT NOT NULL,\r  PRIMARY KEY (id)\r);\r",
+			want:   cleanUsers,
+		},
+		{
+			name:   "colored logger line",
+			output: "\x1b[36mINFO\x1b[0m[0000] \x1b[1mloading models\x1b[22m\n" + cleanUsers,
+			want:   "INFO[0000] loading models\n" + cleanUsers,
+		},
+		{
+			name:   "256 and true color sequences",
+			output: "\x1b[38;5;208mCREATE\x1b[0m TABLE \x1b[38;2;255;0;0musers\x1b[m (id INT);",
+			want:   "CREATE TABLE users (id INT);",
+		},
+		{
+			name:   "OSC window title ended by BEL",
+			output: "\x1b]0;go run ./schema\a" + cleanUsers,
+			want:   cleanUsers,
+		},
+		{
+			name:   "OSC hyperlink ended by ST",
+			output: "\x1b]8;;https://example.com\x1b\\users\x1b]8;;\x1b\\",
+			want:   "users",
+		},
+		{
+			name:   "tput sgr0 charset reset",
+			output: cleanUsers + "\x1b(B\x1b[m",
+			want:   cleanUsers,
+		},
+		{
+			name:   "cursor movement from a progress bar",
+			output: "\x1b[2K\x1b[1G" + cleanUsers,
+			want:   cleanUsers,
+		},
+		{
+			name:   "escape cut off at the end",
+			output: cleanUsers + "\x1b[3",
+			want:   cleanUsers,
+		},
+		{
+			name:   "everything at once",
+			output: "\ufeff\x1b[32mCREATE TABLE users (\x1b[0m\r\n  id INT NOT NULL,\r\n  PRIMARY KEY (id)\r\n);\x1b(B\x1b[m%",
+			want:   cleanUsers,
+		},
+		{
+			name:   "escapes inside a string literal are stripped by default",
+			output: "CREATE TABLE t (c VARCHAR(20) DEFAULT '\x1b[31mred\x1b[0m');",
+			want:   "CREATE TABLE t (c VARCHAR(20) DEFAULT 'red');",
+		},
+		{
+			name:   "escapes inside a string literal are kept with KeepQuotedEscapes",
+			output: "\x1b[1mCREATE TABLE t (c VARCHAR(20) DEFAULT '\x1b[31mred\x1b[0m');\x1b[0m",
+			opts:   SanitizeOptions{KeepQuotedEscapes: true},
+			want:   "CREATE TABLE t (c VARCHAR(20) DEFAULT '\x1b[31mred\x1b[0m');",
+		},
+		{
+			name:   "escapes inside quoted identifiers are kept with KeepQuotedEscapes",
+			output: "CREATE TABLE \"t\x1b[0m\" (`c\x1b[0m` INT);\x1b[0m",
+			opts:   SanitizeOptions{KeepQuotedEscapes: true},
+			want:   "CREATE TABLE \"t\x1b[0m\" (`c\x1b[0m` INT);",
+		},
+		{
+			name:   "escapes inside dollar-quoted bodies are kept with KeepQuotedEscapes",
+			output: "DO $$ BEGIN RAISE NOTICE '\x1b[0m'; END $$;\x1b[0m",
+			opts:   SanitizeOptions{KeepQuotedEscapes: true},
+			want:   "DO $$ BEGIN RAISE NOTICE '\x1b[0m'; END $$;",
+		},
+		{
+			name:   "quotes in comments do not open a string",
+			output: "-- don't\n\x1b[0mCREATE TABLE t (id INT);",
+			opts:   SanitizeOptions{KeepQuotedEscapes: true},
+			want:   "-- don't\nCREATE TABLE t (id INT);",
+		},
+		{
+			name:   "percent inside SQL is kept",
+			output: "CREATE TABLE t (c VARCHAR(20) DEFAULT '100%');",
+			want:   "CREATE TABLE t (c VARCHAR(20) DEFAULT '100%');",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeOutput(tt.output, tt.opts); got != tt.want {
+				t.Errorf("SanitizeOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutorSanitizesProgramOutput(t *testing.T) {
+	clean := scriptExecutor(t, "printf '%s\\n' 'CREATE TABLE users (' '  id INT NOT NULL,' '  PRIMARY KEY (id)' ');'\n")
+	want, err := clean.PlanContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirty := scriptExecutor(t, `printf '\357\273\277\033[32mCREATE TABLE users (\033[0m\r\n  id INT NOT NULL,\r\n  PRIMARY KEY (id)\r\n);\033(B\033[m%%'`+"\n")
+	got, err := dirty.PlanContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Up, want.Up) || !reflect.DeepEqual(got.Desired, want.Desired) {
+		t.Errorf("dirty output planned\n%v\nwant\n%v", got.Up, want.Up)
+	}
+}
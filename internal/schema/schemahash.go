@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// SchemaHash menghitung hash schema dari bentuk terstrukturnya, bukan dari
+// teks SQL: dokumen JSON kanonik (key map terurut) dengan tipe dan definisi
+// constraint dinormalisasi, constraint dan foreign key terurut, dan posisi
+// tabel dibuang. Urutan statement, spasi dan huruf besar-kecil output schema
+// program tidak mengubah hash, sedangkan urutan kolom tetap ikut. extra
+// berisi masukan lain yang bukan bagian schema, mis. directive dan raw_sql.
+func SchemaHash(s *state.SchemaState, extra ...string) string {
+	canonical := state.NewSchemaState()
+	for name, table := range s.Tables {
+		canonical.Tables[name] = canonicalTable(table)
+	}
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		// SchemaState selalu bisa di-encode; hash teks tetap deterministik
+		data = []byte(err.Error())
+	}
+	return calculateHash(string(data) + "\n" + strings.Join(extra, "\n"))
+}
+
+// canonicalTable mengembalikan salinan table dalam bentuk kanonik untuk SchemaHash
+func canonicalTable(table state.Table) state.Table {
+	table = table.Clone()
+	table.Position = 0
+	for name, col := range table.Columns {
+		col.Type = canonicalSQL(col.Type)
+		// group hanya mengatur tampilan CREATE TABLE dan backfill hanya
+		// dipakai saat kolom ditambahkan
+		_, grouped := col.Tags["group"]
+		_, backfilled := col.Tags["backfill"]
+		if grouped || backfilled {
+			tags := make(map[string]string, len(col.Tags))
+			for key, value := range col.Tags {
+				if key != "group" && key != "backfill" {
+					tags[key] = value
+				}
+			}
+			col.Tags = tags
+		}
+		table.Columns[name] = col
+	}
+
+	for i, c := range table.Constraints {
+		c.Type = strings.ToUpper(c.Type)
+		c.Def = canonicalSQL(c.Def)
+		table.Constraints[i] = c
+	}
+	sort.Slice(table.Constraints, func(i, j int) bool {
+		a, b := table.Constraints[i], table.Constraints[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Def != b.Def {
+			return a.Def < b.Def
+		}
+		return a.Name < b.Name
+	})
+
+	table.ForeignKeys = append([]state.ForeignKey(nil), table.ForeignKeys...)
+	sort.Slice(table.ForeignKeys, func(i, j int) bool {
+		a, b := table.ForeignKeys[i], table.ForeignKeys[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return strings.Join(a.Columns, ",") < strings.Join(b.Columns, ",")
+	})
+	return table
+}
+
+// canonicalSQL merapikan potongan SQL untuk SchemaHash: spasi diringkas,
+// quote identifier (" dan `) dibuang dan huruf dikecilkan, kecuali di dalam
+// string literal agar 'Active' dan 'active' tetap berbeda
+func canonicalSQL(sql string) string {
+	var b strings.Builder
+	inString, space := false, false
+	for _, r := range strings.TrimSpace(sql) {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case inString:
+		case r == '"' || r == '`':
+			continue
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			space = true
+			continue
+		default:
+			r = unicode.ToLower(r)
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
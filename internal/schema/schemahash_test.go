@@ -0,0 +1,101 @@
+package schema
+
+import "testing"
+
+func TestSchemaHash(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"gorm output reformatted", gormPostgres, gormPostgresFormatted, true},
+		{
+			"statement order",
+			"CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\nCREATE INDEX idx_b ON b (id);",
+			"CREATE TABLE b (id INT);\nCREATE INDEX idx_b ON b (id);\nCREATE TABLE a (id INT);",
+			true,
+		},
+		{
+			"constraint order",
+			"CREATE TABLE a (id INT, x INT, UNIQUE (x), CHECK (id > 0), PRIMARY KEY (id));",
+			"CREATE TABLE a (id INT, x INT, PRIMARY KEY (id), CHECK (id > 0), UNIQUE (x));",
+			true,
+		},
+		{
+			"spacing inside clauses",
+			"CREATE TABLE a (id INT, x INT, PRIMARY KEY (id, x));",
+			"CREATE TABLE a (id INT, x INT, PRIMARY KEY(id,x));",
+			true,
+		},
+		{
+			"case and quoting",
+			`CREATE TABLE a (id INT, b_id INT, CONSTRAINT fk_a_b FOREIGN KEY (b_id) REFERENCES b (id) ON DELETE CASCADE);`,
+			"create table `a` (`id` int, `b_id` int, constraint `fk_a_b` foreign key (`b_id`) references `b`(`id`) on delete cascade);",
+			true,
+		},
+		{
+			"default function case",
+			"CREATE TABLE a (t TIMESTAMP DEFAULT now());",
+			"CREATE TABLE a (t timestamp DEFAULT NOW());",
+			true,
+		},
+		{
+			"column group comments",
+			"CREATE TABLE a (\n  id INT,\n  plan VARCHAR(20),\n  email VARCHAR(100)\n);",
+			"CREATE TABLE a (\n  id INT,\n  -- Billing\n  plan VARCHAR(20),\n  -- Contact\n  email VARCHAR(100)\n);",
+			true,
+		},
+		{
+			"string literal case",
+			"CREATE TABLE a (s VARCHAR(10) DEFAULT 'x');",
+			"CREATE TABLE a (s VARCHAR(10) DEFAULT 'X');",
+			false,
+		},
+		{
+			"column order",
+			"CREATE TABLE a (id INT, x INT);",
+			"CREATE TABLE a (x INT, id INT);",
+			false,
+		},
+		{
+			"index column order",
+			"CREATE TABLE a (id INT, x INT);\nCREATE INDEX idx_a ON a (id, x);",
+			"CREATE TABLE a (id INT, x INT);\nCREATE INDEX idx_a ON a (x, id);",
+			false,
+		},
+		{
+			"type",
+			"CREATE TABLE a (id INT);",
+			"CREATE TABLE a (id BIGINT);",
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := ParseSQL(tt.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ParseSQL(tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if same := SchemaHash(a) == SchemaHash(b); same != tt.same {
+				t.Errorf("SchemaHash() equal = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
+
+func TestSchemaHashExtra(t *testing.T) {
+	s, err := ParseSQL("CREATE TABLE a (id INT);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if SchemaHash(s, "-- datara:online") == SchemaHash(s) {
+		t.Error("SchemaHash() ignores directives passed as extra")
+	}
+	if SchemaHash(s, "x") != SchemaHash(s, "x") {
+		t.Error("SchemaHash() is not deterministic")
+	}
+}
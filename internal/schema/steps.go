@@ -0,0 +1,40 @@
+package schema
+
+import "github.com/akmalulginan/datara/internal/state"
+
+// MigrationStep adalah satu file migration dari plan. Plan biasanya hanya
+// menghasilkan satu langkah; langkah berikutnya hanya dibuat jika plan memang
+// harus dipecah, mis. VALIDATE CONSTRAINT dari SetTwoPhase.
+type MigrationStep struct {
+	// Name ditambahkan ke nama file, mis. "validate_constraints"; kosong
+	// untuk langkah pertama
+	Name string
+	// Reason menjelaskan kenapa langkah dipisah dari langkah sebelumnya.
+	// Setiap langkah setelah yang pertama wajib mengisinya.
+	Reason string
+	// SQL adalah isi file migration
+	SQL string
+	// From dan To adalah snapshot sebelum dan sesudah langkah, untuk
+	// datara.snapshots
+	From, To *state.SchemaState
+}
+
+// Steps mengembalikan file migration yang ditulis dari plan secara
+// berurutan. migration adalah hasil Apply untuk langkah pertama.
+func (e *Executor) Steps(plan *Plan, migration string) ([]MigrationStep, error) {
+	steps := []MigrationStep{{SQL: migration, From: plan.Current, To: plan.Desired}}
+	if len(plan.Validate) > 0 {
+		sql, err := e.ValidateMigration(plan)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, MigrationStep{
+			Name:   "validate_constraints",
+			Reason: "-two-phase: VALIDATE CONSTRAINT checks existing rows and can run separately, e.g. off-peak",
+			SQL:    sql,
+			From:   plan.Desired,
+			To:     plan.Desired,
+		})
+	}
+	return steps, nil
+}
@@ -0,0 +1,319 @@
+package schema
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SumFile adalah nama file checksum di direktori migration
+const SumFile = "datara.sum"
+
+// ErrOrphanedSum dikembalikan dalam mode strict jika datara.sum berisi file
+// migration yang sudah tidak ada
+var ErrOrphanedSum = errors.New("datara.sum references deleted migration files")
+
+// Sum berisi checksum setiap file migration. Baris pertama datara.sum adalah
+// hash global dari seluruh entry, diikuti satu baris "nama hash" per file.
+type Sum struct {
+	Global string
+	Files  map[string]string
+}
+
+// ReadSum membaca datara.sum dari dir. Sum kosong dikembalikan jika file belum ada.
+func ReadSum(dir string) (*Sum, error) {
+	sum := &Sum{Files: make(map[string]string)}
+
+	f, err := os.Open(filepath.Join(dir, SumFile))
+	if os.IsNotExist(err) {
+		return sum, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", SumFile, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for first := true; scanner.Scan(); first = false {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if first {
+			sum.Global = line
+			continue
+		}
+		name, hash, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid %s entry: %q", SumFile, line)
+		}
+		sum.Files[name] = strings.TrimSpace(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", SumFile, err)
+	}
+	return sum, nil
+}
+
+// Hash menghitung hash global dari entry yang tercatat
+func (s *Sum) Hash() string {
+	var b strings.Builder
+	for _, name := range s.names() {
+		fmt.Fprintf(&b, "%s %s\n", name, s.Files[name])
+	}
+	return calculateHash(b.String())
+}
+
+// Write menulis datara.sum ke dir dengan hash global yang dihitung ulang
+func (s *Sum) Write(dir string) error {
+	s.Global = s.Hash()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", s.Global)
+	for _, name := range s.names() {
+		fmt.Fprintf(&b, "%s %s\n", name, s.Files[name])
+	}
+	if err := os.WriteFile(filepath.Join(dir, SumFile), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", SumFile, err)
+	}
+	return nil
+}
+
+func (s *Sum) names() []string {
+	names := make([]string, 0, len(s.Files))
+	for name := range s.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// VerifySum membandingkan datara.sum dengan file migration di dir.
+// Checksum yang tidak cocok dikembalikan sebagai *ChecksumMismatchError.
+func VerifySum(dir string, pattern *regexp.Regexp) error {
+	sum, err := ReadSum(dir)
+	if err != nil {
+		return err
+	}
+	if got := sum.Hash(); sum.Global != got {
+		return &ChecksumMismatchError{File: SumFile, Want: sum.Global, Got: got}
+	}
+
+	files, err := migrationFiles(dir, pattern)
+	if err != nil {
+		return err
+	}
+	onDisk := make(map[string]bool, len(files))
+	for _, name := range files {
+		onDisk[name] = true
+		want, ok := sum.Files[name]
+		if !ok {
+			return fmt.Errorf("migration %s is missing from %s, run 'datara hash -prune'", name, SumFile)
+		}
+		got, err := fileChecksum(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if want != got {
+			return &ChecksumMismatchError{File: name, Want: want, Got: got}
+		}
+	}
+	for _, name := range sum.names() {
+		if !onDisk[name] {
+			return fmt.Errorf("%s references deleted migration %s, run 'datara hash -prune'", SumFile, name)
+		}
+	}
+	return nil
+}
+
+// UpdateSum menyinkronkan datara.sum dengan file migration di dir: entry untuk
+// file yang sudah dihapus dibuang, file baru ditambahkan dan hash global
+// dihitung ulang. Checksum file yang sudah tercatat tidak diubah. Dengan strict,
+// ErrOrphanedSum dikembalikan tanpa menulis apa pun jika ada entry yatim.
+func UpdateSum(dir string, pattern *regexp.Regexp, strict bool) (pruned, added []string, err error) {
+	sum, err := ReadSum(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files, err := migrationFiles(dir, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	onDisk := make(map[string]bool, len(files))
+	for _, name := range files {
+		onDisk[name] = true
+	}
+
+	for _, name := range sum.names() {
+		if !onDisk[name] {
+			pruned = append(pruned, name)
+		}
+	}
+	if strict && len(pruned) > 0 {
+		return pruned, nil, fmt.Errorf("%w: %s", ErrOrphanedSum, strings.Join(pruned, ", "))
+	}
+	for _, name := range pruned {
+		delete(sum.Files, name)
+	}
+
+	for _, name := range files {
+		if _, ok := sum.Files[name]; ok {
+			continue
+		}
+		hash, err := fileChecksum(filepath.Join(dir, name))
+		if err != nil {
+			return nil, nil, err
+		}
+		sum.Files[name] = hash
+		added = append(added, name)
+	}
+
+	if err := sum.Write(dir); err != nil {
+		return nil, nil, err
+	}
+	return pruned, added, nil
+}
+
+// RecordSum mencatat semua file *.sql di dir ke datara.sum, termasuk yang
+// tidak cocok dengan pola migration, mis. migration runner lain yang diimpor.
+// Setelah tercatat, file tersebut diperlakukan sebagai migration.
+func RecordSum(dir string) error {
+	sum, err := ReadSum(dir)
+	if err != nil {
+		return err
+	}
+	files, err := sqlFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		if _, ok := sum.Files[name]; ok {
+			continue
+		}
+		hash, err := fileChecksum(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		sum.Files[name] = hash
+	}
+	return sum.Write(dir)
+}
+
+// RehashSum mencatat ulang checksum file migration yang sengaja diubah
+// (mis. oleh FixConflicts), di datara.sum atau di trailer file tersebut.
+// datara.sum tidak dibuat jika belum ada.
+func RehashSum(dir string, names ...string) error {
+	// Mode embedded: checksum ada di trailer file itu sendiri
+	for _, name := range names {
+		if err := resealSum(dir, name); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, SumFile)); os.IsNotExist(err) {
+		return nil
+	}
+
+	sum, err := ReadSum(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		hash, err := fileChecksum(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		sum.Files[name] = hash
+	}
+	return sum.Write(dir)
+}
+
+// DefaultMigrationPattern adalah pola nama file migration default: versi
+// 14 digit dari migration.timestamp_format bawaan
+const DefaultMigrationPattern = `^\d{14}.*\.sql$`
+
+// defaultMigrationPattern dipakai jika pola nama file migration nil
+var defaultMigrationPattern = regexp.MustCompile(DefaultMigrationPattern)
+
+// isMigrationFile mengecek apakah nama file cocok dengan pattern, pola nama
+// file migration (migration.file_pattern); nil berarti DefaultMigrationPattern.
+// File lain (mis. scratch.sql) tidak dijalankan ulang dan tidak ikut checksum
+// kecuali sudah tercatat di datara.sum.
+func isMigrationFile(pattern *regexp.Regexp, name string) bool {
+	if pattern == nil {
+		pattern = defaultMigrationPattern
+	}
+	return pattern.MatchString(name)
+}
+
+// migrationFiles mengembalikan nama file migration di dir secara berurutan:
+// file *.sql yang cocok dengan pattern atau sudah tercatat di datara.sum
+func migrationFiles(dir string, pattern *regexp.Regexp) ([]string, error) {
+	files, _, err := splitMigrationFiles(dir, pattern)
+	return files, err
+}
+
+// IgnoredFiles mengembalikan file *.sql di dir yang bukan migration karena
+// tidak cocok dengan pattern dan belum tercatat di datara.sum
+func IgnoredFiles(dir string, pattern *regexp.Regexp) ([]string, error) {
+	_, ignored, err := splitMigrationFiles(dir, pattern)
+	return ignored, err
+}
+
+// splitMigrationFiles memisahkan file *.sql di dir menjadi migration dan
+// file yang diabaikan
+func splitMigrationFiles(dir string, pattern *regexp.Regexp) (files, ignored []string, err error) {
+	all, err := sqlFiles(dir)
+	if err != nil || len(all) == 0 {
+		return nil, nil, err
+	}
+	sum, err := ReadSum(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range all {
+		if _, recorded := sum.Files[name]; recorded || isMigrationFile(pattern, name) {
+			files = append(files, name)
+		} else {
+			ignored = append(ignored, name)
+		}
+	}
+	return files, ignored, nil
+}
+
+// sqlFiles mengembalikan nama semua file *.sql di dir secara berurutan.
+// Snapshot SQL lama (schema.sql) bukan migration dan dilewati.
+func sqlFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".sql" || name == filepath.Base(legacySchemaFile) {
+			continue
+		}
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// fileChecksum menghitung checksum isi file
+func fileChecksum(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return calculateHash(string(data)), nil
+}
@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestMigrationFilesPattern(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"20300101000000_init.sql", "202301011200_add_users.sql", "scratch.sql"} {
+		writeTestFile(t, dir, name, "-- migrate:up\n")
+	}
+
+	tests := []struct {
+		name        string
+		pattern     *regexp.Regexp
+		wantFiles   []string
+		wantIgnored []string
+	}{
+		{
+			name:        "default pattern",
+			wantFiles:   []string{"20300101000000_init.sql"},
+			wantIgnored: []string{"202301011200_add_users.sql", "scratch.sql"},
+		},
+		{
+			name:        "minute versions",
+			pattern:     regexp.MustCompile(`^\d{12}_.*\.sql$`),
+			wantFiles:   []string{"202301011200_add_users.sql"},
+			wantIgnored: []string{"20300101000000_init.sql", "scratch.sql"},
+		},
+		{
+			name:        "default pattern again",
+			wantFiles:   []string{"20300101000000_init.sql"},
+			wantIgnored: []string{"202301011200_add_users.sql", "scratch.sql"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := migrationFiles(dir, tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("migrationFiles() = %v, want %v", files, tt.wantFiles)
+			}
+			ignored, err := IgnoredFiles(dir, tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ignored, tt.wantIgnored) {
+				t.Errorf("IgnoredFiles() = %v, want %v", ignored, tt.wantIgnored)
+			}
+		})
+	}
+}
+
+func TestMigrationFilesRecordedInSum(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "20300101000000.sql", "-- migrate:up\n")
+	writeTestFile(t, dir, "legacy.sql", "-- migrate:up\n")
+	writeTestFile(t, dir, SumFile, "h1:global\n20300101000000.sql h1:a\nlegacy.sql h1:b\n")
+
+	files, err := migrationFiles(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"20300101000000.sql", "legacy.sql"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("migrationFiles() = %v, want %v: files recorded in %s are migrations", files, want, SumFile)
+	}
+}
@@ -0,0 +1,83 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/diff"
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// ErrTableSelection dikembalikan jika pattern SetTables tidak cocok dengan
+// tabel mana pun, atau tabel yang dipilih punya foreign key ke tabel baru atau
+// yang di-drop di luar pilihan
+var ErrTableSelection = errors.New("invalid -table selection")
+
+// SetTables membatasi plan pada tabel yang cocok dengan salah satu pattern
+// (glob seperti path.Match, mis. "order_*"). Perubahan tabel lain tidak ikut
+// migration dan tidak disimpan ke snapshot, sehingga tetap pending untuk
+// generate berikutnya.
+func (e *Executor) SetTables(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid table pattern %q: %w", pattern, err)
+		}
+	}
+	e.tables = patterns
+	return nil
+}
+
+// selectTables menggabungkan snapshot current dengan tabel desired yang
+// dipilih SetTables. Tabel yang tidak dipilih tetap seperti di current. Foreign
+// key antara tabel yang dipilih dan tabel baru atau yang di-drop di luar
+// pilihan ditolak, karena migration-nya tidak akan bisa dijalankan.
+func (e *Executor) selectTables(current, desired *state.SchemaState) (*state.SchemaState, error) {
+	selected := make(map[string]bool)
+	for _, pattern := range e.tables {
+		matched := false
+		for _, tables := range []map[string]state.Table{current.Tables, desired.Tables} {
+			for name := range tables {
+				if ok, _ := path.Match(pattern, name); ok {
+					selected[name] = true
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("%w: %s matches no table in the schema or snapshot", ErrTableSelection, pattern)
+		}
+	}
+
+	merged := current.Clone()
+	for name := range selected {
+		if table, ok := desired.Tables[name]; ok {
+			merged.Tables[name] = table
+		} else {
+			delete(merged.Tables, name)
+		}
+	}
+
+	// Tabel yang dipilih tidak boleh mereferensikan tabel baru di luar pilihan,
+	// dan tabel di luar pilihan tidak boleh mereferensikan tabel yang di-drop
+	var missing []string
+	for _, name := range sortedNames(merged.Tables) {
+		for _, ref := range diff.ReferencedTables(merged.Tables[name]) {
+			if _, ok := merged.Tables[ref]; ok {
+				continue
+			}
+			if selected[name] {
+				missing = append(missing, fmt.Sprintf("%s references %s, which is not selected; add -table %s", name, ref, ref))
+			} else if selected[ref] {
+				missing = append(missing, fmt.Sprintf("%s references %s, which is dropped; add -table %s", name, ref, name))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%w: selected tables depend on tables outside -table:\n  %s", ErrTableSelection, strings.Join(missing, "\n  "))
+	}
+	return merged, nil
+}
@@ -0,0 +1,61 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitTag memecah isi struct tag pada koma di luar quote tunggal dan kurung,
+// sehingga default='Hello, World' dan type=decimal(10,2) tetap satu opsi.
+// Quote di dalam nilai ditulis ganda seperti di SQL atau di-escape dengan
+// backslash; keduanya dikembalikan dalam bentuk SQL (quote ganda).
+func splitTag(tag string) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+	depth, inQuote := 0, false
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		switch {
+		case inQuote && c == '\\' && i+1 < len(tag) && tag[i+1] == '\'':
+			current.WriteString("''")
+			i++
+			continue
+		case c == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				return nil, fmt.Errorf("unbalanced ')' at offset %d in %q", i, tag)
+			}
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote in %q", tag)
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("unclosed '(' in %q", tag)
+	}
+	return append(parts, current.String()), nil
+}
+
+// unquoteTagValue menghapus quote tunggal yang mengapit seluruh nilai tag,
+// mis. 'a, b' menjadi a, b. Nilai lain dikembalikan apa adanya.
+func unquoteTagValue(value string) string {
+	if len(value) < 2 || value[0] != '\'' || value[len(value)-1] != '\'' {
+		return value
+	}
+	inner := value[1 : len(value)-1]
+	if strings.Contains(strings.ReplaceAll(inner, "''", ""), "'") {
+		// Mis. 'a'||'b': bukan satu literal
+		return value
+	}
+	return strings.ReplaceAll(inner, "''", "'")
+}
@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    map[string]string
+		wantErr string
+	}{
+		{tag: "primary_key,unique", want: map[string]string{"primary_key": "", "unique": ""}},
+		{tag: "type=decimal(10,2),not_null", want: map[string]string{"type": "decimal(10,2)", "not_null": ""}},
+		{tag: "type=enum('a','b,c'),default='a'", want: map[string]string{"type": "enum('a','b,c')", "default": "'a'"}},
+		{tag: "type=varchar(255),default='Hello, World'", want: map[string]string{"type": "varchar(255)", "default": "'Hello, World'"}},
+		{tag: "default='it''s',comment='a, b'", want: map[string]string{"default": "'it''s'", "comment": "a, b"}},
+		{tag: `default='it\'s',comment='it\'s (here)'`, want: map[string]string{"default": "'it''s'", "comment": "it's (here)"}},
+		{tag: "default=(now() + interval '1 day'),index", want: map[string]string{"default": "(now() + interval '1 day')", "index": ""}},
+		{tag: "comment='a'||'b'", want: map[string]string{"comment": "'a'||'b'"}},
+		{tag: " size = 100 , ", want: map[string]string{"size": "100"}},
+		{tag: "default='Hello, World", wantErr: "unterminated quote"},
+		{tag: "type=decimal(10,2", wantErr: "unclosed '('"},
+		{tag: "type=decimal10,2)", wantErr: "unbalanced ')'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := parseTags(tt.tag)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseTags() = %v, %v; want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRelTag(t *testing.T) {
+	rel, err := parseRelTag("'users',id,ondelete='set null'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.refTable != "users" || rel.refColumn != "id" || rel.onDelete != "SET NULL" || rel.onUpdate != defaultRelAction {
+		t.Errorf("parseRelTag() = %+v, want users.id ON DELETE SET NULL", rel)
+	}
+	if _, err := parseRelTag("users,'id"); err == nil {
+		t.Error("parseRelTag() accepted an unterminated quote")
+	}
+}
+
+// TestTagErrorNamesField memastikan error tag menyebut model dan field, dan
+// default berkoma sampai ke SQL sebagai satu literal
+func TestTagErrorNamesField(t *testing.T) {
+	_, err := NewGenerator(nil).GenerateSchema(testModel("User", map[string][2]string{
+		"Id":       {"int64", "primary_key"},
+		"Greeting": {"string", "default='Hello, World"},
+	}))
+	if err == nil || !strings.Contains(err.Error(), "model User field Greeting: db tag: unterminated quote") {
+		t.Errorf("GenerateSchema() = %v, want an unterminated quote error for User.Greeting", err)
+	}
+
+	desired, err := NewGenerator(nil).GenerateSchema(testModel("User", map[string][2]string{
+		"Id":       {"int64", "primary_key"},
+		"Greeting": {"string", "type=varchar(255),default='Hello, World'"},
+		"Price":    {"float64", "type=decimal(10,2)"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := desired.Tables["users"].Columns
+	if got := columns["greeting"].DefaultValue.SQL(); got != "'Hello, World'" {
+		t.Errorf("greeting default = %s, want 'Hello, World'", got)
+	}
+	if got := columns["price"].Type; !strings.EqualFold(got, "decimal(10,2)") {
+		t.Errorf("price type = %s, want decimal(10,2)", got)
+	}
+	if len(columns) != 3 {
+		t.Errorf("columns = %v, want id, greeting and price only", columns)
+	}
+}
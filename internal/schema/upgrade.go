@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// legacyFormat adalah nama format snapshot SQL dari versi lama (schema.sql)
+const legacyFormat = "sql"
+
+// stateUpgrade memindahkan state di direktori migration dari satu format ke
+// format berikutnya. Upgrade dijalankan berurutan, sehingga state versi N-1
+// selalu bisa dibaca dan ditulis ulang sebagai versi N.
+type stateUpgrade struct {
+	from, to string
+	// pending mengecek apakah state masih memakai format from
+	pending func(e *Executor) bool
+	apply   func(e *Executor) error
+}
+
+// stateUpgrades adalah semua upgrade format state, dari yang paling lama
+var stateUpgrades = []stateUpgrade{
+	{from: legacyFormat, to: "1.0", pending: hasLegacySnapshot, apply: upgradeLegacySnapshot},
+}
+
+// MigrateState menulis ulang state ke format terbaru dan mengembalikan
+// deskripsi setiap upgrade yang dijalankan. Command lain membaca format lama
+// tanpa menulisnya; file baru ditulis saat snapshot disimpan berikutnya.
+func (e *Executor) MigrateState() ([]string, error) {
+	var applied []string
+	for _, upgrade := range stateUpgrades {
+		if !upgrade.pending(e) {
+			continue
+		}
+		if err := upgrade.apply(e); err != nil {
+			return applied, fmt.Errorf("failed to upgrade state from format %s to %s: %w", upgrade.from, upgrade.to, err)
+		}
+		applied = append(applied, fmt.Sprintf("upgraded state from format %s to %s", upgrade.from, upgrade.to))
+	}
+
+	// Snapshot yang sudah ada tetap harus bisa dibaca binary ini
+	if _, err := state.LoadFromFile(e.path(snapshotFile)); err != nil {
+		return applied, err
+	}
+	return applied, nil
+}
+
+// pendingUpgradeNotice mencatat bahwa state masih memakai format lama
+func (e *Executor) pendingUpgradeNotice() {
+	for _, upgrade := range stateUpgrades {
+		if upgrade.pending(e) {
+			log.Printf("Notice: state uses format %s and is upgraded to %s when the snapshot is next saved; run 'datara migrate-state' to upgrade it now",
+				upgrade.from, upgrade.to)
+		}
+	}
+}
+
+// hasLegacySnapshot mengecek apakah direktori migration masih berisi schema.sql
+func hasLegacySnapshot(e *Executor) bool {
+	_, err := os.Stat(e.path(legacySchemaFile))
+	return err == nil
+}
+
+// upgradeLegacySnapshot mengkonversi schema.sql menjadi schema.json. Jika
+// schema.json sudah ada, schema.sql hanya dihapus.
+func upgradeLegacySnapshot(e *Executor) error {
+	if _, err := os.Stat(e.path(snapshotFile)); os.IsNotExist(err) {
+		snapshot, err := e.readLegacySnapshot()
+		if err != nil {
+			return err
+		}
+		if err := snapshot.SaveToFile(e.path(snapshotFile)); err != nil {
+			return fmt.Errorf("failed to save snapshot file: %w", err)
+		}
+	}
+	if err := os.Remove(e.path(legacySchemaFile)); err != nil {
+		return fmt.Errorf("failed to remove legacy schema file: %w", err)
+	}
+	return nil
+}
+
+// readLegacySnapshot mem-parse snapshot SQL schema.sql
+func (e *Executor) readLegacySnapshot() (*state.SchemaState, error) {
+	legacy, err := os.ReadFile(e.path(legacySchemaFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy schema file: %w", err)
+	}
+	snapshot, err := ParseSQL(SanitizeOutput(string(legacy), SanitizeOptions{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse legacy schema file: %w", err)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/akmalulginan/datara/internal/state"
+)
+
+// destructiveMarker menandai migration yang down-nya memang tidak bisa
+// mengembalikan semuanya, mis. DROP TABLE yang sudah berisi data
+const destructiveMarker = "-- datara:destructive"
+
+// Residue adalah sisa perubahan setelah up dan down sebuah migration dijalankan
+type Residue struct {
+	File        string
+	Differences []string
+	// Destructive bernilai true jika migration ditandai -- datara:destructive,
+	// sehingga residue hanya dilaporkan dan tidak menggagalkan pemeriksaan
+	Destructive bool
+}
+
+// VerifyDown menjalankan ulang semua migration berurutan. Untuk setiap file,
+// bagian up lalu down diterapkan ke state sebelum file tersebut dan hasilnya
+// dibandingkan dengan state awal; perbedaan yang tersisa dikembalikan per file.
+func VerifyDown(dir string, pattern *regexp.Regexp) ([]Residue, error) {
+	files, err := migrationFiles(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newReplay()
+	var residues []Residue
+	for _, name := range files {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		sql := string(content)
+
+		before := r.schema.Clone()
+		upStart, upEnd := upSection(sql)
+		r.applyAll(sql[upStart:upEnd], name)
+		afterUp := r.schema.Clone()
+
+		r.applyAll(sql[downSection(sql):], name)
+		if differences := state.Compare(before, r.schema); len(differences) > 0 {
+			residues = append(residues, Residue{
+				File:        name,
+				Differences: differences,
+				Destructive: strings.Contains(sql, destructiveMarker),
+			})
+		}
+		r.schema = afterUp
+	}
+	return residues, nil
+}
+
+// applyAll menerapkan semua statement di sql. Statement yang akan gagal
+// dilewati; konflik seperti itu dilaporkan oleh Doctor.
+func (r *replay) applyAll(sql, name string) {
+	for _, span := range splitStatementSpans(sql) {
+		r.apply(normalizeDefinition(span.Text), name)
+	}
+}
+
+// downSection mengembalikan awal bagian down sebuah migration, atau akhir file
+// jika migration tidak punya bagian down
+func downSection(sql string) int {
+	_, upEnd := upSection(sql)
+	if strings.HasPrefix(sql[upEnd:], migrateDownMarker) {
+		return upEnd + len(migrateDownMarker)
+	}
+	return len(sql)
+}
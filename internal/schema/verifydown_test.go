@@ -0,0 +1,31 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVerifyDown(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "20240101000000_users.sql",
+		"-- migrate:up\nCREATE TABLE users (id INT);\n\n-- migrate:down\nDROP TABLE users;\n")
+	// Down kosong tidak menghapus kolom yang ditambahkan up
+	writeTestFile(t, dir, "20240102000000_email.sql",
+		"-- migrate:up\nALTER TABLE users ADD COLUMN email VARCHAR(100);\n\n-- migrate:down\n")
+	writeTestFile(t, dir, "20240103000000_name.sql",
+		"-- migrate:up\nALTER TABLE users ADD COLUMN name VARCHAR(50);\n\n-- migrate:down\nALTER TABLE users DROP COLUMN name;\n")
+	writeTestFile(t, dir, "20240104000000_drop_email.sql",
+		"-- datara:destructive\n-- migrate:up\nALTER TABLE users DROP COLUMN email;\n\n-- migrate:down\n")
+
+	residues, err := VerifyDown(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Residue{
+		{File: "20240102000000_email.sql", Differences: []string{"users.email: unexpected column VARCHAR(100)"}},
+		{File: "20240104000000_drop_email.sql", Differences: []string{"users.email: missing column"}, Destructive: true},
+	}
+	if !reflect.DeepEqual(residues, want) {
+		t.Errorf("residues =\n%+v\nwant\n%+v", residues, want)
+	}
+}
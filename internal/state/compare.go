@@ -0,0 +1,164 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Compare mengembalikan perbedaan struktural got terhadap want dalam bentuk
+// yang mudah dibaca, satu baris per perbedaan, mis.
+// "users.email: type varchar(100), want varchar(255)". Urutan kolom tidak
+// dibandingkan; gunakan Position jika diperlukan.
+func Compare(want, got *SchemaState) []string {
+	var differences []string
+	for _, name := range keys(want.Tables, got.Tables) {
+		wantTable, inWant := want.Tables[name]
+		gotTable, inGot := got.Tables[name]
+		switch {
+		case !inGot:
+			differences = append(differences, fmt.Sprintf("table %s: missing", name))
+		case !inWant:
+			differences = append(differences, fmt.Sprintf("table %s: unexpected", name))
+		default:
+			differences = append(differences, compareTable(wantTable, gotTable)...)
+		}
+	}
+	return differences
+}
+
+// compareTable membandingkan kolom, index dan constraint satu tabel
+func compareTable(want, got Table) []string {
+	var differences []string
+	report := func(format string, args ...interface{}) {
+		differences = append(differences, fmt.Sprintf(format, args...))
+	}
+
+	for _, name := range keys(want.Columns, got.Columns) {
+		w, inWant := want.Columns[name]
+		g, inGot := got.Columns[name]
+		column := want.Name + "." + name
+		switch {
+		case !inGot:
+			report("%s: missing column", column)
+			continue
+		case !inWant:
+			report("%s: unexpected column %s", column, g.Type)
+			continue
+		}
+		if !strings.EqualFold(w.Type, g.Type) && !(IsBooleanType(w.Type) && IsBooleanType(g.Type)) {
+			report("%s: type %s, want %s", column, g.Type, w.Type)
+		}
+		if w.Nullable != g.Nullable {
+			report("%s: nullable %t, want %t", column, g.Nullable, w.Nullable)
+		}
+		if !w.DefaultValue.Equal(g.DefaultValue) && !boolDefaultsEqual(w, g) {
+			report("%s: default %s, want %s", column, describeDefault(g.DefaultValue), describeDefault(w.DefaultValue))
+		}
+		if !strings.EqualFold(w.OnUpdate, g.OnUpdate) {
+			report("%s: on update %q, want %q", column, g.OnUpdate, w.OnUpdate)
+		}
+		if w.AutoIncrement != g.AutoIncrement {
+			report("%s: auto increment %t, want %t", column, g.AutoIncrement, w.AutoIncrement)
+		}
+		if !strings.EqualFold(w.Charset, g.Charset) || !strings.EqualFold(w.Collation, g.Collation) {
+			report("%s: charset %q collate %q, want charset %q collate %q", column, g.Charset, g.Collation, w.Charset, w.Collation)
+		}
+	}
+
+	for _, name := range keys(want.Indexes, got.Indexes) {
+		w, inWant := want.Indexes[name]
+		g, inGot := got.Indexes[name]
+		switch {
+		case !inGot:
+			report("%s: missing index %s", want.Name, name)
+		case !inWant:
+			report("%s: unexpected index %s", want.Name, name)
+		case describeIndex(w) != describeIndex(g):
+			report("%s: index %s is %s, want %s", want.Name, name, describeIndex(g), describeIndex(w))
+		}
+	}
+
+	wantConstraints := constraintDefs(want.Constraints)
+	gotConstraints := constraintDefs(got.Constraints)
+	for _, name := range keys(wantConstraints, gotConstraints) {
+		w, inWant := wantConstraints[name]
+		g, inGot := gotConstraints[name]
+		switch {
+		case !inGot:
+			report("%s: missing constraint %s", want.Name, w)
+		case !inWant:
+			report("%s: unexpected constraint %s", want.Name, g)
+		case w != g:
+			report("%s: constraint %s is %s, want %s", want.Name, name, g, w)
+		}
+	}
+	return differences
+}
+
+// describeIndex merangkum index untuk pesan perbedaan, mis. UNIQUE (email)
+func describeIndex(idx Index) string {
+	columns := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		columns[i] = col
+		if length := idx.Lengths[col]; length > 0 {
+			columns[i] += fmt.Sprintf("(%d)", length)
+		}
+	}
+	description := fmt.Sprintf("(%s)", strings.Join(columns, ", "))
+	if idx.Unique {
+		description = "UNIQUE " + description
+	}
+	if len(idx.Include) > 0 {
+		description += fmt.Sprintf(" INCLUDE (%s)", strings.Join(idx.Include, ", "))
+	}
+	return description
+}
+
+// constraintDefs memetakan nama constraint ke definisinya tanpa quote identifier
+func constraintDefs(constraints []Constraint) map[string]string {
+	defs := make(map[string]string, len(constraints))
+	for _, c := range constraints {
+		def := strings.NewReplacer(`"`, "", "`", "").Replace(c.Def)
+		name := c.Name
+		if name == "" {
+			name = def
+		}
+		defs[name] = def
+	}
+	return defs
+}
+
+func describeDefault(d *DefaultValue) string {
+	if d == nil {
+		return "none"
+	}
+	return d.SQL()
+}
+
+// keys mengembalikan gabungan key dua map secara berurutan
+func keys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var names []string
+	for _, m := range []map[string]V{a, b} {
+		for name := range m {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// boolDefaultsEqual mengecek default kolom boolean yang ditulis berbeda,
+// mis. TRUE dan 1
+func boolDefaultsEqual(a, b Column) bool {
+	if !IsBooleanType(a.Type) || !IsBooleanType(b.Type) {
+		return false
+	}
+	x, okA := a.DefaultValue.Bool()
+	y, okB := b.DefaultValue.Bool()
+	return okA && okB && x == y
+}
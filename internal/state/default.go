@@ -0,0 +1,271 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultKind membedakan jenis nilai DEFAULT sebuah kolom
+type DefaultKind string
+
+const (
+	DefaultNull       DefaultKind = "null"
+	DefaultKeyword    DefaultKind = "keyword"
+	DefaultString     DefaultKind = "string"
+	DefaultNumber     DefaultKind = "number"
+	DefaultBool       DefaultKind = "bool"
+	DefaultExpression DefaultKind = "expression"
+)
+
+// defaultKeywords adalah nilai DEFAULT yang dirender tanpa quote
+var defaultKeywords = map[string]bool{
+	"CURRENT_TIMESTAMP": true,
+	"CURRENT_DATE":      true,
+	"CURRENT_TIME":      true,
+	"LOCALTIMESTAMP":    true,
+	"LOCALTIME":         true,
+	"NOW()":             true,
+}
+
+// DefaultValue merepresentasikan nilai DEFAULT kolom secara terstruktur.
+// Untuk DefaultString, Value menyimpan isi string tanpa quote. Untuk
+// DefaultBool, Value adalah "true" atau "false" dan dirender sesuai dialect.
+type DefaultValue struct {
+	Kind  DefaultKind `json:"kind"`
+	Value string      `json:"value,omitempty"`
+}
+
+// ParseDefault mengkonversi ekspresi DEFAULT dari SQL atau tag menjadi
+// DefaultValue. Ekspresi kosong (DEFAULT tanpa nilai, tag default= atau
+// snapshot lama yang menyimpan DEFAULT NULL sebagai "") dibaca sebagai NULL,
+// bukan string kosong; string kosong ditulis sebagai literal dua quote.
+func ParseDefault(expr string) *DefaultValue {
+	expr = strings.TrimSpace(expr)
+	upper := strings.ToUpper(expr)
+
+	switch {
+	case upper == "" || upper == "NULL" || strings.HasPrefix(upper, "NULL::"):
+		return &DefaultValue{Kind: DefaultNull}
+	case upper == "TRUE" || upper == "FALSE":
+		return &DefaultValue{Kind: DefaultBool, Value: strings.ToLower(upper)}
+	case defaultKeywords[upper] || isPrecisionKeyword(upper):
+		return &DefaultValue{Kind: DefaultKeyword, Value: upper}
+	case isNumber(expr):
+		return &DefaultValue{Kind: DefaultNumber, Value: expr}
+	}
+
+	// String literal, termasuk cast Postgres: 'active'::character varying
+	if literal, ok := unquoteLiteral(expr); ok {
+		return &DefaultValue{Kind: DefaultString, Value: literal}
+	}
+
+	// Literal dalam kurung, mis. DEFAULT ('{}') untuk kolom JSON MySQL 8,
+	// sama dengan literal itu sendiri
+	if inner, ok := UnwrapParens(expr); ok {
+		if d := ParseDefault(inner); d.Kind != DefaultExpression {
+			return d
+		}
+	}
+
+	return &DefaultValue{Kind: DefaultExpression, Value: expr}
+}
+
+// SplitOnUpdate memisahkan klausa ON UPDATE dari ekspresi DEFAULT, mis.
+// "CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" menjadi default
+// CURRENT_TIMESTAMP dan on update CURRENT_TIMESTAMP
+func SplitOnUpdate(expr string) (def, onUpdate string) {
+	at := strings.Index(strings.ToUpper(expr), " ON UPDATE ")
+	if at == -1 {
+		return expr, ""
+	}
+	return strings.TrimSpace(expr[:at]), NormalizeOnUpdate(expr[at+len(" ON UPDATE "):])
+}
+
+// NormalizeOnUpdate menyeragamkan ekspresi ON UPDATE: keyword seperti
+// current_timestamp ditulis huruf besar
+func NormalizeOnUpdate(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if d := ParseDefault(expr); d.Kind == DefaultKeyword {
+		return d.Value
+	}
+	return expr
+}
+
+// LiftOnUpdate memindahkan ON UPDATE yang tertulis di dalam default kolom
+// (tag default=... atau snapshot lama) ke Column.OnUpdate
+func (c *Column) LiftOnUpdate() {
+	if c.DefaultValue == nil || c.DefaultValue.Kind != DefaultExpression {
+		return
+	}
+	def, onUpdate := SplitOnUpdate(c.DefaultValue.Value)
+	if onUpdate == "" {
+		return
+	}
+	c.DefaultValue = ParseDefault(def)
+	if c.OnUpdate == "" {
+		c.OnUpdate = onUpdate
+	}
+}
+
+// SQL merender nilai DEFAULT sebagai fragment SQL
+func (d *DefaultValue) SQL() string {
+	switch d.Kind {
+	case DefaultNull:
+		return "NULL"
+	case DefaultString:
+		return fmt.Sprintf("'%s'", strings.ReplaceAll(d.Value, "'", "''"))
+	case DefaultBool:
+		return strings.ToUpper(d.Value)
+	default:
+		return d.Value
+	}
+}
+
+// Equal membandingkan dua DefaultValue secara struktural
+func (d *DefaultValue) Equal(other *DefaultValue) bool {
+	if d == nil || other == nil {
+		return d == other
+	}
+	if d.Kind != other.Kind {
+		return false
+	}
+	if d.Kind == DefaultNumber {
+		a, errA := strconv.ParseFloat(d.Value, 64)
+		b, errB := strconv.ParseFloat(other.Value, 64)
+		if errA == nil && errB == nil {
+			return a == b
+		}
+	}
+	if d.Kind == DefaultKeyword {
+		return strings.EqualFold(d.Value, other.Value)
+	}
+	return d.Value == other.Value
+}
+
+// Bool mengembalikan nilai boolean dari default untuk kolom boolean. Semua
+// bentuk yang umum dari tag, SQL dan JSON diterima: TRUE/FALSE, 'true'/'false',
+// 't'/'f', 1/0 dan '1'/'0'.
+func (d *DefaultValue) Bool() (bool, bool) {
+	if d == nil {
+		return false, false
+	}
+	switch d.Kind {
+	case DefaultBool, DefaultKeyword, DefaultString:
+	case DefaultNumber:
+		if f, err := strconv.ParseFloat(d.Value, 64); err == nil && (f == 0 || f == 1) {
+			return f == 1, true
+		}
+		return false, false
+	default:
+		return false, false
+	}
+	switch strings.ToLower(d.Value) {
+	case "true", "t", "1":
+		return true, true
+	case "false", "f", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// UnmarshalJSON menerima format terstruktur maupun string mentah dari snapshot lama
+func (d *DefaultValue) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch v := raw.(type) {
+	case string:
+		*d = *ParseDefault(v)
+		return nil
+	case float64, bool:
+		*d = *ParseDefault(fmt.Sprint(v))
+		return nil
+	}
+
+	type plain DefaultValue
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*d = DefaultValue(p)
+	return nil
+}
+
+// isNumber mengecek apakah ekspresi adalah literal numerik
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// unquoteLiteral mengambil isi string literal SQL, mengabaikan cast (::type)
+// UnwrapParens mengembalikan isi expr jika seluruh expr diapit satu pasang
+// kurung, mis. "('{}')" tetapi bukan "(a) + (b)"
+func UnwrapParens(expr string) (string, bool) {
+	if !strings.HasPrefix(expr, "(") || !strings.HasSuffix(expr, ")") {
+		return "", false
+	}
+	depth, inQuote := 0, false
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case expr[i] == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case expr[i] == '(':
+			depth++
+		case expr[i] == ')':
+			depth--
+			if depth == 0 && i != len(expr)-1 {
+				return "", false
+			}
+		}
+	}
+	return strings.TrimSpace(expr[1 : len(expr)-1]), depth == 0
+}
+
+func unquoteLiteral(expr string) (string, bool) {
+	if !strings.HasPrefix(expr, "'") {
+		return "", false
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(expr); i++ {
+		if expr[i] != '\'' {
+			b.WriteByte(expr[i])
+			continue
+		}
+		if i+1 < len(expr) && expr[i+1] == '\'' {
+			b.WriteByte('\'')
+			i++
+			continue
+		}
+		// Akhir literal; sisanya hanya boleh berupa cast
+		rest := expr[i+1:]
+		if rest == "" || strings.HasPrefix(rest, "::") {
+			return b.String(), true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// isPrecisionKeyword mengecek keyword waktu dengan fractional seconds,
+// mis. CURRENT_TIMESTAMP(6) atau NOW(3)
+func isPrecisionKeyword(upper string) bool {
+	open := strings.Index(upper, "(")
+	if open == -1 || !strings.HasSuffix(upper, ")") {
+		return false
+	}
+	name, digits := upper[:open], upper[open+1:len(upper)-1]
+	if !defaultKeywords[name] && name != "NOW" {
+		return false
+	}
+	_, err := strconv.Atoi(digits)
+	return err == nil
+}
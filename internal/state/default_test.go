@@ -0,0 +1,101 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDefaultRoundTrip(t *testing.T) {
+	tests := []struct {
+		expr  string
+		kind  DefaultKind
+		value string
+		sql   string
+	}{
+		{"NULL", DefaultNull, "", "NULL"},
+		{"null::character varying", DefaultNull, "", "NULL"},
+		{"0", DefaultNumber, "0", "0"},
+		{"'0'", DefaultString, "0", "'0'"},
+		{"CURRENT_TIMESTAMP", DefaultKeyword, "CURRENT_TIMESTAMP", "CURRENT_TIMESTAMP"},
+		{"current_timestamp", DefaultKeyword, "CURRENT_TIMESTAMP", "CURRENT_TIMESTAMP"},
+		{"'it''s quoted'", DefaultString, "it's quoted", "'it''s quoted'"},
+		{"'active'::character varying", DefaultString, "active", "'active'"},
+		{"''", DefaultString, "", "''"},
+		{"gen_random_uuid()", DefaultExpression, "gen_random_uuid()", "gen_random_uuid()"},
+		// DEFAULT tanpa ekspresi, mis. tag default= kosong, dibaca sebagai NULL
+		{"", DefaultNull, "", "NULL"},
+		{"  ", DefaultNull, "", "NULL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			d := ParseDefault(tt.expr)
+			if d.Kind != tt.kind || d.Value != tt.value {
+				t.Fatalf("ParseDefault(%q) = {%s %q}, want {%s %q}", tt.expr, d.Kind, d.Value, tt.kind, tt.value)
+			}
+			if got := d.SQL(); got != tt.sql {
+				t.Errorf("SQL() = %s, want %s", got, tt.sql)
+			}
+			if again := ParseDefault(d.SQL()); !again.Equal(d) {
+				t.Errorf("ParseDefault(%s) = %+v, want it to equal %+v", d.SQL(), again, d)
+			}
+
+			data, err := json.Marshal(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded DefaultValue
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !decoded.Equal(d) {
+				t.Errorf("JSON round trip %s = %+v, want %+v", data, decoded, d)
+			}
+		})
+	}
+}
+
+func TestDefaultValueEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"0", "'0'", false},
+		{"NULL", "'NULL'", false},
+		{"0", "0.0", true},
+		{"CURRENT_TIMESTAMP", "current_timestamp", true},
+		{"'a'", "'a'::text", true},
+		{"''", "NULL", false},
+		{"''", "", false},
+	}
+	for _, tt := range tests {
+		if got := ParseDefault(tt.a).Equal(ParseDefault(tt.b)); got != tt.want {
+			t.Errorf("DEFAULT %s equal to DEFAULT %s = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultValueLegacySnapshot(t *testing.T) {
+	tests := []struct {
+		json string
+		want DefaultValue
+	}{
+		{`"NULL"`, DefaultValue{Kind: DefaultNull}},
+		{`""`, DefaultValue{Kind: DefaultNull}},
+		{`"''"`, DefaultValue{Kind: DefaultString}},
+		{`"0"`, DefaultValue{Kind: DefaultNumber, Value: "0"}},
+		{`"'0'"`, DefaultValue{Kind: DefaultString, Value: "0"}},
+		{`"CURRENT_TIMESTAMP"`, DefaultValue{Kind: DefaultKeyword, Value: "CURRENT_TIMESTAMP"}},
+		{`1`, DefaultValue{Kind: DefaultNumber, Value: "1"}},
+		{`true`, DefaultValue{Kind: DefaultBool, Value: "true"}},
+		{`{"kind": "string", "value": "it's"}`, DefaultValue{Kind: DefaultString, Value: "it's"}},
+	}
+	for _, tt := range tests {
+		var got DefaultValue
+		if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+			t.Fatalf("%s: %v", tt.json, err)
+		}
+		if got != tt.want {
+			t.Errorf("snapshot default %s = %+v, want %+v", tt.json, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package state
+
+import "strings"
+
+// Opsi tabel MySQL yang dikenali di Table.Options, dalam urutan render
+const (
+	OptionEngine        = "ENGINE"
+	OptionCharset       = "CHARSET"
+	OptionCollate       = "COLLATE"
+	OptionRowFormat     = "ROW_FORMAT"
+	OptionAutoIncrement = "AUTO_INCREMENT"
+	OptionKeyBlockSize  = "KEY_BLOCK_SIZE"
+)
+
+// TableOptionKeys adalah key Table.Options yang dikenali, dalam urutan render
+var TableOptionKeys = []string{OptionEngine, OptionCharset, OptionCollate, OptionRowFormat, OptionAutoIncrement, OptionKeyBlockSize}
+
+// TableOptionKey mengembalikan bentuk kanonik key opsi tabel, mis.
+// "row_format" menjadi ROW_FORMAT dan "DEFAULT CHARACTER SET" menjadi
+// CHARSET. Key yang tidak dikenal dikembalikan dalam huruf besar.
+func TableOptionKey(key string) string {
+	key = strings.ToUpper(strings.Join(strings.Fields(strings.ReplaceAll(key, "_", " ")), " "))
+	key = strings.TrimPrefix(key, "DEFAULT ")
+	switch key {
+	case "CHARACTER SET", "CHARSET":
+		return OptionCharset
+	case "COLLATE", "COLLATION":
+		return OptionCollate
+	}
+	return strings.ReplaceAll(key, " ", "_")
+}
+
+// IsTableOptionKey mengecek apakah key (bentuk apa pun) adalah opsi yang dikenali
+func IsTableOptionKey(key string) bool {
+	key = TableOptionKey(key)
+	for _, known := range TableOptionKeys {
+		if key == known {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTableOptions mengembalikan salinan options dengan key kanonik dan
+// nilai tanpa spasi di tepi; nil jika kosong
+func NormalizeTableOptions(options map[string]string) map[string]string {
+	if len(options) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(options))
+	for key, value := range options {
+		result[TableOptionKey(key)] = strings.TrimSpace(value)
+	}
+	return result
+}
@@ -0,0 +1,41 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// RawDDL adalah SQL mentah milik sebuah tabel untuk objek yang tidak bisa
+// dimodelkan (mis. trigger atau storage clause). Up ditulis setelah CREATE
+// TABLE dan Down sebelum tabel di-drop. RawDDL tidak ikut perbandingan
+// kolom, index maupun constraint; perubahan dideteksi lewat Hash.
+type RawDDL struct {
+	Up   string `json:"up"`
+	Down string `json:"down,omitempty"`
+	// Hash adalah hash isi Up dan Down, disimpan di snapshot
+	Hash string `json:"hash,omitempty"`
+}
+
+// NewRawDDL membuat RawDDL beserta hash isinya
+func NewRawDDL(up, down string) *RawDDL {
+	r := &RawDDL{Up: up, Down: down}
+	r.Hash = r.contentHash()
+	return r
+}
+
+// contentHash menghitung hash dari Up dan Down
+func (r *RawDDL) contentHash() string {
+	h := sha256.New()
+	h.Write([]byte(r.Up))
+	h.Write([]byte{0})
+	h.Write([]byte(r.Down))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Equal membandingkan isi dua RawDDL; nil sama dengan nil
+func (r *RawDDL) Equal(other *RawDDL) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	return r.contentHash() == other.contentHash()
+}
@@ -0,0 +1,99 @@
+package state
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// spatialTypes memetakan tipe spatial MySQL ke nama subtype PostGIS-nya,
+// mis. POINT menjadi geometry(Point,4326)
+var spatialTypes = map[string]string{
+	"GEOMETRY":           "Geometry",
+	"POINT":              "Point",
+	"LINESTRING":         "LineString",
+	"POLYGON":            "Polygon",
+	"MULTIPOINT":         "MultiPoint",
+	"MULTILINESTRING":    "MultiLineString",
+	"MULTIPOLYGON":       "MultiPolygon",
+	"GEOMETRYCOLLECTION": "GeometryCollection",
+}
+
+// geographyType adalah tipe geography PostGIS. Kolom geography disimpan
+// sebagai GEOGRAPHY(<jenis>), mis. GEOGRAPHY(POINT).
+const geographyType = "GEOGRAPHY"
+
+// sridPattern menemukan klausa SRID MySQL di Column.Extra snapshot lama,
+// termasuk komentar versi yang membungkusnya, mis. /*!80003 SRID 4326 */
+var sridPattern = regexp.MustCompile(`(?i)(?:/\*!\d*\s*)?\bSRID\s+(\d+)\b(?:\s*\*/)?`)
+
+// SpatialType memecah tipe kolom spatial, baik MySQL (POINT) maupun PostGIS
+// (geometry(Point,4326), geography), menjadi jenis geometri (POINT), apakah
+// kolom geography, dan SRID di dalam tipe (0 jika tidak ada). ok false jika
+// tipe bukan tipe spatial.
+func SpatialType(sqlType string) (kind string, geography bool, srid int, ok bool) {
+	t := strings.ToUpper(strings.TrimSpace(sqlType))
+	base, args := t, ""
+	if open := strings.Index(t, "("); open != -1 && strings.HasSuffix(t, ")") {
+		base, args = strings.TrimSpace(t[:open]), t[open+1:len(t)-1]
+	}
+	if _, known := spatialTypes[base]; known && args == "" {
+		return base, false, 0, true
+	}
+	if base != "GEOMETRY" && base != geographyType {
+		return "", false, 0, false
+	}
+
+	kind = "GEOMETRY"
+	parts := strings.Split(args, ",")
+	if sub := strings.TrimSpace(parts[0]); sub != "" {
+		if _, known := spatialTypes[sub]; !known {
+			return "", false, 0, false
+		}
+		kind = sub
+	}
+	if len(parts) > 1 {
+		n, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || len(parts) > 2 {
+			return "", false, 0, false
+		}
+		srid = n
+	}
+	return kind, base == geographyType, srid, true
+}
+
+// IsSpatialType mengecek apakah tipe kolom adalah tipe spatial
+func IsSpatialType(sqlType string) bool {
+	_, _, _, ok := SpatialType(sqlType)
+	return ok
+}
+
+// SpatialTypeName mengembalikan nama subtype PostGIS untuk jenis geometri,
+// mis. "LineString" untuk LINESTRING
+func SpatialTypeName(kind string) string {
+	return spatialTypes[kind]
+}
+
+// NormalizeSpatial menyeragamkan kolom spatial dari dialect mana pun: tipe
+// disimpan sebagai jenis geometri (POINT, atau GEOGRAPHY(POINT) untuk
+// geography PostGIS) dan SRID-nya di Column.SRID, termasuk klausa SRID yang
+// disimpan di Extra oleh snapshot lama
+func (c *Column) NormalizeSpatial() {
+	if match := sridPattern.FindStringSubmatch(c.Extra); match != nil {
+		if c.SRID == 0 {
+			c.SRID, _ = strconv.Atoi(match[1])
+		}
+		c.Extra = strings.Join(strings.Fields(sridPattern.ReplaceAllString(c.Extra, "")), " ")
+	}
+	kind, geography, srid, ok := SpatialType(c.Type)
+	if !ok {
+		return
+	}
+	c.Type = kind
+	if geography {
+		c.Type = geographyType + "(" + kind + ")"
+	}
+	if c.SRID == 0 {
+		c.SRID = srid
+	}
+}
@@ -0,0 +1,29 @@
+package state
+
+import "testing"
+
+func TestNormalizeSpatialLegacyExtra(t *testing.T) {
+	tests := []struct {
+		name      string
+		column    Column
+		wantType  string
+		wantSRID  int
+		wantExtra string
+	}{
+		{"plain SRID", Column{Type: "POINT", Extra: "SRID 4326"}, "POINT", 4326, ""},
+		{"versioned comment", Column{Type: "point", Extra: "/*!80003 SRID 4326 */"}, "POINT", 4326, ""},
+		{"other attributes are kept", Column{Type: "POINT", Extra: "/*!80003 SRID 3857 */ INVISIBLE"}, "POINT", 3857, "INVISIBLE"},
+		{"modelled SRID wins", Column{Type: "POINT", SRID: 4326, Extra: "SRID 3857"}, "POINT", 4326, ""},
+		{"PostGIS type", Column{Type: "geometry(Point,4326)"}, "POINT", 4326, ""},
+		{"not spatial", Column{Type: "TEXT", Extra: "STORAGE EXTERNAL"}, "TEXT", 0, "STORAGE EXTERNAL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			col := tt.column
+			col.NormalizeSpatial()
+			if col.Type != tt.wantType || col.SRID != tt.wantSRID || col.Extra != tt.wantExtra {
+				t.Errorf("got type %q, SRID %d, extra %q; want %q, %d, %q", col.Type, col.SRID, col.Extra, tt.wantType, tt.wantSRID, tt.wantExtra)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // SchemaState menyimpan state dari schema database
@@ -16,25 +18,146 @@ type SchemaState struct {
 // Table merepresentasikan state dari sebuah tabel
 type Table struct {
 	Name        string            `json:"name"`
+	Position    int               `json:"position,omitempty"` // urutan deklarasi, dipakai untuk output deterministik
 	Columns     map[string]Column `json:"columns"`
 	Indexes     map[string]Index  `json:"indexes"`
 	Constraints []Constraint      `json:"constraints"`
+	// PrimaryKey dan ForeignKeys adalah constraint terstruktur dari Schema
+	// JSON schema program. Saat dokumen dibaca keduanya diubah menjadi
+	// Constraints, sehingga snapshot tidak pernah menyimpannya.
+	PrimaryKey  []string     `json:"primary_key,omitempty"`
+	ForeignKeys []ForeignKey `json:"foreign_keys,omitempty"`
+	// RawDDL adalah SQL mentah tabel dari raw_sql di datara.hcl atau Schema JSON
+	RawDDL *RawDDL `json:"raw_ddl,omitempty"`
+	// Options adalah opsi tabel MySQL per tabel (lihat TableOptionKeys) yang
+	// menimpa engine, charset dan collation dari config
+	Options map[string]string `json:"options,omitempty"`
 }
 
 // Column merepresentasikan state dari sebuah kolom
 type Column struct {
-	Name          string      `json:"name"`
-	Type          string      `json:"type"`
-	Nullable      bool        `json:"nullable"`
-	DefaultValue  interface{} `json:"default_value,omitempty"`
-	AutoIncrement bool        `json:"auto_increment,omitempty"`
+	Name          string        `json:"name"`
+	Position      int           `json:"position,omitempty"` // urutan kolom di CREATE TABLE
+	Type          string        `json:"type"`
+	Nullable      bool          `json:"nullable"`
+	DefaultValue  *DefaultValue `json:"default_value,omitempty"`
+	AutoIncrement bool          `json:"auto_increment,omitempty"`
+	// OnUpdate adalah ekspresi ON UPDATE kolom, mis. CURRENT_TIMESTAMP untuk
+	// updated_at. Postgres merendernya sebagai trigger BEFORE UPDATE.
+	OnUpdate string `json:"on_update,omitempty"`
+	// Identity adalah strategi auto increment Postgres (IdentitySerial,
+	// IdentityAlways, IdentityByDefault). Kosong berarti default dari config.
+	Identity string `json:"identity,omitempty"`
+	// Charset dan Collation menimpa charset/collation tabel untuk kolom string
+	Charset   string `json:"charset,omitempty"`
+	Collation string `json:"collation,omitempty"`
+	// Sensitive menandai kolom (mis. password) yang default dan komentarnya
+	// disembunyikan dari output yang dibaca manusia. SQL migration tidak terpengaruh.
+	Sensitive bool `json:"sensitive,omitempty"`
+	// Class adalah klasifikasi data kolom (ClassPublic, ClassInternal, ClassPII)
+	// dari tag class=...; dirender ke COMMENT kolom sebagai "class=pii; <comment>"
+	Class string `json:"class,omitempty"`
+	// Mask adalah cara menganonimkan nilai kolom (MaskEmail, MaskHash, MaskNull)
+	// dari tag mask=...; dipakai datara mask-sql, tidak memengaruhi migration
+	Mask string `json:"mask,omitempty"`
+	// SRID adalah spatial reference system kolom spatial dari tag srid=...,
+	// klausa SRID (MySQL) atau tipe geometry(Point,4326) (PostGIS); 0 berarti
+	// tidak ditentukan
+	SRID int `json:"srid,omitempty"`
+	// Extra adalah atribut kolom yang tidak dimodelkan datara, dibaca apa
+	// adanya dari DDL, mis. "STORAGE EXTERNAL" atau opsi
+	// identity "(START WITH 1 CACHE 10)" (Postgres). Perbedaan Extra tidak
+	// dianggap perubahan, tetapi ditulis ulang setiap kali kolom dirender.
+	Extra string `json:"extra,omitempty"`
+	// Deprecated menandai kolom yang akan dihapus (tag deprecated): kolom tetap
+	// ada, komentarnya diberi DEPRECATED, dan DROP-nya kelak tidak diperingatkan
+	Deprecated bool `json:"deprecated,omitempty"`
+	// Tags menyimpan opsi mentah dari struct tag (mis. "primary_key", "default=0").
+	// Key yang tidak dikenali (lihat TagKeys) tetap disimpan tetapi diabaikan
+	// saat generate SQL dan dilaporkan sebagai peringatan.
+	Tags map[string]string `json:"tags,omitempty"`
 }
 
+// Redacted menggantikan nilai kolom sensitif pada output yang dibaca manusia
+const Redacted = "[redacted]"
+
+// Klasifikasi data kolom untuk tag class=...
+const (
+	ClassPublic   = "public"
+	ClassInternal = "internal"
+	ClassPII      = "pii"
+)
+
+// DeprecatedComment ditambahkan ke COMMENT kolom bertag deprecated
+const DeprecatedComment = "DEPRECATED"
+
+// Cara menganonimkan kolom untuk tag mask=...
+const (
+	MaskEmail = "email"
+	MaskHash  = "hash"
+	MaskNull  = "null"
+)
+
+// classPrefix mengawali klasifikasi di COMMENT kolom
+const classPrefix = "class="
+
+// ClassComment menggabungkan klasifikasi dan komentar kolom menjadi
+// "class=pii; <comment>", atau "class=pii" jika komentar kosong
+func ClassComment(class, comment string) string {
+	switch {
+	case class == "":
+		return comment
+	case comment == "":
+		return classPrefix + class
+	}
+	return classPrefix + class + "; " + comment
+}
+
+// SplitClassComment adalah kebalikan ClassComment: memisahkan klasifikasi
+// dari COMMENT kolom yang dibaca dari SQL
+func SplitClassComment(comment string) (class, rest string) {
+	if !strings.HasPrefix(comment, classPrefix) {
+		return "", comment
+	}
+	class, rest, _ = strings.Cut(strings.TrimPrefix(comment, classPrefix), ";")
+	return strings.TrimSpace(class), strings.TrimSpace(rest)
+}
+
+// TypeBoolean adalah tipe internal kolom boolean. Setiap dialect merendernya
+// sendiri: TINYINT(1) di MySQL, BOOLEAN di Postgres, BIT di SQL Server.
+const TypeBoolean = "BOOLEAN"
+
+// IsBooleanType mengecek apakah tipe kolom menyimpan boolean, termasuk
+// TINYINT(1) dari SQL MySQL
+func IsBooleanType(sqlType string) bool {
+	switch strings.ToLower(strings.TrimSpace(sqlType)) {
+	case "bool", "boolean", "tinyint(1)":
+		return true
+	}
+	return false
+}
+
+// Strategi auto increment untuk Postgres. IdentityDistributed hanya untuk
+// CockroachDB: DEFAULT unique_rowid() atau gen_random_uuid() untuk kolom UUID.
+const (
+	IdentitySerial      = "serial"
+	IdentityAlways      = "always"
+	IdentityByDefault   = "by_default"
+	IdentityDistributed = "distributed"
+)
+
 // Index merepresentasikan state dari sebuah index
 type Index struct {
-	Name    string   `json:"name"`
-	Columns []string `json:"columns"`
-	Unique  bool     `json:"unique"`
+	Name    string         `json:"name"`
+	Columns []string       `json:"columns"`
+	Unique  bool           `json:"unique"`
+	Lengths map[string]int `json:"lengths,omitempty"` // prefix length per kolom, mis. col(191)
+	Include []string       `json:"include,omitempty"` // kolom non-key untuk covering index
+	Buckets int            `json:"buckets,omitempty"` // jumlah bucket hash-sharded index (CockroachDB)
+	Spatial bool           `json:"spatial,omitempty"` // SPATIAL index (MySQL) atau index GiST (Postgres)
+	// Description menjelaskan pola query yang dilayani index. Hanya disimpan
+	// di snapshot dan ditulis sebagai komentar SQL, tidak ikut perbandingan.
+	Description string `json:"description,omitempty"`
 }
 
 // Constraint merepresentasikan constraint pada tabel
@@ -42,12 +165,28 @@ type Constraint struct {
 	Name string `json:"name"`
 	Type string `json:"type"` // e.g., "PRIMARY KEY", "FOREIGN KEY", etc.
 	Def  string `json:"def"`  // SQL definition
+	// NotValid menandai FOREIGN KEY atau CHECK yang dibuat dengan NOT VALID
+	// (Postgres) dan belum di-VALIDATE. Tidak ikut perbandingan constraint.
+	NotValid bool `json:"not_valid,omitempty"`
+}
+
+// ForeignKey adalah foreign key terstruktur di Schema JSON
+type ForeignKey struct {
+	// Name kosong berarti fk_<tabel>_<kolom>
+	Name       string   `json:"name,omitempty"`
+	Columns    []string `json:"columns"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns"`
+	OnDelete   string   `json:"on_delete,omitempty"`
+	OnUpdate   string   `json:"on_update,omitempty"`
+	// NotValid membuat foreign key ditambahkan dengan NOT VALID tanpa VALIDATE
+	NotValid bool `json:"not_valid,omitempty"`
 }
 
 // NewSchemaState membuat instance baru dari SchemaState
 func NewSchemaState() *SchemaState {
 	return &SchemaState{
-		Version: "1.0",
+		Version: FormatVersion,
 		Tables:  make(map[string]Table),
 	}
 }
@@ -74,7 +213,8 @@ func (s *SchemaState) SaveToFile(path string) error {
 	return nil
 }
 
-// LoadFromFile membaca state dari file
+// LoadFromFile membaca state dari file. *FormatVersionError dikembalikan jika
+// file ditulis dengan format yang lebih baru.
 func LoadFromFile(path string) (*SchemaState, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -84,11 +224,27 @@ func LoadFromFile(path string) (*SchemaState, error) {
 		}
 		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
+	return Decode(path, data)
+}
 
+// Decode membaca state dari JSON; source (mis. path file) dipakai di pesan
+// error. *FormatVersionError dikembalikan jika formatnya lebih baru.
+func Decode(source string, data []byte) (*SchemaState, error) {
 	var state SchemaState
 	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
 	}
+	if err := CheckFormatVersion(source, state.Version); err != nil {
+		return nil, err
+	}
+	// Snapshot lama menyimpan ON UPDATE di dalam default kolom dan SRID di Extra
+	for _, table := range state.Tables {
+		for name, col := range table.Columns {
+			col.LiftOnUpdate()
+			col.NormalizeSpatial()
+			table.Columns[name] = col
+		}
+	}
 
 	return &state, nil
 }
@@ -108,3 +264,55 @@ func (s *SchemaState) GetTable(name string) (Table, bool) {
 func (s *SchemaState) RemoveTable(name string) {
 	delete(s.Tables, name)
 }
+
+// Clone mengembalikan salinan state yang bisa diubah tanpa memengaruhi aslinya
+func (s *SchemaState) Clone() *SchemaState {
+	clone := &SchemaState{Version: s.Version, Tables: make(map[string]Table, len(s.Tables))}
+	for name, table := range s.Tables {
+		clone.Tables[name] = table.Clone()
+	}
+	return clone
+}
+
+// ColumnNames mengembalikan nama kolom tabel berdasarkan Position lalu nama
+func (t Table) ColumnNames() []string {
+	names := make([]string, 0, len(t.Columns))
+	for name := range t.Columns {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := t.Columns[names[i]], t.Columns[names[j]]
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.Name < b.Name
+	})
+	return names
+}
+
+// Clone mengembalikan salinan tabel beserta kolom, index dan constraint-nya
+func (t Table) Clone() Table {
+	columns := make(map[string]Column, len(t.Columns))
+	for name, col := range t.Columns {
+		columns[name] = col
+	}
+	indexes := make(map[string]Index, len(t.Indexes))
+	for name, idx := range t.Indexes {
+		indexes[name] = idx
+	}
+	t.Columns = columns
+	t.Indexes = indexes
+	t.Constraints = append([]Constraint(nil), t.Constraints...)
+	if t.RawDDL != nil {
+		raw := *t.RawDDL
+		t.RawDDL = &raw
+	}
+	if t.Options != nil {
+		options := make(map[string]string, len(t.Options))
+		for key, value := range t.Options {
+			options[key] = value
+		}
+		t.Options = options
+	}
+	return t
+}
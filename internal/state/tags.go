@@ -0,0 +1,82 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TagKeys adalah key db tag yang dikenali datara. Key lain tetap disimpan di
+// Column.Tags tetapi tidak memengaruhi SQL, sehingga salah ketik seperti
+// "notnul" dilaporkan oleh UnknownTagKeys.
+var TagKeys = []string{
+	"autoincrement", "auto_increment", "backfill", "charset", "class", "collate", "collation",
+	"comment", "default", "deprecated", "diff", "flatten", "group", "identity", "include", "index", "index_comment", "length",
+	"mask", "notnull", "nullable", "on_update", "onupdate", "precision", "prefix", "primary_key", "seed", "sensitive",
+	"serial", "sharded", "size", "spatial", "srid", "type", "unique",
+}
+
+// TableOptionTagKeys adalah key db tag field penanda opsi tabel, mis.
+// _ struct{} `db:"table_options,engine=MyISAM,row_format=COMPRESSED"`
+var TableOptionTagKeys = []string{"table_options", "auto_increment", "charset", "collate", "engine", "key_block_size", "row_format"}
+
+// RelTagKeys adalah opsi key=value rel tag yang dikenali
+var RelTagKeys = []string{"ondelete", "onupdate"}
+
+// UnknownTagKeys mengembalikan pesan untuk setiap key tags yang tidak ada di
+// known, terurut, mis. `unknown key "defualt" (did you mean "default"?)`
+func UnknownTagKeys(tags map[string]string, known []string) []string {
+	valid := make(map[string]bool, len(known))
+	for _, key := range known {
+		valid[key] = true
+	}
+	var unknown []string
+	for key := range tags {
+		if !valid[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	messages := make([]string, 0, len(unknown))
+	for _, key := range unknown {
+		msg := fmt.Sprintf("unknown key %q", key)
+		if suggestion := closestKey(key, known); suggestion != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// closestKey mengembalikan key di known dengan edit distance terkecil, jika
+// jaraknya paling banyak 2
+func closestKey(key string, known []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		if d := editDistance(key, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance menghitung jarak Levenshtein antara a dan b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		row := make([]int, len(b)+1)
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			row[j] = min(prev[j]+1, row[j-1]+1, prev[j-1]+cost)
+		}
+		prev = row
+	}
+	return prev[len(b)]
+}
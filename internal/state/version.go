@@ -0,0 +1,45 @@
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatVersion adalah versi format snapshot schema.json (dan dokumen Schema
+// JSON dari schema program) yang ditulis binary ini. Major version dinaikkan
+// setiap kali format berubah dengan cara yang tidak bisa dibaca versi lama.
+const FormatVersion = "1.0"
+
+// FormatVersionError dikembalikan saat membaca state dengan format yang lebih
+// baru dari yang didukung binary ini
+type FormatVersionError struct {
+	Path    string
+	Version string
+}
+
+func (e *FormatVersionError) Error() string {
+	return fmt.Sprintf("%s uses format version %s, but this datara only supports up to %s; upgrade datara to read it",
+		e.Path, e.Version, FormatVersion)
+}
+
+// CheckFormatVersion mengembalikan *FormatVersionError jika version lebih
+// baru dari FormatVersion. Version kosong dianggap format saat ini.
+func CheckFormatVersion(path, version string) error {
+	if version == "" {
+		return nil
+	}
+	major, ok := majorVersion(version)
+	current, _ := majorVersion(FormatVersion)
+	if !ok || major > current {
+		return &FormatVersionError{Path: path, Version: version}
+	}
+	return nil
+}
+
+// majorVersion mengambil angka major dari versi "X.Y"
+func majorVersion(version string) (int, bool) {
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	return n, err == nil
+}
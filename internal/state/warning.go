@@ -0,0 +1,33 @@
+package state
+
+import "fmt"
+
+// Warning adalah masalah yang tidak menghentikan parse, plan maupun generate,
+// mis. index duplikat atau key tag yang salah ketik. Code stabil untuk
+// dipakai di luar datara; Table dan Column kosong jika tidak relevan.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Table   string `json:"table,omitempty"`
+	Column  string `json:"column,omitempty"`
+}
+
+// String merender warning sebagai "users.email: pesan [code]"
+func (w Warning) String() string {
+	location := w.Table
+	if w.Column != "" {
+		location += "." + w.Column
+	}
+	if location != "" {
+		return fmt.Sprintf("%s: %s [%s]", location, w.Message, w.Code)
+	}
+	return fmt.Sprintf("%s [%s]", w.Message, w.Code)
+}
+
+// Warnings adalah kumpulan warning yang dikembalikan bersama hasil API
+type Warnings []Warning
+
+// Add menambahkan warning dengan pesan fmt.Sprintf(format, args...)
+func (w *Warnings) Add(code, table, column, format string, args ...interface{}) {
+	*w = append(*w, Warning{Code: code, Message: fmt.Sprintf(format, args...), Table: table, Column: column})
+}